@@ -417,6 +417,35 @@ func TestGetAddress(t *testing.T) {
 	}
 }
 
+func TestGetFeelerAddress(t *testing.T) {
+	n := New("testgetfeeleraddress", lookupFunc)
+
+	// Get an address from an empty set (should error)
+	if rv := n.GetFeelerAddress(); rv != nil {
+		t.Errorf("GetFeelerAddress failed: got: %v want: %v\n", rv, nil)
+	}
+
+	// Add a new address and get it
+	err := n.addAddressByIP(someIP + ":8333")
+	if err != nil {
+		t.Fatalf("Adding address failed: %v", err)
+	}
+	ka := n.GetFeelerAddress()
+	if ka == nil {
+		t.Fatalf("Did not get an address where there is one in the new table")
+	}
+	if ka.NetAddress().IP.String() != someIP {
+		t.Errorf("Wrong IP: got %v, want %v", ka.NetAddress().IP.String(), someIP)
+	}
+
+	// Mark this as a good address which moves it to the tried table, so no
+	// feeler address should be returned.
+	n.Good(ka.NetAddress())
+	if rv := n.GetFeelerAddress(); rv != nil {
+		t.Errorf("GetFeelerAddress failed: got: %v want: %v\n", rv, nil)
+	}
+}
+
 func TestGetBestLocalAddress(t *testing.T) {
 	localAddrs := []wire.NetAddress{
 		{IP: net.ParseIP("192.168.0.100")},
@@ -155,6 +155,8 @@ func (b *BlockChain) maybeAcceptBlock(block *cdrutil.Block, flags BehaviorFlags)
 	newNode := newBlockNode(blockHeader, prevNode)
 	newNode.populateTicketInfo(stake.FindSpentTicketsInBlock(block.MsgBlock()))
 	newNode.status = statusDataStored
+	newNode.chainTxCount = prevNode.chainTxCount +
+		blockTxCount(block.MsgBlock())
 	b.index.AddNode(newNode)
 
 	// Insert the block into the database if it's not already there.  Even
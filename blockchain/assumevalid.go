@@ -0,0 +1,122 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+)
+
+// isAssumedValid returns whether or not the passed node is the assumed valid
+// block or one of its ancestors according to the header chain that leads to
+// it.  Since the hash of the assumed valid block commits to all of its
+// ancestors, their scripts do not need to be validated.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) isAssumedValid(node *blockNode) bool {
+	offset := node.height - b.assumeValidStartHeight
+	if offset < 0 || offset >= int64(len(b.assumeValidChain)) {
+		return false
+	}
+	return b.assumeValidChain[offset] == node.hash
+}
+
+// AssumeValidPending returns the hash of the assumed valid block and whether or
+// not the header chain that leads to it from the main chain still needs to be
+// provided via SetAssumeValidChain.  That is the case when the behavior is
+// enabled, the header chain has not been provided yet, and the block is not
+// already part of the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) AssumeValidPending() (chainhash.Hash, bool) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	hash := b.assumeValid
+	if hash == *zeroHash || len(b.assumeValidChain) > 0 {
+		return hash, false
+	}
+
+	var inMainChain bool
+	b.db.View(func(dbTx database.Tx) error {
+		inMainChain = dbMainChainHasBlock(dbTx, &hash)
+		return nil
+	})
+	return hash, !inMainChain
+}
+
+// AssumeValidOffBestChain returns whether or not the assumed valid block is
+// known to not be part of the best chain.  That is the case when the block is
+// in the block index as a side chain block, which means a chain with more work
+// that does not lead to it is known.  Peers failing to provide the headers that
+// lead to the block are not sufficient on their own since they might simply
+// not have it yet or be misbehaving.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) AssumeValidOffBestChain() bool {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	hash := b.assumeValid
+	if hash == *zeroHash || !b.index.HaveBlock(&hash) {
+		return false
+	}
+
+	var inMainChain bool
+	b.db.View(func(dbTx database.Tx) error {
+		inMainChain = dbMainChainHasBlock(dbTx, &hash)
+		return nil
+	})
+	return !inMainChain
+}
+
+// SetAssumeValidChain sets the header chain that leads from the main chain to
+// the assumed valid block.  The hashes must be the hashes of consecutive blocks
+// which each link to the previous one, starting with the block at the provided
+// height and ending with the assumed valid block.
+//
+// Script validation is skipped for the blocks in the header chain when they
+// are connected to the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) SetAssumeValidChain(startHeight int64, hashes []chainhash.Hash) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if b.assumeValid == *zeroHash {
+		return fmt.Errorf("no assumed valid block is set")
+	}
+	if len(hashes) == 0 || hashes[len(hashes)-1] != b.assumeValid {
+		return fmt.Errorf("header chain does not end with the assumed "+
+			"valid block %v", b.assumeValid)
+	}
+
+	b.assumeValidStartHeight = startHeight
+	b.assumeValidChain = append([]chainhash.Hash(nil), hashes...)
+	log.Infof("Skipping script validation for blocks %d to %d which are "+
+		"ancestors of assumed valid block %v", startHeight,
+		startHeight+int64(len(hashes))-1, b.assumeValid)
+	return nil
+}
+
+// DisableAssumeValid disables skipping script validation for the ancestors of
+// the assumed valid block.  It is used when the assumed valid block is found to
+// not be part of the best chain as reported by AssumeValidOffBestChain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) DisableAssumeValid() {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if b.assumeValid == *zeroHash {
+		return
+	}
+	log.Warnf("Assumed valid block %v is not in the best chain -- "+
+		"validating all scripts", b.assumeValid)
+	b.assumeValid = *zeroHash
+	b.assumeValidChain = nil
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/txscript"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// TestAssumeValid ensures script validation is only skipped for the blocks in
+// the header chain that leads to the assumed valid block and that all other
+// consensus rules are still enforced for them.
+func TestAssumeValid(t *testing.T) {
+	// generateBlocks generates and processes enough blocks in the provided
+	// chain to have mature coinbase outputs to spend, and then returns a
+	// block which spends more than its inputs, a block at the same height
+	// which spends an output with an invalid signature script, and a block
+	// that builds on the latter.
+	generateBlocks := func(chain *BlockChain) (*wire.MsgBlock, *wire.MsgBlock, *wire.MsgBlock) {
+		tg := newUtxoTestGenerator(t, chain)
+		tg.generateMatureBlocks()
+
+		g := tg.g
+		parentName := g.TipName()
+		outs := g.OldestCoinbaseOuts()
+		overspend := g.NextBlock("bos", &outs[0], nil,
+			func(b *wire.MsgBlock) {
+				b.Transactions[1].TxOut[0].Value += 1e8
+			})
+		g.SetTip(parentName)
+		badScript := g.NextBlock("bbs", &outs[0], nil,
+			func(b *wire.MsgBlock) {
+				b.Transactions[1].TxIn[0].SignatureScript = []byte{
+					txscript.OP_DATA_1, txscript.OP_FALSE,
+				}
+			})
+		assumed := g.NextBlock("bav", nil, nil)
+		return overspend, badScript, assumed
+	}
+
+	// Ensure the block with the invalid signature script is rejected by a
+	// chain without an assumed valid block.
+	chain, teardownFunc, err := chainSetup("assumevalidtest",
+		&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	_, badScript, _ := generateBlocks(chain)
+	_, _, err = chain.ProcessBlock(cdrutil.NewBlock(badScript), BFNone)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrScriptValidation {
+		t.Fatalf("ProcessBlock: unexpected error for block with invalid "+
+			"script: %v", err)
+	}
+	teardownFunc()
+
+	// Create a new chain instance for the assumed valid block.
+	chain, teardownFunc, err = chainSetup("assumevalidtest",
+		&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+	overspend, badScript, assumed := generateBlocks(chain)
+	startHeight := chain.BestSnapshot().Height + 1
+	overspendHash := overspend.BlockHash()
+	badScriptHash := badScript.BlockHash()
+	assumedHash := assumed.BlockHash()
+
+	// Ensure the header chain can't be set when the behavior is disabled.
+	err = chain.SetAssumeValidChain(startHeight,
+		[]chainhash.Hash{badScriptHash, assumedHash})
+	if err == nil {
+		t.Fatal("SetAssumeValidChain: did not fail when disabled")
+	}
+
+	// Ensure the block which spends more than its inputs is rejected even
+	// when it is assumed valid.
+	chain.assumeValid = overspendHash
+	err = chain.SetAssumeValidChain(startHeight,
+		[]chainhash.Hash{overspendHash})
+	if err != nil {
+		t.Fatalf("SetAssumeValidChain: unexpected error: %v", err)
+	}
+	_, _, err = chain.ProcessBlock(cdrutil.NewBlock(overspend), BFNone)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrSpendTooHigh {
+		t.Fatalf("ProcessBlock: unexpected error for overspending "+
+			"block: %v", err)
+	}
+
+	// Ensure the header chain must end with the assumed valid block.
+	chain.assumeValid = assumedHash
+	chain.assumeValidChain = nil
+	if _, pending := chain.AssumeValidPending(); !pending {
+		t.Fatal("AssumeValidPending: header chain is not pending")
+	}
+	if chain.AssumeValidOffBestChain() {
+		t.Fatal("AssumeValidOffBestChain: unknown assumed valid block " +
+			"is off the best chain")
+	}
+	err = chain.SetAssumeValidChain(startHeight,
+		[]chainhash.Hash{badScriptHash})
+	if err == nil {
+		t.Fatal("SetAssumeValidChain: did not fail for a header chain " +
+			"that does not end with the assumed valid block")
+	}
+
+	// Ensure the block with the invalid signature script is accepted when
+	// it leads to the assumed valid block and that the header chain is no
+	// longer needed once the assumed valid block is connected.
+	err = chain.SetAssumeValidChain(startHeight,
+		[]chainhash.Hash{badScriptHash, assumedHash})
+	if err != nil {
+		t.Fatalf("SetAssumeValidChain: unexpected error: %v", err)
+	}
+	if _, pending := chain.AssumeValidPending(); pending {
+		t.Fatal("AssumeValidPending: header chain is still pending")
+	}
+	for _, msgBlock := range []*wire.MsgBlock{badScript, assumed} {
+		_, _, err := chain.ProcessBlock(cdrutil.NewBlock(msgBlock), BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock: unexpected error for assumed valid "+
+				"block: %v", err)
+		}
+	}
+	if chain.BestSnapshot().Hash != assumedHash {
+		t.Fatal("assumed valid block is not the best block")
+	}
+	if chain.assumeValidChain != nil {
+		t.Fatal("header chain was not cleared after connecting the " +
+			"assumed valid block")
+	}
+	if _, pending := chain.AssumeValidPending(); pending {
+		t.Fatal("AssumeValidPending: assumed valid block in main chain " +
+			"is pending")
+	}
+	if chain.AssumeValidOffBestChain() {
+		t.Fatal("AssumeValidOffBestChain: assumed valid block in main " +
+			"chain is off the best chain")
+	}
+
+	// Ensure disabling the behavior clears the assumed valid block.
+	chain.DisableAssumeValid()
+	if chain.assumeValid != *zeroHash {
+		t.Fatal("DisableAssumeValid: assumed valid block was not cleared")
+	}
+}
@@ -5,5 +5,137 @@
 
 package blockchain
 
+import (
+	"math"
+	"testing"
+
+	"github.com/commanderu/cdrd/blockchain/stake"
+	"github.com/commanderu/cdrd/chaincfg/chainec"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+)
+
 // TODO Make benchmarking tests for various functions, such as sidechain
 // evaluation.
+
+// benchmarkBlockScripts benchmarks the validation of the scripts of a full
+// block of transactions which each spend an output with a signature of the
+// passed type with and without batched signature checks.
+func benchmarkBlockScripts(b *testing.B, sigType int, batchSigs bool) {
+	const numInputs = 2000
+	spends := make([]altSigSpend, numInputs)
+	for i := range spends {
+		spends[i].sigType = sigType
+	}
+	block, view, err := newAltSigBlock(spends)
+	if err != nil {
+		b.Fatalf("failed to create block: %v", err)
+	}
+
+	// Collect the inputs the same way as checkBlockScripts.
+	var txValItems []*txValidateItem
+	for _, tx := range block.Transactions() {
+		for txInIdx, txIn := range tx.MsgTx().TxIn {
+			if txIn.PreviousOutPoint.Index == math.MaxUint32 {
+				continue
+			}
+			txValItems = append(txValItems, &txValidateItem{
+				txInIndex: txInIdx,
+				txIn:      txIn,
+				tx:        tx,
+			})
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		validator := newTxValidator(view, 0, nil)
+		validator.batchSigs = batchSigs
+		if err := validator.Validate(txValItems); err != nil {
+			b.Fatalf("failed to validate block scripts: %v", err)
+		}
+	}
+}
+
+func BenchmarkBlockScriptsEd25519(b *testing.B) {
+	benchmarkBlockScripts(b, chainec.ECTypeEdwards, false)
+}
+
+func BenchmarkBlockScriptsEd25519Batch(b *testing.B) {
+	benchmarkBlockScripts(b, chainec.ECTypeEdwards, true)
+}
+
+func BenchmarkBlockScriptsSchnorr(b *testing.B) {
+	benchmarkBlockScripts(b, chainec.ECTypeSecSchnorr, false)
+}
+
+func BenchmarkBlockScriptsSchnorrBatch(b *testing.B) {
+	benchmarkBlockScripts(b, chainec.ECTypeSecSchnorr, true)
+}
+
+// benchUtxoEntry returns a utxo entry for a transaction with the provided
+// number of unspent pay-to-pubkey-hash outputs.
+func benchUtxoEntry(numOutputs int) *UtxoEntry {
+	entry := newUtxoEntry(1, 100000, 1, false, false, stake.TxTypeRegular)
+	for i := 0; i < numOutputs; i++ {
+		entry.sparseOutputs[uint32(i)] = &utxoOutput{
+			amount: int64(i+1) * 1e8,
+			pkScript: hexToBytes("76a914" +
+				"1018853670f9f3b0582c5b9ee8ce93764ac32b93" + "88ac"),
+		}
+	}
+	return entry
+}
+
+// benchmarkSerializeUtxoEntry benchmarks serializing a utxo entry with the
+// provided number of outputs in the legacy per-transaction format, which is
+// required every time any output of the transaction changes.
+func benchmarkSerializeUtxoEntry(b *testing.B, numOutputs int) {
+	entry := benchUtxoEntry(numOutputs)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := serializeUtxoEntry(entry); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkSerializeUtxoEntry2(b *testing.B) {
+	benchmarkSerializeUtxoEntry(b, 2)
+}
+
+func BenchmarkSerializeUtxoEntry100(b *testing.B) {
+	benchmarkSerializeUtxoEntry(b, 100)
+}
+
+// BenchmarkSerializeUtxoOutput benchmarks serializing a single output in the
+// per-outpoint format, which is all that is required when an output changes
+// regardless of the number of outputs in the transaction.
+func BenchmarkSerializeUtxoOutput(b *testing.B) {
+	entry := benchUtxoEntry(1)
+	output := entry.sparseOutputs[0]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = serializeUtxoOutput(entry, output)
+	}
+}
+
+// BenchmarkUtxoCacheFetchEntry benchmarks fetching an entry that is already
+// in the utxo cache.
+func BenchmarkUtxoCacheFetchEntry(b *testing.B) {
+	var txHash chainhash.Hash
+	cache := newUtxoCache(nil, DefaultUtxoCacheMaxSize,
+		DefaultUtxoCacheFlushInterval)
+	cache.setEntry(txHash, benchUtxoEntry(2))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := cache.fetchEntry(&txHash); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -93,6 +93,12 @@ type blockNode struct {
 	// this node.
 	workSum *big.Int
 
+	// chainTxCount is the total number of regular and stake transactions
+	// in all blocks of the chain up to and including this node.  It allows
+	// transaction rates over a range of blocks to be calculated without
+	// loading the blocks.
+	chainTxCount uint64
+
 	// inMainChain denotes whether the block node is currently on the
 	// the main chain or not.  This is used to help find the common
 	// ancestor when switching chains.
@@ -333,6 +339,8 @@ func (bi *blockIndex) loadBlockNode(dbTx database.Tx, hash *chainhash.Hash) (*bl
 	node.ticketsVoted = entry.ticketsVoted
 	node.ticketsRevoked = entry.ticketsRevoked
 	node.votes = entry.voteInfo
+	node.status = entry.status
+	node.chainTxCount = entry.chainTxCount
 	node.inMainChain = true
 
 	// Add the node to the chain.
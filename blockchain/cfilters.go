@@ -0,0 +1,304 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/commanderu/cdrd/blockchain/internal/dbnamespace"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+	"github.com/commanderu/cdrd/gcs"
+	"github.com/commanderu/cdrd/gcs/blockcf"
+)
+
+// -----------------------------------------------------------------------------
+// The committed filters of a headers-only chain are stored in a bucket keyed by
+// block hash.  Only the regular filters are stored, and only once they have been
+// verified along with the filters of all of the ancestors of their block, so
+// every entry is part of a filter header chain that is known to commit to the
+// stored filters all the way back to the genesis block.
+//
+// The serialized format of an entry is:
+//
+//   <filter header><filter>
+//
+//   Field           Type             Size
+//   filter header   chainhash.Hash   chainhash.HashSize
+//   filter          []byte           variable
+//
+// The filter header is the hash of the filter hash followed by the filter
+// header of the previous block.  The filter header of the genesis block is the
+// zero hash.
+//
+// The most recent main chain block whose filter has been verified is stored
+// under a separate key in the metadata bucket in the following format:
+//
+//   <block hash><block height>
+//
+//   Field          Type             Size
+//   block hash     chainhash.Hash   chainhash.HashSize
+//   block height   uint32           4
+// -----------------------------------------------------------------------------
+
+// cfilterEntry houses a verified committed filter and its filter header.
+type cfilterEntry struct {
+	header chainhash.Hash
+	filter []byte
+}
+
+// dbFetchCFilterEntry uses an existing database transaction to retrieve the
+// committed filter entry for the block with the provided hash.
+func dbFetchCFilterEntry(dbTx database.Tx, hash *chainhash.Hash) (*cfilterEntry, error) {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.CFilterBucketName)
+	serialized := bucket.Get(hash[:])
+	if serialized == nil {
+		return nil, fmt.Errorf("no verified committed filter for block %v",
+			hash)
+	}
+	if len(serialized) < chainhash.HashSize {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt committed filter entry "+
+				"for block %v", hash),
+		}
+	}
+
+	var entry cfilterEntry
+	copy(entry.header[:], serialized[:chainhash.HashSize])
+	entry.filter = make([]byte, len(serialized)-chainhash.HashSize)
+	copy(entry.filter, serialized[chainhash.HashSize:])
+	return &entry, nil
+}
+
+// dbPutCFilterEntry uses an existing database transaction to store the committed
+// filter entry for the block with the provided hash.
+func dbPutCFilterEntry(dbTx database.Tx, hash *chainhash.Hash, entry *cfilterEntry) error {
+	serialized := make([]byte, chainhash.HashSize+len(entry.filter))
+	copy(serialized, entry.header[:])
+	copy(serialized[chainhash.HashSize:], entry.filter)
+	bucket := dbTx.Metadata().Bucket(dbnamespace.CFilterBucketName)
+	return bucket.Put(hash[:], serialized)
+}
+
+// dbPutCFilterTip uses an existing database transaction to store the most recent
+// main chain block whose committed filter has been verified.
+func dbPutCFilterTip(dbTx database.Tx, hash *chainhash.Hash, height int64) error {
+	var serialized [chainhash.HashSize + 4]byte
+	copy(serialized[:], hash[:])
+	byteOrder.PutUint32(serialized[chainhash.HashSize:], uint32(height))
+	return dbTx.Metadata().Put(dbnamespace.CFilterTipKeyName, serialized[:])
+}
+
+// dbFetchCFilterTip uses an existing database transaction to retrieve the most
+// recent main chain block whose committed filter has been verified.  The
+// returned hash is nil when it has not been stored yet.
+func dbFetchCFilterTip(dbTx database.Tx) (*chainhash.Hash, int64, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.CFilterTipKeyName)
+	if serialized == nil {
+		return nil, 0, nil
+	}
+	if len(serialized) != chainhash.HashSize+4 {
+		return nil, 0, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt committed filter tip",
+		}
+	}
+
+	var hash chainhash.Hash
+	copy(hash[:], serialized[:chainhash.HashSize])
+	height := int64(byteOrder.Uint32(serialized[chainhash.HashSize:]))
+	return &hash, height, nil
+}
+
+// initCFilterState creates the bucket for the committed filters of a
+// headers-only chain along with the entry for the genesis block, which is built
+// locally, when they do not exist yet and loads the committed filter tip.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) initCFilterState() error {
+	genesisHash := b.chainParams.GenesisHash
+	var tipHash *chainhash.Hash
+	var tipHeight int64
+	var tipEntry *cfilterEntry
+	err := b.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		_, err := meta.CreateBucketIfNotExists(dbnamespace.CFilterBucketName)
+		if err != nil {
+			return err
+		}
+
+		tipHash, tipHeight, err = dbFetchCFilterTip(dbTx)
+		if err != nil {
+			return err
+		}
+		if tipHash == nil {
+			var entry cfilterEntry
+			f, err := blockcf.Regular(b.chainParams.GenesisBlock)
+			if err != nil && err != gcs.ErrNoData {
+				return err
+			}
+			if f != nil {
+				entry.filter = f.NBytes()
+			}
+			if err := dbPutCFilterEntry(dbTx, genesisHash, &entry); err != nil {
+				return err
+			}
+			if err := dbPutCFilterTip(dbTx, genesisHash, 0); err != nil {
+				return err
+			}
+			tipHash = genesisHash
+		}
+
+		tipEntry, err = dbFetchCFilterEntry(dbTx, tipHash)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// The committed filter tip is always part of the main chain since it is
+	// moved back to the fork point whenever it is detached.
+	tip, err := b.index.AncestorNode(b.bestNode, tipHeight)
+	if err != nil {
+		return err
+	}
+	if tip == nil || tip.hash != *tipHash {
+		return AssertError(fmt.Sprintf("committed filter tip %v (height "+
+			"%d) is not in the main chain", tipHash, tipHeight))
+	}
+	b.cfilterTip = tip
+	b.cfilterTipHeader = tipEntry.header
+
+	log.Infof("Committed filter state: height %d, hash %v", tip.height,
+		tip.hash)
+	return nil
+}
+
+// CFilterTip returns the hash and height of the most recent main chain block of
+// a headers-only chain whose committed filter has been verified along with the
+// filters of all of its ancestors.  The filters that follow it are expected by
+// the next call to ProcessCFilters.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CFilterTip() (chainhash.Hash, int64) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	if b.cfilterTip == nil {
+		return chainhash.Hash{}, 0
+	}
+	return b.cfilterTip.hash, b.cfilterTip.height
+}
+
+// ProcessCFilters verifies the passed regular committed filters for the main
+// chain blocks that directly follow the committed filter tip of a headers-only
+// chain against the passed filter headers and stores them.  The filter header
+// of each block must commit to its filter along with the filter header of the
+// previous block, starting from the filter header of the committed filter tip.
+// The committed filter tip is then updated to the final block.
+//
+// A rule error with the ErrBadCFilter code is returned and nothing is stored
+// when any of the filters is malformed or does not match its filter header.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ProcessCFilters(filterHeaders []chainhash.Hash, filters [][]byte) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if !b.headersOnly {
+		return AssertError("ProcessCFilters called on a chain that is not " +
+			"in headers-only mode")
+	}
+	if len(filterHeaders) != len(filters) {
+		return AssertError(fmt.Sprintf("ProcessCFilters called with %d "+
+			"filter headers and %d filters", len(filterHeaders),
+			len(filters)))
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+
+	// Load the main chain nodes for the blocks of the filters.
+	endHeight := b.cfilterTip.height + int64(len(filters))
+	if endHeight > b.bestNode.height {
+		return fmt.Errorf("committed filters through height %d extend "+
+			"past the best block height %d", endHeight,
+			b.bestNode.height)
+	}
+	nodes := make([]*blockNode, len(filters))
+	node, err := b.index.AncestorNode(b.bestNode, endHeight)
+	if err != nil {
+		return err
+	}
+	for i := len(nodes) - 1; i >= 0; i-- {
+		nodes[i] = node
+		node, err = b.index.PrevNodeFromNode(node)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Ensure each filter is committed to by its filter header.
+	prevHeader := b.cfilterTipHeader
+	for i, filter := range filters {
+		f, err := gcs.FromNBytes(blockcf.P, filter)
+		if err != nil {
+			str := fmt.Sprintf("committed filter for block %v is "+
+				"malformed: %v", nodes[i].hash, err)
+			return ruleError(ErrBadCFilter, str)
+		}
+		header := gcs.MakeHeaderForFilter(f, &prevHeader)
+		if header != filterHeaders[i] {
+			str := fmt.Sprintf("committed filter for block %v "+
+				"(height %d) does not match filter header %v",
+				nodes[i].hash, nodes[i].height, filterHeaders[i])
+			return ruleError(ErrBadCFilter, str)
+		}
+		prevHeader = header
+	}
+
+	// Store the verified filters and update the committed filter tip.
+	tip := nodes[len(nodes)-1]
+	err = b.db.Update(func(dbTx database.Tx) error {
+		for i, node := range nodes {
+			entry := cfilterEntry{header: filterHeaders[i],
+				filter: filters[i]}
+			if err := dbPutCFilterEntry(dbTx, &node.hash, &entry); err != nil {
+				return err
+			}
+		}
+		return dbPutCFilterTip(dbTx, &tip.hash, tip.height)
+	})
+	if err != nil {
+		return err
+	}
+	b.cfilterTip = tip
+	b.cfilterTipHeader = prevHeader
+
+	return nil
+}
+
+// CFilterByHash returns the verified regular committed filter and its filter
+// header for the block with the passed hash in a headers-only chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CFilterByHash(hash *chainhash.Hash) ([]byte, *chainhash.Hash, error) {
+	if !b.headersOnly {
+		return nil, nil, AssertError("CFilterByHash called on a chain " +
+			"that is not in headers-only mode")
+	}
+
+	var entry *cfilterEntry
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		entry, err = dbFetchCFilterEntry(dbTx, hash)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry.filter, &entry.header, nil
+}
@@ -107,11 +107,16 @@ type BlockChain struct {
 	notifications       NotificationCallback
 	sigCache            *txscript.SigCache
 	indexManager        IndexManager
+	headersOnly         bool
 
 	// subsidyCache is the cache that provides quick lookup of subsidy
 	// values.
 	subsidyCache *SubsidyCache
 
+	// utxoCache is the write-back cache of the utxo set that all utxo
+	// views are loaded from and committed to.
+	utxoCache *utxoCache
+
 	// chainLock protects concurrent access to the vast majority of the
 	// fields in this struct below this point.
 	chainLock sync.RWMutex
@@ -121,6 +126,24 @@ type BlockChain struct {
 	noVerify      bool
 	noCheckpoints bool
 
+	// These fields are related to skipping script validation for the
+	// ancestors of the assumed valid block.  The assumed valid hash is the
+	// zero hash when the behavior is disabled.  The header chain that leads
+	// to the assumed valid block is only known once it has been set by the
+	// caller, and houses the hashes of the blocks in it in order of height
+	// starting at the start height.  They are protected by the chain lock.
+	assumeValid            chainhash.Hash
+	assumeValidStartHeight int64
+	assumeValidChain       []chainhash.Hash
+
+	// These fields are related to the committed filters of a headers-only
+	// chain.  The committed filter tip is the most recent main chain block
+	// whose filter has been verified along with all of its ancestors, and
+	// the filter header is the one committed to by that block.  They are
+	// protected by the chain lock.
+	cfilterTip       *blockNode
+	cfilterTipHeader chainhash.Hash
+
 	// These fields are related to the memory block index.  They are
 	// protected by the chain lock.
 	bestNode *blockNode
@@ -719,7 +742,7 @@ func (b *BlockChain) getReorganizeNodes(node *blockNode) (*list.List, *list.List
 	}
 
 	// Don't allow a reorganize to a descendant of a known invalid block.
-	if b.index.NodeStatus(node.parent).KnownInvalid() {
+	if node.parent != nil && b.index.NodeStatus(node.parent).KnownInvalid() {
 		b.index.SetStatusFlags(node, statusInvalidAncestor)
 		return detachNodes, attachNodes, nil
 	}
@@ -788,8 +811,11 @@ func (b *BlockChain) pushMainChainBlockCache(block *cdrutil.Block) {
 // must happen prior to calling this function requires the same details, so
 // it would be inefficient to repeat it.
 //
+// The passed reorganization events, if any, are added to the chain event log
+// ahead of the events for the block in the same database transaction.
+//
 // This function MUST be called with the chain state lock held (for writes).
-func (b *BlockChain) connectBlock(node *blockNode, block, parent *cdrutil.Block, view *UtxoViewpoint, stxos []spentTxOut) error {
+func (b *BlockChain) connectBlock(node *blockNode, block, parent *cdrutil.Block, view *UtxoViewpoint, stxos []spentTxOut, reorgEvents []ChainEvent) error {
 	// Make sure it's extending the end of the best chain.
 	prevHash := block.MsgBlock().Header.PrevBlock
 	if prevHash != b.bestNode.hash {
@@ -836,6 +862,32 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *cdrutil.Block,
 		return err
 	}
 
+	// Determine the events to add to the chain event log for the block.
+	// The stake difficulty is also needed for the stake notifications about
+	// the block below.
+	var nextStakeDiff int64
+	events := append([]ChainEvent(nil), reorgEvents...)
+	if node.height >= b.chainParams.StakeEnabledHeight {
+		nextStakeDiff, err = b.calcNextRequiredStakeDifficulty(node)
+		if err != nil {
+			return err
+		}
+		events = append(events, ChainEvent{
+			Type:            ChainEventTickets,
+			Hash:            node.hash,
+			Height:          node.height,
+			StakeDifficulty: nextStakeDiff,
+			TicketsSpent:    stakeNode.SpentByBlock(),
+			TicketsMissed:   stakeNode.MissedByBlock(),
+			TicketsNew:      stakeNode.NewTickets(),
+		})
+	}
+	events = append(events, ChainEvent{
+		Type:   ChainEventBlockConnected,
+		Hash:   node.hash,
+		Height: node.height,
+	})
+
 	// Atomically insert info into the database.
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
@@ -860,14 +912,6 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *cdrutil.Block,
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
-		// entails removing all of the utxos spent and adding the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
-		if err != nil {
-			return err
-		}
-
 		// Update the transaction spend journal by adding a record for
 		// the block that contains all txos spent by it.
 		err = dbPutSpendJournalEntry(dbTx, block.Hash(), stxos)
@@ -881,6 +925,12 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *cdrutil.Block,
 			return err
 		}
 
+		// Add the events for the block to the chain event log.
+		err = dbAppendChainEvents(dbTx, events)
+		if err != nil {
+			return err
+		}
+
 		// Allow the index manager to call each of the currently active
 		// optional indexes with the block being connected so they can
 		// update themselves accordingly.
@@ -897,8 +947,16 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *cdrutil.Block,
 		return err
 	}
 
+	// Update the utxo cache using the state of the utxo view.  This entails
+	// removing all of the utxos spent and adding the new ones created by
+	// the block.  The cache writes them to the database when it is flushed.
+	_, err = b.utxoCache.commit(view, false)
+	if err != nil {
+		return err
+	}
+
 	// Prune fully spent entries and mark all entries in the view unmodified
-	// now that the modifications have been committed to the database.
+	// now that the modifications have been committed to the cache.
 	view.commit()
 
 	// Mark block as being in the main chain.
@@ -907,6 +965,13 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *cdrutil.Block,
 	// This node is now the end of the best chain.
 	b.bestNode = node
 
+	// Flush the utxo cache to the database when it has grown too large or
+	// it has not been flushed recently.
+	err = b.utxoCache.maybeFlush(&node.hash, node.height)
+	if err != nil {
+		return err
+	}
+
 	// Update the state for the best block.  Notice how this replaces the
 	// entire struct instead of updating the existing one.  This effectively
 	// allows the old version to act as a snapshot which callers can use
@@ -918,11 +983,6 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *cdrutil.Block,
 
 	// Send stake notifications about the new block.
 	if node.height >= b.chainParams.StakeEnabledHeight {
-		nextStakeDiff, err := b.calcNextRequiredStakeDifficulty(node)
-		if err != nil {
-			return err
-		}
-
 		// Notify of spent and missed tickets
 		b.sendNotification(NTSpentAndMissedTickets,
 			&TicketNotificationsData{
@@ -953,6 +1013,7 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *cdrutil.Block,
 	// updating wallets.
 	b.chainLock.Unlock()
 	b.sendNotification(NTBlockConnected, blockAndParent)
+	b.notifyChainEvents(events)
 	b.chainLock.Lock()
 
 	// Optimization: Before checkpoints, immediately dump the parent's stake
@@ -965,6 +1026,12 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *cdrutil.Block,
 		b.bestNode.parent.ticketsRevoked = nil
 	}
 
+	// The header chain that leads to the assumed valid block is no longer
+	// needed once the block itself is connected.
+	if node.hash == b.assumeValid {
+		b.assumeValidChain = nil
+	}
+
 	b.pushMainChainBlockCache(block)
 
 	return nil
@@ -981,8 +1048,11 @@ func (b *BlockChain) dropMainChainBlockCache(block *cdrutil.Block) {
 // disconnectBlock handles disconnecting the passed node/block from the end of
 // the main (best) chain.
 //
+// The passed reorganization events, if any, are added to the chain event log
+// ahead of the event for the block in the same database transaction.
+//
 // This function MUST be called with the chain state lock held (for writes).
-func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *cdrutil.Block, view *UtxoViewpoint) error {
+func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *cdrutil.Block, view *UtxoViewpoint, reorgEvents []ChainEvent) error {
 	// Make sure the node being disconnected is the end of the best chain.
 	if node.hash != b.bestNode.hash {
 		return AssertError("disconnectBlock must be called with the " +
@@ -1035,6 +1105,23 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *cdrutil.Blo
 		return err
 	}
 
+	events := append([]ChainEvent(nil), reorgEvents...)
+	events = append(events, ChainEvent{
+		Type:   ChainEventBlockDisconnected,
+		Hash:   node.hash,
+		Height: node.height,
+	})
+
+	// Update the utxo cache using the state of the utxo view.  This entails
+	// restoring all of the utxos spent and removing the new ones created by
+	// the block.  The commit is reverted when the database update below
+	// fails so the cache does not contain the state of a disconnect that
+	// never happened.
+	cacheUndo, err := b.utxoCache.commit(view, true)
+	if err != nil {
+		return err
+	}
+
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -1048,10 +1135,11 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *cdrutil.Blo
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
-		// entails restoring all of the utxos spent and removing the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
+		// Flush the utxo cache as of the new best block in the same
+		// transaction since the spend journal entry needed to undo the
+		// block is removed below.  This ensures the utxo set in the
+		// database always represents a block in the main chain.
+		err = b.utxoCache.dbFlush(dbTx, &prevNode.hash, prevNode.height)
 		if err != nil {
 			return err
 		}
@@ -1069,6 +1157,12 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *cdrutil.Blo
 			return err
 		}
 
+		// Add the disconnection of the block to the chain event log.
+		err = dbAppendChainEvents(dbTx, events)
+		if err != nil {
+			return err
+		}
+
 		// Allow the index manager to call each of the currently active
 		// optional indexes with the block being disconnected so they
 		// can update themselves accordingly.
@@ -1082,9 +1176,12 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *cdrutil.Blo
 		return nil
 	})
 	if err != nil {
+		b.utxoCache.rollback(cacheUndo)
 		return err
 	}
 
+	b.utxoCache.flushed()
+
 	// Prune fully spent entries and mark all entries in the view unmodified
 	// now that the modifications have been committed to the database.
 	view.commit()
@@ -1112,6 +1209,7 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *cdrutil.Blo
 	// updating wallets.
 	b.chainLock.Unlock()
 	b.sendNotification(NTBlockDisconnected, blockAndParent)
+	b.notifyChainEvents(events)
 	b.chainLock.Lock()
 
 	b.dropMainChainBlockCache(block)
@@ -1154,6 +1252,14 @@ func countNumberOfTransactions(block, parent *cdrutil.Block) uint64 {
 	return numTxns
 }
 
+// blockTxCount returns the total number of regular and stake transactions
+// included in the passed block.  Unlike countNumberOfTransactions, it does not
+// depend on whether or not the regular transactions of the parent block are
+// approved, so it only requires the block itself.
+func blockTxCount(block *wire.MsgBlock) uint64 {
+	return uint64(len(block.Transactions) + len(block.STransactions))
+}
+
 // reorganizeChain reorganizes the block chain by disconnecting the nodes in the
 // detachNodes list and connecting the nodes in the attach list.  It expects
 // that the lists are already in the correct order and are in sync with the
@@ -1271,6 +1377,17 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 		newBest = n
 	}
 
+	// The fork point becomes the new best block once all of the blocks are
+	// detached.  Note that it is the current best block when there are no
+	// nodes to detach.
+	if detachNodes.Len() > 0 {
+		var err error
+		newBest, err = b.index.PrevNodeFromNode(newBest)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Set the fork point and grab the fork block when there are nodes to be
 	// attached.  The fork block is used as the parent to the first node to be
 	// attached below.
@@ -1278,11 +1395,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 	var forkBlock *cdrutil.Block
 	if attachNodes.Len() > 0 {
 		var err error
-		forkNode, err = b.index.PrevNodeFromNode(newBest)
-		if err != nil {
-			return err
-		}
-
+		forkNode = newBest
 		forkBlock, err = b.fetchMainChainBlockByHash(&forkNode.hash)
 		if err != nil {
 			return err
@@ -1330,6 +1443,9 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 		// not needed.
 		err = b.checkConnectBlock(n, block, parent, view, nil)
 		if err != nil {
+			if _, ok := err.(RuleError); ok {
+				b.index.SetStatusFlags(n, statusValidateFailed)
+			}
 			return err
 		}
 
@@ -1338,6 +1454,18 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 	log.Debugf("New best chain validation completed successfully, " +
 		"commencing with the reorganization.")
 
+	// Record the reorganization in the chain event log ahead of the events
+	// for the blocks it disconnects and connects.  It is added in the same
+	// database transaction as the first block that is disconnected or
+	// connected so it is only recorded when the reorganization takes place.
+	reorgEvents := []ChainEvent{{
+		Type:      ChainEventReorganization,
+		Hash:      newBest.hash,
+		Height:    newBest.height,
+		OldHash:   oldBest.hash,
+		OldHeight: oldBest.height,
+	}}
+
 	// Send a notification that a blockchain reorganization is in progress.
 	reorgData := &ReorganizationNtfnsData{
 		oldBest.hash,
@@ -1378,7 +1506,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 
 		// Load all of the utxos referenced by the block that aren't
 		// already in the view.
-		err := view.fetchInputUtxos(b.db, block, parent)
+		err := view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return err
 		}
@@ -1392,10 +1520,11 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 		}
 
 		// Update the database and chain state.
-		err = b.disconnectBlock(n, block, parent, view)
+		err = b.disconnectBlock(n, block, parent, view, reorgEvents)
 		if err != nil {
 			return err
 		}
+		reorgEvents = nil
 	}
 
 	// Connect the new best chain blocks.
@@ -1427,10 +1556,11 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 		}
 
 		// Update the database and chain state.
-		err = b.connectBlock(n, block, parent, view, stxos)
+		err = b.connectBlock(n, block, parent, view, stxos, reorgEvents)
 		if err != nil {
 			return err
 		}
+		reorgEvents = nil
 	}
 
 	// Log the point where the chain forked and old and new best chain
@@ -1599,7 +1729,7 @@ func (b *BlockChain) connectBestChain(node *blockNode, block, parent *cdrutil.Bl
 		// utxos, spend them, and add the new utxos being created by
 		// this block.
 		if fastAdd {
-			err := view.fetchInputUtxos(b.db, block, parent)
+			err := view.fetchInputUtxos(b.utxoCache, block, parent)
 			if err != nil {
 				return false, err
 			}
@@ -1610,7 +1740,7 @@ func (b *BlockChain) connectBestChain(node *blockNode, block, parent *cdrutil.Bl
 		}
 
 		// Connect the block to the main chain.
-		err := b.connectBlock(node, block, parent, view, stxos)
+		err := b.connectBlock(node, block, parent, view, stxos, nil)
 		if err != nil {
 			return false, err
 		}
@@ -1881,6 +2011,38 @@ type Config struct {
 	// This field can be nil if the caller does not wish to make use of an
 	// index manager.
 	IndexManager IndexManager
+
+	// UtxoCacheMaxSize defines the maximum number of bytes the utxo cache
+	// may use before it is flushed to the database and entries are
+	// evicted.
+	//
+	// DefaultUtxoCacheMaxSize is used when this field is zero.
+	UtxoCacheMaxSize uint64
+
+	// UtxoCacheFlushInterval defines the maximum amount of time that may
+	// pass between flushes of the utxo cache to the database.  Modified
+	// utxos which have not been flushed are restored by replaying the
+	// blocks connected since the last flush on startup.
+	//
+	// DefaultUtxoCacheFlushInterval is used when this field is zero.
+	UtxoCacheFlushInterval time.Duration
+
+	// AssumeValid is the hash of a block whose ancestors are assumed to
+	// have valid scripts, so script validation is skipped for them once the
+	// header chain that leads to it is known.  All other consensus rules
+	// are still enforced.
+	//
+	// The behavior is disabled when this field is the zero hash.
+	AssumeValid chainhash.Hash
+
+	// HeadersOnly puts the chain in a light-verification mode in which only
+	// block headers are processed and stored via ProcessBlockHeader along
+	// with the committed filters verified via ProcessCFilters.  Full blocks
+	// are not accepted and there is no utxo set or ticket database beyond
+	// the genesis block, so the checks which rely on them are not performed.
+	//
+	// A database must always be used in the same mode.
+	HeadersOnly bool
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -1904,6 +2066,15 @@ func New(config *Config) (*BlockChain, error) {
 		}
 	}
 
+	utxoCacheMaxSize := config.UtxoCacheMaxSize
+	if utxoCacheMaxSize == 0 {
+		utxoCacheMaxSize = DefaultUtxoCacheMaxSize
+	}
+	utxoFlushInterval := config.UtxoCacheFlushInterval
+	if utxoFlushInterval == 0 {
+		utxoFlushInterval = DefaultUtxoCacheFlushInterval
+	}
+
 	b := BlockChain{
 		checkpointsByHeight:           checkpointsByHeight,
 		db:                            config.DB,
@@ -1912,7 +2083,10 @@ func New(config *Config) (*BlockChain, error) {
 		notifications:                 config.Notifications,
 		sigCache:                      config.SigCache,
 		indexManager:                  config.IndexManager,
+		assumeValid:                   config.AssumeValid,
+		headersOnly:                   config.HeadersOnly,
 		index:                         newBlockIndex(config.DB, params),
+		utxoCache:                     newUtxoCache(config.DB, utxoCacheMaxSize, utxoFlushInterval),
 		orphans:                       make(map[chainhash.Hash]*orphanBlock),
 		prevOrphans:                   make(map[chainhash.Hash][]*orphanBlock),
 		mainchainBlockCache:           make(map[chainhash.Hash]*cdrutil.Block),
@@ -1932,6 +2106,17 @@ func New(config *Config) (*BlockChain, error) {
 		return nil, err
 	}
 
+	// Load the committed filter state of a headers-only chain.  Otherwise,
+	// bring the utxo set up to date with the best chain when blocks were
+	// connected after it was last flushed.
+	if b.headersOnly {
+		if err := b.initCFilterState(); err != nil {
+			return nil, err
+		}
+	} else if err := b.initUtxoCache(config.Interrupt); err != nil {
+		return nil, err
+	}
+
 	// Initialize and catch up all of the currently active optional indexes
 	// as needed.
 	if config.IndexManager != nil {
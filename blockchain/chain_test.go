@@ -401,3 +401,91 @@ func TestForceHeadReorg(t *testing.T) {
 	forceTipReorg("b4", "b5")
 	expectTip("b5")
 }
+
+// TestChainTxStats ensures the transaction statistics calculated from the
+// cumulative transaction counts of the block nodes agree with the number of
+// transactions in the blocks of the window.
+func TestChainTxStats(t *testing.T) {
+	// Create a new database and chain instance to run tests against.
+	params := &chaincfg.SimNetParams
+	chain, teardownFunc, err := chainSetup("chaintxstatstest", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	tg := newUtxoTestGenerator(t, chain)
+	tg.generateMatureBlocks()
+	tg.generateSpendBlocks("bs", 5)
+
+	// Load the main chain blocks to count their transactions directly.
+	best := chain.BestSnapshot()
+	blocks := make([]*cdrutil.Block, best.Height+1)
+	for height := range blocks {
+		block, err := chain.BlockByHeight(int64(height))
+		if err != nil {
+			t.Fatalf("BlockByHeight: unexpected error: %v", err)
+		}
+		blocks[height] = block
+	}
+	countTxns := func(start, end int64) uint64 {
+		var numTxns uint64
+		for _, block := range blocks[start : end+1] {
+			numTxns += uint64(len(block.Transactions()) +
+				len(block.STransactions()))
+		}
+		return numTxns
+	}
+
+	tests := []struct {
+		name      string
+		height    int64
+		numBlocks int64
+	}{
+		{name: "genesis, empty window", height: 0, numBlocks: 0},
+		{name: "tip, empty window", height: best.Height, numBlocks: 0},
+		{name: "tip, one block", height: best.Height, numBlocks: 1},
+		{name: "tip, ten blocks", height: best.Height, numBlocks: 10},
+		{name: "tip, max window", height: best.Height,
+			numBlocks: best.Height - 1},
+		{name: "mid chain", height: best.Height / 2, numBlocks: 5},
+	}
+	for _, test := range tests {
+		final := blocks[test.height]
+		stats, err := chain.ChainTxStats(final.Hash(), test.numBlocks)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+
+		start := blocks[test.height-test.numBlocks]
+		finalTime := final.MsgBlock().Header.Timestamp.Unix()
+		want := ChainTxStats{
+			Time:             finalTime,
+			TxCount:          countTxns(0, test.height),
+			WindowFinalHash:  *final.Hash(),
+			WindowBlockCount: test.numBlocks,
+		}
+		if test.numBlocks > 0 {
+			want.WindowTxCount = countTxns(test.height-test.numBlocks+1,
+				test.height)
+			want.WindowInterval = finalTime -
+				start.MsgBlock().Header.Timestamp.Unix()
+		}
+		if *stats != want {
+			t.Errorf("%s: mismatched stats -- got %+v, want %+v",
+				test.name, *stats, want)
+		}
+	}
+
+	// Ensure windows that are not preceded by a block are rejected.
+	_, err = chain.ChainTxStats(&best.Hash, best.Height)
+	if err == nil {
+		t.Fatal("ChainTxStats: did not reject window covering the genesis " +
+			"block")
+	}
+	_, err = chain.ChainTxStats(&best.Hash, -1)
+	if err == nil {
+		t.Fatal("ChainTxStats: did not reject negative window")
+	}
+}
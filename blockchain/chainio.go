@@ -30,11 +30,11 @@ var (
 const (
 	// currentDatabaseVersion indicates what the current database
 	// version is.
-	currentDatabaseVersion = 3
+	currentDatabaseVersion = 6
 
 	// currentBlockIndexVersion indicates what the current block index
 	// database version.
-	currentBlockIndexVersion = 2
+	currentBlockIndexVersion = 3
 
 	// blockHdrSize is the size of a block header.  This is simply the
 	// constant from wire and is only provided here for convenience since
@@ -177,7 +177,8 @@ func ConvertUtxosToMinimalOutputs(entry *UtxoEntry) []*stake.MinimalOutput {
 
 // -----------------------------------------------------------------------------
 // The block index consists of an entry for every known block.  It consists of
-// information such as the block header and hashes of tickets voted and revoked.
+// information such as the block header, hashes of tickets voted and revoked,
+// and the cumulative number of transactions in the chain up to the block.
 //
 // The serialized key format is:
 //
@@ -190,6 +191,7 @@ func ConvertUtxosToMinimalOutputs(entry *UtxoEntry) []*stake.MinimalOutput {
 // The serialized value format is:
 //
 //   <block header><status><num votes><votes info><num revoked><revoked tickets>
+//   <chain tx count>
 //
 //   Field              Type                Size
 //   block header       wire.BlockHeader    180 bytes
@@ -202,6 +204,7 @@ func ConvertUtxosToMinimalOutputs(entry *UtxoEntry) []*stake.MinimalOutput {
 //   num revoked        VLQ                 variable
 //   revoked tickets
 //     ticket hash      chainhash.Hash      chainhash.HashSize
+//   chain tx count     VLQ                 variable
 // -----------------------------------------------------------------------------
 
 // blockIndexEntry represents a block index database entry.
@@ -211,6 +214,7 @@ type blockIndexEntry struct {
 	voteInfo       []stake.VoteVersionTuple
 	ticketsVoted   []chainhash.Hash
 	ticketsRevoked []chainhash.Hash
+	chainTxCount   uint64
 }
 
 // blockIndexKey generates the binary key for an entry in the block index
@@ -237,7 +241,8 @@ func blockIndexEntrySerializeSize(entry *blockIndexEntry) int {
 
 	return blockHdrSize + 1 + serializeSizeVLQ(uint64(len(entry.voteInfo))) +
 		voteInfoSize + serializeSizeVLQ(uint64(len(entry.ticketsRevoked))) +
-		chainhash.HashSize*len(entry.ticketsRevoked)
+		chainhash.HashSize*len(entry.ticketsRevoked) +
+		serializeSizeVLQ(entry.chainTxCount)
 }
 
 // putBlockIndexEntry serializes the passed block index entry according to the
@@ -276,6 +281,9 @@ func putBlockIndexEntry(target []byte, entry *blockIndexEntry) (int, error) {
 		offset += copy(target[offset:], entry.ticketsRevoked[i][:])
 	}
 
+	// Serialize the cumulative number of transactions in the chain.
+	offset += putVLQ(target[offset:], entry.chainTxCount)
+
 	return offset, nil
 }
 
@@ -287,10 +295,12 @@ func serializeBlockIndexEntry(entry *blockIndexEntry) ([]byte, error) {
 	return serialized, err
 }
 
-// decodeBlockIndexEntry decodes the passed serialized block index entry into
-// the passed struct according to the format described above.  It returns the
-// number of bytes read.
-func decodeBlockIndexEntry(serialized []byte, entry *blockIndexEntry) (int, error) {
+// decodeBlockIndexEntryV2 decodes the fields of the passed serialized block
+// index entry that are present in all block index versions since version 2
+// into the passed struct.  That is everything in the format described above
+// except the chain tx count, which is left unmodified.  It returns the number
+// of bytes read.
+func decodeBlockIndexEntryV2(serialized []byte, entry *blockIndexEntry) (int, error) {
 	// Ensure there are enough bytes to decode header.
 	if len(serialized) < blockHdrSize {
 		return 0, errDeserialize("unexpected end of data while " +
@@ -389,6 +399,27 @@ func decodeBlockIndexEntry(serialized []byte, entry *blockIndexEntry) (int, erro
 	return offset, nil
 }
 
+// decodeBlockIndexEntry decodes the passed serialized block index entry into
+// the passed struct according to the format described above.  It returns the
+// number of bytes read.
+func decodeBlockIndexEntry(serialized []byte, entry *blockIndexEntry) (int, error) {
+	offset, err := decodeBlockIndexEntryV2(serialized, entry)
+	if err != nil {
+		return offset, err
+	}
+
+	// Deserialize the cumulative number of transactions in the chain.
+	chainTxCount, bytesRead := deserializeVLQ(serialized[offset:])
+	if bytesRead == 0 {
+		return offset, errDeserialize("unexpected end of data while " +
+			"reading chain tx count")
+	}
+	offset += bytesRead
+
+	entry.chainTxCount = chainTxCount
+	return offset, nil
+}
+
 // deserializeBlockIndexEntry decodes the passed serialized byte slice into a
 // block index entry according to the format described above.
 func deserializeBlockIndexEntry(serialized []byte) (*blockIndexEntry, error) {
@@ -402,12 +433,21 @@ func deserializeBlockIndexEntry(serialized []byte) (*blockIndexEntry, error) {
 // dbPutBlockNode stores the information needed to reconstruct the provided
 // block node in the block index according to the format described above.
 func dbPutBlockNode(dbTx database.Tx, node *blockNode) error {
+	return dbPutBlockNodeStatus(dbTx, node, node.status)
+}
+
+// dbPutBlockNodeStatus stores the provided block node in the block index the
+// same way as dbPutBlockNode except the provided status is stored instead of
+// the status of the node.  This allows the status to be persisted before it is
+// changed in memory.
+func dbPutBlockNodeStatus(dbTx database.Tx, node *blockNode, status blockStatus) error {
 	serialized, err := serializeBlockIndexEntry(&blockIndexEntry{
 		header:         node.Header(),
-		status:         node.status,
+		status:         status,
 		voteInfo:       node.votes,
 		ticketsVoted:   node.ticketsVoted,
 		ticketsRevoked: node.ticketsRevoked,
+		chainTxCount:   node.chainTxCount,
 	})
 	if err != nil {
 		return err
@@ -842,10 +882,12 @@ func dbRemoveSpendJournalEntry(dbTx database.Tx, blockHash *chainhash.Hash) erro
 }
 
 // -----------------------------------------------------------------------------
-// The unspent transaction output (utxo) set consists of an entry for each
-// transaction which contains a utxo serialized using a format that is highly
-// optimized to reduce space using domain specific compression algorithms.  This
-// format is a slightly modified version of the format used in Bitcoin Core.
+// Prior to database version 4, the unspent transaction output (utxo) set
+// consisted of an entry for each transaction which contained a utxo serialized
+// using a format that is highly optimized to reduce space using domain specific
+// compression algorithms.  This format is a slightly modified version of the
+// format used in Bitcoin Core.  It is only used to migrate legacy databases
+// now.
 //
 // The serialized format is:
 //
@@ -1151,88 +1193,310 @@ func deserializeUtxoEntry(serialized []byte) (*UtxoEntry, error) {
 	return entry, nil
 }
 
-// dbFetchUtxoEntry uses an existing database transaction to fetch all unspent
-// outputs for the provided Bitcoin transaction hash from the utxo set.
+// -----------------------------------------------------------------------------
+// The unspent transaction output (utxo) set consists of an entry for each
+// unspent output keyed by its outpoint.  Keying the set by outpoint means
+// spending an output only requires removing its own entry as opposed to
+// rewriting the details of all of the remaining outputs of the transaction.
 //
-// When there is no entry for the provided hash, nil will be returned for the
-// both the entry and the error.
-func dbFetchUtxoEntry(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry, error) {
-	// Fetch the unspent transaction output information for the passed
-	// transaction hash.  Return now when there is no entry.
-	utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
-	serializedUtxo := utxoBucket.Get(hash[:])
-	if serializedUtxo == nil {
-		return nil, nil
+// The key for each entry is the hash of the transaction followed by the big
+// endian output index so all of the outputs of a transaction are stored
+// together in order, which allows them to be loaded with a single cursor seek.
+//
+// The serialized key format is:
+//
+//   <hash><output index>
+//
+//   Field                 Type             Size
+//   transaction hash      chainhash.Hash   chainhash.HashSize
+//   output index          uint32           4 bytes
+//
+// The serialized value format is:
+//
+//   <version><height><index><flags><compressed txout>[<stakeExtra>]
+//
+//   Field                 Type     Size
+//   transaction version   VLQ      variable
+//   block height          VLQ      variable
+//   block index           VLQ      variable
+//   flags                 VLQ      variable (currently 1 byte)
+//   compressed txout
+//     compressed amount   VLQ      variable
+//     compressed version  VLQ      variable
+//     compressed script   []byte   variable
+//   stakeExtra            []byte   variable
+//
+// The flags are encoded the same way as the legacy format described above and
+// the stake extra field is only present for the outputs of tickets.
+// -----------------------------------------------------------------------------
+
+// utxoSetKeySize is the size of the key of each entry in the utxo set.
+const utxoSetKeySize = chainhash.HashSize + 4
+
+// utxoSetKey returns the key of the entry in the utxo set for the provided
+// outpoint.
+func utxoSetKey(txHash *chainhash.Hash, outputIndex uint32) []byte {
+	key := make([]byte, utxoSetKeySize)
+	copy(key, txHash[:])
+	binary.BigEndian.PutUint32(key[chainhash.HashSize:], outputIndex)
+	return key
+}
+
+// serializeUtxoOutput returns the passed unspent output of the provided entry
+// serialized to a format that is suitable for long-term storage.  The format is
+// described in detail above.
+func serializeUtxoOutput(entry *UtxoEntry, output *utxoOutput) []byte {
+	// Calculate the size needed to serialize the output.
+	flags := encodeFlags(entry.isCoinBase, entry.hasExpiry, entry.txType, false)
+	size := serializeSizeVLQ(uint64(entry.txVersion)) +
+		serializeSizeVLQ(uint64(entry.height)) +
+		serializeSizeVLQ(uint64(entry.index)) +
+		serializeSizeVLQ(uint64(flags)) +
+		compressedTxOutSize(uint64(output.amount), output.scriptVersion,
+			output.pkScript, currentCompressionVersion, output.compressed,
+			true)
+	if entry.txType == stake.TxTypeSStx {
+		size += len(entry.stakeExtra)
 	}
 
-	// A non-nil zero-length entry means there is an entry in the database
-	// for a fully spent transaction which should never be the case.
-	if len(serializedUtxo) == 0 {
-		return nil, AssertError(fmt.Sprintf("database contains entry "+
-			"for fully spent tx %v", hash))
+	// Serialize the version, block height, block index, and flags of the
+	// containing transaction followed by the compressed output.  Outputs
+	// that are already compressed are serialized without modifications.
+	serialized := make([]byte, size)
+	offset := putVLQ(serialized, uint64(entry.txVersion))
+	offset += putVLQ(serialized[offset:], uint64(entry.height))
+	offset += putVLQ(serialized[offset:], uint64(entry.index))
+	offset += putVLQ(serialized[offset:], uint64(flags))
+	offset += putCompressedTxOut(serialized[offset:], uint64(output.amount),
+		output.scriptVersion, output.pkScript, currentCompressionVersion,
+		output.compressed, true)
+	if entry.txType == stake.TxTypeSStx {
+		copy(serialized[offset:], entry.stakeExtra)
+	}
+
+	return serialized
+}
+
+// deserializeUtxoOutput decodes an unspent output from the passed serialized
+// byte slice using a format that is suitable for long-term storage.  It returns
+// a new UtxoEntry without any outputs that houses the details of the containing
+// transaction along with the decoded output.  The format is described in detail
+// above.
+func deserializeUtxoOutput(serialized []byte) (*UtxoEntry, *utxoOutput, error) {
+	// Deserialize the version.
+	version, bytesRead := deserializeVLQ(serialized)
+	offset := bytesRead
+	if offset >= len(serialized) {
+		return nil, nil, errDeserialize("unexpected end of data after " +
+			"version")
+	}
+
+	// Deserialize the block height.
+	blockHeight, bytesRead := deserializeVLQ(serialized[offset:])
+	offset += bytesRead
+	if offset >= len(serialized) {
+		return nil, nil, errDeserialize("unexpected end of data after " +
+			"height")
+	}
+
+	// Deserialize the block index.
+	blockIndex, bytesRead := deserializeVLQ(serialized[offset:])
+	offset += bytesRead
+	if offset >= len(serialized) {
+		return nil, nil, errDeserialize("unexpected end of data after " +
+			"index")
+	}
+
+	// Deserialize the flags.
+	flags, bytesRead := deserializeVLQ(serialized[offset:])
+	offset += bytesRead
+	if offset >= len(serialized) {
+		return nil, nil, errDeserialize("unexpected end of data after " +
+			"flags")
 	}
+	isCoinBase, hasExpiry, txType, _ := decodeFlags(byte(flags))
 
-	// Deserialize the utxo entry and return it.
-	entry, err := deserializeUtxoEntry(serializedUtxo)
+	// Decode the output.  The script and amount fields of the output are
+	// left compressed so decompression can be avoided on those that are not
+	// accessed.
+	//
+	// 'true' below instructs the method to deserialize a stored amount.
+	amount, scriptVersion, compScript, bytesRead, err :=
+		decodeCompressedTxOut(serialized[offset:], currentCompressionVersion,
+			true)
 	if err != nil {
-		// Ensure any deserialization errors are returned as database
-		// corruption errors.
-		if isDeserializeErr(err) {
+		return nil, nil, errDeserialize(fmt.Sprintf("unable to decode "+
+			"utxo: %v", err))
+	}
+	offset += bytesRead
+
+	entry := newUtxoEntry(uint16(version), uint32(blockHeight),
+		uint32(blockIndex), isCoinBase, hasExpiry, txType)
+	output := &utxoOutput{
+		compressed:    true,
+		scriptVersion: scriptVersion,
+		pkScript:      compScript,
+		amount:        amount,
+	}
+
+	// Copy the stake extra data if this was a ticket.
+	if txType == stake.TxTypeSStx {
+		stakeExtra := make([]byte, len(serialized[offset:]))
+		copy(stakeExtra, serialized[offset:])
+		entry.stakeExtra = stakeExtra
+	}
+
+	return entry, output, nil
+}
+
+// dbFetchUtxoEntry uses an existing database transaction to fetch all unspent
+// outputs for the provided transaction hash from the utxo set.
+//
+// When there are no unspent outputs for the provided hash, nil will be returned
+// for the both the entry and the error.
+func dbFetchUtxoEntry(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry, error) {
+	utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
+	return dbFetchUtxoEntryWithCursor(utxoBucket.Cursor(), hash)
+}
+
+// dbFetchUtxoEntryWithCursor fetches all unspent outputs for the provided
+// transaction hash from the utxo set using the passed cursor over the utxo set
+// bucket.  This allows a single cursor to be reused when fetching the entries
+// for many transactions.
+//
+// When there are no unspent outputs for the provided hash, nil will be returned
+// for the both the entry and the error.
+func dbFetchUtxoEntryWithCursor(cursor database.Cursor, hash *chainhash.Hash) (*UtxoEntry, error) {
+	// Load all of the unspent outputs of the transaction which are stored
+	// consecutively since they share the transaction hash prefix.
+	var entry *UtxoEntry
+	for ok := cursor.Seek(hash[:]); ok; ok = cursor.Next() {
+		key := cursor.Key()
+		if !bytes.HasPrefix(key, hash[:]) {
+			break
+		}
+		if len(key) != utxoSetKeySize {
 			return nil, database.Error{
 				ErrorCode: database.ErrCorruption,
-				Description: fmt.Sprintf("corrupt utxo entry "+
-					"for %v: %v", hash, err),
+				Description: fmt.Sprintf("corrupt utxo key for %v: "+
+					"%x", hash, key),
 			}
 		}
 
-		return nil, err
+		// Deserialize the output and add it to the entry.
+		outputIndex := binary.BigEndian.Uint32(key[chainhash.HashSize:])
+		txEntry, output, err := deserializeUtxoOutput(cursor.Value())
+		if err != nil {
+			// Ensure any deserialization errors are returned as
+			// database corruption errors.
+			if isDeserializeErr(err) {
+				return nil, database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt utxo entry "+
+						"for %v:%d: %v", hash, outputIndex, err),
+				}
+			}
+
+			return nil, err
+		}
+		if entry == nil {
+			entry = txEntry
+		}
+		entry.sparseOutputs[outputIndex] = output
 	}
 
 	return entry, nil
 }
 
-// dbPutUtxoView uses an existing database transaction to update the utxo set
-// in the database based on the provided utxo view contents and state.  In
-// particular, only the entries that have been marked as modified are written
-// to the database.
-func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
+// dbPutUtxoOutput uses an existing database transaction to update the entry for
+// the provided outpoint in the utxo set based on the state of the passed entry.
+// The entry is removed from the utxo set when the output does not exist in the
+// entry or is spent.
+func dbPutUtxoOutput(dbTx database.Tx, txHash *chainhash.Hash, outputIndex uint32, entry *UtxoEntry) error {
 	utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
-	for txHashIter, entry := range view.entries {
-		// No need to update the database if the entry was not modified.
-		if entry == nil || !entry.modified {
-			continue
-		}
+	key := utxoSetKey(txHash, outputIndex)
+	if entry == nil {
+		return utxoBucket.Delete(key)
+	}
+	output, ok := entry.sparseOutputs[outputIndex]
+	if !ok || output.spent {
+		return utxoBucket.Delete(key)
+	}
 
-		// Serialize the utxo entry without any entries that have been
-		// spent.
-		serialized, err := serializeUtxoEntry(entry)
-		if err != nil {
-			return err
-		}
+	return utxoBucket.Put(key, serializeUtxoOutput(entry, output))
+}
 
-		// Make a copy of the hash because the iterator changes on each
-		// loop iteration and thus slicing it directly would cause the
-		// data to change out from under the put/delete funcs below.
-		txHash := txHashIter
+// -----------------------------------------------------------------------------
+// The utxo set state consists of the hash and height of the block the utxo set
+// in the database was last flushed at.  Since the utxo set is cached in memory
+// and only periodically flushed, it is typically behind the best chain state.
+// The difference is made up by replaying the blocks after it on startup.
+//
+// The serialized format is:
+//
+//   <block hash><block height>
+//
+//   Field             Type             Size
+//   block hash        chainhash.Hash   chainhash.HashSize
+//   block height      uint32           4 bytes
+// -----------------------------------------------------------------------------
 
-		// Remove the utxo entry if it is now fully spent.
-		if serialized == nil {
-			if err := utxoBucket.Delete(txHash[:]); err != nil {
-				return err
-			}
+// utxoSetState represents the data stored in the database for the block the
+// utxo set was last flushed at.
+type utxoSetState struct {
+	hash   chainhash.Hash
+	height uint32
+}
 
-			continue
-		}
+// serializeUtxoSetState returns the serialization of the passed utxo set state.
+func serializeUtxoSetState(state utxoSetState) []byte {
+	serialized := make([]byte, chainhash.HashSize+4)
+	copy(serialized, state.hash[:])
+	dbnamespace.ByteOrder.PutUint32(serialized[chainhash.HashSize:],
+		state.height)
+	return serialized
+}
 
-		// At this point the utxo entry is not fully spent, so store its
-		// serialization in the database.
-		err = utxoBucket.Put(txHash[:], serialized)
-		if err != nil {
-			return err
+// deserializeUtxoSetState deserializes the passed serialized utxo set state.
+func deserializeUtxoSetState(serialized []byte) (utxoSetState, error) {
+	if len(serialized) != chainhash.HashSize+4 {
+		return utxoSetState{}, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo set state size; "+
+				"want %v got %v", chainhash.HashSize+4,
+				len(serialized)),
 		}
 	}
 
-	return nil
+	var state utxoSetState
+	copy(state.hash[:], serialized[:chainhash.HashSize])
+	state.height = dbnamespace.ByteOrder.Uint32(
+		serialized[chainhash.HashSize:])
+	return state, nil
+}
+
+// dbPutUtxoSetState uses an existing database transaction to store the block
+// the utxo set was flushed at.
+func dbPutUtxoSetState(dbTx database.Tx, hash *chainhash.Hash, height int64) error {
+	serialized := serializeUtxoSetState(utxoSetState{
+		hash:   *hash,
+		height: uint32(height),
+	})
+	return dbTx.Metadata().Put(dbnamespace.UtxoSetStateKeyName, serialized)
+}
+
+// dbFetchUtxoSetState uses an existing database transaction to fetch the block
+// the utxo set was last flushed at.  It returns nil when there is no state.
+func dbFetchUtxoSetState(dbTx database.Tx) (*utxoSetState, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.UtxoSetStateKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	state, err := deserializeUtxoSetState(serialized)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
 }
 
 // -----------------------------------------------------------------------------
@@ -1567,6 +1831,7 @@ func (b *BlockChain) createChainState() error {
 	header := &genesisBlock.MsgBlock().Header
 	node := newBlockNode(header, nil)
 	node.status = statusDataStored | statusValid
+	node.chainTxCount = blockTxCount(genesisBlock.MsgBlock())
 	node.inMainChain = true
 
 	// Initialize the state related to the best block.  Since it is the
@@ -1633,6 +1898,23 @@ func (b *BlockChain) createChainState() error {
 			return err
 		}
 
+		// Create the buckets that house the chain event log and its
+		// block hash index.
+		_, err = meta.CreateBucket(dbnamespace.ChainEventLogBucketName)
+		if err != nil {
+			return err
+		}
+		_, err = meta.CreateBucket(dbnamespace.ChainEventHashIndexBucketName)
+		if err != nil {
+			return err
+		}
+
+		// The utxo set is up to date with the genesis block.
+		err = dbPutUtxoSetState(dbTx, &node.hash, node.height)
+		if err != nil {
+			return err
+		}
+
 		// Add the genesis block to the block index.
 		err = dbPutBlockNode(dbTx, node)
 		if err != nil {
@@ -1781,6 +2063,12 @@ func (b *BlockChain) initChainState(interrupt <-chan struct{}) error {
 			return err
 		}
 
+		// Only the genesis block is stored for a headers-only chain, so
+		// the best node is otherwise loaded from the block index.
+		if b.headersOnly && state.height > 0 {
+			return b.loadHeadersOnlyChainState(dbTx, &state)
+		}
+
 		// Load the best and parent blocks and cache them.
 		utilBlock, err := dbFetchBlockByHash(dbTx, &state.hash)
 		if err != nil {
@@ -1805,6 +2093,15 @@ func (b *BlockChain) initChainState(interrupt <-chan struct{}) error {
 		node.inMainChain = true
 		node.workSum = state.workSum
 
+		// The cumulative transaction count is only available from the
+		// block index entry for the block.
+		entry, err := dbFetchBlockIndexEntry(dbTx, &node.hash,
+			uint32(node.height))
+		if err != nil {
+			return err
+		}
+		node.chainTxCount = entry.chainTxCount
+
 		// Exception for version 1 blockchains: skip loading the stake
 		// node, as the upgrade path handles ensuring this is correctly
 		// set.
@@ -1841,9 +2138,23 @@ func (b *BlockChain) initChainState(interrupt <-chan struct{}) error {
 }
 
 // dbFetchHeaderByHash uses an existing database transaction to retrieve the
-// block header for the provided hash.
+// block header for the provided hash.  The header of a main chain block that is
+// not stored, such as in a headers-only chain, is loaded from the block index.
 func dbFetchHeaderByHash(dbTx database.Tx, hash *chainhash.Hash) (*wire.BlockHeader, error) {
 	headerBytes, err := dbTx.FetchBlockHeader(hash)
+	if dbErr, ok := err.(database.Error); ok && dbErr.ErrorCode ==
+		database.ErrBlockNotFound {
+
+		height, errHeight := dbFetchHeightByHash(dbTx, hash)
+		if errHeight != nil {
+			return nil, err
+		}
+		entry, errEntry := dbFetchBlockIndexEntry(dbTx, hash, uint32(height))
+		if errEntry != nil {
+			return nil, errEntry
+		}
+		return &entry.header, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -178,8 +178,9 @@ func TestBlockIndexSerialization(t *testing.T) {
 				voteInfo:       nil,
 				ticketsVoted:   nil,
 				ticketsRevoked: nil,
+				chainTxCount:   1,
 			},
-			serialized: hexToBytes("040000001f733757b25d804863dbcad599c931e15e3a3425e21a6716690100000000000066c664d887c6e2c0c132b3c5c8c82f9931470d6ecbc5ccc003755d7979bbf25edec2d752551f38a2bd4bb0d06ff9a1ed06f833a5aa8dc12bdc27759b056529020100313e16e64c0b0400030274a10000986f011aee686fbd010000000f4b02001f2c000037c4665904f85df58f01ed92645e0a6b11ee3b3c000000000000000000000000000000000000000004000000030000"),
+			serialized: hexToBytes("040000001f733757b25d804863dbcad599c931e15e3a3425e21a6716690100000000000066c664d887c6e2c0c132b3c5c8c82f9931470d6ecbc5ccc003755d7979bbf25edec2d752551f38a2bd4bb0d06ff9a1ed06f833a5aa8dc12bdc27759b056529020100313e16e64c0b0400030274a10000986f011aee686fbd010000000f4b02001f2c000037c4665904f85df58f01ed92645e0a6b11ee3b3c00000000000000000000000000000000000000000400000003000001"),
 		},
 		{
 			name: "1 vote, no revokes",
@@ -189,8 +190,9 @@ func TestBlockIndexSerialization(t *testing.T) {
 				voteInfo:       baseVoteInfo[:1],
 				ticketsVoted:   baseTicketsVoted[:1],
 				ticketsRevoked: nil,
+				chainTxCount:   127,
 			},
-			serialized: hexToBytes("040000001f733757b25d804863dbcad599c931e15e3a3425e21a6716690100000000000066c664d887c6e2c0c132b3c5c8c82f9931470d6ecbc5ccc003755d7979bbf25edec2d752551f38a2bd4bb0d06ff9a1ed06f833a5aa8dc12bdc27759b056529020100313e16e64c0b0400030274a10000986f011aee686fbd010000000f4b02001f2c000037c4665904f85df58f01ed92645e0a6b11ee3b3c00000000000000000000000000000000000000000400000003012e9b0f3f37c74ba8e8a9211d38e9706106ec482a1422a880ea53475477a8628b040100"),
+			serialized: hexToBytes("040000001f733757b25d804863dbcad599c931e15e3a3425e21a6716690100000000000066c664d887c6e2c0c132b3c5c8c82f9931470d6ecbc5ccc003755d7979bbf25edec2d752551f38a2bd4bb0d06ff9a1ed06f833a5aa8dc12bdc27759b056529020100313e16e64c0b0400030274a10000986f011aee686fbd010000000f4b02001f2c000037c4665904f85df58f01ed92645e0a6b11ee3b3c00000000000000000000000000000000000000000400000003012e9b0f3f37c74ba8e8a9211d38e9706106ec482a1422a880ea53475477a8628b0401007f"),
 		},
 		{
 			name: "no votes, 1 revoke",
@@ -200,8 +202,9 @@ func TestBlockIndexSerialization(t *testing.T) {
 				voteInfo:       nil,
 				ticketsVoted:   nil,
 				ticketsRevoked: baseTicketsRevoked[:1],
+				chainTxCount:   128,
 			},
-			serialized: hexToBytes("040000001f733757b25d804863dbcad599c931e15e3a3425e21a6716690100000000000066c664d887c6e2c0c132b3c5c8c82f9931470d6ecbc5ccc003755d7979bbf25edec2d752551f38a2bd4bb0d06ff9a1ed06f833a5aa8dc12bdc27759b056529020100313e16e64c0b0400030274a10000986f011aee686fbd010000000f4b02001f2c000037c4665904f85df58f01ed92645e0a6b11ee3b3c000000000000000000000000000000000000000004000000030001ebe895e6b1a9397b3ea756c4a5ff1d3bd678293d2980bc8e00a8fc8f1bf04681"),
+			serialized: hexToBytes("040000001f733757b25d804863dbcad599c931e15e3a3425e21a6716690100000000000066c664d887c6e2c0c132b3c5c8c82f9931470d6ecbc5ccc003755d7979bbf25edec2d752551f38a2bd4bb0d06ff9a1ed06f833a5aa8dc12bdc27759b056529020100313e16e64c0b0400030274a10000986f011aee686fbd010000000f4b02001f2c000037c4665904f85df58f01ed92645e0a6b11ee3b3c000000000000000000000000000000000000000004000000030001ebe895e6b1a9397b3ea756c4a5ff1d3bd678293d2980bc8e00a8fc8f1bf046818000"),
 		},
 		{
 			name: "4 votes, same vote versions, different vote bits, 2 revokes",
@@ -211,8 +214,9 @@ func TestBlockIndexSerialization(t *testing.T) {
 				voteInfo:       baseVoteInfo,
 				ticketsVoted:   baseTicketsVoted,
 				ticketsRevoked: baseTicketsRevoked,
+				chainTxCount:   12345,
 			},
-			serialized: hexToBytes("040000001f733757b25d804863dbcad599c931e15e3a3425e21a6716690100000000000066c664d887c6e2c0c132b3c5c8c82f9931470d6ecbc5ccc003755d7979bbf25edec2d752551f38a2bd4bb0d06ff9a1ed06f833a5aa8dc12bdc27759b056529020100313e16e64c0b0400030274a10000986f011aee686fbd010000000f4b02001f2c000037c4665904f85df58f01ed92645e0a6b11ee3b3c00000000000000000000000000000000000000000400000003042e9b0f3f37c74ba8e8a9211d38e9706106ec482a1422a880ea53475477a8628b0401b79d388a66910e033233543a33248a121eff9a2e07d9ff0414ebaca703a0274404150b11a2f3ae3484641b5fe9552dc91ccd575246df415db1b6d78148c78ab8154404157ab8e5b30822e7ec6ae514da760af332c8764bb069a0d30988085273b521269d040102ebe895e6b1a9397b3ea756c4a5ff1d3bd678293d2980bc8e00a8fc8f1bf046810cbe94fb96a1b776d67e32621f6deee6102aac1e05e2c68cc525e76124ff9222"),
+			serialized: hexToBytes("040000001f733757b25d804863dbcad599c931e15e3a3425e21a6716690100000000000066c664d887c6e2c0c132b3c5c8c82f9931470d6ecbc5ccc003755d7979bbf25edec2d752551f38a2bd4bb0d06ff9a1ed06f833a5aa8dc12bdc27759b056529020100313e16e64c0b0400030274a10000986f011aee686fbd010000000f4b02001f2c000037c4665904f85df58f01ed92645e0a6b11ee3b3c00000000000000000000000000000000000000000400000003042e9b0f3f37c74ba8e8a9211d38e9706106ec482a1422a880ea53475477a8628b0401b79d388a66910e033233543a33248a121eff9a2e07d9ff0414ebaca703a0274404150b11a2f3ae3484641b5fe9552dc91ccd575246df415db1b6d78148c78ab8154404157ab8e5b30822e7ec6ae514da760af332c8764bb069a0d30988085273b521269d040102ebe895e6b1a9397b3ea756c4a5ff1d3bd678293d2980bc8e00a8fc8f1bf046810cbe94fb96a1b776d67e32621f6deee6102aac1e05e2c68cc525e76124ff9222df39"),
 		},
 	}
 
@@ -379,6 +383,13 @@ func TestBlockIndexDecodeErrors(t *testing.T) {
 			errType:    errDeserialize(""),
 			bytesRead:  216,
 		},
+		{
+			name:       "no data after revokes",
+			entry:      blockIndexEntry{},
+			serialized: hexToBytes("040000001f733757b25d804863dbcad599c931e15e3a3425e21a6716690100000000000066c664d887c6e2c0c132b3c5c8c82f9931470d6ecbc5ccc003755d7979bbf25edec2d752551f38a2bd4bb0d06ff9a1ed06f833a5aa8dc12bdc27759b056529020100313e16e64c0b0400030274a10000986f011aee686fbd010000000f4b02001f2c000037c4665904f85df58f01ed92645e0a6b11ee3b3c000000000000000000000000000000000000000004000000030000"),
+			errType:    errDeserialize(""),
+			bytesRead:  183,
+		},
 		{
 			name:       "no data after num revokes with revokes",
 			entry:      blockIndexEntry{},
@@ -1393,6 +1404,226 @@ func TestUtxoEntryDeserializeErrors(t *testing.T) {
 	}
 }
 
+// TestUtxoOutputSerialization ensures serializing and deserializing the
+// individual outputs of unspent transaction output entries works as expected.
+func TestUtxoOutputSerialization(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		entry       *UtxoEntry
+		outputIndex uint32
+		serialized  []byte
+	}{
+		{
+			name: "regular tx output 2, not coinbase",
+			entry: &UtxoEntry{
+				txVersion: 1,
+				txType:    stake.TxTypeRegular,
+				height:    12345,
+				index:     1,
+				sparseOutputs: map[uint32]*utxoOutput{
+					2: {
+						amount:        15000000,
+						scriptVersion: 0,
+						pkScript:      hexToBytes("76a914b8025be1b3efc63b0ad48e7f9f10e87544528d5888ac"),
+					},
+				},
+			},
+			outputIndex: 2,
+			serialized:  hexToBytes("01df39010080090000b8025be1b3efc63b0ad48e7f9f10e87544528d58"),
+		},
+		{
+			name: "coinbase output 0 with expiry, compressed",
+			entry: &UtxoEntry{
+				txVersion:  1,
+				isCoinBase: true,
+				hasExpiry:  true,
+				txType:     stake.TxTypeRegular,
+				height:     33333,
+				index:      0,
+				sparseOutputs: map[uint32]*utxoOutput{
+					0: {
+						// Uncompressed PkScript: 76a914b8025be1b3efc63b0ad48e7f9f10e87544528d5888ac
+						amount:        15000000,
+						scriptVersion: 0,
+						pkScript:      hexToBytes("00b8025be1b3efc63b0ad48e7f9f10e87544528d58"),
+						compressed:    true,
+					},
+				},
+			},
+			outputIndex: 0,
+			serialized:  hexToBytes("01818335000380090000b8025be1b3efc63b0ad48e7f9f10e87544528d58"),
+		},
+		{
+			name: "ticket output 0 with stake extra data",
+			entry: &UtxoEntry{
+				txVersion:  1,
+				txType:     stake.TxTypeSStx,
+				height:     3221,
+				index:      4,
+				stakeExtra: hexToBytes("030f001aba76a9140cdf9941c0c221243cb8672cd1ad2c4c0933850588ac0000206a1e1a221182c26bbae681e4d96d452794e1951e70a208520000000000000054b5f466001abf76a914f5a8302ee8695bf836258b8f2b57b38a0be14e4788ac"),
+				sparseOutputs: map[uint32]*utxoOutput{
+					0: {
+						amount:        4294959555,
+						scriptVersion: 0,
+						pkScript:      hexToBytes("ba76a914a13afb81d54c9f8bb0c5e082d56fd563ab9b359688ac"),
+					},
+				},
+			},
+			outputIndex: 0,
+			serialized:  hexToBytes("0198150404808efefade57005aba76a914a13afb81d54c9f8bb0c5e082d56fd563ab9b359688ac030f001aba76a9140cdf9941c0c221243cb8672cd1ad2c4c0933850588ac0000206a1e1a221182c26bbae681e4d96d452794e1951e70a208520000000000000054b5f466001abf76a914f5a8302ee8695bf836258b8f2b57b38a0be14e4788ac"),
+		},
+	}
+
+	for i, test := range tests {
+		// Ensure the output serializes to the expected value.
+		output := test.entry.sparseOutputs[test.outputIndex]
+		gotBytes := serializeUtxoOutput(test.entry, output)
+		if !bytes.Equal(gotBytes, test.serialized) {
+			t.Errorf("serializeUtxoOutput #%d (%s): mismatched "+
+				"bytes - got %x, want %x", i, test.name,
+				gotBytes, test.serialized)
+			continue
+		}
+
+		// Deserialize the output and ensure the details of the
+		// containing transaction match.
+		entry, gotOutput, err := deserializeUtxoOutput(test.serialized)
+		if err != nil {
+			t.Errorf("deserializeUtxoOutput #%d (%s) unexpected "+
+				"error: %v", i, test.name, err)
+			continue
+		}
+		if entry.TxVersion() != test.entry.TxVersion() ||
+			entry.BlockHeight() != test.entry.BlockHeight() ||
+			entry.BlockIndex() != test.entry.BlockIndex() ||
+			entry.IsCoinBase() != test.entry.IsCoinBase() ||
+			entry.HasExpiry() != test.entry.HasExpiry() ||
+			entry.TransactionType() != test.entry.TransactionType() {
+
+			t.Errorf("deserializeUtxoOutput #%d (%s) mismatched "+
+				"entry: got %+v, want %+v", i, test.name, entry,
+				test.entry)
+			continue
+		}
+		if len(entry.sparseOutputs) != 0 {
+			t.Errorf("deserializeUtxoOutput #%d (%s) unexpected "+
+				"outputs in entry: %d", i, test.name,
+				len(entry.sparseOutputs))
+			continue
+		}
+		if !bytes.Equal(entry.stakeExtra, test.entry.stakeExtra) {
+			t.Errorf("deserializeUtxoOutput #%d (%s) mismatched "+
+				"stake extra: got %x, want %x", i, test.name,
+				entry.stakeExtra, test.entry.stakeExtra)
+			continue
+		}
+
+		// Ensure the amount and decompressed script of the output
+		// match.
+		entry.sparseOutputs[test.outputIndex] = gotOutput
+		if entry.AmountByIndex(test.outputIndex) != output.amount {
+			t.Errorf("deserializeUtxoOutput #%d (%s) mismatched "+
+				"amount: got %d, want %d", i, test.name,
+				entry.AmountByIndex(test.outputIndex),
+				output.amount)
+			continue
+		}
+		gotScript := entry.PkScriptByIndex(test.outputIndex)
+		wantScript := test.entry.PkScriptByIndex(test.outputIndex)
+		if !bytes.Equal(gotScript, wantScript) {
+			t.Errorf("deserializeUtxoOutput #%d (%s) mismatched "+
+				"script: got %x, want %x", i, test.name,
+				gotScript, wantScript)
+			continue
+		}
+	}
+}
+
+// TestUtxoOutputDeserializeErrors performs negative tests against
+// deserializing individual unspent transaction outputs to ensure error paths
+// work as expected.
+func TestUtxoOutputDeserializeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		serialized []byte
+	}{
+		{
+			name:       "no data after version",
+			serialized: hexToBytes("01"),
+		},
+		{
+			name:       "no data after block height",
+			serialized: hexToBytes("0101"),
+		},
+		{
+			name:       "no data after block index",
+			serialized: hexToBytes("010101"),
+		},
+		{
+			name:       "no data after flags",
+			serialized: hexToBytes("01010100"),
+		},
+		{
+			name:       "incomplete compressed txout",
+			serialized: hexToBytes("0101010012"),
+		},
+	}
+
+	for _, test := range tests {
+		// Ensure the expected error type is returned and the returned
+		// entry and output are nil.
+		entry, output, err := deserializeUtxoOutput(test.serialized)
+		if !isDeserializeErr(err) {
+			t.Errorf("deserializeUtxoOutput (%s): expected "+
+				"deserialize error - got %v", test.name, err)
+			continue
+		}
+		if entry != nil || output != nil {
+			t.Errorf("deserializeUtxoOutput (%s): returned entry "+
+				"or output is not nil", test.name)
+			continue
+		}
+	}
+}
+
+// TestUtxoSetStateSerialization ensures serializing and deserializing the utxo
+// set state works as expected.
+func TestUtxoSetStateSerialization(t *testing.T) {
+	t.Parallel()
+
+	state := utxoSetState{
+		hash:   *newHashFromStr("000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"),
+		height: 123456,
+	}
+	serialized := hexToBytes("6fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000" +
+		"40e20100")
+	if got := serializeUtxoSetState(state); !bytes.Equal(got, serialized) {
+		t.Fatalf("serializeUtxoSetState: mismatched bytes - got %x, "+
+			"want %x", got, serialized)
+	}
+	got, err := deserializeUtxoSetState(serialized)
+	if err != nil {
+		t.Fatalf("deserializeUtxoSetState: unexpected error: %v", err)
+	}
+	if got != state {
+		t.Fatalf("deserializeUtxoSetState: mismatched state - got %+v, "+
+			"want %+v", got, state)
+	}
+
+	// Ensure a truncated state is reported as database corruption.
+	_, err = deserializeUtxoSetState(serialized[:chainhash.HashSize])
+	if derr, ok := err.(database.Error); !ok ||
+		derr.ErrorCode != database.ErrCorruption {
+
+		t.Fatalf("deserializeUtxoSetState: expected corruption error "+
+			"- got %v", err)
+	}
+}
+
 // TestBestChainStateSerialization ensures serializing and deserializing the
 // best chain state works as expected.
 func TestBestChainStateSerialization(t *testing.T) {
@@ -6,9 +6,12 @@ package blockchain
 
 import (
 	"bytes"
+	"fmt"
 	"sort"
 
 	"github.com/commanderu/cdrd/cdrjson"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
 )
 
 // nodeHeightSorter implements sort.Interface to allow a slice of nodes to
@@ -103,3 +106,98 @@ func (b *BlockChain) ChainTips() []cdrjson.GetChainTipsResult {
 	}
 	return results
 }
+
+// ChainTxStats houses statistics about the number of transactions in the main
+// chain over a window of blocks.
+type ChainTxStats struct {
+	// Time is the timestamp of the final block in the window.
+	Time int64
+
+	// TxCount is the total number of transactions in the chain up to and
+	// including the final block in the window.
+	TxCount uint64
+
+	// WindowFinalHash is the hash of the final block in the window.
+	WindowFinalHash chainhash.Hash
+
+	// WindowBlockCount is the number of blocks in the window.
+	WindowBlockCount int64
+
+	// WindowTxCount is the number of transactions in the window.
+	WindowTxCount uint64
+
+	// WindowInterval is the elapsed time between the timestamps of the
+	// final block in the window and the block that precedes the window.
+	WindowInterval int64
+}
+
+// chainTxCountAndTime returns the cumulative number of transactions in the
+// chain and the timestamp of the block with the given hash and height.  The
+// block node is used when it is in memory, otherwise the values are loaded
+// from the block index entry of the block.
+func (b *BlockChain) chainTxCountAndTime(dbTx database.Tx, hash *chainhash.Hash, height int64) (uint64, int64, error) {
+	if node := b.index.LookupNode(hash); node != nil {
+		return node.chainTxCount, node.timestamp, nil
+	}
+
+	entry, err := dbFetchBlockIndexEntry(dbTx, hash, uint32(height))
+	if err != nil {
+		return 0, 0, err
+	}
+	return entry.chainTxCount, entry.header.Timestamp.Unix(), nil
+}
+
+// ChainTxStats returns statistics about the number of transactions in the
+// window of the given number of blocks which ends with the main chain block
+// with the given hash.  The number of blocks must be less than the height of
+// the final block since the window must be preceded by a block.
+//
+// The statistics are calculated from the cumulative transaction counts stored
+// for the final block and the block that precedes the window, so the cost does
+// not depend on the size of the window.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ChainTxStats(hash *chainhash.Hash, numBlocks int64) (*ChainTxStats, error) {
+	stats := &ChainTxStats{
+		WindowFinalHash:  *hash,
+		WindowBlockCount: numBlocks,
+	}
+	err := b.db.View(func(dbTx database.Tx) error {
+		height, err := dbFetchHeightByHash(dbTx, hash)
+		if err != nil {
+			return err
+		}
+		if numBlocks < 0 || (numBlocks > 0 && numBlocks >= height) {
+			return fmt.Errorf("invalid block count %d for window ending "+
+				"at height %d", numBlocks, height)
+		}
+		stats.TxCount, stats.Time, err = b.chainTxCountAndTime(dbTx, hash,
+			height)
+		if err != nil {
+			return err
+		}
+		if numBlocks == 0 {
+			return nil
+		}
+
+		// Determine the transaction count and timestamp of the block that
+		// precedes the window via the main chain height index.
+		startHeight := height - numBlocks
+		startHash, err := dbFetchHashByHeight(dbTx, startHeight)
+		if err != nil {
+			return err
+		}
+		startTxCount, startTime, err := b.chainTxCountAndTime(dbTx,
+			startHash, startHeight)
+		if err != nil {
+			return err
+		}
+		stats.WindowTxCount = stats.TxCount - startTxCount
+		stats.WindowInterval = stats.Time - startTime
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
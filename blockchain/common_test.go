@@ -59,6 +59,20 @@ func isSupportedDbType(dbType string) bool {
 // block already inserted.  In addition to the new chain instance, it returns
 // a teardown function the caller should invoke when done testing to clean up.
 func chainSetup(dbName string, params *chaincfg.Params) (*BlockChain, func(), error) {
+	return newTestChain(dbName, params, false)
+}
+
+// headersOnlyChainSetup is used to create a new db and headers-only chain
+// instance with the genesis block already inserted.  In addition to the new
+// chain instance, it returns a teardown function the caller should invoke when
+// done testing to clean up.
+func headersOnlyChainSetup(dbName string, params *chaincfg.Params) (*BlockChain, func(), error) {
+	return newTestChain(dbName, params, true)
+}
+
+// newTestChain creates a new db and chain instance in the provided mode for
+// chainSetup and headersOnlyChainSetup.
+func newTestChain(dbName string, params *chaincfg.Params, headersOnly bool) (*BlockChain, func(), error) {
 	if !isSupportedDbType(testDbType) {
 		return nil, nil, fmt.Errorf("unsupported db type %v", testDbType)
 	}
@@ -117,6 +131,7 @@ func chainSetup(dbName string, params *chaincfg.Params) (*BlockChain, func(), er
 		ChainParams: &paramsCopy,
 		TimeSource:  NewMedianTime(),
 		SigCache:    txscript.NewSigCache(1000),
+		HeadersOnly: headersOnly,
 	})
 
 	if err != nil {
@@ -975,6 +975,20 @@ func sdiffAlgoDeploymentVersion(network wire.CurrencyNet) uint32 {
 //
 // This function MUST be called with the chain state lock held (for writes).
 func (b *BlockChain) calcNextRequiredStakeDifficulty(curNode *blockNode) (int64, error) {
+	// The votes needed to determine whether the new algorithm is active are
+	// not available for a headers-only chain, so it is inferred from the
+	// headers instead.
+	if b.headersOnly {
+		sdiffV1, sdiffV2, v2Active, err := b.headersOnlyStakeDifficulties(curNode)
+		if err != nil {
+			return 0, err
+		}
+		if v2Active {
+			return sdiffV2, nil
+		}
+		return sdiffV1, nil
+	}
+
 	// Use the new stake difficulty algorithm if the stake vote for the new
 	// algorithm agenda is active.
 	//
@@ -457,6 +457,10 @@ const (
 	// block that is either not the current best chain tip or its parent.
 	ErrInvalidTemplateParent
 
+	// ErrBadCFilter indicates that a committed filter is malformed or does
+	// not match the filter header committed to for its block.
+	ErrBadCFilter
+
 	// numErrorCodes is the maximum error code number used in tests.
 	numErrorCodes
 )
@@ -560,6 +564,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrInvalidEarlyFinalState: "ErrInvalidEarlyFinalState",
 	ErrInvalidAncestorBlock:   "ErrInvalidAncestorBlock",
 	ErrInvalidTemplateParent:  "ErrInvalidTemplateParent",
+	ErrBadCFilter:             "ErrBadCFilter",
 }
 
 // String returns the ErrorCode as a human-readable name.
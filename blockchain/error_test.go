@@ -112,6 +112,7 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrInvalidEarlyFinalState, "ErrInvalidEarlyFinalState"},
 		{ErrInvalidAncestorBlock, "ErrInvalidAncestorBlock"},
 		{ErrInvalidTemplateParent, "ErrInvalidTemplateParent"},
+		{ErrBadCFilter, "ErrBadCFilter"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
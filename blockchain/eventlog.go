@@ -0,0 +1,430 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/commanderu/cdrd/blockchain/internal/dbnamespace"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+)
+
+const (
+	// chainEventLogSize is the maximum number of events retained in the
+	// chain event log.  The oldest event is removed each time a new one is
+	// added once the log is full.  There are typically two events per
+	// block, so this works out to roughly the last 50,000 blocks.
+	chainEventLogSize = 100000
+
+	// chainEventKeySize is the size of the keys of the chain event log,
+	// which are the big-endian sequence numbers of the events so they are
+	// iterated in order.
+	chainEventKeySize = 8
+)
+
+// ChainEventType identifies the type of an event in the chain event log.
+type ChainEventType uint8
+
+// Constants for the type of a chain event.
+const (
+	// ChainEventBlockConnected indicates the associated block was connected
+	// to the main chain.
+	ChainEventBlockConnected ChainEventType = iota + 1
+
+	// ChainEventBlockDisconnected indicates the associated block was
+	// disconnected from the main chain.
+	ChainEventBlockDisconnected
+
+	// ChainEventReorganization indicates a reorganization from the old best
+	// block to the associated block is about to take place.  It is followed
+	// by the events for each block that is disconnected and connected.
+	ChainEventReorganization
+
+	// ChainEventTickets indicates the tickets which were spent, missed, and
+	// matured by the associated block.  It precedes the connected event of
+	// the block.
+	ChainEventTickets
+)
+
+// chainEventTypeStrings is a map of chain event types back to their names for
+// pretty printing.
+var chainEventTypeStrings = map[ChainEventType]string{
+	ChainEventBlockConnected:    "blockconnected",
+	ChainEventBlockDisconnected: "blockdisconnected",
+	ChainEventReorganization:    "reorganization",
+	ChainEventTickets:           "tickets",
+}
+
+// String returns the ChainEventType in human-readable form.
+func (t ChainEventType) String() string {
+	if s, ok := chainEventTypeStrings[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown ChainEventType (%d)", uint8(t))
+}
+
+// ChainEvent is an event in the chain event log.  Sequence numbers start at 1
+// and increase by one for every event, so they may be used by consumers as a
+// cursor to resume from.
+//
+// The old hash and height are only set for reorganization events, in which
+// case the hash and height are those of the new best block.  The stake
+// difficulty and tickets are only set for ticket events.
+type ChainEvent struct {
+	Sequence uint64
+	Type     ChainEventType
+	Hash     chainhash.Hash
+	Height   int64
+
+	OldHash   chainhash.Hash
+	OldHeight int64
+
+	StakeDifficulty int64
+	TicketsSpent    []chainhash.Hash
+	TicketsMissed   []chainhash.Hash
+	TicketsNew      []chainhash.Hash
+}
+
+// -----------------------------------------------------------------------------
+// The chain event log consists of an entry for every event keyed by its
+// big-endian sequence number.
+//
+// The serialized value format is:
+//
+//   <event type><block hash><block height>[<type specific data>]
+//
+//   Field            Type              Size
+//   event type       uint8             1
+//   block hash       chainhash.Hash    chainhash.HashSize
+//   block height     uint32            4
+//
+// Reorganization events additionally include:
+//
+//   Field            Type              Size
+//   old hash         chainhash.Hash    chainhash.HashSize
+//   old height       uint32            4
+//
+// Ticket events additionally include:
+//
+//   Field            Type              Size
+//   stake difficulty int64             8
+//   num spent        VLQ               variable
+//   spent tickets    []chainhash.Hash  num spent * chainhash.HashSize
+//   num missed       VLQ               variable
+//   missed tickets   []chainhash.Hash  num missed * chainhash.HashSize
+//   num new          VLQ               variable
+//   new tickets      []chainhash.Hash  num new * chainhash.HashSize
+//
+// The block hash index of the chain event log consists of an entry for every
+// block with a connected event in the log keyed by the block hash.  The value
+// is the big-endian sequence number of the most recent connected event for the
+// block.
+// -----------------------------------------------------------------------------
+
+// chainEventKey returns the key of the chain event log entry with the provided
+// sequence number.
+func chainEventKey(seq uint64) []byte {
+	var key [chainEventKeySize]byte
+	binary.BigEndian.PutUint64(key[:], seq)
+	return key[:]
+}
+
+// serializeTicketHashes appends the provided ticket hashes to the target along
+// with their count and returns the result.
+func serializeTicketHashes(target []byte, hashes []chainhash.Hash) []byte {
+	count := make([]byte, serializeSizeVLQ(uint64(len(hashes))))
+	putVLQ(count, uint64(len(hashes)))
+	target = append(target, count...)
+	for i := range hashes {
+		target = append(target, hashes[i][:]...)
+	}
+	return target
+}
+
+// serializeChainEvent returns the serialized chain event log entry for the
+// provided event.
+func serializeChainEvent(event *ChainEvent) []byte {
+	serialized := make([]byte, 1+chainhash.HashSize+4)
+	serialized[0] = byte(event.Type)
+	copy(serialized[1:], event.Hash[:])
+	byteOrder.PutUint32(serialized[1+chainhash.HashSize:],
+		uint32(event.Height))
+
+	switch event.Type {
+	case ChainEventReorganization:
+		var oldHeight [4]byte
+		byteOrder.PutUint32(oldHeight[:], uint32(event.OldHeight))
+		serialized = append(serialized, event.OldHash[:]...)
+		serialized = append(serialized, oldHeight[:]...)
+
+	case ChainEventTickets:
+		var stakeDiff [8]byte
+		byteOrder.PutUint64(stakeDiff[:], uint64(event.StakeDifficulty))
+		serialized = append(serialized, stakeDiff[:]...)
+		serialized = serializeTicketHashes(serialized, event.TicketsSpent)
+		serialized = serializeTicketHashes(serialized, event.TicketsMissed)
+		serialized = serializeTicketHashes(serialized, event.TicketsNew)
+	}
+
+	return serialized
+}
+
+// deserializeTicketHashes decodes ticket hashes along with their count from
+// the provided serialized bytes and returns them along with the number of bytes
+// read.
+func deserializeTicketHashes(serialized []byte) ([]chainhash.Hash, int, error) {
+	count, offset := deserializeVLQ(serialized)
+	if offset == 0 || count > uint64(len(serialized)-offset)/chainhash.HashSize {
+		return nil, offset, errDeserialize("unexpected end of data " +
+			"while reading ticket hashes")
+	}
+	hashes := make([]chainhash.Hash, count)
+	for i := range hashes {
+		copy(hashes[i][:], serialized[offset:])
+		offset += chainhash.HashSize
+	}
+	return hashes, offset, nil
+}
+
+// deserializeChainEvent decodes a chain event log entry with the provided
+// sequence number from the passed serialized bytes.
+func deserializeChainEvent(seq uint64, serialized []byte) (*ChainEvent, error) {
+	const minSize = 1 + chainhash.HashSize + 4
+	if len(serialized) < minSize {
+		return nil, errDeserialize("unexpected end of data while " +
+			"reading chain event")
+	}
+
+	event := &ChainEvent{
+		Sequence: seq,
+		Type:     ChainEventType(serialized[0]),
+		Height:   int64(byteOrder.Uint32(serialized[1+chainhash.HashSize:])),
+	}
+	copy(event.Hash[:], serialized[1:])
+	offset := minSize
+
+	switch event.Type {
+	case ChainEventBlockConnected, ChainEventBlockDisconnected:
+		// No additional data.
+
+	case ChainEventReorganization:
+		if len(serialized[offset:]) < chainhash.HashSize+4 {
+			return nil, errDeserialize("unexpected end of data while " +
+				"reading old best block")
+		}
+		copy(event.OldHash[:], serialized[offset:])
+		offset += chainhash.HashSize
+		event.OldHeight = int64(byteOrder.Uint32(serialized[offset:]))
+
+	case ChainEventTickets:
+		if len(serialized[offset:]) < 8 {
+			return nil, errDeserialize("unexpected end of data while " +
+				"reading stake difficulty")
+		}
+		event.StakeDifficulty = int64(byteOrder.Uint64(serialized[offset:]))
+		offset += 8
+
+		var err error
+		var bytesRead int
+		event.TicketsSpent, bytesRead, err = deserializeTicketHashes(
+			serialized[offset:])
+		if err != nil {
+			return nil, err
+		}
+		offset += bytesRead
+		event.TicketsMissed, bytesRead, err = deserializeTicketHashes(
+			serialized[offset:])
+		if err != nil {
+			return nil, err
+		}
+		offset += bytesRead
+		event.TicketsNew, _, err = deserializeTicketHashes(
+			serialized[offset:])
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, errDeserialize(fmt.Sprintf("unknown chain event "+
+			"type %d", serialized[0]))
+	}
+
+	return event, nil
+}
+
+// dbFetchChainEventLogBounds uses an existing database transaction to fetch the
+// sequence numbers of the oldest and newest events in the chain event log.
+// Both are zero when the log is empty.
+func dbFetchChainEventLogBounds(dbTx database.Tx) (uint64, uint64) {
+	bucket := dbTx.Metadata().Bucket(dbnamespace.ChainEventLogBucketName)
+	cursor := bucket.Cursor()
+	if !cursor.First() {
+		return 0, 0
+	}
+	oldest := binary.BigEndian.Uint64(cursor.Key())
+	cursor.Last()
+	return oldest, binary.BigEndian.Uint64(cursor.Key())
+}
+
+// dbPruneChainEvent uses an existing database transaction to remove the event
+// with the provided sequence number from the chain event log along with its
+// entry in the block hash index when it is the most recent connected event for
+// the block.
+func dbPruneChainEvent(dbTx database.Tx, seq uint64) error {
+	meta := dbTx.Metadata()
+	bucket := meta.Bucket(dbnamespace.ChainEventLogBucketName)
+	key := chainEventKey(seq)
+	value := bucket.Get(key)
+	if len(value) >= 1+chainhash.HashSize &&
+		ChainEventType(value[0]) == ChainEventBlockConnected {
+
+		hashIdx := meta.Bucket(dbnamespace.ChainEventHashIndexBucketName)
+		hash := value[1 : 1+chainhash.HashSize]
+		if bytes.Equal(hashIdx.Get(hash), key) {
+			if err := hashIdx.Delete(hash); err != nil {
+				return err
+			}
+		}
+	}
+	return bucket.Delete(key)
+}
+
+// dbAppendChainEvents uses an existing database transaction to append the
+// provided events to the chain event log and updates the block hash index for
+// the connected events.  The sequence numbers of the events are updated to the
+// ones they were assigned.  The oldest events are removed as needed so the log
+// does not grow beyond the maximum size.
+func dbAppendChainEvents(dbTx database.Tx, events []ChainEvent) error {
+	meta := dbTx.Metadata()
+	bucket := meta.Bucket(dbnamespace.ChainEventLogBucketName)
+	hashIdx := meta.Bucket(dbnamespace.ChainEventHashIndexBucketName)
+	_, newest := dbFetchChainEventLogBounds(dbTx)
+	for i := range events {
+		event := &events[i]
+		event.Sequence = newest + uint64(i) + 1
+		key := chainEventKey(event.Sequence)
+		err := bucket.Put(key, serializeChainEvent(event))
+		if err != nil {
+			return err
+		}
+		if event.Type == ChainEventBlockConnected {
+			if err := hashIdx.Put(event.Hash[:], key); err != nil {
+				return err
+			}
+		}
+
+		if event.Sequence > chainEventLogSize {
+			prunedSeq := event.Sequence - chainEventLogSize
+			if err := dbPruneChainEvent(dbTx, prunedSeq); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dbFetchChainEvents uses an existing database transaction to fetch up to the
+// provided maximum number of events from the chain event log which follow the
+// passed sequence number.  An error is returned when the sequence number is
+// newer than the newest event or when events which follow it have already been
+// removed from the log.
+func dbFetchChainEvents(dbTx database.Tx, seq uint64, maxEvents int) ([]ChainEvent, error) {
+	oldest, newest := dbFetchChainEventLogBounds(dbTx)
+	if seq > newest {
+		return nil, fmt.Errorf("chain event %d is newer than the newest "+
+			"event %d", seq, newest)
+	}
+	if seq == newest {
+		return nil, nil
+	}
+	if seq+1 < oldest {
+		return nil, fmt.Errorf("the chain events which follow event %d "+
+			"are no longer available (oldest available event is %d)",
+			seq, oldest)
+	}
+
+	bucket := dbTx.Metadata().Bucket(dbnamespace.ChainEventLogBucketName)
+	cursor := bucket.Cursor()
+	var events []ChainEvent
+	for ok := cursor.Seek(chainEventKey(seq + 1)); ok; ok = cursor.Next() {
+		if len(events) >= maxEvents {
+			break
+		}
+
+		eventSeq := binary.BigEndian.Uint64(cursor.Key())
+		event, err := deserializeChainEvent(eventSeq, cursor.Value())
+		if err != nil {
+			return nil, database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt chain event %d: %v",
+					eventSeq, err),
+			}
+		}
+		events = append(events, *event)
+	}
+	return events, nil
+}
+
+// ChainEventsAfter returns up to the provided maximum number of events from the
+// chain event log which follow the passed sequence number in the order they
+// happened.  A sequence number of zero returns the events from the start of
+// the log.
+//
+// An error is returned when the sequence number is newer than the newest event
+// or when the events which follow it are no longer available since the log
+// only retains a limited number of the most recent events.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ChainEventsAfter(seq uint64, maxEvents int) ([]ChainEvent, error) {
+	var events []ChainEvent
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		events, err = dbFetchChainEvents(dbTx, seq, maxEvents)
+		return err
+	})
+	return events, err
+}
+
+// ChainEventSequenceByHash returns the sequence number of the most recent
+// event in the chain event log which connected the block with the provided hash
+// to the main chain.  Replaying the events after it brings a consumer that has
+// processed the block up to date, including when the block has since been
+// disconnected.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ChainEventSequenceByHash(hash *chainhash.Hash) (uint64, error) {
+	var seq uint64
+	err := b.db.View(func(dbTx database.Tx) error {
+		hashIdx := dbTx.Metadata().Bucket(
+			dbnamespace.ChainEventHashIndexBucketName)
+		serialized := hashIdx.Get(hash[:])
+		if serialized == nil {
+			return fmt.Errorf("no connected event for block %v in "+
+				"the chain event log", hash)
+		}
+		if len(serialized) != chainEventKeySize {
+			return database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt chain event hash "+
+					"index entry for %v: %x", hash, serialized),
+			}
+		}
+
+		seq = binary.BigEndian.Uint64(serialized)
+		return nil
+	})
+	return seq, err
+}
+
+// notifyChainEvents sends a notification for each of the provided events,
+// which must have already been committed to the chain event log.
+func (b *BlockChain) notifyChainEvents(events []ChainEvent) {
+	for i := range events {
+		b.sendNotification(NTChainEvent, &events[i])
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+)
+
+// TestChainEventSerialization ensures serializing and deserializing chain
+// events works as expected.
+func TestChainEventSerialization(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		event ChainEvent
+	}{{
+		name: "block connected",
+		event: ChainEvent{
+			Sequence: 1,
+			Type:     ChainEventBlockConnected,
+			Hash:     chainhash.Hash{0x01},
+			Height:   100,
+		},
+	}, {
+		name: "block disconnected",
+		event: ChainEvent{
+			Sequence: 2,
+			Type:     ChainEventBlockDisconnected,
+			Hash:     chainhash.Hash{0x02},
+			Height:   100,
+		},
+	}, {
+		name: "reorganization",
+		event: ChainEvent{
+			Sequence:  3,
+			Type:      ChainEventReorganization,
+			Hash:      chainhash.Hash{0x03},
+			Height:    101,
+			OldHash:   chainhash.Hash{0x04},
+			OldHeight: 100,
+		},
+	}, {
+		name: "tickets",
+		event: ChainEvent{
+			Sequence:        4,
+			Type:            ChainEventTickets,
+			Hash:            chainhash.Hash{0x05},
+			Height:          101,
+			StakeDifficulty: 2e8,
+			TicketsSpent:    []chainhash.Hash{{0x06}, {0x07}},
+			TicketsMissed:   []chainhash.Hash{},
+			TicketsNew:      []chainhash.Hash{{0x08}},
+		},
+	}}
+
+	for _, test := range tests {
+		serialized := serializeChainEvent(&test.event)
+		event, err := deserializeChainEvent(test.event.Sequence, serialized)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*event, test.event) {
+			t.Errorf("%s: mismatched event -- got %+v, want %+v",
+				test.name, *event, test.event)
+			continue
+		}
+
+		// Ensure truncated entries are rejected.
+		if test.event.Type == ChainEventBlockConnected ||
+			test.event.Type == ChainEventBlockDisconnected {
+
+			continue
+		}
+		_, err = deserializeChainEvent(test.event.Sequence,
+			serialized[:len(serialized)-1])
+		if !isDeserializeErr(err) {
+			t.Errorf("%s: did not receive deserialize error for "+
+				"truncated entry: %v", test.name, err)
+		}
+	}
+}
+
+// TestChainEventLog ensures the chain event log records the events of blocks
+// being connected and disconnected along with reorganizations, that they can
+// be replayed from a sequence number or block hash, and that unavailable events
+// are reported.
+func TestChainEventLog(t *testing.T) {
+	// Create a new database and chain instance to run tests against.
+	chain, teardownFunc, err := chainSetup("chaineventlogtest",
+		&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Generate enough blocks for some of the purchased tickets to be live.
+	tg := newUtxoTestGenerator(t, chain)
+	tg.generateMatureBlocks()
+	tg.generateSpendBlocks("bs", 20)
+	tip := chain.bestNode
+	stakeEnabledHeight := chain.chainParams.StakeEnabledHeight
+	if tip.height <= stakeEnabledHeight {
+		t.Fatalf("best block height %d is not after the stake enabled "+
+			"height %d", tip.height, stakeEnabledHeight)
+	}
+
+	// Ensure there is a connected event for every block and a preceding
+	// ticket event for every block once stake is enabled, all with
+	// consecutive sequence numbers.
+	events, err := chain.ChainEventsAfter(0, 1000)
+	if err != nil {
+		t.Fatalf("ChainEventsAfter: unexpected error: %v", err)
+	}
+	wantNumEvents := tip.height + tip.height - stakeEnabledHeight + 1
+	if int64(len(events)) != wantNumEvents {
+		t.Fatalf("ChainEventsAfter: got %d events, want %d", len(events),
+			wantNumEvents)
+	}
+	var i int
+	for height := int64(1); height <= tip.height; height++ {
+		hash, err := chain.BlockHashByHeight(height)
+		if err != nil {
+			t.Fatalf("BlockHashByHeight: unexpected error: %v", err)
+		}
+		wantTypes := []ChainEventType{ChainEventBlockConnected}
+		if height >= stakeEnabledHeight {
+			wantTypes = []ChainEventType{ChainEventTickets,
+				ChainEventBlockConnected}
+		}
+		for _, wantType := range wantTypes {
+			event := events[i]
+			i++
+			if event.Sequence != uint64(i) || event.Type != wantType ||
+				event.Hash != *hash || event.Height != height {
+
+				t.Fatalf("unexpected event %+v for block %v (height "+
+					"%d)", event, hash, height)
+			}
+		}
+	}
+
+	// Ensure the ticket event of the best block matches its stake node.
+	// Note that the stake node returns nil for blocks without any tickets
+	// of a given kind.
+	hashesEqual := func(a, b []chainhash.Hash) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+	ticketEvent := events[len(events)-2]
+	if len(ticketEvent.TicketsNew) == 0 ||
+		!hashesEqual(ticketEvent.TicketsSpent, tip.stakeNode.SpentByBlock()) ||
+		!hashesEqual(ticketEvent.TicketsMissed, tip.stakeNode.MissedByBlock()) ||
+		!hashesEqual(ticketEvent.TicketsNew, tip.stakeNode.NewTickets()) {
+
+		t.Fatalf("unexpected ticket event %+v", ticketEvent)
+	}
+	wantStakeDiff, err := chain.calcNextRequiredStakeDifficulty(tip)
+	if err != nil {
+		t.Fatalf("calcNextRequiredStakeDifficulty: unexpected error: %v",
+			err)
+	}
+	if ticketEvent.StakeDifficulty != wantStakeDiff {
+		t.Fatalf("unexpected stake difficulty %d in ticket event, want %d",
+			ticketEvent.StakeDifficulty, wantStakeDiff)
+	}
+
+	// Ensure the maximum number of events is respected and there are no
+	// events after the newest one.
+	newestSeq := events[len(events)-1].Sequence
+	events, err = chain.ChainEventsAfter(newestSeq-3, 2)
+	if err != nil {
+		t.Fatalf("ChainEventsAfter: unexpected error: %v", err)
+	}
+	if len(events) != 2 || events[0].Sequence != newestSeq-2 {
+		t.Fatalf("ChainEventsAfter: unexpected events %+v", events)
+	}
+	events, err = chain.ChainEventsAfter(newestSeq, 1000)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("ChainEventsAfter: unexpected events %+v for newest "+
+			"event (err %v)", events, err)
+	}
+
+	// Invalidate the best block and then reconsider it, which results in it
+	// being disconnected and connected again, each of which is preceded by
+	// a reorganization.
+	tipSeq, err := chain.ChainEventSequenceByHash(&tip.hash)
+	if err != nil {
+		t.Fatalf("ChainEventSequenceByHash: unexpected error: %v", err)
+	}
+	if tipSeq != newestSeq {
+		t.Fatalf("ChainEventSequenceByHash: got %d, want %d", tipSeq,
+			newestSeq)
+	}
+	if err := chain.InvalidateBlock(&tip.hash); err != nil {
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	if err := chain.ReconsiderBlock(&tip.hash); err != nil {
+		t.Fatalf("ReconsiderBlock: unexpected error: %v", err)
+	}
+	events, err = chain.ChainEventsAfter(tipSeq, 1000)
+	if err != nil {
+		t.Fatalf("ChainEventsAfter: unexpected error: %v", err)
+	}
+	parent := tip.parent
+	wantEvents := []ChainEvent{{
+		Type:      ChainEventReorganization,
+		Hash:      parent.hash,
+		Height:    parent.height,
+		OldHash:   tip.hash,
+		OldHeight: tip.height,
+	}, {
+		Type:   ChainEventBlockDisconnected,
+		Hash:   tip.hash,
+		Height: tip.height,
+	}, {
+		Type:      ChainEventReorganization,
+		Hash:      tip.hash,
+		Height:    tip.height,
+		OldHash:   parent.hash,
+		OldHeight: parent.height,
+	}, {
+		Type:   ChainEventTickets,
+		Hash:   tip.hash,
+		Height: tip.height,
+	}, {
+		Type:   ChainEventBlockConnected,
+		Hash:   tip.hash,
+		Height: tip.height,
+	}}
+	if len(events) != len(wantEvents) {
+		t.Fatalf("ChainEventsAfter: got %d events, want %d", len(events),
+			len(wantEvents))
+	}
+	for i, event := range events {
+		want := wantEvents[i]
+		if event.Sequence != tipSeq+uint64(i)+1 || event.Type != want.Type ||
+			event.Hash != want.Hash || event.Height != want.Height ||
+			event.OldHash != want.OldHash ||
+			event.OldHeight != want.OldHeight {
+
+			t.Fatalf("unexpected event #%d -- got %+v, want %+v", i,
+				event, want)
+		}
+	}
+
+	// Ensure the most recent connected event is used for the block.
+	tipSeq, err = chain.ChainEventSequenceByHash(&tip.hash)
+	if err != nil {
+		t.Fatalf("ChainEventSequenceByHash: unexpected error: %v", err)
+	}
+	if tipSeq != events[len(events)-1].Sequence {
+		t.Fatalf("ChainEventSequenceByHash: got %d, want %d", tipSeq,
+			events[len(events)-1].Sequence)
+	}
+	unknownHash := chainhash.Hash{0x01}
+	if _, err := chain.ChainEventSequenceByHash(&unknownHash); err == nil {
+		t.Fatal("ChainEventSequenceByHash: did not fail for unknown block")
+	}
+
+	// Ensure requesting events after one which does not exist yet and after
+	// one which has been removed from the log fails.
+	if _, err := chain.ChainEventsAfter(tipSeq+1, 1000); err == nil {
+		t.Fatal("ChainEventsAfter: did not fail for event newer than the " +
+			"newest event")
+	}
+	err = chain.db.Update(func(dbTx database.Tx) error {
+		return dbPruneChainEvent(dbTx, 1)
+	})
+	if err != nil {
+		t.Fatalf("Failed to remove event: %v", err)
+	}
+	if _, err := chain.ChainEventsAfter(0, 1000); err == nil {
+		t.Fatal("ChainEventsAfter: did not fail for removed events")
+	}
+
+	// Ensure the block hash index entry of the removed connected event of
+	// the first block is removed along with it.
+	firstHash, err := chain.BlockHashByHeight(1)
+	if err != nil {
+		t.Fatalf("BlockHashByHeight: unexpected error: %v", err)
+	}
+	if _, err := chain.ChainEventSequenceByHash(firstHash); err == nil {
+		t.Fatal("ChainEventSequenceByHash: did not fail for block of " +
+			"removed event")
+	}
+	events, err = chain.ChainEventsAfter(1, 1)
+	if err != nil || len(events) != 1 || events[0].Sequence != 2 {
+		t.Fatalf("ChainEventsAfter: unexpected events %+v after removed "+
+			"event (err %v)", events, err)
+	}
+}
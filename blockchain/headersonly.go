@@ -0,0 +1,375 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// loadHeadersOnlyChainState loads the best node of a headers-only chain, which
+// is not stored as a full block, from the block index and initializes the state
+// related to it.  The best node does not have a stake node since there is no
+// ticket database beyond the genesis block.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) loadHeadersOnlyChainState(dbTx database.Tx, state *bestChainState) error {
+	entry, err := dbFetchBlockIndexEntry(dbTx, &state.hash, state.height)
+	if err != nil {
+		return err
+	}
+	if entry.status.HaveData() {
+		return fmt.Errorf("the best block %v is stored, so the database "+
+			"can not be used in headers-only mode", state.hash)
+	}
+
+	node := newBlockNode(&entry.header, nil)
+	node.status = entry.status
+	node.inMainChain = true
+	node.workSum = state.workSum
+	b.bestNode = node
+
+	// Add the new node to the indices for faster lookups.
+	b.index.AddNode(node)
+
+	// Calculate the median time for the block.
+	medianTime, err := b.index.CalcPastMedianTime(node)
+	if err != nil {
+		return err
+	}
+
+	b.stateSnapshot = newBestState(node, 0, 0, state.totalTxns, medianTime,
+		state.totalSubsidy)
+	return nil
+}
+
+// headersOnlyCheckpointHeight returns the height of the most recent checkpoint
+// that is already part of the main chain of a headers-only chain, or zero when
+// there is none.  Every header in the main chain at a checkpoint height has
+// been verified against it, so the height of the best node suffices.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) headersOnlyCheckpointHeight() int64 {
+	if b.noCheckpoints {
+		return 0
+	}
+
+	checkpoints := b.chainParams.Checkpoints
+	for i := len(checkpoints) - 1; i >= 0; i-- {
+		if checkpoints[i].Height <= b.bestNode.height {
+			return checkpoints[i].Height
+		}
+	}
+	return 0
+}
+
+// headersOnlyStakeDifficulties returns the stake difficulties required for the
+// block after the passed node by the original algorithm and the one defined in
+// DCP0001 along with whether the latter is known to be active.
+//
+// The votes that activate the new algorithm are not available from the headers
+// of a headers-only chain.  However, activation is permanent and the algorithms
+// only produce different results at retarget intervals, so the new algorithm is
+// known to be active when the most recent header at an interval where they
+// differ commits to its result.  The final return value is only determined when
+// the difficulties differ and is false otherwise.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) headersOnlyStakeDifficulties(curNode *blockNode) (int64, int64, bool, error) {
+	sdiffV1, err := b.calcNextRequiredStakeDifficultyV1(curNode)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	sdiffV2, err := b.calcNextRequiredStakeDifficultyV2(curNode)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if sdiffV1 == sdiffV2 || curNode == nil {
+		return sdiffV1, sdiffV2, false, nil
+	}
+
+	// Find the most recent header at a retarget interval where the
+	// algorithms differ and determine which one it commits to.
+	windowSize := b.chainParams.StakeDiffWindowSize
+	height := curNode.height - curNode.height%windowSize
+	for ; height > 0; height -= windowSize {
+		node, err := b.index.AncestorNode(curNode, height)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		parent, err := b.index.PrevNodeFromNode(node)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		prevSDiffV1, err := b.calcNextRequiredStakeDifficultyV1(parent)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		prevSDiffV2, err := b.calcNextRequiredStakeDifficultyV2(parent)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		if prevSDiffV1 != prevSDiffV2 {
+			return sdiffV1, sdiffV2, node.sbits == prevSDiffV2, nil
+		}
+	}
+
+	return sdiffV1, sdiffV2, false, nil
+}
+
+// checkHeadersOnlyStakeDifficulty ensures the stake difficulty specified in the
+// passed header of a headers-only chain matches the difficulty calculated based
+// on the previous block and the difficulty retarget rules.  Until the algorithm
+// defined in DCP0001 is known to be active, the header may commit to the result
+// of either algorithm, since the header that first commits to the new one could
+// otherwise not be accepted.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) checkHeadersOnlyStakeDifficulty(header *wire.BlockHeader, prevNode *blockNode) error {
+	sdiffV1, sdiffV2, v2Active, err := b.headersOnlyStakeDifficulties(prevNode)
+	if err != nil {
+		return err
+	}
+	expSDiff := sdiffV1
+	if v2Active {
+		expSDiff = sdiffV2
+	}
+	if header.SBits == expSDiff || (!v2Active && header.SBits == sdiffV2) {
+		return nil
+	}
+
+	errStr := fmt.Sprintf("block stake difficulty of %d is not the "+
+		"expected value of %d", header.SBits, expSDiff)
+	return ruleError(ErrUnexpectedDifficulty, errStr)
+}
+
+// headerExists determines whether a header with the given hash exists either in
+// the main chain or any side chains of a headers-only chain.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) headerExists(hash *chainhash.Hash) (bool, error) {
+	// Check block index first (could be main chain or side chain headers).
+	if b.index.HaveBlock(hash) {
+		return true, nil
+	}
+
+	// Check the main chain in the database.
+	var exists bool
+	err := b.db.View(func(dbTx database.Tx) error {
+		exists = dbMainChainHasBlock(dbTx, hash)
+		return nil
+	})
+	return exists, err
+}
+
+// headerParentNode returns the block node for the parent of the block with the
+// passed header, loading it from the main chain in the database as needed.  It
+// returns nil when the parent is not known.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) headerParentNode(header *wire.BlockHeader) (*blockNode, error) {
+	if node := b.index.LookupNode(&header.PrevBlock); node != nil {
+		return node, nil
+	}
+
+	var height int64
+	var inMainChain bool
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		height, err = dbFetchHeightByHash(dbTx, &header.PrevBlock)
+		if isNotInMainChainErr(err) {
+			return nil
+		}
+		inMainChain = err == nil
+		return err
+	})
+	if err != nil || !inMainChain {
+		return nil, err
+	}
+
+	return b.index.AncestorNode(b.bestNode, height)
+}
+
+// ProcessBlockHeader is the main workhorse for handling insertion of new block
+// headers into a headers-only chain.  It includes functionality such as
+// rejecting duplicate headers, ensuring headers follow all of the rules that
+// can be checked without the full blocks, and insertion into the block index
+// along with best chain selection and reorganization.
+//
+// The checks include the proof of work, the difficulty, the stake difficulty
+// and the number of votes, revocations, and ticket purchases committed to, but
+// not the pool size, final state, and stake version, since they rely on the
+// votes and the ticket database.  Headers that do not connect to a known header
+// are rejected rather than being held as orphans.
+//
+// When no errors occurred during processing, the return value indicates whether
+// or not the header is on the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ProcessBlockHeader(header *wire.BlockHeader, flags BehaviorFlags) (bool, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if !b.headersOnly {
+		return false, AssertError("ProcessBlockHeader called on a chain " +
+			"that is not in headers-only mode")
+	}
+
+	blockHash := header.BlockHash()
+	log.Tracef("Processing block header %v", blockHash)
+
+	// The header must not already exist in the main chain or side chains.
+	exists, err := b.headerExists(&blockHash)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		str := fmt.Sprintf("already have block header %v", blockHash)
+		return false, ruleError(ErrDuplicateBlock, str)
+	}
+
+	// Perform preliminary sanity checks on the header.
+	err = checkBlockHeaderSanity(header, b.timeSource, flags, b.chainParams)
+	if err != nil {
+		return false, err
+	}
+
+	// The header must connect to a known header which is not invalid.
+	prevNode, err := b.headerParentNode(header)
+	if err != nil {
+		return false, err
+	}
+	if prevNode == nil {
+		str := fmt.Sprintf("previous block header %v of header %v is "+
+			"unknown", header.PrevBlock, blockHash)
+		return false, ruleError(ErrMissingParent, str)
+	}
+	if b.index.NodeStatus(prevNode).KnownInvalid() {
+		str := fmt.Sprintf("previous block header %v of header %v is "+
+			"known to be invalid", header.PrevBlock, blockHash)
+		return false, ruleError(ErrInvalidAncestorBlock, str)
+	}
+
+	// The header must pass all of the validation rules which depend on its
+	// position within the block chain.
+	err = b.checkBlockHeaderContext(header, prevNode, flags)
+	if err != nil {
+		return false, err
+	}
+
+	// Create a new block node for the header, add it to the block index, and
+	// store the associated block index entry.  The header could either be
+	// on a side chain or the main chain, but it starts off as a side chain
+	// regardless.
+	newNode := newBlockNode(header, prevNode)
+	b.index.AddNode(newNode)
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbPutBlockNode(dbTx, newNode)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// Headers with no more work than the current best chain extend a side
+	// chain.
+	if newNode.workSum.Cmp(b.bestNode.workSum) <= 0 {
+		log.Debugf("Block header %v (height %v) extends a side chain",
+			blockHash, newNode.height)
+		return false, nil
+	}
+
+	if err := b.connectBestHeader(newNode); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// connectBestHeader makes the passed node, which must have more cumulative work
+// than the current best node, the end of the main chain of a headers-only
+// chain.  The headers of the current main chain back to the fork point are
+// detached and those that lead to the node are attached.  The committed filter
+// tip is moved back to the fork point when it is detached.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) connectBestHeader(node *blockNode) error {
+	detachNodes, attachNodes, err := b.getReorganizeNodes(node)
+	if err != nil {
+		return err
+	}
+	if attachNodes.Len() == 0 {
+		return nil
+	}
+	forkNode := attachNodes.Front().Value.(*blockNode).parent
+
+	medianTime, err := b.index.CalcPastMedianTime(node)
+	if err != nil {
+		return err
+	}
+	state := newBestState(node, 0, 0, 0, medianTime, 0)
+
+	cfilterTip, cfilterTipHeader := b.cfilterTip, b.cfilterTipHeader
+	err = b.db.Update(func(dbTx database.Tx) error {
+		for e := detachNodes.Front(); e != nil; e = e.Next() {
+			n := e.Value.(*blockNode)
+			err := dbRemoveMainChainIndex(dbTx, &n.hash, n.height)
+			if err != nil {
+				return err
+			}
+		}
+		for e := attachNodes.Front(); e != nil; e = e.Next() {
+			n := e.Value.(*blockNode)
+			err := dbPutMainChainIndex(dbTx, &n.hash, n.height)
+			if err != nil {
+				return err
+			}
+		}
+
+		if cfilterTip.height > forkNode.height {
+			entry, err := dbFetchCFilterEntry(dbTx, &forkNode.hash)
+			if err != nil {
+				return err
+			}
+			err = dbPutCFilterTip(dbTx, &forkNode.hash, forkNode.height)
+			if err != nil {
+				return err
+			}
+			cfilterTip, cfilterTipHeader = forkNode, entry.header
+		}
+
+		return dbPutBestState(dbTx, state, node.workSum)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Update the main chain flags, best node, committed filter tip, and
+	// state snapshot now that the database has been updated.
+	for e := detachNodes.Front(); e != nil; e = e.Next() {
+		e.Value.(*blockNode).inMainChain = false
+	}
+	for e := attachNodes.Front(); e != nil; e = e.Next() {
+		e.Value.(*blockNode).inMainChain = true
+	}
+	oldBest := b.bestNode
+	b.bestNode = node
+	b.cfilterTip, b.cfilterTipHeader = cfilterTip, cfilterTipHeader
+	b.stateLock.Lock()
+	b.stateSnapshot = state
+	b.stateLock.Unlock()
+
+	if detachNodes.Len() > 0 {
+		log.Infof("REORGANIZE: Headers fork at %v (height %v)",
+			forkNode.hash, forkNode.height)
+		log.Infof("REORGANIZE: Old best header was %v (height %v)",
+			oldBest.hash, oldBest.height)
+		log.Infof("REORGANIZE: New best header is %v (height %v)",
+			node.hash, node.height)
+	}
+
+	return nil
+}
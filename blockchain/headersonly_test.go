@@ -0,0 +1,306 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/gcs"
+	"github.com/commanderu/cdrd/gcs/blockcf"
+	"github.com/commanderu/cdrd/txscript"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// generateTestBlocks generates and processes blocks that spend mature coinbase
+// outputs and purchase tickets with a full chain instance and returns all of
+// the main chain blocks starting with the genesis block.
+func generateTestBlocks(t *testing.T, params *chaincfg.Params) []*cdrutil.Block {
+	t.Helper()
+
+	chain, teardownFunc, err := chainSetup("headersonlyfullchain", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	tg := newUtxoTestGenerator(t, chain)
+	tg.generateMatureBlocks()
+	tg.generateSpendBlocks("bs", 24)
+
+	tipHeight := chain.BestSnapshot().Height
+	blocks := make([]*cdrutil.Block, 0, tipHeight+1)
+	for height := int64(0); height <= tipHeight; height++ {
+		block, err := chain.BlockByHeight(height)
+		if err != nil {
+			t.Fatalf("Failed to fetch block at height %d: %v", height,
+				err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// TestHeadersOnlyChain ensures a headers-only chain validates and connects
+// headers, verifies and stores committed filters against filter headers, and
+// handles reorganizations and restarts.
+func TestHeadersOnlyChain(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	blocks := generateTestBlocks(t, params)
+	tipHeight := int64(len(blocks) - 1)
+
+	chain, teardownFunc, err := headersOnlyChainSetup("headersonlytest",
+		params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Full blocks must be rejected.
+	_, _, err = chain.ProcessBlock(blocks[1], BFNone)
+	if _, ok := err.(AssertError); !ok {
+		t.Fatalf("ProcessBlock: did not receive expected assertion "+
+			"error -- got %v", err)
+	}
+
+	// Connect all of the headers of the generated chain.
+	for _, block := range blocks[1:] {
+		header := &block.MsgBlock().Header
+		isMainChain, err := chain.ProcessBlockHeader(header, BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlockHeader (height %d): %v",
+				header.Height, err)
+		}
+		if !isMainChain {
+			t.Fatalf("ProcessBlockHeader (height %d): header did "+
+				"not extend the main chain", header.Height)
+		}
+	}
+	best := chain.BestSnapshot()
+	if best.Height != tipHeight || best.Hash != *blocks[tipHeight].Hash() {
+		t.Fatalf("unexpected best block %v (height %d) -- want %v "+
+			"(height %d)", best.Hash, best.Height,
+			blocks[tipHeight].Hash(), tipHeight)
+	}
+	for _, height := range []int64{1, tipHeight / 2, tipHeight} {
+		header, err := chain.HeaderByHeight(height)
+		if err != nil {
+			t.Fatalf("HeaderByHeight(%d): %v", height, err)
+		}
+		if header.BlockHash() != *blocks[height].Hash() {
+			t.Fatalf("HeaderByHeight(%d): unexpected header %v",
+				height, header.BlockHash())
+		}
+	}
+
+	// childHeader returns a header that builds on the passed header with the
+	// difficulties required by the chain and a unique nonce.
+	childHeader := func(parent *wire.BlockHeader, nonce uint32) *wire.BlockHeader {
+		t.Helper()
+
+		parentHash := parent.BlockHash()
+		prevNode := chain.index.LookupNode(&parentHash)
+		if prevNode == nil {
+			t.Fatalf("unable to find node for header %v", parentHash)
+		}
+		header := *parent
+		header.PrevBlock = parentHash
+		header.Height = parent.Height + 1
+		header.Timestamp = parent.Timestamp.Add(time.Second)
+		header.Nonce = nonce
+		header.Bits, err = chain.calcNextRequiredDifficulty(prevNode,
+			header.Timestamp)
+		if err != nil {
+			t.Fatalf("calcNextRequiredDifficulty: %v", err)
+		}
+		header.SBits, err = chain.calcNextRequiredStakeDifficulty(prevNode)
+		if err != nil {
+			t.Fatalf("calcNextRequiredStakeDifficulty: %v", err)
+		}
+		return &header
+	}
+
+	// Ensure invalid headers are rejected.
+	tipHeader := &blocks[tipHeight].MsgBlock().Header
+	badStakeDiff := childHeader(tipHeader, 1)
+	badStakeDiff.SBits++
+	earlyVotes := childHeader(tipHeader, 2)
+	earlyVotes.Voters = 1
+	noParent := childHeader(tipHeader, 3)
+	noParent.PrevBlock = chainhash.Hash{0x01}
+	tests := []struct {
+		name   string
+		header *wire.BlockHeader
+		flags  BehaviorFlags
+		err    ErrorCode
+	}{{
+		name:   "duplicate header",
+		header: tipHeader,
+		err:    ErrDuplicateBlock,
+	}, {
+		name:   "unknown parent",
+		header: noParent,
+		flags:  BFNoPoWCheck,
+		err:    ErrMissingParent,
+	}, {
+		name:   "bad stake difficulty",
+		header: badStakeDiff,
+		flags:  BFNoPoWCheck,
+		err:    ErrUnexpectedDifficulty,
+	}, {
+		name:   "votes before stake validation height",
+		header: earlyVotes,
+		flags:  BFNoPoWCheck,
+		err:    ErrInvalidEarlyStakeTx,
+	}}
+	for _, test := range tests {
+		_, err := chain.ProcessBlockHeader(test.header, test.flags)
+		rerr, ok := err.(RuleError)
+		if !ok || rerr.ErrorCode != test.err {
+			t.Fatalf("%s: did not receive expected error code %v -- "+
+				"got %v", test.name, test.err, err)
+		}
+	}
+
+	// Build the committed filters of the generated blocks along with their
+	// filter headers starting from the zero genesis filter header.
+	filters := make([][]byte, len(blocks))
+	filterHeaders := make([]chainhash.Hash, len(blocks))
+	for i, block := range blocks {
+		f, err := blockcf.Regular(block.MsgBlock())
+		if err != nil {
+			t.Fatalf("Failed to build filter for block %d: %v", i, err)
+		}
+		filters[i] = f.NBytes()
+		if i > 0 {
+			filterHeaders[i] = gcs.MakeHeaderForFilter(f,
+				&filterHeaders[i-1])
+		}
+	}
+
+	// Ensure a filter that does not match its filter header is rejected
+	// without storing any of the batch.
+	badFilters := append([][]byte(nil), filters[1:11]...)
+	badFilters[5], badFilters[6] = badFilters[6], badFilters[5]
+	err = chain.ProcessCFilters(filterHeaders[1:11], badFilters)
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrBadCFilter {
+		t.Fatalf("ProcessCFilters: did not receive expected error code "+
+			"%v -- got %v", ErrBadCFilter, err)
+	}
+	if hash, height := chain.CFilterTip(); height != 0 ||
+		hash != *params.GenesisHash {
+
+		t.Fatalf("unexpected committed filter tip %v (height %d) after "+
+			"bad filter", hash, height)
+	}
+
+	// Ensure filters beyond the best block are rejected.
+	err = chain.ProcessCFilters(filterHeaders[1:], append(filters[1:],
+		filters[1]))
+	if err == nil {
+		t.Fatal("ProcessCFilters: did not reject filters beyond the " +
+			"best block")
+	}
+
+	// Verify all of the filters in two batches.
+	if err := chain.ProcessCFilters(filterHeaders[1:11], filters[1:11]); err != nil {
+		t.Fatalf("ProcessCFilters: %v", err)
+	}
+	if err := chain.ProcessCFilters(filterHeaders[11:], filters[11:]); err != nil {
+		t.Fatalf("ProcessCFilters: %v", err)
+	}
+	if hash, height := chain.CFilterTip(); height != tipHeight ||
+		hash != *blocks[tipHeight].Hash() {
+
+		t.Fatalf("unexpected committed filter tip %v (height %d)",
+			hash, height)
+	}
+	filter, filterHeader, err := chain.CFilterByHash(blocks[5].Hash())
+	if err != nil {
+		t.Fatalf("CFilterByHash: %v", err)
+	}
+	if string(filter) != string(filters[5]) ||
+		*filterHeader != filterHeaders[5] {
+
+		t.Fatalf("CFilterByHash: unexpected filter or filter header %v",
+			filterHeader)
+	}
+
+	// Create a side chain that forks two blocks before the tip and ensure
+	// it becomes the main chain once it has more work, which moves the
+	// committed filter tip back to the fork point.
+	forkHeight := tipHeight - 2
+	sideHeaders := make([]*wire.BlockHeader, 0, 3)
+	parent := &blocks[forkHeight].MsgBlock().Header
+	for i := 0; i < 3; i++ {
+		header := childHeader(parent, uint32(100+i))
+		isMainChain, err := chain.ProcessBlockHeader(header, BFNoPoWCheck)
+		if err != nil {
+			t.Fatalf("ProcessBlockHeader (side chain %d): %v", i, err)
+		}
+		if isMainChain != (i == 2) {
+			t.Fatalf("ProcessBlockHeader (side chain %d): unexpected "+
+				"main chain status %v", i, isMainChain)
+		}
+		sideHeaders = append(sideHeaders, header)
+		parent = header
+	}
+	newTipHash := parent.BlockHash()
+	best = chain.BestSnapshot()
+	if best.Height != tipHeight+1 || best.Hash != newTipHash {
+		t.Fatalf("unexpected best block %v (height %d) after reorg",
+			best.Hash, best.Height)
+	}
+	hash, err := chain.BlockHashByHeight(forkHeight + 1)
+	if err != nil {
+		t.Fatalf("BlockHashByHeight: %v", err)
+	}
+	if *hash != sideHeaders[0].BlockHash() {
+		t.Fatalf("BlockHashByHeight: unexpected hash %v after reorg",
+			hash)
+	}
+	if hash, height := chain.CFilterTip(); height != forkHeight ||
+		hash != *blocks[forkHeight].Hash() {
+
+		t.Fatalf("unexpected committed filter tip %v (height %d) after "+
+			"reorg", hash, height)
+	}
+
+	// Ensure a new chain instance loads the state from the database and is
+	// able to extend it.
+	chain, err = New(&Config{
+		DB:          chain.db,
+		ChainParams: chain.chainParams,
+		TimeSource:  NewMedianTime(),
+		SigCache:    txscript.NewSigCache(1000),
+		HeadersOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to reload chain instance: %v", err)
+	}
+	best = chain.BestSnapshot()
+	if best.Height != tipHeight+1 || best.Hash != newTipHash {
+		t.Fatalf("unexpected best block %v (height %d) after reload",
+			best.Hash, best.Height)
+	}
+	if hash, height := chain.CFilterTip(); height != forkHeight ||
+		hash != *blocks[forkHeight].Hash() {
+
+		t.Fatalf("unexpected committed filter tip %v (height %d) after "+
+			"reload", hash, height)
+	}
+	header := childHeader(parent, 200)
+	isMainChain, err := chain.ProcessBlockHeader(header, BFNoPoWCheck)
+	if err != nil {
+		t.Fatalf("ProcessBlockHeader after reload: %v", err)
+	}
+	if !isMainChain {
+		t.Fatal("ProcessBlockHeader after reload: header did not " +
+			"extend the main chain")
+	}
+}
@@ -0,0 +1,455 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/commanderu/cdrd/blockchain"
+	"github.com/commanderu/cdrd/blockchain/stake"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+	"github.com/commanderu/cdrd/txscript"
+	"github.com/commanderu/cdrd/wire"
+)
+
+const (
+	// blockStatsIndexName is the human-readable name for the index.
+	blockStatsIndexName = "block stats index"
+
+	// blockStatsSize is the size of serialized block statistics.  It
+	// consists of 8 uint32 fields, 1 int32 field, and 17 int64 fields.
+	blockStatsSize = 8*4 + 4 + 17*8
+)
+
+var (
+	// blockStatsIndexKey is the key of the block stats index and the db
+	// bucket used to house it.
+	blockStatsIndexKey = []byte("blockstatsbyhashidx")
+
+	// FeeRatePercentiles are the percentiles of the fee rates of the
+	// regular transactions in a block that are tracked by the block
+	// statistics.
+	FeeRatePercentiles = [5]int{10, 25, 50, 75, 90}
+)
+
+// BlockStats houses statistics about the transactions, fees, and subsidy of a
+// block.  All amounts are in atoms and fee rates are in atoms per kilobyte.
+//
+// The fee rate statistics only consider the regular transactions of the block
+// other than the coinbase, and the fee rate percentiles are weighted by the
+// size of the transactions.
+type BlockStats struct {
+	Height         uint32
+	Time           int64
+	Interval       int64
+	Size           uint32
+	NumTxns        uint32
+	NumTickets     uint32
+	NumVotes       uint32
+	NumRevocations uint32
+	NumInputs      uint32
+	NumOutputs     uint32
+	UtxoIncrease   int32
+	TotalOut       int64
+	TotalStakeOut  int64
+	TotalFee       int64
+	TotalStakeFee  int64
+	MinFeeRate     int64
+	MaxFeeRate     int64
+	AvgFeeRate     int64
+	FeeRates       [len(FeeRatePercentiles)]int64
+	PoWSubsidy     int64
+	PoSSubsidy     int64
+	DevSubsidy     int64
+}
+
+// txFee returns the fee of the provided transaction, which is calculated from
+// the input amounts committed to by the transaction.
+func txFee(msgTx *wire.MsgTx) int64 {
+	var fee int64
+	for _, txIn := range msgTx.TxIn {
+		fee += txIn.ValueIn
+	}
+	for _, txOut := range msgTx.TxOut {
+		fee -= txOut.Value
+	}
+	return fee
+}
+
+// txFeeRate houses the fee rate and size of a transaction for calculating the
+// fee rate statistics of a block.
+type txFeeRate struct {
+	feeRate int64
+	size    int64
+}
+
+// CalcBlockStats returns the statistics for the provided block.  The header of
+// the parent of the block is used to calculate the time since the parent and
+// may be nil for the genesis block.
+func CalcBlockStats(block *cdrutil.Block, prevHeader *wire.BlockHeader, subsidyCache *blockchain.SubsidyCache, params *chaincfg.Params) *BlockStats {
+	msgBlock := block.MsgBlock()
+	header := &msgBlock.Header
+	height := int64(header.Height)
+	stats := &BlockStats{
+		Height:         header.Height,
+		Time:           header.Timestamp.Unix(),
+		Size:           uint32(msgBlock.SerializeSize()),
+		NumTxns:        uint32(len(msgBlock.Transactions)),
+		NumTickets:     uint32(header.FreshStake),
+		NumVotes:       uint32(header.Voters),
+		NumRevocations: uint32(header.Revocations),
+	}
+	if prevHeader != nil {
+		stats.Interval = stats.Time - prevHeader.Timestamp.Unix()
+	}
+
+	// countTxns updates the input and output counts for the provided
+	// transactions.  The coinbase and stakebase inputs are not counted
+	// since they do not spend outputs and only outputs which are added to
+	// the utxo set are counted towards the utxo set increase.
+	var numUtxoOutputs uint32
+	countTxns := func(txns []*wire.MsgTx) {
+		for _, msgTx := range txns {
+			if !blockchain.IsCoinBaseTx(msgTx) {
+				stats.NumInputs += uint32(len(msgTx.TxIn))
+				if stake.IsSSGen(msgTx) {
+					stats.NumInputs--
+				}
+			}
+			stats.NumOutputs += uint32(len(msgTx.TxOut))
+			for _, txOut := range msgTx.TxOut {
+				if !txscript.IsUnspendable(txOut.Value, txOut.PkScript) {
+					numUtxoOutputs++
+				}
+			}
+		}
+	}
+	countTxns(msgBlock.Transactions)
+	countTxns(msgBlock.STransactions)
+	stats.UtxoIncrease = int32(numUtxoOutputs) - int32(stats.NumInputs)
+
+	// Calculate the total output values and fees of both transaction trees
+	// along with the fee rates of the regular transactions.
+	feeRates := make([]txFeeRate, 0, len(msgBlock.Transactions))
+	var totalSize int64
+	for i, msgTx := range msgBlock.Transactions {
+		for _, txOut := range msgTx.TxOut {
+			stats.TotalOut += txOut.Value
+		}
+
+		// Skip the coinbase.
+		if i == 0 {
+			continue
+		}
+
+		fee := txFee(msgTx)
+		size := int64(msgTx.SerializeSize())
+		stats.TotalFee += fee
+		totalSize += size
+		feeRates = append(feeRates, txFeeRate{fee * 1000 / size, size})
+	}
+	for _, msgTx := range msgBlock.STransactions {
+		for _, txOut := range msgTx.TxOut {
+			stats.TotalStakeOut += txOut.Value
+		}
+		stats.TotalStakeFee += txFee(msgTx)
+	}
+
+	// Calculate the fee rate statistics with the percentiles weighted by
+	// the size of the transactions.
+	if len(feeRates) > 0 {
+		sort.Slice(feeRates, func(i, j int) bool {
+			return feeRates[i].feeRate < feeRates[j].feeRate
+		})
+		stats.MinFeeRate = feeRates[0].feeRate
+		stats.MaxFeeRate = feeRates[len(feeRates)-1].feeRate
+		stats.AvgFeeRate = stats.TotalFee * 1000 / totalSize
+
+		var cumulativeSize int64
+		var percentileIdx int
+		for _, feeRate := range feeRates {
+			cumulativeSize += feeRate.size
+			for percentileIdx < len(FeeRatePercentiles) {
+				percentile := int64(FeeRatePercentiles[percentileIdx])
+				if cumulativeSize*100 < totalSize*percentile {
+					break
+				}
+				stats.FeeRates[percentileIdx] = feeRate.feeRate
+				percentileIdx++
+			}
+		}
+	}
+
+	// Calculate the subsidy split.  There is no subsidy for the genesis
+	// block and votes are only included once stake validation begins.
+	if height > 0 {
+		stats.PoWSubsidy = blockchain.CalcBlockWorkSubsidy(subsidyCache,
+			height, header.Voters, params)
+		stats.DevSubsidy = blockchain.CalcBlockTaxSubsidy(subsidyCache,
+			height, header.Voters, params)
+		if height >= params.StakeValidationHeight {
+			stats.PoSSubsidy = blockchain.CalcStakeVoteSubsidy(
+				subsidyCache, height, params) * int64(header.Voters)
+		}
+	}
+
+	return stats
+}
+
+// -----------------------------------------------------------------------------
+// The block stats index consists of an entry for every block in the main chain
+// which maps the hash of the block to its statistics.
+//
+// The serialized format for keys and values in the block stats index is:
+//
+//   <block hash> = <stats>
+//
+//   Field           Type              Size
+//   height          uint32            4
+//   time            int64             8
+//   interval        int64             8
+//   size            uint32            4
+//   num txns        uint32            4
+//   num tickets     uint32            4
+//   num votes       uint32            4
+//   num revocations uint32            4
+//   num inputs      uint32            4
+//   num outputs     uint32            4
+//   utxo increase   int32             4
+//   total out       int64             8
+//   total stake out int64             8
+//   total fee       int64             8
+//   total stake fee int64             8
+//   min fee rate    int64             8
+//   max fee rate    int64             8
+//   avg fee rate    int64             8
+//   fee rates       [5]int64          40
+//   pow subsidy     int64             8
+//   pos subsidy     int64             8
+//   dev subsidy     int64             8
+//   -----
+//   Total: 172 bytes
+//
+// NOTE: The num tickets, num votes, and num revocations fields are wider than
+// the corresponding header fields so the format does not need to change should
+// the header fields ever be widened.
+// -----------------------------------------------------------------------------
+
+// serializeBlockStats returns the serialization of the passed block stats
+// according to the format described above.
+func serializeBlockStats(stats *BlockStats) []byte {
+	serialized := make([]byte, blockStatsSize)
+	offset := 0
+	putUint32 := func(v uint32) {
+		byteOrder.PutUint32(serialized[offset:], v)
+		offset += 4
+	}
+	putInt64 := func(v int64) {
+		byteOrder.PutUint64(serialized[offset:], uint64(v))
+		offset += 8
+	}
+
+	putUint32(stats.Height)
+	putInt64(stats.Time)
+	putInt64(stats.Interval)
+	putUint32(stats.Size)
+	putUint32(stats.NumTxns)
+	putUint32(stats.NumTickets)
+	putUint32(stats.NumVotes)
+	putUint32(stats.NumRevocations)
+	putUint32(stats.NumInputs)
+	putUint32(stats.NumOutputs)
+	putUint32(uint32(stats.UtxoIncrease))
+	putInt64(stats.TotalOut)
+	putInt64(stats.TotalStakeOut)
+	putInt64(stats.TotalFee)
+	putInt64(stats.TotalStakeFee)
+	putInt64(stats.MinFeeRate)
+	putInt64(stats.MaxFeeRate)
+	putInt64(stats.AvgFeeRate)
+	for _, feeRate := range stats.FeeRates {
+		putInt64(feeRate)
+	}
+	putInt64(stats.PoWSubsidy)
+	putInt64(stats.PoSSubsidy)
+	putInt64(stats.DevSubsidy)
+	return serialized
+}
+
+// deserializeBlockStats decodes the passed serialized block stats according to
+// the format described above.
+func deserializeBlockStats(serialized []byte) (*BlockStats, error) {
+	if len(serialized) != blockStatsSize {
+		return nil, errDeserialize(fmt.Sprintf("unexpected block stats "+
+			"size %d (expected %d)", len(serialized), blockStatsSize))
+	}
+
+	offset := 0
+	getUint32 := func() uint32 {
+		v := byteOrder.Uint32(serialized[offset:])
+		offset += 4
+		return v
+	}
+	getInt64 := func() int64 {
+		v := int64(byteOrder.Uint64(serialized[offset:]))
+		offset += 8
+		return v
+	}
+
+	var stats BlockStats
+	stats.Height = getUint32()
+	stats.Time = getInt64()
+	stats.Interval = getInt64()
+	stats.Size = getUint32()
+	stats.NumTxns = getUint32()
+	stats.NumTickets = getUint32()
+	stats.NumVotes = getUint32()
+	stats.NumRevocations = getUint32()
+	stats.NumInputs = getUint32()
+	stats.NumOutputs = getUint32()
+	stats.UtxoIncrease = int32(getUint32())
+	stats.TotalOut = getInt64()
+	stats.TotalStakeOut = getInt64()
+	stats.TotalFee = getInt64()
+	stats.TotalStakeFee = getInt64()
+	stats.MinFeeRate = getInt64()
+	stats.MaxFeeRate = getInt64()
+	stats.AvgFeeRate = getInt64()
+	for i := range stats.FeeRates {
+		stats.FeeRates[i] = getInt64()
+	}
+	stats.PoWSubsidy = getInt64()
+	stats.PoSSubsidy = getInt64()
+	stats.DevSubsidy = getInt64()
+	return &stats, nil
+}
+
+// dbFetchBlockStats uses an existing database transaction to fetch the stats of
+// the block with the provided hash from the index.  It returns nil when there
+// is no entry for the block.
+func dbFetchBlockStats(dbTx database.Tx, hash *chainhash.Hash) (*BlockStats, error) {
+	serialized := dbTx.Metadata().Bucket(blockStatsIndexKey).Get(hash[:])
+	if serialized == nil {
+		return nil, nil
+	}
+	stats, err := deserializeBlockStats(serialized)
+	if err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("failed to deserialize block "+
+				"stats for %s: %v", hash, err),
+		}
+	}
+	return stats, nil
+}
+
+// BlockStatsIndex implements an index of the statistics of all blocks in the
+// main chain by block hash.
+type BlockStatsIndex struct {
+	db           database.DB
+	chainParams  *chaincfg.Params
+	subsidyCache *blockchain.SubsidyCache
+}
+
+// Ensure the BlockStatsIndex type implements the Indexer interface.
+var _ Indexer = (*BlockStatsIndex)(nil)
+
+// Ensure the BlockStatsIndex type implements the IndexDropper interface.
+var _ IndexDropper = (*BlockStatsIndex)(nil)
+
+// Init initializes the block stats index.  This is part of the Indexer
+// interface.
+func (idx *BlockStatsIndex) Init() error {
+	return nil // Nothing to do.
+}
+
+// Key returns the database key to use for the index as a byte slice.  This is
+// part of the Indexer interface.
+func (idx *BlockStatsIndex) Key() []byte {
+	return blockStatsIndexKey
+}
+
+// Name returns the human-readable name of the index.  This is part of the
+// Indexer interface.
+func (idx *BlockStatsIndex) Name() string {
+	return blockStatsIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs to be
+// created for the first time.  It creates the bucket for the index.  This is
+// part of the Indexer interface.
+func (idx *BlockStatsIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(blockStatsIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds the statistics of the block.
+// This is part of the Indexer interface.
+func (idx *BlockStatsIndex) ConnectBlock(dbTx database.Tx, block, parent *cdrutil.Block, view *blockchain.UtxoViewpoint) error {
+	var prevHeader *wire.BlockHeader
+	if parent != nil {
+		prevHeader = &parent.MsgBlock().Header
+	}
+	stats := CalcBlockStats(block, prevHeader, idx.subsidyCache,
+		idx.chainParams)
+	bucket := dbTx.Metadata().Bucket(blockStatsIndexKey)
+	return bucket.Put(block.Hash()[:], serializeBlockStats(stats))
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the statistics of
+// the block.  This is part of the Indexer interface.
+func (idx *BlockStatsIndex) DisconnectBlock(dbTx database.Tx, block, parent *cdrutil.Block, view *blockchain.UtxoViewpoint) error {
+	return dbTx.Metadata().Bucket(blockStatsIndexKey).Delete(block.Hash()[:])
+}
+
+// BlockStats returns the statistics of the block with the provided hash from
+// the index.  It returns nil when the block is not indexed, which is the case
+// for the genesis block, blocks which are not part of the main chain, and
+// blocks the index has not caught up to yet.
+//
+// This function is safe for concurrent access.
+func (idx *BlockStatsIndex) BlockStats(hash *chainhash.Hash) (*BlockStats, error) {
+	var stats *BlockStats
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		stats, err = dbFetchBlockStats(dbTx, hash)
+		return err
+	})
+	return stats, err
+}
+
+// NewBlockStatsIndex returns a new instance of an indexer that is used to
+// create a mapping of the hashes of all blocks in the main chain to their
+// statistics.
+//
+// It implements the Indexer interface which plugs into the IndexManager that
+// in turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewBlockStatsIndex(db database.DB, chainParams *chaincfg.Params) *BlockStatsIndex {
+	return &BlockStatsIndex{
+		db:           db,
+		chainParams:  chainParams,
+		subsidyCache: blockchain.NewSubsidyCache(0, chainParams),
+	}
+}
+
+// DropBlockStatsIndex drops the block stats index from the provided database
+// if it exists.
+func DropBlockStatsIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropFlatIndex(db, blockStatsIndexKey, blockStatsIndexName,
+		interrupt)
+}
+
+// DropIndex drops the block stats index from the provided database if it
+// exists.  This is part of the IndexDropper interface.
+func (*BlockStatsIndex) DropIndex(db database.DB, interrupt <-chan struct{}) error {
+	return DropBlockStatsIndex(db, interrupt)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/commanderu/cdrd/blockchain"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// TestCalcBlockStats ensures the statistics calculated for a block with
+// transactions of known fees are as expected.
+func TestCalcBlockStats(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	pkScript := append(append([]byte{0x76, 0xa9, 0x14}, make([]byte, 20)...),
+		0x88, 0xac)
+
+	// Create a block with a coinbase and four equally-sized transactions
+	// which pay increasing fees.
+	var msgBlock wire.MsgBlock
+	coinbase := wire.NewMsgTx()
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			math.MaxUint32, wire.TxTreeRegular),
+		ValueIn: 5000000,
+	})
+	coinbase.AddTxOut(wire.NewTxOut(5000000, pkScript))
+	msgBlock.AddTransaction(coinbase)
+	fees := []int64{1000, 2000, 3000, 4000}
+	for i, fee := range fees {
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{1},
+				uint32(i), wire.TxTreeRegular),
+			ValueIn: 100000000,
+		})
+		tx.AddTxOut(wire.NewTxOut(100000000-fee, pkScript))
+		msgBlock.AddTransaction(tx)
+	}
+	prevHeader := wire.BlockHeader{Timestamp: time.Unix(1500000000, 0)}
+	msgBlock.Header.Timestamp = time.Unix(1500000300, 0)
+	block := cdrutil.NewBlock(&msgBlock)
+
+	subsidyCache := blockchain.NewSubsidyCache(0, params)
+	stats := CalcBlockStats(block, &prevHeader, subsidyCache, params)
+
+	txSize := int64(msgBlock.Transactions[1].SerializeSize())
+	feeRate := func(fee int64) int64 { return fee * 1000 / txSize }
+	want := &BlockStats{
+		Time:         1500000300,
+		Interval:     300,
+		Size:         uint32(msgBlock.SerializeSize()),
+		NumTxns:      5,
+		NumInputs:    4,
+		NumOutputs:   5,
+		UtxoIncrease: 1,
+		TotalOut:     5000000 + 4*100000000 - 10000,
+		TotalFee:     10000,
+		MinFeeRate:   feeRate(1000),
+		MaxFeeRate:   feeRate(4000),
+		AvgFeeRate:   10000 * 1000 / (4 * txSize),
+		FeeRates: [5]int64{feeRate(1000), feeRate(1000), feeRate(2000),
+			feeRate(3000), feeRate(4000)},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Fatalf("mismatched block stats -- got %+v, want %+v", stats,
+			want)
+	}
+}
+
+// TestBlockStatsSerialization ensures serializing and deserializing block
+// stats works as expected.
+func TestBlockStatsSerialization(t *testing.T) {
+	stats := &BlockStats{
+		Height:         250000,
+		Time:           1500000300,
+		Interval:       -30,
+		Size:           12345,
+		NumTxns:        7,
+		NumTickets:     20,
+		NumVotes:       5,
+		NumRevocations: 1,
+		NumInputs:      40,
+		NumOutputs:     90,
+		UtxoIncrease:   -12,
+		TotalOut:       123456789012,
+		TotalStakeOut:  987654321098,
+		TotalFee:       1740000,
+		TotalStakeFee:  300000,
+		MinFeeRate:     10000,
+		MaxFeeRate:     500000,
+		AvgFeeRate:     10500,
+		FeeRates:       [5]int64{10000, 10000, 10100, 20000, 400000},
+		PoWSubsidy:     1234567890,
+		PoSSubsidy:     246913578,
+		DevSubsidy:     123456789,
+	}
+
+	serialized := serializeBlockStats(stats)
+	if len(serialized) != blockStatsSize {
+		t.Fatalf("unexpected serialized size -- got %d, want %d",
+			len(serialized), blockStatsSize)
+	}
+	gotStats, err := deserializeBlockStats(serialized)
+	if err != nil {
+		t.Fatalf("deserializeBlockStats: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotStats, stats) {
+		t.Fatalf("mismatched block stats -- got %+v, want %+v",
+			gotStats, stats)
+	}
+
+	// Ensure truncated data is rejected.
+	_, err = deserializeBlockStats(serialized[:blockStatsSize-1])
+	if !isDeserializeErr(err) {
+		t.Fatalf("deserializeBlockStats: unexpected error for truncated "+
+			"data: %v", err)
+	}
+}
@@ -53,11 +53,34 @@ var (
 	SpendJournalBucketName = []byte("spendjournal")
 
 	// UtxoSetBucketName is the name of the db bucket used to house the
-	// unspent transaction output set.
-	UtxoSetBucketName = []byte("utxoset")
+	// unspent transaction output set keyed by outpoint.
+	UtxoSetBucketName = []byte("utxosetv2")
+
+	// UtxoSetStateKeyName is the name of the db key used to store the block
+	// the unspent transaction output set was last flushed at.
+	UtxoSetStateKeyName = []byte("utxosetstate")
 
 	// BlockIndexBucketName is the name of the db bucket used to house the
 	// block index which consists of metadata for all known blocks both in
 	// the main chain and on side chains.
 	BlockIndexBucketName = []byte("blockidx")
+
+	// ChainEventLogBucketName is the name of the db bucket used to house the
+	// log of main chain events keyed by their sequence number.
+	ChainEventLogBucketName = []byte("chaineventlog")
+
+	// ChainEventHashIndexBucketName is the name of the db bucket used to
+	// house the index of block hashes to the sequence number of the most
+	// recent event in the chain event log which connected the block.
+	ChainEventHashIndexBucketName = []byte("chaineventhashidx")
+
+	// CFilterBucketName is the name of the db bucket used to house the
+	// verified regular committed filters and filter headers of the main
+	// chain blocks of a headers-only chain keyed by block hash.
+	CFilterBucketName = []byte("hdrcfilters")
+
+	// CFilterTipKeyName is the name of the db key used to store the most
+	// recent main chain block of a headers-only chain whose committed
+	// filter has been verified.
+	CFilterTipKeyName = []byte("hdrcfiltertip")
 )
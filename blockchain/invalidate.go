@@ -0,0 +1,378 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/commanderu/cdrd/blockchain/internal/dbnamespace"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+)
+
+// lookupNodeForStatusChange returns the block node for the provided hash,
+// dynamically loading it from the database when it is part of the main chain
+// but not yet in memory.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) lookupNodeForStatusChange(hash *chainhash.Hash) (*blockNode, error) {
+	if node := b.index.LookupNode(hash); node != nil {
+		return node, nil
+	}
+
+	inMainChain, err := b.MainChainHasBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	if !inMainChain {
+		return nil, fmt.Errorf("block %v is not known", hash)
+	}
+	return b.findNode(hash, 0)
+}
+
+// descendants returns all of the in-memory descendants of the provided node.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func descendants(node *blockNode) []*blockNode {
+	var nodes []*blockNode
+	pending := append([]*blockNode(nil), node.children...)
+	for len(pending) > 0 {
+		n := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		nodes = append(nodes, n)
+		pending = append(pending, n.children...)
+	}
+	return nodes
+}
+
+// nodeStatusUpdate describes a new status of a block node which is applied to
+// the node in memory once it has been persisted to the database.
+type nodeStatusUpdate struct {
+	node   *blockNode
+	status blockStatus
+}
+
+// applyStatusUpdates sets the status of the block nodes in memory to the
+// provided updated status.  It must only be called once the updated status has
+// been persisted so the block index in memory never differs from the database
+// when updating the database fails.
+//
+// This function is safe for concurrent access.
+func (bi *blockIndex) applyStatusUpdates(updates []nodeStatusUpdate) {
+	bi.Lock()
+	for _, u := range updates {
+		u.node.status = u.status
+	}
+	bi.Unlock()
+}
+
+// dbUpdateDescendantsStatus uses an existing database transaction to persist
+// the provided status flags set and unset for all descendants of the provided
+// node to the block index.  Since only part of the block index is kept in
+// memory, the descendants are found by walking the block index in the database
+// in addition to the children of the nodes in memory.  It returns the status
+// updates for the descendants in memory, which the caller must apply once the
+// transaction succeeds, along with the number of descendants.
+//
+// Descendants which are not in memory can't be found from the chain tips, so
+// every block index entry above the height of the node is visited.  The cost is
+// therefore proportional to the number of blocks after the node, which is
+// similar to the number of descendants for blocks in the main chain.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) dbUpdateDescendantsStatus(dbTx database.Tx, node *blockNode, set, unset blockStatus) ([]nodeStatusUpdate, int, error) {
+	// Start with the descendants which are in memory.
+	nodes := descendants(node)
+	known := make(map[chainhash.Hash]struct{}, len(nodes)+1)
+	known[node.hash] = struct{}{}
+	for _, n := range nodes {
+		known[n.hash] = struct{}{}
+	}
+
+	// Find the descendants which are only in the database.  The block index
+	// is keyed by height, so the parent of every entry is visited before
+	// the entry itself.  The entries are updated once the iteration is done
+	// since the bucket must not be modified while iterating.
+	type dbEntry struct {
+		key   []byte
+		entry *blockIndexEntry
+	}
+	var entries []dbEntry
+	var startKey [4]byte
+	binary.BigEndian.PutUint32(startKey[:], uint32(node.height+1))
+	bucket := dbTx.Metadata().Bucket(dbnamespace.BlockIndexBucketName)
+	cursor := bucket.Cursor()
+	for ok := cursor.Seek(startKey[:]); ok; ok = cursor.Next() {
+		entry, err := deserializeBlockIndexEntry(cursor.Value())
+		if err != nil {
+			return nil, 0, err
+		}
+		if _, ok := known[entry.header.PrevBlock]; !ok {
+			continue
+		}
+		hash := entry.header.BlockHash()
+		if _, ok := known[hash]; ok {
+			continue
+		}
+		known[hash] = struct{}{}
+		if n := b.index.LookupNode(&hash); n != nil {
+			nodes = append(nodes, n)
+			continue
+		}
+		key := append([]byte(nil), cursor.Key()...)
+		entries = append(entries, dbEntry{key, entry})
+	}
+
+	// Persist the updated status of the descendants.
+	var updates []nodeStatusUpdate
+	for _, n := range nodes {
+		oldStatus := b.index.NodeStatus(n)
+		status := (oldStatus | set) &^ unset
+		if status == oldStatus {
+			continue
+		}
+		if err := dbPutBlockNodeStatus(dbTx, n, status); err != nil {
+			return nil, 0, err
+		}
+		updates = append(updates, nodeStatusUpdate{n, status})
+	}
+	for _, e := range entries {
+		status := (e.entry.status | set) &^ unset
+		if status == e.entry.status {
+			continue
+		}
+		e.entry.status = status
+		serialized, err := serializeBlockIndexEntry(e.entry)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := bucket.Put(e.key, serialized); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return updates, len(nodes) + len(entries), nil
+}
+
+// isValidChainCandidate returns whether or not the chain ending at the provided
+// node may become the best chain, which is the case when its block data is
+// available and neither it nor any of the blocks back to the main chain are
+// known to be invalid.
+//
+// This function MUST be called with the block index lock held (for reads).
+func isValidChainCandidate(node *blockNode) bool {
+	if !node.inMainChain && !node.status.HaveData() {
+		return false
+	}
+	for n := node; n != nil; n = n.parent {
+		if n.status.KnownInvalid() {
+			return false
+		}
+		if n.inMainChain {
+			break
+		}
+	}
+	return true
+}
+
+// bestValidChainCandidates returns all nodes in the block index that may
+// become the best chain and have more cumulative work than the provided node
+// sorted by descending work.  The provided node is always the final entry.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) bestValidChainCandidates(fallback *blockNode) []*blockNode {
+	var candidates []*blockNode
+	b.index.RLock()
+	for _, node := range b.index.index {
+		if node.workSum.Cmp(fallback.workSum) > 0 &&
+			isValidChainCandidate(node) {
+
+			candidates = append(candidates, node)
+		}
+	}
+	b.index.RUnlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].workSum.Cmp(candidates[j].workSum) > 0
+	})
+	return append(candidates, fallback)
+}
+
+// reorganizeToBestValidChain reorganizes the chain to the valid chain with the
+// most cumulative work, which is the provided fallback node unless a chain with
+// more work is available.  Chains which fail to connect are marked invalid by
+// the reorganization and the next best candidate is tried instead.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) reorganizeToBestValidChain(fallback *blockNode) error {
+	for _, node := range b.bestValidChainCandidates(fallback) {
+		b.index.RLock()
+		valid := isValidChainCandidate(node)
+		b.index.RUnlock()
+		if !valid {
+			continue
+		}
+		if node == b.bestNode {
+			return nil
+		}
+
+		detachNodes, attachNodes, err := b.getReorganizeNodes(node)
+		if err != nil {
+			return err
+		}
+		err = b.reorganizeChain(detachNodes, attachNodes)
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(RuleError); !ok || node == fallback {
+			return err
+		}
+		log.Warnf("Unable to reorganize to block %v (height %d): %v",
+			node.hash, node.height, err)
+	}
+
+	return nil
+}
+
+// invalidateBlock marks the provided node as invalid and all of its
+// descendants as having an invalid ancestor and reorganizes the chain away from
+// it when it is part of the main chain.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) invalidateBlock(node *blockNode) error {
+	if node.hash == *b.chainParams.GenesisHash {
+		return fmt.Errorf("the genesis block can't be invalidated")
+	}
+
+	// Mark the block and all of its descendants invalid.  The updated
+	// status is only applied in memory once it has been persisted to the
+	// block index.
+	var updates []nodeStatusUpdate
+	var numDescendants int
+	err := b.db.Update(func(dbTx database.Tx) error {
+		status := b.index.NodeStatus(node) | statusValidateFailed
+		if err := dbPutBlockNodeStatus(dbTx, node, status); err != nil {
+			return err
+		}
+
+		descendantUpdates, n, err := b.dbUpdateDescendantsStatus(dbTx,
+			node, statusInvalidAncestor, 0)
+		if err != nil {
+			return err
+		}
+		updates = append([]nodeStatusUpdate{{node, status}},
+			descendantUpdates...)
+		numDescendants = n
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	b.index.applyStatusUpdates(updates)
+
+	// There is nothing more to do when the block is on a side chain since
+	// the current best chain remains valid.
+	if !node.inMainChain {
+		return nil
+	}
+
+	// Disconnect the invalid blocks and connect the best remaining valid
+	// chain which is at least the chain ending at the parent of the
+	// invalid block.  Disconnecting blocks also rolls back the stake state
+	// and notifies the caller about the disconnected blocks.
+	parent, err := b.index.PrevNodeFromNode(node)
+	if err != nil {
+		return err
+	}
+	log.Infof("Invalidating block %v (height %d) and %d descendants",
+		node.hash, node.height, numDescendants)
+	return b.reorganizeToBestValidChain(parent)
+}
+
+// InvalidateBlock permanently marks the block identified by the provided hash
+// as invalid along with all of its descendants.  When the block is part of the
+// main chain, the chain is reorganized to the remaining valid chain with the
+// most cumulative work.  The status is stored in the block index, so the block
+// remains invalid until ReconsiderBlock is called for it.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) InvalidateBlock(hash *chainhash.Hash) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node, err := b.lookupNodeForStatusChange(hash)
+	if err != nil {
+		return err
+	}
+	return b.invalidateBlock(node)
+}
+
+// reconsiderBlock removes the invalid status from the provided node, its
+// ancestors back to the main chain, and all of its descendants and then
+// reorganizes to the valid chain with the most cumulative work.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) reconsiderBlock(node *blockNode) error {
+	const invalidFlags = statusValidateFailed | statusInvalidAncestor
+
+	// Clear the invalid status of the block, its descendants and any
+	// ancestors which have to become valid for the block to be connected.
+	// The updated status is only applied in memory once it has been
+	// persisted to the block index.
+	var updates []nodeStatusUpdate
+	err := b.db.Update(func(dbTx database.Tx) error {
+		updates = updates[:0]
+		for n := node; n != nil; n = n.parent {
+			status := b.index.NodeStatus(n)
+			if status&invalidFlags != 0 {
+				status &^= invalidFlags
+				err := dbPutBlockNodeStatus(dbTx, n, status)
+				if err != nil {
+					return err
+				}
+				updates = append(updates, nodeStatusUpdate{n, status})
+			}
+			if n.inMainChain {
+				break
+			}
+		}
+
+		descendantUpdates, _, err := b.dbUpdateDescendantsStatus(dbTx,
+			node, 0, invalidFlags)
+		if err != nil {
+			return err
+		}
+		updates = append(updates, descendantUpdates...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	b.index.applyStatusUpdates(updates)
+
+	// The reconsidered blocks are validated again when they are connected,
+	// which marks them invalid once more should they still fail.
+	log.Infof("Reconsidering block %v (height %d)", node.hash, node.height)
+	return b.reorganizeToBestValidChain(b.bestNode)
+}
+
+// ReconsiderBlock removes the invalid status of the block identified by the
+// provided hash, its ancestors and all of its descendants, which undoes the
+// effects of InvalidateBlock as well as those of previously failed validation,
+// and reorganizes the chain to the valid chain with the most cumulative work.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ReconsiderBlock(hash *chainhash.Hash) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node, err := b.lookupNodeForStatusChange(hash)
+	if err != nil {
+		return err
+	}
+	return b.reconsiderBlock(node)
+}
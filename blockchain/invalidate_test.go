@@ -0,0 +1,218 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/commanderu/cdrd/blockchain/chaingen"
+	"github.com/commanderu/cdrd/blockchain/internal/dbnamespace"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+)
+
+// TestInvalidateReconsiderBlock ensures invalidating and reconsidering blocks
+// reorganizes the chain and persists the block status as expected.
+func TestInvalidateReconsiderBlock(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	g, err := chaingen.MakeGenerator(params)
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+
+	// Create a new database and chain instance to run tests against.
+	chain, teardownFunc, err := chainSetup("invalidateblocktest", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// process processes the named block associated with the generator and
+	// ensures it is accepted with the provided main chain flag.
+	process := func(blockName string, wantMainChain bool) {
+		block := cdrutil.NewBlock(g.BlockByName(blockName))
+		isMainChain, _, err := chain.ProcessBlock(block, BFNone)
+		if err != nil {
+			t.Fatalf("block %q should have been accepted: %v",
+				blockName, err)
+		}
+		if isMainChain != wantMainChain {
+			t.Fatalf("block %q unexpected main chain flag -- got %v, "+
+				"want %v", blockName, isMainChain, wantMainChain)
+		}
+	}
+	expectTip := func(tipName string) {
+		wantTip := g.BlockByName(tipName).BlockHash()
+		if best := chain.BestSnapshot(); best.Hash != wantTip {
+			t.Fatalf("block %q (hash %s) should be the current tip -- "+
+				"got %s", tipName, wantTip, best.Hash)
+		}
+	}
+	blockHash := func(blockName string) *chainhash.Hash {
+		hash := g.BlockByName(blockName).BlockHash()
+		return &hash
+	}
+	expectStoredStatus := func(blockName string, wantInvalid bool) {
+		hash := blockHash(blockName)
+		height := g.BlockByName(blockName).Header.Height
+		err := chain.db.View(func(dbTx database.Tx) error {
+			entry, err := dbFetchBlockIndexEntry(dbTx, hash, height)
+			if err != nil {
+				return err
+			}
+			if got := entry.status.KnownInvalid(); got != wantInvalid {
+				t.Fatalf("block %q unexpected stored invalid status "+
+					"-- got %v, want %v", blockName, got,
+					wantInvalid)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to fetch block index entry for %q: %v",
+				blockName, err)
+		}
+	}
+	expectStatus := func(blockName string, wantInvalid bool) {
+		node := chain.index.LookupNode(blockHash(blockName))
+		if node == nil {
+			t.Fatalf("block %q is not in the block index", blockName)
+		}
+		if got := chain.index.NodeStatus(node).KnownInvalid(); got != wantInvalid {
+			t.Fatalf("block %q unexpected invalid status -- got %v, "+
+				"want %v", blockName, got, wantInvalid)
+		}
+
+		// Ensure the status stored in the block index matches.
+		expectStoredStatus(blockName, wantInvalid)
+	}
+
+	// Generate all blocks up front since the generator is unable to switch
+	// to other branches before stake is enabled:
+	//
+	//   genesis -> bp -> b1 -> b2 -> b3 -> b4
+	//                      \-> c2 -> c3
+	//                      \-> d2
+	g.CreatePremineBlock("bp", 0)
+	g.NextBlock("b1", nil, nil)
+	g.NextBlock("b2", nil, nil)
+	g.NextBlock("b3", nil, nil)
+	g.NextBlock("b4", nil, nil)
+	g.SetTip("b1")
+	g.NextBlock("c2", nil, nil)
+	g.NextBlock("c3", nil, nil)
+	g.SetTip("b1")
+	g.NextBlock("d2", nil, nil)
+
+	process("bp", true)
+	process("b1", true)
+	process("b2", true)
+	process("b3", true)
+	process("c2", false)
+	expectTip("b3")
+
+	// Invalidating b2 must reorganize to the side chain and mark all of
+	// its descendants invalid.
+	if err := chain.InvalidateBlock(blockHash("b2")); err != nil {
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	expectTip("c2")
+	expectStatus("b2", true)
+	expectStatus("b3", true)
+	expectStatus("c2", false)
+
+	// Blocks building on an invalidated block must be rejected.
+	_, _, err = chain.ProcessBlock(cdrutil.NewBlock(g.BlockByName("b4")),
+		BFNone)
+	if rerr, ok := err.(RuleError); !ok ||
+		rerr.ErrorCode != ErrInvalidAncestorBlock {
+
+		t.Fatalf("block \"b4\" unexpected error -- got %v, want %v",
+			err, ErrInvalidAncestorBlock)
+	}
+
+	// Invalidating a block on a side chain must not change the tip.
+	process("c3", true)
+	process("d2", false)
+	if err := chain.InvalidateBlock(blockHash("d2")); err != nil {
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	expectTip("c3")
+	expectStatus("d2", true)
+
+	// Reconsidering b2 must clear the status of it and its descendants,
+	// but the side chain has the same work, so it remains the tip.
+	if err := chain.ReconsiderBlock(blockHash("b2")); err != nil {
+		t.Fatalf("ReconsiderBlock: unexpected error: %v", err)
+	}
+	expectTip("c3")
+	expectStatus("b2", false)
+	expectStatus("b3", false)
+
+	// Invalidating c2 must reorganize back to the chain with b3.
+	if err := chain.InvalidateBlock(blockHash("c2")); err != nil {
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	expectTip("b3")
+	expectStatus("c2", true)
+	expectStatus("c3", true)
+
+	// Reconsidering c3 must also clear the status of its ancestor c2 and
+	// leave the tip alone since it does not have more work.
+	if err := chain.ReconsiderBlock(blockHash("c3")); err != nil {
+		t.Fatalf("ReconsiderBlock: unexpected error: %v", err)
+	}
+	expectTip("b3")
+	expectStatus("c2", false)
+	expectStatus("c3", false)
+
+	// Descendants which are not in memory must also be marked invalid and
+	// reconsidered via the block index in the database.
+	chain.index.RemoveNode(chain.index.LookupNode(blockHash("c3")))
+	if err := chain.InvalidateBlock(blockHash("c2")); err != nil {
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	expectTip("b3")
+	expectStatus("c2", true)
+	expectStoredStatus("c3", true)
+	if err := chain.ReconsiderBlock(blockHash("c2")); err != nil {
+		t.Fatalf("ReconsiderBlock: unexpected error: %v", err)
+	}
+	expectTip("b3")
+	expectStatus("c2", false)
+	expectStoredStatus("c3", false)
+
+	// The status in memory must not change when updating the block index
+	// fails, which is forced by storing a corrupt entry after c2.
+	corruptKey := blockIndexKey(&chainhash.Hash{0x01},
+		g.BlockByName("c3").Header.Height)
+	err = chain.db.Update(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(dbnamespace.BlockIndexBucketName)
+		return bucket.Put(corruptKey, []byte{0x00})
+	})
+	if err != nil {
+		t.Fatalf("failed to store corrupt block index entry: %v", err)
+	}
+	if err := chain.InvalidateBlock(blockHash("c2")); err == nil {
+		t.Fatal("InvalidateBlock: did not fail for a corrupt block index")
+	}
+	expectStatus("c2", false)
+	err = chain.db.Update(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(dbnamespace.BlockIndexBucketName)
+		return bucket.Delete(corruptKey)
+	})
+	if err != nil {
+		t.Fatalf("failed to remove corrupt block index entry: %v", err)
+	}
+
+	// The genesis block and unknown blocks can't be invalidated.
+	if err := chain.InvalidateBlock(params.GenesisHash); err == nil {
+		t.Fatal("InvalidateBlock: did not fail for the genesis block")
+	}
+	if err := chain.InvalidateBlock(&chainhash.Hash{0x01}); err == nil {
+		t.Fatal("InvalidateBlock: did not fail for an unknown block")
+	}
+}
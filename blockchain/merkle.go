@@ -6,10 +6,12 @@
 package blockchain
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/commanderu/cdrd/chaincfg/chainhash"
 	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/wire"
 )
 
 // nextPowerOfTwo returns the next highest power of two from a given number if
@@ -115,3 +117,292 @@ func BuildMerkleTreeStore(transactions []*cdrutil.Tx) []*chainhash.Hash {
 
 	return merkles
 }
+
+// PartialMerkleTree houses a partial merkle tree which proves the inclusion of
+// a subset of the leaves of a full merkle tree without requiring all of them.
+//
+// The hashes are those of the nodes required to calculate the merkle root in
+// depth-first order and the flag bits, which are packed into bytes starting
+// with the least significant bit, describe how the tree is traversed.  A set
+// bit for an internal node means it is the parent of a matched leaf and hence
+// its children are descended into, while a clear bit means its hash is
+// provided instead.  A set bit for a leaf means it is matched.
+//
+// This is the same format used for each transaction tree of the merkleblock
+// wire message.
+type PartialMerkleTree struct {
+	NumLeaves uint32
+	Hashes    []*chainhash.Hash
+	Flags     []byte
+}
+
+// merkleTreeWidth returns the number of nodes at the given height of a merkle
+// tree with the given number of leaves, where the leaves are at height zero.
+func merkleTreeWidth(numLeaves, height uint32) uint32 {
+	return uint32((uint64(numLeaves) + (1 << height) - 1) >> height)
+}
+
+// merkleTreeHeight returns the height of the root of a merkle tree with the
+// given number of leaves.
+func merkleTreeHeight(numLeaves uint32) uint32 {
+	var height uint32
+	for merkleTreeWidth(numLeaves, height) > 1 {
+		height++
+	}
+	return height
+}
+
+// partialMerkleTreeBuilder houses the state used to build a partial merkle
+// tree.
+type partialMerkleTreeBuilder struct {
+	leaves  []*chainhash.Hash
+	matches []bool
+	hashes  []*chainhash.Hash
+	bits    []bool
+}
+
+// calcHash returns the hash of the node at the given height and position.
+func (b *partialMerkleTreeBuilder) calcHash(height, pos uint32) *chainhash.Hash {
+	if height == 0 {
+		return b.leaves[pos]
+	}
+
+	left := b.calcHash(height-1, pos*2)
+	right := left
+	if pos*2+1 < merkleTreeWidth(uint32(len(b.leaves)), height-1) {
+		right = b.calcHash(height-1, pos*2+1)
+	}
+	return HashMerkleBranches(left, right)
+}
+
+// traverseAndBuild builds the partial merkle tree for the node at the given
+// height and position using a recursive depth-first approach.
+func (b *partialMerkleTreeBuilder) traverseAndBuild(height, pos uint32) {
+	// Determine whether this node is the parent of a matched leaf.
+	numLeaves := uint32(len(b.leaves))
+	var isParent bool
+	for i := pos << height; i < (pos+1)<<height && i < numLeaves; i++ {
+		isParent = isParent || b.matches[i]
+	}
+	b.bits = append(b.bits, isParent)
+
+	// The hash of leaves and nodes that are not the parent of a matched
+	// leaf is included in the tree instead of descending into them.
+	if height == 0 || !isParent {
+		b.hashes = append(b.hashes, b.calcHash(height, pos))
+		return
+	}
+
+	b.traverseAndBuild(height-1, pos*2)
+	if pos*2+1 < merkleTreeWidth(numLeaves, height-1) {
+		b.traverseAndBuild(height-1, pos*2+1)
+	}
+}
+
+// NewPartialMerkleTree returns a partial merkle tree for the provided leaves
+// which proves the inclusion of each leaf for which the matches entry at the
+// same index is true.  The number of matches must be the same as the number of
+// leaves.
+func NewPartialMerkleTree(leaves []*chainhash.Hash, matches []bool) *PartialMerkleTree {
+	tree := &PartialMerkleTree{
+		NumLeaves: uint32(len(leaves)),
+		Hashes:    make([]*chainhash.Hash, 0),
+		Flags:     make([]byte, 0),
+	}
+
+	// There is nothing to traverse for an empty tree.
+	if tree.NumLeaves == 0 {
+		return tree
+	}
+
+	b := partialMerkleTreeBuilder{leaves: leaves, matches: matches}
+	b.traverseAndBuild(merkleTreeHeight(tree.NumLeaves), 0)
+
+	tree.Hashes = b.hashes
+	tree.Flags = make([]byte, (len(b.bits)+7)/8)
+	for i, bit := range b.bits {
+		if bit {
+			tree.Flags[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return tree
+}
+
+// partialMerkleTreeExtractor houses the state used to extract the merkle root
+// and matched leaves from a partial merkle tree.
+type partialMerkleTreeExtractor struct {
+	tree           *PartialMerkleTree
+	bitsUsed       uint32
+	hashesUsed     uint32
+	matchedHashes  []*chainhash.Hash
+	matchedIndices []uint32
+}
+
+// traverseAndExtract returns the hash of the node at the given height and
+// position of the partial merkle tree using a recursive depth-first approach
+// while recording the matched leaves.
+func (e *partialMerkleTreeExtractor) traverseAndExtract(height, pos uint32) (*chainhash.Hash, error) {
+	if e.bitsUsed >= uint32(len(e.tree.Flags))*8 {
+		return nil, fmt.Errorf("partial merkle tree overflowed its flag " +
+			"bits")
+	}
+	isParent := e.tree.Flags[e.bitsUsed/8]&(1<<(e.bitsUsed%8)) != 0
+	e.bitsUsed++
+
+	// The hash is provided for leaves and nodes that are not the parent
+	// of a matched leaf.
+	if height == 0 || !isParent {
+		if e.hashesUsed >= uint32(len(e.tree.Hashes)) {
+			return nil, fmt.Errorf("partial merkle tree overflowed " +
+				"its hashes")
+		}
+		hash := e.tree.Hashes[e.hashesUsed]
+		e.hashesUsed++
+		if height == 0 && isParent {
+			e.matchedHashes = append(e.matchedHashes, hash)
+			e.matchedIndices = append(e.matchedIndices, pos)
+		}
+		return hash, nil
+	}
+
+	left, err := e.traverseAndExtract(height-1, pos*2)
+	if err != nil {
+		return nil, err
+	}
+	right := left
+	if pos*2+1 < merkleTreeWidth(e.tree.NumLeaves, height-1) {
+		right, err = e.traverseAndExtract(height-1, pos*2+1)
+		if err != nil {
+			return nil, err
+		}
+
+		// Reject identical left and right nodes since duplicating the
+		// final node of a level allows different leaves to produce the
+		// same merkle root.
+		if *left == *right {
+			return nil, fmt.Errorf("partial merkle tree contains " +
+				"identical sibling nodes")
+		}
+	}
+	return HashMerkleBranches(left, right), nil
+}
+
+// ExtractMatches returns the merkle root the partial merkle tree commits to
+// along with the hashes and indices of the matched leaves.  An error is
+// returned when the tree is malformed.
+//
+// The merkle root of an empty tree is the zero hash to match
+// BuildMerkleTreeStore.
+func (t *PartialMerkleTree) ExtractMatches() (*chainhash.Hash, []*chainhash.Hash, []uint32, error) {
+	if t.NumLeaves == 0 {
+		if len(t.Hashes) != 0 || len(t.Flags) != 0 {
+			return nil, nil, nil, fmt.Errorf("empty partial merkle " +
+				"tree contains hashes or flags")
+		}
+		return &chainhash.Hash{}, nil, nil, nil
+	}
+
+	// There can't be more hashes than leaves or fewer flag bits than
+	// hashes since every hash has a corresponding flag bit.
+	if uint64(len(t.Hashes)) > uint64(t.NumLeaves) {
+		return nil, nil, nil, fmt.Errorf("partial merkle tree contains "+
+			"%d hashes for %d leaves", len(t.Hashes), t.NumLeaves)
+	}
+	if uint64(len(t.Flags))*8 < uint64(len(t.Hashes)) {
+		return nil, nil, nil, fmt.Errorf("partial merkle tree contains "+
+			"%d flag bytes for %d hashes", len(t.Flags), len(t.Hashes))
+	}
+
+	e := partialMerkleTreeExtractor{tree: t}
+	root, err := e.traverseAndExtract(merkleTreeHeight(t.NumLeaves), 0)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Ensure all hashes and flag bytes were consumed.
+	if e.hashesUsed != uint32(len(t.Hashes)) {
+		return nil, nil, nil, fmt.Errorf("partial merkle tree contains "+
+			"%d unused hashes", uint32(len(t.Hashes))-e.hashesUsed)
+	}
+	if (e.bitsUsed+7)/8 != uint32(len(t.Flags)) {
+		return nil, nil, nil, fmt.Errorf("partial merkle tree contains "+
+			"%d unused flag bytes", uint32(len(t.Flags))-(e.bitsUsed+7)/8)
+	}
+
+	return root, e.matchedHashes, e.matchedIndices, nil
+}
+
+// newTxTreePartialMerkleTree returns a partial merkle tree for the provided
+// transactions which make up a transaction tree of a block that proves the
+// inclusion of the transactions for which the provided function returns true.
+// The leaves of the tree are the full transaction hashes, including witness
+// data, since that is what the merkle roots in the block header commit to.
+func newTxTreePartialMerkleTree(txns []*cdrutil.Tx, match func(*cdrutil.Tx) bool) *PartialMerkleTree {
+	leaves := make([]*chainhash.Hash, 0, len(txns))
+	matches := make([]bool, 0, len(txns))
+	for _, tx := range txns {
+		txHashFull := tx.MsgTx().TxHashFull()
+		leaves = append(leaves, &txHashFull)
+		matches = append(matches, match(tx))
+	}
+	return NewPartialMerkleTree(leaves, matches)
+}
+
+// NewMerkleBlock returns a merkleblock message for the provided block which
+// proves the inclusion of the transactions for which the provided function
+// returns true.  Partial merkle trees are created for both the regular and
+// stake transaction trees.  The function is invoked for all transactions of
+// the regular transaction tree before those of the stake transaction tree.
+func NewMerkleBlock(block *cdrutil.Block, match func(*cdrutil.Tx) bool) *wire.MsgMerkleBlock {
+	regular := newTxTreePartialMerkleTree(block.Transactions(), match)
+	stake := newTxTreePartialMerkleTree(block.STransactions(), match)
+
+	return &wire.MsgMerkleBlock{
+		Header:        block.MsgBlock().Header,
+		Transactions:  regular.NumLeaves,
+		Hashes:        regular.Hashes,
+		Flags:         regular.Flags,
+		STransactions: stake.NumLeaves,
+		SHashes:       stake.Hashes,
+		SFlags:        stake.Flags,
+	}
+}
+
+// CheckMerkleBlock ensures the partial merkle trees of the provided merkleblock
+// message commit to the regular and stake transaction tree merkle roots of its
+// header and returns the indices of the matched transactions in each tree.
+func CheckMerkleBlock(msg *wire.MsgMerkleBlock) ([]uint32, []uint32, error) {
+	regular := PartialMerkleTree{
+		NumLeaves: msg.Transactions,
+		Hashes:    msg.Hashes,
+		Flags:     msg.Flags,
+	}
+	root, _, regularIndices, err := regular.ExtractMatches()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !msg.Header.MerkleRoot.IsEqual(root) {
+		str := fmt.Sprintf("regular transaction tree merkle root is "+
+			"%v, but the partial merkle tree commits to %v",
+			msg.Header.MerkleRoot, root)
+		return nil, nil, ruleError(ErrBadMerkleRoot, str)
+	}
+
+	stake := PartialMerkleTree{
+		NumLeaves: msg.STransactions,
+		Hashes:    msg.SHashes,
+		Flags:     msg.SFlags,
+	}
+	root, _, stakeIndices, err := stake.ExtractMatches()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !msg.Header.StakeRoot.IsEqual(root) {
+		str := fmt.Sprintf("stake transaction tree merkle root is %v, "+
+			"but the partial merkle tree commits to %v",
+			msg.Header.StakeRoot, root)
+		return nil, nil, ruleError(ErrBadMerkleRoot, str)
+	}
+
+	return regularIndices, stakeIndices, nil
+}
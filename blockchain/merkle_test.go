@@ -5,7 +5,255 @@
 
 package blockchain
 
+import (
+	"reflect"
+	"testing"
+
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/wire"
+)
+
 // TODO Make tests for merkle root calculation. Merkle root calculation and
 // corruption is already well tested in the blockchain error unit tests and
 // reorganization unit tests, but it'd be nice to have a specific test for
 // these functions and their error paths.
+
+// testMerkleTxns returns the provided number of unique transactions.
+func testMerkleTxns(numTxns int) []*cdrutil.Tx {
+	txns := make([]*cdrutil.Tx, 0, numTxns)
+	for i := 0; i < numTxns; i++ {
+		msgTx := wire.NewMsgTx()
+		msgTx.LockTime = uint32(i)
+		txns = append(txns, cdrutil.NewTx(msgTx))
+	}
+	return txns
+}
+
+// testMerkleBlock returns a block with the provided number of regular and stake
+// transactions and the merkle roots in its header set accordingly.
+func testMerkleBlock(numTxns, numSTxns int) *cdrutil.Block {
+	txns := testMerkleTxns(numTxns + numSTxns)
+	var msgBlock wire.MsgBlock
+	for _, tx := range txns[:numTxns] {
+		msgBlock.AddTransaction(tx.MsgTx())
+	}
+	for _, tx := range txns[numTxns:] {
+		msgBlock.AddSTransaction(tx.MsgTx())
+	}
+
+	block := cdrutil.NewBlock(&msgBlock)
+	merkles := BuildMerkleTreeStore(block.Transactions())
+	msgBlock.Header.MerkleRoot = *merkles[len(merkles)-1]
+	merkles = BuildMerkleTreeStore(block.STransactions())
+	msgBlock.Header.StakeRoot = *merkles[len(merkles)-1]
+	return cdrutil.NewBlock(&msgBlock)
+}
+
+// TestPartialMerkleTree ensures partial merkle trees commit to the same merkle
+// root as the full merkle tree and that the matched leaves are extracted for
+// various tree sizes and match patterns.
+func TestPartialMerkleTree(t *testing.T) {
+	for numLeaves := 0; numLeaves <= 33; numLeaves++ {
+		txns := testMerkleTxns(numLeaves)
+		merkles := BuildMerkleTreeStore(txns)
+		wantRoot := merkles[len(merkles)-1]
+
+		leaves := make([]*chainhash.Hash, 0, numLeaves)
+		for _, tx := range txns {
+			txHashFull := tx.MsgTx().TxHashFull()
+			leaves = append(leaves, &txHashFull)
+		}
+
+		// Test matching no leaves, all leaves, and every leaf whose index
+		// is a multiple of a few different values.
+		for _, modulus := range []int{0, 1, 2, 3, 7, 32} {
+			matches := make([]bool, numLeaves)
+			var wantIndices []uint32
+			var wantHashes []*chainhash.Hash
+			for i := range matches {
+				if modulus != 0 && i%modulus == 0 {
+					matches[i] = true
+					wantIndices = append(wantIndices, uint32(i))
+					wantHashes = append(wantHashes, leaves[i])
+				}
+			}
+
+			tree := NewPartialMerkleTree(leaves, matches)
+			root, hashes, indices, err := tree.ExtractMatches()
+			if err != nil {
+				t.Errorf("leaves %d, modulus %d: unexpected error: %v",
+					numLeaves, modulus, err)
+				continue
+			}
+			if *root != *wantRoot {
+				t.Errorf("leaves %d, modulus %d: mismatched root -- "+
+					"got %v, want %v", numLeaves, modulus, root,
+					wantRoot)
+				continue
+			}
+			if !reflect.DeepEqual(indices, wantIndices) {
+				t.Errorf("leaves %d, modulus %d: mismatched indices "+
+					"-- got %v, want %v", numLeaves, modulus,
+					indices, wantIndices)
+				continue
+			}
+			if !reflect.DeepEqual(hashes, wantHashes) {
+				t.Errorf("leaves %d, modulus %d: mismatched hashes "+
+					"-- got %v, want %v", numLeaves, modulus,
+					hashes, wantHashes)
+				continue
+			}
+		}
+	}
+}
+
+// TestPartialMerkleTreeErrors ensures malformed partial merkle trees are
+// rejected.
+func TestPartialMerkleTreeErrors(t *testing.T) {
+	txns := testMerkleTxns(5)
+	leaves := make([]*chainhash.Hash, 0, len(txns))
+	for _, tx := range txns {
+		txHashFull := tx.MsgTx().TxHashFull()
+		leaves = append(leaves, &txHashFull)
+	}
+	matches := []bool{false, true, false, false, true}
+	valid := NewPartialMerkleTree(leaves, matches)
+
+	// copyTree returns a copy of the valid tree that can be modified.
+	copyTree := func() *PartialMerkleTree {
+		return &PartialMerkleTree{
+			NumLeaves: valid.NumLeaves,
+			Hashes:    append([]*chainhash.Hash(nil), valid.Hashes...),
+			Flags:     append([]byte(nil), valid.Flags...),
+		}
+	}
+
+	tests := []struct {
+		name string
+		tree func() *PartialMerkleTree
+	}{{
+		name: "empty tree with hashes",
+		tree: func() *PartialMerkleTree {
+			return &PartialMerkleTree{Hashes: leaves[:1]}
+		},
+	}, {
+		name: "empty tree with flags",
+		tree: func() *PartialMerkleTree {
+			return &PartialMerkleTree{Flags: []byte{0x01}}
+		},
+	}, {
+		name: "no flags",
+		tree: func() *PartialMerkleTree {
+			tree := copyTree()
+			tree.Flags = nil
+			return tree
+		},
+	}, {
+		name: "more hashes than leaves",
+		tree: func() *PartialMerkleTree {
+			return &PartialMerkleTree{
+				NumLeaves: 1,
+				Hashes:    leaves[:2],
+				Flags:     []byte{0x01},
+			}
+		},
+	}, {
+		name: "missing hash",
+		tree: func() *PartialMerkleTree {
+			tree := copyTree()
+			tree.Hashes = tree.Hashes[:len(tree.Hashes)-1]
+			return tree
+		},
+	}, {
+		name: "unused hash",
+		tree: func() *PartialMerkleTree {
+			tree := copyTree()
+			tree.Hashes = append(tree.Hashes, leaves[0])
+			return tree
+		},
+	}, {
+		name: "unused flag byte",
+		tree: func() *PartialMerkleTree {
+			tree := copyTree()
+			tree.Flags = append(tree.Flags, 0x00)
+			return tree
+		},
+	}, {
+		name: "identical siblings",
+		tree: func() *PartialMerkleTree {
+			dupLeaves := []*chainhash.Hash{leaves[0], leaves[0]}
+			return NewPartialMerkleTree(dupLeaves, []bool{true, true})
+		},
+	}}
+
+	for _, test := range tests {
+		_, _, _, err := test.tree().ExtractMatches()
+		if err == nil {
+			t.Errorf("%s: did not receive expected error", test.name)
+		}
+	}
+}
+
+// TestMerkleBlock ensures merkleblock messages created for a block prove the
+// inclusion of the matched transactions of both transaction trees and that
+// messages which do not commit to the merkle roots of the header are rejected.
+func TestMerkleBlock(t *testing.T) {
+	block := testMerkleBlock(7, 3)
+	regularMatch := block.Transactions()[5].Hash()
+	stakeMatch := block.STransactions()[1].Hash()
+	msg := NewMerkleBlock(block, func(tx *cdrutil.Tx) bool {
+		return *tx.Hash() == *regularMatch || *tx.Hash() == *stakeMatch
+	})
+
+	regularIndices, stakeIndices, err := CheckMerkleBlock(msg)
+	if err != nil {
+		t.Fatalf("CheckMerkleBlock: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(regularIndices, []uint32{5}) {
+		t.Fatalf("mismatched regular indices -- got %v, want [5]",
+			regularIndices)
+	}
+	if !reflect.DeepEqual(stakeIndices, []uint32{1}) {
+		t.Fatalf("mismatched stake indices -- got %v, want [1]",
+			stakeIndices)
+	}
+
+	// Ensure a block without stake transactions is handled.
+	noStakeMsg := NewMerkleBlock(testMerkleBlock(2, 0),
+		func(*cdrutil.Tx) bool { return true })
+	regularIndices, stakeIndices, err = CheckMerkleBlock(noStakeMsg)
+	if err != nil {
+		t.Fatalf("CheckMerkleBlock: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(regularIndices, []uint32{0, 1}) ||
+		len(stakeIndices) != 0 {
+
+		t.Fatalf("mismatched indices -- got %v and %v", regularIndices,
+			stakeIndices)
+	}
+
+	// Ensure a message with a modified merkle root in the header is
+	// rejected for each tree.
+	badMsg := *msg
+	badMsg.Header.MerkleRoot[0] ^= 0x01
+	_, _, err = CheckMerkleBlock(&badMsg)
+	if !isRuleErrorCode(err, ErrBadMerkleRoot) {
+		t.Fatalf("CheckMerkleBlock: unexpected error for bad regular "+
+			"root: %v", err)
+	}
+	badMsg = *msg
+	badMsg.Header.StakeRoot[0] ^= 0x01
+	_, _, err = CheckMerkleBlock(&badMsg)
+	if !isRuleErrorCode(err, ErrBadMerkleRoot) {
+		t.Fatalf("CheckMerkleBlock: unexpected error for bad stake "+
+			"root: %v", err)
+	}
+}
+
+// isRuleErrorCode returns whether or not the provided error is a rule error
+// with the provided error code.
+func isRuleErrorCode(err error, code ErrorCode) bool {
+	rerr, ok := err.(RuleError)
+	return ok && rerr.ErrorCode == code
+}
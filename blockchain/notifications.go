@@ -45,6 +45,9 @@ const (
 	// NTSpentAndMissedTickets indicates newly maturing tickets from a newly
 	// accepted block.
 	NTNewTickets
+
+	// NTChainEvent indicates an event was added to the chain event log.
+	NTChainEvent
 )
 
 // notificationTypeStrings is a map of notification types back to their constant
@@ -56,6 +59,7 @@ var notificationTypeStrings = map[NotificationType]string{
 	NTReorganization:        "NTReorganization",
 	NTSpentAndMissedTickets: "NTSpentAndMissedTickets",
 	NTNewTickets:            "NTNewTickets",
+	NTChainEvent:            "NTChainEvent",
 }
 
 // String returns the NotificationType in human-readable form.
@@ -102,6 +106,7 @@ type TicketNotificationsData struct {
 //  - NTReorganization:        *ReorganizationNtfnsData
 //  - NTSpentAndMissedTickets: *TicketNotificationsData
 //  - NTNewTickets:            *TicketNotificationsData
+//  - NTChainEvent:            *ChainEvent
 type Notification struct {
 	Type NotificationType
 	Data interface{}
@@ -39,6 +39,11 @@ const (
 //
 // This function MUST be called with the chain state lock held (for reads).
 func (b *BlockChain) blockExists(hash *chainhash.Hash) (bool, error) {
+	// Only the headers are available in headers-only mode.
+	if b.headersOnly {
+		return b.headerExists(hash)
+	}
+
 	// Check block index first (could be main chain or side chain blocks).
 	if b.index.HaveBlock(hash) {
 		return true, nil
@@ -143,6 +148,12 @@ func (b *BlockChain) ProcessBlock(block *cdrutil.Block, flags BehaviorFlags) (bo
 	b.chainLock.Lock()
 	defer b.chainLock.Unlock()
 
+	// Full blocks are not accepted by a headers-only chain.
+	if b.headersOnly {
+		return false, false, AssertError("ProcessBlock called on a " +
+			"headers-only chain")
+	}
+
 	fastAdd := flags&BFFastAdd == BFFastAdd
 
 	blockHash := block.Hash()
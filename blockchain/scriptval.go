@@ -32,6 +32,11 @@ type txValidator struct {
 	utxoView     *UtxoViewpoint
 	flags        txscript.ScriptFlags
 	sigCache     *txscript.SigCache
+
+	// batchSigs specifies whether or not the secp256k1 Schnorr signature
+	// checks are deferred to a batch which is verified by each
+	// validation handler once all inputs have been sent.
+	batchSigs bool
 }
 
 // sendResult sends the result of a script pair validation on the internal
@@ -45,77 +50,148 @@ func (v *txValidator) sendResult(result error) {
 	}
 }
 
+// validateItem executes and validates the script pair of the passed input.  The
+// secp256k1 Schnorr signature checks are deferred to the passed batch when it
+// is not nil.
+func (v *txValidator) validateItem(txVI *txValidateItem, batch *txscript.SigBatch) error {
+	// Ensure the referenced input transaction is available.
+	txIn := txVI.txIn
+	originTxHash := &txIn.PreviousOutPoint.Hash
+	originTxIndex := txIn.PreviousOutPoint.Index
+	txEntry := v.utxoView.LookupEntry(originTxHash)
+	if txEntry == nil {
+		str := fmt.Sprintf("unable to find input transaction %v "+
+			"referenced from transaction %v", originTxHash,
+			txVI.tx.Hash())
+		return ruleError(ErrMissingTxOut, str)
+	}
+
+	// Ensure the referenced input transaction public key script is
+	// available.
+	pkScript := txEntry.PkScriptByIndex(originTxIndex)
+	if pkScript == nil {
+		str := fmt.Sprintf("unable to find unspent output %v script "+
+			"referenced from transaction %s:%d",
+			txIn.PreviousOutPoint, txVI.tx.Hash(), txVI.txInIndex)
+		return ruleError(ErrBadTxInput, str)
+	}
+
+	// Create a new script engine for the script pair.
+	sigScript := txIn.SignatureScript
+	version := txEntry.ScriptVersionByIndex(originTxIndex)
+	vm, err := txscript.NewEngine(pkScript, txVI.tx.MsgTx(),
+		txVI.txInIndex, v.flags, version, v.sigCache)
+	if err != nil {
+		str := fmt.Sprintf("failed to parse input %s:%d which "+
+			"references output %s:%d - %v (input script bytes %x, "+
+			"prev output script bytes %x)", txVI.tx.Hash(),
+			txVI.txInIndex, originTxHash, originTxIndex, err,
+			sigScript, pkScript)
+		return ruleError(ErrScriptMalformed, str)
+	}
+	if batch != nil {
+		vm.SetSigBatch(batch)
+	}
+
+	// Execute the script pair.
+	if err := vm.Execute(); err != nil {
+		str := fmt.Sprintf("failed to validate input %s:%d which "+
+			"references output %s:%d - %v (input script bytes %x, "+
+			"prev output script bytes %x)", txVI.tx.Hash(),
+			txVI.txInIndex, originTxHash, originTxIndex, err,
+			sigScript, pkScript)
+		return ruleError(ErrScriptValidation, str)
+	}
+
+	return nil
+}
+
+// batchedItem is an input which deferred signature checks to a batch along with
+// the range of the checks in the batch.
+type batchedItem struct {
+	txVI       *txValidateItem
+	start, end int
+}
+
+// verifyBatch verifies the signature checks deferred to the passed batch by the
+// passed inputs.  When the batch fails, each input with an invalid signature is
+// executed again without a batch to determine whether or not it is actually
+// invalid, since a script may legitimately rely on a signature being invalid.
+func (v *txValidator) verifyBatch(batch *txscript.SigBatch, items []batchedItem) error {
+	if batch.Verify() {
+		return nil
+	}
+
+	for _, item := range items {
+		if batch.VerifyRange(item.start, item.end) {
+			continue
+		}
+		if err := v.validateItem(item.txVI, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // validateHandler consumes items to validate from the internal validate channel
 // and returns the result of the validation on the internal result channel. It
 // must be run as a goroutine.
+//
+// When signature checks are batched, the result of each input only reflects
+// the signatures which are not deferred, so the handler sends one additional
+// result for its batch once the validate channel is closed.
 func (v *txValidator) validateHandler() {
+	var batch *txscript.SigBatch
+	var batchedItems []batchedItem
+	if v.batchSigs {
+		batch = txscript.NewSigBatch()
+	}
+
 out:
 	for {
 		select {
-		case txVI := <-v.validateChan:
-			// Ensure the referenced input transaction is available.
-			txIn := txVI.txIn
-			originTxHash := &txIn.PreviousOutPoint.Hash
-			originTxIndex := txIn.PreviousOutPoint.Index
-			txEntry := v.utxoView.LookupEntry(originTxHash)
-			if txEntry == nil {
-				str := fmt.Sprintf("unable to find input "+
-					"transaction %v referenced from "+
-					"transaction %v", originTxHash,
-					txVI.tx.Hash())
-				err := ruleError(ErrMissingTxOut, str)
-				v.sendResult(err)
+		case txVI, ok := <-v.validateChan:
+			if !ok {
+				if batch != nil {
+					v.sendResult(v.verifyBatch(batch,
+						batchedItems))
+				}
 				break out
 			}
 
-			// Ensure the referenced input transaction public key
-			// script is available.
-			pkScript := txEntry.PkScriptByIndex(originTxIndex)
-			if pkScript == nil {
-				str := fmt.Sprintf("unable to find unspent "+
-					"output %v script referenced from "+
-					"transaction %s:%d",
-					txIn.PreviousOutPoint, txVI.tx.Hash(),
-					txVI.txInIndex)
-				err := ruleError(ErrBadTxInput, str)
+			if batch == nil {
+				err := v.validateItem(txVI, nil)
 				v.sendResult(err)
-				break out
+				if err != nil {
+					break out
+				}
+				continue
 			}
 
-			// Create a new script engine for the script pair.
-			sigScript := txIn.SignatureScript
-			version := txEntry.ScriptVersionByIndex(originTxIndex)
-
-			vm, err := txscript.NewEngine(pkScript, txVI.tx.MsgTx(),
-				txVI.txInIndex, v.flags, version, v.sigCache)
+			// Execution with deferred signature checks treats
+			// them as valid, so a failure might be the result of
+			// a signature the script expects to be invalid.
+			// Discard the deferred checks and execute the input
+			// again without the batch to obtain its actual
+			// result in that case.
+			start := batch.Len()
+			err := v.validateItem(txVI, batch)
+			if err != nil && batch.Len() > start {
+				batch.Truncate(start)
+				err = v.validateItem(txVI, nil)
+			}
+			v.sendResult(err)
 			if err != nil {
-				str := fmt.Sprintf("failed to parse input "+
-					"%s:%d which references output %s:%d - "+
-					"%v (input script bytes %x, prev output "+
-					"script bytes %x)", txVI.tx.Hash(),
-					txVI.txInIndex, originTxHash,
-					originTxIndex, err, sigScript, pkScript)
-				err := ruleError(ErrScriptMalformed, str)
-				v.sendResult(err)
 				break out
 			}
-
-			// Execute the script pair.
-			if err := vm.Execute(); err != nil {
-				str := fmt.Sprintf("failed to validate input "+
-					"%s:%d which references output %s:%d - "+
-					"%v (input script bytes %x, prev output "+
-					"script bytes %x)", txVI.tx.Hash(),
-					txVI.txInIndex, originTxHash,
-					originTxIndex, err, sigScript, pkScript)
-				err := ruleError(ErrScriptValidation, str)
-				v.sendResult(err)
-				break out
+			if batch.Len() > start {
+				batchedItems = append(batchedItems, batchedItem{
+					txVI:  txVI,
+					start: start,
+					end:   batch.Len(),
+				})
 			}
 
-			// Validation succeeded.
-			v.sendResult(nil)
-
 		case <-v.quitChan:
 			break out
 		}
@@ -146,13 +222,22 @@ func (v *txValidator) Validate(items []*txValidateItem) error {
 		go v.validateHandler()
 	}
 
+	// Each handler reports the result of its batch in addition to the
+	// result of each input when signature checks are batched.
+	numInputs := len(items)
+	numResults := numInputs
+	if v.batchSigs {
+		numResults += maxGoRoutines
+	}
+
 	// Validate each of the inputs.  The quit channel is closed when any
 	// errors occur so all processing goroutines exit regardless of which
-	// input had the validation error.
-	numInputs := len(items)
+	// input had the validation error.  The validate channel is closed once
+	// all of the inputs have been sent so the handlers verify their
+	// batches.
 	currentItem := 0
 	processedItems := 0
-	for processedItems < numInputs {
+	for processedItems < numResults {
 		// Only send items while there are still items that need to
 		// be processed.  The select statement will never select a nil
 		// channel.
@@ -166,6 +251,9 @@ func (v *txValidator) Validate(items []*txValidateItem) error {
 		select {
 		case validateChan <- item:
 			currentItem++
+			if currentItem == numInputs {
+				close(v.validateChan)
+			}
 
 		case err := <-v.resultChan:
 			processedItems++
@@ -257,6 +345,9 @@ func checkBlockScripts(block *cdrutil.Block, utxoView *UtxoViewpoint, txTree boo
 		}
 	}
 
-	// Validate all of the inputs.
-	return newTxValidator(utxoView, scriptFlags, sigCache).Validate(txValItems)
+	// Validate all of the inputs while verifying the secp256k1 Schnorr
+	// signatures of the block in batches.
+	validator := newTxValidator(utxoView, scriptFlags, sigCache)
+	validator.batchSigs = true
+	return validator.Validate(txValItems)
 }
@@ -0,0 +1,171 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg/chainec"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/txscript"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// altSigSpend describes an input of a block created by newAltSigBlock.
+type altSigSpend struct {
+	// sigType is the alternative signature type of the input.
+	sigType int
+
+	// invalid specifies whether or not the signature of the input must be
+	// invalid.
+	invalid bool
+
+	// expectInvalid specifies whether or not the output spent by the input
+	// requires an invalid signature.
+	expectInvalid bool
+}
+
+// newAltSigBlock returns a block with a transaction for each of the passed
+// spends along with a view which contains the outputs they spend.  Each
+// output pays to a distinct key of the signature type of its spend.
+func newAltSigBlock(spends []altSigSpend) (*cdrutil.Block, *UtxoViewpoint, error) {
+	type spendKey struct {
+		dsa    chainec.DSA
+		priv   chainec.PrivateKey
+		pubKey []byte
+	}
+	keys := make([]spendKey, len(spends))
+	fundingTx := wire.NewMsgTx()
+	fundingTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil))
+	for i, spend := range spends {
+		scalar := new(big.Int).SetInt64(int64(i + 1)).Bytes()
+		scalar = append(make([]byte, 32-len(scalar)), scalar...)
+		switch spend.sigType {
+		case chainec.ECTypeEdwards:
+			priv, pub := chainec.Edwards.PrivKeyFromScalar(scalar)
+			keys[i] = spendKey{chainec.Edwards, priv, pub.Serialize()}
+		default:
+			priv, pub := chainec.Secp256k1.PrivKeyFromBytes(scalar)
+			keys[i] = spendKey{chainec.SecSchnorr, priv,
+				pub.SerializeCompressed()}
+		}
+
+		builder := txscript.NewScriptBuilder().AddData(keys[i].pubKey).
+			AddInt64(int64(spend.sigType)).AddOp(txscript.OP_CHECKSIGALT)
+		if spend.expectInvalid {
+			builder.AddOp(txscript.OP_NOT)
+		}
+		pkScript, err := builder.Script()
+		if err != nil {
+			return nil, nil, err
+		}
+		fundingTx.AddTxOut(wire.NewTxOut(1000, pkScript))
+	}
+	view := NewUtxoViewpoint()
+	view.AddTxOuts(cdrutil.NewTx(fundingTx), 1, 0)
+
+	var block wire.MsgBlock
+	fundingHash := fundingTx.TxHash()
+	for i, spend := range spends {
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&fundingHash, uint32(i),
+			wire.TxTreeRegular), nil))
+		tx.AddTxOut(wire.NewTxOut(900, []byte{txscript.OP_TRUE}))
+
+		key := &keys[i]
+		pkScript := fundingTx.TxOut[i].PkScript
+		hash, err := txscript.CalcSignatureHash(pkScript,
+			txscript.SigHashAll, tx, 0, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		r, s, err := key.dsa.Sign(key.priv, hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		sig := append(key.dsa.NewSignature(r, s).Serialize(),
+			byte(txscript.SigHashAll))
+		tx.TxIn[0].SignatureScript, err = txscript.NewScriptBuilder().
+			AddData(sig).Script()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Invalidate the signature by changing the transaction after
+		// signing it.
+		if spend.invalid {
+			tx.TxOut[0].Value--
+		}
+		block.AddTransaction(tx)
+	}
+	return cdrutil.NewBlock(&block), view, nil
+}
+
+// TestCheckBlockScriptsBatch ensures block script validation with batched
+// signature checks accepts blocks with valid signatures, identifies the input
+// with an invalid signature, and accepts scripts which require an invalid
+// signature.
+func TestCheckBlockScriptsBatch(t *testing.T) {
+	const ed, sec = chainec.ECTypeEdwards, chainec.ECTypeSecSchnorr
+
+	tests := []struct {
+		name       string
+		spends     []altSigSpend
+		invalidIdx int // index of the input expected to fail, if any
+	}{{
+		name: "all valid",
+		spends: []altSigSpend{{sigType: ed}, {sigType: sec},
+			{sigType: ed}, {sigType: sec}, {sigType: sec}},
+		invalidIdx: -1,
+	}, {
+		name: "invalid ed25519 signature",
+		spends: []altSigSpend{{sigType: ed}, {sigType: sec},
+			{sigType: ed, invalid: true}, {sigType: sec}},
+		invalidIdx: 2,
+	}, {
+		name: "invalid schnorr signature",
+		spends: []altSigSpend{{sigType: ed}, {sigType: sec},
+			{sigType: ed}, {sigType: sec, invalid: true}},
+		invalidIdx: 3,
+	}, {
+		name: "script requires invalid signatures",
+		spends: []altSigSpend{{sigType: ed}, {sigType: sec},
+			{sigType: ed, invalid: true, expectInvalid: true},
+			{sigType: sec, invalid: true, expectInvalid: true}},
+		invalidIdx: -1,
+	}, {
+		name: "script requires invalid signature with valid signature",
+		spends: []altSigSpend{{sigType: ed}, {sigType: sec},
+			{sigType: sec, expectInvalid: true}},
+		invalidIdx: 2,
+	}}
+	for _, test := range tests {
+		block, view, err := newAltSigBlock(test.spends)
+		if err != nil {
+			t.Fatalf("%s: failed to create block: %v", test.name, err)
+		}
+
+		err = checkBlockScripts(block, view, true, 0, nil)
+		if test.invalidIdx == -1 {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(RuleError)
+		if !ok || rerr.ErrorCode != ErrScriptValidation {
+			t.Errorf("%s: unexpected error -- got %v, want %v",
+				test.name, err, ErrScriptValidation)
+			continue
+		}
+		txHash := block.Transactions()[test.invalidIdx].Hash().String()
+		if !strings.Contains(rerr.Description, txHash) {
+			t.Errorf("%s: error does not identify input of %s: %v",
+				test.name, txHash, err)
+		}
+	}
+}
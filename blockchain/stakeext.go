@@ -7,7 +7,6 @@ package blockchain
 
 import (
 	"github.com/commanderu/cdrd/chaincfg/chainhash"
-	"github.com/commanderu/cdrd/database"
 	"github.com/commanderu/cdrd/cdrutil"
 	"github.com/commanderu/cdrd/txscript"
 )
@@ -117,30 +116,24 @@ func (b *BlockChain) TicketsWithAddress(address cdrutil.Address) ([]chainhash.Ha
 
 	tickets := sn.LiveTickets()
 
-	var ticketsWithAddr []chainhash.Hash
-	err := b.db.View(func(dbTx database.Tx) error {
-		for _, hash := range tickets {
-			utxo, err := dbFetchUtxoEntry(dbTx, &hash)
-			if err != nil {
-				return err
-			}
-
-			_, addrs, _, err :=
-				txscript.ExtractPkScriptAddrs(txscript.DefaultScriptVersion,
-					utxo.PkScriptByIndex(0), b.chainParams)
-			if err != nil {
-				return err
-			}
-			if addrs[0].EncodeAddress() == address.EncodeAddress() {
-				ticketsWithAddr = append(ticketsWithAddr, hash)
-			}
-		}
-		return nil
-	})
+	utxos, err := b.utxoCache.fetchEntries(tickets)
 	if err != nil {
 		return nil, err
 	}
 
+	var ticketsWithAddr []chainhash.Hash
+	for i, utxo := range utxos {
+		_, addrs, _, err :=
+			txscript.ExtractPkScriptAddrs(txscript.DefaultScriptVersion,
+				utxo.PkScriptByIndex(0), b.chainParams)
+		if err != nil {
+			return nil, err
+		}
+		if addrs[0].EncodeAddress() == address.EncodeAddress() {
+			ticketsWithAddr = append(ticketsWithAddr, tickets[i])
+		}
+	}
+
 	return ticketsWithAddr, nil
 }
 
@@ -229,20 +222,14 @@ func (b *BlockChain) TicketPoolValue() (cdrutil.Amount, error) {
 	sn := b.bestNode.stakeNode
 	b.chainLock.RUnlock()
 
-	var amt int64
-	err := b.db.View(func(dbTx database.Tx) error {
-		for _, hash := range sn.LiveTickets() {
-			utxo, err := dbFetchUtxoEntry(dbTx, &hash)
-			if err != nil {
-				return err
-			}
-
-			amt += utxo.sparseOutputs[0].amount
-		}
-		return nil
-	})
+	utxos, err := b.utxoCache.fetchEntries(sn.LiveTickets())
 	if err != nil {
 		return 0, err
 	}
+
+	var amt int64
+	for _, utxo := range utxos {
+		amt += utxo.sparseOutputs[0].amount
+	}
 	return cdrutil.Amount(amt), nil
 }
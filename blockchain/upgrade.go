@@ -395,6 +395,365 @@ func upgradeToVersion3(db database.DB, dbInfo *databaseInfo, interrupt <-chan st
 	})
 }
 
+// migrateUtxoSet migrates all entries from the v1 utxo set bucket, which stores
+// an entry with all of the unspent outputs of each transaction keyed by the
+// transaction hash, to the v2 bucket, which stores an entry for each individual
+// unspent output keyed by its outpoint.  Since the v2 utxo set is cached and
+// flushed independently of the best chain state, the block it represents is
+// also stored.
+//
+// The migrated entries are removed from the v1 bucket in the same database
+// transaction they are written to the v2 bucket, so the migration is resumable.
+// The new utxo set is guaranteed to be fully updated if this returns without
+// failure.
+func migrateUtxoSet(db database.DB, interrupt <-chan struct{}) error {
+	// Hardcoded bucket and key names so updates to the global values do not
+	// affect old upgrades.
+	v1BucketName := []byte("utxoset")
+	v2BucketName := []byte("utxosetv2")
+	chainStateKeyName := []byte("chainstate")
+	utxoSetStateKeyName := []byte("utxosetstate")
+
+	log.Info("Migrating the utxo set to be keyed by outpoint.  This will " +
+		"take a while...")
+	start := time.Now()
+
+	// Create the new utxo set bucket as needed.
+	err := db.Update(func(dbTx database.Tx) error {
+		_, err := dbTx.Metadata().CreateBucketIfNotExists(v2BucketName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// doBatch contains the primary logic for upgrading the utxo set in
+	// batches.  This is done because attempting to migrate in a single
+	// database transaction could result in massive memory usage and could
+	// potentially crash on many systems due to ulimits.
+	//
+	// It returns the number of entries processed.
+	const maxEntries = 20000
+	doBatch := func(dbTx database.Tx) (uint32, error) {
+		meta := dbTx.Metadata()
+		v1UtxoBucket := meta.Bucket(v1BucketName)
+		if v1UtxoBucket == nil {
+			return 0, nil
+		}
+
+		v2UtxoBucket := meta.Bucket(v2BucketName)
+		if v2UtxoBucket == nil {
+			return 0, fmt.Errorf("bucket %s does not exist", v2BucketName)
+		}
+
+		// Collect the entries to migrate in this batch since the bucket
+		// can't be modified while iterating it.
+		var migratedKeys [][]byte
+		err := v1UtxoBucket.ForEach(func(hashBytes, serialized []byte) error {
+			if len(migratedKeys) >= maxEntries {
+				return errBatchFinished
+			}
+
+			var txHash chainhash.Hash
+			copy(txHash[:], hashBytes)
+			entry, err := deserializeUtxoEntry(serialized)
+			if err != nil {
+				return err
+			}
+
+			// Write an entry for each unspent output of the transaction
+			// keyed by its outpoint.
+			for outputIndex, output := range entry.sparseOutputs {
+				key := utxoSetKey(&txHash, outputIndex)
+				err := v2UtxoBucket.Put(key, serializeUtxoOutput(entry,
+					output))
+				if err != nil {
+					return err
+				}
+			}
+
+			migratedKeys = append(migratedKeys, txHash[:])
+
+			if interruptRequested(interrupt) {
+				return errInterruptRequested
+			}
+
+			return nil
+		})
+		if err != nil && err != errInterruptRequested &&
+			err != errBatchFinished {
+
+			return 0, err
+		}
+
+		// Remove the migrated entries from the v1 bucket.
+		for _, key := range migratedKeys {
+			if err := v1UtxoBucket.Delete(key); err != nil {
+				return 0, err
+			}
+		}
+		return uint32(len(migratedKeys)), nil
+	}
+
+	// Migrate all entries in batches for the reasons mentioned above.
+	var totalMigrated uint64
+	for {
+		var numMigrated uint32
+		err := db.Update(func(dbTx database.Tx) error {
+			var err error
+			numMigrated, err = doBatch(dbTx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
+		if numMigrated == 0 {
+			break
+		}
+
+		totalMigrated += uint64(numMigrated)
+		log.Infof("Migrated %d entries (%d total)", numMigrated, totalMigrated)
+	}
+
+	// Remove the now empty v1 bucket and mark the new utxo set as up to date
+	// with the best chain state.
+	err = db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		if meta.Bucket(v1BucketName) != nil {
+			if err := meta.DeleteBucket(v1BucketName); err != nil {
+				return err
+			}
+		}
+
+		serializedData := meta.Get(chainStateKeyName)
+		best, err := deserializeBestChainState(serializedData)
+		if err != nil {
+			return err
+		}
+		return meta.Put(utxoSetStateKeyName, serializeUtxoSetState(
+			utxoSetState{hash: best.hash, height: best.height}))
+	})
+	if err != nil {
+		return err
+	}
+
+	seconds := int64(time.Since(start) / time.Second)
+	log.Infof("Done upgrading utxo set.  Total entries: %d in %d seconds",
+		totalMigrated, seconds)
+	return nil
+}
+
+// upgradeToVersion4 upgrades a version 3 blockchain to version 4 which stores
+// the utxo set keyed by outpoint.
+func upgradeToVersion4(db database.DB, dbInfo *databaseInfo, interrupt <-chan struct{}) error {
+	if err := migrateUtxoSet(db, interrupt); err != nil {
+		return err
+	}
+
+	// Update and persist the updated database version.
+	dbInfo.version = 4
+	return db.Update(func(dbTx database.Tx) error {
+		return dbPutDatabaseInfo(dbTx, dbInfo)
+	})
+}
+
+// addBlockIndexChainTxCounts updates every entry in the block index to the
+// version 3 format, which additionally stores the cumulative number of
+// transactions in the chain up to and including the block.  The entries are
+// iterated by height so the count of the parent of each block is always known
+// by the time the block itself is updated.
+//
+// The entries are updated in batches and any counts that were already written
+// by a previously interrupted upgrade are simply recalculated, so the upgrade
+// is resumable.  The block index is guaranteed to be fully updated if this
+// returns without failure.
+func addBlockIndexChainTxCounts(db database.DB, interrupt <-chan struct{}) error {
+	// Hardcoded bucket name so updates to the global values do not affect old
+	// upgrades.
+	bucketName := []byte("blockidx")
+
+	log.Info("Adding transaction counts to the block index.  This will take " +
+		"a while...")
+	start := time.Now()
+
+	// doBatch contains the primary logic for updating the block index entries
+	// in batches.  This is done because attempting to update them in a single
+	// database transaction could result in massive memory usage and could
+	// potentially crash on many systems due to ulimits.
+	//
+	// The counts of the blocks at the current and previous heights are
+	// tracked across batches since they are needed to calculate the counts
+	// of the blocks at the next height.
+	//
+	// It returns the number of entries processed.
+	const maxEntries = 20000
+	var resumeKey []byte
+	var curHeight uint32
+	var prevCounts, curCounts map[chainhash.Hash]uint64
+	doBatch := func(dbTx database.Tx) (uint32, error) {
+		blockIdxBucket := dbTx.Metadata().Bucket(bucketName)
+		if blockIdxBucket == nil {
+			return 0, fmt.Errorf("bucket %s does not exist", bucketName)
+		}
+
+		// Calculate the updated entries so long as the max number of entries
+		// for this batch has not been exceeded.  They are collected and
+		// written afterwards since the bucket can't be modified while
+		// iterating it.
+		var keys, values [][]byte
+		cursor := blockIdxBucket.Cursor()
+		ok := cursor.First()
+		if resumeKey != nil {
+			ok = cursor.Seek(resumeKey)
+			if ok && bytes.Equal(cursor.Key(), resumeKey) {
+				ok = cursor.Next()
+			}
+		}
+		for ; ok && len(keys) < maxEntries; ok = cursor.Next() {
+			if interruptRequested(interrupt) {
+				return 0, errInterruptRequested
+			}
+
+			key := cursor.Key()
+			height := binary.BigEndian.Uint32(key[0:4])
+			var blockHash chainhash.Hash
+			copy(blockHash[:], key[4:])
+
+			// Move on to the next height as needed.
+			if curCounts == nil || height != curHeight {
+				prevCounts = curCounts
+				curCounts = make(map[chainhash.Hash]uint64)
+				curHeight = height
+			}
+
+			var entry blockIndexEntry
+			_, err := decodeBlockIndexEntryV2(cursor.Value(), &entry)
+			if err != nil {
+				return 0, err
+			}
+
+			// Load the block to determine the number of transactions it
+			// contains.
+			blockBytes, err := dbTx.FetchBlock(&blockHash)
+			if err != nil {
+				return 0, err
+			}
+			var block wire.MsgBlock
+			err = block.Deserialize(bytes.NewReader(blockBytes))
+			if err != nil {
+				return 0, err
+			}
+			entry.chainTxCount = blockTxCount(&block)
+			if height > 0 {
+				parentCount, ok := prevCounts[entry.header.PrevBlock]
+				if !ok {
+					return 0, fmt.Errorf("missing block index entry "+
+						"for parent %s of block %s (height %d)",
+						entry.header.PrevBlock, blockHash, height)
+				}
+				entry.chainTxCount += parentCount
+			}
+			curCounts[blockHash] = entry.chainTxCount
+
+			serialized, err := serializeBlockIndexEntry(&entry)
+			if err != nil {
+				return 0, err
+			}
+			keys = append(keys, append([]byte(nil), key...))
+			values = append(values, serialized)
+		}
+
+		// Write the updated entries.
+		for i := range keys {
+			if err := blockIdxBucket.Put(keys[i], values[i]); err != nil {
+				return 0, err
+			}
+		}
+		if len(keys) > 0 {
+			resumeKey = keys[len(keys)-1]
+		}
+		return uint32(len(keys)), nil
+	}
+
+	// Update all entries in batches for the reasons mentioned above.
+	var totalUpdated uint64
+	for {
+		var numUpdated uint32
+		err := db.Update(func(dbTx database.Tx) error {
+			var err error
+			numUpdated, err = doBatch(dbTx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
+		if numUpdated == 0 {
+			break
+		}
+
+		totalUpdated += uint64(numUpdated)
+		log.Infof("Updated %d entries (%d total)", numUpdated, totalUpdated)
+	}
+
+	seconds := int64(time.Since(start) / time.Second)
+	log.Infof("Done updating block index.  Total entries: %d in %d seconds",
+		totalUpdated, seconds)
+	return nil
+}
+
+// upgradeToVersion5 upgrades a version 4 blockchain to version 5 along with
+// upgrading the block index to version 3 which stores the cumulative number of
+// transactions in the chain for each block.
+func upgradeToVersion5(db database.DB, dbInfo *databaseInfo, interrupt <-chan struct{}) error {
+	if err := addBlockIndexChainTxCounts(db, interrupt); err != nil {
+		return err
+	}
+
+	// Update and persist the updated database versions.
+	dbInfo.version = 5
+	dbInfo.bidxVer = 3
+	return db.Update(func(dbTx database.Tx) error {
+		return dbPutDatabaseInfo(dbTx, dbInfo)
+	})
+}
+
+// upgradeToVersion6 upgrades a version 5 blockchain to version 6 which adds
+// the chain event log and its block hash index.  The log starts out empty since
+// the events of the existing blocks are not known.
+func upgradeToVersion6(db database.DB, dbInfo *databaseInfo) error {
+	// Hardcoded bucket names so updates to the global values do not affect
+	// old upgrades.
+	bucketName := []byte("chaineventlog")
+	hashIdxBucketName := []byte("chaineventhashidx")
+
+	log.Info("Adding the chain event log")
+	return db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		_, err := meta.CreateBucketIfNotExists(bucketName)
+		if err != nil {
+			return err
+		}
+		_, err = meta.CreateBucketIfNotExists(hashIdxBucketName)
+		if err != nil {
+			return err
+		}
+
+		// Update and persist the updated database version.
+		dbInfo.version = 6
+		return dbPutDatabaseInfo(dbTx, dbInfo)
+	})
+}
+
 // upgradeDB upgrades old database versions to the newest version by applying
 // all possible upgrades iteratively.
 //
@@ -415,5 +774,26 @@ func upgradeDB(db database.DB, chainParams *chaincfg.Params, dbInfo *databaseInf
 		}
 	}
 
+	// Migrate to the utxo set keyed by outpoint if needed.
+	if dbInfo.version == 3 {
+		if err := upgradeToVersion4(db, dbInfo, interrupt); err != nil {
+			return err
+		}
+	}
+
+	// Add the cumulative transaction counts to the block index if needed.
+	if dbInfo.version == 4 {
+		if err := upgradeToVersion5(db, dbInfo, interrupt); err != nil {
+			return err
+		}
+	}
+
+	// Add the chain event log if needed.
+	if dbInfo.version == 5 {
+		if err := upgradeToVersion6(db, dbInfo); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,190 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/commanderu/cdrd/blockchain/internal/dbnamespace"
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/database"
+)
+
+// TestMigrateUtxoSet ensures a utxo set stored in the legacy per-transaction
+// format is migrated to the per-output format and the new utxo set is marked
+// as up to date with the best chain state.
+func TestMigrateUtxoSet(t *testing.T) {
+	// Create a new database and chain instance to run tests against.
+	params := &chaincfg.SimNetParams
+	chain, teardownFunc, err := chainSetup("migrateutxosettest", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Generate a chain with partially spent transactions and tickets and
+	// write its utxo set to the database.
+	tg := newUtxoTestGenerator(t, chain)
+	tg.generateMatureBlocks()
+	tg.generateSpendBlocks("bs", 5)
+	if err := chain.FlushUtxoCache(); err != nil {
+		t.Fatalf("FlushUtxoCache: unexpected error: %v", err)
+	}
+
+	// Replace the utxo set with the legacy format and remove the utxo set
+	// state to simulate a version 3 database.
+	v1BucketName := []byte("utxoset")
+	var numLegacyEntries int
+	err = chain.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		v1UtxoBucket, err := meta.CreateBucket(v1BucketName)
+		if err != nil {
+			return err
+		}
+		for i := range tg.txHashes {
+			entry, err := dbFetchUtxoEntry(dbTx, &tg.txHashes[i])
+			if err != nil {
+				return err
+			}
+			if entry == nil {
+				continue
+			}
+			serialized, err := serializeUtxoEntry(entry)
+			if err != nil {
+				return err
+			}
+			err = v1UtxoBucket.Put(tg.txHashes[i][:], serialized)
+			if err != nil {
+				return err
+			}
+			numLegacyEntries++
+		}
+
+		if err := meta.DeleteBucket(dbnamespace.UtxoSetBucketName); err != nil {
+			return err
+		}
+		return meta.Delete(dbnamespace.UtxoSetStateKeyName)
+	})
+	if err != nil {
+		t.Fatalf("failed to create legacy utxo set: %v", err)
+	}
+	if numLegacyEntries == 0 {
+		t.Fatal("no legacy utxo entries were created")
+	}
+
+	if err := migrateUtxoSet(chain.db, nil); err != nil {
+		t.Fatalf("migrateUtxoSet: unexpected error: %v", err)
+	}
+
+	// Ensure the legacy bucket was removed and the migrated utxo set matches
+	// the one in the cache.
+	err = chain.db.View(func(dbTx database.Tx) error {
+		if dbTx.Metadata().Bucket(v1BucketName) != nil {
+			return fmt.Errorf("legacy utxo set bucket still exists")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertUtxoSetFlushed(t, chain, tg.txHashes)
+}
+
+// TestAddBlockIndexChainTxCounts ensures the cumulative transaction counts are
+// added to block index entries stored in the version 2 format and that they
+// match the counts of the block nodes.
+func TestAddBlockIndexChainTxCounts(t *testing.T) {
+	// Create a new database and chain instance to run tests against.
+	params := &chaincfg.SimNetParams
+	chain, teardownFunc, err := chainSetup("addchaintxcountstest", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	tg := newUtxoTestGenerator(t, chain)
+	tg.generateMatureBlocks()
+	tg.generateSpendBlocks("bs", 5)
+
+	// Remove the chain tx counts from all block index entries to simulate a
+	// version 4 database.
+	wantCounts := make(map[string]uint64)
+	err = chain.db.Update(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(dbnamespace.BlockIndexBucketName)
+		var keys, values [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			entry, err := deserializeBlockIndexEntry(v)
+			if err != nil {
+				return err
+			}
+			wantCounts[string(k)] = entry.chainTxCount
+			legacySize := len(v) - serializeSizeVLQ(entry.chainTxCount)
+			keys = append(keys, append([]byte(nil), k...))
+			values = append(values, append([]byte(nil), v[:legacySize]...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for i := range keys {
+			if err := bucket.Put(keys[i], values[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to create legacy block index: %v", err)
+	}
+
+	if err := addBlockIndexChainTxCounts(chain.db, nil); err != nil {
+		t.Fatalf("addBlockIndexChainTxCounts: unexpected error: %v", err)
+	}
+
+	// Ensure every entry has the expected count again and the count of the
+	// best block agrees with its block node.
+	best := chain.BestSnapshot()
+	bestNode := chain.index.LookupNode(&best.Hash)
+	err = chain.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(dbnamespace.BlockIndexBucketName)
+		numEntries := 0
+		err := bucket.ForEach(func(k, v []byte) error {
+			numEntries++
+			entry, err := deserializeBlockIndexEntry(v)
+			if err != nil {
+				return err
+			}
+			if entry.chainTxCount != wantCounts[string(k)] {
+				return fmt.Errorf("mismatched chain tx count for "+
+					"entry %x -- got %d, want %d", k,
+					entry.chainTxCount, wantCounts[string(k)])
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if numEntries != len(wantCounts) {
+			return fmt.Errorf("mismatched number of entries -- got %d, "+
+				"want %d", numEntries, len(wantCounts))
+		}
+
+		entry, err := dbFetchBlockIndexEntry(dbTx, &best.Hash,
+			uint32(best.Height))
+		if err != nil {
+			return err
+		}
+		if entry.chainTxCount != bestNode.chainTxCount {
+			return fmt.Errorf("mismatched best chain tx count -- got "+
+				"%d, want %d", entry.chainTxCount,
+				bestNode.chainTxCount)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,545 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/commanderu/cdrd/blockchain/internal/dbnamespace"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+)
+
+const (
+	// DefaultUtxoCacheMaxSize is the default maximum number of bytes the
+	// utxo cache may use before it is flushed to the database and entries
+	// are evicted.
+	DefaultUtxoCacheMaxSize = 150 * 1024 * 1024
+
+	// DefaultUtxoCacheFlushInterval is the default maximum amount of time
+	// that may pass between flushes of the utxo cache to the database.
+	DefaultUtxoCacheFlushInterval = 2 * time.Minute
+
+	// utxoEntryOverhead is the approximate number of bytes used by a cached
+	// utxo entry excluding its outputs and stake extra data.  It accounts
+	// for the map entry, the hash key, and the entry itself.
+	utxoEntryOverhead = 144
+
+	// utxoOutputOverhead is the approximate number of bytes used by a cached
+	// output excluding its public key script.  It accounts for the map
+	// entry and the output itself.
+	utxoOutputOverhead = 72
+
+	// utxoCacheEvictDivisor controls how much of the cache is evicted once
+	// it exceeds its maximum size.  Entries are evicted until the cache
+	// uses at most maxSize - maxSize/utxoCacheEvictDivisor bytes, which
+	// prevents flushing again after every block once the cache is full.
+	utxoCacheEvictDivisor = 10
+)
+
+// utxoCacheKey identifies an individual output in the utxo cache.
+type utxoCacheKey struct {
+	hash  chainhash.Hash
+	index uint32
+}
+
+// utxoCacheUndo houses the cache state replaced by a commit so the commit can
+// be reverted when the database update that depends on it fails.
+type utxoCacheUndo struct {
+	// entries maps the hash of each entry replaced by the commit to the
+	// entry that was cached before it.  A nil entry means there was no
+	// cached entry for the hash.
+	entries map[chainhash.Hash]*UtxoEntry
+
+	// modified contains the outputs that were not marked modified before
+	// the commit.
+	modified []utxoCacheKey
+}
+
+// utxoCache is a size-bounded write-back cache of the utxo set in the database.
+//
+// Entries are loaded from the database on demand and all modifications made
+// when connecting and disconnecting blocks are applied to the cache, which
+// tracks the individual outputs that differ from the database.  The modified
+// outputs are periodically written to the database in a single batch along
+// with the block the utxo set represents, which avoids writing outputs that
+// are created and spent between flushes altogether.
+//
+// Modified entries are never evicted, so any entry that is not in the cache
+// is always up to date in the database.
+type utxoCache struct {
+	db            database.DB
+	maxSize       uint64
+	flushInterval time.Duration
+
+	// mtx protects the following fields since entries are loaded by
+	// callers that only hold the chain lock for reads.
+	mtx       sync.Mutex
+	entries   map[chainhash.Hash]*UtxoEntry
+	modified  map[utxoCacheKey]struct{}
+	totalSize uint64
+	lastFlush time.Time
+}
+
+// newUtxoCache returns a new utxo cache backed by the provided database that
+// uses at most maxSize bytes and is flushed at least every flush interval.
+func newUtxoCache(db database.DB, maxSize uint64, flushInterval time.Duration) *utxoCache {
+	return &utxoCache{
+		db:            db,
+		maxSize:       maxSize,
+		flushInterval: flushInterval,
+		entries:       make(map[chainhash.Hash]*UtxoEntry),
+		modified:      make(map[utxoCacheKey]struct{}),
+		lastFlush:     time.Now(),
+	}
+}
+
+// utxoEntrySize returns the approximate number of bytes the provided entry uses
+// in the cache.
+func utxoEntrySize(entry *UtxoEntry) uint64 {
+	size := uint64(utxoEntryOverhead + len(entry.stakeExtra))
+	for _, output := range entry.sparseOutputs {
+		size += uint64(utxoOutputOverhead + len(output.pkScript))
+	}
+	return size
+}
+
+// setEntry adds or replaces the cached entry for the provided hash.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) setEntry(hash chainhash.Hash, entry *UtxoEntry) {
+	c.removeEntry(hash)
+	c.entries[hash] = entry
+	c.totalSize += utxoEntrySize(entry)
+}
+
+// removeEntry removes the cached entry for the provided hash if it exists.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) removeEntry(hash chainhash.Hash) {
+	if entry, ok := c.entries[hash]; ok {
+		c.totalSize -= utxoEntrySize(entry)
+		delete(c.entries, hash)
+	}
+}
+
+// cloneUnspent returns a deep copy of the provided cached entry without any of
+// its spent outputs, which matches an entry loaded from the database.  It
+// returns nil when the entry does not exist or is fully spent.
+func cloneUnspent(entry *UtxoEntry) *UtxoEntry {
+	if entry == nil || entry.IsFullySpent() {
+		return nil
+	}
+
+	clone := entry.Clone()
+	for outputIndex, output := range clone.sparseOutputs {
+		if output.spent {
+			delete(clone.sparseOutputs, outputIndex)
+		}
+	}
+	return clone
+}
+
+// loadEntries loads the entries for the provided hashes that are not already
+// cached from the database into the cache.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) loadEntries(hashes []chainhash.Hash) error {
+	var missing []chainhash.Hash
+	for _, hash := range hashes {
+		if _, ok := c.entries[hash]; !ok {
+			missing = append(missing, hash)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// Load all of the missing entries with a single cursor.  The hashes are
+	// sorted so the cursor only ever moves forward through the utxo set.
+	sort.Slice(missing, func(i, j int) bool {
+		return bytes.Compare(missing[i][:], missing[j][:]) < 0
+	})
+	return c.db.View(func(dbTx database.Tx) error {
+		utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
+		cursor := utxoBucket.Cursor()
+		for i := range missing {
+			entry, err := dbFetchUtxoEntryWithCursor(cursor, &missing[i])
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				c.setEntry(missing[i], entry)
+			}
+		}
+		return nil
+	})
+}
+
+// fetchEntries returns copies of the entries for the provided hashes, loading
+// them from the database as needed.  The entry for a hash which has no unspent
+// outputs is nil.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) fetchEntries(hashes []chainhash.Hash) ([]*UtxoEntry, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if err := c.loadEntries(hashes); err != nil {
+		return nil, err
+	}
+	entries := make([]*UtxoEntry, len(hashes))
+	for i := range hashes {
+		entries[i] = cloneUnspent(c.entries[hashes[i]])
+	}
+	return entries, nil
+}
+
+// fetchEntry returns a copy of the entry for the provided hash, loading it from
+// the database as needed.  It returns nil when the hash has no unspent outputs.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) fetchEntry(hash *chainhash.Hash) (*UtxoEntry, error) {
+	entries, err := c.fetchEntries([]chainhash.Hash{*hash})
+	if err != nil {
+		return nil, err
+	}
+	return entries[0], nil
+}
+
+// outputModified returns whether or not the database entry for an output needs
+// to be updated when the cached output transitions from the old state to the
+// new one.  A nil old output means its state in the database is unknown.
+func outputModified(oldOutput, newOutput *utxoOutput) bool {
+	if oldOutput == nil || oldOutput.spent != newOutput.spent {
+		return true
+	}
+	return !newOutput.spent && (oldOutput.amount != newOutput.amount ||
+		oldOutput.scriptVersion != newOutput.scriptVersion)
+}
+
+// markModified records the outputs that differ between the old cached entry and
+// the new one for the provided hash.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) markModified(hash chainhash.Hash, oldEntry, newEntry *UtxoEntry) {
+	// Every output is stored along with the details of the transaction, so
+	// all outputs need to be rewritten when they change.
+	headerModified := oldEntry == nil ||
+		oldEntry.txVersion != newEntry.txVersion ||
+		oldEntry.height != newEntry.height ||
+		oldEntry.index != newEntry.index ||
+		oldEntry.txType != newEntry.txType ||
+		oldEntry.isCoinBase != newEntry.isCoinBase ||
+		oldEntry.hasExpiry != newEntry.hasExpiry
+
+	for outputIndex, output := range newEntry.sparseOutputs {
+		var oldOutput *utxoOutput
+		if oldEntry != nil {
+			oldOutput = oldEntry.sparseOutputs[outputIndex]
+		}
+		if headerModified || outputModified(oldOutput, output) {
+			c.modified[utxoCacheKey{hash, outputIndex}] = struct{}{}
+		}
+	}
+
+	// Outputs that were removed from the entry altogether, such as those of
+	// a disconnected transaction, must be removed from the database too.
+	if oldEntry == nil {
+		return
+	}
+	for outputIndex, oldOutput := range oldEntry.sparseOutputs {
+		if _, ok := newEntry.sparseOutputs[outputIndex]; !ok && !oldOutput.spent {
+			c.modified[utxoCacheKey{hash, outputIndex}] = struct{}{}
+		}
+	}
+}
+
+// commit applies all of the entries marked modified in the provided view to the
+// cache.  The view itself is not changed, so the caller is expected to commit
+// the view afterwards.
+//
+// Entries which are not cached are assumed to only contain outputs which
+// supersede the ones in the database unless loadMissing is set, in which case
+// they are loaded from the database first so outputs that no longer exist in
+// the view are removed as well.  This is required when disconnecting blocks
+// since the outputs of disconnected transactions are dropped from the view.
+//
+// The returned undo data may be passed to rollback to revert the commit as long
+// as the cache has not been flushed since.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) commit(view *UtxoViewpoint, loadMissing bool) (*utxoCacheUndo, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if loadMissing {
+		var hashes []chainhash.Hash
+		for txHash, entry := range view.entries {
+			if entry != nil && entry.modified {
+				hashes = append(hashes, txHash)
+			}
+		}
+		if err := c.loadEntries(hashes); err != nil {
+			return nil, err
+		}
+	}
+
+	undo := &utxoCacheUndo{entries: make(map[chainhash.Hash]*UtxoEntry)}
+	for txHash, entry := range view.entries {
+		if entry == nil || !entry.modified {
+			continue
+		}
+
+		// Keep track of the replaced entry and the outputs that are
+		// about to be marked modified for the first time.
+		oldEntry := c.entries[txHash]
+		undo.entries[txHash] = oldEntry
+		for outputIndex := range entry.sparseOutputs {
+			key := utxoCacheKey{txHash, outputIndex}
+			if _, ok := c.modified[key]; !ok {
+				undo.modified = append(undo.modified, key)
+			}
+		}
+		if oldEntry != nil {
+			for outputIndex := range oldEntry.sparseOutputs {
+				_, ok := entry.sparseOutputs[outputIndex]
+				key := utxoCacheKey{txHash, outputIndex}
+				if _, modified := c.modified[key]; !ok && !modified {
+					undo.modified = append(undo.modified, key)
+				}
+			}
+		}
+
+		c.markModified(txHash, oldEntry, entry)
+		c.setEntry(txHash, entry.Clone())
+	}
+
+	return undo, nil
+}
+
+// rollback reverts a commit using the undo data it returned.  It must not be
+// called once the cache has been flushed after the commit.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) rollback(undo *utxoCacheUndo) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for hash, entry := range undo.entries {
+		if entry == nil {
+			c.removeEntry(hash)
+			continue
+		}
+		c.setEntry(hash, entry)
+	}
+	for _, key := range undo.modified {
+		delete(c.modified, key)
+	}
+}
+
+// dbFlush writes all modified outputs in the cache to the database along with
+// the provided block as the block the utxo set represents using the passed
+// database transaction.  The flushed method must be called once the
+// transaction has been committed successfully.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) dbFlush(dbTx database.Tx, hash *chainhash.Hash, height int64) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for key := range c.modified {
+		err := dbPutUtxoOutput(dbTx, &key.hash, key.index,
+			c.entries[key.hash])
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbPutUtxoSetState(dbTx, hash, height)
+}
+
+// flushed marks all outputs in the cache unmodified after they were written to
+// the database by dbFlush, prunes spent outputs, and evicts entries as needed
+// to keep the cache within its maximum size.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) flushed() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	// Spent outputs no longer need to be tracked now that they have been
+	// removed from the database.
+	for key := range c.modified {
+		entry, ok := c.entries[key.hash]
+		if !ok {
+			continue
+		}
+		output, ok := entry.sparseOutputs[key.index]
+		if !ok || !output.spent {
+			continue
+		}
+		c.totalSize -= uint64(utxoOutputOverhead + len(output.pkScript))
+		delete(entry.sparseOutputs, key.index)
+		if len(entry.sparseOutputs) == 0 {
+			c.removeEntry(key.hash)
+		}
+	}
+	c.modified = make(map[utxoCacheKey]struct{})
+	c.lastFlush = time.Now()
+
+	// Evict entries once the cache exceeds its maximum size.  All entries
+	// are unmodified at this point, so any of them may be evicted.
+	if c.totalSize <= c.maxSize {
+		return
+	}
+	target := c.maxSize - c.maxSize/utxoCacheEvictDivisor
+	for hash := range c.entries {
+		if c.totalSize <= target {
+			break
+		}
+		c.removeEntry(hash)
+	}
+}
+
+// flush writes all modified outputs in the cache to the database along with
+// the provided block as the block the utxo set represents.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) flush(hash *chainhash.Hash, height int64) error {
+	c.mtx.Lock()
+	numModified := len(c.modified)
+	c.mtx.Unlock()
+
+	err := c.db.Update(func(dbTx database.Tx) error {
+		return c.dbFlush(dbTx, hash, height)
+	})
+	if err != nil {
+		return err
+	}
+	c.flushed()
+
+	log.Debugf("Flushed %d modified utxos at block %v (height %d)",
+		numModified, hash, height)
+	return nil
+}
+
+// maybeFlush flushes the cache when it exceeds its maximum size or the flush
+// interval has elapsed since it was last flushed.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) maybeFlush(hash *chainhash.Hash, height int64) error {
+	c.mtx.Lock()
+	needsFlush := c.totalSize > c.maxSize ||
+		time.Since(c.lastFlush) >= c.flushInterval
+	c.mtx.Unlock()
+
+	if !needsFlush {
+		return nil
+	}
+	return c.flush(hash, height)
+}
+
+// initUtxoCache brings the utxo set in the database up to date with the best
+// chain by replaying the blocks that were connected after it was last flushed,
+// which is the case when the cache was not flushed before shutdown.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) initUtxoCache(interrupt <-chan struct{}) error {
+	var state *utxoSetState
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		state, err = dbFetchUtxoSetState(dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return AssertError("the utxo set state is missing from the " +
+			"database")
+	}
+
+	tip := b.bestNode
+	if state.hash == tip.hash {
+		return nil
+	}
+
+	// Blocks are never disconnected without flushing the utxo set first, so
+	// it must represent an ancestor of the best chain tip.
+	inMainChain, err := b.MainChainHasBlock(&state.hash)
+	if err != nil {
+		return err
+	}
+	if !inMainChain || int64(state.height) > tip.height {
+		return AssertError(fmt.Sprintf("the utxo set is at block %v "+
+			"(height %d) which is not an ancestor of the best chain "+
+			"tip %v (height %d)", state.hash, state.height, tip.hash,
+			tip.height))
+	}
+
+	log.Infof("Replaying %d blocks to bring the utxo set up to date with "+
+		"the best chain", tip.height-int64(state.height))
+	for height := int64(state.height) + 1; height <= tip.height; height++ {
+		var block, parent *cdrutil.Block
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			block, err = dbFetchBlockByHeight(dbTx, height)
+			if err != nil {
+				return err
+			}
+			parent, err = dbFetchBlockByHeight(dbTx, height-1)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		// The blocks have already been validated, so only the utxos
+		// they spend and create need to be applied.
+		view := NewUtxoViewpoint()
+		err = b.connectTransactions(view, block, parent, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := b.utxoCache.commit(view, false); err != nil {
+			return err
+		}
+
+		if interruptRequested(interrupt) {
+			err := b.utxoCache.flush(block.Hash(), height)
+			if err != nil {
+				return err
+			}
+			return errInterruptRequested
+		}
+		if err := b.utxoCache.maybeFlush(block.Hash(), height); err != nil {
+			return err
+		}
+	}
+
+	return b.utxoCache.flush(&tip.hash, tip.height)
+}
+
+// FlushUtxoCache writes all modified utxos in the utxo cache to the database.
+// It should be called before shutting down to avoid replaying the blocks that
+// were connected since the last flush on the next startup.  There is no utxo
+// set to flush for a headers-only chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FlushUtxoCache() error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if b.headersOnly {
+		return nil
+	}
+	return b.utxoCache.flush(&b.bestNode.hash, b.bestNode.height)
+}
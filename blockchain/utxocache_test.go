@@ -0,0 +1,331 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/commanderu/cdrd/blockchain/chaingen"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+)
+
+// utxoTestGenerator wraps a chain generator and a chain instance to generate
+// and process blocks which spend coinbase outputs and purchase tickets, so the
+// utxo set contains partially spent transactions as well as tickets.
+type utxoTestGenerator struct {
+	t     *testing.T
+	g     *chaingen.Generator
+	chain *BlockChain
+
+	// txHashes houses the hashes of all transactions in all of the
+	// processed blocks.
+	txHashes []chainhash.Hash
+}
+
+// newUtxoTestGenerator returns a test generator for the provided chain.
+func newUtxoTestGenerator(t *testing.T, chain *BlockChain) *utxoTestGenerator {
+	g, err := chaingen.MakeGenerator(chain.chainParams)
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+	return &utxoTestGenerator{t: t, g: &g, chain: chain}
+}
+
+// acceptTip processes the current tip of the generator and ensures it extends
+// the main chain.
+func (tg *utxoTestGenerator) acceptTip() {
+	block := cdrutil.NewBlock(tg.g.Tip())
+	isMainChain, _, err := tg.chain.ProcessBlock(block, BFNone)
+	if err != nil {
+		tg.t.Fatalf("block %q should have been accepted: %v",
+			tg.g.TipName(), err)
+	}
+	if !isMainChain {
+		tg.t.Fatalf("block %q should have extended the main chain",
+			tg.g.TipName())
+	}
+	for _, tx := range block.Transactions() {
+		tg.txHashes = append(tg.txHashes, *tx.Hash())
+	}
+	for _, tx := range block.STransactions() {
+		tg.txHashes = append(tg.txHashes, *tx.Hash())
+	}
+}
+
+// generateMatureBlocks generates and processes the premine block followed by
+// enough blocks to have mature coinbase outputs to spend.
+func (tg *utxoTestGenerator) generateMatureBlocks() {
+	tg.g.CreatePremineBlock("bp", 0)
+	tg.acceptTip()
+	for i := uint16(0); i < tg.chain.chainParams.CoinbaseMaturity; i++ {
+		tg.g.NextBlock(fmt.Sprintf("bm%d", i), nil, nil)
+		tg.g.SaveTipCoinbaseOuts()
+		tg.acceptTip()
+	}
+}
+
+// generateSpendBlocks generates and processes the provided number of blocks
+// which each spend the oldest mature coinbase outputs in a regular transaction
+// and ticket purchases.
+func (tg *utxoTestGenerator) generateSpendBlocks(prefix string, numBlocks int) {
+	for i := 0; i < numBlocks; i++ {
+		outs := tg.g.OldestCoinbaseOuts()
+		tg.g.NextBlock(fmt.Sprintf("%s%d", prefix, i), &outs[0], outs[1:])
+		tg.g.SaveTipCoinbaseOuts()
+		tg.acceptTip()
+	}
+}
+
+// assertUtxoEntriesEqual ensures the provided utxo entries have the same
+// transaction details and unspent outputs.
+func assertUtxoEntriesEqual(t *testing.T, desc string, got, want *UtxoEntry) {
+	t.Helper()
+
+	if got == nil || want == nil {
+		if got != want {
+			t.Fatalf("%s: mismatched entry -- got %v, want %v", desc,
+				got, want)
+		}
+		return
+	}
+	if got.TxVersion() != want.TxVersion() ||
+		got.BlockHeight() != want.BlockHeight() ||
+		got.BlockIndex() != want.BlockIndex() ||
+		got.IsCoinBase() != want.IsCoinBase() ||
+		got.HasExpiry() != want.HasExpiry() ||
+		got.TransactionType() != want.TransactionType() ||
+		!bytes.Equal(got.stakeExtra, want.stakeExtra) {
+
+		t.Fatalf("%s: mismatched entry -- got %+v, want %+v", desc, got,
+			want)
+	}
+
+	var numUnspent int
+	for outputIndex := range want.sparseOutputs {
+		if want.IsOutputSpent(outputIndex) {
+			continue
+		}
+		numUnspent++
+		if got.IsOutputSpent(outputIndex) {
+			t.Fatalf("%s: output %d is spent", desc, outputIndex)
+		}
+		if got.AmountByIndex(outputIndex) != want.AmountByIndex(outputIndex) ||
+			!bytes.Equal(got.PkScriptByIndex(outputIndex),
+				want.PkScriptByIndex(outputIndex)) {
+
+			t.Fatalf("%s: mismatched output %d", desc, outputIndex)
+		}
+	}
+	for outputIndex := range got.sparseOutputs {
+		if !got.IsOutputSpent(outputIndex) {
+			numUnspent--
+		}
+	}
+	if numUnspent != 0 {
+		t.Fatalf("%s: mismatched number of unspent outputs", desc)
+	}
+}
+
+// assertUtxoSetFlushed ensures the utxo set in the database is flushed at the
+// current best block of the provided chain and matches the utxos in its cache
+// for all of the provided transaction hashes.
+func assertUtxoSetFlushed(t *testing.T, chain *BlockChain, txHashes []chainhash.Hash) {
+	t.Helper()
+
+	best := chain.BestSnapshot()
+	err := chain.db.View(func(dbTx database.Tx) error {
+		state, err := dbFetchUtxoSetState(dbTx)
+		if err != nil {
+			return err
+		}
+		if state == nil || state.hash != best.Hash ||
+			int64(state.height) != best.Height {
+
+			t.Fatalf("utxo set state %+v is not at best block %v "+
+				"(height %d)", state, best.Hash, best.Height)
+		}
+
+		for i := range txHashes {
+			dbEntry, err := dbFetchUtxoEntry(dbTx, &txHashes[i])
+			if err != nil {
+				return err
+			}
+			cacheEntry, err := chain.FetchUtxoEntry(&txHashes[i])
+			if err != nil {
+				return err
+			}
+			assertUtxoEntriesEqual(t, fmt.Sprintf("tx %v",
+				txHashes[i]), dbEntry, cacheEntry)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to check utxo set: %v", err)
+	}
+}
+
+// TestUtxoCacheFlushAndReplay ensures the utxo cache writes modified outputs
+// to the database when it is flushed, that blocks connected after the last
+// flush are replayed when the chain is loaded again, and that disconnecting
+// blocks flushes the cache.
+func TestUtxoCacheFlushAndReplay(t *testing.T) {
+	// Create a new database and chain instance to run tests against.
+	params := &chaincfg.SimNetParams
+	chain, teardownFunc, err := chainSetup("utxocachetest", params)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Prevent the cache from being flushed automatically.
+	chain.utxoCache.flushInterval = time.Hour
+
+	tg := newUtxoTestGenerator(t, chain)
+	tg.generateMatureBlocks()
+
+	// Flushing must write all outputs to the database and leave none of
+	// them marked modified.
+	if err := chain.FlushUtxoCache(); err != nil {
+		t.Fatalf("FlushUtxoCache: unexpected error: %v", err)
+	}
+	if n := len(chain.utxoCache.modified); n != 0 {
+		t.Fatalf("unexpected number of modified outputs after flush: %d",
+			n)
+	}
+	assertUtxoSetFlushed(t, chain, tg.txHashes)
+	flushedBest := chain.BestSnapshot()
+
+	// Connect blocks that spend outputs and purchase tickets without
+	// flushing the cache.
+	tg.generateSpendBlocks("bs", 5)
+	if len(chain.utxoCache.modified) == 0 {
+		t.Fatal("no modified outputs after connecting blocks")
+	}
+	err = chain.db.View(func(dbTx database.Tx) error {
+		state, err := dbFetchUtxoSetState(dbTx)
+		if err != nil {
+			return err
+		}
+		if state.hash != flushedBest.Hash {
+			t.Fatalf("utxo set state unexpectedly changed to %v",
+				state.hash)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to fetch utxo set state: %v", err)
+	}
+
+	// Loading the chain again from the same database without flushing must
+	// replay the blocks connected since the last flush.
+	chain2, err := New(&Config{
+		DB:          chain.db,
+		ChainParams: chain.chainParams,
+		TimeSource:  NewMedianTime(),
+	})
+	if err != nil {
+		t.Fatalf("failed to load chain instance: %v", err)
+	}
+	for i := range tg.txHashes {
+		want, err := chain.FetchUtxoEntry(&tg.txHashes[i])
+		if err != nil {
+			t.Fatalf("FetchUtxoEntry: unexpected error: %v", err)
+		}
+		got, err := chain2.FetchUtxoEntry(&tg.txHashes[i])
+		if err != nil {
+			t.Fatalf("FetchUtxoEntry: unexpected error: %v", err)
+		}
+		assertUtxoEntriesEqual(t, fmt.Sprintf("tx %v", tg.txHashes[i]),
+			got, want)
+	}
+	assertUtxoSetFlushed(t, chain2, tg.txHashes)
+
+	// Disconnecting a block must restore the outputs it spent and flush
+	// the utxo set at the new best block.
+	tipHash := chain2.BestSnapshot().Hash
+	tg.chain = chain2
+	tg.generateSpendBlocks("bt", 2)
+	if err := chain2.InvalidateBlock(&tipHash); err != nil {
+		t.Fatalf("InvalidateBlock: unexpected error: %v", err)
+	}
+	if len(chain2.utxoCache.modified) != 0 {
+		t.Fatal("modified outputs remain after disconnecting blocks")
+	}
+	assertUtxoSetFlushed(t, chain2, tg.txHashes)
+}
+
+// TestUtxoCacheRollback ensures reverting a commit to the utxo cache restores
+// the cached entries, the outputs marked modified, and the size of the cache.
+func TestUtxoCacheRollback(t *testing.T) {
+	// Create a new database and chain instance to run tests against.
+	chain, teardownFunc, err := chainSetup("utxocacherollbacktest",
+		&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	tg := newUtxoTestGenerator(t, chain)
+	tg.generateMatureBlocks()
+	if err := chain.FlushUtxoCache(); err != nil {
+		t.Fatalf("FlushUtxoCache: unexpected error: %v", err)
+	}
+	cache := chain.utxoCache
+	hash := tg.txHashes[0]
+	want, err := cache.fetchEntry(&hash)
+	if err != nil {
+		t.Fatalf("fetchEntry: unexpected error: %v", err)
+	}
+	if want == nil {
+		t.Fatalf("no unspent outputs for tx %v", hash)
+	}
+	wantSize := cache.totalSize
+
+	// Commit a view that spends an output of a cached entry and adds an
+	// entry that is not cached.
+	view := NewUtxoViewpoint()
+	entry := want.Clone()
+	for outputIndex := range entry.sparseOutputs {
+		entry.SpendOutput(outputIndex)
+		break
+	}
+	newEntry := want.Clone()
+	newEntry.modified = true
+	newHash := chainhash.Hash{0x01}
+	view.entries[hash] = entry
+	view.entries[newHash] = newEntry
+	undo, err := cache.commit(view, true)
+	if err != nil {
+		t.Fatalf("commit: unexpected error: %v", err)
+	}
+	if len(cache.modified) == 0 {
+		t.Fatal("no modified outputs after commit")
+	}
+
+	// Reverting the commit must leave the cache as it was before.
+	cache.rollback(undo)
+	if n := len(cache.modified); n != 0 {
+		t.Fatalf("unexpected number of modified outputs after rollback: "+
+			"%d", n)
+	}
+	if _, ok := cache.entries[newHash]; ok {
+		t.Fatal("committed entry remains cached after rollback")
+	}
+	got, err := cache.fetchEntry(&hash)
+	if err != nil {
+		t.Fatalf("fetchEntry: unexpected error: %v", err)
+	}
+	assertUtxoEntriesEqual(t, fmt.Sprintf("tx %v", hash), got, want)
+	if cache.totalSize != wantSize {
+		t.Fatalf("unexpected cache size after rollback: got %d, want %d",
+			cache.totalSize, wantSize)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/commanderu/cdrd/blockchain/internal/dbnamespace"
+	"github.com/commanderu/cdrd/blockchain/stake"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// utxoScanProgressInterval is the number of unspent outputs scanned between
+// invocations of the progress callback of a utxo set scan.
+const utxoScanProgressInterval = 10000
+
+// ScannedUtxo describes an unspent transaction output which was matched while
+// scanning the utxo set.
+type ScannedUtxo struct {
+	OutPoint      wire.OutPoint
+	Amount        int64
+	ScriptVersion uint16
+	PkScript      []byte
+	BlockHeight   int64
+	TxType        stake.TxType
+	IsCoinBase    bool
+}
+
+// UtxoScanResult houses the result of scanning the utxo set.  The hash and
+// height identify the block the scanned utxo set is as of.
+type UtxoScanResult struct {
+	Hash       chainhash.Hash
+	Height     int64
+	NumScanned uint64
+	Matches    []ScannedUtxo
+}
+
+// ScanUtxoSet iterates every unspent transaction output in the utxo set once
+// and returns those for which the provided match function returns true.
+//
+// The utxo cache is flushed before the scan begins and the scan is performed
+// against a snapshot of the database, so the result is consistent with the
+// block it reports even though new blocks may be connected while it runs.
+//
+// The progress function, when not nil, is periodically invoked with the
+// estimated fraction of the utxo set that has been scanned.  The estimate is
+// based on the position of the current output within the key space, which is
+// accurate since the entries are keyed by uniformly distributed transaction
+// hashes.
+//
+// The scan is stopped with an error as soon as possible once the interrupt
+// channel is closed.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ScanUtxoSet(match func(scriptVersion uint16, pkScript []byte) bool, progress func(float64), interrupt <-chan struct{}) (*UtxoScanResult, error) {
+	if err := b.FlushUtxoCache(); err != nil {
+		return nil, err
+	}
+
+	var result UtxoScanResult
+	err := b.db.View(func(dbTx database.Tx) error {
+		// The utxo set state identifies the block the utxo set in the
+		// snapshot was last flushed at.
+		state, err := dbFetchUtxoSetState(dbTx)
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			return AssertError("utxo set state does not exist")
+		}
+		result.Hash = state.hash
+		result.Height = int64(state.height)
+
+		utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
+		cursor := utxoBucket.Cursor()
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if len(key) != utxoSetKeySize {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt utxo key: "+
+						"%x", key),
+				}
+			}
+
+			result.NumScanned++
+			if result.NumScanned%utxoScanProgressInterval == 0 {
+				if interruptRequested(interrupt) {
+					return errInterruptRequested
+				}
+				if progress != nil {
+					pos := binary.BigEndian.Uint32(key)
+					progress(float64(pos) / (1 << 32))
+				}
+			}
+
+			entry, output, err := deserializeUtxoOutput(cursor.Value())
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt utxo entry "+
+						"for key %x: %v", key, err),
+				}
+			}
+			output.maybeDecompress(currentCompressionVersion)
+			if !match(output.scriptVersion, output.pkScript) {
+				continue
+			}
+
+			var hash chainhash.Hash
+			copy(hash[:], key[:chainhash.HashSize])
+			tree := wire.TxTreeRegular
+			if entry.txType != stake.TxTypeRegular {
+				tree = wire.TxTreeStake
+			}
+			index := binary.BigEndian.Uint32(key[chainhash.HashSize:])
+			result.Matches = append(result.Matches, ScannedUtxo{
+				OutPoint:      *wire.NewOutPoint(&hash, index, tree),
+				Amount:        output.amount,
+				ScriptVersion: output.scriptVersion,
+				PkScript:      output.pkScript,
+				BlockHeight:   int64(entry.height),
+				TxType:        entry.txType,
+				IsCoinBase:    entry.isCoinBase,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if progress != nil {
+		progress(1)
+	}
+	return &result, nil
+}
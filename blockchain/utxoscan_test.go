@@ -0,0 +1,122 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/commanderu/cdrd/blockchain/stake"
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// TestScanUtxoSet ensures scanning the utxo set returns every unspent output
+// that matches along with the details of the transaction that created it.
+func TestScanUtxoSet(t *testing.T) {
+	// Create a new database and chain instance to run tests against.
+	chain, teardownFunc, err := chainSetup("scanutxosettest",
+		&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	tg := newUtxoTestGenerator(t, chain)
+	tg.generateMatureBlocks()
+	tg.generateSpendBlocks("bs", 5)
+
+	// Count the unspent outputs of all transactions via the utxo cache.
+	var numUnspent uint64
+	for i := range tg.txHashes {
+		entry, err := chain.FetchUtxoEntry(&tg.txHashes[i])
+		if err != nil {
+			t.Fatalf("FetchUtxoEntry: unexpected error: %v", err)
+		}
+		if entry == nil {
+			continue
+		}
+		for outputIndex := range entry.sparseOutputs {
+			if !entry.IsOutputSpent(outputIndex) {
+				numUnspent++
+			}
+		}
+	}
+
+	// Ensure matching everything returns all unspent outputs with details
+	// that agree with the utxo cache.
+	var lastProgress float64
+	result, err := chain.ScanUtxoSet(func(uint16, []byte) bool {
+		return true
+	}, func(progress float64) {
+		lastProgress = progress
+	}, nil)
+	if err != nil {
+		t.Fatalf("ScanUtxoSet: unexpected error: %v", err)
+	}
+	best := chain.BestSnapshot()
+	if result.Hash != best.Hash || result.Height != best.Height {
+		t.Fatalf("mismatched scan block -- got %v (%d), want %v (%d)",
+			result.Hash, result.Height, best.Hash, best.Height)
+	}
+	if result.NumScanned != numUnspent ||
+		uint64(len(result.Matches)) != numUnspent {
+
+		t.Fatalf("mismatched number of unspent outputs -- scanned %d, "+
+			"matched %d, want %d", result.NumScanned,
+			len(result.Matches), numUnspent)
+	}
+	if lastProgress != 1 {
+		t.Fatalf("unexpected final progress %v", lastProgress)
+	}
+	var sawTicket bool
+	for _, utxo := range result.Matches {
+		op := utxo.OutPoint
+		entry, err := chain.FetchUtxoEntry(&op.Hash)
+		if err != nil {
+			t.Fatalf("FetchUtxoEntry: unexpected error: %v", err)
+		}
+		if entry == nil || entry.IsOutputSpent(op.Index) {
+			t.Fatalf("scanned output %v is not unspent", op)
+		}
+		wantTree := wire.TxTreeRegular
+		if entry.TransactionType() != stake.TxTypeRegular {
+			wantTree = wire.TxTreeStake
+		}
+		if utxo.Amount != entry.AmountByIndex(op.Index) ||
+			!bytes.Equal(utxo.PkScript, entry.PkScriptByIndex(op.Index)) ||
+			utxo.BlockHeight != entry.BlockHeight() ||
+			utxo.TxType != entry.TransactionType() ||
+			utxo.IsCoinBase != entry.IsCoinBase() ||
+			op.Tree != wantTree {
+
+			t.Fatalf("mismatched details for scanned output %v", op)
+		}
+		if utxo.TxType == stake.TxTypeSStx {
+			sawTicket = true
+		}
+	}
+	if !sawTicket {
+		t.Fatal("scan did not return any ticket outputs")
+	}
+
+	// Ensure only the outputs with a matching script are returned.
+	wantScript := result.Matches[0].PkScript
+	result, err = chain.ScanUtxoSet(func(_ uint16, pkScript []byte) bool {
+		return bytes.Equal(pkScript, wantScript)
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("ScanUtxoSet: unexpected error: %v", err)
+	}
+	if len(result.Matches) == 0 || result.NumScanned != numUnspent {
+		t.Fatalf("unexpected scan result -- scanned %d, matched %d",
+			result.NumScanned, len(result.Matches))
+	}
+	for _, utxo := range result.Matches {
+		if !bytes.Equal(utxo.PkScript, wantScript) {
+			t.Fatalf("scanned output %v does not match", utxo.OutPoint)
+		}
+	}
+}
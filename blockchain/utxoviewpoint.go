@@ -10,7 +10,6 @@ import (
 
 	"github.com/commanderu/cdrd/blockchain/stake"
 	"github.com/commanderu/cdrd/chaincfg/chainhash"
-	"github.com/commanderu/cdrd/database"
 	"github.com/commanderu/cdrd/cdrutil"
 	"github.com/commanderu/cdrd/txscript"
 )
@@ -465,7 +464,7 @@ func (b *BlockChain) connectTransactions(view *UtxoViewpoint, block *cdrutil.Blo
 
 	if parent != nil && block.Height() != 0 {
 		view.SetStakeViewpoint(ViewpointPrevValidInitial)
-		err := view.fetchInputUtxos(b.db, block, parent)
+		err := view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return err
 		}
@@ -481,7 +480,7 @@ func (b *BlockChain) connectTransactions(view *UtxoViewpoint, block *cdrutil.Blo
 	}
 
 	view.SetStakeViewpoint(thisNodeStakeViewpoint)
-	err := view.fetchInputUtxos(b.db, block, parent)
+	err := view.fetchInputUtxos(b.utxoCache, block, parent)
 	if err != nil {
 		return err
 	}
@@ -524,7 +523,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint, block *cdrutil.
 		thisNodeStakeViewpoint = ViewpointPrevValidStake
 	}
 	view.SetStakeViewpoint(thisNodeStakeViewpoint)
-	err := view.fetchInputUtxos(b.db, block, parent)
+	err := view.fetchInputUtxos(b.utxoCache, block, parent)
 	if err != nil {
 		return err
 	}
@@ -624,7 +623,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint, block *cdrutil.
 		// history in the first place.
 		if regularTxTreeValid {
 			view.SetStakeViewpoint(ViewpointPrevValidInitial)
-			err = view.fetchInputUtxos(b.db, block, parent)
+			err = view.fetchInputUtxos(b.utxoCache, block, parent)
 			if err != nil {
 				return err
 			}
@@ -845,7 +844,7 @@ func (view *UtxoViewpoint) commit() {
 // Upon completion of this function, the view will contain an entry for each
 // requested transaction.  Fully spent transactions, or those which otherwise
 // don't exist, will result in a nil entry in the view.
-func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, txSet map[chainhash.Hash]struct{}) error {
+func (view *UtxoViewpoint) fetchUtxosMain(cache *utxoCache, txSet map[chainhash.Hash]struct{}) error {
 	// Nothing to do if there are no requested hashes.
 	if len(txSet) == 0 {
 		return nil
@@ -859,29 +858,29 @@ func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, txSet map[chainhash.Ha
 	// since other code uses the presence of an entry in the store as a way
 	// to optimize spend and unspend updates to apply only to the specific
 	// utxos that the caller needs access to.
-	return db.View(func(dbTx database.Tx) error {
-		for hash := range txSet {
-			hashCopy := hash
-			// If the UTX already exists in the view, skip adding it.
-			if _, ok := view.entries[hashCopy]; ok {
-				continue
-			}
-			entry, err := dbFetchUtxoEntry(dbTx, &hashCopy)
-			if err != nil {
-				return err
-			}
-
-			view.entries[hash] = entry
+	hashes := make([]chainhash.Hash, 0, len(txSet))
+	for hash := range txSet {
+		// If the UTX already exists in the view, skip adding it.
+		if _, ok := view.entries[hash]; ok {
+			continue
 		}
+		hashes = append(hashes, hash)
+	}
+	entries, err := cache.fetchEntries(hashes)
+	if err != nil {
+		return err
+	}
+	for i := range hashes {
+		view.entries[hashes[i]] = entries[i]
+	}
 
-		return nil
-	})
+	return nil
 }
 
 // fetchUtxos loads utxo details about provided set of transaction hashes into
-// the view from the database as needed unless they already exist in the view in
-// which case they are ignored.
-func (view *UtxoViewpoint) fetchUtxos(db database.DB, txSet map[chainhash.Hash]struct{}) error {
+// the view from the utxo cache as needed unless they already exist in the view
+// in which case they are ignored.
+func (view *UtxoViewpoint) fetchUtxos(cache *utxoCache, txSet map[chainhash.Hash]struct{}) error {
 	// Nothing to do if there are no requested hashes.
 	if len(txSet) == 0 {
 		return nil
@@ -898,15 +897,15 @@ func (view *UtxoViewpoint) fetchUtxos(db database.DB, txSet map[chainhash.Hash]s
 		txNeededSet[hash] = struct{}{}
 	}
 
-	// Request the input utxos from the database.
-	return view.fetchUtxosMain(db, txNeededSet)
+	// Request the input utxos from the utxo cache.
+	return view.fetchUtxosMain(cache, txNeededSet)
 }
 
 // fetchInputUtxos loads utxo details about the input transactions referenced
-// by the transactions in the given block into the view from the database as
+// by the transactions in the given block into the view from the utxo cache as
 // needed.  In particular, referenced entries that are earlier in the block are
 // added to the view and entries that are already in the view are not modified.
-func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block, parent *cdrutil.Block) error {
+func (view *UtxoViewpoint) fetchInputUtxos(cache *utxoCache, block, parent *cdrutil.Block) error {
 	viewpoint := view.StakeViewpoint()
 
 	// Build a map of in-flight transactions because some of the inputs in
@@ -959,8 +958,8 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block, parent *cdruti
 			}
 		}
 
-		// Request the input utxos from the database.
-		return view.fetchUtxosMain(db, txNeededSet)
+		// Request the input utxos from the utxo cache.
+		return view.fetchUtxosMain(cache, txNeededSet)
 	}
 
 	// Case 2+3: ViewpointPrevValidStake and ViewpointPrevInvalidStake.
@@ -999,8 +998,8 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block, parent *cdruti
 			}
 		}
 
-		// Request the input utxos from the database.
-		return view.fetchUtxosMain(db, txNeededSet)
+		// Request the input utxos from the utxo cache.
+		return view.fetchUtxosMain(cache, txNeededSet)
 	}
 
 	// Case 4+5: ViewpointPrevValidRegular and ViewpointPrevInvalidRegular.
@@ -1055,8 +1054,8 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block, parent *cdruti
 			}
 		}
 
-		// Request the input utxos from the database.
-		return view.fetchUtxosMain(db, txNeededSet)
+		// Request the input utxos from the utxo cache.
+		return view.fetchUtxosMain(cache, txNeededSet)
 	}
 
 	// TODO actual blockchain error
@@ -1093,7 +1092,7 @@ func (b *BlockChain) FetchUtxoView(tx *cdrutil.Tx, treeValid bool) (*UtxoViewpoi
 		if err != nil {
 			return nil, err
 		}
-		err = view.fetchInputUtxos(b.db, block, parent)
+		err = view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return nil, err
 		}
@@ -1124,7 +1123,7 @@ func (b *BlockChain) FetchUtxoView(tx *cdrutil.Tx, treeValid bool) (*UtxoViewpoi
 		}
 	}
 
-	err := view.fetchUtxosMain(b.db, txNeededSet)
+	err := view.fetchUtxosMain(b.utxoCache, txNeededSet)
 
 	return view, err
 }
@@ -1143,15 +1142,5 @@ func (b *BlockChain) FetchUtxoEntry(txHash *chainhash.Hash) (*UtxoEntry, error)
 	b.chainLock.RLock()
 	defer b.chainLock.RUnlock()
 
-	var entry *UtxoEntry
-	err := b.db.View(func(dbTx database.Tx) error {
-		var err error
-		entry, err = dbFetchUtxoEntry(dbTx, txHash)
-		return err
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return entry, nil
+	return b.utxoCache.fetchEntry(txHash)
 }
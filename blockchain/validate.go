@@ -849,15 +849,22 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 		// Ensure the stake difficulty specified in the block header
 		// matches the calculated difficulty based on the previous block
 		// and difficulty retarget rules.
-		expSDiff, err := b.calcNextRequiredStakeDifficulty(prevNode)
-		if err != nil {
-			return err
-		}
-		if header.SBits != expSDiff {
-			errStr := fmt.Sprintf("block stake difficulty of %d "+
-				"is not the expected value of %d", header.SBits,
-				expSDiff)
-			return ruleError(ErrUnexpectedDifficulty, errStr)
+		if b.headersOnly {
+			err := b.checkHeadersOnlyStakeDifficulty(header, prevNode)
+			if err != nil {
+				return err
+			}
+		} else {
+			expSDiff, err := b.calcNextRequiredStakeDifficulty(prevNode)
+			if err != nil {
+				return err
+			}
+			if header.SBits != expSDiff {
+				errStr := fmt.Sprintf("block stake difficulty of %d "+
+					"is not the expected value of %d", header.SBits,
+					expSDiff)
+				return ruleError(ErrUnexpectedDifficulty, errStr)
+			}
 		}
 
 		// Ensure the timestamp for the block header is after the
@@ -899,15 +906,24 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 	// chain before it.  This prevents storage of new, otherwise valid,
 	// blocks which build off of old blocks that are likely at a much
 	// easier difficulty and therefore could be used to waste cache and
-	// disk space.
-	checkpointBlock, err := b.findPreviousCheckpoint()
-	if err != nil {
-		return err
+	// disk space.  Only the headers of the checkpoints are available for
+	// a headers-only chain.
+	var checkpointHeight int64
+	if b.headersOnly {
+		checkpointHeight = b.headersOnlyCheckpointHeight()
+	} else {
+		checkpointBlock, err := b.findPreviousCheckpoint()
+		if err != nil {
+			return err
+		}
+		if checkpointBlock != nil {
+			checkpointHeight = checkpointBlock.Height()
+		}
 	}
-	if checkpointBlock != nil && blockHeight < checkpointBlock.Height() {
+	if checkpointHeight > 0 && blockHeight < checkpointHeight {
 		str := fmt.Sprintf("block at height %d forks the main chain "+
 			"before the previous checkpoint at height %d",
-			blockHeight, checkpointBlock.Height())
+			blockHeight, checkpointHeight)
 		return ruleError(ErrForkTooOld, str)
 	}
 
@@ -963,6 +979,12 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 			return ruleError(ErrBlockVersionTooOld, str)
 		}
 
+		// The remaining checks rely on the votes and the ticket database,
+		// neither of which are available for a headers-only chain.
+		if b.headersOnly {
+			return nil
+		}
+
 		// Enforce the stake version in the header once a majority of
 		// the network has upgraded to version 3 blocks.
 		if header.Version >= 3 && b.isMajorityVersion(3, prevNode,
@@ -1195,7 +1217,7 @@ func (b *BlockChain) checkDupTxs(txSet []*cdrutil.Tx, view *UtxoViewpoint) error
 	for _, tx := range txSet {
 		fetchSet[*tx.Hash()] = struct{}{}
 	}
-	err := view.fetchUtxos(b.db, fetchSet)
+	err := view.fetchUtxos(b.utxoCache, fetchSet)
 	if err != nil {
 		return err
 	}
@@ -2344,11 +2366,17 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block, parent *cdrutil.B
 	// will therefore be detected by the next checkpoint).  This is a huge
 	// optimization because running the scripts is the most time consuming
 	// portion of block handling.
+	//
+	// Similarly, don't run scripts for ancestors of the assumed valid block
+	// since its hash commits to them in the same way.
 	checkpoint := b.latestCheckpoint()
 	runScripts := !b.noVerify
 	if checkpoint != nil && node.height <= checkpoint.Height {
 		runScripts = false
 	}
+	if b.isAssumedValid(node) {
+		runScripts = false
+	}
 	var scriptFlags txscript.ScriptFlags
 	if runScripts {
 		var err error
@@ -2373,7 +2401,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block, parent *cdrutil.B
 		thisNodeRegularViewpoint = ViewpointPrevValidRegular
 
 		utxoView.SetStakeViewpoint(ViewpointPrevValidInitial)
-		err = utxoView.fetchInputUtxos(b.db, block, parent)
+		err = utxoView.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return err
 		}
@@ -2395,7 +2423,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block, parent *cdrutil.B
 		return err
 	}
 
-	err = utxoView.fetchInputUtxos(b.db, block, parent)
+	err = utxoView.fetchInputUtxos(b.utxoCache, block, parent)
 	if err != nil {
 		return err
 	}
@@ -2470,7 +2498,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block, parent *cdrutil.B
 		return err
 	}
 
-	err = utxoView.fetchInputUtxos(b.db, block, parent)
+	err = utxoView.fetchInputUtxos(b.utxoCache, block, parent)
 	if err != nil {
 		return err
 	}
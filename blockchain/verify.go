@@ -0,0 +1,706 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/commanderu/cdrd/blockchain/internal/dbnamespace"
+	"github.com/commanderu/cdrd/blockchain/stake"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+)
+
+const (
+	// VerifyLevelLookup only ensures each block can be loaded from the
+	// database.
+	VerifyLevelLookup = 0
+
+	// VerifyLevelSanity additionally performs context-free sanity checks on
+	// each block.
+	VerifyLevelSanity = 1
+
+	// VerifyLevelSpendJournal additionally loads the spend journal entry of
+	// each block and uses it to disconnect the block in a scratch utxo view.
+	VerifyLevelSpendJournal = 2
+
+	// VerifyLevelTickets additionally ensures the ticket database matches
+	// the ticket treaps recomputed from the blocks, both at the end of the
+	// chain and after undoing each block.
+	VerifyLevelTickets = 3
+
+	// VerifyLevelReconnect additionally reconnects the disconnected blocks
+	// to the scratch utxo view with full validation, including scripts,
+	// ensures the spent outputs they generate match the spend journal, and
+	// ensures the resulting utxos match the utxo set.
+	VerifyLevelReconnect = 4
+
+	// VerifyLevelUtxoValue additionally ensures the total value of the utxo
+	// set does not exceed the total coin supply.
+	VerifyLevelUtxoValue = 5
+
+	// maxVerifyJournalDepth is the maximum number of blocks that are
+	// verified at VerifyLevelSpendJournal and above.  The blocks are all
+	// disconnected in a single scratch utxo view, so its size grows with
+	// the number of blocks.
+	maxVerifyJournalDepth = 2880
+)
+
+// errVerifyChainChanged is returned by VerifyChain when the main chain changes
+// while it is being verified.
+var errVerifyChainChanged = errors.New("the main chain changed while it " +
+	"was being verified")
+
+// ChainInconsistency describes an inconsistency which was found while verifying
+// the chain.  The hash and height identify the block the inconsistency relates
+// to.
+type ChainInconsistency struct {
+	Hash        chainhash.Hash
+	Height      int64
+	Description string
+}
+
+// VerifyChainResult houses the result of verifying the chain.  The level is the
+// level that was actually used after clamping the requested one to the
+// supported levels.  The utxo set value and coin supply are only set when the
+// level is at least VerifyLevelUtxoValue.
+type VerifyChainResult struct {
+	Level           int64
+	NumBlocks       int64
+	UtxoSetValue    int64
+	CoinSupply      int64
+	Inconsistencies []ChainInconsistency
+}
+
+// chainVerifier houses the state used while verifying the blocks at the end of
+// the main chain.  The nodes and blocks are in order of descending height and
+// the final entry is the parent of the earliest block being verified, which is
+// only needed to disconnect and reconnect it.  The blocks are only kept when
+// the verification level requires them after they are loaded, which limits the
+// number of blocks to maxVerifyJournalDepth.  The block is nil for any blocks
+// that could not be loaded.
+type chainVerifier struct {
+	b         *BlockChain
+	nodes     []*blockNode
+	blocks    []*cdrutil.Block
+	interrupt <-chan struct{}
+	result    *VerifyChainResult
+}
+
+// lock acquires the chain state lock for writes and ensures the best block is
+// still the one being verified, since the lock is only held for one block or
+// step of the verification at a time so block processing is not stalled while
+// it is running.  The lock is not held when an error is returned.
+func (v *chainVerifier) lock() error {
+	v.b.chainLock.Lock()
+	if v.b.bestNode != v.nodes[0] {
+		v.b.chainLock.Unlock()
+		return errVerifyChainChanged
+	}
+	return nil
+}
+
+// unlock releases the chain state lock acquired by lock.
+func (v *chainVerifier) unlock() {
+	v.b.chainLock.Unlock()
+}
+
+// addInconsistency records an inconsistency for the provided node.
+func (v *chainVerifier) addInconsistency(node *blockNode, format string, args ...interface{}) {
+	desc := fmt.Sprintf(format, args...)
+	log.Errorf("Verify found inconsistency in block %v (height %d): %s",
+		node.hash, node.height, desc)
+	v.result.Inconsistencies = append(v.result.Inconsistencies,
+		ChainInconsistency{
+			Hash:        node.hash,
+			Height:      node.height,
+			Description: desc,
+		})
+}
+
+// loadBlocks loads each block being verified along with the parent of the
+// earliest one and, when requested, performs context-free sanity checks on
+// them.  The blocks are only kept in memory for the later checks when requested
+// so the lower levels are able to stream the entire chain.
+func (v *chainVerifier) loadBlocks(checkSanity, keepBlocks bool) error {
+	b := v.b
+	if keepBlocks {
+		v.blocks = make([]*cdrutil.Block, len(v.nodes))
+	}
+	for i, node := range v.nodes {
+		if interruptRequested(v.interrupt) {
+			return errInterruptRequested
+		}
+
+		if err := v.lock(); err != nil {
+			return err
+		}
+		block, err := b.fetchMainChainBlockByHash(&node.hash)
+		v.unlock()
+		if err != nil {
+			v.addInconsistency(node, "unable to load block: %v", err)
+			continue
+		}
+		if block.Height() != node.height {
+			v.addInconsistency(node, "block commits to height %d",
+				block.Height())
+			continue
+		}
+		if keepBlocks {
+			v.blocks[i] = block
+		}
+
+		// The parent of the earliest block is not being verified.
+		if !checkSanity || i == len(v.nodes)-1 {
+			continue
+		}
+		err = checkBlockSanity(block, b.timeSource, BFNone, b.chainParams)
+		if err != nil {
+			v.addInconsistency(node, "block failed sanity checks: %v",
+				err)
+		}
+	}
+	return nil
+}
+
+// disconnectBlocks disconnects the blocks being verified from a new utxo view
+// using their spend journal entries and returns the view along with the entries
+// for each block that was disconnected.  Since the view can't be restored to
+// the state before a block once disconnecting it fails, the process stops at
+// the first failure.
+func (v *chainVerifier) disconnectBlocks() (*UtxoViewpoint, [][]spentTxOut, error) {
+	b := v.b
+	view := NewUtxoViewpoint()
+	view.SetBestHash(&v.nodes[0].hash)
+	view.SetStakeViewpoint(ViewpointPrevValidInitial)
+	var journal [][]spentTxOut
+	for i := 0; i < len(v.nodes)-1; i++ {
+		if interruptRequested(v.interrupt) {
+			return nil, nil, errInterruptRequested
+		}
+
+		node, block, parent := v.nodes[i], v.blocks[i], v.blocks[i+1]
+		if block == nil || parent == nil {
+			v.addInconsistency(node, "unable to disconnect block without "+
+				"it and its parent")
+			break
+		}
+
+		if err := v.lock(); err != nil {
+			return nil, nil, err
+		}
+		var stxos []spentTxOut
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			stxos, err = dbFetchSpendJournalEntry(dbTx, block, parent)
+			return err
+		})
+		if err != nil {
+			v.unlock()
+			v.addInconsistency(node, "unable to load spend journal "+
+				"entry: %v", err)
+			break
+		}
+		if len(stxos) != countSpentOutputs(block, parent) {
+			v.unlock()
+			v.addInconsistency(node, "spend journal entry has %d spent "+
+				"outputs instead of %d", len(stxos),
+				countSpentOutputs(block, parent))
+			break
+		}
+
+		err = b.disconnectTransactions(view, block, parent, stxos)
+		v.unlock()
+		if err != nil {
+			v.addInconsistency(node, "unable to disconnect block: %v",
+				err)
+			break
+		}
+		journal = append(journal, stxos)
+	}
+	return view, journal, nil
+}
+
+// stxoScript returns the uncompressed public key script of the provided spent
+// output.
+func stxoScript(stxo *spentTxOut) []byte {
+	if stxo.compressed {
+		return decompressScript(stxo.pkScript, currentCompressionVersion)
+	}
+	return stxo.pkScript
+}
+
+// stxosEqual returns whether or not the provided spent outputs describe the
+// same output.  The details of the containing transaction are only compared
+// when it was fully spent since they are not available otherwise.
+func stxosEqual(a, b *spentTxOut) bool {
+	if a.amount != b.amount || a.scriptVersion != b.scriptVersion ||
+		a.txFullySpent != b.txFullySpent ||
+		!bytes.Equal(stxoScript(a), stxoScript(b)) {
+
+		return false
+	}
+	if !a.txFullySpent {
+		return true
+	}
+	return a.isCoinBase == b.isCoinBase && a.hasExpiry == b.hasExpiry &&
+		a.txType == b.txType && a.txVersion == b.txVersion &&
+		bytes.Equal(a.stakeExtra, b.stakeExtra)
+}
+
+// utxoEntriesEqual returns whether or not the provided utxo entries have the
+// same transaction details and unspent outputs.  Nil and fully spent entries
+// are considered equal.
+func utxoEntriesEqual(a, b *UtxoEntry) bool {
+	aSpent := a == nil || a.IsFullySpent()
+	bSpent := b == nil || b.IsFullySpent()
+	if aSpent || bSpent {
+		return aSpent == bSpent
+	}
+	if a.TxVersion() != b.TxVersion() || a.BlockHeight() != b.BlockHeight() ||
+		a.BlockIndex() != b.BlockIndex() || a.IsCoinBase() != b.IsCoinBase() ||
+		a.HasExpiry() != b.HasExpiry() ||
+		a.TransactionType() != b.TransactionType() {
+
+		return false
+	}
+
+	numUnspent := 0
+	for outputIndex := range a.sparseOutputs {
+		if a.IsOutputSpent(outputIndex) {
+			continue
+		}
+		numUnspent++
+		if b.IsOutputSpent(outputIndex) ||
+			a.AmountByIndex(outputIndex) != b.AmountByIndex(outputIndex) ||
+			a.ScriptVersionByIndex(outputIndex) != b.ScriptVersionByIndex(outputIndex) ||
+			!bytes.Equal(a.PkScriptByIndex(outputIndex),
+				b.PkScriptByIndex(outputIndex)) {
+
+			return false
+		}
+	}
+	for outputIndex := range b.sparseOutputs {
+		if !b.IsOutputSpent(outputIndex) {
+			numUnspent--
+		}
+	}
+	return numUnspent == 0
+}
+
+// reconnectBlocks reconnects the blocks that were disconnected from the
+// provided view with full validation and ensures the spent outputs they
+// generate match the provided spend journal entries.  Once all of them are
+// reconnected, it also ensures the utxos in the view match the utxo set.
+func (v *chainVerifier) reconnectBlocks(view *UtxoViewpoint, journal [][]spentTxOut) error {
+	b := v.b
+	for i := len(journal) - 1; i >= 0; i-- {
+		if interruptRequested(v.interrupt) {
+			return errInterruptRequested
+		}
+
+		node, block, parent := v.nodes[i], v.blocks[i], v.blocks[i+1]
+		stxos := make([]spentTxOut, 0, len(journal[i]))
+		if err := v.lock(); err != nil {
+			return err
+		}
+		err := b.checkConnectBlock(node, block, parent, view, &stxos)
+		v.unlock()
+		if err != nil {
+			// The view is no longer usable once connecting fails.
+			v.addInconsistency(node, "block failed validation when "+
+				"reconnected: %v", err)
+			return nil
+		}
+
+		if len(stxos) != len(journal[i]) {
+			v.addInconsistency(node, "reconnecting block spent %d "+
+				"outputs while the spend journal entry has %d",
+				len(stxos), len(journal[i]))
+			continue
+		}
+		for j := range stxos {
+			if !stxosEqual(&stxos[j], &journal[i][j]) {
+				v.addInconsistency(node, "spend journal entry %d does "+
+					"not match the output spent when reconnecting", j)
+			}
+		}
+	}
+	if len(journal) != len(v.nodes)-1 {
+		return nil
+	}
+
+	// Ensure the view that was disconnected and reconnected matches the
+	// utxo set for all of the transactions it was modified for.
+	hashes := make([]chainhash.Hash, 0, len(view.entries))
+	for hash := range view.entries {
+		hashes = append(hashes, hash)
+	}
+	if err := v.lock(); err != nil {
+		return err
+	}
+	entries, err := b.utxoCache.fetchEntries(hashes)
+	v.unlock()
+	if err != nil {
+		return err
+	}
+	tip := v.nodes[0]
+	for i := range hashes {
+		if !utxoEntriesEqual(view.entries[hashes[i]], entries[i]) {
+			v.addInconsistency(tip, "utxos of transaction %v do not "+
+				"match the utxo set after reconnecting", hashes[i])
+		}
+	}
+	return nil
+}
+
+// stakeNodeMismatch returns a description of the first difference between the
+// ticket treaps and lottery state of the provided stake nodes or an empty
+// string when they match.
+func stakeNodeMismatch(got, want *stake.Node) string {
+	hashesEqual := func(a, b []chainhash.Hash) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch {
+	case got.PoolSize() != want.PoolSize():
+		return fmt.Sprintf("%d live tickets instead of %d",
+			got.PoolSize(), want.PoolSize())
+	case !hashesEqual(got.LiveTickets(), want.LiveTickets()):
+		return "mismatched live tickets"
+	case !hashesEqual(got.MissedTickets(), want.MissedTickets()):
+		return "mismatched missed tickets"
+	}
+
+	gotRevoked, wantRevoked := got.RevokedTickets(), want.RevokedTickets()
+	if len(gotRevoked) != len(wantRevoked) {
+		return fmt.Sprintf("%d revoked tickets instead of %d",
+			len(gotRevoked), len(wantRevoked))
+	}
+	for i := range gotRevoked {
+		if *gotRevoked[i] != *wantRevoked[i] {
+			return "mismatched revoked tickets"
+		}
+	}
+
+	switch {
+	case !hashesEqual(got.Winners(), want.Winners()):
+		return "mismatched lottery winners"
+	case got.FinalState() != want.FinalState():
+		return fmt.Sprintf("lottery final state %x instead of %x",
+			got.FinalState(), want.FinalState())
+	}
+	return ""
+}
+
+// newTicketsForBlock returns the tickets that mature in the block associated
+// with the provided node by loading the block that purchased them.
+func (v *chainVerifier) newTicketsForBlock(node *blockNode) ([]chainhash.Hash, error) {
+	b := v.b
+	if node.height < b.chainParams.StakeEnabledHeight {
+		return nil, nil
+	}
+	matureNode, err := b.nodeAtHeightFromTopNode(node,
+		int64(b.chainParams.TicketMaturity))
+	if err != nil {
+		return nil, err
+	}
+	matureBlock, err := b.fetchMainChainBlockByHash(&matureNode.hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var tickets []chainhash.Hash
+	for _, stx := range matureBlock.MsgBlock().STransactions {
+		if stake.IsSStx(stx) {
+			tickets = append(tickets, stx.TxHash())
+		}
+	}
+	return tickets, nil
+}
+
+// verifyTickets ensures the ticket database matches the ticket treaps in
+// memory at the end of the chain.  It then undoes the ticket changes of each
+// block being verified using the undo data in the ticket database, recomputes
+// the ticket treaps from the blocks themselves, and ensures they match after
+// each block as well as the commitments in the block headers.
+func (v *chainVerifier) verifyTickets() error {
+	b := v.b
+	tip := v.nodes[0]
+	undoNodes := make([]*stake.Node, 0, len(v.nodes))
+	if err := v.lock(); err != nil {
+		return err
+	}
+	err := b.db.View(func(dbTx database.Tx) error {
+		dbNode, err := stake.LoadBestNode(dbTx, uint32(tip.height),
+			tip.hash, tip.Header(), b.chainParams)
+		if err != nil {
+			v.addInconsistency(tip, "unable to load ticket database: %v",
+				err)
+			return nil
+		}
+		if desc := stakeNodeMismatch(dbNode, tip.stakeNode); desc != "" {
+			v.addInconsistency(tip, "ticket database does not match "+
+				"the ticket treaps in memory: %s", desc)
+		}
+		undoNodes = append(undoNodes, dbNode)
+		return nil
+	})
+	v.unlock()
+	if err != nil || len(undoNodes) == 0 {
+		return err
+	}
+
+	// Undo the ticket changes of each block using the undo data in the
+	// ticket database.
+	for i := 1; i < len(v.nodes); i++ {
+		if interruptRequested(v.interrupt) {
+			return errInterruptRequested
+		}
+
+		if err := v.lock(); err != nil {
+			return err
+		}
+		var stakeNode *stake.Node
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			stakeNode, err = undoNodes[i-1].DisconnectNode(
+				v.nodes[i].lotteryIV(), nil, nil, dbTx)
+			return err
+		})
+		v.unlock()
+		if err != nil {
+			v.addInconsistency(v.nodes[i-1], "unable to undo ticket "+
+				"changes: %v", err)
+			break
+		}
+		undoNodes = append(undoNodes, stakeNode)
+	}
+
+	// Recompute the ticket treaps starting from the earliest restored one.
+	stakeNode := undoNodes[len(undoNodes)-1]
+	for i := len(undoNodes) - 2; i >= 0; i-- {
+		if interruptRequested(v.interrupt) {
+			return errInterruptRequested
+		}
+
+		node, block := v.nodes[i], v.blocks[i]
+		if block == nil {
+			v.addInconsistency(node, "unable to recompute ticket "+
+				"changes without the block")
+			return nil
+		}
+		header := &block.MsgBlock().Header
+		if header.PoolSize != uint32(stakeNode.PoolSize()) ||
+			header.FinalState != stakeNode.FinalState() {
+
+			v.addInconsistency(node, "header commitments to pool size "+
+				"%d and final state %x do not match recomputed %d "+
+				"and %x", header.PoolSize, header.FinalState,
+				stakeNode.PoolSize(), stakeNode.FinalState())
+		}
+
+		if err := v.lock(); err != nil {
+			return err
+		}
+		newTickets, err := v.newTicketsForBlock(node)
+		v.unlock()
+		if err != nil {
+			v.addInconsistency(node, "unable to load maturing "+
+				"tickets: %v", err)
+			return nil
+		}
+		spent := stake.FindSpentTicketsInBlock(block.MsgBlock())
+		stakeNode, err = stakeNode.ConnectNode(node.lotteryIV(),
+			spent.VotedTickets, spent.RevokedTickets, newTickets)
+		if err != nil {
+			v.addInconsistency(node, "unable to recompute ticket "+
+				"changes: %v", err)
+			return nil
+		}
+		if desc := stakeNodeMismatch(undoNodes[i], stakeNode); desc != "" {
+			v.addInconsistency(node, "ticket database does not match "+
+				"the recomputed ticket treaps: %s", desc)
+		}
+	}
+	return nil
+}
+
+// verifyUtxoValue flushes the utxo cache and ensures the total value of the
+// utxo set does not exceed the total coin supply.  The value may be lower
+// since outputs that are provably unspendable are not part of the utxo set and
+// fees are not required to be claimed.
+func (v *chainVerifier) verifyUtxoValue() error {
+	b := v.b
+	tip := v.nodes[0]
+	if err := v.lock(); err != nil {
+		return err
+	}
+	locked := true
+	defer func() {
+		if locked {
+			v.unlock()
+		}
+	}()
+	if err := b.utxoCache.flush(&tip.hash, tip.height); err != nil {
+		return err
+	}
+	b.stateLock.RLock()
+	coinSupply := b.stateSnapshot.TotalSubsidy
+	b.stateLock.RUnlock()
+
+	var total int64
+	var numScanned uint64
+	err := b.db.View(func(dbTx database.Tx) error {
+		// The database transaction provides a snapshot of the flushed
+		// utxo set, so the lock is not needed while scanning it.
+		v.unlock()
+		locked = false
+
+		utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
+		cursor := utxoBucket.Cursor()
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			numScanned++
+			if numScanned%utxoScanProgressInterval == 0 &&
+				interruptRequested(v.interrupt) {
+
+				return errInterruptRequested
+			}
+
+			_, output, err := deserializeUtxoOutput(cursor.Value())
+			if err != nil {
+				v.addInconsistency(tip, "corrupt utxo entry for key "+
+					"%x: %v", cursor.Key(), err)
+				continue
+			}
+			total += output.amount
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	v.result.UtxoSetValue = total
+	v.result.CoinSupply = coinSupply
+	if total > coinSupply {
+		v.addInconsistency(tip, "total value of the utxo set %v exceeds "+
+			"the coin supply %v", cdrutil.Amount(total),
+			cdrutil.Amount(coinSupply))
+	}
+	return nil
+}
+
+// VerifyChain verifies the provided number of blocks at the end of the main
+// chain along with the chain state that relates to them.  A depth of zero
+// verifies the entire chain, however the depth is limited to 2880 blocks at
+// VerifyLevelSpendJournal and above since those levels keep all of the blocks
+// being verified in memory.  The lower levels load one block at a time.  The level controls how thorough the
+// verification is, where each level performs the checks described by the
+// VerifyLevel constants in addition to those of all lower levels.  Levels
+// outside of the supported range are clamped to it.
+//
+// Rather than stopping at the first inconsistency, all inconsistencies that are
+// found are reported in the result.  However, the checks that rely on the state
+// built up by a check that failed are skipped.  An error is only returned when
+// the verification could not be performed, such as when it is interrupted.
+//
+// The chain state lock is only held while verifying each block, so blocks can
+// still be processed while it is running.  However, the verification is
+// stopped with an error when the main chain changes.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) VerifyChain(level, depth int64, interrupt <-chan struct{}) (*VerifyChainResult, error) {
+	if level < VerifyLevelLookup {
+		level = VerifyLevelLookup
+	}
+	if level > VerifyLevelUtxoValue {
+		level = VerifyLevelUtxoValue
+	}
+
+	if level >= VerifyLevelSpendJournal &&
+		(depth <= 0 || depth > maxVerifyJournalDepth) {
+
+		depth = maxVerifyJournalDepth
+	}
+
+	// Collect the nodes being verified along with the parent of the
+	// earliest one.
+	b.chainLock.Lock()
+	tip := b.bestNode
+	finishHeight := tip.height - depth
+	if depth <= 0 || finishHeight < 0 {
+		finishHeight = 0
+	}
+	v := chainVerifier{
+		b:         b,
+		nodes:     make([]*blockNode, 0, tip.height-finishHeight+1),
+		interrupt: interrupt,
+		result: &VerifyChainResult{
+			Level:     level,
+			NumBlocks: tip.height - finishHeight,
+		},
+	}
+	for node := tip; node != nil && node.height >= finishHeight; {
+		v.nodes = append(v.nodes, node)
+		if node.height == finishHeight {
+			break
+		}
+		var err error
+		node, err = b.index.PrevNodeFromNode(node)
+		if err != nil {
+			b.chainLock.Unlock()
+			return nil, err
+		}
+	}
+	b.chainLock.Unlock()
+	log.Infof("Verifying chain for %d blocks at level %d",
+		v.result.NumBlocks, level)
+
+	err := v.loadBlocks(level >= VerifyLevelSanity,
+		level >= VerifyLevelSpendJournal)
+	if err != nil {
+		return nil, err
+	}
+
+	if level >= VerifyLevelSpendJournal {
+		view, journal, err := v.disconnectBlocks()
+		if err != nil {
+			return nil, err
+		}
+		if level >= VerifyLevelReconnect {
+			if err := v.reconnectBlocks(view, journal); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if level >= VerifyLevelTickets {
+		if err := v.verifyTickets(); err != nil {
+			return nil, err
+		}
+	}
+
+	if level >= VerifyLevelUtxoValue {
+		if err := v.verifyUtxoValue(); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Infof("Chain verify completed with %d inconsistencies",
+		len(v.result.Inconsistencies))
+	return v.result, nil
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/database"
+	"github.com/commanderu/cdrd/txscript"
+)
+
+// TestVerifyChain ensures verifying a consistent chain does not report any
+// inconsistencies at any level and that corrupting the spend journal and the
+// ticket database is detected with all of the inconsistencies reported.
+func TestVerifyChain(t *testing.T) {
+	// Create a new database and chain instance to run tests against.
+	chain, teardownFunc, err := chainSetup("verifychaintest",
+		&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	// Generate enough blocks for some of the purchased tickets to be live.
+	tg := newUtxoTestGenerator(t, chain)
+	tg.generateMatureBlocks()
+	tg.generateSpendBlocks("bs", 20)
+	tip := chain.bestNode
+	if tip.stakeNode.PoolSize() == 0 {
+		t.Fatal("no live tickets")
+	}
+
+	// Ensure the entire chain verifies at every level and that levels
+	// outside of the supported range are clamped.
+	for level := int64(-1); level <= VerifyLevelUtxoValue+1; level++ {
+		result, err := chain.VerifyChain(level, 0, nil)
+		if err != nil {
+			t.Fatalf("VerifyChain(%d): unexpected error: %v", level, err)
+		}
+		for _, inconsistency := range result.Inconsistencies {
+			t.Errorf("VerifyChain(%d): unexpected inconsistency in "+
+				"block %v (height %d): %s", level,
+				inconsistency.Hash, inconsistency.Height,
+				inconsistency.Description)
+		}
+		wantLevel := level
+		if wantLevel < VerifyLevelLookup {
+			wantLevel = VerifyLevelLookup
+		}
+		if wantLevel > VerifyLevelUtxoValue {
+			wantLevel = VerifyLevelUtxoValue
+		}
+		if result.Level != wantLevel || result.NumBlocks != tip.height {
+			t.Fatalf("VerifyChain(%d): unexpected level %d and number "+
+				"of blocks %d", level, result.Level, result.NumBlocks)
+		}
+		if wantLevel == VerifyLevelUtxoValue && (result.UtxoSetValue <= 0 ||
+			result.UtxoSetValue > result.CoinSupply) {
+
+			t.Fatalf("VerifyChain(%d): unexpected utxo set value %d "+
+				"for coin supply %d", level, result.UtxoSetValue,
+				result.CoinSupply)
+		}
+	}
+
+	// Corrupt the script of the first output spent by the best block in its
+	// spend journal entry and replace one of the live tickets in the ticket
+	// database with a ticket that does not exist.
+	block, err := chain.fetchMainChainBlockByHash(&tip.hash)
+	if err != nil {
+		t.Fatalf("fetchMainChainBlockByHash: unexpected error: %v", err)
+	}
+	parent, err := chain.fetchMainChainBlockByHash(&tip.parentHash)
+	if err != nil {
+		t.Fatalf("fetchMainChainBlockByHash: unexpected error: %v", err)
+	}
+	err = chain.db.Update(func(dbTx database.Tx) error {
+		stxos, err := dbFetchSpendJournalEntry(dbTx, block, parent)
+		if err != nil {
+			return err
+		}
+		stxos[0].pkScript = []byte{txscript.OP_RETURN}
+		stxos[0].compressed = false
+		err = dbPutSpendJournalEntry(dbTx, &tip.hash, stxos)
+		if err != nil {
+			return err
+		}
+
+		// The name of the live tickets bucket of the ticket database is
+		// internal to the stake package.
+		bucket := dbTx.Metadata().Bucket([]byte("livetickets"))
+		ticket := tip.stakeNode.LiveTickets()[0]
+		value := append([]byte(nil), bucket.Get(ticket[:])...)
+		if err := bucket.Delete(ticket[:]); err != nil {
+			return err
+		}
+		bogusTicket := chainhash.Hash{0x01}
+		return bucket.Put(bogusTicket[:], value)
+	})
+	if err != nil {
+		t.Fatalf("Failed to corrupt the chain state: %v", err)
+	}
+
+	// Ensure the levels that do not examine the spend journal or ticket
+	// database do not report any inconsistencies.
+	result, err := chain.VerifyChain(VerifyLevelSanity, 5, nil)
+	if err != nil {
+		t.Fatalf("VerifyChain: unexpected error: %v", err)
+	}
+	if len(result.Inconsistencies) != 0 {
+		t.Fatalf("VerifyChain: unexpected inconsistencies %v",
+			result.Inconsistencies)
+	}
+
+	// Ensure both corruptions are reported.  Note that the corrupted output
+	// is spent by the regular transaction tree of the parent of the best
+	// block, so the scripts fail to validate when reconnecting the parent
+	// since that is where the transaction tree is validated.
+	result, err = chain.VerifyChain(VerifyLevelReconnect, 5, nil)
+	if err != nil {
+		t.Fatalf("VerifyChain: unexpected error: %v", err)
+	}
+	tests := []struct {
+		hash chainhash.Hash
+		desc string
+	}{
+		{tip.parentHash, "block failed validation when reconnected"},
+		{tip.hash, "ticket database does not match the ticket treaps in " +
+			"memory: mismatched live tickets"},
+	}
+	for _, test := range tests {
+		var found bool
+		for _, inconsistency := range result.Inconsistencies {
+			if inconsistency.Hash == test.hash &&
+				strings.HasPrefix(inconsistency.Description, test.desc) {
+
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("VerifyChain: inconsistency %q for block %v not "+
+				"reported in %v", test.desc, test.hash,
+				result.Inconsistencies)
+		}
+	}
+}
@@ -37,6 +37,10 @@ const (
 	// database name.
 	blockDbNamePrefix = "blocks"
 
+	// headersDbNamePrefix is the prefix for the block database name in
+	// headers-only mode.
+	headersDbNamePrefix = "headers"
+
 	// maxResendLimit is the maximum number of times a node can resend a
 	// block or transaction before it is dropped.
 	maxResendLimit = 3
@@ -192,6 +196,20 @@ type forceReorganizationMsg struct {
 	reply      chan forceReorganizationResponse
 }
 
+// invalidateBlockMsg is a message type to be sent across the message channel
+// for requesting that a block and all of its descendants be marked invalid.
+type invalidateBlockMsg struct {
+	hash  *chainhash.Hash
+	reply chan error
+}
+
+// reconsiderBlockMsg is a message type to be sent across the message channel
+// for requesting that the invalid status of a block be removed.
+type reconsiderBlockMsg struct {
+	hash  *chainhash.Hash
+	reply chan error
+}
+
 // processBlockResponse is a response sent to the reply channel of a
 // processBlockMsg.
 type processBlockResponse struct {
@@ -402,6 +420,16 @@ type blockManager struct {
 	startHeader      *list.Element
 	nextCheckpoint   *chaincfg.Checkpoint
 
+	// assumeValid is the hash of the assumed valid block when the headers
+	// being downloaded in headers-first mode lead to it rather than to a
+	// checkpoint.  The next checkpoint is a stand-in for the assumed valid
+	// block once its header has been received.
+	assumeValid *chainhash.Hash
+
+	// cfBatch is the batch of committed filters being downloaded from the
+	// sync peer in headers-only mode.
+	cfBatch *cfilterBatch
+
 	// lotteryDataBroadcastMutex is a mutex protecting the map
 	// that checks if block lottery data has been broadcasted
 	// yet for any given block, so notifications are never
@@ -421,6 +449,13 @@ func (b *blockManager) resetHeaderState(newestHash *chainhash.Hash, newestHeight
 	b.headerList.Init()
 	b.startHeader = nil
 
+	// Restore the actual next checkpoint when it is a stand-in for the
+	// assumed valid block.
+	if b.assumeValid != nil {
+		b.assumeValid = nil
+		b.nextCheckpoint = b.findNextHeaderCheckpoint(newestHeight)
+	}
+
 	// When there is a next checkpoint, add an entry for the latest known
 	// block into the header pool.  This allows the next downloaded header
 	// to prove it links to the chain properly.
@@ -453,6 +488,66 @@ func (b *blockManager) updateChainState(newestHash *chainhash.Hash,
 	b.chainState.curPrevHash = curPrevHash
 }
 
+// updateChainStateAfterReorg updates the chain state and notifies websocket
+// clients of the new stake difficulty after the best chain was changed by an
+// explicit request rather than by processing a block.
+func (b *blockManager) updateChainStateAfterReorg() {
+	// Query the db for the latest best block since the reorganization
+	// changed it.
+	best := b.chain.BestSnapshot()
+
+	// Fetch the required lottery data.
+	winningTickets, poolSize, finalState, err :=
+		b.chain.LotteryDataForBlock(&best.Hash)
+
+	// Update registered websocket clients on the current stake difficulty.
+	nextStakeDiff, errSDiff :=
+		b.chain.CalcNextRequiredStakeDifficulty()
+	if err != nil {
+		bmgrLog.Warnf("Failed to get next stake difficulty "+
+			"calculation: %v", err)
+	}
+	r := b.server.rpcServer
+	if r != nil && errSDiff == nil {
+		r.ntfnMgr.NotifyStakeDifficulty(
+			&StakeDifficultyNtfnData{
+				best.Hash,
+				best.Height,
+				nextStakeDiff,
+			})
+		b.server.txMemPool.PruneStakeTx(nextStakeDiff,
+			best.Height)
+		b.server.txMemPool.PruneExpiredTx(best.Height)
+	}
+	if b.server.pubSub != nil && errSDiff == nil {
+		b.server.pubSub.NotifyStakeDifficulty(
+			&StakeDifficultyNtfnData{
+				best.Hash,
+				best.Height,
+				nextStakeDiff,
+			})
+	}
+
+	missedTickets, err := b.chain.MissedTickets()
+	if err != nil {
+		bmgrLog.Warnf("Failed to get missed tickets"+
+			": %v", err)
+	}
+
+	// The blockchain should be updated, so fetch the latest snapshot.
+	best = b.chain.BestSnapshot()
+	curPrevHash := b.chain.BestPrevHash()
+
+	b.updateChainState(&best.Hash,
+		best.Height,
+		finalState,
+		uint32(poolSize),
+		nextStakeDiff,
+		winningTickets,
+		missedTickets,
+		curPrevHash)
+}
+
 // findNextHeaderCheckpoint returns the next checkpoint after the passed height.
 // It returns nil when there is not one either because the height is already
 // later than the final checkpoint or some other reason such as disabled
@@ -540,6 +635,13 @@ func (b *blockManager) startSync(peers *list.List) {
 		bmgrLog.Infof("Syncing to block height %d from peer %v",
 			bestPeer.LastBlock(), bestPeer.Addr())
 
+		// Only the headers and committed filters are downloaded in
+		// headers-only mode.
+		if cfg.HeadersOnly {
+			b.startHeadersOnlySync(bestPeer, locator)
+			return
+		}
+
 		// When the current height is less than a known checkpoint we
 		// can use block headers to learn about which blocks comprise
 		// the chain up to the checkpoint and perform less validation
@@ -571,6 +673,27 @@ func (b *blockManager) startSync(peers *list.List) {
 			bmgrLog.Infof("Downloading headers for blocks %d to "+
 				"%d from peer %s", best.Height+1,
 				b.nextCheckpoint.Height, bestPeer.Addr())
+		} else if assumeValid, ok := b.chain.AssumeValidPending(); ok {
+			// Similarly, when the assumed valid block is not known yet,
+			// use block headers to learn which blocks lead to it, so
+			// their scripts do not need to be validated.  Unlike the
+			// blocks that lead to a checkpoint, the blocks are still
+			// subject to all other validation.
+			b.headerList.Init()
+			b.startHeader = nil
+			b.headerList.PushBack(&headerNode{height: best.Height,
+				hash: &best.Hash})
+			err := bestPeer.PushGetHeadersMsg(locator, &assumeValid)
+			if err != nil {
+				bmgrLog.Errorf("Failed to push getheadermsg for the "+
+					"latest blocks: %v", err)
+				return
+			}
+			b.headersFirstMode = true
+			b.assumeValid = &assumeValid
+			bmgrLog.Infof("Downloading headers for blocks %d to "+
+				"assumed valid block %v from peer %s",
+				best.Height+1, assumeValid, bestPeer.Addr())
 		} else {
 			err := bestPeer.PushGetBlocksMsg(locator, &zeroHash)
 			if err != nil {
@@ -588,6 +711,10 @@ func (b *blockManager) startSync(peers *list.List) {
 // isSyncCandidate returns whether or not the peer is a candidate to consider
 // syncing from.
 func (b *blockManager) isSyncCandidate(sp *serverPeer) bool {
+	if cfg.HeadersOnly {
+		return isHeadersOnlySyncCandidate(sp)
+	}
+
 	// The peer is not a candidate for sync if it's not a full node.
 	return sp.Services()&wire.SFNodeNetwork == wire.SFNodeNetwork
 }
@@ -668,6 +795,12 @@ func (b *blockManager) handleDonePeerMsg(peers *list.List, sp *serverPeer) {
 		delete(b.requestedBlocks, k)
 	}
 
+	// Abandon the batch of committed filters being downloaded from the
+	// peer so it is requested from the next sync peer.
+	if b.cfBatch != nil && b.cfBatch.peer == sp {
+		b.cfBatch = nil
+	}
+
 	// Attempt to find a new peer to sync from if the quitting peer is the
 	// sync peer.  Also, reset the headers-first state if in headers-first
 	// mode so
@@ -982,7 +1115,12 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		if firstNodeEl != nil {
 			firstNode := firstNodeEl.Value.(*headerNode)
 			if blockHash.IsEqual(firstNode.hash) {
-				behaviorFlags |= blockchain.BFFastAdd
+				// The blocks that lead to the assumed valid block
+				// only skip script validation, which is handled by
+				// the chain, so they are not eligible.
+				if b.assumeValid == nil {
+					behaviorFlags |= blockchain.BFFastAdd
+				}
 				if firstNode.hash.IsEqual(b.nextCheckpoint.Hash) {
 					isCheckpointBlock = true
 				} else {
@@ -1100,10 +1238,10 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 			b.lotteryDataBroadcastMutex.RLock()
 			_, beenNotified := b.lotteryDataBroadcast[*blockHash]
 			b.lotteryDataBroadcastMutex.RUnlock()
-			if !beenNotified && r != nil &&
+			if !beenNotified && (r != nil || b.server.pubSub != nil) &&
 				int64(bmsg.block.MsgBlock().Header.Height) >
 					b.server.chainParams.LatestCheckpointHeight() {
-				r.ntfnMgr.NotifyWinningTickets(winningTicketsNtfn)
+				b.server.notifyWinningTickets(winningTicketsNtfn)
 
 				b.lotteryDataBroadcastMutex.Lock()
 				b.lotteryDataBroadcast[*blockHash] = struct{}{}
@@ -1155,6 +1293,14 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 					best.Height)
 				b.server.txMemPool.PruneExpiredTx(best.Height)
 			}
+			if b.server.pubSub != nil && errSDiff == nil {
+				b.server.pubSub.NotifyStakeDifficulty(
+					&StakeDifficultyNtfnData{
+						best.Hash,
+						best.Height,
+						nextStakeDiff,
+					})
+			}
 
 			winningTickets, poolSize, finalState, err :=
 				b.chain.LotteryDataForBlock(blockHash)
@@ -1235,11 +1381,39 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 	}
 
 	// This is headers-first mode, the block is a checkpoint, and there are
-	// no more checkpoints, so switch to normal mode by requesting blocks
-	// from the block after this one up to the end of the chain (zero hash).
+	// no more checkpoints.  When the assumed valid block is not known yet,
+	// get the headers that lead to it starting from the block after this
+	// one.  The checkpoint remains in the header list to verify they link
+	// properly.
+	if b.assumeValid == nil {
+		if assumeValid, ok := b.chain.AssumeValidPending(); ok {
+			locator := blockchain.BlockLocator([]*chainhash.Hash{blockHash})
+			err := bmsg.peer.PushGetHeadersMsg(locator, &assumeValid)
+			if err != nil {
+				bmgrLog.Warnf("Failed to send getheaders message to "+
+					"peer %s: %v", bmsg.peer.Addr(), err)
+				return
+			}
+			b.assumeValid = &assumeValid
+			bmgrLog.Infof("Downloading headers for blocks %d to "+
+				"assumed valid block %v from peer %s", prevHeight+1,
+				assumeValid, b.syncPeer.Addr())
+			return
+		}
+	}
+
+	// Otherwise, switch to normal mode by requesting blocks from the block
+	// after this one up to the end of the chain (zero hash).
+	if b.assumeValid != nil {
+		b.assumeValid = nil
+		bmgrLog.Infof("Reached the assumed valid block -- switching to " +
+			"normal mode")
+	} else {
+		bmgrLog.Infof("Reached the final checkpoint -- switching to " +
+			"normal mode")
+	}
 	b.headersFirstMode = false
 	b.headerList.Init()
-	bmgrLog.Infof("Reached the final checkpoint -- switching to normal mode")
 	locator := blockchain.BlockLocator([]*chainhash.Hash{blockHash})
 	err = bmsg.peer.PushGetBlocksMsg(locator, &zeroHash)
 	if err != nil {
@@ -1301,6 +1475,11 @@ func (b *blockManager) fetchHeaderBlocks() {
 
 // handleHeadersMsg handles headers messages from all peers.
 func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
+	if cfg.HeadersOnly {
+		b.handleHeadersOnlyHeadersMsg(hmsg)
+		return
+	}
+
 	// The remote peer is misbehaving if we didn't request headers.
 	msg := hmsg.headers
 	numHeaders := len(msg.Headers)
@@ -1311,8 +1490,13 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		return
 	}
 
-	// Nothing to do for an empty headers message.
+	// An empty headers message while downloading the headers that lead to
+	// the assumed valid block means the peer does not have it in its best
+	// header chain.
 	if numHeaders == 0 {
+		if b.nextCheckpoint == nil && b.assumeValid != nil {
+			b.abandonAssumeValid(hmsg.peer)
+		}
 		return
 	}
 
@@ -1351,6 +1535,30 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 			return
 		}
 
+		// The assumed valid block acts as the next checkpoint once its
+		// header is received.  The headers that lead to it are provided
+		// to the chain so the scripts of the blocks are not validated.
+		if b.nextCheckpoint == nil {
+			if !node.hash.IsEqual(b.assumeValid) {
+				continue
+			}
+			b.nextCheckpoint = &chaincfg.Checkpoint{
+				Height: node.height,
+				Hash:   node.hash,
+			}
+			first := b.headerList.Front().Value.(*headerNode)
+			err := b.chain.SetAssumeValidChain(first.height+1,
+				b.headerListHashes())
+			if err != nil {
+				bmgrLog.Warnf("Failed to set the headers that lead "+
+					"to the assumed valid block: %v", err)
+			}
+			receivedCheckpoint = true
+			bmgrLog.Infof("Received block header for assumed valid "+
+				"block at height %d/hash %s", node.height, node.hash)
+			break
+		}
+
 		// Verify the header at the next checkpoint height matches.
 		if node.height == b.nextCheckpoint.Height {
 			if node.hash.IsEqual(b.nextCheckpoint.Hash) {
@@ -1387,11 +1595,22 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		return
 	}
 
+	// The peer does not have the assumed valid block in its best header
+	// chain when it sends fewer than the maximum number of headers without
+	// reaching it.
+	stopHash := b.assumeValid
+	if b.nextCheckpoint != nil {
+		stopHash = b.nextCheckpoint.Hash
+	} else if numHeaders < wire.MaxBlockHeadersPerMsg {
+		b.abandonAssumeValid(hmsg.peer)
+		return
+	}
+
 	// This header is not a checkpoint, so request the next batch of
 	// headers starting from the latest known header and ending with the
 	// next checkpoint.
 	locator := blockchain.BlockLocator([]*chainhash.Hash{finalHash})
-	err := hmsg.peer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
+	err := hmsg.peer.PushGetHeadersMsg(locator, stopHash)
 	if err != nil {
 		bmgrLog.Warnf("Failed to send getheaders message to "+
 			"peer %s: %v", hmsg.peer.Addr(), err)
@@ -1399,6 +1618,50 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 	}
 }
 
+// headerListHashes returns the hashes of the headers in the header list other
+// than the first one, which is the final block that is already known and only
+// used to ensure the next header links properly.
+func (b *blockManager) headerListHashes() []chainhash.Hash {
+	var hashes []chainhash.Hash
+	for e := b.headerList.Front().Next(); e != nil; e = e.Next() {
+		hashes = append(hashes, *e.Value.(*headerNode).hash)
+	}
+	return hashes
+}
+
+// abandonAssumeValid switches to normal mode by requesting blocks from the
+// peer starting with the current best block since the assumed valid block is
+// not in the best header chain of the sync peer.  The blocks from the peer are
+// fully validated.
+//
+// Skipping script validation for the ancestors of the assumed valid block is
+// only disabled altogether when the block is known to not be part of the best
+// chain, so a single peer is not able to disable it.  Otherwise, the headers
+// that lead to it are requested again when syncing from the next peer.
+func (b *blockManager) abandonAssumeValid(peer *serverPeer) {
+	if b.chain.AssumeValidOffBestChain() {
+		b.chain.DisableAssumeValid()
+	} else {
+		bmgrLog.Infof("Peer %s does not have assumed valid block %v -- "+
+			"validating all scripts of the blocks from it", peer.Addr(),
+			b.assumeValid)
+	}
+	best := b.chain.BestSnapshot()
+	b.resetHeaderState(&best.Hash, best.Height)
+
+	locator, err := b.chain.LatestBlockLocator()
+	if err != nil {
+		bmgrLog.Errorf("Failed to get block locator for the latest "+
+			"block: %v", err)
+		return
+	}
+	err = peer.PushGetBlocksMsg(locator, &zeroHash)
+	if err != nil {
+		bmgrLog.Warnf("Failed to send getblocks message to peer %s: %v",
+			peer.Addr(), err)
+	}
+}
+
 // haveInventory returns whether or not the inventory represented by the passed
 // inventory vector is known.  This includes checking all of the various places
 // inventory can be when it is in different states such as blocks that are part
@@ -1471,6 +1734,13 @@ func (b *blockManager) handleInvMsg(imsg *invMsg) {
 		}
 	}
 
+	// Only the headers of announced blocks are requested in headers-only
+	// mode.
+	if cfg.HeadersOnly {
+		b.handleHeadersOnlyInvMsg(imsg, lastBlock)
+		return
+	}
+
 	// Request the advertised inventory if we don't already have it.  Also,
 	// request parent blocks of orphans if we receive one we already have.
 	// Finally, attempt to detect potential stalls due to long side chains
@@ -1655,6 +1925,12 @@ out:
 			case *headersMsg:
 				b.handleHeadersMsg(msg)
 
+			case *cfHeadersMsg:
+				b.handleCFHeadersMsg(msg)
+
+			case *cfilterMsg:
+				b.handleCFilterMsg(msg)
+
 			case *donePeerMsg:
 				b.handleDonePeerMsg(candidatePeers, msg.peer)
 
@@ -1690,61 +1966,27 @@ out:
 				// Reorganizing has succeeded, so we need to
 				// update the chain state.
 				if err == nil {
-					// Query the db for the latest best block since
-					// the block that was processed could be on a
-					// side chain or have caused a reorg.
-					best := b.chain.BestSnapshot()
-
-					// Fetch the required lottery data.
-					winningTickets, poolSize, finalState, err :=
-						b.chain.LotteryDataForBlock(&best.Hash)
-
-					// Update registered websocket clients on the
-					// current stake difficulty.
-					nextStakeDiff, errSDiff :=
-						b.chain.CalcNextRequiredStakeDifficulty()
-					if err != nil {
-						bmgrLog.Warnf("Failed to get next stake difficulty "+
-							"calculation: %v", err)
-					}
-					r := b.server.rpcServer
-					if r != nil && errSDiff == nil {
-						r.ntfnMgr.NotifyStakeDifficulty(
-							&StakeDifficultyNtfnData{
-								best.Hash,
-								best.Height,
-								nextStakeDiff,
-							})
-						b.server.txMemPool.PruneStakeTx(nextStakeDiff,
-							best.Height)
-						b.server.txMemPool.PruneExpiredTx(best.Height)
-					}
-
-					missedTickets, err := b.chain.MissedTickets()
-					if err != nil {
-						bmgrLog.Warnf("Failed to get missed tickets"+
-							": %v", err)
-					}
-
-					// The blockchain should be updated, so fetch the
-					// latest snapshot.
-					best = b.chain.BestSnapshot()
-					curPrevHash := b.chain.BestPrevHash()
-
-					b.updateChainState(&best.Hash,
-						best.Height,
-						finalState,
-						uint32(poolSize),
-						nextStakeDiff,
-						winningTickets,
-						missedTickets,
-						curPrevHash)
+					b.updateChainStateAfterReorg()
 				}
 
 				msg.reply <- forceReorganizationResponse{
 					err: err,
 				}
 
+			case invalidateBlockMsg:
+				err := b.chain.InvalidateBlock(msg.hash)
+				if err == nil {
+					b.updateChainStateAfterReorg()
+				}
+				msg.reply <- err
+
+			case reconsiderBlockMsg:
+				err := b.chain.ReconsiderBlock(msg.hash)
+				if err == nil {
+					b.updateChainStateAfterReorg()
+				}
+				msg.reply <- err
+
 			case tipGenerationMsg:
 				g, err := b.chain.TipGeneration()
 				msg.reply <- tipGenerationResponse{
@@ -1793,7 +2035,8 @@ out:
 					// do this if we're above the latest checkpoint
 					// height.
 					r := b.server.rpcServer
-					if r != nil && !isOrphan && !beenNotified &&
+					if (r != nil || b.server.pubSub != nil) &&
+						!isOrphan && !beenNotified &&
 						(msg.block.Height() >=
 							b.server.chainParams.StakeValidationHeight-1) &&
 						(msg.block.Height() >
@@ -1803,7 +2046,7 @@ out:
 							int64(msg.block.MsgBlock().Header.Height),
 							winningTickets}
 
-						r.ntfnMgr.NotifyWinningTickets(ntfnData)
+						b.server.notifyWinningTickets(ntfnData)
 						b.lotteryDataBroadcastMutex.Lock()
 						b.lotteryDataBroadcast[*msg.block.Hash()] = struct{}{}
 						b.lotteryDataBroadcastMutex.Unlock()
@@ -1826,14 +2069,17 @@ out:
 						bmgrLog.Warnf("Failed to get next stake difficulty "+
 							"calculation: %v", err)
 					} else {
+						ntfnData := &StakeDifficultyNtfnData{
+							best.Hash,
+							best.Height,
+							nextStakeDiff,
+						}
 						r := b.server.rpcServer
 						if r != nil {
-							r.ntfnMgr.NotifyStakeDifficulty(
-								&StakeDifficultyNtfnData{
-									best.Hash,
-									best.Height,
-									nextStakeDiff,
-								})
+							r.ntfnMgr.NotifyStakeDifficulty(ntfnData)
+						}
+						if b.server.pubSub != nil {
+							b.server.pubSub.NotifyStakeDifficulty(ntfnData)
 						}
 					}
 
@@ -1964,7 +2210,7 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			b.server.chainParams.StakeValidationHeight-1 &&
 			!tooOldForLotteryData &&
 			block.Height() > b.server.chainParams.LatestCheckpointHeight() &&
-			r != nil {
+			(r != nil || b.server.pubSub != nil) {
 
 			hash := block.Hash()
 			b.lotteryDataBroadcastMutex.Lock()
@@ -1986,9 +2232,10 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 						Tickets:     wt,
 					}
 
-					// Notify registered websocket clients of newly
-					// eligible tickets to vote on.
-					r.ntfnMgr.NotifyWinningTickets(ntfnData)
+					// Notify registered websocket clients and
+					// pub/sub subscribers of newly eligible
+					// tickets to vote on.
+					b.server.notifyWinningTickets(ntfnData)
 					b.lotteryDataBroadcastMutex.Lock()
 					b.lotteryDataBroadcast[*hash] = struct{}{}
 					b.lotteryDataBroadcastMutex.Unlock()
@@ -2083,6 +2330,11 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			r.ntfnMgr.NotifyBlockConnected(block)
 		}
 
+		// Notify pub/sub subscribers of the connected block.
+		if b.server.pubSub != nil {
+			b.server.pubSub.NotifyBlockConnected(block)
+		}
+
 	// Stake tickets are spent or missed from the most recently connected block.
 	case blockchain.NTSpentAndMissedTickets:
 		tnd, ok := notification.Data.(*blockchain.TicketNotificationsData)
@@ -2109,6 +2361,19 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			r.ntfnMgr.NotifyNewTickets(tnd)
 		}
 
+	// An event has been added to the chain event log.
+	case blockchain.NTChainEvent:
+		event, ok := notification.Data.(*blockchain.ChainEvent)
+		if !ok {
+			bmgrLog.Warnf("Chain event notification is not a ChainEvent")
+			break
+		}
+
+		// Notify registered websocket clients.
+		if r := b.server.rpcServer; r != nil {
+			r.ntfnMgr.NotifyChainEvent(event)
+		}
+
 	// A block has been disconnected from the main block chain.
 	case blockchain.NTBlockDisconnected:
 		blockSlice, ok := notification.Data.([]*cdrutil.Block)
@@ -2275,6 +2540,13 @@ func (b *blockManager) Stop() error {
 	bmgrLog.Infof("Block manager shutting down")
 	close(b.quit)
 	b.wg.Wait()
+
+	// Flush the utxo cache so the blocks connected since it was last
+	// flushed do not need to be replayed on the next startup.
+	if err := b.chain.FlushUtxoCache(); err != nil {
+		bmgrLog.Errorf("Unable to flush the utxo cache: %v", err)
+		return err
+	}
 	return nil
 }
 
@@ -2430,6 +2702,25 @@ func (b *blockManager) ForceReorganization(formerBest, newBest chainhash.Hash) e
 	return response.err
 }
 
+// InvalidateBlock marks the block with the provided hash and all of its
+// descendants invalid and reorganizes away from them when needed.  It is
+// funneled through the block manager since blockchain is not safe for
+// concurrent access.
+func (b *blockManager) InvalidateBlock(hash *chainhash.Hash) error {
+	reply := make(chan error)
+	b.msgChan <- invalidateBlockMsg{hash: hash, reply: reply}
+	return <-reply
+}
+
+// ReconsiderBlock removes the invalid status of the block with the provided
+// hash and reorganizes to the best valid chain.  It is funneled through the
+// block manager since blockchain is not safe for concurrent access.
+func (b *blockManager) ReconsiderBlock(hash *chainhash.Hash) error {
+	reply := make(chan error)
+	b.msgChan <- reconsiderBlockMsg{hash: hash, reply: reply}
+	return <-reply
+}
+
 // TipGeneration returns the hashes of all the children of the current best
 // chain tip.  It is funneled through the block manager since blockchain is not
 // safe for concurrent access.
@@ -2543,6 +2834,11 @@ func newBlockManager(s *server, indexManager blockchain.IndexManager, interrupt
 		Notifications: bm.handleNotifyMsg,
 		SigCache:      s.sigCache,
 		IndexManager:  indexManager,
+
+		UtxoCacheMaxSize:       uint64(cfg.UtxoCacheMaxSize) * 1024 * 1024,
+		UtxoCacheFlushInterval: cfg.UtxoFlushInterval,
+		AssumeValid:            cfg.assumeValid,
+		HeadersOnly:            cfg.HeadersOnly,
 	})
 	if err != nil {
 		return nil, err
@@ -2569,6 +2865,14 @@ func newBlockManager(s *server, indexManager blockchain.IndexManager, interrupt
 		return nil, fmt.Errorf("closing after dumping blockchain")
 	}
 
+	// There is no ticket database to provide the lottery data in
+	// headers-only mode.
+	if cfg.HeadersOnly {
+		bm.updateHeadersOnlyChainState()
+		bm.lotteryDataBroadcast = make(map[chainhash.Hash]struct{})
+		return &bm, nil
+	}
+
 	// Query the DB for the current winning ticket data.
 	wt, ps, fs, err := bm.chain.LotteryDataForBlock(&best.Hash)
 	if err != nil {
@@ -2604,8 +2908,14 @@ func newBlockManager(s *server, indexManager blockchain.IndexManager, interrupt
 
 // blockDbPath returns the path to the block database given a database type.
 func blockDbPath(dbType string) string {
-	// The database name is based on the database type.
-	dbName := blockDbNamePrefix + "_" + dbType
+	// The database name is based on the database type.  Headers-only
+	// databases use a separate name since they can not be used to store
+	// full blocks.
+	prefix := blockDbNamePrefix
+	if cfg.HeadersOnly {
+		prefix = headersDbNamePrefix
+	}
+	dbName := prefix + "_" + dbType
 	if dbType == "sqlite" {
 		dbName = dbName + ".db"
 	}
@@ -0,0 +1,155 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/wire"
+)
+
+const (
+	// cfilterCacheLimit is the maximum number of committed filters that are
+	// kept in memory to serve repeated requests from light clients.  It is
+	// large enough to hold a couple of maximum sized getcfilters batches for
+	// both filter types.
+	cfilterCacheLimit = 4 * wire.MaxGetCFiltersReqRange
+)
+
+// cfilterCacheKey uniquely identifies a committed filter in the cache.
+type cfilterCacheKey struct {
+	blockHash  chainhash.Hash
+	filterType wire.FilterType
+}
+
+// cfilterCacheEntry houses a cached committed filter along with the key that
+// identifies it so the key is available on eviction.
+type cfilterCacheEntry struct {
+	key    cfilterCacheKey
+	filter []byte
+}
+
+// cfilterCache provides a concurrency safe cache of serialized committed
+// filters that is limited to a maximum number of items with eviction for the
+// least recently used entry when the limit is exceeded.
+//
+// Committed filters are immutable for a given block hash, so entries never
+// need to be invalidated due to chain reorganizations.
+type cfilterCache struct {
+	mtx     sync.Mutex
+	entries map[cfilterCacheKey]*list.Element // nearly O(1) lookups
+	lruList *list.List                        // O(1) insert, update, delete
+	limit   int
+}
+
+// Lookup returns the cached filter of the given type for the passed block hash
+// along with whether or not it was found.  A successful lookup marks the entry
+// as the most recently used.
+//
+// This function is safe for concurrent access.
+func (c *cfilterCache) Lookup(hash *chainhash.Hash, filterType wire.FilterType) ([]byte, bool) {
+	key := cfilterCacheKey{blockHash: *hash, filterType: filterType}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	node, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+	c.lruList.MoveToFront(node)
+	return node.Value.(*cfilterCacheEntry).filter, true
+}
+
+// Add adds the passed filter to the cache and handles eviction of the least
+// recently used item if adding the new item would exceed the max limit.
+// Adding an existing item makes it the most recently used item.
+//
+// This function is safe for concurrent access.
+func (c *cfilterCache) Add(hash *chainhash.Hash, filterType wire.FilterType, filter []byte) {
+	key := cfilterCacheKey{blockHash: *hash, filterType: filterType}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	// When the limit is zero, nothing can be added to the cache, so just
+	// return.
+	if c.limit == 0 {
+		return
+	}
+
+	// When the entry already exists move it to the front of the list
+	// thereby marking it most recently used.
+	if node, exists := c.entries[key]; exists {
+		node.Value.(*cfilterCacheEntry).filter = filter
+		c.lruList.MoveToFront(node)
+		return
+	}
+
+	// Evict the least recently used entry (back of the list) if the new
+	// entry would exceed the size limit for the cache.  Also reuse the list
+	// node so a new one doesn't have to be allocated.
+	entry := &cfilterCacheEntry{key: key, filter: filter}
+	if len(c.entries)+1 > c.limit {
+		node := c.lruList.Back()
+		lru := node.Value.(*cfilterCacheEntry)
+		delete(c.entries, lru.key)
+
+		node.Value = entry
+		c.lruList.MoveToFront(node)
+		c.entries[key] = node
+		return
+	}
+
+	// The limit hasn't been reached yet, so just add the new item.
+	c.entries[key] = c.lruList.PushFront(entry)
+}
+
+// Len returns the number of filters in the cache.
+//
+// This function is safe for concurrent access.
+func (c *cfilterCache) Len() int {
+	c.mtx.Lock()
+	n := len(c.entries)
+	c.mtx.Unlock()
+	return n
+}
+
+// newCFilterCache returns a new committed filter cache that is limited to the
+// number of entries specified by limit.
+func newCFilterCache(limit int) *cfilterCache {
+	return &cfilterCache{
+		entries: make(map[cfilterCacheKey]*list.Element),
+		lruList: list.New(),
+		limit:   limit,
+	}
+}
+
+// cfCheckpt houses the committed filter header of a checkpoint block along with
+// the hash of the block so the checkpoint can be validated against the current
+// main chain.
+type cfCheckpt struct {
+	blockHash    chainhash.Hash
+	filterHeader chainhash.Hash
+}
+
+// cfCheckptCache provides a concurrency safe cache of the committed filter
+// header checkpoints for each filter type.  The checkpoints for each filter
+// type are stored in ascending height order starting with the checkpoint at
+// height CFCheckptInterval.
+type cfCheckptCache struct {
+	mtx      sync.Mutex
+	checkpts map[wire.FilterType][]cfCheckpt
+}
+
+// newCFCheckptCache returns a new empty committed filter header checkpoint
+// cache.
+func newCFCheckptCache() *cfCheckptCache {
+	return &cfCheckptCache{
+		checkpts: make(map[wire.FilterType][]cfCheckpt),
+	}
+}
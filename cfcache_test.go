@@ -0,0 +1,72 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// TestCFilterCache ensures the committed filter cache behaves as expected
+// including limiting, eviction of least recently used entries, and keeping
+// filters of different types separate.
+func TestCFilterCache(t *testing.T) {
+	const limit = 10
+	c := newCFilterCache(limit)
+
+	// Fill the cache with regular filters and ensure they are all found
+	// and that the extended filters for the same blocks are not.
+	hashes := make([]chainhash.Hash, limit+1)
+	for i := range hashes {
+		hashes[i][0] = byte(i)
+	}
+	for i := 0; i < limit; i++ {
+		c.Add(&hashes[i], wire.GCSFilterRegular, []byte{byte(i)})
+	}
+	if c.Len() != limit {
+		t.Fatalf("Len: unexpected number of entries - got %d, want %d",
+			c.Len(), limit)
+	}
+	for i := 0; i < limit; i++ {
+		filter, ok := c.Lookup(&hashes[i], wire.GCSFilterRegular)
+		if !ok || !bytes.Equal(filter, []byte{byte(i)}) {
+			t.Fatalf("Lookup #%d: unexpected result - got %x (%v)",
+				i, filter, ok)
+		}
+		if _, ok := c.Lookup(&hashes[i], wire.GCSFilterExtended); ok {
+			t.Fatalf("Lookup #%d: found unexpected extended filter",
+				i)
+		}
+	}
+
+	// Mark the first entry as recently used and add a new entry which
+	// should evict the second entry since it is now the least recently
+	// used one.
+	c.Lookup(&hashes[0], wire.GCSFilterRegular)
+	c.Add(&hashes[limit], wire.GCSFilterRegular, []byte{limit})
+	if c.Len() != limit {
+		t.Fatalf("Len: unexpected number of entries - got %d, want %d",
+			c.Len(), limit)
+	}
+	if _, ok := c.Lookup(&hashes[0], wire.GCSFilterRegular); !ok {
+		t.Fatal("Lookup: recently used entry was evicted")
+	}
+	if _, ok := c.Lookup(&hashes[1], wire.GCSFilterRegular); ok {
+		t.Fatal("Lookup: least recently used entry was not evicted")
+	}
+	if _, ok := c.Lookup(&hashes[limit], wire.GCSFilterRegular); !ok {
+		t.Fatal("Lookup: newly added entry not found")
+	}
+
+	// Ensure a cache with a limit of zero never holds entries.
+	c = newCFilterCache(0)
+	c.Add(&hashes[0], wire.GCSFilterRegular, []byte{0x00})
+	if _, ok := c.Lookup(&hashes[0], wire.GCSFilterRegular); ok {
+		t.Fatal("Lookup: found entry in zero limit cache")
+	}
+}
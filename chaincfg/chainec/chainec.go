@@ -189,6 +189,12 @@ type DSA interface {
 	// public key.
 	Verify(pub PublicKey, hash []byte, r, s *big.Int) bool
 
+	// BatchVerify verifies a batch of signatures, where sigs[i] is the
+	// signature of hashes[i] by pubs[i], and returns whether or not they
+	// are all valid.  It does not identify the invalid signatures of a
+	// failed batch.
+	BatchVerify(pubs []PublicKey, hashes [][]byte, sigs []Signature) bool
+
 	// ----------------------------------------------------------------------------
 	// Symmetric cipher encryption
 	//
@@ -46,6 +46,7 @@ type edwardsDSA struct {
 	generateKey func(rand io.Reader) ([]byte, *big.Int, *big.Int, error)
 	sign        func(priv PrivateKey, hash []byte) (r, s *big.Int, err error)
 	verify      func(pub PublicKey, hash []byte, r, s *big.Int) bool
+	batchVerify func(pubs []PublicKey, hashes [][]byte, sigs []Signature) bool
 
 	// Symmetric cipher encryption
 	generateSharedSecret func(privkey []byte, x, y *big.Int) []byte
@@ -139,6 +140,10 @@ func (e edwardsDSA) Sign(priv PrivateKey, hash []byte) (r, s *big.Int,
 func (e edwardsDSA) Verify(pub PublicKey, hash []byte, r, s *big.Int) bool {
 	return e.verify(pub, hash, r, s)
 }
+func (e edwardsDSA) BatchVerify(pubs []PublicKey, hashes [][]byte,
+	sigs []Signature) bool {
+	return e.batchVerify(pubs, hashes, sigs)
+}
 
 // Symmetric cipher encryption
 func (e edwardsDSA) GenerateSharedSecret(privkey []byte, x, y *big.Int) []byte {
@@ -298,6 +303,26 @@ func newEdwardsDSA() DSA {
 			}
 			return edwards.Verify(&epub, hash, r, s)
 		},
+		batchVerify: func(pubs []PublicKey, hashes [][]byte, sigs []Signature) bool {
+			if len(pubs) != len(sigs) {
+				return false
+			}
+			epubs := make([]*edwards.PublicKey, len(pubs))
+			esigs := make([]*edwards.Signature, len(sigs))
+			for i, pub := range pubs {
+				if pub.GetType() != ECTypeEdwards {
+					return false
+				}
+				epub, ok := pub.(edwards.PublicKey)
+				if !ok {
+					return false
+				}
+				epubs[i] = &epub
+				esigs[i] = edwards.NewSignature(sigs[i].GetR(),
+					sigs[i].GetS())
+			}
+			return edwards.BatchVerify(epubs, hashes, esigs)
+		},
 
 		// Symmetric cipher encryption
 		generateSharedSecret: func(privkey []byte, x, y *big.Int) []byte {
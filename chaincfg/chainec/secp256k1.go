@@ -47,6 +47,7 @@ type secp256k1DSA struct {
 	generateKey func(rand io.Reader) ([]byte, *big.Int, *big.Int, error)
 	sign        func(priv PrivateKey, hash []byte) (r, s *big.Int, err error)
 	verify      func(pub PublicKey, hash []byte, r, s *big.Int) bool
+	batchVerify func(pubs []PublicKey, hashes [][]byte, sigs []Signature) bool
 
 	// Symmetric cipher encryption
 	generateSharedSecret func(privkey []byte, x, y *big.Int) []byte
@@ -140,6 +141,10 @@ func (sp secp256k1DSA) Sign(priv PrivateKey, hash []byte) (r, s *big.Int,
 func (sp secp256k1DSA) Verify(pub PublicKey, hash []byte, r, s *big.Int) bool {
 	return sp.verify(pub, hash, r, s)
 }
+func (sp secp256k1DSA) BatchVerify(pubs []PublicKey, hashes [][]byte,
+	sigs []Signature) bool {
+	return sp.batchVerify(pubs, hashes, sigs)
+}
 
 // Symmetric cipher encryption
 func (sp secp256k1DSA) GenerateSharedSecret(privkey []byte, x, y *big.Int) []byte {
@@ -299,6 +304,22 @@ func newSecp256k1DSA() DSA {
 			ssig := secp256k1.NewSignature(r, s)
 			return ssig.Verify(hash, spub)
 		},
+		batchVerify: func(pubs []PublicKey, hashes [][]byte, sigs []Signature) bool {
+			// There is no faster way to verify a batch of ECDSA
+			// signatures, so verify each of them.
+			if len(pubs) != len(sigs) || len(hashes) != len(sigs) {
+				return false
+			}
+			for i, sig := range sigs {
+				spub := secp256k1.NewPublicKey(pubs[i].GetX(),
+					pubs[i].GetY())
+				ssig := secp256k1.NewSignature(sig.GetR(), sig.GetS())
+				if !ssig.Verify(hashes[i], spub) {
+					return false
+				}
+			}
+			return true
+		},
 
 		// Symmetric cipher encryption
 		generateSharedSecret: func(privkey []byte, x, y *big.Int) []byte {
@@ -47,6 +47,7 @@ type secSchnorrDSA struct {
 	generateKey func(rand io.Reader) ([]byte, *big.Int, *big.Int, error)
 	sign        func(priv PrivateKey, hash []byte) (r, s *big.Int, err error)
 	verify      func(pub PublicKey, hash []byte, r, s *big.Int) bool
+	batchVerify func(pubs []PublicKey, hashes [][]byte, sigs []Signature) bool
 
 	// Symmetric cipher encryption
 	generateSharedSecret func(privkey []byte, x, y *big.Int) []byte
@@ -136,6 +137,10 @@ func (sp secSchnorrDSA) Sign(priv PrivateKey, hash []byte) (r, s *big.Int,
 func (sp secSchnorrDSA) Verify(pub PublicKey, hash []byte, r, s *big.Int) bool {
 	return sp.verify(pub, hash, r, s)
 }
+func (sp secSchnorrDSA) BatchVerify(pubs []PublicKey, hashes [][]byte,
+	sigs []Signature) bool {
+	return sp.batchVerify(pubs, hashes, sigs)
+}
 
 // Symmetric cipher encryption
 func (sp secSchnorrDSA) GenerateSharedSecret(privkey []byte, x, y *big.Int) []byte {
@@ -277,6 +282,19 @@ func newSecSchnorrDSA() DSA {
 			spub := secp256k1.NewPublicKey(pub.GetX(), pub.GetY())
 			return schnorr.Verify(spub, hash, r, s)
 		},
+		batchVerify: func(pubs []PublicKey, hashes [][]byte, sigs []Signature) bool {
+			if len(pubs) != len(sigs) {
+				return false
+			}
+			spubs := make([]*secp256k1.PublicKey, len(pubs))
+			ssigs := make([]*schnorr.Signature, len(sigs))
+			for i, pub := range pubs {
+				spubs[i] = secp256k1.NewPublicKey(pub.GetX(), pub.GetY())
+				ssigs[i] = schnorr.NewSignature(sigs[i].GetR(),
+					sigs[i].GetS())
+			}
+			return schnorr.BatchVerify(spubs, hashes, ssigs)
+		},
 
 		// Symmetric cipher encryption
 		generateSharedSecret: func(privkey []byte, x, y *big.Int) []byte {
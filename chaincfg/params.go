@@ -333,6 +333,18 @@ type Params struct {
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints []Checkpoint
 
+	// AssumeValid is the hash of a block which is known to be valid.  By
+	// default, script validation is skipped for its ancestors while all of
+	// the other consensus rules are still enforced.  It is the zero hash
+	// when there is no such block for the network.
+	//
+	// The block must be a recent block beyond the final checkpoint since
+	// the scripts of the blocks before a checkpoint are not validated
+	// regardless, so a checkpoint would provide no benefit.  None of the
+	// networks specify a block yet, so the scripts of all blocks after the
+	// final checkpoint are validated unless one is configured.
+	AssumeValid chainhash.Hash
+
 	// These fields are related to voting on consensus rule changes as
 	// defined by BIP0009.
 	//
@@ -526,6 +538,8 @@ var MainNetParams = Params{
 		{214672, newHashFromStr("0000000000000021d5cbeead55cb7fd659f07e8127358929ffc34cd362209758")},
 	},
 
+	AssumeValid: chainhash.Hash{},
+
 	// The miner confirmation window is defined as:
 	//   target proof of work timespan / target proof of work spacing
 	RuleChangeActivationQuorum:     4032, // 10 % of RuleChangeActivationInterval * TicketsPerBlock
@@ -732,6 +746,8 @@ var TestNet2Params = Params{
 		{249802, newHashFromStr("0000000000153386623d86ce70cc9372fa000ac3b999eff11b9fc7a3ca0d072a")},
 	},
 
+	AssumeValid: chainhash.Hash{},
+
 	// Consensus rule change deployments.
 	//
 	// The miner confirmation window is defined as:
@@ -899,6 +915,9 @@ var SimNetParams = Params{
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: nil,
 
+	// There is no assumed valid block for simnet.
+	AssumeValid: chainhash.Hash{},
+
 	// Consensus rule change deployments.
 	//
 	// The miner confirmation window is defined as:
@@ -267,13 +267,15 @@ func (bi *blockImporter) statusHandler(resultsChan chan *importResults) {
 		}
 		close(bi.quit)
 
-	// The import finished normally.
+	// The import finished normally.  Flush the utxo cache so the imported
+	// blocks do not need to be replayed the next time the chain is loaded.
 	case <-bi.doneChan:
+		err := bi.chain.FlushUtxoCache()
 		resultsChan <- &importResults{
 			blocksProcessed: bi.blocksProcessed,
 			blocksImported:  bi.blocksImported,
 			duration:        time.Since(bi.startTime),
-			err:             nil,
+			err:             err,
 		}
 	}
 }
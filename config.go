@@ -23,6 +23,7 @@ import (
 
 	"github.com/btcsuite/btclog"
 	"github.com/btcsuite/go-socks/socks"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
 	"github.com/commanderu/cdrd/connmgr"
 	"github.com/commanderu/cdrd/database"
 	_ "github.com/commanderu/cdrd/database/ffldb"
@@ -44,6 +45,7 @@ const (
 	defaultMaxRPCClients         = 10
 	defaultMaxRPCWebsockets      = 25
 	defaultMaxRPCConcurrentReqs  = 20
+	defaultMaxRESTClients        = 10
 	defaultDbType                = "ffldb"
 	defaultFreeTxRelayLimit      = 15.0
 	defaultBlockMinSize          = 0
@@ -56,6 +58,8 @@ const (
 	defaultMaxOrphanTransactions = 1000
 	defaultMaxOrphanTxSize       = 5000
 	defaultSigCacheMaxSize       = 100000
+	defaultUtxoCacheMaxSizeMiB   = 150
+	defaultUtxoFlushInterval     = 2 * time.Minute
 	defaultTxIndex               = false
 	defaultNoExistsAddrIndex     = false
 	defaultNoCFilters            = false
@@ -115,6 +119,14 @@ type config struct {
 	RPCMaxConcurrentReqs int           `long:"rpcmaxconcurrentreqs" description:"Max number of concurrent RPC requests that may be processed concurrently"`
 	DisableRPC           bool          `long:"norpc" description:"Disable built-in RPC server -- NOTE: The RPC server is disabled by default if no rpcuser/rpcpass or rpclimituser/rpclimitpass is specified"`
 	DisableTLS           bool          `long:"notls" description:"Disable TLS for the RPC server -- NOTE: This is only allowed if the RPC server is bound to localhost"`
+	RESTListeners        []string      `long:"restlisten" description:"Add an interface/port to listen for unauthenticated read-only REST connections -- NOTE: The REST server is disabled unless at least one interface is specified (default port: 37461, testnet: 19111)"`
+	RESTMaxClients       int           `long:"restmaxclients" description:"Max number of concurrent REST clients"`
+	PubHashBlock         string        `long:"zmqpubhashblock" description:"Publish the hash of each connected block to subscribers on the given address (eg. tcp://127.0.0.1:28332)"`
+	PubRawBlock          string        `long:"zmqpubrawblock" description:"Publish each connected block to subscribers on the given address"`
+	PubHashTx            string        `long:"zmqpubhashtx" description:"Publish the hash of each transaction accepted to the memory pool to subscribers on the given address"`
+	PubRawTx             string        `long:"zmqpubrawtx" description:"Publish each transaction accepted to the memory pool to subscribers on the given address"`
+	PubWinningTickets    string        `long:"zmqpubwinningtickets" description:"Publish the winning tickets of each new block to subscribers on the given address"`
+	PubStakeDifficulty   string        `long:"zmqpubstakedifficulty" description:"Publish the next stake difficulty after each new best block to subscribers on the given address"`
 	DisableDNSSeed       bool          `long:"nodnsseed" description:"Disable DNS seeding for peers"`
 	ExternalIPs          []string      `long:"externalip" description:"Add an ip to the list of local addresses we claim to listen on to peers"`
 	Proxy                string        `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
@@ -128,6 +140,7 @@ type config struct {
 	TestNet              bool          `long:"testnet" description:"Use the test network"`
 	SimNet               bool          `long:"simnet" description:"Use the simulation test network"`
 	DisableCheckpoints   bool          `long:"nocheckpoints" description:"Disable built-in checkpoints.  Don't do this unless you know what you're doing."`
+	AssumeValid          string        `long:"assumevalid" description:"Hash of a block assumed to be valid.  Script validation is skipped for its ancestors while all other consensus rules are still enforced -- Use 0 to validate all scripts (default: network-specific block, if any)"`
 	DbType               string        `long:"dbtype" description:"Database backend to use for the Block Chain"`
 	Profile              string        `long:"profile" description:"Enable HTTP profiling on given [addr:]port -- NOTE port must be between 1024 and 65536"`
 	CPUProfile           string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
@@ -147,10 +160,13 @@ type config struct {
 	BlockPrioritySize    uint32        `long:"blockprioritysize" description:"Size in bytes for high-priority/low-fee transactions when creating a block"`
 	GetWorkKeys          []string      `long:"getworkkey" description:"DEPRECATED -- Use the --miningaddr option instead"`
 	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
+	UtxoCacheMaxSize     uint          `long:"utxocachemaxsize" description:"The maximum size in MiB of the utxo cache"`
+	UtxoFlushInterval    time.Duration `long:"utxoflushinterval" description:"The maximum amount of time between flushes of the utxo cache to the database.  Valid time units are {s, m, h}.  Minimum 1 second"`
 	NonAggressive        bool          `long:"nonaggressive" description:"Disable mining off of the parent block of the blockchain if there aren't enough voters"`
 	NoMiningStateSync    bool          `long:"nominingstatesync" description:"Disable synchronizing the mining state with other nodes"`
 	AllowOldVotes        bool          `long:"allowoldvotes" description:"Enable the addition of very old votes to the mempool"`
 	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
+	HeadersOnly          bool          `long:"headersonly" description:"Only sync and validate block headers and verified committed filters instead of full blocks and serve the subset of RPCs that can be answered from them.  A database must always be used in the same mode."`
 	AcceptNonStd         bool          `long:"acceptnonstd" description:"Accept and relay non-standard transactions to the network regardless of the default settings for the active network."`
 	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
 	TxIndex              bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
@@ -159,8 +175,11 @@ type config struct {
 	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
 	NoExistsAddrIndex    bool          `long:"noexistsaddrindex" description:"Disable the exists address index, which tracks whether or not an address has even been used."`
 	DropExistsAddrIndex  bool          `long:"dropexistsaddrindex" description:"Deletes the exists address index from the database on start up and then exits."`
+	PeerBloomFilters     bool          `long:"peerbloomfilters" description:"Enable bloom filtering support for SPV peers"`
 	NoCFilters           bool          `long:"nocfilters" description:"Disable compact filtering (CF) support"`
 	DropCFIndex          bool          `long:"dropcfindex" description:"Deletes the index used for compact filtering (CF) support from the database on start up and then exits."`
+	BlockStatsIndex      bool          `long:"blockstatsindex" description:"Maintain an index of per-block statistics which makes historic queries via the getblockstats RPC faster"`
+	DropBlockStatsIndex  bool          `long:"dropblockstatsindex" description:"Deletes the block statistics index from the database on start up and then exits."`
 	PipeRx               uint          `long:"piperx" description:"File descriptor of read end pipe to enable parent -> child process communication"`
 	PipeTx               uint          `long:"pipetx" description:"File descriptor of write end pipe to enable parent <- child process communication"`
 	LifetimeEvents       bool          `long:"lifetimeevents" description:"Send lifetime notifications over the TX pipe"`
@@ -168,6 +187,7 @@ type config struct {
 	lookup               func(string) ([]net.IP, error)
 	oniondial            func(string, string) (net.Conn, error)
 	dial                 func(string, string) (net.Conn, error)
+	assumeValid          chainhash.Hash
 	miningAddrs          []cdrutil.Address
 	minRelayTxFee        cdrutil.Amount
 	whitelists           []*net.IPNet
@@ -436,6 +456,7 @@ func loadConfig() (*config, []string, error) {
 		RPCMaxClients:        defaultMaxRPCClients,
 		RPCMaxWebsockets:     defaultMaxRPCWebsockets,
 		RPCMaxConcurrentReqs: defaultMaxRPCConcurrentReqs,
+		RESTMaxClients:       defaultMaxRESTClients,
 		DataDir:              defaultDataDir,
 		LogDir:               defaultLogDir,
 		DbType:               defaultDbType,
@@ -448,6 +469,8 @@ func loadConfig() (*config, []string, error) {
 		BlockPrioritySize:    mempool.DefaultBlockPrioritySize,
 		MaxOrphanTxs:         defaultMaxOrphanTransactions,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
+		UtxoCacheMaxSize:     defaultUtxoCacheMaxSizeMiB,
+		UtxoFlushInterval:    defaultUtxoFlushInterval,
 		Generate:             defaultGenerate,
 		NoMiningStateSync:    defaultNoMiningStateSync,
 		TxIndex:              defaultTxIndex,
@@ -719,6 +742,23 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Ensure the utxo cache options are sane.
+	if cfg.UtxoCacheMaxSize == 0 {
+		str := "%s: the utxocachemaxsize option may not be zero"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.UtxoFlushInterval < time.Second {
+		str := "%s: the utxoflushinterval option may not be less than 1s " +
+			"-- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.UtxoFlushInterval)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Validate any given whitelisted IP addresses and networks.
 	if len(cfg.Whitelists) > 0 {
 		var ip net.IP
@@ -900,6 +940,16 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --blockstatsindex and --dropblockstatsindex do not mix.
+	if cfg.BlockStatsIndex && cfg.DropBlockStatsIndex {
+		err := fmt.Errorf("%s: the --blockstatsindex and "+
+			"--dropblockstatsindex options may not be activated at "+
+			"the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// !--noexistsaddrindex and --dropexistsaddrindex do not mix.
 	if !cfg.NoExistsAddrIndex && cfg.DropExistsAddrIndex {
 		err := fmt.Errorf("dropexistsaddrindex cannot be activated when " +
@@ -917,6 +967,62 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --headersonly does not mix with the options that require full blocks.
+	if cfg.HeadersOnly {
+		var conflicts []string
+		if cfg.TxIndex {
+			conflicts = append(conflicts, "--txindex")
+		}
+		if cfg.AddrIndex {
+			conflicts = append(conflicts, "--addrindex")
+		}
+		if cfg.BlockStatsIndex {
+			conflicts = append(conflicts, "--blockstatsindex")
+		}
+		if cfg.Generate {
+			conflicts = append(conflicts, "--generate")
+		}
+		if cfg.PeerBloomFilters {
+			conflicts = append(conflicts, "--peerbloomfilters")
+		}
+		if len(cfg.RESTListeners) > 0 {
+			conflicts = append(conflicts, "--restlisten")
+		}
+		if len(conflicts) > 0 {
+			err := fmt.Errorf("%s: the --headersonly option may not be "+
+				"activated at the same time as %s", funcName,
+				strings.Join(conflicts, ", "))
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		// There are no transactions or full blocks to index, relay, or
+		// filter, and the committed filters are stored by the chain
+		// itself.
+		cfg.NoCFilters = true
+		cfg.NoExistsAddrIndex = true
+		cfg.BlocksOnly = true
+		cfg.NoMiningStateSync = true
+	}
+
+	// Parse the assumed valid block hash, which defaults to the one for the
+	// active network.  A value of 0 disables the behavior.
+	cfg.assumeValid = activeNetParams.AssumeValid
+	if cfg.AssumeValid == "0" {
+		cfg.assumeValid = chainhash.Hash{}
+	} else if cfg.AssumeValid != "" {
+		hash, err := chainhash.NewHashFromStr(cfg.AssumeValid)
+		if err != nil {
+			str := "%s: assumevalid '%s' is not a valid block hash: %v"
+			err := fmt.Errorf(str, funcName, cfg.AssumeValid, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.assumeValid = *hash
+	}
+
 	// Check getwork keys are valid and saved parsed versions.
 	cfg.miningAddrs = make([]cdrutil.Address, 0, len(cfg.GetWorkKeys)+
 		len(cfg.MiningAddrs))
@@ -980,6 +1086,30 @@ func loadConfig() (*config, []string, error) {
 	cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners,
 		activeNetParams.rpcPort)
 
+	// Add default port to all REST listener addresses if needed and remove
+	// duplicate addresses.
+	cfg.RESTListeners = normalizeAddresses(cfg.RESTListeners,
+		activeNetParams.restPort)
+
+	// Strip the optional transport prefix from all publisher addresses and
+	// ensure they include a port since there is no default.
+	for _, addr := range []*string{&cfg.PubHashBlock, &cfg.PubRawBlock,
+		&cfg.PubHashTx, &cfg.PubRawTx, &cfg.PubWinningTickets,
+		&cfg.PubStakeDifficulty} {
+
+		if *addr == "" {
+			continue
+		}
+		*addr = strings.TrimPrefix(*addr, "tcp://")
+		if _, _, err := net.SplitHostPort(*addr); err != nil {
+			str := "%s: publisher address '%s' is invalid: %v"
+			err := fmt.Errorf(str, funcName, *addr, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// Only allow TLS to be disabled if the RPC is bound to localhost
 	// addresses.
 	if !cfg.DisableRPC && cfg.DisableTLS {
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"encoding/json"
+	"os"
+)
+
+const (
+	// AnchorsFilename is the default filename used to persist anchor
+	// connections across restarts.
+	AnchorsFilename = "anchors.json"
+
+	// MaxAnchors is the maximum number of anchor connections that are
+	// persisted and reconnected to first after a restart.
+	MaxAnchors = 2
+)
+
+// serializedAnchors is the format used to store the anchor addresses.
+type serializedAnchors struct {
+	Version int      `json:"version"`
+	Addrs   []string `json:"addrs"`
+}
+
+// anchorsVersion is the current version of the serialized anchors format.
+const anchorsVersion = 1
+
+// SaveAnchors writes up to MaxAnchors of the passed addresses, which are
+// expected to be in the form host:port, to the file at the given path so they
+// can be loaded with LoadAnchors after a restart.
+func SaveAnchors(filePath string, addrs []string) error {
+	if len(addrs) > MaxAnchors {
+		addrs = addrs[:MaxAnchors]
+	}
+	w, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	err = enc.Encode(&serializedAnchors{Version: anchorsVersion, Addrs: addrs})
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// LoadAnchors reads the anchor addresses previously written by SaveAnchors from
+// the file at the given path.  The file is removed once it has been read so
+// that a node which repeatedly fails after connecting to its anchors does not
+// keep reconnecting to them.  No addresses and no error are returned when the
+// file does not exist.
+func LoadAnchors(filePath string) ([]string, error) {
+	r, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sa serializedAnchors
+	err = json.NewDecoder(r).Decode(&sa)
+	r.Close()
+	if rmErr := os.Remove(filePath); err == nil {
+		err = rmErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := sa.Addrs
+	if len(addrs) > MaxAnchors {
+		addrs = addrs[:MaxAnchors]
+	}
+	return addrs, nil
+}
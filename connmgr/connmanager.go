@@ -36,6 +36,10 @@ var (
 	// defaultTargetOutbound is the default number of outbound connections to
 	// maintain.
 	defaultTargetOutbound = uint32(8)
+
+	// defaultFeelerInterval is the default duration of time between feeler
+	// connections.
+	defaultFeelerInterval = time.Minute * 2
 )
 
 // ConnState represents the state of the requested connection.
@@ -135,6 +139,30 @@ type Config struct {
 
 	// Dial connects to the address on the named network. It cannot be nil.
 	Dial func(network, addr string) (net.Conn, error)
+
+	// Anchors defines addresses that were connected to during a previous
+	// run, such as those loaded with LoadAnchors.  They are connected to
+	// before any addresses are requested from GetNewAddress and count
+	// toward TargetOutbound.
+	Anchors []net.Addr
+
+	// GetFeelerAddress is a way to get an address to make a short-lived
+	// feeler connection to.  Feeler connections are used to test addresses
+	// which have never been successfully connected to, so they may be
+	// promoted to known good addresses.  If nil, no feeler connections
+	// will be made.
+	GetFeelerAddress func() (net.Addr, error)
+
+	// OnFeelerConnection is a callback that is fired when a feeler
+	// connection is established.  Feeler connections are not tracked by
+	// the connection manager and do not count toward TargetOutbound, so it
+	// is the caller's responsibility to close the connection once the
+	// address has been tested.
+	OnFeelerConnection func(*ConnReq, net.Conn)
+
+	// FeelerInterval is the duration to wait between feeler connections.
+	// Defaults to 2m.
+	FeelerInterval time.Duration
 }
 
 // handleConnected is used to queue a successful connection.
@@ -327,6 +355,51 @@ func (cm *ConnManager) Remove(id uint64) {
 	cm.requests <- handleDisconnected{id, false}
 }
 
+// feelerHandler periodically makes a feeler connection to an address provided
+// by the GetFeelerAddress callback.  It must be run as a goroutine.
+func (cm *ConnManager) feelerHandler() {
+	ticker := time.NewTicker(cm.cfg.FeelerInterval)
+	defer ticker.Stop()
+
+out:
+	for {
+		select {
+		case <-ticker.C:
+			addr, err := cm.cfg.GetFeelerAddress()
+			if err != nil {
+				log.Tracef("No feeler address available: %v", err)
+				continue
+			}
+
+			c := &ConnReq{Addr: addr}
+			atomic.StoreUint64(&c.id, atomic.AddUint64(&cm.connReqCount, 1))
+			go func() {
+				log.Debugf("Attempting feeler connection to %v", c)
+				conn, err := cm.cfg.Dial(c.Addr.Network(), c.Addr.String())
+				if err != nil {
+					c.updateState(ConnFailed)
+					log.Debugf("Failed feeler connection to %v: %v",
+						c, err)
+					return
+				}
+				c.updateState(ConnEstablished)
+				c.conn = conn
+				if atomic.LoadInt32(&cm.stop) != 0 {
+					conn.Close()
+					return
+				}
+				cm.cfg.OnFeelerConnection(c, conn)
+			}()
+
+		case <-cm.quit:
+			break out
+		}
+	}
+
+	cm.wg.Done()
+	log.Trace("Feeler handler done")
+}
+
 // listenHandler accepts incoming connections on a given listener.  It must be
 // run as a goroutine.
 func (cm *ConnManager) listenHandler(listener net.Listener) {
@@ -367,6 +440,27 @@ func (cm *ConnManager) Start() {
 		}
 	}
 
+	// Start the feeler connection handler when the caller provided a way to
+	// obtain feeler addresses and handle the resulting connections.
+	if cm.cfg.GetFeelerAddress != nil && cm.cfg.OnFeelerConnection != nil {
+		cm.wg.Add(1)
+		go cm.feelerHandler()
+	}
+
+	// Reconnect to the anchor connections from a previous run first so they
+	// take precedence over newly sourced addresses.  The request ids are
+	// assigned here so the anchors are accounted for in the number of
+	// connection requests below.
+	for _, addr := range cm.cfg.Anchors {
+		if atomic.LoadUint64(&cm.connReqCount) >= uint64(cm.cfg.TargetOutbound) {
+			break
+		}
+		c := &ConnReq{Addr: addr}
+		atomic.StoreUint64(&c.id, atomic.AddUint64(&cm.connReqCount, 1))
+		log.Debugf("Reconnecting to anchor %v", c)
+		go cm.Connect(c)
+	}
+
 	for i := atomic.LoadUint64(&cm.connReqCount); i < uint64(cm.cfg.TargetOutbound); i++ {
 		go cm.NewConnReq()
 	}
@@ -409,6 +503,9 @@ func New(cfg *Config) (*ConnManager, error) {
 	if cfg.TargetOutbound == 0 {
 		cfg.TargetOutbound = defaultTargetOutbound
 	}
+	if cfg.FeelerInterval <= 0 {
+		cfg.FeelerInterval = defaultFeelerInterval
+	}
 	cm := ConnManager{
 		cfg:      *cfg, // Copy so caller can't mutate
 		requests: make(chan interface{}),
@@ -7,7 +7,11 @@ package connmgr
 import (
 	"errors"
 	"io"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -215,6 +219,130 @@ func TestTargetOutbound(t *testing.T) {
 	cmgr.Stop()
 }
 
+// TestAnchors tests that anchor connections persisted by SaveAnchors are loaded
+// by LoadAnchors and connected to before any new addresses are requested.
+func TestAnchors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "connmgrtest")
+	if err != nil {
+		t.Fatalf("TempDir error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Ensure loading anchors when no file exists is not an error.
+	anchorsFile := filepath.Join(dir, AnchorsFilename)
+	addrs, err := LoadAnchors(anchorsFile)
+	if err != nil || len(addrs) != 0 {
+		t.Fatalf("LoadAnchors: unexpected result - got %v, %v", addrs, err)
+	}
+
+	// Save more than the maximum number of anchors and ensure only the
+	// maximum are loaded and the file is removed afterwards.
+	err = SaveAnchors(anchorsFile, []string{"127.0.0.1:18555",
+		"127.0.0.2:18555", "127.0.0.3:18555"})
+	if err != nil {
+		t.Fatalf("SaveAnchors error: %v", err)
+	}
+	addrs, err = LoadAnchors(anchorsFile)
+	if err != nil {
+		t.Fatalf("LoadAnchors error: %v", err)
+	}
+	wantAddrs := []string{"127.0.0.1:18555", "127.0.0.2:18555"}
+	if !reflect.DeepEqual(addrs, wantAddrs) {
+		t.Fatalf("LoadAnchors: got %v, want %v", addrs, wantAddrs)
+	}
+	if _, err := os.Stat(anchorsFile); !os.IsNotExist(err) {
+		t.Fatalf("LoadAnchors: anchors file was not removed")
+	}
+
+	// Ensure the anchors are connected to first and the remaining outbound
+	// connections are made to new addresses.
+	anchors := make([]net.Addr, 0, len(addrs))
+	for _, addr := range addrs {
+		tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+		if err != nil {
+			t.Fatalf("ResolveTCPAddr error: %v", err)
+		}
+		anchors = append(anchors, tcpAddr)
+	}
+	newAddr := &net.TCPAddr{IP: net.ParseIP("127.0.0.4"), Port: 18555}
+	connected := make(chan *ConnReq)
+	cmgr, err := New(&Config{
+		TargetOutbound: 3,
+		Dial:           mockDialer,
+		Anchors:        anchors,
+		GetNewAddress: func() (net.Addr, error) {
+			return newAddr, nil
+		},
+		OnConnection: func(c *ConnReq, conn net.Conn) {
+			connected <- c
+		},
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	cmgr.Start()
+	gotAddrs := make(map[string]int)
+	for i := 0; i < 3; i++ {
+		c := <-connected
+		gotAddrs[c.Addr.String()]++
+	}
+	wantConns := map[string]int{
+		anchors[0].String(): 1,
+		anchors[1].String(): 1,
+		newAddr.String():    1,
+	}
+	if !reflect.DeepEqual(gotAddrs, wantConns) {
+		t.Fatalf("anchors: got connections %v, want %v", gotAddrs,
+			wantConns)
+	}
+	cmgr.Stop()
+}
+
+// TestFeelers tests that feeler connections are made periodically to the
+// addresses provided by GetFeelerAddress and are not counted as outbound
+// connections.
+func TestFeelers(t *testing.T) {
+	feelerAddr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 18555}
+	feelers := make(chan *ConnReq, 10)
+	cmgr, err := New(&Config{
+		Dial:           mockDialer,
+		FeelerInterval: time.Millisecond,
+		GetFeelerAddress: func() (net.Addr, error) {
+			return feelerAddr, nil
+		},
+		OnFeelerConnection: func(c *ConnReq, conn net.Conn) {
+			conn.Close()
+			select {
+			case feelers <- c:
+			default:
+			}
+		},
+		OnConnection: func(c *ConnReq, conn net.Conn) {
+			t.Errorf("feelers: unexpected outbound connection %v", c)
+		},
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	cmgr.Start()
+	for i := 0; i < 2; i++ {
+		select {
+		case c := <-feelers:
+			if c.Addr.String() != feelerAddr.String() {
+				t.Fatalf("feelers: got address %v, want %v",
+					c.Addr, feelerAddr)
+			}
+			if c.State() != ConnEstablished {
+				t.Fatalf("feelers: got state %v, want %v",
+					c.State(), ConnEstablished)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("feelers: timeout waiting for feeler connection")
+		}
+	}
+	cmgr.Stop()
+}
+
 // TestRetryPermanent tests that permanent connection requests are retried.
 //
 // We make a permanent connection request using Connect, disconnect it using
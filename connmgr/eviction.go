@@ -0,0 +1,194 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+const (
+	// evictProtectNetGroup is the number of distinct network groups with the
+	// highest keyed ordering that have a candidate protected from eviction.
+	// Using a keyed ordering of network groups makes it impractical for an
+	// attacker to predict which network groups are protected.
+	evictProtectNetGroup = 4
+
+	// evictProtectPing is the number of candidates with the lowest ping
+	// latency that are protected from eviction.
+	evictProtectPing = 8
+
+	// evictProtectTx is the number of candidates that most recently relayed
+	// a transaction that are protected from eviction.
+	evictProtectTx = 4
+
+	// evictProtectBlock is the number of candidates that most recently
+	// relayed a block that are protected from eviction.
+	evictProtectBlock = 4
+)
+
+// EvictionCandidate describes an inbound connection that may be evicted to
+// make room for a new inbound connection when all inbound slots are in use.
+type EvictionCandidate struct {
+	// ID is a caller defined identifier for the connection.
+	ID uint64
+
+	// NetGroup is the network group of the remote address such as the one
+	// returned by addrmgr.GroupKey.
+	NetGroup string
+
+	// PingTime is the most recent ping round trip time of the connection.
+	// A value of zero indicates the latency is unknown.
+	PingTime time.Duration
+
+	// LastBlockTime and LastTxTime are the times the connection last
+	// relayed a block and a transaction, respectively.  The zero time
+	// indicates nothing has been relayed.
+	LastBlockTime time.Time
+	LastTxTime    time.Time
+
+	// ConnectedTime is the time the connection was established.
+	ConnectedTime time.Time
+}
+
+// keyedNetGroup returns a value derived from the passed network group and key
+// that is used to order candidates by network group without allowing the
+// ordering to be predicted by remote peers.
+func keyedNetGroup(key uint64, netGroup string) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], key)
+	h := sha256.New()
+	h.Write(buf[:])
+	h.Write([]byte(netGroup))
+	return binary.LittleEndian.Uint64(h.Sum(nil))
+}
+
+// protect sorts the passed candidates with the provided less function and
+// removes up to the given number of candidates from the end of the sorted
+// slice, thereby protecting them from eviction.  The remaining candidates are
+// returned.
+func protect(candidates []EvictionCandidate, n int, less func(a, b *EvictionCandidate) bool) []EvictionCandidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return less(&candidates[i], &candidates[j])
+	})
+	if n > len(candidates) {
+		n = len(candidates)
+	}
+	return candidates[:len(candidates)-n]
+}
+
+// protectNetGroups removes up to the given number of candidates, each from a
+// distinct network group, from the passed candidates, thereby protecting them
+// from eviction.  The longest running candidate in each of the network groups
+// with the highest keyed ordering is protected so that remote peers are unable
+// to predict which groups are protected.  The remaining candidates are
+// returned.
+func protectNetGroups(candidates []EvictionCandidate, n int, key uint64) []EvictionCandidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := &candidates[i], &candidates[j]
+		aKey := keyedNetGroup(key, a.NetGroup)
+		bKey := keyedNetGroup(key, b.NetGroup)
+		if aKey != bKey {
+			return aKey < bKey
+		}
+		return a.ConnectedTime.After(b.ConnectedTime)
+	})
+
+	protected := make(map[string]struct{}, n)
+	remaining := make([]EvictionCandidate, 0, len(candidates))
+	for i := len(candidates) - 1; i >= 0; i-- {
+		c := &candidates[i]
+		if _, ok := protected[c.NetGroup]; !ok && len(protected) < n {
+			protected[c.NetGroup] = struct{}{}
+			continue
+		}
+		remaining = append(remaining, *c)
+	}
+	return remaining
+}
+
+// SelectEvictionCandidate chooses which of the passed inbound connections, if
+// any, should be evicted in order to make room for a new inbound connection.
+// The caller is expected to exclude connections that must never be evicted,
+// such as whitelisted peers, from the candidates.
+//
+// Candidates are protected from eviction based on characteristics that are
+// difficult for an attacker to simultaneously control so that filling the
+// inbound slots does not allow an attacker to eclipse the node.  In order, the
+// following candidates are protected:
+//
+//   - One in each of a number of distinct network groups as determined by
+//     a keyed ordering
+//   - Those with the lowest ping latency
+//   - Those that most recently relayed transactions
+//   - Those that most recently relayed blocks
+//   - Half of the remaining candidates with the longest connection age
+//
+// Of the candidates that remain, the youngest connection in the network group
+// with the most connections is selected.  The second return value is false
+// when every candidate is protected.
+//
+// The netGroupKey should be a random value that is kept private and remains
+// the same for the lifetime of the process.
+func SelectEvictionCandidate(candidates []EvictionCandidate, netGroupKey uint64) (EvictionCandidate, bool) {
+	// Work on a copy so the caller's slice is not reordered.
+	remaining := make([]EvictionCandidate, len(candidates))
+	copy(remaining, candidates)
+
+	remaining = protectNetGroups(remaining, evictProtectNetGroup, netGroupKey)
+	remaining = protect(remaining, evictProtectPing, func(a, b *EvictionCandidate) bool {
+		// Unknown ping times sort as the worst possible latency.
+		if a.PingTime == 0 || b.PingTime == 0 {
+			return a.PingTime == 0 && b.PingTime != 0
+		}
+		return a.PingTime > b.PingTime
+	})
+	remaining = protect(remaining, evictProtectTx, func(a, b *EvictionCandidate) bool {
+		return a.LastTxTime.Before(b.LastTxTime)
+	})
+	remaining = protect(remaining, evictProtectBlock, func(a, b *EvictionCandidate) bool {
+		return a.LastBlockTime.Before(b.LastBlockTime)
+	})
+	remaining = protect(remaining, len(remaining)/2, func(a, b *EvictionCandidate) bool {
+		return a.ConnectedTime.After(b.ConnectedTime)
+	})
+	if len(remaining) == 0 {
+		return EvictionCandidate{}, false
+	}
+
+	// Group the remaining candidates by network group while keeping track
+	// of the youngest connection in each group.
+	type group struct {
+		count    int
+		youngest *EvictionCandidate
+	}
+	groups := make(map[string]*group)
+	for i := range remaining {
+		c := &remaining[i]
+		g, ok := groups[c.NetGroup]
+		if !ok {
+			g = &group{youngest: c}
+			groups[c.NetGroup] = g
+		}
+		g.count++
+		if c.ConnectedTime.After(g.youngest.ConnectedTime) {
+			g.youngest = c
+		}
+	}
+
+	// Choose the group with the most connections, preferring the one with
+	// the youngest connection on ties, and evict its youngest connection.
+	var selected *group
+	for _, g := range groups {
+		if selected == nil || g.count > selected.count ||
+			(g.count == selected.count &&
+				g.youngest.ConnectedTime.After(selected.youngest.ConnectedTime)) {
+			selected = g
+		}
+	}
+	return *selected.youngest, true
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestSelectEvictionCandidate ensures inbound eviction protects candidates by
+// ping latency, recent relay, and connection age and selects the youngest
+// connection from the network group with the most connections.
+func TestSelectEvictionCandidate(t *testing.T) {
+	now := time.Now()
+
+	// makeCandidates returns the given number of candidates which are all in
+	// distinct network groups, have the same ping time, have not relayed
+	// anything, and were connected one minute apart with the first being
+	// the oldest.
+	makeCandidates := func(n int) []EvictionCandidate {
+		candidates := make([]EvictionCandidate, 0, n)
+		for i := 0; i < n; i++ {
+			candidates = append(candidates, EvictionCandidate{
+				ID:            uint64(i),
+				NetGroup:      fmt.Sprintf("10.%d", i),
+				PingTime:      time.Second,
+				ConnectedTime: now.Add(time.Duration(i-n) * time.Minute),
+			})
+		}
+		return candidates
+	}
+
+	// Ensure nothing is evicted when every candidate is protected.
+	candidates := makeCandidates(evictProtectNetGroup)
+	if c, ok := SelectEvictionCandidate(candidates, 0); ok {
+		t.Fatalf("unexpected eviction of %d with all candidates "+
+			"protected", c.ID)
+	}
+	if _, ok := SelectEvictionCandidate(nil, 0); ok {
+		t.Fatal("unexpected eviction with no candidates")
+	}
+
+	// Ensure only one candidate per network group is protected by the
+	// network group protection and that it is the oldest in its group.
+	candidates = makeCandidates(10)
+	for i := range candidates {
+		candidates[i].NetGroup = fmt.Sprintf("10.%d", i%2)
+	}
+	remaining := protectNetGroups(candidates, evictProtectNetGroup, 0)
+	if len(remaining) != len(candidates)-2 {
+		t.Fatalf("unexpected number of unprotected candidates -- got %d, "+
+			"want %d", len(remaining), len(candidates)-2)
+	}
+	for _, c := range remaining {
+		if c.ID == 0 || c.ID == 1 {
+			t.Fatalf("oldest candidate %d in group %s is not protected",
+				c.ID, c.NetGroup)
+		}
+	}
+
+	// Place the ten youngest candidates into a shared network group and
+	// give the older candidates distinct ping times and relay times so
+	// that they are deterministically protected.  Also make the youngest
+	// candidate in the shared group relay a recent block and the second
+	// youngest have the lowest ping time.  The youngest candidate in the
+	// shared group which is not protected should be evicted.
+	const numCandidates = 60
+	candidates = makeCandidates(numCandidates)
+	for i := 0; i < numCandidates-10; i++ {
+		candidates[i].PingTime = time.Duration(i+1) * time.Millisecond
+	}
+	for i := 8; i < 12; i++ {
+		candidates[i].LastTxTime = now
+	}
+	for i := 12; i < 15; i++ {
+		candidates[i].LastBlockTime = now
+	}
+	for i := numCandidates - 10; i < numCandidates; i++ {
+		candidates[i].NetGroup = "192.168"
+	}
+	candidates[numCandidates-1].LastBlockTime = now
+	candidates[numCandidates-2].PingTime = time.Microsecond
+	for key := uint64(0); key < 10; key++ {
+		c, ok := SelectEvictionCandidate(candidates, key)
+		if !ok {
+			t.Fatalf("no eviction candidate selected with key %d", key)
+		}
+		if c.NetGroup != "192.168" {
+			t.Fatalf("evicted candidate %d in group %s with key %d, "+
+				"want group 192.168", c.ID, c.NetGroup, key)
+		}
+		if c.ID == numCandidates-1 || c.ID == numCandidates-2 {
+			t.Fatalf("evicted protected candidate %d with key %d",
+				c.ID, key)
+		}
+	}
+
+	// Ensure the passed candidates are not reordered.
+	for i := range candidates {
+		if candidates[i].ID != uint64(i) {
+			t.Fatalf("candidates were reordered")
+		}
+	}
+}
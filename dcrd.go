@@ -166,6 +166,14 @@ func cdrdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropBlockStatsIndex {
+		if err := indexers.DropBlockStatsIndex(db, interrupt); err != nil {
+			cdrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
 	if cfg.DropCFIndex {
 		if err := indexers.DropCfIndex(db, interrupt); err != nil {
 			cdrdLog.Errorf("%v", err)
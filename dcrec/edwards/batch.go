@@ -0,0 +1,228 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/binary"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// batchCoefficientSize is the size in bytes of the coefficients used to
+// combine the signatures of a batch.
+const batchCoefficientSize = 16
+
+// scMinusOne is the little endian encoding of -1 modulo the group order.
+var scMinusOne = [32]byte{
+	0xec, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58,
+	0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10,
+}
+
+// scGroupOrder is the little endian encoding of the group order.
+var scGroupOrder = [32]byte{
+	0xed, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58,
+	0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10,
+}
+
+// identityBytes is the encoding of the identity element of the group.
+var identityBytes = [32]byte{1}
+
+// windowTable holds the cached multiples 1*P through 15*P of a point for use
+// in windowed scalar multiplication.
+type windowTable [15]cachedGroupElement
+
+// newWindowTable returns the window table for the passed point.
+func newWindowTable(p *edwards25519.ExtendedGroupElement) *windowTable {
+	var t windowTable
+	toCached(&t[0], p)
+	cur := *p
+	var r edwards25519.CompletedGroupElement
+	for i := 1; i < len(t); i++ {
+		geAdd(&r, &cur, &t[0])
+		r.ToExtended(&cur)
+		toCached(&t[i], &cur)
+	}
+	return &t
+}
+
+// scalarMultSum returns the sum of the products of the passed little endian
+// scalars and points.  The points are multiplied simultaneously with 4-bit
+// fixed windows so the doublings are shared between all of the points.  This
+// is variable time and must only be used with public values.
+func scalarMultSum(scalars []*[32]byte, points []*edwards25519.ExtendedGroupElement) *edwards25519.ExtendedGroupElement {
+	tables := make([]*windowTable, len(points))
+	for i, p := range points {
+		tables[i] = newWindowTable(p)
+	}
+
+	var q edwards25519.ExtendedGroupElement
+	var r edwards25519.CompletedGroupElement
+	q.Zero()
+	for i := 63; i >= 0; i-- {
+		for j := 0; j < 4; j++ {
+			q.Double(&r)
+			r.ToExtended(&q)
+		}
+		for n, s := range scalars {
+			nibble := (s[i/2] >> (4 * uint(i%2))) & 0x0f
+			if nibble == 0 {
+				continue
+			}
+			geAdd(&r, &q, &tables[n][nibble-1])
+			r.ToExtended(&q)
+		}
+	}
+	return &q
+}
+
+// isTorsionFree returns whether the passed point is in the prime order subgroup,
+// which is the case when multiplying it by the group order results in the
+// identity element.
+func isTorsionFree(p *edwards25519.ExtendedGroupElement) bool {
+	q := scalarMultSum([]*[32]byte{&scGroupOrder},
+		[]*edwards25519.ExtendedGroupElement{p})
+	var encoded [32]byte
+	q.ToBytes(&encoded)
+	return encoded == identityBytes
+}
+
+// BatchVerify verifies all of the passed Ed25519 signatures, where sigs[i] is
+// the signature of hashes[i] by pubs[i], and returns whether or not they are
+// all valid.
+//
+// Each signature is valid when R = s*B - h*A.  Rather than checking each of
+// these equations separately, the equations are multiplied by coefficients
+// derived from the entire batch and summed, so a single multi-scalar
+// multiplication checks all of them at once.  This is significantly faster
+// than calling Verify for each signature, however, a failed batch does not
+// identify the invalid signatures, so callers must fall back to Verify to find
+// them.
+//
+// Like Verify, the combined equation is not multiplied by the cofactor, so the
+// small order components of R and the public key of different signatures could
+// otherwise cancel each other out and allow a batch containing signatures that
+// Verify rejects to pass.  In order to ensure the result always agrees with
+// Verify, signatures where either point is not in the prime order subgroup are
+// verified individually instead of being added to the combined equation.  Note
+// that checking the subgroup membership of both points costs roughly as much
+// as verifying the signature individually.
+func BatchVerify(pubs []*PublicKey, hashes [][]byte, sigs []*Signature) bool {
+	if len(pubs) != len(sigs) || len(hashes) != len(sigs) {
+		return false
+	}
+	if len(sigs) == 0 {
+		return true
+	}
+
+	// Decode all of the points while performing the same checks as
+	// Verify and derive the seed for the coefficients from the entire
+	// batch.
+	pubBytes := make([]*[32]byte, len(sigs))
+	sigBytes := make([]*[64]byte, len(sigs))
+	batched := make([]int, 0, len(sigs))
+	points := make([]*edwards25519.ExtendedGroupElement, 0, 2*len(sigs))
+	seed := sha512.New()
+	for i, sig := range sigs {
+		pub := pubs[i]
+		if pub == nil || hashes[i] == nil || sig == nil || sig.R == nil ||
+			sig.S == nil {
+			return false
+		}
+		pubBytes[i] = copyBytes(pub.Serialize())
+		sigBytes[i] = copyBytes64(sig.Serialize())
+		if sigBytes[i][63]&224 != 0 {
+			return false
+		}
+
+		var a, r edwards25519.ExtendedGroupElement
+		if !a.FromBytes(pubBytes[i]) {
+			return false
+		}
+		var rBytes, encoded [32]byte
+		copy(rBytes[:], sigBytes[i][:32])
+		if !r.FromBytes(&rBytes) {
+			return false
+		}
+		r.ToBytes(&encoded)
+		if encoded != rBytes {
+			return false
+		}
+
+		// Verify signatures with points that have a small order
+		// component individually.
+		if !isTorsionFree(&a) || !isTorsionFree(&r) {
+			if !Verify(pub, hashes[i], sig.R, sig.S) {
+				return false
+			}
+			continue
+		}
+		batched = append(batched, i)
+		points = append(points, &r, &a)
+
+		var msgLen [8]byte
+		binary.LittleEndian.PutUint64(msgLen[:], uint64(len(hashes[i])))
+		seed.Write(pubBytes[i][:])
+		seed.Write(sigBytes[i][:])
+		seed.Write(msgLen[:])
+		seed.Write(hashes[i])
+	}
+	if len(batched) == 0 {
+		return true
+	}
+	var seedDigest [sha512.Size + 4]byte
+	seed.Sum(seedDigest[:0])
+
+	// Sum z_i*R_i + z_i*h_i*A_i over the batched signatures along with
+	// -(sum z_i*s_i)*B and ensure the result is the identity.
+	var zero, sSum [32]byte
+	scalars := make([]*[32]byte, 0, 2*len(batched))
+	for j, i := range batched {
+		z := new([32]byte)
+		if j == 0 {
+			z[0] = 1
+		} else {
+			binary.LittleEndian.PutUint32(seedDigest[sha512.Size:],
+				uint32(j))
+			digest := sha512.Sum512(seedDigest[:])
+			copy(z[:batchCoefficientSize], digest[:])
+		}
+
+		h := sha512.New()
+		h.Write(sigBytes[i][:32])
+		h.Write(pubBytes[i][:])
+		h.Write(hashes[i])
+		var digest [64]byte
+		h.Sum(digest[:0])
+		var hReduced, s [32]byte
+		edwards25519.ScReduce(&hReduced, &digest)
+		copy(s[:], sigBytes[i][32:])
+
+		zh := new([32]byte)
+		edwards25519.ScMulAdd(zh, z, &hReduced, &zero)
+		edwards25519.ScMulAdd(&sSum, z, &s, &sSum)
+		scalars = append(scalars, z, zh)
+	}
+	q := scalarMultSum(scalars, points)
+
+	var negSSum [32]byte
+	var sB edwards25519.ExtendedGroupElement
+	var sBCached cachedGroupElement
+	edwards25519.ScMulAdd(&negSSum, &sSum, &scMinusOne, &zero)
+	edwards25519.GeScalarMultBase(&sB, &negSSum)
+	toCached(&sBCached, &sB)
+	var r edwards25519.CompletedGroupElement
+	geAdd(&r, q, &sBCached)
+	r.ToExtended(q)
+
+	var result [32]byte
+	q.ToBytes(&result)
+	return bytes.Equal(result[:], identityBytes[:])
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"crypto/sha512"
+	"math/big"
+	"testing"
+
+	"github.com/agl/ed25519/edwards25519"
+)
+
+// batchParams splits the passed signature parameters into the arguments of
+// BatchVerify.
+func batchParams(sigList []*SignatureVerParams) ([]*PublicKey, [][]byte, []*Signature) {
+	pubs := make([]*PublicKey, len(sigList))
+	msgs := make([][]byte, len(sigList))
+	sigs := make([]*Signature, len(sigList))
+	for i, p := range sigList {
+		pubs[i], msgs[i], sigs[i] = p.pubkey, p.msg, p.sig
+	}
+	return pubs, msgs, sigs
+}
+
+// TestBatchVerify ensures batch verification accepts batches of valid
+// signatures and rejects batches which contain an invalid signature.
+func TestBatchVerify(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+
+	numSigs := 64
+	sigList := randSigList(curve, numSigs)
+	pubs, msgs, sigs := batchParams(sigList)
+
+	for _, n := range []int{0, 1, 2, 7, numSigs} {
+		if !BatchVerify(pubs[:n], msgs[:n], sigs[:n]) {
+			t.Fatalf("batch of %d valid signatures failed", n)
+		}
+	}
+
+	// Corrupt each of the signature, message, and public key of an entry
+	// in turn and ensure the batch fails.
+	badS := &Signature{sigs[5].R, new(big.Int).Add(sigs[5].S, big.NewInt(1))}
+	badSigs := append([]*Signature{}, sigs...)
+	badSigs[5] = badS
+	if BatchVerify(pubs, msgs, badSigs) {
+		t.Fatal("batch with a modified s value passed")
+	}
+
+	badMsgs := append([][]byte{}, msgs...)
+	badMsgs[9] = append([]byte{}, msgs[9]...)
+	badMsgs[9][0] ^= 0x01
+	if BatchVerify(pubs, badMsgs, sigs) {
+		t.Fatal("batch with a modified message passed")
+	}
+
+	badPubs := append([]*PublicKey{}, pubs...)
+	badPubs[3], badPubs[4] = pubs[4], pubs[3]
+	if BatchVerify(badPubs, msgs, sigs) {
+		t.Fatal("batch with swapped public keys passed")
+	}
+
+	// Swapping the messages of two signatures must fail even though the
+	// batch contains the same set of messages.
+	badMsgs = append([][]byte{}, msgs...)
+	badMsgs[2], badMsgs[3] = msgs[3], msgs[2]
+	if BatchVerify(pubs, badMsgs, sigs) {
+		t.Fatal("batch with swapped messages passed")
+	}
+
+	if BatchVerify(pubs[:2], msgs[:1], sigs[:2]) {
+		t.Fatal("batch with mismatched lengths passed")
+	}
+}
+
+// orderTwoPointBytes is the encoding of the point of order two, (0, -1).
+var orderTwoPointBytes = [32]byte{
+	0xec, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f,
+}
+
+// torsionSig returns a public key and a signature of the passed message which
+// are derived from the passed seed.  The point of order two is added to R and,
+// when requested, to the public key.
+func torsionSig(t *testing.T, seed byte, msg []byte, pubTorsion bool) (*PublicKey, *Signature) {
+	t.Helper()
+
+	// scalarFromSeed derives a scalar from the seed and a tag.
+	scalarFromSeed := func(tag byte) *[32]byte {
+		digest := sha512.Sum512([]byte{seed, tag})
+		var sc [32]byte
+		edwards25519.ScReduce(&sc, &digest)
+		return &sc
+	}
+
+	// addTorsion adds the point of order two to the passed point.
+	var torsion edwards25519.ExtendedGroupElement
+	torsion.FromBytes(&orderTwoPointBytes)
+	var torsionCached cachedGroupElement
+	toCached(&torsionCached, &torsion)
+	addTorsion := func(p *edwards25519.ExtendedGroupElement) {
+		var r edwards25519.CompletedGroupElement
+		geAdd(&r, p, &torsionCached)
+		r.ToExtended(p)
+	}
+
+	a, nonce := scalarFromSeed(0), scalarFromSeed(1)
+	var pubPoint, rPoint edwards25519.ExtendedGroupElement
+	edwards25519.GeScalarMultBase(&pubPoint, a)
+	edwards25519.GeScalarMultBase(&rPoint, nonce)
+	if pubTorsion {
+		addTorsion(&pubPoint)
+	}
+	addTorsion(&rPoint)
+	var pubBytes, rBytes [32]byte
+	pubPoint.ToBytes(&pubBytes)
+	rPoint.ToBytes(&rBytes)
+
+	// s = nonce + h*a where h = H(R || A || msg).
+	h := sha512.New()
+	h.Write(rBytes[:])
+	h.Write(pubBytes[:])
+	h.Write(msg)
+	var digest [64]byte
+	h.Sum(digest[:0])
+	var hReduced, s [32]byte
+	edwards25519.ScReduce(&hReduced, &digest)
+	edwards25519.ScMulAdd(&s, &hReduced, a, nonce)
+
+	curve := Edwards()
+	pub, err := ParsePubKey(curve, pubBytes[:])
+	if err != nil {
+		t.Fatalf("failed to parse public key: %v", err)
+	}
+	sig, err := ParseSignature(curve, append(rBytes[:], s[:]...))
+	if err != nil {
+		t.Fatalf("failed to parse signature: %v", err)
+	}
+	return pub, sig
+}
+
+// TestBatchVerifyTorsion ensures batches containing signatures with points
+// that have a small order component agree with Verify.  In particular, a pair
+// of signatures which Verify rejects only because of the small order
+// component of R must not be able to cancel each other out.
+func TestBatchVerifyTorsion(t *testing.T) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+	pubs, msgs, sigs := batchParams(randSigList(curve, 4))
+
+	for i := byte(0); i < 32; i++ {
+		msg1 := []byte{i, 1}
+		msg2 := []byte{i, 2}
+		pub1, sig1 := torsionSig(t, 2*i, msg1, false)
+		pub2, sig2 := torsionSig(t, 2*i+1, msg2, false)
+		if Verify(pub1, msg1, sig1.R, sig1.S) ||
+			Verify(pub2, msg2, sig2.R, sig2.S) {
+			t.Fatalf("%d: torsion signature unexpectedly verified", i)
+		}
+		if BatchVerify([]*PublicKey{pub1, pub2}, [][]byte{msg1, msg2},
+			[]*Signature{sig1, sig2}) {
+			t.Fatalf("%d: batch of torsion signatures passed", i)
+		}
+		if BatchVerify(append(pubs, pub1, pub2),
+			append(msgs, msg1, msg2), append(sigs, sig1, sig2)) {
+			t.Fatalf("%d: batch with torsion signatures passed", i)
+		}
+	}
+
+	// Adding the point of order two to both R and the public key results
+	// in a signature that Verify accepts when the challenge is odd.  Ensure
+	// the batch agrees with Verify for both cases.
+	for i := byte(0); i < 8; i++ {
+		msg := []byte{i, 3}
+		pub, sig := torsionSig(t, 64+i, msg, true)
+		want := Verify(pub, msg, sig.R, sig.S)
+		got := BatchVerify(append(pubs, pub), append(msgs, msg),
+			append(sigs, sig))
+		if got != want {
+			t.Fatalf("%d: mismatched batch result -- got %v, want %v",
+				i, got, want)
+		}
+	}
+}
+
+func benchmarkBatchVerification(b *testing.B, numSigs int) {
+	curve := new(TwistedEdwardsCurve)
+	curve.InitParam25519()
+	pubs, msgs, sigs := batchParams(randSigList(curve, numSigs))
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if !BatchVerify(pubs, msgs, sigs) {
+			b.Fatal("batch failed")
+		}
+	}
+}
+
+func BenchmarkBatchVerification16(b *testing.B)  { benchmarkBatchVerification(b, 16) }
+func BenchmarkBatchVerification128(b *testing.B) { benchmarkBatchVerification(b, 128) }
@@ -64,6 +64,9 @@ func GenerateSharedSecret(privkey *PrivateKey, pubkey *PublicKey) []byte {
 // The primary aim is to ensure byte compatibility with Pyelliptic.
 // Additionally, refer to section 5.8.1 of ANSI X9.63 for rationale on this
 // format.
+//
+// This format is retained for compatibility.  New code should use the versioned
+// and authenticated EncryptAEAD instead.
 func Encrypt(curve *TwistedEdwardsCurve, pubkey *PublicKey, in []byte) ([]byte,
 	error) {
 	ephemeral, err := GeneratePrivateKey(curve)
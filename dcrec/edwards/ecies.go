@@ -0,0 +1,249 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/commanderu/cdrd/cdrec/internal/ecies"
+	"golang.org/x/crypto/curve25519"
+)
+
+// CipherSuite identifies the authenticated cipher used to encrypt messages with
+// EncryptAEAD and NewEncryptWriter.
+type CipherSuite byte
+
+// These constants define the supported cipher suites.
+const (
+	// CipherChaCha20Poly1305 encrypts messages with ChaCha20-Poly1305.
+	CipherChaCha20Poly1305 CipherSuite = ecies.ChaCha20Poly1305
+
+	// CipherAES256GCM encrypts messages with AES-256-GCM.
+	CipherAES256GCM CipherSuite = ecies.AES256GCM
+)
+
+var (
+	// ErrUnsupportedVersion occurs when decrypting a message created with an
+	// unknown version of the authenticated encryption scheme.
+	ErrUnsupportedVersion = ecies.ErrUnsupportedVersion
+
+	// ErrUnsupportedCipherSuite occurs when an unknown cipher suite is
+	// requested or specified by a message.
+	ErrUnsupportedCipherSuite = ecies.ErrUnsupportedCipherSuite
+
+	// ErrMessageAuthentication occurs when a message fails to authenticate
+	// during decryption.  This happens because of either an invalid private
+	// key, mismatched associated data or a corrupt or truncated ciphertext.
+	ErrMessageAuthentication = ecies.ErrAuthentication
+
+	// errLowOrderPoint occurs when a public key or the ECDH shared secret is
+	// a point of small order, which would make the shared secret
+	// predictable.
+	errLowOrderPoint = errors.New("low order point")
+)
+
+// eciesInfo separates the keys derived for X25519 messages from those of other
+// curves.
+const eciesInfo = "commanderu ECIES v1 X25519"
+
+// edwardsToMontgomery converts the y coordinate of an Ed25519 point to the u
+// coordinate of the birationally equivalent Curve25519 point, which is
+// u = (1 + y) / (1 - y), encoded as 32 little endian bytes as used by X25519.
+func edwardsToMontgomery(curve *TwistedEdwardsCurve, y *big.Int) ([]byte, error) {
+	denom := new(big.Int).Sub(one, y)
+	denom.Mod(denom, curve.P)
+	if denom.Sign() == 0 {
+		return nil, errLowOrderPoint
+	}
+	u := new(big.Int).Add(one, y)
+	u.Mul(u, curve.invert(denom))
+	u.Mod(u, curve.P)
+	return BigIntToEncodedBytes(u)[:], nil
+}
+
+// montgomeryToEdwards converts the little endian u coordinate of a Curve25519
+// point to one of the two birationally equivalent Ed25519 points, using
+// y = (u - 1) / (u + 1).  Since the u coordinate does not determine the sign of
+// x, the point with positive x is returned.  This does not affect the u
+// coordinate of any multiple of the point.
+func montgomeryToEdwards(curve *TwistedEdwardsCurve, uBytes []byte) (*big.Int,
+	*big.Int, error) {
+
+	u := EncodedBytesToBigInt(copyBytes(uBytes))
+	if u.Cmp(curve.P) >= 0 {
+		return nil, nil, errors.New("non-canonical X25519 public key")
+	}
+	denom := new(big.Int).Add(u, one)
+	denom.Mod(denom, curve.P)
+	if denom.Sign() == 0 {
+		return nil, nil, errLowOrderPoint
+	}
+	y := new(big.Int).Sub(u, one)
+	y.Mul(y, curve.invert(denom))
+	y.Mod(y, curve.P)
+
+	// Decoding the encoded y coordinate recovers x and fails for points
+	// on the twist of the curve.
+	return curve.EncodedBytesToBigIntPoint(BigIntToEncodedBytes(y))
+}
+
+// x25519SharedSecret returns the X25519 shared secret of the recipient's
+// private key and the ephemeral X25519 public key.
+func x25519SharedSecret(curve *TwistedEdwardsCurve, priv *PrivateKey,
+	ephemeral []byte) ([]byte, error) {
+
+	// Private keys created from an Ed25519 secret use the clamped hash of
+	// the secret as scalar, which is exactly the X25519 private key.
+	if priv.secret != nil {
+		var pk [PrivKeyBytesLen]byte
+		copy(pk[:], priv.secret[:])
+		scalar := computeScalar(&pk)
+		defer zeroSlice(scalar[:])
+		defer zeroSlice(pk[:])
+		secret, err := curve25519.X25519(scalar[:], ephemeral)
+		if err != nil {
+			return nil, errLowOrderPoint
+		}
+		return secret, nil
+	}
+
+	// Other private keys are arbitrary scalars which are not clamped as
+	// X25519 requires, so the multiplication is performed on the Edwards
+	// form of the ephemeral key instead.  Unlike with clamped scalars, a
+	// point with a small order component would leak information about the
+	// scalar, so the point must be in the prime order subgroup.
+	x, y, err := montgomeryToEdwards(curve, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	nx, ny := curve.ScalarMult(x, y, curve.N.Bytes())
+	if nx == nil || nx.Sign() != 0 || ny.Cmp(one) != 0 {
+		return nil, errLowOrderPoint
+	}
+	_, sy := curve.ScalarMult(x, y, priv.GetD().Bytes())
+	if sy == nil {
+		return nil, errLowOrderPoint
+	}
+	return edwardsToMontgomery(curve, sy)
+}
+
+// NewEncryptWriter returns a writer which encrypts everything written to it for
+// the target public key and writes the result to w.  The writer must be closed
+// to complete the message, which does not close w.
+//
+// The target Ed25519 public key is converted to its X25519 form and the message
+// starts with a version byte, the cipher suite and a freshly generated
+// ephemeral X25519 public key, so messages may also be created with any X25519
+// implementation.  The symmetric key is derived with HKDF-SHA256 from the
+// X25519 shared secret and the plaintext is then encrypted in authenticated
+// chunks of 64KiB so that large payloads never need to be held in memory.  The
+// optional associated data is authenticated along with every chunk but not
+// included in the message, so the same associated data must be provided to
+// decrypt it.
+func NewEncryptWriter(curve *TwistedEdwardsCurve, w io.Writer,
+	pubkey *PublicKey, associatedData []byte,
+	suite CipherSuite) (io.WriteCloser, error) {
+
+	recipient, err := edwardsToMontgomery(curve, pubkey.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	var scalar [32]byte
+	if _, err := io.ReadFull(rand.Reader, scalar[:]); err != nil {
+		return nil, err
+	}
+	defer zeroSlice(scalar[:])
+	ephemeral, err := curve25519.X25519(scalar[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := curve25519.X25519(scalar[:], recipient)
+	if err != nil {
+		return nil, errLowOrderPoint
+	}
+	defer zeroSlice(secret)
+
+	return ecies.NewWriter(w, &ecies.Params{
+		Suite:          byte(suite),
+		Ephemeral:      ephemeral,
+		Recipient:      recipient,
+		Secret:         secret,
+		Info:           eciesInfo,
+		AssociatedData: associatedData,
+	})
+}
+
+// NewDecryptReader returns a reader which decrypts a message created with
+// NewEncryptWriter or EncryptAEAD that is read from r.  Plaintext is only
+// returned once the chunk containing it has been authenticated, and
+// ErrMessageAuthentication is returned when any part of the message fails to
+// authenticate.
+func NewDecryptReader(curve *TwistedEdwardsCurve, r io.Reader,
+	priv *PrivateKey, associatedData []byte) (io.Reader, error) {
+
+	suite, ephemeral, err := ecies.ReadHeader(r, PubKeyBytesLen)
+	if err != nil {
+		return nil, err
+	}
+	_, pubY := priv.Public()
+	recipient, err := edwardsToMontgomery(curve, pubY)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := x25519SharedSecret(curve, priv, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroSlice(secret)
+
+	return ecies.NewReader(r, &ecies.Params{
+		Suite:          suite,
+		Ephemeral:      ephemeral,
+		Recipient:      recipient,
+		Secret:         secret,
+		Info:           eciesInfo,
+		AssociatedData: associatedData,
+	})
+}
+
+// EncryptAEAD encrypts the plaintext for the target public key and
+// authenticates it along with the optional associated data.  See
+// NewEncryptWriter for details of the scheme, which is versioned and unrelated
+// to the format of Encrypt.
+func EncryptAEAD(curve *TwistedEdwardsCurve, pubkey *PublicKey, plaintext,
+	associatedData []byte, suite CipherSuite) ([]byte, error) {
+
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(curve, &buf, pubkey, associatedData, suite)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptAEAD decrypts a message created with EncryptAEAD or NewEncryptWriter
+// using the same associated data it was encrypted with.
+func DecryptAEAD(curve *TwistedEdwardsCurve, priv *PrivateKey, ciphertext,
+	associatedData []byte) ([]byte, error) {
+
+	r, err := NewDecryptReader(curve, bytes.NewReader(ciphertext), priv,
+		associatedData)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package edwards
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// TestX25519Conversion ensures the X25519 forms of Ed25519 keys and the shared
+// secrets computed from them match those of an independent X25519
+// implementation.
+func TestX25519Conversion(t *testing.T) {
+	c := new(TwistedEdwardsCurve)
+	c.InitParam25519()
+
+	secret, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5" +
+		"697b326919703bac031cae7f60")
+	privkey, pubkey := PrivKeyFromSecret(c, secret)
+	if privkey == nil {
+		t.Fatal("failed to create private key")
+	}
+
+	// The X25519 private key of an Ed25519 secret is the clamped first
+	// half of its SHA512 hash.
+	digest := sha512.Sum512(secret)
+	xPriv := digest[:32]
+	wantPub, err := curve25519.X25519(xPriv, curve25519.Basepoint)
+	if err != nil {
+		t.Fatalf("X25519: unexpected error: %v", err)
+	}
+	xPub, err := edwardsToMontgomery(c, pubkey.Y)
+	if err != nil {
+		t.Fatalf("edwardsToMontgomery: unexpected error: %v", err)
+	}
+	if !bytes.Equal(xPub, wantPub) {
+		t.Fatalf("edwardsToMontgomery: got %x, want %x", xPub, wantPub)
+	}
+
+	ephemeral := bytes.Repeat([]byte{0x77}, 32)
+	ephemeralPub, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		t.Fatalf("X25519: unexpected error: %v", err)
+	}
+	want, err := curve25519.X25519(ephemeral, xPub)
+	if err != nil {
+		t.Fatalf("X25519: unexpected error: %v", err)
+	}
+	got, err := x25519SharedSecret(c, privkey, ephemeralPub)
+	if err != nil {
+		t.Fatalf("x25519SharedSecret: unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("x25519SharedSecret: got %x, want %x", got, want)
+	}
+
+	// Points of small order must be rejected for keys created from a
+	// secret as well as arbitrary scalars, which must also reject points on
+	// the twist and points with a small order component.
+	generated, err := GeneratePrivateKey(c)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	lowOrder := make([]byte, 32)
+	lowOrder[0] = 1
+	for _, key := range []*PrivateKey{privkey, generated} {
+		if _, err := x25519SharedSecret(c, key, lowOrder); err == nil {
+			t.Error("x25519SharedSecret: no error for low order point")
+		}
+	}
+	twist := make([]byte, 32)
+	twist[0] = 2
+	if _, err := x25519SharedSecret(c, generated, twist); err == nil {
+		t.Error("x25519SharedSecret: no error for point on twist")
+	}
+
+	// Add the point of order four to the ephemeral key to obtain a point
+	// with a small order component.
+	ex, ey, err := montgomeryToEdwards(c, ephemeralPub)
+	if err != nil {
+		t.Fatalf("montgomeryToEdwards: unexpected error: %v", err)
+	}
+	tx, ty, err := montgomeryToEdwards(c, lowOrder)
+	if err != nil {
+		t.Fatalf("montgomeryToEdwards: unexpected error: %v", err)
+	}
+	_, my := c.Add(ex, ey, tx, ty)
+	mixed, err := edwardsToMontgomery(c, my)
+	if err != nil {
+		t.Fatalf("edwardsToMontgomery: unexpected error: %v", err)
+	}
+	if _, err := x25519SharedSecret(c, generated, mixed); err == nil {
+		t.Error("x25519SharedSecret: no error for point with small " +
+			"order component")
+	}
+}
+
+// TestCipheringAEAD ensures messages encrypted with the authenticated scheme
+// round trip for all cipher suites and key types and that decryption fails
+// with the wrong key or associated data.
+func TestCipheringAEAD(t *testing.T) {
+	c := new(TwistedEdwardsCurve)
+	c.InitParam25519()
+
+	generated, err := GeneratePrivateKey(c)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	fromSecret, _ := PrivKeyFromSecret(c, bytes.Repeat([]byte{0x11}, 32))
+	otherKey, err := GeneratePrivateKey(c)
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+
+	in := []byte("Hey there dude. How are you doing? This is a test.")
+	ad := []byte("associated data")
+	for _, privkey := range []*PrivateKey{generated, fromSecret} {
+		pkx, pky := privkey.Public()
+		pubkey := NewPublicKey(c, pkx, pky)
+		for _, suite := range []CipherSuite{CipherChaCha20Poly1305,
+			CipherAES256GCM} {
+
+			out, err := EncryptAEAD(c, pubkey, in, ad, suite)
+			if err != nil {
+				t.Fatalf("suite %d: failed to encrypt: %v", suite,
+					err)
+			}
+			dec, err := DecryptAEAD(c, privkey, out, ad)
+			if err != nil {
+				t.Fatalf("suite %d: failed to decrypt: %v", suite,
+					err)
+			}
+			if !bytes.Equal(in, dec) {
+				t.Errorf("suite %d: decrypted data doesn't match "+
+					"original", suite)
+			}
+			_, err = DecryptAEAD(c, otherKey, out, ad)
+			if err != ErrMessageAuthentication {
+				t.Errorf("suite %d: unexpected error decrypting "+
+					"with the wrong key: %v", suite, err)
+			}
+			_, err = DecryptAEAD(c, privkey, out, nil)
+			if err != ErrMessageAuthentication {
+				t.Errorf("suite %d: unexpected error decrypting "+
+					"with the wrong associated data: %v", suite,
+					err)
+			}
+		}
+	}
+}
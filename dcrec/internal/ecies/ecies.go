@@ -0,0 +1,343 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package ecies implements the curve independent parts of the versioned and
+// authenticated elliptic curve integrated encryption scheme offered by the
+// secp256k1 and edwards packages.
+//
+// A message consists of a header followed by a sequence of encrypted chunks:
+//
+//	struct {
+//		Version   byte   // currently 1
+//		Suite     byte   // ChaCha20Poly1305 or AES256GCM
+//		Ephemeral []byte // curve specific serialized ephemeral public key
+//		Chunks    []byte // one or more sealed chunks
+//	}
+//
+// The symmetric key is derived with HKDF-SHA256 from the ECDH shared secret
+// using the ephemeral and recipient public keys as salt and a curve specific
+// info string.  The plaintext is split into chunks of ChunkSize bytes which are
+// sealed individually so that large payloads can be encrypted and decrypted
+// in a streaming manner.  The nonce of every chunk is its 88-bit big endian
+// index followed by a byte which is 1 for the final chunk and 0 otherwise,
+// which prevents chunks from being reordered, dropped or truncated.  Every
+// chunk authenticates the header along with the caller supplied associated
+// data.
+package ecies
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// Version is the version of the message format.
+	Version = 1
+
+	// ChaCha20Poly1305 identifies the ChaCha20-Poly1305 AEAD cipher suite.
+	ChaCha20Poly1305 = 1
+
+	// AES256GCM identifies the AES-256-GCM AEAD cipher suite.
+	AES256GCM = 2
+
+	// ChunkSize is the maximum number of plaintext bytes sealed in a chunk.
+	ChunkSize = 64 * 1024
+
+	// keySize is the size of the symmetric key for both cipher suites.
+	keySize = 32
+
+	// nonceSize is the size of the nonce for both cipher suites.
+	nonceSize = 12
+)
+
+var (
+	// ErrUnsupportedVersion indicates a message was created with an unknown
+	// version of the format.
+	ErrUnsupportedVersion = errors.New("unsupported ECIES version")
+
+	// ErrUnsupportedCipherSuite indicates an unknown cipher suite was
+	// requested or specified by a message.
+	ErrUnsupportedCipherSuite = errors.New("unsupported ECIES cipher suite")
+
+	// ErrAuthentication indicates a chunk of a message failed to
+	// authenticate.  This happens because of either an invalid private key,
+	// mismatched associated data or a corrupt or truncated ciphertext.
+	ErrAuthentication = errors.New("message authentication failed")
+
+	// errClosed is returned when writing to a closed writer.
+	errClosed = errors.New("write to closed ECIES writer")
+)
+
+// Params houses the curve specific values needed to derive the symmetric key
+// of a message.
+type Params struct {
+	// Suite is the AEAD cipher suite of the message.
+	Suite byte
+
+	// Ephemeral is the serialized ephemeral public key of the message.
+	Ephemeral []byte
+
+	// Recipient is the serialized public key of the recipient.
+	Recipient []byte
+
+	// Secret is the ECDH shared secret of the ephemeral and recipient keys.
+	Secret []byte
+
+	// Info separates the derived keys of the different curves.
+	Info string
+
+	// AssociatedData is authenticated along with every chunk but not
+	// encrypted.
+	AssociatedData []byte
+}
+
+// header returns the serialized message header for the parameters.
+func (p *Params) header() []byte {
+	header := make([]byte, 0, 2+len(p.Ephemeral))
+	header = append(header, Version, p.Suite)
+	return append(header, p.Ephemeral...)
+}
+
+// aead derives the symmetric key for the parameters and returns the
+// corresponding AEAD cipher along with the additional data to authenticate
+// with every chunk.
+func (p *Params) aead() (cipher.AEAD, []byte, error) {
+	salt := make([]byte, 0, len(p.Ephemeral)+len(p.Recipient))
+	salt = append(salt, p.Ephemeral...)
+	salt = append(salt, p.Recipient...)
+	var key [keySize]byte
+	kdf := hkdf.New(sha256.New, p.Secret, salt, []byte(p.Info))
+	if _, err := io.ReadFull(kdf, key[:]); err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		for i := range key {
+			key[i] = 0
+		}
+	}()
+
+	var aead cipher.AEAD
+	switch p.Suite {
+	case ChaCha20Poly1305:
+		c, err := chacha20poly1305.New(key[:])
+		if err != nil {
+			return nil, nil, err
+		}
+		aead = c
+	case AES256GCM:
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, nil, err
+		}
+		c, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, nil, err
+		}
+		aead = c
+	default:
+		return nil, nil, ErrUnsupportedCipherSuite
+	}
+
+	header := p.header()
+	ad := make([]byte, 0, len(header)+len(p.AssociatedData))
+	ad = append(ad, header...)
+	ad = append(ad, p.AssociatedData...)
+	return aead, ad, nil
+}
+
+// chunkNonce returns the nonce of the chunk with the given index.
+func chunkNonce(index uint64, last bool) []byte {
+	var nonce [nonceSize]byte
+	binary.BigEndian.PutUint64(nonce[3:11], index)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce[:]
+}
+
+// ReadHeader reads the header of a message from r.  The version is checked and
+// the cipher suite along with the ephemeral public key of ephemeralLen bytes
+// are returned.
+func ReadHeader(r io.Reader, ephemeralLen int) (byte, []byte, error) {
+	header := make([]byte, 2+ephemeralLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return 0, nil, ErrAuthentication
+		}
+		return 0, nil, err
+	}
+	if header[0] != Version {
+		return 0, nil, ErrUnsupportedVersion
+	}
+	if header[1] != ChaCha20Poly1305 && header[1] != AES256GCM {
+		return 0, nil, ErrUnsupportedCipherSuite
+	}
+	return header[1], header[2:], nil
+}
+
+// encrypter seals the plaintext written to it in chunks.
+type encrypter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	ad    []byte
+	index uint64
+	buf   []byte
+	err   error
+}
+
+// NewWriter writes the header of a message with the provided parameters to w
+// and returns a writer which encrypts everything written to it to w.  The
+// writer must be closed to write the final chunk.
+func NewWriter(w io.Writer, p *Params) (io.WriteCloser, error) {
+	aead, ad, err := p.aead()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(p.header()); err != nil {
+		return nil, err
+	}
+	return &encrypter{
+		w:    w,
+		aead: aead,
+		ad:   ad,
+		buf:  make([]byte, 0, ChunkSize+aead.Overhead()),
+	}, nil
+}
+
+// flush seals the buffered plaintext as the next chunk and writes it.
+func (e *encrypter) flush(last bool) {
+	sealed := e.aead.Seal(e.buf[:0], chunkNonce(e.index, last), e.buf, e.ad)
+	_, e.err = e.w.Write(sealed)
+	e.index++
+	e.buf = e.buf[:0]
+}
+
+// Write encrypts p.  Full chunks are only written once more plaintext follows
+// since the final chunk is sealed differently.
+//
+// This is part of the io.Writer interface.
+func (e *encrypter) Write(p []byte) (int, error) {
+	var n int
+	for e.err == nil && len(p) > 0 {
+		if len(e.buf) == ChunkSize {
+			e.flush(false)
+			continue
+		}
+		c := copy(e.buf[len(e.buf):ChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, e.err
+}
+
+// Close writes the final chunk.  It does not close the underlying writer.
+//
+// This is part of the io.Closer interface.
+func (e *encrypter) Close() error {
+	if e.err == errClosed {
+		return nil
+	}
+	if e.err != nil {
+		return e.err
+	}
+	e.flush(true)
+	if e.err != nil {
+		return e.err
+	}
+	e.err = errClosed
+	return nil
+}
+
+// decrypter opens the chunks read from the underlying reader.
+type decrypter struct {
+	r         io.Reader
+	aead      cipher.AEAD
+	ad        []byte
+	index     uint64
+	buf       []byte
+	lookahead []byte
+	plain     []byte
+	done      bool
+	err       error
+}
+
+// NewReader returns a reader which decrypts the chunks of a message read from
+// r.  The header of the message must already have been consumed by ReadHeader.
+// Plaintext is only returned once the chunk containing it was authenticated
+// and the reader returns ErrAuthentication when any chunk fails to
+// authenticate.
+func NewReader(r io.Reader, p *Params) (io.Reader, error) {
+	aead, ad, err := p.aead()
+	if err != nil {
+		return nil, err
+	}
+	return &decrypter{
+		r:    r,
+		aead: aead,
+		ad:   ad,
+		// One extra byte is read to determine whether a full chunk
+		// is the final one.
+		buf: make([]byte, ChunkSize+aead.Overhead()+1),
+	}, nil
+}
+
+// readChunk reads and opens the next chunk.
+func (d *decrypter) readChunk() error {
+	full := ChunkSize + d.aead.Overhead()
+	n := copy(d.buf, d.lookahead)
+	d.lookahead = nil
+	m, err := io.ReadFull(d.r, d.buf[n:full+1])
+	n += m
+	last := false
+	switch err {
+	case nil:
+		// Preserve the lookahead byte since the chunk is opened in
+		// place.
+		d.lookahead = []byte{d.buf[full]}
+		n = full
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+	if n < d.aead.Overhead() {
+		return ErrAuthentication
+	}
+
+	plain, err := d.aead.Open(d.buf[:0], chunkNonce(d.index, last),
+		d.buf[:n], d.ad)
+	if err != nil {
+		return ErrAuthentication
+	}
+	d.index++
+	d.plain = plain
+	d.done = last
+	return nil
+}
+
+// Read reads decrypted plaintext into p.
+//
+// This is part of the io.Reader interface.
+func (d *decrypter) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.readChunk()
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ecies
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+)
+
+// testParams returns parameters with fixed keys for the given cipher suite.
+func testParams(suite byte) *Params {
+	return &Params{
+		Suite:          suite,
+		Ephemeral:      bytes.Repeat([]byte{0x02}, 33),
+		Recipient:      bytes.Repeat([]byte{0x03}, 33),
+		Secret:         bytes.Repeat([]byte{0x42}, 32),
+		Info:           "test",
+		AssociatedData: []byte("associated data"),
+	}
+}
+
+// seal encrypts the plaintext with the parameters by writing it in pieces of
+// the provided size.
+func seal(t *testing.T, p *Params, plaintext []byte, piece int) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, p)
+	if err != nil {
+		t.Fatalf("NewWriter: unexpected error: %v", err)
+	}
+	for len(plaintext) > 0 {
+		n := piece
+		if n > len(plaintext) {
+			n = len(plaintext)
+		}
+		if _, err := w.Write(plaintext[:n]); err != nil {
+			t.Fatalf("Write: unexpected error: %v", err)
+		}
+		plaintext = plaintext[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+	if _, err := w.Write([]byte{0}); err == nil {
+		t.Fatalf("Write after Close did not return an error")
+	}
+	return buf.Bytes()
+}
+
+// open decrypts the message with the parameters.
+func open(p *Params, msg []byte, oneByte bool) ([]byte, error) {
+	var r io.Reader = bytes.NewReader(msg)
+	if oneByte {
+		r = iotest.OneByteReader(r)
+	}
+	suite, ephemeral, err := ReadHeader(r, len(p.Ephemeral))
+	if err != nil {
+		return nil, err
+	}
+	if suite != p.Suite || !bytes.Equal(ephemeral, p.Ephemeral) {
+		return nil, ErrAuthentication
+	}
+	dr, err := NewReader(r, p)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(dr)
+}
+
+// TestStreamChunking ensures messages round trip for plaintext sizes around
+// the chunk boundaries regardless of how the plaintext is written and read and
+// that any modification of the chunk sequence is detected.
+func TestStreamChunking(t *testing.T) {
+	sizes := []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1,
+		2 * ChunkSize, 2*ChunkSize + 17}
+	for _, suite := range []byte{ChaCha20Poly1305, AES256GCM} {
+		p := testParams(suite)
+		for _, size := range sizes {
+			plaintext := make([]byte, size)
+			for i := range plaintext {
+				plaintext[i] = byte(i * 7)
+			}
+			for _, piece := range []int{1000, ChunkSize, 3 * ChunkSize} {
+				if piece == 1000 && size > ChunkSize+1 {
+					continue
+				}
+				msg := seal(t, p, plaintext, piece)
+				chunks := (size + ChunkSize - 1) / ChunkSize
+				if chunks == 0 {
+					chunks = 1
+				}
+				wantLen := 2 + 33 + size + chunks*16
+				if len(msg) != wantLen {
+					t.Fatalf("suite %d size %d: got message "+
+						"length %d, want %d", suite, size,
+						len(msg), wantLen)
+				}
+				for _, oneByte := range []bool{false, true} {
+					if oneByte && size > ChunkSize+1 {
+						continue
+					}
+					got, err := open(p, msg, oneByte)
+					if err != nil {
+						t.Fatalf("suite %d size %d: unexpected "+
+							"error: %v", suite, size, err)
+					}
+					if !bytes.Equal(got, plaintext) {
+						t.Fatalf("suite %d size %d: plaintext "+
+							"mismatch", suite, size)
+					}
+				}
+			}
+		}
+	}
+
+	// Truncating the message at a chunk boundary, dropping a chunk,
+	// appending data or flipping a bit must all fail to authenticate.
+	p := testParams(ChaCha20Poly1305)
+	plaintext := make([]byte, 2*ChunkSize+5)
+	msg := seal(t, p, plaintext, ChunkSize)
+	header := 2 + 33
+	chunk := ChunkSize + 16
+	dropped := append(append([]byte{}, msg[:header+chunk]...),
+		msg[header+2*chunk:]...)
+	flipped := append([]byte{}, msg...)
+	flipped[header+chunk+3] ^= 0x01
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{"truncated at chunk boundary", msg[:header+chunk]},
+		{"truncated tag", msg[:len(msg)-1]},
+		{"header only", msg[:header]},
+		{"dropped chunk", dropped},
+		{"appended data", append(append([]byte{}, msg...), 0)},
+		{"flipped bit", flipped},
+	}
+	for _, test := range tests {
+		if _, err := open(p, test.msg, false); err != ErrAuthentication {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+	}
+
+	// Mismatched associated data or cipher suites must fail too.
+	other := testParams(ChaCha20Poly1305)
+	other.AssociatedData = nil
+	if _, err := open(other, msg, false); err != ErrAuthentication {
+		t.Errorf("mismatched associated data: unexpected error: %v", err)
+	}
+	badVersion := append([]byte{}, msg...)
+	badVersion[0] = 2
+	if _, err := open(p, badVersion, false); err != ErrUnsupportedVersion {
+		t.Errorf("bad version: unexpected error: %v", err)
+	}
+	badSuite := append([]byte{}, msg...)
+	badSuite[1] = 3
+	if _, err := open(p, badSuite, false); err != ErrUnsupportedCipherSuite {
+		t.Errorf("bad cipher suite: unexpected error: %v", err)
+	}
+	if _, err := NewWriter(ioutil.Discard, testParams(0)); err != ErrUnsupportedCipherSuite {
+		t.Errorf("NewWriter: unexpected error for unknown cipher "+
+			"suite: %v", err)
+	}
+}
@@ -75,6 +75,61 @@ func BenchmarkScalarMult(b *testing.B) {
 	}
 }
 
+// BenchmarkScalarBaseMultConstTime benchmarks the constant time base point
+// multiplication used for signing.
+func BenchmarkScalarBaseMultConstTime(b *testing.B) {
+	k := new(modNScalar).SetHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	curve := S256()
+	curve.baseMultConstTimeTable()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		curve.scalarBaseMultConstTime(k)
+	}
+}
+
+// BenchmarkCombinedMult benchmarks the secp256k1 curve CombinedMult function
+// which computes s*G + e*P as performed by signature verification.
+func BenchmarkCombinedMult(b *testing.B) {
+	x := fromHex("34f9460f0e4f08393d192b3c5133a6ba099aa0ad9fd54ebccfacdfa239ff49c6")
+	y := fromHex("0b71ea9bd730fd8923f6d25a7a91e7dd7728a960686cb5a901bb419e0f2ca232")
+	s := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	e := fromHex("8de472e2399610baaa7f84840547cd409434e31f5d3bd71e4d947f283874f9c0")
+	curve := S256()
+	curve.baseTables()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		curve.CombinedMult(x, y, s.Bytes(), e.Bytes())
+	}
+}
+
+// BenchmarkSplitK benchmarks the decomposition of scalars for the endomorphism.
+func BenchmarkSplitK(b *testing.B) {
+	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	curve := S256()
+	for i := 0; i < b.N; i++ {
+		curve.splitK(k.Bytes())
+	}
+}
+
+// BenchmarkModNScalarMul benchmarks multiplying two scalars modulo the group
+// order.
+func BenchmarkModNScalarMul(b *testing.B) {
+	s1 := new(modNScalar).SetHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	s2 := new(modNScalar).SetHex("8de472e2399610baaa7f84840547cd409434e31f5d3bd71e4d947f283874f9c0")
+	for i := 0; i < b.N; i++ {
+		s1.Mul(s2)
+	}
+}
+
+// BenchmarkModNScalarInverse benchmarks the constant time inversion of a
+// scalar modulo the group order.
+func BenchmarkModNScalarInverse(b *testing.B) {
+	s := new(modNScalar).SetHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	for i := 0; i < b.N; i++ {
+		s.Inverse()
+	}
+}
+
 // BenchmarkNAF benchmarks the NAF function.
 func BenchmarkNAF(b *testing.B) {
 	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
@@ -113,6 +168,20 @@ func BenchmarkSigVerify(b *testing.B) {
 	}
 }
 
+// BenchmarkSign benchmarks how long it takes to produce deterministic RFC6979
+// signatures with the constant time signing path.
+func BenchmarkSign(b *testing.B) {
+	privKey, _ := PrivKeyFromBytes(fromHex("9e0699c91ca1e3b7e3c9ba71eb71c89890872be97576010fe593fbf3fd57e66d").Bytes())
+	msgHash := fromHex("8de472e2399610baaa7f84840547cd409434e31f5d3bd71e4d947f283874f9c0").Bytes()
+	if _, err := privKey.Sign(msgHash); err != nil {
+		b.Fatalf("failed to sign: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		privKey.Sign(msgHash)
+	}
+}
+
 // BenchmarkFieldNormalize benchmarks how long it takes the internal field
 // to perform normalization (which includes modular reduction).
 func BenchmarkFieldNormalize(b *testing.B) {
@@ -66,6 +66,18 @@ type KoblitzCurve struct {
 	b1 *big.Int
 	a2 *big.Int
 	b2 *big.Int
+
+	// gTable and gEndoTable are the tables of odd multiples of the base
+	// point and its endomorphism used by CombinedMult.  They are computed
+	// on first use.
+	baseTablesOnce sync.Once
+	gTable         []jacobianPoint
+	gEndoTable     []jacobianPoint
+
+	// ctTable is the table of multiples of the base point used by
+	// scalarBaseMultConstTime.  It is computed on first use.
+	ctTableOnce sync.Once
+	ctTable     *[64][16]projectivePoint
 }
 
 // Params returns the parameters for the curve.
@@ -620,8 +632,86 @@ func (curve *KoblitzCurve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
 	return curve.fieldJacobianToBigAffine(fx3, fy3, fz3)
 }
 
-// splitK returns a balanced length-two representation of k and their signs.
-// This is algorithm 3.74 from [GECC].
+// Constants used by splitScalar to decompose scalars.  See EndomorphismVectors
+// in gensecp256k1.go for the derivation of the basis vectors a1, b1, a2, and b2
+// they are based on.
+var (
+	// endoNegLambda is -lambda (mod N).
+	endoNegLambda = new(modNScalar).SetHex("ac9c52b33fa3cf1f5ad9e3fd77ed9ba4a880b9fc8ec739c2e0cfc810b51283cf")
+
+	// endoNegB1 and endoNegB2 are -b1 (mod N) and -b2 (mod N).
+	endoNegB1 = new(modNScalar).SetHex("e4437ed6010e88286f547fa90abfe4c3")
+	endoNegB2 = new(modNScalar).SetHex("fffffffffffffffffffffffffffffffe8a280ac50774346dd765cda83db1562c")
+
+	// endoG1 and endoG2 are round(2^384 * b2 / N) and
+	// round(2^384 * -b1 / N), which allow the divisions by N in the
+	// decomposition to be replaced by multiplications and shifts.
+	endoG1 = new(modNScalar).SetHex("3086d221a7d46bcde86c90e49284eb153daa8a1471e8ca7fe893209a45dbb031")
+	endoG2 = new(modNScalar).SetHex("e4437ed6010e88286f547fa90abfe4c4221208ac9df506c61571b4ae8ac47f71")
+)
+
+// mulShift384 returns round(a * b / 2^384) in constant time.
+func mulShift384(a, b *modNScalar) modNScalar {
+	var w [2 * scalarWords]uint32
+	mulWide(a.n[:], b.n[:], w[:])
+
+	// Take the upper 128 bits and round based on the bit below them.  The
+	// result is at most 2^128, so it is a valid scalar.
+	var r modNScalar
+	carry := uint64(w[11] >> 31)
+	for i := 0; i < 4; i++ {
+		carry += uint64(w[12+i])
+		r.n[i] = uint32(carry)
+		carry >>= 32
+	}
+	r.n[4] = uint32(carry)
+	return r
+}
+
+// splitScalar returns a balanced length-two representation of k such that
+// k = k1 + k2 * lambda (mod N) where k1 and k2, when interpreted as signed
+// values in the range (-N/2, N/2], are both less than 2^128 in magnitude.  This
+// is algorithm 3.74 from [GECC] with the divisions by N precomputed as
+// described in section 4 of "Faster Point Multiplication on Elliptic Curves
+// with Efficient Endomorphisms" (Gallant, Lambert, Vanstone).
+//
+// This function runs in constant time.
+func splitScalar(k *modNScalar) (modNScalar, modNScalar) {
+	// c1 = round(b2 * k / n) and c2 = round(-b1 * k / n) from step 4.
+	c1 := mulShift384(k, endoG1)
+	c2 := mulShift384(k, endoG2)
+
+	// k2 = -c1 * b1 - c2 * b2 and k1 = k - k2 * lambda from step 5.
+	var k1, k2 modNScalar
+	c1.Mul(endoNegB1)
+	c2.Mul(endoNegB2)
+	k2.Add2(&c1, &c2)
+	k1.Mul2(&k2, endoNegLambda).Add(k)
+	return k1, k2
+}
+
+// signedMagnitude returns the magnitude of the passed scalar interpreted as a
+// signed value in the range (-N/2, N/2] as big-endian bytes without leading
+// zeros along with its sign.
+func signedMagnitude(s modNScalar) ([]byte, int) {
+	if s.IsZero() {
+		return nil, 0
+	}
+	sign := 1
+	if s.IsOverHalfOrder() {
+		s.Negate()
+		sign = -1
+	}
+	b := s.Bytes()
+	i := 0
+	for i < len(b) && b[i] == 0 {
+		i++
+	}
+	return b[i:], sign
+}
+
+// splitK returns a balanced length-two representation of k and their signs
+// such that k = k1 + k2 * lambda (mod N).  See splitScalar for details.
 //
 // One thing of note about this algorithm is that no matter what c1 and c2 are,
 // the final equation of k = k1 + k2 * lambda (mod n) will hold.  This is
@@ -629,38 +719,12 @@ func (curve *KoblitzCurve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
 //
 // c1 and c2 are chosen to minimize the max(k1,k2).
 func (curve *KoblitzCurve) splitK(k []byte) ([]byte, []byte, int, int) {
-	// All math here is done with big.Int, which is slow.
-	// At some point, it might be useful to write something similar to
-	// fieldVal but for N instead of P as the prime field if this ends up
-	// being a bottleneck.
-	bigIntK := new(big.Int)
-	c1, c2 := new(big.Int), new(big.Int)
-	tmp1, tmp2 := new(big.Int), new(big.Int)
-	k1, k2 := new(big.Int), new(big.Int)
-
-	bigIntK.SetBytes(k)
-	// c1 = round(b2 * k / n) from step 4.
-	// Rounding isn't really necessary and costs too much, hence skipped
-	c1.Mul(curve.b2, bigIntK)
-	c1.Div(c1, curve.N)
-	// c2 = round(b1 * k / n) from step 4 (sign reversed to optimize one step)
-	// Rounding isn't really necessary and costs too much, hence skipped
-	c2.Mul(curve.b1, bigIntK)
-	c2.Div(c2, curve.N)
-	// k1 = k - c1 * a1 - c2 * a2 from step 5 (note c2's sign is reversed)
-	tmp1.Mul(c1, curve.a1)
-	tmp2.Mul(c2, curve.a2)
-	k1.Sub(bigIntK, tmp1)
-	k1.Add(k1, tmp2)
-	// k2 = - c1 * b1 - c2 * b2 from step 5 (note c2's sign is reversed)
-	tmp1.Mul(c1, curve.b1)
-	tmp2.Mul(c2, curve.b2)
-	k2.Sub(tmp2, tmp1)
-
-	// Note Bytes() throws out the sign of k1 and k2. This matters
-	// since k1 and/or k2 can be negative. Hence, we pass that
-	// back separately.
-	return k1.Bytes(), k2.Bytes(), k1.Sign(), k2.Sign()
+	var kScalar modNScalar
+	kScalar.SetByteSlice(k)
+	k1, k2 := splitScalar(&kScalar)
+	k1Bytes, k1Sign := signedMagnitude(k1)
+	k2Bytes, k2Sign := signedMagnitude(k2)
+	return k1Bytes, k2Bytes, k1Sign, k2Sign
 }
 
 // moduloReduce reduces k from more than 32 bytes to 32 bytes and under.  This
@@ -756,105 +820,286 @@ func NAF(k []byte) ([]byte, []byte) {
 	return retPos[1:], retNeg[1:]
 }
 
-// ScalarMult returns k*(Bx, By) where k is a big endian integer.
-// Part of the elliptic.Curve interface.
-func (curve *KoblitzCurve) ScalarMult(Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
-	// Point Q = ∞ (point at infinity).
-	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+// Constants related to multiplication with the windowed NAF method.
+const (
+	// wnafMaxDigits is the maximum number of digits in the windowed NAF
+	// of a scalar.
+	wnafMaxDigits = 257
+
+	// wnafWindow is the window size used for the scalars of arbitrary
+	// points.  Each point requires a table of 2^(wnafWindow-2) of its odd
+	// multiples.
+	wnafWindow = 5
+
+	// wnafWindowG is the window size used for the scalars of the base
+	// point.  Its tables are only computed once, so a larger window which
+	// requires fewer additions is used.
+	wnafWindowG = 8
+)
 
-	// Decompose K into k1 and k2 in order to halve the number of EC ops.
-	// See Algorithm 3.74 in [GECC].
-	k1, k2, signK1, signK2 := curve.splitK(curve.moduloReduce(k))
+// wnaf converts the passed scalar to its windowed NAF with the passed window
+// size, least significant digit first, and returns the number of digits.  Each
+// nonzero digit is odd, less than 2^(w-1) in magnitude, and followed by at
+// least w-1 zero digits.  Scalars greater than N/2 are treated as negative,
+// so balanced scalars such as those produced by splitScalar only require
+// about half the digits of a full scalar.
+//
+// This function is not constant time and must only be used with public
+// scalars.
+func wnaf(k *modNScalar, w uint, digits *[wnafMaxDigits]int8) int {
+	s := *k
+	sign := int32(1)
+	if s.IsOverHalfOrder() {
+		s.Negate()
+		sign = -1
+	}
 
-	// The main equation here to remember is:
-	//   k * P = k1 * P + k2 * ϕ(P)
-	//
-	// P1 below is P in the equation, P2 below is ϕ(P) in the equation
-	p1x, p1y := curve.bigAffineToField(Bx, By)
-	p1yNeg := new(fieldVal).NegateVal(p1y, 1)
-	p1z := new(fieldVal).SetInt(1)
-
-	// NOTE: ϕ(x,y) = (βx,y).  The Jacobian z coordinate is 1, so this math
-	// goes through.
-	p2x := new(fieldVal).Mul2(p1x, curve.beta)
-	p2y := new(fieldVal).Set(p1y)
-	p2yNeg := new(fieldVal).NegateVal(p2y, 1)
-	p2z := new(fieldVal).SetInt(1)
-
-	// Flip the positive and negative values of the points as needed
-	// depending on the signs of k1 and k2.  As mentioned in the equation
-	// above, each of k1 and k2 are multiplied by the respective point.
-	// Since -k * P is the same thing as k * -P, and the group law for
-	// elliptic curves states that P(x, y) = -P(x, -y), it's faster and
-	// simplifies the code to just make the point negative.
-	if signK1 == -1 {
-		p1y, p1yNeg = p1yNeg, p1y
-	}
-	if signK2 == -1 {
-		p2y, p2yNeg = p2yNeg, p2y
-	}
-
-	// NAF versions of k1 and k2 should have a lot more zeros.
-	//
-	// The Pos version of the bytes contain the +1s and the Neg versions
-	// contain the -1s.
-	k1PosNAF, k1NegNAF := NAF(k1)
-	k2PosNAF, k2NegNAF := NAF(k2)
-	k1Len := len(k1PosNAF)
-	k2Len := len(k2PosNAF)
-
-	m := k1Len
-	if m < k2Len {
-		m = k2Len
-	}
-
-	// Add left-to-right using the NAF optimization.  See algorithm 3.77
-	// from [GECC].  This should be faster overall since there will be a lot
-	// more instances of 0, hence reducing the number of Jacobian additions
-	// at the cost of 1 possible extra doubling.
-	var k1BytePos, k1ByteNeg, k2BytePos, k2ByteNeg byte
-	for i := 0; i < m; i++ {
-		// Since we're going left-to-right, pad the front with 0s.
-		if i < m-k1Len {
-			k1BytePos = 0
-			k1ByteNeg = 0
-		} else {
-			k1BytePos = k1PosNAF[i-(m-k1Len)]
-			k1ByteNeg = k1NegNAF[i-(m-k1Len)]
+	// getBits returns count bits of the scalar starting at the passed bit.
+	var words [scalarWords + 1]uint32
+	copy(words[:], s.n[:])
+	getBits := func(bit, count uint) uint32 {
+		idx, off := bit/32, bit%32
+		v := words[idx] >> off
+		if off+count > 32 && idx+1 < uint(len(words)) {
+			v |= words[idx+1] << (32 - off)
+		}
+		return v & (1<<count - 1)
+	}
+
+	// Scan the bits from least to most significant, skipping bits that
+	// match the current carry.  Each window that starts with a set bit
+	// (after accounting for the carry) becomes a digit, and windows which
+	// would result in a digit of 2^(w-1) or more are instead encoded as a
+	// negative digit with a carry into the next window.
+	*digits = [wnafMaxDigits]int8{}
+	var carry uint32
+	numDigits := 0
+	for bit := uint(0); bit < wnafMaxDigits; {
+		if getBits(bit, 1) == carry {
+			bit++
+			continue
 		}
-		if i < m-k2Len {
-			k2BytePos = 0
-			k2ByteNeg = 0
-		} else {
-			k2BytePos = k2PosNAF[i-(m-k2Len)]
-			k2ByteNeg = k2NegNAF[i-(m-k2Len)]
+		now := w
+		if bit+now > wnafMaxDigits {
+			now = wnafMaxDigits - bit
 		}
+		word := int32(getBits(bit, now) + carry)
+		carry = uint32(word>>(w-1)) & 1
+		word -= int32(carry << w)
+		digits[bit] = int8(sign * word)
+		numDigits = int(bit) + 1
+		bit += now
+	}
+	return numDigits
+}
+
+// jacobianPoint is a point in Jacobian coordinates.
+type jacobianPoint struct {
+	x, y, z fieldVal
+}
 
-		for j := 7; j >= 0; j-- {
-			// Q = 2 * Q
-			curve.doubleJacobian(qx, qy, qz, qx, qy, qz)
+// wnafTerm is a point, in the form of a table of its odd multiples, along with
+// the windowed NAF of the scalar it is multiplied by in wnafMultSum.
+type wnafTerm struct {
+	table     []jacobianPoint
+	digits    [wnafMaxDigits]int8
+	numDigits int
+}
 
-			if k1BytePos&0x80 == 0x80 {
-				curve.addJacobian(qx, qy, qz, p1x, p1y, p1z,
-					qx, qy, qz)
-			} else if k1ByteNeg&0x80 == 0x80 {
-				curve.addJacobian(qx, qy, qz, p1x, p1yNeg, p1z,
-					qx, qy, qz)
-			}
+// oddMultiples returns a table of the first 2^(w-2) odd multiples of the
+// passed point, that is P, 3P, 5P, and so on.
+func (curve *KoblitzCurve) oddMultiples(px, py *fieldVal, w uint) []jacobianPoint {
+	table := make([]jacobianPoint, 1<<(w-2))
+	table[0].x.Set(px).Normalize()
+	table[0].y.Set(py).Normalize()
+	table[0].z.SetInt(1)
+	var p2 jacobianPoint
+	curve.doubleJacobian(&table[0].x, &table[0].y, &table[0].z, &p2.x,
+		&p2.y, &p2.z)
+	for i := 1; i < len(table); i++ {
+		prev, cur := &table[i-1], &table[i]
+		curve.addJacobian(&prev.x, &prev.y, &prev.z, &p2.x, &p2.y, &p2.z,
+			&cur.x, &cur.y, &cur.z)
+	}
+	return table
+}
 
-			if k2BytePos&0x80 == 0x80 {
-				curve.addJacobian(qx, qy, qz, p2x, p2y, p2z,
+// endomorphismTable returns a table of the endomorphism ϕ(P) of each point P in
+// the passed table.
+func (curve *KoblitzCurve) endomorphismTable(table []jacobianPoint) []jacobianPoint {
+	// NOTE: ϕ(x,y) = (βx,y).  In Jacobian coordinates x = X/Z^2, so
+	// ϕ(X,Y,Z) = (βX,Y,Z).
+	endoTable := make([]jacobianPoint, len(table))
+	for i := range table {
+		endoTable[i] = table[i]
+		endoTable[i].x.Mul(curve.beta).Normalize()
+	}
+	return endoTable
+}
+
+// appendTerms appends the terms of the multiplication of the point with the
+// passed tables of odd multiples of it and its endomorphism by k to terms.
+func appendTerms(terms []wnafTerm, table, endoTable []jacobianPoint,
+	w uint, k *modNScalar) []wnafTerm {
+
+	// Decompose k into k1 and k2 in order to halve the number of doublings.
+	// The main equation here to remember is:
+	//   k * P = k1 * P + k2 * ϕ(P)
+	k1, k2 := splitScalar(k)
+	terms = append(terms, wnafTerm{table: table}, wnafTerm{table: endoTable})
+	t1, t2 := &terms[len(terms)-2], &terms[len(terms)-1]
+	t1.numDigits = wnaf(&k1, w, &t1.digits)
+	t2.numDigits = wnaf(&k2, w, &t2.digits)
+	return terms
+}
+
+// appendPointTerms appends the terms of the multiplication of the passed
+// affine point by k to terms.  Nothing is appended when the product is the
+// point at infinity.
+func (curve *KoblitzCurve) appendPointTerms(terms []wnafTerm, x, y *big.Int,
+	k *modNScalar) []wnafTerm {
+
+	if (x.Sign() == 0 && y.Sign() == 0) || k.IsZero() {
+		return terms
+	}
+	px, py := curve.bigAffineToField(x, y)
+	table := curve.oddMultiples(px, py, wnafWindow)
+	return appendTerms(terms, table, curve.endomorphismTable(table),
+		wnafWindow, k)
+}
+
+// baseTables returns the tables of odd multiples of the base point and its
+// endomorphism, which are computed on first use, with each point converted to
+// affine coordinates so the faster additions for z values of one are used.
+func (curve *KoblitzCurve) baseTables() ([]jacobianPoint, []jacobianPoint) {
+	curve.baseTablesOnce.Do(func() {
+		gx, gy := curve.bigAffineToField(curve.Gx, curve.Gy)
+		table := curve.oddMultiples(gx, gy, wnafWindowG)
+		for i := range table {
+			p := &table[i]
+			curve.fieldJacobianToBigAffine(&p.x, &p.y, &p.z)
+		}
+		curve.gTable = table
+		curve.gEndoTable = curve.endomorphismTable(table)
+	})
+	return curve.gTable, curve.gEndoTable
+}
+
+// wnafMultSum computes the sum of the passed terms and stores the result in
+// (qx, qy, qz).
+//
+// The terms are added left-to-right with their doublings shared, which is known
+// as Straus' method (or Shamir's trick).  The windowed NAF of each scalar only
+// has a nonzero digit in one of any w consecutive digits, so far fewer
+// additions are needed than with the plain NAF at the cost of precomputing the
+// odd multiples of each point.  See algorithm 3.36 and 3.51 from [GECC].
+func (curve *KoblitzCurve) wnafMultSum(terms []wnafTerm, qx, qy, qz *fieldVal) {
+	// Point Q = ∞ (point at infinity).
+	qx.Zero()
+	qy.Zero()
+	qz.Zero()
+
+	var m int
+	for i := range terms {
+		if terms[i].numDigits > m {
+			m = terms[i].numDigits
+		}
+	}
+	var negY fieldVal
+	for i := m - 1; i >= 0; i-- {
+		// Q = 2 * Q
+		curve.doubleJacobian(qx, qy, qz, qx, qy, qz)
+
+		// Since -P = (x, -y), negative digits are handled by adding the
+		// negation of the corresponding multiple.
+		for j := range terms {
+			t := &terms[j]
+			digit := t.digits[i]
+			switch {
+			case digit > 0:
+				p := &t.table[digit>>1]
+				curve.addJacobian(qx, qy, qz, &p.x, &p.y, &p.z,
 					qx, qy, qz)
-			} else if k2ByteNeg&0x80 == 0x80 {
-				curve.addJacobian(qx, qy, qz, p2x, p2yNeg, p2z,
+			case digit < 0:
+				p := &t.table[(-digit)>>1]
+				negY.NegateVal(&p.y, 1)
+				curve.addJacobian(qx, qy, qz, &p.x, &negY, &p.z,
 					qx, qy, qz)
 			}
-			k1BytePos <<= 1
-			k1ByteNeg <<= 1
-			k2BytePos <<= 1
-			k2ByteNeg <<= 1
 		}
 	}
+}
+
+// ScalarMult returns k*(Bx, By) where k is a big endian integer.
+// Part of the elliptic.Curve interface.
+//
+// This function is not constant time and must not be used with secret
+// scalars.
+func (curve *KoblitzCurve) ScalarMult(Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
+	var kScalar modNScalar
+	kScalar.SetByteSlice(curve.moduloReduce(k))
+	terms := curve.appendPointTerms(make([]wnafTerm, 0, 2), Bx, By,
+		&kScalar)
+
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	curve.wnafMultSum(terms, qx, qy, qz)
+
+	// Convert the Jacobian coordinate field values back to affine big.Ints.
+	return curve.fieldJacobianToBigAffine(qx, qy, qz)
+}
+
+// CombinedMult returns baseScalar*G + scalar*(Bx, By) where G is the base
+// point of the group and both scalars are big endian integers.  The point at
+// infinity is returned as (0, 0).
+//
+// Both multiplications are performed simultaneously, which is significantly
+// faster than computing them separately and adding the results.  This is the
+// operation performed by signature verification.  The tables of multiples of
+// the base point are computed on first use.
+//
+// This function is not constant time and must not be used with secret
+// scalars.
+func (curve *KoblitzCurve) CombinedMult(Bx, By *big.Int, baseScalar, scalar []byte) (*big.Int, *big.Int) {
+	var u1, u2 modNScalar
+	u1.SetByteSlice(curve.moduloReduce(baseScalar))
+	u2.SetByteSlice(curve.moduloReduce(scalar))
+
+	terms := make([]wnafTerm, 0, 4)
+	if !u1.IsZero() {
+		gTable, gEndoTable := curve.baseTables()
+		terms = appendTerms(terms, gTable, gEndoTable, wnafWindowG, &u1)
+	}
+	terms = curve.appendPointTerms(terms, Bx, By, &u2)
+
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	curve.wnafMultSum(terms, qx, qy, qz)
+
+	// Convert the Jacobian coordinate field values back to affine big.Ints.
+	return curve.fieldJacobianToBigAffine(qx, qy, qz)
+}
+
+// ScalarMultSum returns k[0]*(Bx[0], By[0]) + ... + k[n-1]*(Bx[n-1], By[n-1])
+// where each k is a big endian integer.  The point at infinity is returned as
+// (0, 0).
+//
+// The points are multiplied simultaneously using the same decomposition and
+// windowed NAF optimizations as ScalarMult, so the doublings are shared between
+// all of the points.  This makes the sum considerably faster to compute than
+// summing the results of individual multiplications, such as when verifying a
+// batch of signatures.
+//
+// This function is not constant time and must not be used with secret
+// scalars.
+func (curve *KoblitzCurve) ScalarMultSum(Bx, By []*big.Int, k [][]byte) (*big.Int, *big.Int) {
+	terms := make([]wnafTerm, 0, 2*len(k))
+	for i := range k {
+		var kScalar modNScalar
+		kScalar.SetByteSlice(curve.moduloReduce(k[i]))
+		terms = curve.appendPointTerms(terms, Bx[i], By[i], &kScalar)
+	}
+
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	curve.wnafMultSum(terms, qx, qy, qz)
 
 	// Convert the Jacobian coordinate field values back to affine big.Ints.
 	return curve.fieldJacobianToBigAffine(qx, qy, qz)
@@ -882,6 +1127,141 @@ func (curve *KoblitzCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
 	return curve.fieldJacobianToBigAffine(qx, qy, qz)
 }
 
+// projectivePoint is a point in homogeneous projective coordinates, where
+// x = X/Z and y = Y/Z.  The point at infinity is (0, 1, 0).
+type projectivePoint struct {
+	x, y, z fieldVal
+}
+
+// addProjective adds the passed projective points together and stores the
+// result in r, which may be the same as either of them.  The coordinates of
+// the points must be normalized and the result is normalized.
+//
+// It uses the complete addition formulas for curves with a = 0 from algorithm
+// 7 of "Complete addition formulas for prime order elliptic curves" (Renes,
+// Costello, Batina).  Unlike addJacobian, the formulas produce the correct
+// result for every pair of points, including doubling and the point at
+// infinity, with the same sequence of operations, so this function runs in
+// constant time.
+func addProjective(p1, p2, r *projectivePoint) {
+	// b3 is 3*b where b = 7 is the constant of the curve equation.
+	const b3 = 21
+
+	var t0, t1, t2, t3, t4, x3, y3, z3 fieldVal
+	t0.Mul2(&p1.x, &p2.x)       // t0 = X1*X2 (mag: 1)
+	t1.Mul2(&p1.y, &p2.y)       // t1 = Y1*Y2 (mag: 1)
+	t2.Mul2(&p1.z, &p2.z)       // t2 = Z1*Z2 (mag: 1)
+	t3.Add2(&p1.x, &p1.y)       // t3 = X1+Y1 (mag: 2)
+	t4.Add2(&p2.x, &p2.y)       // t4 = X2+Y2 (mag: 2)
+	t3.Mul(&t4)                 // t3 = t3*t4 (mag: 1)
+	t4.Add2(&t0, &t1).Negate(2) // t4 = -(t0+t1) (mag: 3)
+	t3.Add(&t4)                 // t3 = t3-(t0+t1) (mag: 4)
+	t4.Add2(&p1.y, &p1.z)       // t4 = Y1+Z1 (mag: 2)
+	x3.Add2(&p2.y, &p2.z)       // X3 = Y2+Z2 (mag: 2)
+	t4.Mul(&x3)                 // t4 = t4*X3 (mag: 1)
+	x3.Add2(&t1, &t2).Negate(2) // X3 = -(t1+t2) (mag: 3)
+	t4.Add(&x3)                 // t4 = t4-(t1+t2) (mag: 4)
+	x3.Add2(&p1.x, &p1.z)       // X3 = X1+Z1 (mag: 2)
+	y3.Add2(&p2.x, &p2.z)       // Y3 = X2+Z2 (mag: 2)
+	x3.Mul(&y3)                 // X3 = X3*Y3 (mag: 1)
+	y3.Add2(&t0, &t2).Negate(2) // Y3 = -(t0+t2) (mag: 3)
+	y3.Add(&x3).Normalize()     // Y3 = X3-(t0+t2) (mag: 1)
+	t0.MulInt(3)                // t0 = 3*t0 (mag: 3)
+	t2.MulInt(b3).Normalize()   // t2 = b3*t2 (mag: 1)
+	z3.Add2(&t1, &t2)           // Z3 = t1+t2 (mag: 2)
+	t2.Negate(1)                // t2 = -t2 (mag: 2)
+	t1.Add(&t2)                 // t1 = t1-t2 (mag: 3)
+	y3.MulInt(b3).Normalize()   // Y3 = b3*Y3 (mag: 1)
+	x3.Mul2(&t4, &y3).Negate(1) // X3 = -(t4*Y3) (mag: 2)
+	t2.Mul2(&t3, &t1)           // t2 = t3*t1 (mag: 1)
+	x3.Add(&t2)                 // X3 = t2-t4*Y3 (mag: 3)
+	y3.Mul(&t0)                 // Y3 = Y3*t0 (mag: 1)
+	t1.Mul(&z3)                 // t1 = t1*Z3 (mag: 1)
+	y3.Add(&t1)                 // Y3 = t1+Y3 (mag: 2)
+	t0.Mul(&t3)                 // t0 = t0*t3 (mag: 1)
+	z3.Mul(&t4)                 // Z3 = Z3*t4 (mag: 1)
+	z3.Add(&t0)                 // Z3 = Z3+t0 (mag: 2)
+
+	// Normalize the resulting field values to a magnitude of 1.
+	r.x.Set(x3.Normalize())
+	r.y.Set(y3.Normalize())
+	r.z.Set(z3.Normalize())
+}
+
+// baseMultConstTimeTable returns the table used by scalarBaseMultConstTime,
+// which is computed on first use.  Entry j of window i is j*16^i*G.
+func (curve *KoblitzCurve) baseMultConstTimeTable() *[64][16]projectivePoint {
+	curve.ctTableOnce.Do(func() {
+		var table [64][16]projectivePoint
+		var base jacobianPoint
+		gx, gy := curve.bigAffineToField(curve.Gx, curve.Gy)
+		base.x.Set(gx)
+		base.y.Set(gy)
+		base.z.SetInt(1)
+		for i := range table {
+			// Entry 0 is the point at infinity.
+			table[i][0].y.SetInt(1)
+
+			// Convert each multiple from Jacobian coordinates,
+			// where x = X/Z^2 and y = Y/Z^3, to projective
+			// coordinates as (X*Z, Y, Z^3).
+			cur := base
+			for j := 1; j < 16; j++ {
+				p := &table[i][j]
+				p.x.Mul2(&cur.x, &cur.z).Normalize()
+				p.y.Set(&cur.y).Normalize()
+				p.z.SquareVal(&cur.z).Mul(&cur.z).Normalize()
+				curve.addJacobian(&cur.x, &cur.y, &cur.z, &base.x,
+					&base.y, &base.z, &cur.x, &cur.y, &cur.z)
+			}
+
+			// The final sum is 16*base, which is the base of the
+			// next window.
+			base = cur
+		}
+		curve.ctTable = &table
+	})
+	return curve.ctTable
+}
+
+// scalarBaseMultConstTime returns k*G in affine coordinates with the
+// coordinates normalized.  The point at infinity is returned as (0, 0).
+//
+// Unlike ScalarBaseMult, this function runs in constant time, so it is
+// suitable for secret scalars such as signing nonces.  Every entry of the
+// table for each 4-bit window of k is read regardless of the value of the
+// window and the entries are added with complete formulas.
+func (curve *KoblitzCurve) scalarBaseMultConstTime(k *modNScalar) (fieldVal, fieldVal) {
+	table := curve.baseMultConstTimeTable()
+
+	// Point Q = ∞ (point at infinity).
+	var q, entry projectivePoint
+	q.y.SetInt(1)
+	for i := range table {
+		window := (k.n[i/8] >> (uint(i%8) * 4)) & 0xf
+		for j := range table[i] {
+			// The mask has all bits set when j is the window and no
+			// bits set otherwise.
+			mask := -(((uint32(j) ^ window) - 1) >> 31)
+			p := &table[i][j]
+			for w := 0; w < fieldWords; w++ {
+				entry.x.n[w] = (p.x.n[w] & mask) | (entry.x.n[w] &^ mask)
+				entry.y.n[w] = (p.y.n[w] & mask) | (entry.y.n[w] &^ mask)
+				entry.z.n[w] = (p.z.n[w] & mask) | (entry.z.n[w] &^ mask)
+			}
+		}
+		addProjective(&q, &entry, &q)
+	}
+
+	// Convert to affine coordinates.  The inverse of zero is zero, so the
+	// point at infinity results in (0, 0).
+	var zInv, x, y fieldVal
+	zInv.Set(&q.z).Inverse()
+	x.Mul2(&q.x, &zInv).Normalize()
+	y.Mul2(&q.y, &zInv).Normalize()
+	return x, y
+}
+
 // QPlus1Div4 returns the Q+1/4 constant for the curve for use in calculating
 // square roots via exponention.
 func (curve *KoblitzCurve) QPlus1Div4() *big.Int {
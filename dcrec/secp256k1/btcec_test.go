@@ -664,7 +664,239 @@ func TestScalarMultRand(t *testing.T) {
 	}
 }
 
+func TestScalarMultSumRand(t *testing.T) {
+	// Strategy for this test:
+	// Multiply random points by random exponents, which includes scalars
+	// larger than the group order and zero, and ensure the sum matches
+	// the sum of the individual products.
+	s256 := S256()
+	for i := 0; i < 64; i++ {
+		numPoints := i%8 + 1
+		xs := make([]*big.Int, numPoints)
+		ys := make([]*big.Int, numPoints)
+		ks := make([][]byte, numPoints)
+		xWant, yWant := new(big.Int), new(big.Int)
+		for j := 0; j < numPoints; j++ {
+			data := make([]byte, 32+j%3)
+			_, err := rand.Read(data)
+			if err != nil {
+				t.Fatalf("failed to read random data at %d", i)
+			}
+			xs[j], ys[j] = s256.ScalarBaseMult(data)
+			if j == 1 {
+				data = nil
+			}
+			ks[j] = data
+			x, y := s256.ScalarMult(xs[j], ys[j], data)
+			xWant, yWant = s256.Add(xWant, yWant, x, y)
+		}
+		x, y := s256.ScalarMultSum(xs, ys, ks)
+		if x.Cmp(xWant) != 0 || y.Cmp(yWant) != 0 {
+			t.Fatalf("%d: bad output: got (%X, %X), want (%X, %X)", i,
+				x, y, xWant, yWant)
+		}
+	}
+
+	// The sum of a point and its negation is the point at infinity.
+	x, y := s256.ScalarMultSum([]*big.Int{s256.Gx, s256.Gx},
+		[]*big.Int{s256.Gy, new(big.Int).Sub(s256.P, s256.Gy)},
+		[][]byte{{0x05}, {0x05}})
+	if x.Sign() != 0 || y.Sign() != 0 {
+		t.Fatalf("bad output for G - G: got (%X, %X), want (0, 0)", x, y)
+	}
+}
+
+// TestCombinedMultRand ensures CombinedMult produces the same result as
+// computing the base point and point multiplications separately for random
+// scalars, including zero and scalars larger than the group order.
+func TestCombinedMultRand(t *testing.T) {
+	s256 := S256()
+	for i := 0; i < 256; i++ {
+		data := make([]byte, 32+i%3)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		px, py := s256.ScalarBaseMult(data[:32])
+		baseScalar, scalar := data[1:], data[:32]
+		switch i % 16 {
+		case 1:
+			baseScalar = nil
+		case 2:
+			scalar = nil
+		case 3:
+			// The point at infinity when the products cancel out.
+			py = new(big.Int).Sub(s256.P, py)
+			baseScalar = new(big.Int).Mul(new(big.Int).SetBytes(
+				data[:32]), new(big.Int).SetBytes(scalar)).Bytes()
+		}
+
+		x1, y1 := s256.ScalarBaseMult(baseScalar)
+		x2, y2 := s256.ScalarMult(px, py, scalar)
+		xWant, yWant := s256.Add(x1, y1, x2, y2)
+		x, y := s256.CombinedMult(px, py, baseScalar, scalar)
+		if x.Cmp(xWant) != 0 || y.Cmp(yWant) != 0 {
+			t.Fatalf("%d: bad output: got (%X, %X), want (%X, %X)", i,
+				x, y, xWant, yWant)
+		}
+	}
+}
+
+// TestScalarBaseMultConstTime ensures the constant time base point
+// multiplication produces the same result as ScalarBaseMult for edge cases and
+// random scalars.
+func TestScalarBaseMultConstTime(t *testing.T) {
+	s256 := S256()
+	scalars := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(16),
+		new(big.Int).Sub(s256.N, big.NewInt(1)),
+		new(big.Int).Rsh(s256.N, 1),
+	}
+	for i := 0; i < 256; i++ {
+		data := make([]byte, 32)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("failed to read random data at %d", i)
+		}
+		scalars = append(scalars, new(big.Int).Mod(
+			new(big.Int).SetBytes(data), s256.N))
+	}
+
+	for i, k := range scalars {
+		var kScalar modNScalar
+		kScalar.SetByteSlice(k.Bytes())
+		fx, fy := s256.scalarBaseMultConstTime(&kScalar)
+		x := new(big.Int).SetBytes(fx.Bytes()[:])
+		y := new(big.Int).SetBytes(fy.Bytes()[:])
+		xWant, yWant := s256.ScalarBaseMult(k.Bytes())
+		if x.Cmp(xWant) != 0 || y.Cmp(yWant) != 0 {
+			t.Fatalf("%d: bad output for %X: got (%X, %X), want "+
+				"(%X, %X)", i, k, x, y, xWant, yWant)
+		}
+	}
+}
+
+// TestSplitK ensures splitK produces the expected balanced decomposition of
+// various scalars, including edge cases, and that the decompositions are
+// valid.
 func TestSplitK(t *testing.T) {
+	tests := []struct {
+		k      string
+		k1, k2 string
+		s1, s2 int
+	}{
+		{
+			"6df2b5d30854069ccdec40ae022f5c948936324a4e9ebed8eb82cfd5a6b6d766",
+			"000000000000000000000000000000002ccc99964baf1d28052d726634712212",
+			"000000000000000000000000000000003dfe1e2f50f1dcaaf77220c8585407e1",
+			1, 1,
+		},
+		{
+			"6ca00a8f10632170accc1b3baf2a118fa5725f41473f8959f34b8f860c47d88d",
+			"0000000000000000000000000000000028d4b8aae65b14aa26acd13f809b8f91",
+			"000000000000000000000000000000000b70a8dc2efec1d89c84e3cf011a2533",
+			-1, 1,
+		},
+		{
+			"b2eda8ab31b259032d39cbc2a234af17fcee89c863a8917b2740b67568166289",
+			"000000000000000000000000000000008104653194aedfe2e4b6e320287427a1",
+			"00000000000000000000000000000000121c7c419ce3013ee3e4466f51f854fb",
+			-1, -1,
+		},
+		{
+			"f6f00e44f179936f2befc7442721b0633f6bafdf7161c167ffc6f7751980e3a0",
+			"0000000000000000000000000000000008d0264f10bcdcd97da3faa38f85308d",
+			"0000000000000000000000000000000065fed1506eb6605a899a54e155665f79",
+			-1, -1,
+		},
+		{
+			"8679085ab081dc92cdd23091ce3ee998f6b320e419c3475fae6b5b7d3081996e",
+			"000000000000000000000000000000005a478c864668c467379f8dda2e6e0b4e",
+			"00000000000000000000000000000000413faae1eb7b9f1f62ebf3bfedf22fee",
+			1, 1,
+		},
+		{
+			"6b1247bb7931dfcae5b5603c8b5ae22ce94d670138c51872225beae6bba8cdb3",
+			"0000000000000000000000000000000089fe26a58dc142795cc0e409dee1da7b",
+			"00000000000000000000000000000000056a1dd32f6e9d830446485d0159f164",
+			1, -1,
+		},
+		{
+			"a2e8ba2e8ba2e8ba2e8ba2e8ba2e8ba219b51835b55cc30ebfe2f6599bc56f58",
+			"00000000000000000000000000000000764c0cc3632b68a4746daf123fec43f9",
+			"00000000000000000000000000000000415be1b5f1e695f25f9a28477189cf28",
+			-1, 1,
+		},
+		{
+			// One.
+			"01",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			1, 0,
+		},
+		{
+			// N-1.
+			"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			-1, 0,
+		},
+		{
+			// Lambda.
+			"5363ad4cc05c30e0a5261c028812645a122e22ea20816678df02967c1b23bd72",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			0, 1,
+		},
+		{
+			// Half the group order.
+			"7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a0",
+			"00000000000000000000000000000000a2a8918ca85bafe22016d0b917e4dd76",
+			"0000000000000000000000000000000059de565a2c9d0e2d4373f7623c1d7cd7",
+			1, -1,
+		},
+		{
+			// 2^128.
+			"0100000000000000000000000000000000",
+			"0000000000000000000000000000000014ca50f7a8e2f3f657c1108d9d44cfd8",
+			"000000000000000000000000000000003086d221a7d46bcde86c90e49284eb15",
+			-1, -1,
+		},
+	}
+
+	s256 := S256()
+	for i, test := range tests {
+		k, ok := new(big.Int).SetString(test.k, 16)
+		if !ok {
+			t.Errorf("%d: bad value for k: %s", i, test.k)
+		}
+		k1, k2, k1Sign, k2Sign := s256.splitK(k.Bytes())
+		k1str := fmt.Sprintf("%064x", k1)
+		if test.k1 != k1str {
+			t.Errorf("%d: bad k1: got %v, want %v", i, k1str, test.k1)
+		}
+		k2str := fmt.Sprintf("%064x", k2)
+		if test.k2 != k2str {
+			t.Errorf("%d: bad k2: got %v, want %v", i, k2str, test.k2)
+		}
+		if test.s1 != k1Sign {
+			t.Errorf("%d: bad k1 sign: got %d, want %d", i, k1Sign, test.s1)
+		}
+		if test.s2 != k2Sign {
+			t.Errorf("%d: bad k2 sign: got %d, want %d", i, k2Sign, test.s2)
+		}
+		if err := checkSplitK(s256, k, k1, k2, k1Sign, k2Sign); err != nil {
+			t.Errorf("%d: %v", i, err)
+		}
+	}
+}
+
+// TestSplitKOriginalVectors ensures the decompositions produced by the previous
+// implementation of splitK, which truncated rather than rounded the divisions
+// by N, remain valid decompositions and that splitK produces a valid
+// decomposition for the same scalars.
+func TestSplitKOriginalVectors(t *testing.T) {
 	tests := []struct {
 		k      string
 		k1, k2 string
@@ -720,36 +952,54 @@ func TestSplitK(t *testing.T) {
 		if !ok {
 			t.Errorf("%d: bad value for k: %s", i, test.k)
 		}
-		k1, k2, k1Sign, k2Sign := s256.splitK(k.Bytes())
-		k1str := fmt.Sprintf("%064x", k1)
-		if test.k1 != k1str {
-			t.Errorf("%d: bad k1: got %v, want %v", i, k1str, test.k1)
-		}
-		k2str := fmt.Sprintf("%064x", k2)
-		if test.k2 != k2str {
-			t.Errorf("%d: bad k2: got %v, want %v", i, k2str, test.k2)
+		k1, ok := new(big.Int).SetString(test.k1, 16)
+		if !ok {
+			t.Errorf("%d: bad value for k1: %s", i, test.k1)
 		}
-		if test.s1 != k1Sign {
-			t.Errorf("%d: bad k1 sign: got %d, want %d", i, k1Sign, test.s1)
+		k2, ok := new(big.Int).SetString(test.k2, 16)
+		if !ok {
+			t.Errorf("%d: bad value for k2: %s", i, test.k2)
 		}
-		if test.s2 != k2Sign {
-			t.Errorf("%d: bad k2 sign: got %d, want %d", i, k2Sign, test.s2)
+
+		// Ensure the test vector itself is a valid decomposition.
+		err := checkSplitK(s256, k, k1.Bytes(), k2.Bytes(), test.s1, test.s2)
+		if err != nil {
+			t.Errorf("%d: bad test vector: %v", i, err)
 		}
-		k1Int := new(big.Int).SetBytes(k1)
-		k1SignInt := new(big.Int).SetInt64(int64(k1Sign))
-		k1Int.Mul(k1Int, k1SignInt)
-		k2Int := new(big.Int).SetBytes(k2)
-		k2SignInt := new(big.Int).SetInt64(int64(k2Sign))
-		k2Int.Mul(k2Int, k2SignInt)
-		gotK := new(big.Int).Mul(k2Int, s256.lambda)
-		gotK.Add(k1Int, gotK)
-		gotK.Mod(gotK, s256.N)
-		if k.Cmp(gotK) != 0 {
-			t.Errorf("%d: bad k: got %X, want %X", i, gotK.Bytes(), k.Bytes())
+
+		k1Bytes, k2Bytes, k1Sign, k2Sign := s256.splitK(k.Bytes())
+		err = checkSplitK(s256, k, k1Bytes, k2Bytes, k1Sign, k2Sign)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
 		}
 	}
 }
 
+// checkSplitK returns an error if the passed signed halves do not satisfy
+// k = k1 + k2 * lambda (mod N) or either half exceeds sqrt(N) in magnitude.
+func checkSplitK(curve *KoblitzCurve, k *big.Int, k1, k2 []byte, k1Sign, k2Sign int) error {
+	k1Int := new(big.Int).SetBytes(k1)
+	k1Int.Mul(k1Int, big.NewInt(int64(k1Sign)))
+	k2Int := new(big.Int).SetBytes(k2)
+	k2Int.Mul(k2Int, big.NewInt(int64(k2Sign)))
+
+	sqrtN := new(big.Int).Sqrt(curve.N)
+	if k1Int.CmpAbs(sqrtN) > 0 {
+		return fmt.Errorf("k1 %X exceeds sqrt(N)", k1Int)
+	}
+	if k2Int.CmpAbs(sqrtN) > 0 {
+		return fmt.Errorf("k2 %X exceeds sqrt(N)", k2Int)
+	}
+
+	gotK := new(big.Int).Mul(k2Int, curve.lambda)
+	gotK.Add(k1Int, gotK)
+	gotK.Mod(gotK, curve.N)
+	if k.Cmp(gotK) != 0 {
+		return fmt.Errorf("bad k: got %X, want %X", gotK.Bytes(), k.Bytes())
+	}
+	return nil
+}
+
 func TestSplitKRand(t *testing.T) {
 	s256 := S256()
 	for i := 0; i < 1024; i++ {
@@ -759,19 +1009,10 @@ func TestSplitKRand(t *testing.T) {
 			t.Fatalf("failed to read random data at %d", i)
 			break
 		}
-		k := new(big.Int).SetBytes(bytesK)
+		k := new(big.Int).Mod(new(big.Int).SetBytes(bytesK), s256.N)
 		k1, k2, k1Sign, k2Sign := s256.splitK(bytesK)
-		k1Int := new(big.Int).SetBytes(k1)
-		k1SignInt := new(big.Int).SetInt64(int64(k1Sign))
-		k1Int.Mul(k1Int, k1SignInt)
-		k2Int := new(big.Int).SetBytes(k2)
-		k2SignInt := new(big.Int).SetInt64(int64(k2Sign))
-		k2Int.Mul(k2Int, k2SignInt)
-		gotK := new(big.Int).Mul(k2Int, s256.lambda)
-		gotK.Add(k1Int, gotK)
-		gotK.Mod(gotK, s256.N)
-		if k.Cmp(gotK) != 0 {
-			t.Errorf("%d: bad k: got %X, want %X", i, gotK.Bytes(), k.Bytes())
+		if err := checkSplitK(s256, k, k1, k2, k1Sign, k2Sign); err != nil {
+			t.Errorf("%d: %v", i, err)
 		}
 	}
 }
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/commanderu/cdrd/cdrec/internal/ecies"
+)
+
+// CipherSuite identifies the authenticated cipher used to encrypt messages with
+// EncryptAEAD and NewEncryptWriter.
+type CipherSuite byte
+
+// These constants define the supported cipher suites.
+const (
+	// CipherChaCha20Poly1305 encrypts messages with ChaCha20-Poly1305.
+	CipherChaCha20Poly1305 CipherSuite = ecies.ChaCha20Poly1305
+
+	// CipherAES256GCM encrypts messages with AES-256-GCM.
+	CipherAES256GCM CipherSuite = ecies.AES256GCM
+)
+
+var (
+	// ErrUnsupportedVersion occurs when decrypting a message created with an
+	// unknown version of the authenticated encryption scheme.
+	ErrUnsupportedVersion = ecies.ErrUnsupportedVersion
+
+	// ErrUnsupportedCipherSuite occurs when an unknown cipher suite is
+	// requested or specified by a message.
+	ErrUnsupportedCipherSuite = ecies.ErrUnsupportedCipherSuite
+
+	// ErrMessageAuthentication occurs when a message fails to authenticate
+	// during decryption.  This happens because of either an invalid private
+	// key, mismatched associated data or a corrupt or truncated ciphertext.
+	ErrMessageAuthentication = ecies.ErrAuthentication
+)
+
+// eciesInfo separates the keys derived for secp256k1 messages from those of
+// other curves.
+const eciesInfo = "commanderu ECIES v1 secp256k1"
+
+// zeroSlice zeroes the memory of a secret byte slice.
+func zeroSlice(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// sharedSecretX returns the 32-byte big endian x coordinate of the ECDH shared
+// secret of the private and public keys.
+func sharedSecretX(privkey *PrivateKey, pubkey *PublicKey) []byte {
+	x, _ := S256().ScalarMult(pubkey.X, pubkey.Y, privkey.D.Bytes())
+	return paddedAppend(32, nil, x.Bytes())
+}
+
+// NewEncryptWriter returns a writer which encrypts everything written to it for
+// the target public key and writes the result to w.  The writer must be closed
+// to complete the message, which does not close w.
+//
+// The message starts with a version byte, the cipher suite and the compressed
+// public key of a freshly generated ephemeral private key.  The symmetric key
+// is derived with HKDF-SHA256 from the x coordinate of the ECDH shared secret
+// and the plaintext is then encrypted in authenticated chunks of 64KiB so that
+// large payloads never need to be held in memory.  The optional associated
+// data is authenticated along with every chunk but not included in the message,
+// so the same associated data must be provided to decrypt it.
+func NewEncryptWriter(w io.Writer, pubkey *PublicKey, associatedData []byte,
+	suite CipherSuite) (io.WriteCloser, error) {
+
+	ephemeral, err := GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	secret := sharedSecretX(ephemeral, pubkey)
+	defer zeroSlice(secret)
+
+	return ecies.NewWriter(w, &ecies.Params{
+		Suite:          byte(suite),
+		Ephemeral:      (*PublicKey)(&ephemeral.PublicKey).SerializeCompressed(),
+		Recipient:      pubkey.SerializeCompressed(),
+		Secret:         secret,
+		Info:           eciesInfo,
+		AssociatedData: associatedData,
+	})
+}
+
+// NewDecryptReader returns a reader which decrypts a message created with
+// NewEncryptWriter or EncryptAEAD that is read from r.  Plaintext is only
+// returned once the chunk containing it has been authenticated, and
+// ErrMessageAuthentication is returned when any part of the message fails to
+// authenticate.
+func NewDecryptReader(r io.Reader, priv *PrivateKey,
+	associatedData []byte) (io.Reader, error) {
+
+	suite, ephemeralBytes, err := ecies.ReadHeader(r, PubKeyBytesLenCompressed)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := ParsePubKey(ephemeralBytes)
+	if err != nil {
+		return nil, err
+	}
+	secret := sharedSecretX(priv, ephemeral)
+	defer zeroSlice(secret)
+
+	return ecies.NewReader(r, &ecies.Params{
+		Suite:          suite,
+		Ephemeral:      ephemeralBytes,
+		Recipient:      (*PublicKey)(&priv.PublicKey).SerializeCompressed(),
+		Secret:         secret,
+		Info:           eciesInfo,
+		AssociatedData: associatedData,
+	})
+}
+
+// EncryptAEAD encrypts the plaintext for the target public key and
+// authenticates it along with the optional associated data.  See
+// NewEncryptWriter for details of the scheme, which is versioned and unrelated
+// to the format of Encrypt.
+func EncryptAEAD(pubkey *PublicKey, plaintext, associatedData []byte,
+	suite CipherSuite) ([]byte, error) {
+
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, pubkey, associatedData, suite)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptAEAD decrypts a message created with EncryptAEAD or NewEncryptWriter
+// using the same associated data it was encrypted with.
+func DecryptAEAD(priv *PrivateKey, ciphertext, associatedData []byte) ([]byte,
+	error) {
+
+	r, err := NewDecryptReader(bytes.NewReader(ciphertext), priv,
+		associatedData)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
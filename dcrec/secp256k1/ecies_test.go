@@ -0,0 +1,116 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// TestCipheringAEAD ensures messages encrypted with the authenticated scheme
+// round trip for all cipher suites and that decryption fails with the wrong
+// key, associated data or a modified ciphertext.
+func TestCipheringAEAD(t *testing.T) {
+	privkey, err := GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	pubkey := (*PublicKey)(&privkey.PublicKey)
+	otherKey, err := GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+
+	in := []byte("Hey there dude. How are you doing? This is a test.")
+	ad := []byte("associated data")
+	for _, suite := range []CipherSuite{CipherChaCha20Poly1305,
+		CipherAES256GCM} {
+
+		out, err := EncryptAEAD(pubkey, in, ad, suite)
+		if err != nil {
+			t.Fatalf("suite %d: failed to encrypt: %v", suite, err)
+		}
+		if len(out) != 2+33+len(in)+16 {
+			t.Errorf("suite %d: unexpected ciphertext length %d", suite,
+				len(out))
+		}
+		dec, err := DecryptAEAD(privkey, out, ad)
+		if err != nil {
+			t.Fatalf("suite %d: failed to decrypt: %v", suite, err)
+		}
+		if !bytes.Equal(in, dec) {
+			t.Errorf("suite %d: decrypted data doesn't match original",
+				suite)
+		}
+
+		if _, err := DecryptAEAD(otherKey, out, ad); err != ErrMessageAuthentication {
+			t.Errorf("suite %d: unexpected error decrypting with "+
+				"the wrong key: %v", suite, err)
+		}
+		if _, err := DecryptAEAD(privkey, out, nil); err != ErrMessageAuthentication {
+			t.Errorf("suite %d: unexpected error decrypting with "+
+				"the wrong associated data: %v", suite, err)
+		}
+
+		// Changing the cipher suite in the header must not allow the
+		// message to be decrypted.
+		modified := append([]byte{}, out...)
+		modified[1] ^= 3
+		if _, err := DecryptAEAD(privkey, modified, ad); err != ErrMessageAuthentication {
+			t.Errorf("suite %d: unexpected error decrypting with "+
+				"a modified suite: %v", suite, err)
+		}
+	}
+
+	if _, err := EncryptAEAD(pubkey, in, nil, 9); err != ErrUnsupportedCipherSuite {
+		t.Errorf("unexpected error for unknown cipher suite: %v", err)
+	}
+	if _, err := DecryptAEAD(privkey, make([]byte, 2+33), nil); err != ErrUnsupportedVersion {
+		t.Errorf("unexpected error for unknown version: %v", err)
+	}
+	if _, err := DecryptAEAD(privkey, []byte{1, 1, 2}, nil); err != ErrMessageAuthentication {
+		t.Errorf("unexpected error for short message: %v", err)
+	}
+}
+
+// TestCipheringAEADStream ensures large payloads round trip through the
+// streaming writer and reader.
+func TestCipheringAEADStream(t *testing.T) {
+	privkey, err := GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate private key: %v", err)
+	}
+	pubkey := (*PublicKey)(&privkey.PublicKey)
+
+	in := make([]byte, 300*1024+7)
+	for i := range in {
+		in[i] = byte(i)
+	}
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, pubkey, nil, CipherChaCha20Poly1305)
+	if err != nil {
+		t.Fatalf("failed to create writer: %v", err)
+	}
+	if _, err := io.Copy(w, bytes.NewReader(in)); err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+
+	r, err := NewDecryptReader(&buf, privkey, nil)
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	dec, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if !bytes.Equal(in, dec) {
+		t.Error("decrypted data doesn't match original")
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// randScalar returns a random scalar along with its value as a big integer.
+// Every fourth scalar is chosen near the group order or zero to exercise the
+// reduction edge cases.
+func randScalar(t *testing.T, i int) (*modNScalar, *big.Int) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		t.Fatalf("failed to read random data: %v", err)
+	}
+	v := new(big.Int).SetBytes(b[:])
+	switch i % 8 {
+	case 0:
+		v.Sub(S256().N, big.NewInt(int64(b[0]%4)+1))
+	case 4:
+		v.SetInt64(int64(b[0] % 4))
+	}
+	v.Mod(v, S256().N)
+	s := new(modNScalar)
+	s.SetByteSlice(v.Bytes())
+	return s, v
+}
+
+// hexToBytes converts the passed hex string, which may have an odd length,
+// into bytes and panics on failure.
+func hexToBytes(s string) []byte {
+	if len(s)%2 != 0 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// scalarToBig returns the passed scalar as a big integer.
+func scalarToBig(s *modNScalar) *big.Int {
+	b := s.Bytes()
+	return new(big.Int).SetBytes(b[:])
+}
+
+// TestModNScalarSetBytes ensures setting scalars from bytes reduces values
+// which are not less than the group order and reports them as overflowing.
+func TestModNScalarSetBytes(t *testing.T) {
+	tests := []struct {
+		in       string // hex encoded test value
+		expected string // expected hex encoded reduced value
+		overflow bool   // expected overflow result
+	}{
+		{"0", "0", false},
+		{"1", "1", false},
+		{"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+			"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+			false},
+		{"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141",
+			"0", true},
+		{"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364142",
+			"1", true},
+		{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"14551231950b75fc4402da1732fc9bebe", true},
+		// Values longer than 32 bytes are truncated.
+		{"01ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"14551231950b75fc4402da1732fc9bebe", true},
+	}
+
+	for i, test := range tests {
+		var s modNScalar
+		overflow := s.SetByteSlice(hexToBytes(test.in))
+		expected := new(modNScalar).SetHex(test.expected)
+		if !s.Equals(expected) {
+			t.Errorf("#%d: wrong result -- got %v, want %v", i, s,
+				expected)
+		}
+		if overflow != test.overflow {
+			t.Errorf("#%d: wrong overflow -- got %v, want %v", i,
+				overflow, test.overflow)
+		}
+	}
+}
+
+// TestModNScalarArithmeticRand ensures the scalar arithmetic produces the same
+// results as the equivalent big integer arithmetic modulo the group order for
+// random values.
+func TestModNScalarArithmeticRand(t *testing.T) {
+	N := S256().N
+	halfOrder := new(big.Int).Rsh(N, 1)
+	for i := 0; i < 1024; i++ {
+		a, aBig := randScalar(t, i)
+		b, bBig := randScalar(t, i/2)
+
+		want := new(big.Int).Add(aBig, bBig)
+		want.Mod(want, N)
+		if got := new(modNScalar).Add2(a, b); scalarToBig(got).Cmp(want) != 0 {
+			t.Fatalf("#%d: %v + %v -- got %v, want %x", i, a, b, got,
+				want)
+		}
+
+		want.Mul(aBig, bBig).Mod(want, N)
+		if got := new(modNScalar).Mul2(a, b); scalarToBig(got).Cmp(want) != 0 {
+			t.Fatalf("#%d: %v * %v -- got %v, want %x", i, a, b, got,
+				want)
+		}
+
+		want.Neg(aBig).Mod(want, N)
+		if got := new(modNScalar).NegateVal(a); scalarToBig(got).Cmp(want) != 0 {
+			t.Fatalf("#%d: -%v -- got %v, want %x", i, a, got, want)
+		}
+		negated := *a
+		negated.CondNegate(0)
+		if !negated.Equals(a) {
+			t.Fatalf("#%d: conditional negation of %v with flag 0 "+
+				"changed it to %v", i, a, negated)
+		}
+		negated.CondNegate(1)
+		if scalarToBig(&negated).Cmp(want) != 0 {
+			t.Fatalf("#%d: conditional negation of %v -- got %v, "+
+				"want %x", i, a, negated, want)
+		}
+
+		if a.IsOverHalfOrder() != (aBig.Cmp(halfOrder) > 0) {
+			t.Fatalf("#%d: wrong over half order result for %v", i,
+				a)
+		}
+
+		if aBig.Sign() == 0 {
+			continue
+		}
+		want.ModInverse(aBig, N)
+		if got := new(modNScalar).Set(a).Inverse(); scalarToBig(got).Cmp(want) != 0 {
+			t.Fatalf("#%d: 1/%v -- got %v, want %x", i, a, got, want)
+		}
+	}
+}
+
+// TestSplitScalarRand ensures the decomposition of random scalars results in
+// balanced scalars less than 2^128 in magnitude which recombine to the
+// original scalar.
+func TestSplitScalarRand(t *testing.T) {
+	s256 := S256()
+	limit := new(big.Int).Lsh(big.NewInt(1), 128)
+	for i := 0; i < 1024; i++ {
+		k, kBig := randScalar(t, i)
+		k1, k2 := splitScalar(k)
+		k1Bytes, k1Sign := signedMagnitude(k1)
+		k2Bytes, k2Sign := signedMagnitude(k2)
+		k1Big := new(big.Int).SetBytes(k1Bytes)
+		k2Big := new(big.Int).SetBytes(k2Bytes)
+		if k1Big.Cmp(limit) >= 0 || k2Big.Cmp(limit) >= 0 {
+			t.Fatalf("#%d: unbalanced decomposition of %v: %x, %x", i,
+				k, k1Big, k2Big)
+		}
+
+		// k = k1 + k2*lambda (mod N)
+		k1Big.Mul(k1Big, big.NewInt(int64(k1Sign)))
+		k2Big.Mul(k2Big, big.NewInt(int64(k2Sign)))
+		got := new(big.Int).Mul(k2Big, s256.lambda)
+		got.Add(got, k1Big).Mod(got, s256.N)
+		if got.Cmp(kBig) != 0 {
+			t.Fatalf("#%d: bad decomposition of %v: got %x", i, k,
+				got)
+		}
+	}
+}
+
+// TestWNAFRand ensures the windowed NAF of random scalars has the expected
+// properties and represents the original scalar.
+func TestWNAFRand(t *testing.T) {
+	N := S256().N
+	for i := 0; i < 1024; i++ {
+		k, kBig := randScalar(t, i)
+		w := uint(i%7 + 2)
+		var digits [wnafMaxDigits]int8
+		numDigits := wnaf(k, w, &digits)
+
+		got := new(big.Int)
+		lastNonZero := -int(w)
+		for j := numDigits - 1; j >= 0; j-- {
+			got.Lsh(got, 1)
+			got.Add(got, big.NewInt(int64(digits[j])))
+		}
+		for j := 0; j < numDigits; j++ {
+			d := int(digits[j])
+			if d == 0 {
+				continue
+			}
+			if d%2 == 0 || d >= 1<<(w-1) || d <= -(1<<(w-1)) {
+				t.Fatalf("#%d: invalid digit %d for window %d", i,
+					d, w)
+			}
+			if j-lastNonZero < int(w) {
+				t.Fatalf("#%d: nonzero digits %d and %d are too "+
+					"close for window %d", i, lastNonZero, j, w)
+			}
+			lastNonZero = j
+		}
+		if numDigits > 0 && digits[numDigits-1] == 0 {
+			t.Fatalf("#%d: digit count %d includes leading zeros", i,
+				numDigits)
+		}
+		got.Mod(got, N)
+		if got.Cmp(kBig) != 0 {
+			t.Fatalf("#%d: wNAF of %v represents %x", i, k, got)
+		}
+	}
+}
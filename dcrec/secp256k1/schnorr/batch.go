@@ -0,0 +1,149 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/cdrec/secp256k1"
+)
+
+// batchCoefficientSize is the size in bytes of the coefficients used to
+// combine the signatures of a batch.  A batch which contains an invalid
+// signature passes with a probability of at most 2^-128.
+const batchCoefficientSize = 16
+
+// liftX returns the point on the curve with the passed x coordinate and an
+// even y coordinate, which is the point committed to by the R value of a
+// signature.  False is returned when there is no such point.
+func liftX(curve *secp256k1.KoblitzCurve, x *big.Int) (*big.Int, bool) {
+	if x.Sign() < 0 || x.Cmp(curve.P) >= 0 {
+		return nil, false
+	}
+
+	// Y = +-sqrt(x^3 + B)
+	y2 := new(big.Int).Mul(x, x)
+	y2.Mul(y2, x)
+	y2.Add(y2, curve.B)
+	y2.Mod(y2, curve.P)
+	y := new(big.Int).Exp(y2, curve.QPlus1Div4(), curve.P)
+	if new(big.Int).Exp(y, big.NewInt(2), curve.P).Cmp(y2) != 0 {
+		return nil, false
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(curve.P, y)
+	}
+	return y, true
+}
+
+// batchCoefficients deterministically derives the coefficients used to combine
+// the signatures of a batch from all of the public keys, messages, and
+// signatures in it.  The first coefficient is always one.
+func batchCoefficients(pubkeys []*secp256k1.PublicKey, msgs [][]byte,
+	sigs []*Signature) []*big.Int {
+
+	seedInput := make([]byte, 0, len(sigs)*(PubKeyBytesLen+scalarSize+
+		SignatureSize))
+	for i := range sigs {
+		seedInput = append(seedInput, pubkeys[i].SerializeCompressed()...)
+		seedInput = append(seedInput, msgs[i]...)
+		seedInput = append(seedInput, sigs[i].Serialize()...)
+	}
+	seed := chainhash.HashB(seedInput)
+
+	coefficients := make([]*big.Int, len(sigs))
+	coefficients[0] = big.NewInt(1)
+	var buf [chainhash.HashSize + 4]byte
+	copy(buf[:], seed)
+	for i := 1; i < len(sigs); i++ {
+		binary.LittleEndian.PutUint32(buf[chainhash.HashSize:], uint32(i))
+		h := chainhash.HashB(buf[:])
+		coefficients[i] = new(big.Int).SetBytes(h[:batchCoefficientSize])
+	}
+	return coefficients
+}
+
+// BatchVerify verifies all of the passed secp256k1 Schnorr signatures, where
+// sigs[i] is the signature of msgs[i] by pubkeys[i], and returns whether or not
+// they are all valid.  BLAKE256 is used as the hashing function.
+//
+// Each signature is valid when R = h*Q + s*G, where R is the point with an even
+// y coordinate committed to by its r value.  Rather than checking each of these
+// equations separately, the equations are multiplied by coefficients derived
+// from the entire batch and summed, so a single multi-scalar multiplication
+// checks all of them at once.  This is significantly faster than calling Verify
+// for each signature, however, a failed batch does not identify the invalid
+// signatures, so callers must fall back to Verify to find them.
+func BatchVerify(pubkeys []*secp256k1.PublicKey, msgs [][]byte,
+	sigs []*Signature) bool {
+
+	if len(pubkeys) != len(sigs) || len(msgs) != len(sigs) {
+		return false
+	}
+	if len(sigs) == 0 {
+		return true
+	}
+
+	curve := secp256k1.S256()
+	for i, sig := range sigs {
+		pubkey := pubkeys[i]
+		if pubkey == nil || sig == nil || sig.R == nil || sig.S == nil {
+			return false
+		}
+		if !curve.IsOnCurve(pubkey.GetX(), pubkey.GetY()) {
+			return false
+		}
+		if len(msgs[i]) != scalarSize {
+			return false
+		}
+	}
+	coefficients := batchCoefficients(pubkeys, msgs, sigs)
+
+	// Sum a_i*h_i*Q_i - a_i*R_i over all signatures followed by
+	// (sum a_i*s_i)*G and ensure the result is the point at infinity.
+	numPoints := 2*len(sigs) + 1
+	xs := make([]*big.Int, 0, numPoints)
+	ys := make([]*big.Int, 0, numPoints)
+	ks := make([][]byte, 0, numPoints)
+	sSum := new(big.Int)
+	for i, sig := range sigs {
+		if sig.S.Sign() < 0 || sig.S.Cmp(curve.N) >= 0 {
+			return false
+		}
+		ry, ok := liftX(curve, sig.R)
+		if !ok {
+			return false
+		}
+
+		rBytes := BigIntToEncodedBytes(sig.R)
+		h := chainhash.HashB(append(rBytes[:], msgs[i]...))
+		hBig := new(big.Int).SetBytes(h)
+		if hBig.Sign() == 0 || hBig.Cmp(curve.N) >= 0 {
+			return false
+		}
+
+		a := coefficients[i]
+		ah := hBig.Mul(hBig, a)
+		ah.Mod(ah, curve.N)
+		xs = append(xs, pubkeys[i].GetX())
+		ys = append(ys, pubkeys[i].GetY())
+		ks = append(ks, ah.Bytes())
+
+		xs = append(xs, sig.R)
+		ys = append(ys, ry.Sub(curve.P, ry))
+		ks = append(ks, a.Bytes())
+
+		sSum.Add(sSum, new(big.Int).Mul(a, sig.S))
+	}
+	sSum.Mod(sSum, curve.N)
+	xs = append(xs, curve.Gx)
+	ys = append(ys, curve.Gy)
+	ks = append(ks, sSum.Bytes())
+
+	x, y := curve.ScalarMultSum(xs, ys, ks)
+	return x.Sign() == 0 && y.Sign() == 0
+}
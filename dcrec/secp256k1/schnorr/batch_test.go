@@ -0,0 +1,83 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/commanderu/cdrd/cdrec/secp256k1"
+)
+
+// batchParams splits the passed signature parameters into the arguments of
+// BatchVerify.
+func batchParams(sigList []*SignatureVerParams) ([]*secp256k1.PublicKey, [][]byte, []*Signature) {
+	pubs := make([]*secp256k1.PublicKey, len(sigList))
+	msgs := make([][]byte, len(sigList))
+	sigs := make([]*Signature, len(sigList))
+	for i, p := range sigList {
+		pubs[i], msgs[i], sigs[i] = p.pubkey, p.msg, p.sig
+	}
+	return pubs, msgs, sigs
+}
+
+// TestBatchVerify ensures batch verification accepts batches of valid
+// signatures and rejects batches which contain an invalid signature.
+func TestBatchVerify(t *testing.T) {
+	numSigs := 64
+	pubs, msgs, sigs := batchParams(randSigList(numSigs))
+
+	for _, n := range []int{0, 1, 2, 7, numSigs} {
+		if !BatchVerify(pubs[:n], msgs[:n], sigs[:n]) {
+			t.Fatalf("batch of %d valid signatures failed", n)
+		}
+	}
+
+	// Corrupt each of the signature, message, and public key of an entry
+	// in turn and ensure the batch fails.
+	badSigs := append([]*Signature{}, sigs...)
+	badSigs[5] = NewSignature(sigs[5].R,
+		new(big.Int).Add(sigs[5].S, big.NewInt(1)))
+	if BatchVerify(pubs, msgs, badSigs) {
+		t.Fatal("batch with a modified s value passed")
+	}
+
+	badSigs[5] = NewSignature(new(big.Int).Add(sigs[5].R, big.NewInt(1)),
+		sigs[5].S)
+	if BatchVerify(pubs, msgs, badSigs) {
+		t.Fatal("batch with a modified r value passed")
+	}
+
+	badMsgs := append([][]byte{}, msgs...)
+	badMsgs[9] = append([]byte{}, msgs[9]...)
+	badMsgs[9][0] ^= 0x01
+	if BatchVerify(pubs, badMsgs, sigs) {
+		t.Fatal("batch with a modified message passed")
+	}
+
+	badPubs := append([]*secp256k1.PublicKey{}, pubs...)
+	badPubs[3], badPubs[4] = pubs[4], pubs[3]
+	if BatchVerify(badPubs, msgs, sigs) {
+		t.Fatal("batch with swapped public keys passed")
+	}
+
+	if BatchVerify(pubs[:2], msgs[:1], sigs[:2]) {
+		t.Fatal("batch with mismatched lengths passed")
+	}
+}
+
+func benchmarkBatchVerification(b *testing.B, numSigs int) {
+	pubs, msgs, sigs := batchParams(randSigList(numSigs))
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if !BatchVerify(pubs, msgs, sigs) {
+			b.Fatal("batch failed")
+		}
+	}
+}
+
+func BenchmarkBatchVerification16(b *testing.B)  { benchmarkBatchVerification(b, 16) }
+func BenchmarkBatchVerification128(b *testing.B) { benchmarkBatchVerification(b, 128) }
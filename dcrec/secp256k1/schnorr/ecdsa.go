@@ -253,9 +253,7 @@ func schnorrVerify(sig []byte,
 	}
 
 	// r' = hQ + sG
-	lx, ly := curve.ScalarMult(pubkey.GetX(), pubkey.GetY(), h)
-	rx, ry := curve.ScalarBaseMult(sigS)
-	rlx, rly := curve.Add(lx, ly, rx, ry)
+	rlx, rly := curve.CombinedMult(pubkey.GetX(), pubkey.GetY(), sigS, h)
 
 	if rly.Bit(0) == 1 {
 		str := fmt.Sprintf("calculated R y-value was odd")
@@ -61,22 +61,37 @@ const (
 	// ErrNonmatchingR indicates that all signatures to be combined in a
 	// threshold signature failed to have a matching R value.
 	ErrNonmatchingR
+
+	// ErrSessionState indicates that a multi-party signing session step was
+	// performed out of order or more than once.
+	ErrSessionState
+
+	// ErrBadNonceCommitment indicates that a public nonce revealed during a
+	// multi-party signing session did not match its commitment.
+	ErrBadNonceCommitment
+
+	// ErrBadPartialSig indicates that a partial signature of a multi-party
+	// signing session was invalid.
+	ErrBadPartialSig
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
 var errorCodeStrings = map[ErrorCode]string{
-	ErrBadInputSize:      "BadInputSize",
-	ErrInputValue:        "ErrInputValue",
-	ErrSchnorrHashValue:  "ErrSchnorrHashValue",
-	ErrPointNotOnCurve:   "ErrPointNotOnCurve",
-	ErrBadSigRYValue:     "ErrBadSigRYValue",
-	ErrBadSigRNotOnCurve: "ErrBadSigRNotOnCurve",
-	ErrRegenerateRPoint:  "ErrRegenerateRPoint",
-	ErrPubKeyOffCurve:    "ErrPubKeyOffCurve",
-	ErrRegenSig:          "ErrRegenSig",
-	ErrBadNonce:          "ErrBadNonce",
-	ErrZeroSigS:          "ErrZeroSigS",
-	ErrNonmatchingR:      "ErrNonmatchingR",
+	ErrBadInputSize:       "BadInputSize",
+	ErrInputValue:         "ErrInputValue",
+	ErrSchnorrHashValue:   "ErrSchnorrHashValue",
+	ErrPointNotOnCurve:    "ErrPointNotOnCurve",
+	ErrBadSigRYValue:      "ErrBadSigRYValue",
+	ErrBadSigRNotOnCurve:  "ErrBadSigRNotOnCurve",
+	ErrRegenerateRPoint:   "ErrRegenerateRPoint",
+	ErrPubKeyOffCurve:     "ErrPubKeyOffCurve",
+	ErrRegenSig:           "ErrRegenSig",
+	ErrBadNonce:           "ErrBadNonce",
+	ErrZeroSigS:           "ErrZeroSigS",
+	ErrNonmatchingR:       "ErrNonmatchingR",
+	ErrSessionState:       "ErrSessionState",
+	ErrBadNonceCommitment: "ErrBadNonceCommitment",
+	ErrBadPartialSig:      "ErrBadPartialSig",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -0,0 +1,495 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/cdrec/secp256k1"
+)
+
+var (
+	// muSigKeyListTag and muSigCoefficientTag domain separate the hashes
+	// used to derive the coefficients of the keys of an aggregate key.
+	muSigKeyListTag     = []byte("MuSig key list")
+	muSigCoefficientTag = []byte("MuSig coefficient")
+
+	// muSigNonceTag and muSigCommitmentTag domain separate the hashes used
+	// to derive secret nonces and to commit to public nonces.
+	muSigNonceTag      = []byte("MuSig nonce")
+	muSigCommitmentTag = []byte("MuSig nonce commitment")
+)
+
+// taggedHash returns the BLAKE256 hash of the passed tag followed by the
+// passed data.
+func taggedHash(tag []byte, data ...[]byte) []byte {
+	size := len(tag)
+	for _, d := range data {
+		size += len(d)
+	}
+	b := make([]byte, 0, size)
+	b = append(b, tag...)
+	for _, d := range data {
+		b = append(b, d...)
+	}
+	return chainhash.HashB(b)
+}
+
+// AggregateKey is the combination of the public keys of the signers of a
+// multi-party signature.  Unlike CombinePubkeys, each key is multiplied by a
+// coefficient which commits to all of the keys, which prevents a signer from
+// choosing its key as a function of the others to control the aggregate key
+// (a rogue-key attack).
+type AggregateKey struct {
+	// PubKey is the aggregate public key which signatures produced by
+	// signing sessions for the key verify against.
+	PubKey *secp256k1.PublicKey
+
+	// pubKeys are the keys of the signers sorted by their compressed
+	// serialization, which determines the index of each signer.
+	pubKeys []*secp256k1.PublicKey
+
+	// coefficients are the coefficients the key of each signer is
+	// multiplied by.
+	coefficients []*big.Int
+}
+
+// AggregatePubKeys returns the aggregate key of the passed public keys, which
+// are sorted first so that the result does not depend on their order.
+//
+// The aggregate key is a_1*X_1 + ... + a_n*X_n where
+// a_i = BLAKE256("MuSig coefficient" || L || X_i) and
+// L = BLAKE256("MuSig key list" || X_1 || ... || X_n) with each key in its
+// compressed serialization.
+func AggregatePubKeys(pubKeys []*secp256k1.PublicKey) (*AggregateKey, error) {
+	if len(pubKeys) == 0 {
+		str := "no public keys to aggregate"
+		return nil, schnorrError(ErrInputValue, str)
+	}
+
+	curve := secp256k1.S256()
+	serialized := make([][]byte, len(pubKeys))
+	sorted := make([]*secp256k1.PublicKey, len(pubKeys))
+	copy(sorted, pubKeys)
+	for _, pubKey := range sorted {
+		if pubKey == nil {
+			str := "nil public key"
+			return nil, schnorrError(ErrInputValue, str)
+		}
+		if !curve.IsOnCurve(pubKey.GetX(), pubKey.GetY()) {
+			str := "public key is not on curve"
+			return nil, schnorrError(ErrPointNotOnCurve, str)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].SerializeCompressed(),
+			sorted[j].SerializeCompressed()) < 0
+	})
+	for i, pubKey := range sorted {
+		serialized[i] = pubKey.SerializeCompressed()
+		if i > 0 && bytes.Equal(serialized[i], serialized[i-1]) {
+			str := fmt.Sprintf("duplicate public key %x",
+				serialized[i])
+			return nil, schnorrError(ErrInputValue, str)
+		}
+	}
+	keyList := taggedHash(muSigKeyListTag, serialized...)
+
+	key := &AggregateKey{
+		pubKeys:      sorted,
+		coefficients: make([]*big.Int, len(sorted)),
+	}
+	xs := make([]*big.Int, len(sorted))
+	ys := make([]*big.Int, len(sorted))
+	ks := make([][]byte, len(sorted))
+	for i, pubKey := range sorted {
+		a := new(big.Int).SetBytes(taggedHash(muSigCoefficientTag,
+			keyList, serialized[i]))
+		a.Mod(a, curve.N)
+		key.coefficients[i] = a
+		xs[i], ys[i], ks[i] = pubKey.GetX(), pubKey.GetY(), a.Bytes()
+	}
+	x, y := curve.ScalarMultSum(xs, ys, ks)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		str := "aggregate public key is the point at infinity"
+		return nil, schnorrError(ErrPubKeyOffCurve, str)
+	}
+	key.PubKey = secp256k1.NewPublicKey(x, y)
+	return key, nil
+}
+
+// PubKeys returns the public keys of the signers in the order of their signer
+// indexes.
+func (key *AggregateKey) PubKeys() []*secp256k1.PublicKey {
+	pubKeys := make([]*secp256k1.PublicKey, len(key.pubKeys))
+	copy(pubKeys, key.pubKeys)
+	return pubKeys
+}
+
+// SignerIndex returns the index of the signer with the passed public key or -1
+// when the key is not part of the aggregate key.
+func (key *AggregateKey) SignerIndex(pubKey *secp256k1.PublicKey) int {
+	for i, k := range key.pubKeys {
+		if k.IsEqual(pubKey) {
+			return i
+		}
+	}
+	return -1
+}
+
+// NonceCommitment returns the commitment to the passed public nonce which is
+// exchanged during the first round of a signing session.
+func NonceCommitment(pubNonce *secp256k1.PublicKey) []byte {
+	return taggedHash(muSigCommitmentTag, pubNonce.SerializeCompressed())
+}
+
+// sessionState is the step of the protocol a signing session is at.
+type sessionState int
+
+// These constants define the steps of a signing session in the order they
+// are performed.
+const (
+	// stateCommit is the state in which the nonce commitments of all of
+	// the signers are being collected.
+	stateCommit sessionState = iota
+
+	// stateReveal is the state in which the public nonces of all of the
+	// signers are being collected.
+	stateReveal
+
+	// stateSign is the state in which the partial signature of the signer
+	// may be created.
+	stateSign
+
+	// stateSigned is the state once the partial signature has been
+	// created and the secret nonce destroyed.
+	stateSigned
+)
+
+// Session is the state of a single signer of a multi-party Schnorr signature
+// for an aggregate key.  Signatures are produced in three rounds:
+//
+//  1. Each signer sends the NonceCommitment of its session to the others,
+//     which are passed to SetNonceCommitments
+//  2. Each signer sends its PublicNonce to the others, which are passed to
+//     SetPublicNonces and checked against the commitments
+//  3. Each signer sends its partial signature from Sign to the others, any
+//     of which combines them into the final signature with CombineSigs
+//
+// Committing to the nonces before revealing them prevents a signer from
+// choosing its nonce as a function of the nonces of the others.  The secret
+// nonce of a session is generated randomly when the session is created and
+// destroyed once it is used to sign, so a session can only ever produce a
+// single partial signature and must not be persisted or copied.  A new session
+// is required for every signing attempt, including retries after a failure.
+//
+// The final signature is a regular Schnorr signature which verifies against
+// the aggregate public key with Verify.
+type Session struct {
+	key       *AggregateKey
+	signerIdx int
+	msg       []byte
+	privKey   *big.Int
+
+	secNonce *big.Int
+	pubNonce *secp256k1.PublicKey
+
+	state       sessionState
+	commitments [][]byte
+	combinedR   *secp256k1.PublicKey
+	challenge   *big.Int
+}
+
+// NewSession returns a signing session of the message by the signer with the
+// passed private key, which must be one of the keys of the aggregate key.
+func NewSession(key *AggregateKey, privKey *secp256k1.PrivateKey,
+	msg []byte) (*Session, error) {
+
+	return newSession(key, privKey, msg, rand.Reader)
+}
+
+// newSession returns a signing session which generates its secret nonce using
+// the passed source of randomness.
+func newSession(key *AggregateKey, privKey *secp256k1.PrivateKey, msg []byte,
+	randReader io.Reader) (*Session, error) {
+
+	if len(msg) != scalarSize {
+		str := fmt.Sprintf("wrong size for message (got %v, want %v)",
+			len(msg), scalarSize)
+		return nil, schnorrError(ErrBadInputSize, str)
+	}
+	curve := secp256k1.S256()
+	d := privKey.GetD()
+	if d.Sign() <= 0 || d.Cmp(curve.N) >= 0 {
+		str := "private key scalar is out of bounds"
+		return nil, schnorrError(ErrInputValue, str)
+	}
+	signerIdx := key.SignerIndex(secp256k1.NewPublicKey(privKey.Public()))
+	if signerIdx == -1 {
+		str := "private key is not part of the aggregate key"
+		return nil, schnorrError(ErrInputValue, str)
+	}
+
+	// Derive the secret nonce from fresh randomness along with the private
+	// key, aggregate key, and message so that the nonce remains
+	// unpredictable when the source of randomness is weak, but is never
+	// reused when the same message is signed more than once.
+	var secNonce *big.Int
+	privBytes := BigIntToEncodedBytes(d)
+	defer zeroArray(privBytes)
+	var randBytes [scalarSize]byte
+	defer zeroArray(&randBytes)
+	for {
+		if _, err := io.ReadFull(randReader, randBytes[:]); err != nil {
+			return nil, err
+		}
+		k := taggedHash(muSigNonceTag, randBytes[:], privBytes[:],
+			key.PubKey.SerializeCompressed(), msg)
+		secNonce = new(big.Int).SetBytes(k)
+		zeroSlice(k)
+		if secNonce.Sign() != 0 && secNonce.Cmp(curve.N) < 0 {
+			break
+		}
+	}
+	x, y := curve.ScalarBaseMult(secNonce.Bytes())
+
+	return &Session{
+		key:         key,
+		signerIdx:   signerIdx,
+		msg:         append([]byte{}, msg...),
+		privKey:     new(big.Int).Set(d),
+		secNonce:    secNonce,
+		pubNonce:    secp256k1.NewPublicKey(x, y),
+		commitments: make([][]byte, len(key.pubKeys)),
+	}, nil
+}
+
+// SignerIndex returns the index of the signer of the session, which is the
+// position of its commitment, public nonce, and partial signature in the
+// slices passed to the session.
+func (s *Session) SignerIndex() int {
+	return s.signerIdx
+}
+
+// NonceCommitment returns the commitment to the public nonce of the session to
+// send to the other signers during the first round.
+func (s *Session) NonceCommitment() []byte {
+	return NonceCommitment(s.pubNonce)
+}
+
+// SetNonceCommitments sets the nonce commitments of all of the signers, indexed
+// by signer index, which must include the commitment of the session itself.
+func (s *Session) SetNonceCommitments(commitments [][]byte) error {
+	if s.state != stateCommit {
+		str := "nonce commitments have already been set"
+		return schnorrError(ErrSessionState, str)
+	}
+	if len(commitments) != len(s.commitments) {
+		str := fmt.Sprintf("wrong number of nonce commitments (got %v, "+
+			"want %v)", len(commitments), len(s.commitments))
+		return schnorrError(ErrBadInputSize, str)
+	}
+	for i, commitment := range commitments {
+		if len(commitment) != chainhash.HashSize {
+			str := fmt.Sprintf("wrong size for nonce commitment %v "+
+				"(got %v, want %v)", i, len(commitment),
+				chainhash.HashSize)
+			return schnorrError(ErrBadInputSize, str)
+		}
+	}
+	if !bytes.Equal(commitments[s.signerIdx], s.NonceCommitment()) {
+		str := "nonce commitment of the signer does not match the session"
+		return schnorrError(ErrBadNonceCommitment, str)
+	}
+
+	for i, commitment := range commitments {
+		s.commitments[i] = append([]byte{}, commitment...)
+	}
+	s.state = stateReveal
+	return nil
+}
+
+// PublicNonce returns the public nonce of the session to send to the other
+// signers during the second round.  It may only be revealed once the nonce
+// commitments of all of the signers are set.
+func (s *Session) PublicNonce() (*secp256k1.PublicKey, error) {
+	if s.state < stateReveal {
+		str := "public nonce requested before nonce commitments were set"
+		return nil, schnorrError(ErrSessionState, str)
+	}
+	return s.pubNonce, nil
+}
+
+// SetPublicNonces sets the public nonces of all of the signers, indexed by
+// signer index, after ensuring each matches its commitment.
+func (s *Session) SetPublicNonces(pubNonces []*secp256k1.PublicKey) error {
+	if s.state != stateReveal {
+		str := "public nonces set out of order"
+		return schnorrError(ErrSessionState, str)
+	}
+	if len(pubNonces) != len(s.commitments) {
+		str := fmt.Sprintf("wrong number of public nonces (got %v, "+
+			"want %v)", len(pubNonces), len(s.commitments))
+		return schnorrError(ErrBadInputSize, str)
+	}
+
+	curve := secp256k1.S256()
+	rx, ry := new(big.Int), new(big.Int)
+	for i, pubNonce := range pubNonces {
+		if pubNonce == nil ||
+			!curve.IsOnCurve(pubNonce.GetX(), pubNonce.GetY()) {
+			str := fmt.Sprintf("public nonce %v is not on curve", i)
+			return schnorrError(ErrPointNotOnCurve, str)
+		}
+		if !bytes.Equal(NonceCommitment(pubNonce), s.commitments[i]) {
+			str := fmt.Sprintf("public nonce %v does not match its "+
+				"commitment", i)
+			return schnorrError(ErrBadNonceCommitment, str)
+		}
+		rx, ry = curve.Add(rx, ry, pubNonce.GetX(), pubNonce.GetY())
+	}
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		str := "combined nonce is the point at infinity"
+		return schnorrError(ErrBadNonce, str)
+	}
+
+	// h = Hash(r || m)
+	rBytes := BigIntToEncodedBytes(rx)
+	h := new(big.Int).SetBytes(chainhash.HashB(append(rBytes[:],
+		s.msg...)))
+	if h.Sign() == 0 || h.Cmp(curve.N) >= 0 {
+		str := "hash of (R || m) is out of range"
+		return schnorrError(ErrSchnorrHashValue, str)
+	}
+
+	s.combinedR = secp256k1.NewPublicKey(rx, ry)
+	s.challenge = h
+	s.state = stateSign
+	return nil
+}
+
+// signerNonce returns the public nonce, negated when the combined nonce has an
+// odd y coordinate, which the partial signature of the signer with the passed
+// nonce commits to.
+func (s *Session) signerNonce(pubNonce *secp256k1.PublicKey) (*big.Int, *big.Int) {
+	x, y := pubNonce.GetX(), pubNonce.GetY()
+	if s.combinedR.GetY().Bit(0) == 1 {
+		y = new(big.Int).Sub(secp256k1.S256().P, y)
+	}
+	return x, y
+}
+
+// Sign returns the partial signature of the session during the third round.
+// The secret nonce is destroyed afterwards, so this may only be called once.
+//
+// The partial signature is s_i = k_i - h*a_i*x_i where k_i is the secret
+// nonce, negated when the combined nonce R has an odd y coordinate, and
+// h = BLAKE256(R.x || m).  Its R value is the x coordinate of R.
+func (s *Session) Sign() (*Signature, error) {
+	switch {
+	case s.state < stateSign:
+		str := "signing requested before public nonces were set"
+		return nil, schnorrError(ErrSessionState, str)
+	case s.state == stateSigned:
+		str := "session has already signed and its nonce is destroyed"
+		return nil, schnorrError(ErrSessionState, str)
+	}
+
+	// Destroy the secret nonce and private key regardless of the result
+	// so the nonce can never be used again.
+	curve := secp256k1.S256()
+	k := s.secNonce
+	defer func() {
+		k.SetInt64(0)
+		s.privKey.SetInt64(0)
+		s.secNonce = nil
+		s.state = stateSigned
+	}()
+	if s.combinedR.GetY().Bit(0) == 1 {
+		k.Sub(curve.N, k)
+	}
+
+	// s_i = k_i - h*a_i*x_i
+	sBig := new(big.Int).Mul(s.challenge, s.key.coefficients[s.signerIdx])
+	sBig.Mul(sBig, s.privKey)
+	sBig.Sub(k, sBig)
+	sBig.Mod(sBig, curve.N)
+
+	return NewSignature(new(big.Int).Set(s.combinedR.GetX()), sBig), nil
+}
+
+// VerifyPartialSig returns whether or not the passed partial signature of the
+// signer with the passed index is valid, that is s_i*G + h*a_i*X_i = R_i where
+// R_i is the public nonce of the signer, negated when the combined nonce has an
+// odd y coordinate.  The public nonces must have been set.
+func (s *Session) VerifyPartialSig(signerIdx int, sig *Signature,
+	pubNonces []*secp256k1.PublicKey) bool {
+
+	if s.state < stateSign || signerIdx < 0 ||
+		signerIdx >= len(s.key.pubKeys) || len(pubNonces) != len(s.commitments) ||
+		sig == nil || sig.R == nil || sig.S == nil {
+		return false
+	}
+	curve := secp256k1.S256()
+	if sig.R.Cmp(s.combinedR.GetX()) != 0 || sig.S.Sign() < 0 ||
+		sig.S.Cmp(curve.N) >= 0 {
+		return false
+	}
+	if !bytes.Equal(NonceCommitment(pubNonces[signerIdx]),
+		s.commitments[signerIdx]) {
+		return false
+	}
+
+	ha := new(big.Int).Mul(s.challenge, s.key.coefficients[signerIdx])
+	ha.Mod(ha, curve.N)
+	pubKey := s.key.pubKeys[signerIdx]
+	x, y := curve.ScalarMultSum(
+		[]*big.Int{pubKey.GetX(), curve.Gx},
+		[]*big.Int{pubKey.GetY(), curve.Gy},
+		[][]byte{ha.Bytes(), sig.S.Bytes()})
+	wantX, wantY := s.signerNonce(pubNonces[signerIdx])
+	return x.Cmp(wantX) == 0 && y.Cmp(wantY) == 0
+}
+
+// CombineSigs verifies the passed partial signatures of all of the signers,
+// indexed by signer index, and combines them into the final signature which
+// verifies against the aggregate public key with Verify.  The public nonces
+// are needed to identify an invalid partial signature.
+func (s *Session) CombineSigs(sigs []*Signature,
+	pubNonces []*secp256k1.PublicKey) (*Signature, error) {
+
+	if s.state < stateSign {
+		str := "signatures combined before public nonces were set"
+		return nil, schnorrError(ErrSessionState, str)
+	}
+	if len(sigs) != len(s.key.pubKeys) {
+		str := fmt.Sprintf("wrong number of partial signatures (got "+
+			"%v, want %v)", len(sigs), len(s.key.pubKeys))
+		return nil, schnorrError(ErrBadInputSize, str)
+	}
+
+	curve := secp256k1.S256()
+	sSum := new(big.Int)
+	for i, sig := range sigs {
+		if !s.VerifyPartialSig(i, sig, pubNonces) {
+			str := fmt.Sprintf("partial signature %v is invalid", i)
+			return nil, schnorrError(ErrBadPartialSig, str)
+		}
+		sSum.Add(sSum, sig.S)
+	}
+	sSum.Mod(sSum, curve.N)
+	if sSum.Sign() == 0 {
+		str := "combined sig s is zero"
+		return nil, schnorrError(ErrZeroSigS, str)
+	}
+
+	return NewSignature(new(big.Int).Set(s.combinedR.GetX()), sSum), nil
+}
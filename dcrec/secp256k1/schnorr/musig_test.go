@@ -0,0 +1,352 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package schnorr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/commanderu/cdrd/cdrec/secp256k1"
+)
+
+// muSigSigner is a signer of a multi-party signature test vector.
+type muSigSigner struct {
+	privKey     string
+	randomness  string
+	coefficient string
+	partialSig  string
+}
+
+// muSigTestVector is a multi-party signature produced by signers with fixed
+// keys and randomness.
+type muSigTestVector struct {
+	msg       string
+	signers   []muSigSigner // in signer index order
+	aggPubKey string
+	sig       string
+}
+
+// muSigTestVectors are regression vectors for the key aggregation and signing
+// of multi-party signatures.
+var muSigTestVectors = []muSigTestVector{{
+	msg: "e4f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f",
+	signers: []muSigSigner{{
+		privKey:     "0202020202020202020202020202020202020202020202020202020202020202",
+		randomness:  "1111111111111111111111111111111111111111111111111111111111111111",
+		coefficient: "0f5093fbafc48eaca9bea5c2cb13c82b7e89fb4b3d780f4c5dea776b6ae14904",
+		partialSig:  "7833cad45dc2c266f2118e0aaa4325beff7c3b5c648faae1e33446908579a56a9911c039f9a1fe5d1e3fde220240874036c9a70c7e4fdfad8ffc9a4ef4e04860",
+	}, {
+		privKey:     "0303030303030303030303030303030303030303030303030303030303030303",
+		randomness:  "1212121212121212121212121212121212121212121212121212121212121212",
+		coefficient: "e0d1638ee73f72e4c19598baaa85ee3b40140a877c8cc8fac76937707469d059",
+		partialSig:  "7833cad45dc2c266f2118e0aaa4325beff7c3b5c648faae1e33446908579a56a47ec23ed1be263c2bcdaad0b29260134a8f69d3777cf1dc2682f97db0a23e3b7",
+	}, {
+		privKey:     "0101010101010101010101010101010101010101010101010101010101010101",
+		randomness:  "1010101010101010101010101010101010101010101010101010101010101010",
+		coefficient: "f8dc7ddf7cef46a3de4133348e2e8b5f96a0e22674917d3d10dd6f6d106c507f",
+		partialSig:  "7833cad45dc2c266f2118e0aaa4325beff7c3b5c648faae1e33446908579a56aeeb88412c0edba1d2b274cfa2e51d75a4d6dd4ca168adce77a2551f796ce13e6",
+	}},
+	aggPubKey: "036143ee178a4e0d0e06a3081c0bda35232d59907d5c9c6506dfe2d9c4be8daa93",
+	sig:       "7833cad45dc2c266f2118e0aaa4325beff7c3b5c648faae1e33446908579a56acfb66839d6721c3d0641d82759b85fd0727f3c275d613a1bb27f2594c59bfebc",
+}}
+
+// decodeHex decodes the passed hex string and panics on failure.
+func decodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// muSigSessions returns a signing session of the message for each of the
+// passed private keys with randomness read from the respective reader.
+func muSigSessions(t *testing.T, key *AggregateKey,
+	privKeys []*secp256k1.PrivateKey, msg []byte,
+	randomness [][]byte) []*Session {
+
+	sessions := make([]*Session, len(privKeys))
+	for i, privKey := range privKeys {
+		var err error
+		sessions[i], err = newSession(key, privKey, msg,
+			bytes.NewReader(randomness[i]))
+		if err != nil {
+			t.Fatalf("newSession #%d: %v", i, err)
+		}
+	}
+	return sessions
+}
+
+// muSigRounds performs the commitment and reveal rounds of the passed sessions
+// and returns their public nonces indexed by signer index.
+func muSigRounds(t *testing.T, sessions []*Session) []*secp256k1.PublicKey {
+	commitments := make([][]byte, len(sessions))
+	for _, s := range sessions {
+		commitments[s.SignerIndex()] = s.NonceCommitment()
+	}
+	for i, s := range sessions {
+		if err := s.SetNonceCommitments(commitments); err != nil {
+			t.Fatalf("SetNonceCommitments #%d: %v", i, err)
+		}
+	}
+	pubNonces := make([]*secp256k1.PublicKey, len(sessions))
+	for i, s := range sessions {
+		pubNonce, err := s.PublicNonce()
+		if err != nil {
+			t.Fatalf("PublicNonce #%d: %v", i, err)
+		}
+		pubNonces[s.SignerIndex()] = pubNonce
+	}
+	for i, s := range sessions {
+		if err := s.SetPublicNonces(pubNonces); err != nil {
+			t.Fatalf("SetPublicNonces #%d: %v", i, err)
+		}
+	}
+	return pubNonces
+}
+
+// TestMuSigVectors ensures key aggregation and multi-party signing produce the
+// expected results for the test vectors and the signatures verify against the
+// aggregate key.
+func TestMuSigVectors(t *testing.T) {
+	for i, test := range muSigTestVectors {
+		msg := decodeHex(test.msg)
+		privKeys := make([]*secp256k1.PrivateKey, len(test.signers))
+		pubKeys := make([]*secp256k1.PublicKey, len(test.signers))
+		randomness := make([][]byte, len(test.signers))
+		for j, signer := range test.signers {
+			privKeys[j], pubKeys[j] = secp256k1.PrivKeyFromBytes(
+				decodeHex(signer.privKey))
+			randomness[j] = decodeHex(signer.randomness)
+		}
+
+		// The aggregate key does not depend on the order of the keys.
+		reversed := make([]*secp256k1.PublicKey, len(pubKeys))
+		for j, pubKey := range pubKeys {
+			reversed[len(pubKeys)-1-j] = pubKey
+		}
+		key, err := AggregatePubKeys(reversed)
+		if err != nil {
+			t.Fatalf("AggregatePubKeys #%d: %v", i, err)
+		}
+		aggPubKey := key.PubKey.SerializeCompressed()
+		if !bytes.Equal(aggPubKey, decodeHex(test.aggPubKey)) {
+			t.Fatalf("#%d: unexpected aggregate key -- got %x, want %s",
+				i, aggPubKey, test.aggPubKey)
+		}
+		for j, signer := range test.signers {
+			if idx := key.SignerIndex(pubKeys[j]); idx != j {
+				t.Fatalf("#%d: unexpected index of signer %d -- "+
+					"got %d", i, j, idx)
+			}
+			coefficient := BigIntToEncodedBytes(key.coefficients[j])
+			if !bytes.Equal(coefficient[:],
+				decodeHex(signer.coefficient)) {
+				t.Fatalf("#%d: unexpected coefficient of signer "+
+					"%d -- got %x, want %s", i, j, coefficient,
+					signer.coefficient)
+			}
+		}
+
+		sessions := muSigSessions(t, key, privKeys, msg, randomness)
+		pubNonces := muSigRounds(t, sessions)
+		partialSigs := make([]*Signature, len(sessions))
+		for j, s := range sessions {
+			partialSigs[j], err = s.Sign()
+			if err != nil {
+				t.Fatalf("Sign #%d/%d: %v", i, j, err)
+			}
+			got := partialSigs[j].Serialize()
+			want := decodeHex(test.signers[j].partialSig)
+			if !bytes.Equal(got, want) {
+				t.Fatalf("#%d: unexpected partial signature of "+
+					"signer %d -- got %x, want %x", i, j, got,
+					want)
+			}
+		}
+
+		sig, err := sessions[0].CombineSigs(partialSigs, pubNonces)
+		if err != nil {
+			t.Fatalf("CombineSigs #%d: %v", i, err)
+		}
+		if !bytes.Equal(sig.Serialize(), decodeHex(test.sig)) {
+			t.Fatalf("#%d: unexpected signature -- got %x, want %s",
+				i, sig.Serialize(), test.sig)
+		}
+		if !Verify(key.PubKey, msg, sig.R, sig.S) {
+			t.Fatalf("#%d: signature failed to verify", i)
+		}
+	}
+}
+
+// TestMuSigRandom ensures signatures produced by sessions with random nonces
+// for varying numbers of signers verify against the aggregate key and not
+// against the naive combination of the keys.
+func TestMuSigRandom(t *testing.T) {
+	msg := decodeHex(muSigTestVectors[0].msg)
+	for n := 1; n <= 6; n++ {
+		privKeys := make([]*secp256k1.PrivateKey, n)
+		pubKeys := make([]*secp256k1.PublicKey, n)
+		for i := range privKeys {
+			privKey, err := secp256k1.GeneratePrivateKey()
+			if err != nil {
+				t.Fatalf("GeneratePrivateKey: %v", err)
+			}
+			privKeys[i] = privKey
+			pubKeys[i] = secp256k1.NewPublicKey(privKey.Public())
+		}
+		key, err := AggregatePubKeys(pubKeys)
+		if err != nil {
+			t.Fatalf("AggregatePubKeys: %v", err)
+		}
+
+		sessions := make([]*Session, n)
+		for i, privKey := range privKeys {
+			sessions[i], err = NewSession(key, privKey, msg)
+			if err != nil {
+				t.Fatalf("NewSession: %v", err)
+			}
+		}
+		pubNonces := muSigRounds(t, sessions)
+		partialSigs := make([]*Signature, n)
+		for _, s := range sessions {
+			partialSigs[s.SignerIndex()], err = s.Sign()
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+		}
+		sig, err := sessions[n-1].CombineSigs(partialSigs, pubNonces)
+		if err != nil {
+			t.Fatalf("CombineSigs: %v", err)
+		}
+		if !Verify(key.PubKey, msg, sig.R, sig.S) {
+			t.Fatalf("signature of %d signers failed to verify", n)
+		}
+		if n > 1 {
+			naiveKey := CombinePubkeys(pubKeys)
+			if Verify(naiveKey, msg, sig.R, sig.S) {
+				t.Fatalf("signature of %d signers verified "+
+					"against naive key", n)
+			}
+		}
+	}
+}
+
+// TestMuSigErrors ensures signing sessions reject invalid input, steps which
+// are performed out of order, and nonces and partial signatures of dishonest
+// signers.
+func TestMuSigErrors(t *testing.T) {
+	test := muSigTestVectors[0]
+	msg := decodeHex(test.msg)
+	privKeys := make([]*secp256k1.PrivateKey, len(test.signers))
+	pubKeys := make([]*secp256k1.PublicKey, len(test.signers))
+	randomness := make([][]byte, len(test.signers))
+	for i, signer := range test.signers {
+		privKeys[i], pubKeys[i] = secp256k1.PrivKeyFromBytes(
+			decodeHex(signer.privKey))
+		randomness[i] = decodeHex(signer.randomness)
+	}
+
+	// assertErr ensures the passed error has the expected code.
+	assertErr := func(what string, err error, want ErrorCode) {
+		t.Helper()
+		serr, ok := err.(Error)
+		if !ok || serr.ErrorCode != want {
+			t.Fatalf("%s: unexpected error -- got %v, want %v", what,
+				err, want)
+		}
+	}
+
+	// Keys must be unique and signers must be part of the aggregate key.
+	_, err := AggregatePubKeys(nil)
+	assertErr("no keys", err, ErrInputValue)
+	_, err = AggregatePubKeys([]*secp256k1.PublicKey{pubKeys[0], pubKeys[1],
+		pubKeys[0]})
+	assertErr("duplicate key", err, ErrInputValue)
+	key, err := AggregatePubKeys(pubKeys[:2])
+	if err != nil {
+		t.Fatalf("AggregatePubKeys: %v", err)
+	}
+	_, err = NewSession(key, privKeys[2], msg)
+	assertErr("foreign signer", err, ErrInputValue)
+	_, err = NewSession(key, privKeys[0], msg[:31])
+	assertErr("short message", err, ErrBadInputSize)
+
+	// Steps performed before the previous round completes fail.
+	key, err = AggregatePubKeys(pubKeys)
+	if err != nil {
+		t.Fatalf("AggregatePubKeys: %v", err)
+	}
+	sessions := muSigSessions(t, key, privKeys, msg, randomness)
+	_, err = sessions[0].PublicNonce()
+	assertErr("nonce before commitments", err, ErrSessionState)
+	err = sessions[0].SetPublicNonces(make([]*secp256k1.PublicKey, 3))
+	assertErr("nonces before commitments", err, ErrSessionState)
+	_, err = sessions[0].Sign()
+	assertErr("sign before commitments", err, ErrSessionState)
+
+	// The commitments must include the commitment of the session.
+	commitments := make([][]byte, len(sessions))
+	for i, s := range sessions {
+		commitments[i] = s.NonceCommitment()
+	}
+	err = sessions[0].SetNonceCommitments(commitments[:2])
+	assertErr("missing commitment", err, ErrBadInputSize)
+	err = sessions[0].SetNonceCommitments([][]byte{commitments[1],
+		commitments[1], commitments[2]})
+	assertErr("wrong own commitment", err, ErrBadNonceCommitment)
+	for i, s := range sessions {
+		if err := s.SetNonceCommitments(commitments); err != nil {
+			t.Fatalf("SetNonceCommitments #%d: %v", i, err)
+		}
+	}
+	err = sessions[0].SetNonceCommitments(commitments)
+	assertErr("commitments set twice", err, ErrSessionState)
+	_, err = sessions[0].Sign()
+	assertErr("sign before nonces", err, ErrSessionState)
+
+	// A nonce which does not match its commitment is rejected.
+	pubNonces := make([]*secp256k1.PublicKey, len(sessions))
+	for i, s := range sessions {
+		pubNonces[i], _ = s.PublicNonce()
+	}
+	swapped := []*secp256k1.PublicKey{pubNonces[0], pubNonces[2],
+		pubNonces[1]}
+	err = sessions[0].SetPublicNonces(swapped)
+	assertErr("nonce mismatch", err, ErrBadNonceCommitment)
+	for i, s := range sessions {
+		if err := s.SetPublicNonces(pubNonces); err != nil {
+			t.Fatalf("SetPublicNonces #%d: %v", i, err)
+		}
+	}
+
+	// A session signs only once.
+	partialSigs := make([]*Signature, len(sessions))
+	for i, s := range sessions {
+		partialSigs[i], err = s.Sign()
+		if err != nil {
+			t.Fatalf("Sign #%d: %v", i, err)
+		}
+	}
+	_, err = sessions[0].Sign()
+	assertErr("sign twice", err, ErrSessionState)
+
+	// An invalid partial signature is identified.
+	bad := NewSignature(partialSigs[1].R,
+		new(big.Int).Add(partialSigs[1].S, big.NewInt(1)))
+	if sessions[0].VerifyPartialSig(1, bad, pubNonces) {
+		t.Fatal("invalid partial signature verified")
+	}
+	if !sessions[0].VerifyPartialSig(1, partialSigs[1], pubNonces) {
+		t.Fatal("valid partial signature failed to verify")
+	}
+	_, err = sessions[0].CombineSigs([]*Signature{partialSigs[0], bad,
+		partialSigs[2]}, pubNonces)
+	assertErr("bad partial signature", err, ErrBadPartialSig)
+	_, err = sessions[0].CombineSigs(partialSigs[:2], pubNonces)
+	assertErr("missing partial signature", err, ErrBadInputSize)
+}
@@ -22,6 +22,10 @@ var BlakeVersionStringRFC6979 = []byte("Schnorr+BLAKE256")
 
 // CombinePubkeys combines a slice of public keys into a single public key
 // by adding them together with point addition.
+//
+// The combined key is vulnerable to rogue-key attacks when the keys are not
+// known to be honestly generated.  AggregatePubKeys and Session should be used
+// for multi-party signatures instead.
 func CombinePubkeys(pks []*secp256k1.PublicKey) *secp256k1.PublicKey {
 	numPubKeys := len(pks)
 	curve := secp256k1.S256()
@@ -7,7 +7,6 @@ package secp256k1
 
 import (
 	"bytes"
-	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/hmac"
 	"crypto/sha256"
@@ -81,10 +80,35 @@ func (sig *Signature) Serialize() []byte {
 	return b
 }
 
-// Verify calls ecdsa.Verify to verify the signature of hash using the public
-// key.  It returns true if the signature is valid, false otherwise.
+// Verify verifies the signature of hash using the public key.  It returns true
+// if the signature is valid, false otherwise.
+//
+// This is the verification algorithm of [SECG] section 4.1.4 with the two
+// point multiplications computed simultaneously by CombinedMult.
 func (sig *Signature) Verify(hash []byte, pubKey *PublicKey) bool {
-	return ecdsa.Verify(pubKey.ToECDSA(), hash, sig.GetR(), sig.GetS())
+	curve := S256()
+	N := curve.N
+	r, s := sig.GetR(), sig.GetS()
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
+		return false
+	}
+
+	// u1 = e/s and u2 = r/s
+	e := hashToInt(hash)
+	w := new(big.Int).ModInverse(s, N)
+	u1 := e.Mul(e, w)
+	u1.Mod(u1, N)
+	u2 := w.Mul(r, w)
+	u2.Mod(u2, N)
+
+	// R = u1*G + u2*Q must not be the point at infinity and its x
+	// coordinate must equal r (mod N).
+	x, y := curve.CombinedMult(pubKey.X, pubKey.Y, u1.Bytes(), u2.Bytes())
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return false
+	}
+	x.Mod(x, N)
+	return x.Cmp(r) == 0
 }
 
 // IsEqual compares this Signature instance to the one passed, returning true
@@ -427,34 +451,40 @@ func RecoverCompact(signature,
 
 // signRFC6979 generates a deterministic ECDSA signature according to RFC 6979
 // and BIP 62.
+//
+// Since the private key and nonce are secret, all arithmetic involving them is
+// performed in constant time with scalars and the nonce point is computed with
+// scalarBaseMultConstTime.
 func signRFC6979(privateKey *PrivateKey, hash []byte) (*Signature, error) {
-
-	privkey := privateKey.ToECDSA()
-	N := order
-	k := NonceRFC6979(privkey.D, hash, nil, nil)
-	inv := new(big.Int).ModInverse(k, N)
-	r, _ := privkey.Curve.ScalarBaseMult(k.Bytes())
-	if r.Cmp(N) == 1 {
-		r.Sub(r, N)
-	}
-
-	if r.Sign() == 0 {
+	curve := S256()
+	nonce := NonceRFC6979(privateKey.D, hash, nil, nil)
+	var k, d, e, r, s modNScalar
+	k.SetByteSlice(nonce.Bytes())
+	d.SetByteSlice(privateKey.D.Bytes())
+	defer k.Zero()
+	defer d.Zero()
+
+	// r = (k*G).x (mod N)
+	rx, _ := curve.scalarBaseMultConstTime(&k)
+	r.SetBytes(rx.Bytes())
+	if r.IsZero() {
 		return nil, errors.New("calculated R is zero")
 	}
 
-	e := hashToInt(hash)
-	s := new(big.Int).Mul(privkey.D, r)
-	s.Add(s, e)
-	s.Mul(s, inv)
-	s.Mod(s, N)
-
-	if s.Cmp(halforder) == 1 {
-		s.Sub(N, s)
-	}
-	if s.Sign() == 0 {
+	// s = (e + d*r) / k (mod N), negated when it is over half the order
+	// to tame malleability as required by BIP 62.
+	e.SetByteSlice(hashToInt(hash).Bytes())
+	s.Mul2(&d, &r).Add(&e).Mul(k.Inverse())
+	s.CondNegate(s.overHalfOrder())
+	if s.IsZero() {
 		return nil, errors.New("calculated S is zero")
 	}
-	return &Signature{R: r, S: s}, nil
+
+	rBytes, sBytes := r.Bytes(), s.Bytes()
+	return &Signature{
+		R: new(big.Int).SetBytes(rBytes[:]),
+		S: new(big.Int).SetBytes(sBytes[:]),
+	}, nil
 }
 
 // NonceRFC6979 generates an ECDSA nonce (`k`) deterministically according to
@@ -7,6 +7,7 @@ package secp256k1
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
@@ -608,3 +609,104 @@ func TestSignatureIsEqual(t *testing.T) {
 			"equal to %v", sig1, sig2)
 	}
 }
+
+// TestVerifyDifferential ensures signature verification, which computes the
+// point multiplications simultaneously, agrees with the crypto/ecdsa package,
+// which computes them separately, for random signatures along with edge cases
+// of r and s and signatures that involve the point at infinity.
+func TestVerifyDifferential(t *testing.T) {
+	curve := S256()
+	N := curve.N
+
+	// verify ensures the result of verifying the passed signature agrees
+	// with crypto/ecdsa and returns it.
+	verify := func(desc string, pubKey *PublicKey, hash []byte, r, s *big.Int) bool {
+		t.Helper()
+		sig := &Signature{R: r, S: s}
+		got := sig.Verify(hash, pubKey)
+		want := ecdsa.Verify(pubKey.ToECDSA(), hash, r, s)
+		if got != want {
+			t.Fatalf("%s: mismatched verification result for r %x, "+
+				"s %x -- got %v, want %v", desc, r, s, got, want)
+		}
+		return got
+	}
+
+	for i := 0; i < 128; i++ {
+		privKey, err := GeneratePrivateKey()
+		if err != nil {
+			t.Fatalf("failed to generate private key: %v", err)
+		}
+		pubKey := NewPublicKey(privKey.Public())
+		hash := make([]byte, 32+i%3*8)
+		if _, err := rand.Read(hash); err != nil {
+			t.Fatalf("failed to read random data: %v", err)
+		}
+		sig, err := privKey.Sign(hash)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		if !verify("valid", pubKey, hash, sig.R, sig.S) {
+			t.Fatalf("%d: valid signature failed to verify", i)
+		}
+
+		// Modified hashes and signature values.
+		badHash := append([]byte{}, hash...)
+		badHash[i%32] ^= 0x01
+		verify("modified hash", pubKey, badHash, sig.R, sig.S)
+		verify("negated s", pubKey, hash, sig.R,
+			new(big.Int).Sub(N, sig.S))
+		verify("modified r", pubKey, hash,
+			new(big.Int).Add(sig.R, big.NewInt(1)), sig.S)
+
+		// Random values of r and s.
+		randR, err := rand.Int(rand.Reader, N)
+		if err != nil {
+			t.Fatalf("failed to read random data: %v", err)
+		}
+		randS, err := rand.Int(rand.Reader, N)
+		if err != nil {
+			t.Fatalf("failed to read random data: %v", err)
+		}
+		verify("random", pubKey, hash, randR, randS)
+
+		// Edge cases of r and s.
+		edgeValues := []*big.Int{
+			big.NewInt(0),
+			big.NewInt(1),
+			new(big.Int).Sub(N, big.NewInt(1)),
+			new(big.Int).Set(N),
+			new(big.Int).Add(N, big.NewInt(1)),
+			new(big.Int).Add(N, sig.R),
+			new(big.Int).Add(N, sig.S),
+			new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256),
+				big.NewInt(1)),
+		}
+		for _, v := range edgeValues {
+			if verify("edge r", pubKey, hash, v, sig.S) {
+				t.Fatalf("%d: signature with r %x verified", i, v)
+			}
+			if verify("edge s", pubKey, hash, sig.R, v) {
+				t.Fatalf("%d: signature with s %x verified", i, v)
+			}
+		}
+
+		// Choose the hash such that u1*G + u2*Q is the point at infinity,
+		// which requires e = -r*d (mod N).
+		e := new(big.Int).Mul(randR, privKey.D)
+		e.Neg(e).Mod(e, N)
+		infHash := make([]byte, 32)
+		e.FillBytes(infHash)
+		if verify("point at infinity", pubKey, infHash, randR, randS) {
+			t.Fatalf("%d: signature resulting in the point at "+
+				"infinity verified", i)
+		}
+
+		// A public key at the point at infinity never verifies.
+		infPubKey := NewPublicKey(new(big.Int), new(big.Int))
+		if verify("public key at infinity", infPubKey, hash, sig.R, sig.S) {
+			t.Fatalf("%d: signature for the public key at infinity "+
+				"verified", i)
+		}
+	}
+}
@@ -81,6 +81,28 @@ func TestChainSvrCmds(t *testing.T) {
 				Expiry:   cdrjson.Int64(12312333333),
 			},
 		},
+		{
+			name: "combinepsbt",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("combinepsbt", []string{"cHNidP8=", "cHNidP9="})
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewCombinePsbtCmd([]string{"cHNidP8=", "cHNidP9="})
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"combinepsbt","params":[["cHNidP8=","cHNidP9="]],"id":1}`,
+			unmarshalled: &cdrjson.CombinePsbtCmd{Psbts: []string{"cHNidP8=", "cHNidP9="}},
+		},
+		{
+			name: "decodepsbt",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("decodepsbt", "cHNidP8=")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewDecodePsbtCmd("cHNidP8=")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"decodepsbt","params":["cHNidP8="],"id":1}`,
+			unmarshalled: &cdrjson.DecodePsbtCmd{Psbt: "cHNidP8="},
+		},
 		{
 			name: "decoderawtransaction",
 			newCmd: func() (interface{}, error) {
@@ -92,6 +114,39 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"decoderawtransaction","params":["123"],"id":1}`,
 			unmarshalled: &cdrjson.DecodeRawTransactionCmd{HexTx: "123"},
 		},
+		{
+			name: "debugscript",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("debugscript", "0102", 1, "51")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewDebugScriptCmd("0102", 1, "51", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"debugscript","params":["0102",1,"51"],"id":1}`,
+			unmarshalled: &cdrjson.DebugScriptCmd{
+				HexTx:         "0102",
+				Index:         1,
+				PkScript:      "51",
+				ScriptVersion: func() *uint16 { v := uint16(0); return &v }(),
+			},
+		},
+		{
+			name: "debugscript optional",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("debugscript", "0102", 1, "51", 0)
+			},
+			staticCmd: func() interface{} {
+				version := uint16(0)
+				return cdrjson.NewDebugScriptCmd("0102", 1, "51", &version)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"debugscript","params":["0102",1,"51",0],"id":1}`,
+			unmarshalled: &cdrjson.DebugScriptCmd{
+				HexTx:         "0102",
+				Index:         1,
+				PkScript:      "51",
+				ScriptVersion: func() *uint16 { v := uint16(0); return &v }(),
+			},
+		},
 		{
 			name: "decodescript",
 			newCmd: func() (interface{}, error) {
@@ -103,6 +158,34 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"decodescript","params":["00"],"id":1}`,
 			unmarshalled: &cdrjson.DecodeScriptCmd{HexScript: "00"},
 		},
+		{
+			name: "finalizepsbt",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("finalizepsbt", "cHNidP8=")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewFinalizePsbtCmd("cHNidP8=", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"finalizepsbt","params":["cHNidP8="],"id":1}`,
+			unmarshalled: &cdrjson.FinalizePsbtCmd{
+				Psbt:    "cHNidP8=",
+				Extract: cdrjson.Bool(true),
+			},
+		},
+		{
+			name: "finalizepsbt optional",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("finalizepsbt", "cHNidP8=", false)
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewFinalizePsbtCmd("cHNidP8=", cdrjson.Bool(false))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"finalizepsbt","params":["cHNidP8=",false],"id":1}`,
+			unmarshalled: &cdrjson.FinalizePsbtCmd{
+				Psbt:    "cHNidP8=",
+				Extract: cdrjson.Bool(false),
+			},
+		},
 		{
 			name: "getaddednodeinfo",
 			newCmd: func() (interface{}, error) {
@@ -235,6 +318,34 @@ func TestChainSvrCmds(t *testing.T) {
 				Verbose: cdrjson.Bool(true),
 			},
 		},
+		{
+			name: "getblockstats",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("getblockstats", "123")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewGetBlockStatsCmd("123", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockstats","params":["123"],"id":1}`,
+			unmarshalled: &cdrjson.GetBlockStatsCmd{
+				HashOrHeight: "123",
+			},
+		},
+		{
+			name: "getblockstats optional stats",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("getblockstats", "000000000000000000000000000000000000000000000000000000000000000a", `["height","totalfee"]`)
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewGetBlockStatsCmd("000000000000000000000000000000000000000000000000000000000000000a",
+					&[]string{"height", "totalfee"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockstats","params":["000000000000000000000000000000000000000000000000000000000000000a",["height","totalfee"]],"id":1}`,
+			unmarshalled: &cdrjson.GetBlockStatsCmd{
+				HashOrHeight: "000000000000000000000000000000000000000000000000000000000000000a",
+				Stats:        &[]string{"height", "totalfee"},
+			},
+		},
 		{
 			name: "getblocksubsidy",
 			newCmd: func() (interface{}, error) {
@@ -371,6 +482,35 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"getchaintips","params":[],"id":1}`,
 			unmarshalled: &cdrjson.GetChainTipsCmd{},
 		},
+		{
+			name: "getchaintxstats",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("getchaintxstats")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewGetChainTxStatsCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getchaintxstats","params":[],"id":1}`,
+			unmarshalled: &cdrjson.GetChainTxStatsCmd{
+				NBlocks:   nil,
+				BlockHash: nil,
+			},
+		},
+		{
+			name: "getchaintxstats optional",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("getchaintxstats", 100, "123")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewGetChainTxStatsCmd(cdrjson.Int32(100),
+					cdrjson.String("123"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getchaintxstats","params":[100,"123"],"id":1}`,
+			unmarshalled: &cdrjson.GetChainTxStatsCmd{
+				NBlocks:   cdrjson.Int32(100),
+				BlockHash: cdrjson.String("123"),
+			},
+		},
 		{
 			name: "getconnectioncount",
 			newCmd: func() (interface{}, error) {
@@ -621,6 +761,35 @@ func TestChainSvrCmds(t *testing.T) {
 				IncludeMempool: cdrjson.Bool(true),
 			},
 		},
+		{
+			name: "gettxoutproof",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("gettxoutproof", []string{"123", "456"})
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewGetTxOutProofCmd([]string{"123", "456"}, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettxoutproof","params":[["123","456"]],"id":1}`,
+			unmarshalled: &cdrjson.GetTxOutProofCmd{
+				TxIDs:     []string{"123", "456"},
+				BlockHash: nil,
+			},
+		},
+		{
+			name: "gettxoutproof optional",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("gettxoutproof", []string{"123"}, "abc")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewGetTxOutProofCmd([]string{"123"},
+					cdrjson.String("abc"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettxoutproof","params":[["123"],"abc"],"id":1}`,
+			unmarshalled: &cdrjson.GetTxOutProofCmd{
+				TxIDs:     []string{"123"},
+				BlockHash: cdrjson.String("abc"),
+			},
+		},
 		{
 			name: "gettxoutsetinfo",
 			newCmd: func() (interface{}, error) {
@@ -684,6 +853,19 @@ func TestChainSvrCmds(t *testing.T) {
 				Command: cdrjson.String("getblock"),
 			},
 		},
+		{
+			name: "invalidateblock",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("invalidateblock", "123")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewInvalidateBlockCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"invalidateblock","params":["123"],"id":1}`,
+			unmarshalled: &cdrjson.InvalidateBlockCmd{
+				BlockHash: "123",
+			},
+		},
 		{
 			name: "ping",
 			newCmd: func() (interface{}, error) {
@@ -695,6 +877,56 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"ping","params":[],"id":1}`,
 			unmarshalled: &cdrjson.PingCmd{},
 		},
+		{
+			name: "reconsiderblock",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("reconsiderblock", "123")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewReconsiderBlockCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"reconsiderblock","params":["123"],"id":1}`,
+			unmarshalled: &cdrjson.ReconsiderBlockCmd{
+				BlockHash: "123",
+			},
+		},
+		{
+			name: "scantxoutset",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("scantxoutset", "status")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewScanTxOutSetCmd(cdrjson.ScanTxOutSetStatus, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"scantxoutset","params":["status"],"id":1}`,
+			unmarshalled: &cdrjson.ScanTxOutSetCmd{
+				Action: cdrjson.ScanTxOutSetStatus,
+			},
+		},
+		{
+			name: "scantxoutset optional scan objects",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("scantxoutset", "start",
+					`[{"desc":"raw(51)"},{"desc":"pkh(dpubXXX/*)","rangestart":0,"rangeend":10}]`)
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewScanTxOutSetCmd(cdrjson.ScanTxOutSetStart,
+					&[]cdrjson.Descriptor{
+						{Desc: "raw(51)"},
+						{Desc: "pkh(dpubXXX/*)", RangeStart: cdrjson.Uint32(0),
+							RangeEnd: cdrjson.Uint32(10)},
+					})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"scantxoutset","params":["start",[{"desc":"raw(51)"},{"desc":"pkh(dpubXXX/*)","rangestart":0,"rangeend":10}]],"id":1}`,
+			unmarshalled: &cdrjson.ScanTxOutSetCmd{
+				Action: cdrjson.ScanTxOutSetStart,
+				ScanObjects: &[]cdrjson.Descriptor{
+					{Desc: "raw(51)"},
+					{Desc: "pkh(dpubXXX/*)", RangeStart: cdrjson.Uint32(0),
+						RangeEnd: cdrjson.Uint32(10)},
+				},
+			},
+		},
 		{
 			name: "searchrawtransactions",
 			newCmd: func() (interface{}, error) {
@@ -959,8 +1191,9 @@ func TestChainSvrCmds(t *testing.T) {
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"verifychain","params":[],"id":1}`,
 			unmarshalled: &cdrjson.VerifyChainCmd{
-				CheckLevel: cdrjson.Int64(3),
+				CheckLevel: cdrjson.Int64(1),
 				CheckDepth: cdrjson.Int64(288),
+				Verbose:    cdrjson.Bool(false),
 			},
 		},
 		{
@@ -975,6 +1208,7 @@ func TestChainSvrCmds(t *testing.T) {
 			unmarshalled: &cdrjson.VerifyChainCmd{
 				CheckLevel: cdrjson.Int64(2),
 				CheckDepth: cdrjson.Int64(288),
+				Verbose:    cdrjson.Bool(false),
 			},
 		},
 		{
@@ -989,6 +1223,26 @@ func TestChainSvrCmds(t *testing.T) {
 			unmarshalled: &cdrjson.VerifyChainCmd{
 				CheckLevel: cdrjson.Int64(2),
 				CheckDepth: cdrjson.Int64(500),
+				Verbose:    cdrjson.Bool(false),
+			},
+		},
+		{
+			name: "verifychain optional3",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("verifychain", 5, 0, true)
+			},
+			staticCmd: func() interface{} {
+				return &cdrjson.VerifyChainCmd{
+					CheckLevel: cdrjson.Int64(5),
+					CheckDepth: cdrjson.Int64(0),
+					Verbose:    cdrjson.Bool(true),
+				}
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"verifychain","params":[5,0,true],"id":1}`,
+			unmarshalled: &cdrjson.VerifyChainCmd{
+				CheckLevel: cdrjson.Int64(5),
+				CheckDepth: cdrjson.Int64(0),
+				Verbose:    cdrjson.Bool(true),
 			},
 		},
 		{
@@ -1006,6 +1260,19 @@ func TestChainSvrCmds(t *testing.T) {
 				Message:   "test",
 			},
 		},
+		{
+			name: "verifytxoutproof",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("verifytxoutproof", "00")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewVerifyTxOutProofCmd("00")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"verifytxoutproof","params":["00"],"id":1}`,
+			unmarshalled: &cdrjson.VerifyTxOutProofCmd{
+				Proof: "00",
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -1105,6 +1372,12 @@ func TestChainSvrCmdErrors(t *testing.T) {
 			marshalled: `{"sizelimit":"invalid"}`,
 			err:        cdrjson.Error{Code: cdrjson.ErrInvalidType},
 		},
+		{
+			name:       "invalid hash or height",
+			result:     new(cdrjson.HashOrHeight),
+			marshalled: `1.5`,
+			err:        cdrjson.Error{Code: cdrjson.ErrInvalidType},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -1127,3 +1400,32 @@ func TestChainSvrCmdErrors(t *testing.T) {
 		}
 	}
 }
+
+// TestHashOrHeight ensures a block hash or height may be specified as either a
+// JSON string or a JSON number.
+func TestHashOrHeight(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		marshalled string
+		want       cdrjson.HashOrHeight
+	}{
+		{`"123"`, "123"},
+		{`123`, "123"},
+		{`-1`, "-1"},
+		{`"000000000000000000000000000000000000000000000000000000000000000a"`,
+			"000000000000000000000000000000000000000000000000000000000000000a"},
+	}
+
+	for i, test := range tests {
+		var got cdrjson.HashOrHeight
+		if err := json.Unmarshal([]byte(test.marshalled), &got); err != nil {
+			t.Errorf("Test #%d unexpected error: %v", i, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Test #%d mismatched value - got %q, want %q", i,
+				got, test.want)
+		}
+	}
+}
@@ -73,6 +73,80 @@ type CreateMultiSigResult struct {
 	RedeemScript string `json:"redeemScript"`
 }
 
+// DebugScriptStep models the state of the script engine after executing a
+// single opcode as returned in the steps of the debugscript command.
+type DebugScriptStep struct {
+	ScriptIdx int      `json:"scriptidx"`
+	OpcodeIdx int      `json:"opcodeidx"`
+	Opcode    string   `json:"opcode"`
+	Disasm    string   `json:"disasm"`
+	DataStack []string `json:"datastack"`
+	AltStack  []string `json:"altstack"`
+	CondStack []int    `json:"condstack"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// DebugScriptResult models the data returned from the debugscript command.
+type DebugScriptResult struct {
+	Valid      bool              `json:"valid"`
+	Error      string            `json:"error,omitempty"`
+	FailedStep int               `json:"failedstep"`
+	Steps      []DebugScriptStep `json:"steps"`
+}
+
+// PsbtDerivation models the HD derivation path of a public key as returned
+// in the inputs and outputs of the decodepsbt command.
+type PsbtDerivation struct {
+	PubKey            string `json:"pubkey"`
+	MasterFingerprint string `json:"masterfingerprint"`
+	Path              string `json:"path"`
+}
+
+// PsbtPartialSig models a partial signature of an input as returned by the
+// decodepsbt command.
+type PsbtPartialSig struct {
+	PubKey    string `json:"pubkey"`
+	Signature string `json:"signature"`
+	SigType   string `json:"sigtype"`
+}
+
+// PsbtPrevOut models the previous output spent by an input as returned by
+// the decodepsbt command.
+type PsbtPrevOut struct {
+	Value        float64            `json:"value"`
+	Version      uint16             `json:"version"`
+	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
+}
+
+// DecodePsbtInput models an input as returned by the decodepsbt command.
+type DecodePsbtInput struct {
+	PrevOut        *PsbtPrevOut      `json:"prevout,omitempty"`
+	RedeemScript   string            `json:"redeemscript,omitempty"`
+	Derivations    []PsbtDerivation  `json:"derivations,omitempty"`
+	PartialSigs    []PsbtPartialSig  `json:"partialsigs,omitempty"`
+	SigHashType    uint32            `json:"sighashtype,omitempty"`
+	FinalScriptSig *ScriptSig        `json:"finalscriptsig,omitempty"`
+	Unknown        map[string]string `json:"unknown,omitempty"`
+}
+
+// DecodePsbtOutput models an output as returned by the decodepsbt command.
+type DecodePsbtOutput struct {
+	RedeemScript string            `json:"redeemscript,omitempty"`
+	Derivations  []PsbtDerivation  `json:"derivations,omitempty"`
+	Unknown      map[string]string `json:"unknown,omitempty"`
+}
+
+// DecodePsbtResult models the data returned from the decodepsbt command.
+type DecodePsbtResult struct {
+	Tx       TxRawDecodeResult  `json:"tx"`
+	TxType   string             `json:"txtype"`
+	Inputs   []DecodePsbtInput  `json:"inputs"`
+	Outputs  []DecodePsbtOutput `json:"outputs"`
+	Unknown  map[string]string  `json:"unknown,omitempty"`
+	Fee      *float64           `json:"fee,omitempty"`
+	Complete bool               `json:"complete"`
+}
+
 // DecodeScriptResult models the data returned from the decodescript command.
 type DecodeScriptResult struct {
 	Asm       string   `json:"asm"`
@@ -82,6 +156,13 @@ type DecodeScriptResult struct {
 	P2sh      string   `json:"p2sh,omitempty"`
 }
 
+// FinalizePsbtResult models the data returned from the finalizepsbt command.
+type FinalizePsbtResult struct {
+	Psbt     string `json:"psbt,omitempty"`
+	Hex      string `json:"hex,omitempty"`
+	Complete bool   `json:"complete"`
+}
+
 // GetAddedNodeInfoResultAddr models the data of the addresses portion of the
 // getaddednodeinfo command.
 type GetAddedNodeInfoResultAddr struct {
@@ -108,6 +189,36 @@ type GetBlockChainInfoResult struct {
 	ChainWork            string  `json:"chainwork"`
 }
 
+// GetBlockStatsResult models the data returned from the getblockstats command.
+// All amounts are in atoms and all fee rates are in atoms per kilobyte.  Only
+// the requested fields are returned when a subset of the stats is requested.
+type GetBlockStatsResult struct {
+	BlockHash          string  `json:"blockhash"`
+	Height             int64   `json:"height"`
+	Time               int64   `json:"time"`
+	Interval           int64   `json:"interval"`
+	BlockSize          uint32  `json:"blocksize"`
+	Txs                uint32  `json:"txs"`
+	Tickets            uint32  `json:"tickets"`
+	Votes              uint32  `json:"votes"`
+	Revocations        uint32  `json:"revocations"`
+	Ins                uint32  `json:"ins"`
+	Outs               uint32  `json:"outs"`
+	UtxoIncrease       int32   `json:"utxoincrease"`
+	TotalOut           int64   `json:"totalout"`
+	TotalStakeOut      int64   `json:"totalstakeout"`
+	TotalFee           int64   `json:"totalfee"`
+	TotalStakeFee      int64   `json:"totalstakefee"`
+	MinFeeRate         int64   `json:"minfeerate"`
+	MaxFeeRate         int64   `json:"maxfeerate"`
+	AvgFeeRate         int64   `json:"avgfeerate"`
+	MedianFeeRate      int64   `json:"medianfeerate"`
+	FeeRatePercentiles []int64 `json:"feeratepercentiles"`
+	PoWSubsidy         int64   `json:"powsubsidy"`
+	PoSSubsidy         int64   `json:"possubsidy"`
+	DevSubsidy         int64   `json:"devsubsidy"`
+}
+
 // GetBlockSubsidyResult models the data returned from the getblocksubsidy
 // command.
 type GetBlockSubsidyResult struct {
@@ -180,6 +291,18 @@ type GetChainTipsResult struct {
 	Status    string `json:"status"`
 }
 
+// GetChainTxStatsResult models the data returned from the getchaintxstats
+// command.
+type GetChainTxStatsResult struct {
+	Time                 int64   `json:"time"`
+	TxCount              uint64  `json:"txcount"`
+	WindowFinalBlockHash string  `json:"windowfinalblockhash"`
+	WindowBlockCount     int64   `json:"windowblockcount"`
+	WindowTxCount        uint64  `json:"windowtxcount"`
+	WindowInterval       int64   `json:"windowinterval"`
+	TxRate               float64 `json:"txrate"`
+}
+
 // GetMempoolInfoResult models the data returned from the getmempoolinfo
 // command.
 type GetMempoolInfoResult struct {
@@ -236,6 +359,36 @@ type GetRawMempoolVerboseResult struct {
 	Depends          []string `json:"depends"`
 }
 
+// ScanTxOutSetUnspent models an unspent transaction output found by the
+// scantxoutset command.
+type ScanTxOutSetUnspent struct {
+	TxID         string  `json:"txid"`
+	Vout         uint32  `json:"vout"`
+	Tree         int8    `json:"tree"`
+	ScriptPubKey string  `json:"scriptpubkey"`
+	Desc         string  `json:"desc"`
+	Amount       float64 `json:"amount"`
+	Height       int64   `json:"height"`
+	TxType       string  `json:"txtype"`
+	Coinbase     bool    `json:"coinbase"`
+}
+
+// ScanTxOutSetResult models the data returned from the scantxoutset command
+// when a scan is started.
+type ScanTxOutSetResult struct {
+	SearchedItems uint64                `json:"searcheditems"`
+	BestBlock     string                `json:"bestblock"`
+	Height        int64                 `json:"height"`
+	Unspents      []ScanTxOutSetUnspent `json:"unspents"`
+	TotalAmount   float64               `json:"totalamount"`
+}
+
+// ScanTxOutSetStatusResult models the data returned from the scantxoutset
+// command when the status of a scan in progress is requested.
+type ScanTxOutSetStatusResult struct {
+	Progress float64 `json:"progress"`
+}
+
 // ScriptPubKeyResult models the scriptPubKey data of a tx script.  It is
 // defined separately since it is used by multiple commands.
 type ScriptPubKeyResult struct {
@@ -550,6 +703,26 @@ type ValidateAddressChainResult struct {
 	Address string `json:"address,omitempty"`
 }
 
+// VerifyChainInconsistency models an inconsistency found while verifying the
+// chain.  It is returned as part of the verifychain command result when the
+// verbose flag is set.
+type VerifyChainInconsistency struct {
+	Hash        string `json:"hash"`
+	Height      int64  `json:"height"`
+	Description string `json:"description"`
+}
+
+// VerifyChainResult models the data returned from the verifychain command when
+// the verbose flag is set.
+type VerifyChainResult struct {
+	Verified        bool                       `json:"verified"`
+	CheckLevel      int64                      `json:"checklevel"`
+	NumBlocks       int64                      `json:"numblocks"`
+	UtxoSetValue    float64                    `json:"utxosetvalue,omitempty"`
+	CoinSupply      float64                    `json:"coinsupply,omitempty"`
+	Inconsistencies []VerifyChainInconsistency `json:"inconsistencies"`
+}
+
 // GetHeadersResult models the data returned by the chain server getheaders
 // command.
 type GetHeadersResult struct {
@@ -31,21 +31,37 @@ type OutPoint struct {
 	Index uint32 `json:"index"`
 }
 
+// Descriptor describes an output script descriptor that will be marshalled
+// to and from JSON.  The inclusive range of child indexes to derive only
+// applies to ranged descriptors.
+type Descriptor struct {
+	Desc       string  `json:"desc"`
+	RangeStart *uint32 `json:"rangestart,omitempty"`
+	RangeEnd   *uint32 `json:"rangeend,omitempty"`
+}
+
 // LoadTxFilterCmd defines the loadtxfilter request parameters to load or
 // reload a transaction filter.
 type LoadTxFilterCmd struct {
-	Reload    bool
-	Addresses []string
-	OutPoints []OutPoint
+	Reload      bool
+	Addresses   []string
+	OutPoints   []OutPoint
+	Descriptors *[]Descriptor
 }
 
 // NewLoadTxFilterCmd returns a new instance which can be used to issue a
 // loadtxfilter JSON-RPC command.
-func NewLoadTxFilterCmd(reload bool, addresses []string, outPoints []OutPoint) *LoadTxFilterCmd {
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewLoadTxFilterCmd(reload bool, addresses []string, outPoints []OutPoint,
+	descriptors *[]Descriptor) *LoadTxFilterCmd {
+
 	return &LoadTxFilterCmd{
-		Reload:    reload,
-		Addresses: addresses,
-		OutPoints: outPoints,
+		Reload:      reload,
+		Addresses:   addresses,
+		OutPoints:   outPoints,
+		Descriptors: descriptors,
 	}
 }
 
@@ -58,6 +74,21 @@ func NewNotifyBlocksCmd() *NotifyBlocksCmd {
 	return &NotifyBlocksCmd{}
 }
 
+// NotifyBlocksFromCmd defines the notifyblocksfrom JSON-RPC command.  The
+// cursor is either the sequence number of the last chain event that was
+// processed or the hash of the last block that was processed.
+type NotifyBlocksFromCmd struct {
+	Cursor string
+}
+
+// NewNotifyBlocksFromCmd returns a new instance which can be used to issue a
+// notifyblocksfrom JSON-RPC command.
+func NewNotifyBlocksFromCmd(cursor string) *NotifyBlocksFromCmd {
+	return &NotifyBlocksFromCmd{
+		Cursor: cursor,
+	}
+}
+
 // NotifyWinningTicketsCmd is a type handling custom marshaling and
 // unmarshaling of notifywinningtickets JSON websocket extension
 // commands.
@@ -153,12 +184,19 @@ type RescanCmd struct {
 	// Concatenated block hashes in non-byte-reversed hex encoding.  Must
 	// have length evenly divisible by 2*chainhash.HashSize.
 	BlockHashes string
+
+	// Descriptors to add to the loaded transaction filter before
+	// rescanning.
+	Descriptors *[]Descriptor
 }
 
 // NewRescanCmd returns a new instance which can be used to issue a rescan
 // JSON-RPC command.
-func NewRescanCmd(blockHashes string) *RescanCmd {
-	return &RescanCmd{BlockHashes: blockHashes}
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewRescanCmd(blockHashes string, descriptors *[]Descriptor) *RescanCmd {
+	return &RescanCmd{BlockHashes: blockHashes, Descriptors: descriptors}
 }
 
 func init() {
@@ -168,6 +206,7 @@ func init() {
 	MustRegisterCmd("authenticate", (*AuthenticateCmd)(nil), flags)
 	MustRegisterCmd("loadtxfilter", (*LoadTxFilterCmd)(nil), flags)
 	MustRegisterCmd("notifyblocks", (*NotifyBlocksCmd)(nil), flags)
+	MustRegisterCmd("notifyblocksfrom", (*NotifyBlocksFromCmd)(nil), flags)
 	MustRegisterCmd("notifynewtransactions", (*NotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCmd("notifynewtickets", (*NotifyNewTicketsCmd)(nil), flags)
 	MustRegisterCmd("notifyspentandmissedtickets",
@@ -96,6 +96,19 @@ func TestChainSvrWsCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"notifyblocks","params":[],"id":1}`,
 			unmarshalled: &cdrjson.NotifyBlocksCmd{},
 		},
+		{
+			name: "notifyblocksfrom",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("notifyblocksfrom", "123")
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewNotifyBlocksFromCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifyblocksfrom","params":["123"],"id":1}`,
+			unmarshalled: &cdrjson.NotifyBlocksFromCmd{
+				Cursor: "123",
+			},
+		},
 		{
 			name: "stopnotifyblocks",
 			newCmd: func() (interface{}, error) {
@@ -150,13 +163,72 @@ func TestChainSvrWsCmds(t *testing.T) {
 				return cdrjson.NewCmd("rescan", "0000000000000000000000000000000000000000000000000000000000000123")
 			},
 			staticCmd: func() interface{} {
-				return cdrjson.NewRescanCmd("0000000000000000000000000000000000000000000000000000000000000123")
+				return cdrjson.NewRescanCmd("0000000000000000000000000000000000000000000000000000000000000123", nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"rescan","params":["0000000000000000000000000000000000000000000000000000000000000123"],"id":1}`,
 			unmarshalled: &cdrjson.RescanCmd{
 				BlockHashes: "0000000000000000000000000000000000000000000000000000000000000123",
 			},
 		},
+		{
+			name: "rescan optional",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("rescan", "0000000000000000000000000000000000000000000000000000000000000123",
+					`[{"desc":"raw(51)"}]`)
+			},
+			staticCmd: func() interface{} {
+				descs := []cdrjson.Descriptor{{Desc: "raw(51)"}}
+				return cdrjson.NewRescanCmd("0000000000000000000000000000000000000000000000000000000000000123", &descs)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"rescan","params":["0000000000000000000000000000000000000000000000000000000000000123",[{"desc":"raw(51)"}]],"id":1}`,
+			unmarshalled: &cdrjson.RescanCmd{
+				BlockHashes: "0000000000000000000000000000000000000000000000000000000000000123",
+				Descriptors: &[]cdrjson.Descriptor{{Desc: "raw(51)"}},
+			},
+		},
+		{
+			name: "loadtxfilter",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("loadtxfilter", false, `["DsSej1qR3Fyc8kV176DCh9n9cY9nqf9Quxk"]`, `[]`)
+			},
+			staticCmd: func() interface{} {
+				return cdrjson.NewLoadTxFilterCmd(false, []string{"DsSej1qR3Fyc8kV176DCh9n9cY9nqf9Quxk"},
+					[]cdrjson.OutPoint{}, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"loadtxfilter","params":[false,["DsSej1qR3Fyc8kV176DCh9n9cY9nqf9Quxk"],[]],"id":1}`,
+			unmarshalled: &cdrjson.LoadTxFilterCmd{
+				Reload:    false,
+				Addresses: []string{"DsSej1qR3Fyc8kV176DCh9n9cY9nqf9Quxk"},
+				OutPoints: []cdrjson.OutPoint{},
+			},
+		},
+		{
+			name: "loadtxfilter optional",
+			newCmd: func() (interface{}, error) {
+				return cdrjson.NewCmd("loadtxfilter", true, `[]`, `[]`,
+					`[{"desc":"pkh(dpub/0/*)","rangestart":5,"rangeend":10}]`)
+			},
+			staticCmd: func() interface{} {
+				descs := []cdrjson.Descriptor{{
+					Desc:       "pkh(dpub/0/*)",
+					RangeStart: cdrjson.Uint32(5),
+					RangeEnd:   cdrjson.Uint32(10),
+				}}
+				return cdrjson.NewLoadTxFilterCmd(true, []string{},
+					[]cdrjson.OutPoint{}, &descs)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"loadtxfilter","params":[true,[],[],[{"desc":"pkh(dpub/0/*)","rangestart":5,"rangeend":10}]],"id":1}`,
+			unmarshalled: &cdrjson.LoadTxFilterCmd{
+				Reload:    true,
+				Addresses: []string{},
+				OutPoints: []cdrjson.OutPoint{},
+				Descriptors: &[]cdrjson.Descriptor{{
+					Desc:       "pkh(dpub/0/*)",
+					RangeStart: cdrjson.Uint32(5),
+					RangeEnd:   cdrjson.Uint32(10),
+				}},
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
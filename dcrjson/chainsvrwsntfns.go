@@ -21,6 +21,10 @@ const (
 	// block chain is in the process of a reorganization.
 	ReorganizationNtfnMethod = "reorganization"
 
+	// ChainEventNtfnMethod is the method used for notifications from the
+	// chain server of events from its chain event log.
+	ChainEventNtfnMethod = "chainevent"
+
 	// TxAcceptedNtfnMethod is the method used for notifications from the
 	// chain server that a transaction has been accepted into the mempool.
 	TxAcceptedNtfnMethod = "txaccepted"
@@ -85,6 +89,19 @@ func NewReorganizationNtfn(oldHash string, oldHeight int32, newHash string,
 	}
 }
 
+// ChainEventNtfn defines the chainevent JSON-RPC notification.
+type ChainEventNtfn struct {
+	Event ChainEventResult `json:"event"`
+}
+
+// NewChainEventNtfn returns a new instance which can be used to issue a
+// chainevent JSON-RPC notification.
+func NewChainEventNtfn(event ChainEventResult) *ChainEventNtfn {
+	return &ChainEventNtfn{
+		Event: event,
+	}
+}
+
 // TxAcceptedNtfn defines the txaccepted JSON-RPC notification.
 type TxAcceptedNtfn struct {
 	TxID   string  `json:"txid"`
@@ -133,6 +150,7 @@ func init() {
 	MustRegisterCmd(BlockConnectedNtfnMethod, (*BlockConnectedNtfn)(nil), flags)
 	MustRegisterCmd(BlockDisconnectedNtfnMethod, (*BlockDisconnectedNtfn)(nil), flags)
 	MustRegisterCmd(ReorganizationNtfnMethod, (*ReorganizationNtfn)(nil), flags)
+	MustRegisterCmd(ChainEventNtfnMethod, (*ChainEventNtfn)(nil), flags)
 	MustRegisterCmd(TxAcceptedNtfnMethod, (*TxAcceptedNtfn)(nil), flags)
 	MustRegisterCmd(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags)
 	MustRegisterCmd(RelevantTxAcceptedNtfnMethod, (*RelevantTxAcceptedNtfn)(nil), flags)
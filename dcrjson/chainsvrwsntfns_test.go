@@ -83,6 +83,34 @@ func TestChainSvrWsNtfns(t *testing.T) {
 				Amount: 1.5,
 			},
 		},
+		{
+			name: "chainevent",
+			newNtfn: func() (interface{}, error) {
+				return cdrjson.NewCmd("chainevent", `{"sequence":5,"type":"tickets","hash":"123","height":100,"stakedifficulty":20000,"ticketsnew":["456"]}`)
+			},
+			staticNtfn: func() interface{} {
+				event := cdrjson.ChainEventResult{
+					Sequence:        5,
+					Type:            "tickets",
+					Hash:            "123",
+					Height:          100,
+					StakeDifficulty: 20000,
+					TicketsNew:      []string{"456"},
+				}
+				return cdrjson.NewChainEventNtfn(event)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"chainevent","params":[{"sequence":5,"type":"tickets","hash":"123","height":100,"stakedifficulty":20000,"ticketsnew":["456"]}],"id":null}`,
+			unmarshalled: &cdrjson.ChainEventNtfn{
+				Event: cdrjson.ChainEventResult{
+					Sequence:        5,
+					Type:            "tickets",
+					Hash:            "123",
+					Height:          100,
+					StakeDifficulty: 20000,
+					TicketsNew:      []string{"456"},
+				},
+			},
+		},
 		{
 			name: "txacceptedverbose",
 			newNtfn: func() (interface{}, error) {
@@ -21,3 +21,20 @@ type RescannedBlock struct {
 	Hash         string   `json:"hash"`
 	Transactions []string `json:"transactions"`
 }
+
+// ChainEventResult models the data of an event from the chain event log of the
+// chain server.  The old hash and height are only set for reorganization
+// events, in which case the hash and height are those of the new best block.
+// The stake difficulty and tickets are only set for ticket events.
+type ChainEventResult struct {
+	Sequence        uint64   `json:"sequence"`
+	Type            string   `json:"type"`
+	Hash            string   `json:"hash"`
+	Height          int64    `json:"height"`
+	OldHash         string   `json:"oldhash,omitempty"`
+	OldHeight       int64    `json:"oldheight,omitempty"`
+	StakeDifficulty int64    `json:"stakedifficulty,omitempty"`
+	TicketsSpent    []string `json:"ticketsspent,omitempty"`
+	TicketsMissed   []string `json:"ticketsmissed,omitempty"`
+	TicketsNew      []string `json:"ticketsnew,omitempty"`
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// TestFilterLarge ensures a maximum sized filter can be created.
+func TestFilterLarge(t *testing.T) {
+	f := NewFilter(100000000, 0, 0.01, wire.BloomUpdateNone)
+	if len(f.msgFilterLoad.Filter) > wire.MaxFilterLoadFilterSize {
+		t.Errorf("TestFilterLarge test failed: %d > %d",
+			len(f.msgFilterLoad.Filter), wire.MaxFilterLoadFilterSize)
+	}
+}
+
+// TestFilterLoad ensures loading and unloading of a filter pass.
+func TestFilterLoad(t *testing.T) {
+	merkle := wire.MsgFilterLoad{}
+
+	f := LoadFilter(&merkle)
+	if !f.IsLoaded() {
+		t.Errorf("TestFilterLoad IsLoaded test failed: want %v got %v",
+			true, !f.IsLoaded())
+		return
+	}
+	f.Unload()
+	if f.IsLoaded() {
+		t.Errorf("TestFilterLoad IsLoaded test failed: want %v got %v",
+			f.IsLoaded(), false)
+		return
+	}
+
+	// Ensure an empty filter never matches and does not panic.
+	f.Reload(&merkle)
+	merkle.HashFuncs = 10
+	f.Add([]byte{0x01})
+	if f.Matches([]byte{0x01}) {
+		t.Error("TestFilterLoad: empty filter unexpectedly matched")
+	}
+}
+
+// TestFilterInsert ensures inserting data into the filter causes that data
+// to be matched and the resulting serialized MsgFilterLoad is the expected
+// value.
+func TestFilterInsert(t *testing.T) {
+	var tests = []struct {
+		hex    string
+		insert bool
+	}{
+		{"99108ad8ed9bb6274d3980bab5a85c048f0950c8", true},
+		{"19108ad8ed9bb6274d3980bab5a85c048f0950c8", false},
+		{"b5a2c786d9ef4658287ced5914b37a1b4aa32eee", true},
+		{"b9300670b4c5366e95b2699e8b18bc75e5f729c5", true},
+	}
+
+	for _, tweak := range []struct {
+		tweak uint32
+		want  string
+	}{
+		{0, "03614e9b050000000000000001"},
+		{2147483649, "03ce4299050000000100008001"},
+	} {
+		f := NewFilter(3, tweak.tweak, 0.01, wire.BloomUpdateAll)
+
+		for i, test := range tests {
+			data, err := hex.DecodeString(test.hex)
+			if err != nil {
+				t.Errorf("TestFilterInsert DecodeString failed: %v\n", err)
+				return
+			}
+			if test.insert {
+				f.Add(data)
+			}
+
+			result := f.Matches(data)
+			if test.insert != result {
+				t.Errorf("TestFilterInsert Matches test #%d failure: "+
+					"got %v want %v\n", i, result, test.insert)
+				return
+			}
+		}
+
+		want, err := hex.DecodeString(tweak.want)
+		if err != nil {
+			t.Errorf("TestFilterInsert DecodeString failed: %v\n", err)
+			return
+		}
+
+		got := bytes.NewBuffer(nil)
+		err = f.MsgFilterLoad().BtcEncode(got, wire.ProtocolVersion)
+		if err != nil {
+			t.Errorf("TestFilterInsert BtcDecode failed: %v\n", err)
+			return
+		}
+
+		if !bytes.Equal(got.Bytes(), want) {
+			t.Errorf("TestFilterInsert failure: got %x want %x\n",
+				got.Bytes(), want)
+			return
+		}
+	}
+}
+
+// TestFilterMatchTxAndUpdate ensures transactions are matched by their hash,
+// the data pushes in their public key and signature scripts, and the outpoints
+// they spend, and that matching outputs are added to the filter according to
+// the update flags.
+func TestFilterMatchTxAndUpdate(t *testing.T) {
+	pubKey := bytes.Repeat([]byte{0x02}, 33)
+	p2pk := append(append([]byte{0x21}, pubKey...), 0xac) // <pubkey> OP_CHECKSIG
+	pkHash := bytes.Repeat([]byte{0x11}, 20)
+	p2pkh := append(append([]byte{0x76, 0xa9, 0x14}, pkHash...), 0x88, 0xac)
+
+	fundingTx := wire.NewMsgTx()
+	fundingTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01},
+		0, wire.TxTreeRegular), []byte{0x01, 0xaa}))
+	fundingTx.AddTxOut(wire.NewTxOut(100, p2pkh))
+	fundingTx.AddTxOut(wire.NewTxOut(200, p2pk))
+	funding := cdrutil.NewTx(fundingTx)
+
+	spendTx := wire.NewMsgTx()
+	spendTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(funding.Hash(), 1,
+		wire.TxTreeRegular), nil))
+	spendTx.AddTxOut(wire.NewTxOut(150, []byte{0x51}))
+	spend := cdrutil.NewTx(spendTx)
+
+	tests := []struct {
+		name       string
+		flags      wire.BloomUpdateType
+		data       []byte
+		matchSpend bool
+	}{
+		{"tx hash", wire.BloomUpdateAll, funding.Hash()[:], false},
+		{"signature script push", wire.BloomUpdateAll, []byte{0xaa}, false},
+		{"p2pk push, update all", wire.BloomUpdateAll, pubKey, true},
+		{"p2pk push, update p2pk only", wire.BloomUpdateP2PubkeyOnly,
+			pubKey, true},
+		{"p2pkh push, update p2pk only", wire.BloomUpdateP2PubkeyOnly,
+			pkHash, false},
+		{"p2pk push, update none", wire.BloomUpdateNone, pubKey, false},
+	}
+
+	for _, test := range tests {
+		f := NewFilter(10, 0, 0.000001, test.flags)
+		f.Add(test.data)
+		if !f.MatchTxAndUpdate(funding) {
+			t.Errorf("%s: funding transaction did not match", test.name)
+			continue
+		}
+		if got := f.MatchTxAndUpdate(spend); got != test.matchSpend {
+			t.Errorf("%s: spending transaction match - got %v, want %v",
+				test.name, got, test.matchSpend)
+		}
+	}
+
+	// Ensure an unrelated filter does not match.
+	f := NewFilter(10, 0, 0.000001, wire.BloomUpdateAll)
+	f.Add([]byte{0xde, 0xad})
+	if f.MatchTxAndUpdate(funding) {
+		t.Error("unrelated filter unexpectedly matched")
+	}
+}
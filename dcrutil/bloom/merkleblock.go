@@ -0,0 +1,30 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import (
+	"github.com/commanderu/cdrd/blockchain"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// NewMerkleBlock returns a new *wire.MsgMerkleBlock and an array of the matched
+// transaction hashes based on the passed block and filter.  Partial merkle
+// trees are created for both the regular and stake transaction trees.  The
+// matched hashes of the regular transaction tree precede those of the stake
+// transaction tree.
+func NewMerkleBlock(block *cdrutil.Block, filter *Filter) (*wire.MsgMerkleBlock, []*chainhash.Hash) {
+	var matchedHashes []*chainhash.Hash
+	msgMerkleBlock := blockchain.NewMerkleBlock(block, func(tx *cdrutil.Tx) bool {
+		if !filter.MatchTxAndUpdate(tx) {
+			return false
+		}
+		matchedHashes = append(matchedHashes, tx.Hash())
+		return true
+	})
+	return msgMerkleBlock, matchedHashes
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bloom
+
+import (
+	"testing"
+
+	"github.com/commanderu/cdrd/blockchain"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// partialTree houses the state used to extract the merkle root and matched
+// leaves from a serialized partial merkle tree.
+type partialTree struct {
+	numTx   uint32
+	hashes  []*chainhash.Hash
+	flags   []byte
+	bitIdx  uint32
+	hashIdx int
+	matched []chainhash.Hash
+}
+
+// width returns the number of nodes of the tree at the given height.
+func (p *partialTree) width(height uint32) uint32 {
+	return (p.numTx + (1 << height) - 1) >> height
+}
+
+// extract returns the hash of the node at the given height and position while
+// recording the matched leaves.
+func (p *partialTree) extract(height, pos uint32) *chainhash.Hash {
+	bit := (p.flags[p.bitIdx/8] >> (p.bitIdx % 8)) & 1
+	p.bitIdx++
+	if height == 0 || bit == 0 {
+		hash := p.hashes[p.hashIdx]
+		p.hashIdx++
+		if height == 0 && bit == 1 {
+			p.matched = append(p.matched, *hash)
+		}
+		return hash
+	}
+	left := p.extract(height-1, pos*2)
+	right := left
+	if pos*2+1 < p.width(height-1) {
+		right = p.extract(height-1, pos*2+1)
+	}
+	return blockchain.HashMerkleBranches(left, right)
+}
+
+// root extracts the merkle root and matched leaf hashes of the partial tree.
+func (p *partialTree) root(t *testing.T) *chainhash.Hash {
+	if p.numTx == 0 {
+		return &chainhash.Hash{}
+	}
+	height := uint32(0)
+	for p.width(height) > 1 {
+		height++
+	}
+	root := p.extract(height, 0)
+	if p.hashIdx != len(p.hashes) {
+		t.Fatalf("partial tree did not consume all hashes")
+	}
+	return root
+}
+
+// TestMerkleBlock ensures the partial merkle trees of a filtered block commit
+// to the merkle roots of the block and only flag the matched transactions.
+func TestMerkleBlock(t *testing.T) {
+	newTx := func(seed byte) *wire.MsgTx {
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{seed},
+			0, wire.TxTreeRegular), []byte{0x01, seed}))
+		tx.AddTxOut(wire.NewTxOut(int64(seed), []byte{0x51}))
+		return tx
+	}
+
+	for _, numTx := range []int{1, 2, 5, 7} {
+		msgBlock := wire.MsgBlock{}
+		for i := 0; i < numTx; i++ {
+			msgBlock.AddTransaction(newTx(byte(i)))
+		}
+		msgBlock.AddSTransaction(newTx(0xf0))
+		block := cdrutil.NewBlock(&msgBlock)
+
+		// Match the last regular transaction by a signature script
+		// push and the stake transaction by hash.
+		f := NewFilter(10, 0, 0.000001, wire.BloomUpdateNone)
+		f.Add([]byte{byte(numTx - 1)})
+		f.AddHash(block.STransactions()[0].Hash())
+
+		merkleBlock, matched := NewMerkleBlock(block, f)
+		if len(matched) != 2 ||
+			*matched[0] != *block.Transactions()[numTx-1].Hash() ||
+			*matched[1] != *block.STransactions()[0].Hash() {
+			t.Fatalf("numTx %d: unexpected matched hashes %v", numTx,
+				matched)
+		}
+
+		regular := partialTree{numTx: merkleBlock.Transactions,
+			hashes: merkleBlock.Hashes, flags: merkleBlock.Flags}
+		store := blockchain.BuildMerkleTreeStore(block.Transactions())
+		if root := regular.root(t); *root != *store[len(store)-1] {
+			t.Fatalf("numTx %d: regular root mismatch - got %v, want %v",
+				numTx, root, store[len(store)-1])
+		}
+		wantLeaf := block.Transactions()[numTx-1].MsgTx().TxHashFull()
+		if len(regular.matched) != 1 || regular.matched[0] != wantLeaf {
+			t.Fatalf("numTx %d: unexpected regular leaves %v", numTx,
+				regular.matched)
+		}
+
+		stake := partialTree{numTx: merkleBlock.STransactions,
+			hashes: merkleBlock.SHashes, flags: merkleBlock.SFlags}
+		sstore := blockchain.BuildMerkleTreeStore(block.STransactions())
+		if root := stake.root(t); *root != *sstore[len(sstore)-1] {
+			t.Fatalf("numTx %d: stake root mismatch - got %v, want %v",
+				numTx, root, sstore[len(sstore)-1])
+		}
+	}
+}
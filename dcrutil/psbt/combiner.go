@@ -0,0 +1,124 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+)
+
+// mergeUnknowns adds the unknown key and value pairs of src which are not
+// already in dst.
+func mergeUnknowns(dst, src []*Unknown) []*Unknown {
+	for _, u := range src {
+		var found bool
+		for _, existing := range dst {
+			if bytes.Equal(existing.Key, u.Key) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, u)
+		}
+	}
+	return dst
+}
+
+// mergeDerivations adds the derivations of src for public keys which are not
+// already in dst.
+func mergeDerivations(dst, src []*Derivation) []*Derivation {
+	for _, d := range src {
+		var found bool
+		for _, existing := range dst {
+			if bytes.Equal(existing.PubKey, d.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, d)
+		}
+	}
+	return dst
+}
+
+// merge adds the information in src which is missing from the input.  A
+// finalized input takes precedence over the partial signatures of the other.
+func (pi *PInput) merge(src *PInput) {
+	if pi.FinalScriptSig == nil && src.FinalScriptSig != nil {
+		*pi = *src
+		return
+	}
+	if pi.PrevOut == nil {
+		pi.PrevOut = src.PrevOut
+	}
+	if pi.RedeemScript == nil {
+		pi.RedeemScript = src.RedeemScript
+	}
+	if pi.SigHashType == 0 {
+		pi.SigHashType = src.SigHashType
+	}
+	if pi.FinalScriptSig == nil {
+		for _, ps := range src.PartialSigs {
+			if pi.findPartialSig(ps.PubKey) == nil {
+				pi.PartialSigs = append(pi.PartialSigs, ps)
+			}
+		}
+		pi.Derivations = mergeDerivations(pi.Derivations, src.Derivations)
+	}
+	pi.Unknowns = mergeUnknowns(pi.Unknowns, src.Unknowns)
+}
+
+// merge adds the information in src which is missing from the output.
+func (po *POutput) merge(src *POutput) {
+	if po.RedeemScript == nil {
+		po.RedeemScript = src.RedeemScript
+	}
+	po.Derivations = mergeDerivations(po.Derivations, src.Derivations)
+	po.Unknowns = mergeUnknowns(po.Unknowns, src.Unknowns)
+}
+
+// Combine merges the information of the passed packets, which must all be for
+// the same unsigned transaction, into a new packet.  This allows the packets
+// signed independently by multiple parties to be brought back together.
+func Combine(packets ...*Packet) (*Packet, error) {
+	if len(packets) == 0 {
+		return nil, ErrMissingUnsignedTx
+	}
+
+	// Start with a deep copy of the first packet so that none of the
+	// passed packets are modified.
+	first, err := packets[0].Bytes()
+	if err != nil {
+		return nil, err
+	}
+	combined, err := FromBytes(first)
+	if err != nil {
+		return nil, err
+	}
+	var txBuf bytes.Buffer
+	if err := combined.UnsignedTx.Serialize(&txBuf); err != nil {
+		return nil, err
+	}
+
+	for _, p := range packets[1:] {
+		var otherTxBuf bytes.Buffer
+		if err := p.UnsignedTx.Serialize(&otherTxBuf); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(txBuf.Bytes(), otherTxBuf.Bytes()) {
+			return nil, ErrMismatchedTx
+		}
+
+		for i := range combined.Inputs {
+			combined.Inputs[i].merge(&p.Inputs[i])
+		}
+		for i := range combined.Outputs {
+			combined.Outputs[i].merge(&p.Outputs[i])
+		}
+		combined.Unknowns = mergeUnknowns(combined.Unknowns, p.Unknowns)
+	}
+	return combined, nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package psbt provides a container for partially signed transactions.
+
+A partially signed transaction packet houses an unsigned transaction along with
+the information each party needs in order to sign it without access to the
+chain or a wallet, such as the previous outputs spent by the inputs, redeem
+scripts, and the HD derivation paths of the keys involved.  Packets are passed
+between parties, typically in their base64 encoded form, to collect the
+signatures for an input before the final signature scripts are built and the
+signed transaction is extracted.
+
+Roles
+
+The API is organized around the roles of the parties involved:
+
+	Creator    New creates a packet for an unsigned transaction
+	Updater    AddInPrevOut, AddInRedeemScript, AddInSigHashType,
+	           AddInDerivation, AddOutRedeemScript, and AddOutDerivation
+	           add the information needed to sign
+	Signer     Sign and AddPartialSig add verified signatures
+	Combiner   Combine merges packets signed by different parties
+	Finalizer  FinalizeInput and Finalize build and verify the signature
+	           scripts
+	Extractor  Extract returns the fully signed transaction
+
+Signature Types
+
+Each partial signature records its signature type which is one of
+chainec.ECTypeSecp256k1, chainec.ECTypeEdwards, or chainec.ECTypeSecSchnorr.
+Inputs which spend pay-to-pubkey and pay-to-pubkey-hash outputs of any
+signature type, and pay-to-script-hash multisig outputs, may be signed and
+finalized.
+
+Stake Transactions
+
+Ticket purchases, votes, and revocations are supported.  Inputs which spend the
+stake tagged outputs of tickets, votes, and revocations are signed and
+finalized like the regular pay-to-pubkey-hash and pay-to-script-hash outputs
+they wrap, while the stakebase input of a vote, which does not spend a previous
+output, keeps the signature script it was created with and requires no
+signatures.
+
+Serialization
+
+The binary serialization is modeled after BIP0174 and consists of magic bytes
+followed by a global map which houses the unsigned transaction, one map per
+input, and one map per output.  Each map is a series of key and value pairs
+terminated by a zero byte.  Key types which are not known to this package are
+preserved so packets created by newer software may be passed through.
+*/
+package psbt
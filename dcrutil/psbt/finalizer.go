@@ -0,0 +1,185 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/commanderu/cdrd/txscript"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// verifyFlags are the script flags used to verify finalized inputs.  They
+// match the base flags required for a transaction to be considered standard.
+const verifyFlags = txscript.ScriptBip16 |
+	txscript.ScriptVerifyDERSignatures |
+	txscript.ScriptVerifyStrictEncoding |
+	txscript.ScriptVerifyMinimalData |
+	txscript.ScriptDiscourageUpgradableNops |
+	txscript.ScriptVerifyCleanStack |
+	txscript.ScriptVerifyCheckLockTimeVerify |
+	txscript.ScriptVerifyCheckSequenceVerify |
+	txscript.ScriptVerifyLowS
+
+// findPartialSig returns the signature of the input for the passed public key
+// or nil when there is none.
+func (pi *PInput) findPartialSig(pubKey []byte) *PartialSig {
+	for _, ps := range pi.PartialSigs {
+		if bytes.Equal(ps.PubKey, pubKey) {
+			return ps
+		}
+	}
+	return nil
+}
+
+// signatureScript builds the signature script for the input from its partial
+// signatures.
+func (pi *PInput) signatureScript(info *spendInfo) ([]byte, error) {
+	pushes, err := txscript.PushedData(info.script)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := txscript.NewScriptBuilder()
+	switch info.class {
+	case txscript.PubKeyTy, txscript.PubkeyAltTy:
+		ps := pi.findPartialSig(pushes[0])
+		if ps == nil {
+			return nil, ErrIncomplete
+		}
+		builder.AddData(ps.Signature)
+
+	case txscript.PubKeyHashTy, txscript.PubkeyHashAltTy:
+		var found bool
+		for _, ps := range pi.PartialSigs {
+			if info.requiresKey(ps.PubKey, ps.SigType) {
+				builder.AddData(ps.Signature).AddData(ps.PubKey)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrIncomplete
+		}
+
+	case txscript.MultiSigTy:
+		// The signatures must be in the same order as the public keys
+		// in the script.  Note that, unlike some other chains,
+		// OP_CHECKMULTISIG does not consume an extra dummy element.
+		_, numSigs, err := txscript.CalcMultiSigStats(info.script)
+		if err != nil {
+			return nil, err
+		}
+		var added int
+		for _, pubKey := range pushes {
+			if added == numSigs {
+				break
+			}
+			if ps := pi.findPartialSig(pubKey); ps != nil {
+				builder.AddData(ps.Signature)
+				added++
+			}
+		}
+		if added < numSigs {
+			return nil, ErrIncomplete
+		}
+
+	default:
+		return nil, ErrUnsupportedScript
+	}
+
+	if info.redeemScript != nil {
+		builder.AddData(info.redeemScript)
+	}
+	return builder.Script()
+}
+
+// FinalizeInput builds the final signature script of the input at the passed
+// index from its partial signatures and verifies it with the script engine.
+// The stakebase input of a vote is finalized with its existing signature
+// script.  The information which is only needed for signing is removed from
+// the input once it is finalized.
+//
+// ErrIncomplete is returned when the input does not have enough signatures.
+func (p *Packet) FinalizeInput(i int) error {
+	pi, err := p.input(i)
+	if err != nil {
+		return err
+	}
+
+	var sigScript []byte
+	if isVoteStakeBase(p.UnsignedTx, i) {
+		sigScript = p.UnsignedTx.TxIn[i].SignatureScript
+	} else {
+		info, err := p.spendInfo(i)
+		if err != nil {
+			return err
+		}
+		sigScript, err = pi.signatureScript(info)
+		if err != nil {
+			return err
+		}
+
+		tx := p.UnsignedTx.Copy()
+		tx.TxIn[i].SignatureScript = sigScript
+		vm, err := txscript.NewEngine(pi.PrevOut.PkScript, tx, i,
+			verifyFlags, pi.PrevOut.Version, nil)
+		if err != nil {
+			return err
+		}
+		if err := vm.Execute(); err != nil {
+			return fmt.Errorf("finalized input %d does not verify: %v",
+				i, err)
+		}
+	}
+
+	pi.FinalScriptSig = append([]byte{}, sigScript...)
+	pi.RedeemScript = nil
+	pi.Derivations = nil
+	pi.PartialSigs = nil
+	pi.SigHashType = 0
+	return nil
+}
+
+// Finalize finalizes all inputs which have not been finalized yet.  It
+// attempts to finalize every input and returns the first error encountered,
+// so the inputs which have enough signatures are finalized even when others do
+// not.
+func (p *Packet) Finalize() error {
+	var firstErr error
+	for i := range p.Inputs {
+		if p.Inputs[i].FinalScriptSig != nil {
+			continue
+		}
+		if err := p.FinalizeInput(i); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// IsComplete returns whether or not all inputs have been finalized.
+func (p *Packet) IsComplete() bool {
+	for i := range p.Inputs {
+		if p.Inputs[i].FinalScriptSig == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Extract returns the fully signed transaction once all inputs have been
+// finalized.  ErrIncomplete is returned otherwise.
+func (p *Packet) Extract() (*wire.MsgTx, error) {
+	if !p.IsComplete() {
+		return nil, ErrIncomplete
+	}
+	tx := p.UnsignedTx.Copy()
+	for i, txIn := range tx.TxIn {
+		txIn.SignatureScript = p.Inputs[i].FinalScriptSig
+	}
+	return tx, nil
+}
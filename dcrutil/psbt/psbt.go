@@ -0,0 +1,715 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/commanderu/cdrd/blockchain/stake"
+	"github.com/commanderu/cdrd/chaincfg/chainec"
+	"github.com/commanderu/cdrd/txscript"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// psbtMagic is the magic bytes which prefix every serialized packet.
+var psbtMagic = [5]byte{0x70, 0x73, 0x62, 0x74, 0xff} // "psbt" + 0xff
+
+const (
+	// maxValueLen is the maximum length of any key or value in a serialized
+	// packet.  It is large enough to hold the largest possible transaction.
+	maxValueLen = wire.MaxBlockPayload
+
+	// maxDerivationPathLen is the maximum number of child indexes in a
+	// serialized derivation path.
+	maxDerivationPathLen = 255
+)
+
+// The key types of the global map.
+const (
+	globalUnsignedTxType byte = 0x00
+)
+
+// The key types of the per-input maps.
+const (
+	inputPrevOutType        byte = 0x00
+	inputPartialSigType     byte = 0x02
+	inputSigHashType        byte = 0x03
+	inputRedeemScriptType   byte = 0x04
+	inputDerivationType     byte = 0x06
+	inputFinalScriptSigType byte = 0x07
+)
+
+// The key types of the per-output maps.
+const (
+	outputRedeemScriptType byte = 0x00
+	outputDerivationType   byte = 0x02
+)
+
+var (
+	// ErrInvalidMagic is returned when the serialized packet does not start
+	// with the expected magic bytes.
+	ErrInvalidMagic = errors.New("invalid packet magic")
+
+	// ErrDuplicateKey is returned when a serialized map contains the same
+	// key more than once.
+	ErrDuplicateKey = errors.New("duplicate key in packet map")
+
+	// ErrMissingUnsignedTx is returned when the serialized packet does not
+	// include the unsigned transaction.
+	ErrMissingUnsignedTx = errors.New("packet does not include the " +
+		"unsigned transaction")
+
+	// ErrInvalidSigScript is returned when the unsigned transaction has an
+	// input with a signature script other than the stakebase of a vote.
+	ErrInvalidSigScript = errors.New("unsigned transaction has a " +
+		"non-empty signature script")
+
+	// ErrInvalidIndex is returned when an input or output index is out of
+	// range.
+	ErrInvalidIndex = errors.New("input or output index out of range")
+
+	// ErrMissingPrevOut is returned when an input must be signed or
+	// finalized before its previous output has been provided.
+	ErrMissingPrevOut = errors.New("previous output of input is unknown")
+
+	// ErrMissingRedeemScript is returned when an input which spends a
+	// pay-to-script-hash output must be signed or finalized before its
+	// redeem script has been provided.
+	ErrMissingRedeemScript = errors.New("redeem script of input is unknown")
+
+	// ErrRedeemScriptMismatch is returned when a redeem script does not
+	// hash to the script hash of the output it is provided for.
+	ErrRedeemScriptMismatch = errors.New("redeem script does not match " +
+		"the script hash")
+
+	// ErrUnsupportedScript is returned when an input spends a script this
+	// package is unable to sign or finalize.
+	ErrUnsupportedScript = errors.New("unsupported script type")
+
+	// ErrKeyNotInScript is returned when a key or signature is provided
+	// for an input whose script does not involve the public key.
+	ErrKeyNotInScript = errors.New("public key is not required by the " +
+		"script")
+
+	// ErrInvalidSignature is returned when a partial signature is not a
+	// valid signature of the input.
+	ErrInvalidSignature = errors.New("invalid partial signature")
+
+	// ErrInputFinalized is returned when an input is modified after it has
+	// been finalized.
+	ErrInputFinalized = errors.New("input is already finalized")
+
+	// ErrIncomplete is returned when an input does not have enough
+	// signatures to be finalized or a transaction is extracted before all
+	// of its inputs are finalized.
+	ErrIncomplete = errors.New("not enough signatures")
+
+	// ErrMismatchedTx is returned when packets for different unsigned
+	// transactions are combined.
+	ErrMismatchedTx = errors.New("packets have different unsigned " +
+		"transactions")
+)
+
+// Derivation houses the HD derivation path of a public key which is relevant
+// to an input or output.
+type Derivation struct {
+	// PubKey is the serialized public key.
+	PubKey []byte
+
+	// MasterKeyFingerprint is the fingerprint of the master key the path
+	// is relative to.
+	MasterKeyFingerprint uint32
+
+	// Path is the child indexes from the master key to the public key.
+	// Hardened indexes include hdkeychain.HardenedKeyStart.
+	Path []uint32
+}
+
+// PartialSig houses a signature for an input along with the public key it is
+// valid for and the type of the signature.
+type PartialSig struct {
+	// PubKey is the serialized public key.
+	PubKey []byte
+
+	// Signature is the serialized signature including the signature hash
+	// type.
+	Signature []byte
+
+	// SigType is the signature type of the signature and public key which
+	// is one of chainec.ECTypeSecp256k1, chainec.ECTypeEdwards, or
+	// chainec.ECTypeSecSchnorr.
+	SigType int
+}
+
+// Unknown houses a key and value pair with a key type that is not known to
+// this package so it can be preserved when the packet is reserialized.
+type Unknown struct {
+	Key   []byte
+	Value []byte
+}
+
+// PInput houses the information needed to sign and finalize an input of the
+// unsigned transaction.
+type PInput struct {
+	// PrevOut is the previous output the input spends.  It is nil when it
+	// has not been provided yet and is never required for the stakebase
+	// input of a vote.
+	PrevOut *wire.TxOut
+
+	// RedeemScript is the redeem script of a pay-to-script-hash previous
+	// output.
+	RedeemScript []byte
+
+	// Derivations are the derivation paths of the keys relevant to the
+	// input.
+	Derivations []*Derivation
+
+	// PartialSigs are the signatures collected for the input so far.
+	PartialSigs []*PartialSig
+
+	// SigHashType is the signature hash type signatures must use.  It is
+	// zero when it has not been specified in which case txscript.SigHashAll
+	// is used.
+	SigHashType txscript.SigHashType
+
+	// FinalScriptSig is the signature script of the input once it has been
+	// finalized.
+	FinalScriptSig []byte
+
+	// Unknowns are the key and value pairs with unknown key types.
+	Unknowns []*Unknown
+}
+
+// POutput houses the information about an output of the unsigned transaction
+// which is useful for verifying it belongs to the signer.
+type POutput struct {
+	// RedeemScript is the redeem script of a pay-to-script-hash output.
+	RedeemScript []byte
+
+	// Derivations are the derivation paths of the keys relevant to the
+	// output.
+	Derivations []*Derivation
+
+	// Unknowns are the key and value pairs with unknown key types.
+	Unknowns []*Unknown
+}
+
+// Packet is a partially signed transaction.  It houses the unsigned
+// transaction along with the per-input and per-output information the various
+// parties need in order to sign and finalize it.
+type Packet struct {
+	// UnsignedTx is the transaction being signed.  The signature scripts of
+	// its inputs are empty with the exception of the stakebase input of a
+	// vote.
+	UnsignedTx *wire.MsgTx
+
+	// Inputs houses the information about each input of the unsigned
+	// transaction in the same order.
+	Inputs []PInput
+
+	// Outputs houses the information about each output of the unsigned
+	// transaction in the same order.
+	Outputs []POutput
+
+	// Unknowns are the global key and value pairs with unknown key types.
+	Unknowns []*Unknown
+}
+
+// isVoteStakeBase returns whether or not the passed input index of the
+// transaction is the stakebase input of a vote which does not spend a
+// previous output and therefore carries its signature script from creation.
+func isVoteStakeBase(tx *wire.MsgTx, idx int) bool {
+	return idx == 0 && stake.IsSSGen(tx)
+}
+
+// checkUnsignedTx ensures the signature scripts of the inputs of the passed
+// transaction are empty with the exception of the stakebase input of a vote.
+func checkUnsignedTx(tx *wire.MsgTx) error {
+	for i, txIn := range tx.TxIn {
+		if len(txIn.SignatureScript) != 0 && !isVoteStakeBase(tx, i) {
+			return ErrInvalidSigScript
+		}
+	}
+	return nil
+}
+
+// New returns a new packet for the passed unsigned transaction which may be a
+// regular transaction, ticket purchase, vote, or revocation.  The signature
+// scripts of all inputs must be empty other than the stakebase input of a vote.
+// The transaction is copied so the caller may modify it afterwards.
+func New(tx *wire.MsgTx) (*Packet, error) {
+	if err := checkUnsignedTx(tx); err != nil {
+		return nil, err
+	}
+	return &Packet{
+		UnsignedTx: tx.Copy(),
+		Inputs:     make([]PInput, len(tx.TxIn)),
+		Outputs:    make([]POutput, len(tx.TxOut)),
+	}, nil
+}
+
+// TxType returns the stake transaction type of the unsigned transaction.
+func (p *Packet) TxType() stake.TxType {
+	return stake.DetermineTxType(p.UnsignedTx)
+}
+
+// writeKeyValue writes a single key and value pair where the key is the key
+// type followed by the key data.
+func writeKeyValue(w io.Writer, keyType byte, keyData, value []byte) error {
+	key := make([]byte, 0, 1+len(keyData))
+	key = append(key, keyType)
+	key = append(key, keyData...)
+	if err := wire.WriteVarBytes(w, 0, key); err != nil {
+		return err
+	}
+	return wire.WriteVarBytes(w, 0, value)
+}
+
+// writeSeparator writes the separator which terminates a map.
+func writeSeparator(w io.Writer) error {
+	_, err := w.Write([]byte{0x00})
+	return err
+}
+
+// writeUnknowns writes the passed unknown key and value pairs.
+func writeUnknowns(w io.Writer, unknowns []*Unknown) error {
+	for _, u := range unknowns {
+		if err := wire.WriteVarBytes(w, 0, u.Key); err != nil {
+			return err
+		}
+		if err := wire.WriteVarBytes(w, 0, u.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serializeDerivation returns the serialized value of a derivation which is
+// the master key fingerprint followed by each child index.
+func serializeDerivation(d *Derivation) []byte {
+	value := make([]byte, 4+4*len(d.Path))
+	binary.LittleEndian.PutUint32(value, d.MasterKeyFingerprint)
+	for i, index := range d.Path {
+		binary.LittleEndian.PutUint32(value[4+4*i:], index)
+	}
+	return value
+}
+
+// serializePrevOut returns the serialized value of a previous output which is
+// the amount, the script version, and the script.
+func serializePrevOut(txOut *wire.TxOut) []byte {
+	var buf bytes.Buffer
+	var scratch [10]byte
+	binary.LittleEndian.PutUint64(scratch[:8], uint64(txOut.Value))
+	binary.LittleEndian.PutUint16(scratch[8:], txOut.Version)
+	buf.Write(scratch[:])
+	wire.WriteVarBytes(&buf, 0, txOut.PkScript)
+	return buf.Bytes()
+}
+
+// Serialize writes the binary serialization of the packet to w.
+func (p *Packet) Serialize(w io.Writer) error {
+	if _, err := w.Write(psbtMagic[:]); err != nil {
+		return err
+	}
+
+	var txBuf bytes.Buffer
+	txBuf.Grow(p.UnsignedTx.SerializeSize())
+	if err := p.UnsignedTx.Serialize(&txBuf); err != nil {
+		return err
+	}
+	err := writeKeyValue(w, globalUnsignedTxType, nil, txBuf.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := writeUnknowns(w, p.Unknowns); err != nil {
+		return err
+	}
+	if err := writeSeparator(w); err != nil {
+		return err
+	}
+
+	for i := range p.Inputs {
+		if err := p.Inputs[i].serialize(w); err != nil {
+			return err
+		}
+	}
+	for i := range p.Outputs {
+		if err := p.Outputs[i].serialize(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serialize writes the map of the input to w.
+func (pi *PInput) serialize(w io.Writer) error {
+	if pi.PrevOut != nil {
+		err := writeKeyValue(w, inputPrevOutType, nil,
+			serializePrevOut(pi.PrevOut))
+		if err != nil {
+			return err
+		}
+	}
+	for _, ps := range pi.PartialSigs {
+		value := make([]byte, 0, 1+len(ps.Signature))
+		value = append(value, byte(ps.SigType))
+		value = append(value, ps.Signature...)
+		err := writeKeyValue(w, inputPartialSigType, ps.PubKey, value)
+		if err != nil {
+			return err
+		}
+	}
+	if pi.SigHashType != 0 {
+		var value [4]byte
+		binary.LittleEndian.PutUint32(value[:], uint32(pi.SigHashType))
+		err := writeKeyValue(w, inputSigHashType, nil, value[:])
+		if err != nil {
+			return err
+		}
+	}
+	if pi.RedeemScript != nil {
+		err := writeKeyValue(w, inputRedeemScriptType, nil, pi.RedeemScript)
+		if err != nil {
+			return err
+		}
+	}
+	for _, d := range pi.Derivations {
+		err := writeKeyValue(w, inputDerivationType, d.PubKey,
+			serializeDerivation(d))
+		if err != nil {
+			return err
+		}
+	}
+	if pi.FinalScriptSig != nil {
+		err := writeKeyValue(w, inputFinalScriptSigType, nil,
+			pi.FinalScriptSig)
+		if err != nil {
+			return err
+		}
+	}
+	if err := writeUnknowns(w, pi.Unknowns); err != nil {
+		return err
+	}
+	return writeSeparator(w)
+}
+
+// serialize writes the map of the output to w.
+func (po *POutput) serialize(w io.Writer) error {
+	if po.RedeemScript != nil {
+		err := writeKeyValue(w, outputRedeemScriptType, nil,
+			po.RedeemScript)
+		if err != nil {
+			return err
+		}
+	}
+	for _, d := range po.Derivations {
+		err := writeKeyValue(w, outputDerivationType, d.PubKey,
+			serializeDerivation(d))
+		if err != nil {
+			return err
+		}
+	}
+	if err := writeUnknowns(w, po.Unknowns); err != nil {
+		return err
+	}
+	return writeSeparator(w)
+}
+
+// Bytes returns the binary serialization of the packet.
+func (p *Packet) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := p.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// B64Encode returns the base64 encoding of the binary serialization of the
+// packet which is the form typically exchanged between parties.
+func (p *Packet) B64Encode() (string, error) {
+	b, err := p.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// readMap reads the key and value pairs of a single map up to and including
+// the separator and invokes the passed function for each pair.  The key type
+// is provided separately from the remaining key data.  It ensures there are no
+// duplicate keys.
+func readMap(r io.Reader, f func(keyType byte, keyData, value []byte) error) error {
+	seen := make(map[string]struct{})
+	for {
+		key, err := wire.ReadVarBytes(r, 0, maxValueLen, "key")
+		if err != nil {
+			return err
+		}
+		if len(key) == 0 {
+			return nil
+		}
+		if _, ok := seen[string(key)]; ok {
+			return ErrDuplicateKey
+		}
+		seen[string(key)] = struct{}{}
+
+		value, err := wire.ReadVarBytes(r, 0, maxValueLen, "value")
+		if err != nil {
+			return err
+		}
+		if err := f(key[0], key[1:], value); err != nil {
+			return err
+		}
+	}
+}
+
+// requireNoKeyData returns an error when the passed key data, which must be
+// empty for the key type, is not.
+func requireNoKeyData(keyType byte, keyData []byte) error {
+	if len(keyData) != 0 {
+		return fmt.Errorf("unexpected key data for key type %#02x",
+			keyType)
+	}
+	return nil
+}
+
+// parseDerivation parses a derivation from its serialized key data and value.
+func parseDerivation(pubKey, value []byte) (*Derivation, error) {
+	if len(value) < 4 || len(value)%4 != 0 ||
+		len(value)/4-1 > maxDerivationPathLen {
+		return nil, fmt.Errorf("invalid derivation path length %d",
+			len(value))
+	}
+	path := make([]uint32, len(value)/4-1)
+	for i := range path {
+		path[i] = binary.LittleEndian.Uint32(value[4+4*i:])
+	}
+	return &Derivation{
+		PubKey:               pubKey,
+		MasterKeyFingerprint: binary.LittleEndian.Uint32(value),
+		Path:                 path,
+	}, nil
+}
+
+// parsePrevOut parses a previous output from its serialized value.
+func parsePrevOut(value []byte) (*wire.TxOut, error) {
+	if len(value) < 10 {
+		return nil, fmt.Errorf("invalid previous output length %d",
+			len(value))
+	}
+	r := bytes.NewReader(value[10:])
+	pkScript, err := wire.ReadVarBytes(r, 0, maxValueLen, "pkscript")
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after previous output",
+			r.Len())
+	}
+	return &wire.TxOut{
+		Value:    int64(binary.LittleEndian.Uint64(value)),
+		Version:  binary.LittleEndian.Uint16(value[8:]),
+		PkScript: pkScript,
+	}, nil
+}
+
+// parseInput reads the map of an input from r.
+func parseInput(r io.Reader) (*PInput, error) {
+	var pi PInput
+	err := readMap(r, func(keyType byte, keyData, value []byte) error {
+		switch keyType {
+		case inputPrevOutType:
+			if err := requireNoKeyData(keyType, keyData); err != nil {
+				return err
+			}
+			prevOut, err := parsePrevOut(value)
+			if err != nil {
+				return err
+			}
+			pi.PrevOut = prevOut
+
+		case inputPartialSigType:
+			if len(value) < 1 {
+				return errors.New("empty partial signature")
+			}
+			pi.PartialSigs = append(pi.PartialSigs, &PartialSig{
+				PubKey:    keyData,
+				Signature: value[1:],
+				SigType:   int(value[0]),
+			})
+
+		case inputSigHashType:
+			if err := requireNoKeyData(keyType, keyData); err != nil {
+				return err
+			}
+			if len(value) != 4 {
+				return fmt.Errorf("invalid signature hash type "+
+					"length %d", len(value))
+			}
+			pi.SigHashType = txscript.SigHashType(
+				binary.LittleEndian.Uint32(value))
+
+		case inputRedeemScriptType:
+			if err := requireNoKeyData(keyType, keyData); err != nil {
+				return err
+			}
+			pi.RedeemScript = value
+
+		case inputDerivationType:
+			d, err := parseDerivation(keyData, value)
+			if err != nil {
+				return err
+			}
+			pi.Derivations = append(pi.Derivations, d)
+
+		case inputFinalScriptSigType:
+			if err := requireNoKeyData(keyType, keyData); err != nil {
+				return err
+			}
+			pi.FinalScriptSig = value
+
+		default:
+			key := append([]byte{keyType}, keyData...)
+			pi.Unknowns = append(pi.Unknowns, &Unknown{key, value})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pi, nil
+}
+
+// parseOutput reads the map of an output from r.
+func parseOutput(r io.Reader) (*POutput, error) {
+	var po POutput
+	err := readMap(r, func(keyType byte, keyData, value []byte) error {
+		switch keyType {
+		case outputRedeemScriptType:
+			if err := requireNoKeyData(keyType, keyData); err != nil {
+				return err
+			}
+			po.RedeemScript = value
+
+		case outputDerivationType:
+			d, err := parseDerivation(keyData, value)
+			if err != nil {
+				return err
+			}
+			po.Derivations = append(po.Derivations, d)
+
+		default:
+			key := append([]byte{keyType}, keyData...)
+			po.Unknowns = append(po.Unknowns, &Unknown{key, value})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &po, nil
+}
+
+// Parse reads a packet from its binary serialization in r.
+func Parse(r io.Reader) (*Packet, error) {
+	var magic [len(psbtMagic)]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic != psbtMagic {
+		return nil, ErrInvalidMagic
+	}
+
+	var p Packet
+	err := readMap(r, func(keyType byte, keyData, value []byte) error {
+		switch keyType {
+		case globalUnsignedTxType:
+			if err := requireNoKeyData(keyType, keyData); err != nil {
+				return err
+			}
+			var tx wire.MsgTx
+			if err := tx.FromBytes(value); err != nil {
+				return err
+			}
+			p.UnsignedTx = &tx
+
+		default:
+			key := append([]byte{keyType}, keyData...)
+			p.Unknowns = append(p.Unknowns, &Unknown{key, value})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p.UnsignedTx == nil {
+		return nil, ErrMissingUnsignedTx
+	}
+	if err := checkUnsignedTx(p.UnsignedTx); err != nil {
+		return nil, err
+	}
+
+	p.Inputs = make([]PInput, len(p.UnsignedTx.TxIn))
+	for i := range p.Inputs {
+		pi, err := parseInput(r)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %v", i, err)
+		}
+		p.Inputs[i] = *pi
+	}
+	p.Outputs = make([]POutput, len(p.UnsignedTx.TxOut))
+	for i := range p.Outputs {
+		po, err := parseOutput(r)
+		if err != nil {
+			return nil, fmt.Errorf("output %d: %v", i, err)
+		}
+		p.Outputs[i] = *po
+	}
+	return &p, nil
+}
+
+// FromBytes parses a packet from its binary serialization and ensures there
+// is no trailing data.
+func FromBytes(b []byte) (*Packet, error) {
+	r := bytes.NewReader(b)
+	p, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after packet", r.Len())
+	}
+	return p, nil
+}
+
+// B64Decode parses a packet from the base64 encoding of its binary
+// serialization.
+func B64Decode(s string) (*Packet, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(b)
+}
+
+// dsaForSigType returns the digital signature algorithm for the passed
+// signature type.
+func dsaForSigType(sigType int) (chainec.DSA, error) {
+	switch sigType {
+	case chainec.ECTypeSecp256k1:
+		return chainec.Secp256k1, nil
+	case chainec.ECTypeEdwards:
+		return chainec.Edwards, nil
+	case chainec.ECTypeSecSchnorr:
+		return chainec.SecSchnorr, nil
+	}
+	return nil, fmt.Errorf("unknown signature type %d", sigType)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg/chainec"
+	"github.com/commanderu/cdrd/txscript"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// TestSerializeRoundTrip ensures a packet with all fields populated survives
+// serialization and parsing, including key types unknown to this package.
+func TestSerializeRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(newSpendTx(2))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	p.Inputs[0] = PInput{
+		PrevOut: &wire.TxOut{
+			Value:    12345,
+			Version:  1,
+			PkScript: []byte{txscript.OP_TRUE},
+		},
+		RedeemScript: []byte{txscript.OP_DROP},
+		Derivations: []*Derivation{{
+			PubKey:               []byte{0x02, 0x01},
+			MasterKeyFingerprint: 0xdeadbeef,
+			Path:                 []uint32{0x80000000, 1, 2},
+		}},
+		PartialSigs: []*PartialSig{{
+			PubKey:    []byte{0x02, 0x01},
+			Signature: []byte{0x30, 0x01},
+			SigType:   chainec.ECTypeSecSchnorr,
+		}},
+		SigHashType: txscript.SigHashSingle,
+		Unknowns:    []*Unknown{{Key: []byte{0xfc, 0x01}, Value: []byte{0x02}}},
+	}
+	p.Inputs[1] = PInput{FinalScriptSig: []byte{}}
+	p.Outputs[0] = POutput{
+		RedeemScript: []byte{txscript.OP_TRUE},
+		Derivations: []*Derivation{{
+			PubKey: []byte{0x03},
+			Path:   []uint32{},
+		}},
+	}
+	p.Unknowns = []*Unknown{{Key: []byte{0xfc}, Value: []byte{}}}
+
+	b64, err := p.B64Encode()
+	if err != nil {
+		t.Fatalf("B64Encode: %v", err)
+	}
+	decoded, err := B64Decode(b64)
+	if err != nil {
+		t.Fatalf("B64Decode: %v", err)
+	}
+	if decoded.UnsignedTx.TxHash() != p.UnsignedTx.TxHash() {
+		t.Fatalf("unsigned tx does not round trip -- got %v, want %v",
+			decoded.UnsignedTx.TxHash(), p.UnsignedTx.TxHash())
+	}
+	if !reflect.DeepEqual(decoded.Inputs, p.Inputs) {
+		t.Fatalf("inputs do not round trip -- got %+v, want %+v",
+			decoded.Inputs, p.Inputs)
+	}
+	if !reflect.DeepEqual(decoded.Outputs, p.Outputs) {
+		t.Fatalf("outputs do not round trip -- got %+v, want %+v",
+			decoded.Outputs, p.Outputs)
+	}
+	if !reflect.DeepEqual(decoded.Unknowns, p.Unknowns) {
+		t.Fatalf("unknowns do not round trip -- got %+v, want %+v",
+			decoded.Unknowns, p.Unknowns)
+	}
+}
+
+// TestParseErrors ensures malformed serialized packets are rejected.
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	p, err := New(newSpendTx(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	valid, err := p.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+
+	// The unsigned transaction map is followed by one empty map for the
+	// input and one for the output, so the last two bytes are separators.
+	globalMap := valid[len(psbtMagic) : len(valid)-2]
+
+	tests := []struct {
+		name string
+		b    []byte
+		err  error
+	}{{
+		name: "bad magic",
+		b:    append([]byte("psbx\xff"), valid[len(psbtMagic):]...),
+		err:  ErrInvalidMagic,
+	}, {
+		name: "missing unsigned tx",
+		b:    append(psbtMagic[:], 0x00),
+		err:  ErrMissingUnsignedTx,
+	}, {
+		name: "duplicate unsigned tx",
+		b: bytes.Join([][]byte{psbtMagic[:],
+			globalMap[:len(globalMap)-1], globalMap}, nil),
+		err: ErrDuplicateKey,
+	}}
+	for _, test := range tests {
+		_, err := FromBytes(test.b)
+		if err != test.err {
+			t.Errorf("%s: unexpected error -- got %v, want %v",
+				test.name, err, test.err)
+		}
+	}
+
+	// Truncated and trailing data must also be rejected.
+	if _, err := FromBytes(valid[:len(valid)-1]); err == nil {
+		t.Error("truncated packet: did not receive expected error")
+	}
+	if _, err := FromBytes(append(valid, 0x00)); err == nil {
+		t.Error("trailing data: did not receive expected error")
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg/chainec"
+	"github.com/commanderu/cdrd/txscript"
+)
+
+// spendInfo describes the script which must be satisfied to spend the previous
+// output of an input.
+type spendInfo struct {
+	// class is the class of the script which requires signatures.  It is
+	// the class of the redeem script for pay-to-script-hash outputs and the
+	// class of the script without the stake tag for stake outputs.
+	class txscript.ScriptClass
+
+	// script is the script which requires signatures.
+	script []byte
+
+	// subScript is the script committed to by the signature hash.
+	subScript []byte
+
+	// redeemScript is the redeem script which must be pushed after the
+	// signatures for pay-to-script-hash outputs.
+	redeemScript []byte
+}
+
+// spendInfo returns the information needed to sign and finalize the input at
+// the passed index which must have a known previous output.
+func (p *Packet) spendInfo(i int) (*spendInfo, error) {
+	pi := &p.Inputs[i]
+	if pi.PrevOut == nil {
+		return nil, ErrMissingPrevOut
+	}
+	version := pi.PrevOut.Version
+	pkScript := pi.PrevOut.PkScript
+
+	// Ticket purchase, vote, and revocation outputs are regular
+	// pay-to-pubkey-hash or pay-to-script-hash scripts prefixed with a
+	// stake tag opcode.  The signatures commit to the full script.
+	script := pkScript
+	if txscript.IsStakeOutput(script) {
+		script = script[1:]
+	}
+	info := &spendInfo{
+		class:     txscript.GetScriptClass(version, script),
+		script:    script,
+		subScript: pkScript,
+	}
+
+	if info.class == txscript.ScriptHashTy {
+		if pi.RedeemScript == nil {
+			return nil, ErrMissingRedeemScript
+		}
+		err := checkRedeemScript(version, pkScript, pi.RedeemScript)
+		if err != nil {
+			return nil, err
+		}
+		info.class = txscript.GetScriptClass(version, pi.RedeemScript)
+		info.script = pi.RedeemScript
+		info.subScript = pi.RedeemScript
+		info.redeemScript = pi.RedeemScript
+	}
+
+	switch info.class {
+	case txscript.PubKeyTy, txscript.PubkeyAltTy, txscript.PubKeyHashTy,
+		txscript.PubkeyHashAltTy, txscript.MultiSigTy:
+	default:
+		return nil, ErrUnsupportedScript
+	}
+	return info, nil
+}
+
+// requiresKey returns whether or not the script requires a signature of the
+// passed type from the passed serialized public key.
+func (info *spendInfo) requiresKey(pubKey []byte, sigType int) bool {
+	pushes, err := txscript.PushedData(info.script)
+	if err != nil || len(pushes) == 0 {
+		return false
+	}
+
+	// Alternative signature scripts encode the signature type they
+	// require while all other scripts require secp256k1 signatures.
+	wantSigType := chainec.ECTypeSecp256k1
+	switch info.class {
+	case txscript.PubkeyAltTy, txscript.PubkeyHashAltTy:
+		wantSigType, err = txscript.ExtractPkScriptAltSigType(info.script)
+		if err != nil {
+			return false
+		}
+	}
+	if sigType != wantSigType {
+		return false
+	}
+
+	switch info.class {
+	case txscript.PubKeyTy, txscript.PubkeyAltTy:
+		return bytes.Equal(pushes[0], pubKey)
+
+	case txscript.PubKeyHashTy, txscript.PubkeyHashAltTy:
+		return bytes.Equal(pushes[0], cdrutil.Hash160(pubKey))
+
+	case txscript.MultiSigTy:
+		for _, push := range pushes {
+			if bytes.Equal(push, pubKey) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sigHashType returns the signature hash type signatures of the input must
+// use.
+func (pi *PInput) sigHashType() txscript.SigHashType {
+	if pi.SigHashType == 0 {
+		return txscript.SigHashAll
+	}
+	return pi.SigHashType
+}
+
+// parseSignature parses a serialized signature, without the signature hash
+// type, of the passed signature type.
+func parseSignature(dsa chainec.DSA, sigType int, sig []byte) (chainec.Signature, error) {
+	if sigType == chainec.ECTypeSecp256k1 {
+		return dsa.ParseDERSignature(sig)
+	}
+	return dsa.ParseSignature(sig)
+}
+
+// AddPartialSig adds a signature to the input at the passed index after
+// ensuring the script requires a signature from the public key and the
+// signature is valid.  A signature for the same public key replaces the
+// existing one.
+func (p *Packet) AddPartialSig(i int, ps *PartialSig) error {
+	pi, err := p.input(i)
+	if err != nil {
+		return err
+	}
+	info, err := p.spendInfo(i)
+	if err != nil {
+		return err
+	}
+	if !info.requiresKey(ps.PubKey, ps.SigType) {
+		return ErrKeyNotInScript
+	}
+
+	// The signature must commit to the signature hash type of the input.
+	hashType := pi.sigHashType()
+	if len(ps.Signature) == 0 ||
+		txscript.SigHashType(ps.Signature[len(ps.Signature)-1]) != hashType {
+		return ErrInvalidSignature
+	}
+
+	dsa, err := dsaForSigType(ps.SigType)
+	if err != nil {
+		return err
+	}
+	pubKey, err := dsa.ParsePubKey(ps.PubKey)
+	if err != nil {
+		return err
+	}
+	sig, err := parseSignature(dsa, ps.SigType,
+		ps.Signature[:len(ps.Signature)-1])
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	hash, err := txscript.CalcSignatureHash(info.subScript, hashType,
+		p.UnsignedTx, i, nil)
+	if err != nil {
+		return err
+	}
+	if !dsa.Verify(pubKey, hash, sig.GetR(), sig.GetS()) {
+		return ErrInvalidSignature
+	}
+
+	for j, existing := range pi.PartialSigs {
+		if bytes.Equal(existing.PubKey, ps.PubKey) {
+			pi.PartialSigs[j] = ps
+			return nil
+		}
+	}
+	pi.PartialSigs = append(pi.PartialSigs, ps)
+	return nil
+}
+
+// Sign signs the input at the passed index with the passed private key using
+// the passed signature type and adds the signature to the input.  Note that
+// secp256k1 Schnorr signatures are created with secp256k1 private keys.  For
+// secp256k1 signatures, the script determines whether the compressed or
+// uncompressed public key is used.
+func (p *Packet) Sign(i int, privKey chainec.PrivateKey, sigType int) error {
+	pi, err := p.input(i)
+	if err != nil {
+		return err
+	}
+	info, err := p.spendInfo(i)
+	if err != nil {
+		return err
+	}
+
+	dsa, err := dsaForSigType(sigType)
+	if err != nil {
+		return err
+	}
+	pub := dsa.NewPublicKey(privKey.Public())
+	candidates := [][]byte{pub.Serialize()}
+	if sigType == chainec.ECTypeSecp256k1 {
+		candidates = [][]byte{pub.SerializeCompressed(),
+			pub.SerializeUncompressed()}
+	}
+	var pubKey []byte
+	for _, candidate := range candidates {
+		if info.requiresKey(candidate, sigType) {
+			pubKey = candidate
+			break
+		}
+	}
+	if pubKey == nil {
+		return ErrKeyNotInScript
+	}
+
+	hashType := pi.sigHashType()
+	hash, err := txscript.CalcSignatureHash(info.subScript, hashType,
+		p.UnsignedTx, i, nil)
+	if err != nil {
+		return err
+	}
+	r, s, err := dsa.Sign(privKey, hash)
+	if err != nil {
+		return err
+	}
+	sig := append(dsa.NewSignature(r, s).Serialize(), byte(hashType))
+
+	return p.AddPartialSig(i, &PartialSig{
+		PubKey:    pubKey,
+		Signature: sig,
+		SigType:   sigType,
+	})
+}
@@ -0,0 +1,330 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/commanderu/cdrd/blockchain/stake"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainec"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/txscript"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// testParams are the network parameters used to create the test addresses.
+var testParams = &chaincfg.TestNet2Params
+
+// newTestKey returns a new random private key of the passed signature type
+// along with the serialized public key scripts pay to.
+func newTestKey(t *testing.T, sigType int) (chainec.PrivateKey, []byte) {
+	dsa, err := dsaForSigType(sigType)
+	if err != nil {
+		t.Fatalf("dsaForSigType: %v", err)
+	}
+	keyBytes, _, _, err := dsa.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	privKey, pubKey := dsa.PrivKeyFromBytes(keyBytes)
+	if sigType == chainec.ECTypeSecp256k1 {
+		return privKey, pubKey.SerializeCompressed()
+	}
+	return privKey, pubKey.Serialize()
+}
+
+// payToPubKeyHash returns a script which pays to the hash of the passed public
+// key of the passed signature type.
+func payToPubKeyHash(t *testing.T, pubKey []byte, sigType int) []byte {
+	addr, err := cdrutil.NewAddressPubKeyHash(cdrutil.Hash160(pubKey),
+		testParams, sigType)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: %v", err)
+	}
+	script, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %v", err)
+	}
+	return script
+}
+
+// newSpendTx returns an unsigned transaction which spends the passed number of
+// previous outputs to a single output.
+func newSpendTx(numInputs int) *wire.MsgTx {
+	tx := wire.NewMsgTx()
+	for i := 0; i < numInputs; i++ {
+		prevOut := wire.NewOutPoint(&chainhash.Hash{byte(i + 1)}, uint32(i),
+			wire.TxTreeRegular)
+		tx.AddTxIn(wire.NewTxIn(prevOut, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(1e8, []byte{txscript.OP_TRUE}))
+	return tx
+}
+
+// signAndExtract signs every input of the packet with the passed keys, which
+// create signatures of the passed types, finalizes it, and ensures the
+// extracted transaction is fully signed.
+func signAndExtract(t *testing.T, p *Packet, keys [][]chainec.PrivateKey, sigTypes []int) *wire.MsgTx {
+	for i, inputKeys := range keys {
+		for _, key := range inputKeys {
+			if err := p.Sign(i, key, sigTypes[i]); err != nil {
+				t.Fatalf("Sign input %d: %v", i, err)
+			}
+		}
+	}
+	if err := p.Finalize(); err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	if !p.IsComplete() {
+		t.Fatal("packet is not complete after finalizing")
+	}
+	tx, err := p.Extract()
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	for i, txIn := range tx.TxIn {
+		if len(txIn.SignatureScript) == 0 {
+			t.Fatalf("input %d has no signature script", i)
+		}
+	}
+	return tx
+}
+
+// TestSignSigTypes ensures inputs which spend pay-to-pubkey-hash outputs of
+// all signature types may be signed, finalized, and extracted.
+func TestSignSigTypes(t *testing.T) {
+	t.Parallel()
+
+	sigTypes := []int{chainec.ECTypeSecp256k1, chainec.ECTypeEdwards,
+		chainec.ECTypeSecSchnorr}
+	tx := newSpendTx(len(sigTypes))
+	p, err := New(tx)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	keys := make([][]chainec.PrivateKey, len(sigTypes))
+	for i, sigType := range sigTypes {
+		privKey, pubKey := newTestKey(t, sigType)
+		keys[i] = []chainec.PrivateKey{privKey}
+		prevOut := wire.NewTxOut(1e8, payToPubKeyHash(t, pubKey, sigType))
+		if err := p.AddInPrevOut(i, prevOut); err != nil {
+			t.Fatalf("AddInPrevOut: %v", err)
+		}
+	}
+
+	// A key which is not involved in the script must be rejected.
+	otherKey, _ := newTestKey(t, chainec.ECTypeSecp256k1)
+	if err := p.Sign(0, otherKey, chainec.ECTypeSecp256k1); err != ErrKeyNotInScript {
+		t.Fatalf("Sign with unrelated key: unexpected error -- got %v, "+
+			"want %v", err, ErrKeyNotInScript)
+	}
+
+	// Extracting before finalizing must fail.
+	if _, err := p.Extract(); err != ErrIncomplete {
+		t.Fatalf("Extract: unexpected error -- got %v, want %v", err,
+			ErrIncomplete)
+	}
+
+	signAndExtract(t, p, keys, sigTypes)
+	for i, sigType := range sigTypes {
+		if p.Inputs[i].PartialSigs != nil {
+			t.Fatalf("input %d (sig type %d) still has partial "+
+				"signatures after finalizing", i, sigType)
+		}
+	}
+
+	// Finalized inputs may not be modified.
+	if err := p.Sign(0, keys[0][0], sigTypes[0]); err != ErrInputFinalized {
+		t.Fatalf("Sign finalized input: unexpected error -- got %v, "+
+			"want %v", err, ErrInputFinalized)
+	}
+}
+
+// TestSignMultiSig ensures a pay-to-script-hash multisig input may be signed
+// independently by multiple parties whose packets are then combined and
+// finalized.
+func TestSignMultiSig(t *testing.T) {
+	t.Parallel()
+
+	var privKeys []chainec.PrivateKey
+	var addrs []*cdrutil.AddressSecpPubKey
+	for i := 0; i < 3; i++ {
+		privKey, pubKey := newTestKey(t, chainec.ECTypeSecp256k1)
+		addr, err := cdrutil.NewAddressSecpPubKey(pubKey, testParams)
+		if err != nil {
+			t.Fatalf("NewAddressSecpPubKey: %v", err)
+		}
+		privKeys = append(privKeys, privKey)
+		addrs = append(addrs, addr)
+	}
+	redeemScript, err := txscript.MultiSigScript(addrs, 2)
+	if err != nil {
+		t.Fatalf("MultiSigScript: %v", err)
+	}
+	p2sh, err := txscript.PayToScriptHashScript(cdrutil.Hash160(redeemScript))
+	if err != nil {
+		t.Fatalf("PayToScriptHashScript: %v", err)
+	}
+
+	p, err := New(newSpendTx(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := p.AddInPrevOut(0, wire.NewTxOut(1e8, p2sh)); err != nil {
+		t.Fatalf("AddInPrevOut: %v", err)
+	}
+
+	// Signing requires the redeem script and the redeem script must match
+	// the script hash.
+	if err := p.Sign(0, privKeys[0], chainec.ECTypeSecp256k1); err != ErrMissingRedeemScript {
+		t.Fatalf("Sign without redeem script: unexpected error -- got "+
+			"%v, want %v", err, ErrMissingRedeemScript)
+	}
+	err = p.AddInRedeemScript(0, []byte{txscript.OP_TRUE})
+	if err != ErrRedeemScriptMismatch {
+		t.Fatalf("AddInRedeemScript: unexpected error -- got %v, want "+
+			"%v", err, ErrRedeemScriptMismatch)
+	}
+	if err := p.AddInRedeemScript(0, redeemScript); err != nil {
+		t.Fatalf("AddInRedeemScript: %v", err)
+	}
+
+	// Sign copies of the packet with the last two keys independently so
+	// the signatures are out of order with respect to the script.
+	b64, err := p.B64Encode()
+	if err != nil {
+		t.Fatalf("B64Encode: %v", err)
+	}
+	var signed []*Packet
+	for _, privKey := range privKeys[1:] {
+		party, err := B64Decode(b64)
+		if err != nil {
+			t.Fatalf("B64Decode: %v", err)
+		}
+		if err := party.Sign(0, privKey, chainec.ECTypeSecp256k1); err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		signed = append(signed, party)
+	}
+
+	// A single signature is not enough to finalize.
+	if err := signed[0].FinalizeInput(0); err != ErrIncomplete {
+		t.Fatalf("FinalizeInput: unexpected error -- got %v, want %v",
+			err, ErrIncomplete)
+	}
+
+	combined, err := Combine(signed[1], signed[0])
+	if err != nil {
+		t.Fatalf("Combine: %v", err)
+	}
+	if len(combined.Inputs[0].PartialSigs) != 2 {
+		t.Fatalf("unexpected number of combined signatures -- got %d, "+
+			"want 2", len(combined.Inputs[0].PartialSigs))
+	}
+	signAndExtract(t, combined, nil, nil)
+
+	// Packets for different transactions may not be combined.
+	other, err := New(newSpendTx(2))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := Combine(p, other); err != ErrMismatchedTx {
+		t.Fatalf("Combine: unexpected error -- got %v, want %v", err,
+			ErrMismatchedTx)
+	}
+}
+
+// TestSignStakeTxns ensures the inputs of votes and revocations, which spend
+// stake tagged ticket outputs, may be signed and finalized and that the
+// stakebase input of a vote requires no signatures.
+func TestSignStakeTxns(t *testing.T) {
+	t.Parallel()
+
+	privKey, pubKey := newTestKey(t, chainec.ECTypeSecp256k1)
+	addr, err := cdrutil.NewAddressPubKeyHash(cdrutil.Hash160(pubKey),
+		testParams, chainec.ECTypeSecp256k1)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: %v", err)
+	}
+	ticketScript, err := txscript.PayToSStx(addr)
+	if err != nil {
+		t.Fatalf("PayToSStx: %v", err)
+	}
+	ticketOut := wire.NewTxOut(1e8, ticketScript)
+	ticketOutPoint := wire.NewOutPoint(&chainhash.Hash{0x01}, 0,
+		wire.TxTreeStake)
+
+	// Build a vote which spends the ticket.
+	blockRef, err := txscript.GenerateSSGenBlockRef(chainhash.Hash{0x02}, 100)
+	if err != nil {
+		t.Fatalf("GenerateSSGenBlockRef: %v", err)
+	}
+	votes, err := txscript.GenerateSSGenVotes(0x0001)
+	if err != nil {
+		t.Fatalf("GenerateSSGenVotes: %v", err)
+	}
+	reward, err := txscript.PayToSSGen(addr)
+	if err != nil {
+		t.Fatalf("PayToSSGen: %v", err)
+	}
+	stakeBaseScript := []byte{0x00, 0x00}
+	vote := wire.NewMsgTx()
+	vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular), stakeBaseScript))
+	vote.AddTxIn(wire.NewTxIn(ticketOutPoint, nil))
+	vote.AddTxOut(wire.NewTxOut(0, blockRef))
+	vote.AddTxOut(wire.NewTxOut(0, votes))
+	vote.AddTxOut(wire.NewTxOut(1e8, reward))
+
+	p, err := New(vote)
+	if err != nil {
+		t.Fatalf("New vote: %v", err)
+	}
+	if p.TxType() != stake.TxTypeSSGen {
+		t.Fatalf("unexpected vote tx type %v", p.TxType())
+	}
+	if err := p.AddInPrevOut(1, ticketOut); err != nil {
+		t.Fatalf("AddInPrevOut: %v", err)
+	}
+	tx := signAndExtract(t, p, [][]chainec.PrivateKey{nil, {privKey}},
+		[]int{0, chainec.ECTypeSecp256k1})
+	if string(tx.TxIn[0].SignatureScript) != string(stakeBaseScript) {
+		t.Fatalf("stakebase signature script was modified -- got %x, "+
+			"want %x", tx.TxIn[0].SignatureScript, stakeBaseScript)
+	}
+
+	// A regular transaction may not have any signature scripts.
+	vote.TxOut = vote.TxOut[2:]
+	if _, err := New(vote); err != ErrInvalidSigScript {
+		t.Fatalf("New: unexpected error -- got %v, want %v", err,
+			ErrInvalidSigScript)
+	}
+
+	// Build a revocation which spends the ticket.
+	refund, err := txscript.PayToSSRtx(addr)
+	if err != nil {
+		t.Fatalf("PayToSSRtx: %v", err)
+	}
+	revocation := wire.NewMsgTx()
+	revocation.AddTxIn(wire.NewTxIn(ticketOutPoint, nil))
+	revocation.AddTxOut(wire.NewTxOut(1e8, refund))
+
+	p, err = New(revocation)
+	if err != nil {
+		t.Fatalf("New revocation: %v", err)
+	}
+	if p.TxType() != stake.TxTypeSSRtx {
+		t.Fatalf("unexpected revocation tx type %v", p.TxType())
+	}
+	if err := p.AddInPrevOut(0, ticketOut); err != nil {
+		t.Fatalf("AddInPrevOut: %v", err)
+	}
+	signAndExtract(t, p, [][]chainec.PrivateKey{{privKey}},
+		[]int{chainec.ECTypeSecp256k1})
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/txscript"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// input returns the input at the passed index and ensures it has not been
+// finalized.
+func (p *Packet) input(i int) (*PInput, error) {
+	if i < 0 || i >= len(p.Inputs) {
+		return nil, ErrInvalidIndex
+	}
+	pi := &p.Inputs[i]
+	if pi.FinalScriptSig != nil {
+		return nil, ErrInputFinalized
+	}
+	return pi, nil
+}
+
+// output returns the output at the passed index.
+func (p *Packet) output(i int) (*POutput, error) {
+	if i < 0 || i >= len(p.Outputs) {
+		return nil, ErrInvalidIndex
+	}
+	return &p.Outputs[i], nil
+}
+
+// checkRedeemScript ensures the passed redeem script hashes to the script hash
+// of the passed script when it is a, possibly stake tagged,
+// pay-to-script-hash script.
+func checkRedeemScript(version uint16, pkScript, redeemScript []byte) error {
+	if txscript.IsStakeOutput(pkScript) {
+		pkScript = pkScript[1:]
+	}
+	if txscript.GetScriptClass(version, pkScript) != txscript.ScriptHashTy {
+		return nil
+	}
+	scriptHash, err := txscript.GetScriptHashFromP2SHScript(pkScript)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(cdrutil.Hash160(redeemScript), scriptHash) {
+		return ErrRedeemScriptMismatch
+	}
+	return nil
+}
+
+// AddInPrevOut sets the previous output spent by the input at the passed
+// index.  When the input already has a redeem script, it must match the
+// previous output.
+func (p *Packet) AddInPrevOut(i int, prevOut *wire.TxOut) error {
+	pi, err := p.input(i)
+	if err != nil {
+		return err
+	}
+	if pi.RedeemScript != nil {
+		err := checkRedeemScript(prevOut.Version, prevOut.PkScript,
+			pi.RedeemScript)
+		if err != nil {
+			return err
+		}
+	}
+	pi.PrevOut = prevOut
+	return nil
+}
+
+// AddInRedeemScript sets the redeem script of the pay-to-script-hash output
+// spent by the input at the passed index.  When the previous output is
+// already known, the redeem script must match it.
+func (p *Packet) AddInRedeemScript(i int, redeemScript []byte) error {
+	pi, err := p.input(i)
+	if err != nil {
+		return err
+	}
+	if pi.PrevOut != nil {
+		err := checkRedeemScript(pi.PrevOut.Version, pi.PrevOut.PkScript,
+			redeemScript)
+		if err != nil {
+			return err
+		}
+	}
+	pi.RedeemScript = redeemScript
+	return nil
+}
+
+// AddInSigHashType sets the signature hash type which signatures of the input
+// at the passed index must use.
+func (p *Packet) AddInSigHashType(i int, hashType txscript.SigHashType) error {
+	pi, err := p.input(i)
+	if err != nil {
+		return err
+	}
+	pi.SigHashType = hashType
+	return nil
+}
+
+// addDerivation adds the passed derivation to the slice, replacing any
+// existing derivation for the same public key.
+func addDerivation(derivations []*Derivation, d *Derivation) []*Derivation {
+	for i, existing := range derivations {
+		if bytes.Equal(existing.PubKey, d.PubKey) {
+			derivations[i] = d
+			return derivations
+		}
+	}
+	return append(derivations, d)
+}
+
+// AddInDerivation adds the derivation path of a key which is relevant to the
+// input at the passed index.
+func (p *Packet) AddInDerivation(i int, d *Derivation) error {
+	pi, err := p.input(i)
+	if err != nil {
+		return err
+	}
+	pi.Derivations = addDerivation(pi.Derivations, d)
+	return nil
+}
+
+// AddOutRedeemScript sets the redeem script of the output at the passed index
+// which must pay to the hash of the script.
+func (p *Packet) AddOutRedeemScript(i int, redeemScript []byte) error {
+	po, err := p.output(i)
+	if err != nil {
+		return err
+	}
+	txOut := p.UnsignedTx.TxOut[i]
+	err = checkRedeemScript(txOut.Version, txOut.PkScript, redeemScript)
+	if err != nil {
+		return err
+	}
+	po.RedeemScript = redeemScript
+	return nil
+}
+
+// AddOutDerivation adds the derivation path of a key which is relevant to the
+// output at the passed index.
+func (p *Packet) AddOutDerivation(i int, d *Derivation) error {
+	po, err := p.output(i)
+	if err != nil {
+		return err
+	}
+	po.Derivations = addDerivation(po.Derivations, d)
+	return nil
+}
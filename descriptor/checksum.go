@@ -0,0 +1,114 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ChecksumLen is the number of characters in a descriptor checksum.
+	ChecksumLen = 8
+
+	// inputCharset is the set of characters allowed in a descriptor.  The
+	// position of each character is used to compute the checksum.  It is
+	// ordered so that the characters most commonly found in descriptors,
+	// such as hex digits and the characters which separate expressions,
+	// are grouped together.
+	inputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+	// checksumCharset is the set of characters used to encode checksums.
+	checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// polyMod updates the passed checksum state with the passed 5-bit value by
+// treating the state as a polynomial over GF(32) modulo the generator of a
+// BCH code which detects any error affecting up to 4 characters.
+func polyMod(c uint64, val int) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ uint64(val)
+	if c0&1 != 0 {
+		c ^= 0xf5dee51989
+	}
+	if c0&2 != 0 {
+		c ^= 0xa9fdca3312
+	}
+	if c0&4 != 0 {
+		c ^= 0x1bab10e32d
+	}
+	if c0&8 != 0 {
+		c ^= 0x3706b1677a
+	}
+	if c0&16 != 0 {
+		c ^= 0x644d626ffd
+	}
+	return c
+}
+
+// Checksum returns the checksum of the passed descriptor which must not
+// already include a checksum.  The checksum is compatible with the one used
+// by other implementations of output script descriptors.
+func Checksum(desc string) (string, error) {
+	c := uint64(1)
+	var class, classCount int
+	for i := 0; i < len(desc); i++ {
+		pos := strings.IndexByte(inputCharset, desc[i])
+		if pos == -1 {
+			return "", fmt.Errorf("invalid character %q at position %d",
+				desc[i], i)
+		}
+
+		// Emit the low 5 bits of the position of each character and
+		// combine the high bits of every group of 3 characters into an
+		// additional symbol.
+		c = polyMod(c, pos&31)
+		class = class*3 + pos>>5
+		classCount++
+		if classCount == 3 {
+			c = polyMod(c, class)
+			class = 0
+			classCount = 0
+		}
+	}
+	if classCount > 0 {
+		c = polyMod(c, class)
+	}
+	for i := 0; i < ChecksumLen; i++ {
+		c = polyMod(c, 0)
+	}
+	c ^= 1
+
+	var checksum [ChecksumLen]byte
+	for i := 0; i < ChecksumLen; i++ {
+		checksum[i] = checksumCharset[(c>>(5*(ChecksumLen-1-uint(i))))&31]
+	}
+	return string(checksum[:]), nil
+}
+
+// splitChecksum splits the passed descriptor into the descriptor and its
+// checksum and ensures the checksum, if any, is valid.  The returned checksum
+// is computed when the descriptor does not include one.
+func splitChecksum(desc string) (string, string, error) {
+	var checksum string
+	if i := strings.LastIndexByte(desc, '#'); i != -1 {
+		desc, checksum = desc[:i], desc[i+1:]
+		if len(checksum) != ChecksumLen {
+			return "", "", fmt.Errorf("checksum %q is not %d characters",
+				checksum, ChecksumLen)
+		}
+	}
+
+	wantChecksum, err := Checksum(desc)
+	if err != nil {
+		return "", "", err
+	}
+	if checksum != "" && checksum != wantChecksum {
+		return "", "", ErrChecksumMismatch
+	}
+	return desc, wantChecksum, nil
+}
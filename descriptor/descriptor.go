@@ -0,0 +1,459 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainec"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/hdkeychain"
+	"github.com/commanderu/cdrd/txscript"
+)
+
+var (
+	// ErrChecksumMismatch describes an error in which the checksum of a
+	// descriptor does not match the descriptor.
+	ErrChecksumMismatch = errors.New("descriptor checksum mismatch")
+
+	// ErrPrivateKey describes an error in which a descriptor contains an
+	// extended private key.  Descriptors are intended for watch-only use,
+	// so only extended public keys are accepted.
+	ErrPrivateKey = errors.New("extended private keys are not supported")
+
+	// ErrHardenedDerivation describes an error in which a descriptor
+	// requests hardened derivation, which is not possible from an extended
+	// public key.
+	ErrHardenedDerivation = errors.New("hardened derivation requires an " +
+		"extended private key")
+
+	// ErrInvalidRange describes an error in which the start of a range of
+	// child indexes is after its end.
+	ErrInvalidRange = errors.New("range start is after range end")
+)
+
+// scriptType identifies the type of output script a descriptor describes.
+type scriptType int
+
+// These constants define the supported script types.
+const (
+	stPubKeyHash scriptType = iota
+	stPubKeyHashEdwards
+	stPubKeyHashSchnorr
+	stScriptHashMultiSig
+	stAddress
+	stRaw
+)
+
+// stakeTags maps the names of the stake-tagged descriptor expressions to the
+// functions which tag a pay-to-pubkey-hash or pay-to-script-hash output script
+// with the associated stake opcode.
+var stakeTags = map[string]func(cdrutil.Address) ([]byte, error){
+	"sstx":       txscript.PayToSStx,
+	"ssgen":      txscript.PayToSSGen,
+	"ssrtx":      txscript.PayToSSRtx,
+	"sstxchange": txscript.PayToSStxChange,
+}
+
+// keyExpr is a parsed key expression.  It is either a static serialized public
+// key or an extended public key along with whether or not it is further
+// derived with a child index provided at expansion time.
+type keyExpr struct {
+	pubKey []byte
+	extKey *hdkeychain.ExtendedKey
+	ranged bool
+}
+
+// derive returns the serialized public key the key expression represents at
+// the passed child index.  The index is ignored for key expressions which are
+// not ranged.
+func (k *keyExpr) derive(index uint32) ([]byte, error) {
+	if k.extKey == nil {
+		return k.pubKey, nil
+	}
+
+	extKey := k.extKey
+	if k.ranged {
+		var err error
+		extKey, err = extKey.Child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	pubKey, err := extKey.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+	return pubKey.SerializeCompressed(), nil
+}
+
+// Descriptor is a parsed output script descriptor.  It describes either a
+// single output script or, when it contains a key expression ending in /*, a
+// range of output scripts derived from extended public keys.
+//
+// See the package documentation for the supported descriptor language.
+type Descriptor struct {
+	desc      string
+	checksum  string
+	params    *chaincfg.Params
+	typ       scriptType
+	stakeTag  func(cdrutil.Address) ([]byte, error)
+	keys      []*keyExpr
+	threshold int
+	sorted    bool
+	addr      cdrutil.Address
+	script    []byte
+}
+
+// String returns the descriptor along with its checksum.
+func (d *Descriptor) String() string {
+	return d.desc + "#" + d.checksum
+}
+
+// IsRange returns whether or not the descriptor describes a range of output
+// scripts rather than a single one.
+func (d *Descriptor) IsRange() bool {
+	for _, key := range d.keys {
+		if key.ranged {
+			return true
+		}
+	}
+	return false
+}
+
+// Script returns the output script the descriptor describes at the passed
+// child index.  The index is ignored for descriptors which are not ranged.
+//
+// NOTE: There is an extremely small chance (< 1 in 2^127) that a child index
+// does not derive to a usable key.  The hdkeychain.ErrInvalidChild error is
+// returned when this occurs, and the caller is expected to skip the index.
+func (d *Descriptor) Script(index uint32) ([]byte, error) {
+	switch d.typ {
+	case stRaw:
+		return d.script, nil
+
+	case stAddress:
+		return txscript.PayToAddrScript(d.addr)
+
+	case stScriptHashMultiSig:
+		pubKeys := make([]*cdrutil.AddressSecpPubKey, 0, len(d.keys))
+		for _, key := range d.keys {
+			pubKey, err := key.derive(index)
+			if err != nil {
+				return nil, err
+			}
+			addr, err := cdrutil.NewAddressSecpPubKey(pubKey, d.params)
+			if err != nil {
+				return nil, err
+			}
+			pubKeys = append(pubKeys, addr)
+		}
+		if d.sorted {
+			sort.Slice(pubKeys, func(i, j int) bool {
+				return bytes.Compare(pubKeys[i].ScriptAddress(),
+					pubKeys[j].ScriptAddress()) < 0
+			})
+		}
+		redeemScript, err := txscript.MultiSigScript(pubKeys, d.threshold)
+		if err != nil {
+			return nil, err
+		}
+		if len(redeemScript) > txscript.MaxScriptElementSize {
+			return nil, fmt.Errorf("multisig redeem script is %d bytes "+
+				"which exceeds the max allowed size of %d",
+				len(redeemScript), txscript.MaxScriptElementSize)
+		}
+		addr, err := cdrutil.NewAddressScriptHash(redeemScript, d.params)
+		if err != nil {
+			return nil, err
+		}
+		if d.stakeTag != nil {
+			return d.stakeTag(addr)
+		}
+		return txscript.PayToAddrScript(addr)
+	}
+
+	// The remaining types are pay-to-pubkey-hash variants.
+	pubKey, err := d.keys[0].derive(index)
+	if err != nil {
+		return nil, err
+	}
+	algo := chainec.ECTypeSecp256k1
+	switch d.typ {
+	case stPubKeyHashEdwards:
+		algo = chainec.ECTypeEdwards
+	case stPubKeyHashSchnorr:
+		algo = chainec.ECTypeSecSchnorr
+	}
+	addr, err := cdrutil.NewAddressPubKeyHash(cdrutil.Hash160(pubKey),
+		d.params, algo)
+	if err != nil {
+		return nil, err
+	}
+	if d.stakeTag != nil {
+		return d.stakeTag(addr)
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+// Expand returns the output scripts the descriptor describes for each child
+// index in the inclusive range from start to end.  Descriptors which are not
+// ranged describe a single output script regardless of the range.
+//
+// Child indexes which do not derive to a usable key are skipped.
+func (d *Descriptor) Expand(start, end uint32) ([][]byte, error) {
+	if start > end {
+		return nil, ErrInvalidRange
+	}
+	if !d.IsRange() {
+		script, err := d.Script(0)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{script}, nil
+	}
+	if end >= hdkeychain.HardenedKeyStart {
+		return nil, fmt.Errorf("range end %d is not a non-hardened child "+
+			"index", end)
+	}
+
+	scripts := make([][]byte, 0, end-start+1)
+	for index := start; ; index++ {
+		script, err := d.Script(index)
+		if err != nil && err != hdkeychain.ErrInvalidChild {
+			return nil, err
+		}
+		if err == nil {
+			scripts = append(scripts, script)
+		}
+		if index == end {
+			break
+		}
+	}
+	return scripts, nil
+}
+
+// Parse parses the passed descriptor for the passed network.  The descriptor
+// may optionally end with a checksum, in which case the checksum must be
+// valid.
+func Parse(desc string, params *chaincfg.Params) (*Descriptor, error) {
+	desc, checksum, err := splitChecksum(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Descriptor{desc: desc, checksum: checksum, params: params}
+	name, args, err := splitExpr(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unwrap stake-tagged expressions, which may only tag pay-to-pubkey-hash
+	// and pay-to-script-hash expressions.
+	if stakeTag, ok := stakeTags[name]; ok {
+		d.stakeTag = stakeTag
+		tagName := name
+		name, args, err = splitExpr(args)
+		if err != nil {
+			return nil, err
+		}
+		if name != "pkh" && name != "sh" {
+			return nil, fmt.Errorf("%s() may only contain pkh() or sh(), "+
+				"not %s()", tagName, name)
+		}
+	}
+
+	switch name {
+	case "pkh":
+		return d, d.parsePubKeyHash(stPubKeyHash, args)
+
+	case "edpkh":
+		return d, d.parsePubKeyHash(stPubKeyHashEdwards, args)
+
+	case "schnorrpkh":
+		return d, d.parsePubKeyHash(stPubKeyHashSchnorr, args)
+
+	case "sh":
+		return d, d.parseScriptHash(args)
+
+	case "addr":
+		addr, err := cdrutil.DecodeAddress(args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %v", args, err)
+		}
+		if !addr.IsForNet(params) {
+			return nil, fmt.Errorf("address %q is not for %s", args,
+				params.Name)
+		}
+		d.typ = stAddress
+		d.addr = addr
+		return d, nil
+
+	case "raw":
+		script, err := hex.DecodeString(args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex script %q", args)
+		}
+		d.typ = stRaw
+		d.script = script
+		return d, nil
+	}
+
+	return nil, fmt.Errorf("unknown descriptor expression %s()", name)
+}
+
+// parsePubKeyHash parses the single key expression argument of a
+// pay-to-pubkey-hash expression of the passed type into the descriptor.
+func (d *Descriptor) parsePubKeyHash(typ scriptType, args string) error {
+	key, err := parseKey(args, typ, d.params)
+	if err != nil {
+		return err
+	}
+	d.typ = typ
+	d.keys = []*keyExpr{key}
+	return nil
+}
+
+// parseScriptHash parses the argument of a sh() expression into the
+// descriptor.  Only multisig scripts are supported.
+func (d *Descriptor) parseScriptHash(args string) error {
+	name, args, err := splitExpr(args)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "multi":
+	case "sortedmulti":
+		d.sorted = true
+	default:
+		return fmt.Errorf("sh() may only contain multi() or sortedmulti(), "+
+			"not %s()", name)
+	}
+
+	parts := strings.Split(args, ",")
+	if len(parts) < 2 {
+		return fmt.Errorf("%s() requires a threshold and at least one key",
+			name)
+	}
+	threshold, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return fmt.Errorf("invalid multisig threshold %q", parts[0])
+	}
+	numKeys := len(parts) - 1
+	if numKeys > txscript.MaxPubKeysPerMultiSig {
+		return fmt.Errorf("multisig has %d keys which exceeds the max "+
+			"of %d", numKeys, txscript.MaxPubKeysPerMultiSig)
+	}
+	if threshold < 1 || threshold > numKeys {
+		return fmt.Errorf("multisig threshold %d is not between 1 and "+
+			"the number of keys %d", threshold, numKeys)
+	}
+
+	d.typ = stScriptHashMultiSig
+	d.threshold = threshold
+	d.keys = make([]*keyExpr, 0, numKeys)
+	for _, part := range parts[1:] {
+		key, err := parseKey(part, stScriptHashMultiSig, d.params)
+		if err != nil {
+			return err
+		}
+		d.keys = append(d.keys, key)
+	}
+	return nil
+}
+
+// splitExpr splits the passed expression of the form name(args) into its name
+// and arguments.
+func splitExpr(expr string) (string, string, error) {
+	open := strings.IndexByte(expr, '(')
+	if open == -1 || !strings.HasSuffix(expr, ")") {
+		return "", "", fmt.Errorf("expression %q is not of the form "+
+			"name(...)", expr)
+	}
+	return expr[:open], expr[open+1 : len(expr)-1], nil
+}
+
+// parseKey parses the passed key expression for use in a script of the passed
+// type.  Key expressions are either hex-encoded public keys or extended public
+// keys followed by an optional derivation path of non-hardened child indexes
+// which may end with /* to denote a ranged key.
+func parseKey(expr string, typ scriptType, params *chaincfg.Params) (*keyExpr, error) {
+	// Edwards public keys are only supported as hex-encoded public keys
+	// since they can't be derived from extended keys.
+	if typ == stPubKeyHashEdwards {
+		pubKey, err := hex.DecodeString(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex Ed25519 public key %q",
+				expr)
+		}
+		if _, err := chainec.Edwards.ParsePubKey(pubKey); err != nil {
+			return nil, fmt.Errorf("invalid Ed25519 public key %q: %v",
+				expr, err)
+		}
+		return &keyExpr{pubKey: pubKey}, nil
+	}
+
+	// Hex-encoded secp256k1 public keys.  Schnorr public keys must be
+	// compressed.
+	if pubKey, err := hex.DecodeString(expr); err == nil {
+		if _, err := chainec.Secp256k1.ParsePubKey(pubKey); err != nil {
+			return nil, fmt.Errorf("invalid public key %q: %v", expr,
+				err)
+		}
+		if typ == stPubKeyHashSchnorr && len(pubKey) != 33 {
+			return nil, fmt.Errorf("public key %q is not compressed",
+				expr)
+		}
+		return &keyExpr{pubKey: pubKey}, nil
+	}
+
+	// Extended public keys with an optional derivation path.
+	parts := strings.Split(expr, "/")
+	extKey, err := hdkeychain.NewKeyFromString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %q: %v", parts[0], err)
+	}
+	if extKey.IsPrivate() {
+		return nil, ErrPrivateKey
+	}
+	if !extKey.IsForNet(params) {
+		return nil, fmt.Errorf("extended key %q is not for %s", parts[0],
+			params.Name)
+	}
+	key := &keyExpr{extKey: extKey}
+	for i, part := range parts[1:] {
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") {
+			return nil, ErrHardenedDerivation
+		}
+		if part == "*" {
+			if i != len(parts)-2 {
+				return nil, fmt.Errorf("key %q may only have * as the "+
+					"final child index", expr)
+			}
+			key.ranged = true
+			break
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid child index %q in key %q",
+				part, expr)
+		}
+		if index >= hdkeychain.HardenedKeyStart {
+			return nil, ErrHardenedDerivation
+		}
+		key.extKey, err = key.extKey.Child(uint32(index))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
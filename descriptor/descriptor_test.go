@@ -0,0 +1,363 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/chaincfg/chainec"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/hdkeychain"
+	"github.com/commanderu/cdrd/txscript"
+)
+
+// TestChecksum ensures descriptor checksums are calculated as expected and
+// that descriptors with invalid checksums are rejected.
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	// Test vectors shared with other implementations of descriptors.
+	tests := []struct {
+		desc     string
+		checksum string
+	}{
+		{"raw(deadbeef)", "89f8spxm"},
+		{"addr(mkmZxiEcEd8ZqjQWVZuC6so5dFMKEFpN2j)", "02wpgw69"},
+	}
+	for _, test := range tests {
+		checksum, err := Checksum(test.desc)
+		if err != nil {
+			t.Fatalf("Checksum(%q): unexpected error: %v", test.desc, err)
+		}
+		if checksum != test.checksum {
+			t.Fatalf("Checksum(%q): got %q, want %q", test.desc, checksum,
+				test.checksum)
+		}
+	}
+
+	params := &chaincfg.MainNetParams
+	if _, err := Parse("raw(deadbeef)#89f8spxm", params); err != nil {
+		t.Fatalf("Parse: unexpected error with valid checksum: %v", err)
+	}
+	if _, err := Parse("raw(deadbeef)#89f8spxn", params); err != ErrChecksumMismatch {
+		t.Fatalf("Parse: unexpected error with invalid checksum -- got %v, "+
+			"want %v", err, ErrChecksumMismatch)
+	}
+	if _, err := Parse("raw(deadbeef)#89f8", params); err == nil {
+		t.Fatal("Parse: did not fail with short checksum")
+	}
+	if _, err := Checksum("raw(deadbeef)é"); err == nil {
+		t.Fatal("Checksum: did not fail with invalid character")
+	}
+}
+
+// testKeys returns an extended public key along with the first three
+// serialized public keys derived from its external branch.
+func testKeys(t *testing.T, params *chaincfg.Params) (string, [][]byte) {
+	seed := bytes.Repeat([]byte{0x2a}, hdkeychain.RecommendedSeedLen)
+	master, err := hdkeychain.NewMaster(seed, params)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+	account, err := master.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+	branch, err := account.Child(0)
+	if err != nil {
+		t.Fatalf("Child: unexpected error: %v", err)
+	}
+
+	pubKeys := make([][]byte, 3)
+	for i := range pubKeys {
+		child, err := branch.Child(uint32(i))
+		if err != nil {
+			t.Fatalf("Child: unexpected error: %v", err)
+		}
+		pubKey, err := child.ECPubKey()
+		if err != nil {
+			t.Fatalf("ECPubKey: unexpected error: %v", err)
+		}
+		pubKeys[i] = pubKey.SerializeCompressed()
+	}
+	return account.String(), pubKeys
+}
+
+// pkhScript returns the pay-to-pubkey-hash script for the passed serialized
+// public key and signature algorithm.
+func pkhScript(t *testing.T, pubKey []byte, algo int, params *chaincfg.Params) []byte {
+	addr, err := cdrutil.NewAddressPubKeyHash(cdrutil.Hash160(pubKey),
+		params, algo)
+	if err != nil {
+		t.Fatalf("NewAddressPubKeyHash: unexpected error: %v", err)
+	}
+	script, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: unexpected error: %v", err)
+	}
+	return script
+}
+
+// multiSigScript returns the pay-to-script-hash script for a multisig script
+// with the passed threshold and serialized public keys in the provided order.
+func multiSigScript(t *testing.T, threshold int, pubKeys [][]byte, params *chaincfg.Params) []byte {
+	addrs := make([]*cdrutil.AddressSecpPubKey, len(pubKeys))
+	for i, pubKey := range pubKeys {
+		var err error
+		addrs[i], err = cdrutil.NewAddressSecpPubKey(pubKey, params)
+		if err != nil {
+			t.Fatalf("NewAddressSecpPubKey: unexpected error: %v", err)
+		}
+	}
+	redeemScript, err := txscript.MultiSigScript(addrs, threshold)
+	if err != nil {
+		t.Fatalf("MultiSigScript: unexpected error: %v", err)
+	}
+	addr, err := cdrutil.NewAddressScriptHash(redeemScript, params)
+	if err != nil {
+		t.Fatalf("NewAddressScriptHash: unexpected error: %v", err)
+	}
+	script, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: unexpected error: %v", err)
+	}
+	return script
+}
+
+// tagScript returns the passed script tagged with the passed stake opcode.
+func tagScript(op byte, script []byte) []byte {
+	return append([]byte{op}, script...)
+}
+
+// TestExpand ensures parsing and expanding a variety of descriptors produces
+// the expected output scripts.
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+	xpub, pubKeys := testKeys(t, params)
+	hexKeys := make([]string, len(pubKeys))
+	for i, pubKey := range pubKeys {
+		hexKeys[i] = hex.EncodeToString(pubKey)
+	}
+	edPubKey := bytes.Repeat([]byte{0x01}, 32)
+	edHexKey := hex.EncodeToString(edPubKey)
+	secp := chainec.ECTypeSecp256k1
+
+	// Sorting the keys is independent of the order they are listed in.
+	sortedKeys := [][]byte{pubKeys[0], pubKeys[1], pubKeys[2]}
+	for i := 0; i < len(sortedKeys); i++ {
+		for j := i + 1; j < len(sortedKeys); j++ {
+			if bytes.Compare(sortedKeys[j], sortedKeys[i]) < 0 {
+				sortedKeys[i], sortedKeys[j] = sortedKeys[j], sortedKeys[i]
+			}
+		}
+	}
+
+	tests := []struct {
+		name    string
+		desc    string
+		isRange bool
+		start   uint32
+		end     uint32
+		scripts [][]byte
+	}{{
+		name: "pkh hex key",
+		desc: "pkh(" + hexKeys[0] + ")",
+		end:  10,
+		scripts: [][]byte{
+			pkhScript(t, pubKeys[0], secp, params),
+		},
+	}, {
+		name: "pkh extended key with path",
+		desc: "pkh(" + xpub + "/0/2)",
+		scripts: [][]byte{
+			pkhScript(t, pubKeys[2], secp, params),
+		},
+	}, {
+		name:    "ranged pkh",
+		desc:    "pkh(" + xpub + "/0/*)",
+		isRange: true,
+		start:   1,
+		end:     2,
+		scripts: [][]byte{
+			pkhScript(t, pubKeys[1], secp, params),
+			pkhScript(t, pubKeys[2], secp, params),
+		},
+	}, {
+		name: "edwards pkh",
+		desc: "edpkh(" + edHexKey + ")",
+		scripts: [][]byte{
+			pkhScript(t, edPubKey, chainec.ECTypeEdwards, params),
+		},
+	}, {
+		name:    "ranged schnorr pkh",
+		desc:    "schnorrpkh(" + xpub + "/0/*)",
+		isRange: true,
+		end:     0,
+		scripts: [][]byte{
+			pkhScript(t, pubKeys[0], chainec.ECTypeSecSchnorr, params),
+		},
+	}, {
+		name: "multisig",
+		desc: "sh(multi(2," + hexKeys[2] + "," + hexKeys[0] + "," + hexKeys[1] + "))",
+		scripts: [][]byte{
+			multiSigScript(t, 2, [][]byte{pubKeys[2], pubKeys[0], pubKeys[1]}, params),
+		},
+	}, {
+		name: "sorted multisig",
+		desc: "sh(sortedmulti(2," + hexKeys[2] + "," + hexKeys[0] + "," + hexKeys[1] + "))",
+		scripts: [][]byte{
+			multiSigScript(t, 2, sortedKeys, params),
+		},
+	}, {
+		name:    "ranged multisig mixing keys",
+		desc:    "sh(multi(1," + hexKeys[2] + "," + xpub + "/0/*))",
+		isRange: true,
+		end:     1,
+		scripts: [][]byte{
+			multiSigScript(t, 1, [][]byte{pubKeys[2], pubKeys[0]}, params),
+			multiSigScript(t, 1, [][]byte{pubKeys[2], pubKeys[1]}, params),
+		},
+	}, {
+		name: "ticket purchase pkh",
+		desc: "sstx(pkh(" + hexKeys[0] + "))",
+		scripts: [][]byte{
+			tagScript(txscript.OP_SSTX, pkhScript(t, pubKeys[0], secp, params)),
+		},
+	}, {
+		name: "vote multisig",
+		desc: "ssgen(sh(multi(1," + hexKeys[0] + ")))",
+		scripts: [][]byte{
+			tagScript(txscript.OP_SSGEN, multiSigScript(t, 1, pubKeys[:1], params)),
+		},
+	}, {
+		name:    "ranged revocation",
+		desc:    "ssrtx(pkh(" + xpub + "/0/*))",
+		isRange: true,
+		end:     0,
+		scripts: [][]byte{
+			tagScript(txscript.OP_SSRTX, pkhScript(t, pubKeys[0], secp, params)),
+		},
+	}, {
+		name: "ticket change",
+		desc: "sstxchange(pkh(" + hexKeys[1] + "))",
+		scripts: [][]byte{
+			tagScript(txscript.OP_SSTXCHANGE, pkhScript(t, pubKeys[1], secp, params)),
+		},
+	}, {
+		name: "raw",
+		desc: "raw(deadbeef)",
+		scripts: [][]byte{
+			{0xde, 0xad, 0xbe, 0xef},
+		},
+	}}
+
+	for _, test := range tests {
+		d, err := Parse(test.desc, params)
+		if err != nil {
+			t.Errorf("%q: unexpected parse error: %v", test.name, err)
+			continue
+		}
+		if d.IsRange() != test.isRange {
+			t.Errorf("%q: unexpected range -- got %v, want %v", test.name,
+				d.IsRange(), test.isRange)
+			continue
+		}
+		scripts, err := d.Expand(test.start, test.end)
+		if err != nil {
+			t.Errorf("%q: unexpected expand error: %v", test.name, err)
+			continue
+		}
+		if len(scripts) != len(test.scripts) {
+			t.Errorf("%q: unexpected number of scripts -- got %d, want %d",
+				test.name, len(scripts), len(test.scripts))
+			continue
+		}
+		for i := range scripts {
+			if !bytes.Equal(scripts[i], test.scripts[i]) {
+				t.Errorf("%q: unexpected script %d -- got %x, want %x",
+					test.name, i, scripts[i], test.scripts[i])
+			}
+		}
+
+		// Ensure the descriptor round trips through its string form with
+		// a checksum.
+		d2, err := Parse(d.String(), params)
+		if err != nil {
+			t.Errorf("%q: unexpected error parsing %q: %v", test.name,
+				d.String(), err)
+			continue
+		}
+		if d2.String() != d.String() {
+			t.Errorf("%q: descriptor does not round trip -- got %q, "+
+				"want %q", test.name, d2.String(), d.String())
+		}
+	}
+}
+
+// TestParseErrors ensures parsing invalid descriptors fails.
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.MainNetParams
+	xpub, pubKeys := testKeys(t, params)
+	hexKey := hex.EncodeToString(pubKeys[0])
+	seed := bytes.Repeat([]byte{0x2a}, hdkeychain.RecommendedSeedLen)
+	master, err := hdkeychain.NewMaster(seed, params)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+	testNetXpub, _ := testKeys(t, &chaincfg.TestNet2Params)
+
+	tests := []struct {
+		name string
+		desc string
+		err  error
+	}{
+		{"unknown expression", "pk(" + hexKey + ")", nil},
+		{"missing parenthesis", "pkh(" + hexKey, nil},
+		{"private extended key", "pkh(" + master.String() + "/0/*)", ErrPrivateKey},
+		{"hardened derivation", "pkh(" + xpub + "/0'/*)", ErrHardenedDerivation},
+		{"hardened index", "pkh(" + xpub + "/2147483648)", ErrHardenedDerivation},
+		{"wildcard not last", "pkh(" + xpub + "/*/0)", nil},
+		{"wrong network", "pkh(" + testNetXpub + "/0/*)", nil},
+		{"invalid public key", "pkh(02" + hexKey[4:] + ")", nil},
+		{"uncompressed schnorr key", "schnorrpkh(04" + hexKey[2:] + hexKey[2:] + ")", nil},
+		{"edwards extended key", "edpkh(" + xpub + "/0/*)", nil},
+		{"stake tagged edwards", "sstx(edpkh(" + hexKey[2:] + "))", nil},
+		{"stake tagged raw", "ssgen(raw(deadbeef))", nil},
+		{"sh without multisig", "sh(pkh(" + hexKey + "))", nil},
+		{"zero threshold", "sh(multi(0," + hexKey + "))", nil},
+		{"threshold above keys", "sh(multi(2," + hexKey + "))", nil},
+		{"multisig without keys", "sh(multi(1))", nil},
+		{"invalid raw hex", "raw(xyz)", nil},
+		{"invalid address", "addr(notanaddress)", nil},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.desc, params)
+		if err == nil {
+			t.Errorf("%q: parse did not fail", test.name)
+			continue
+		}
+		if test.err != nil && err != test.err {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+		}
+	}
+
+	// Ensure an invalid range is rejected.
+	d, err := Parse("pkh("+xpub+"/0/*)", params)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if _, err := d.Expand(2, 1); err != ErrInvalidRange {
+		t.Fatalf("unexpected expand error -- got %v, want %v", err,
+			ErrInvalidRange)
+	}
+}
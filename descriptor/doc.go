@@ -0,0 +1,48 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package descriptor provides an API for output script descriptors.
+
+An output script descriptor is a compact, human-readable description of a set
+of output scripts.  Descriptors allow watch-only software to describe, for
+example, every pay-to-pubkey-hash output script under an extended public key or
+a multisig pay-to-script-hash output script built from several keys, rather
+than enumerating the individual addresses.
+
+Descriptor Language
+
+A descriptor is one of the following expressions, optionally followed by # and
+an 8 character checksum:
+
+	pkh(KEY)                 pay-to-pubkey-hash of a secp256k1 key
+	edpkh(HEX)               pay-to-pubkey-hash of an Ed25519 key
+	schnorrpkh(KEY)          pay-to-pubkey-hash of a secp256k1 Schnorr key
+	sh(multi(K,KEY,...))     pay-to-script-hash of a K-of-N multisig script
+	sh(sortedmulti(K,KEY,...))
+	                         same as multi, but with the keys sorted
+	sstx(SCRIPT)             SCRIPT tagged with OP_SSTX
+	ssgen(SCRIPT)            SCRIPT tagged with OP_SSGEN
+	ssrtx(SCRIPT)            SCRIPT tagged with OP_SSRTX
+	sstxchange(SCRIPT)       SCRIPT tagged with OP_SSTXCHANGE
+	addr(ADDRESS)            the output script which pays to ADDRESS
+	raw(HEX)                 the hex-encoded output script itself
+
+The stake-tagged expressions may only contain pkh and sh expressions.
+
+A KEY is either a hex-encoded secp256k1 public key or an extended public key
+followed by an optional path of non-hardened child indexes, such as
+dpubXXX/0/1.  A path which ends with /* denotes a ranged key which is derived
+with the child indexes provided when the descriptor is expanded.  Descriptors
+are intended for watch-only use, so extended private keys and hardened
+derivation are not supported.
+
+Checksums
+
+The checksum detects errors in descriptors which are copied by hand and is
+compatible with the checksum used by other implementations of output script
+descriptors.  Parse verifies the checksum of descriptors which include one and
+the String method of a parsed descriptor always includes it.
+*/
+package descriptor
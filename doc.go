@@ -59,6 +59,11 @@ Application Options:
                             rpclimituser/rpclimitpass is specified
       --notls               Disable TLS for the RPC server -- NOTE: This is only
                             allowed if the RPC server is bound to localhost
+      --restlisten=         Add an interface/port to listen for unauthenticated
+                            read-only REST connections -- NOTE: The REST server
+                            is disabled unless at least one interface is
+                            specified (default port: 37461, testnet: 19111)
+      --restmaxclients=     Max number of concurrent REST clients (10)
       --nodnsseed           Disable DNS seeding for peers
       --externalip=         Add an ip to the list of local addresses we claim to
                             listen on to peers
@@ -76,6 +81,11 @@ Application Options:
       --simnet              Use the simulation test network
       --nocheckpoints       Disable built-in checkpoints.  Don't do this unless
                             you know what you're doing.
+      --assumevalid=        Hash of a block assumed to be valid.  Script
+                            validation is skipped for its ancestors while all
+                            other consensus rules are still enforced -- Use 0
+                            to validate all scripts (default: network-specific
+                            block, if any)
       --dbtype=             Database backend to use for the Block Chain (ffldb)
       --profile=            Enable HTTP profiling on given [addr:]port -- NOTE: port
                             must be between 1024 and 65536
@@ -119,7 +129,16 @@ Application Options:
 
       --sigcachemaxsize=    The maximum number of entries in the signature
                             verification cache.
+      --utxocachemaxsize=   The maximum size in MiB of the utxo cache (150)
+      --utxoflushinterval=  The maximum amount of time between flushes of the
+                            utxo cache to the database.  Valid time units are
+                            {s, m, h}.  Minimum 1 second (2m0s)
       --blocksonly          Do not accept transactions from remote peers.
+      --peerbloomfilters    Enable bloom filtering support for SPV peers
+      --headersonly         Only sync and validate block headers and verified
+                            committed filters instead of full blocks and serve
+                            the subset of RPCs that can be answered from them.
+                            A database must always be used in the same mode.
       --acceptnonstd        Accept and relay non-standard transactions to
                             the network regardless of the default settings
                             for the active network.
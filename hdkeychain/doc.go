@@ -47,6 +47,33 @@ the HardenedKeyStart constant + the hardened key number as the index to the
 Child function.  This provides the ability to cascade the keys into a tree and
 hence generate the hierarchical deterministic key chains.
 
+Deriving Paths
+
+Rather than calling Child for every level of the tree, a textual derivation path
+such as m/44'/42'/0'/0/5 may be derived with the DerivePath function.  Hardened
+components are marked with a trailing ' or h.  The ParsePath function converts
+such a path to a DerivationPath which can be derived with the Derive function.
+
+The DerivePublic and DerivePublicPath functions only derive extended public keys
+and are intended for watch-only use.  When a path contains hardened components,
+they return a PublicPathError which lists every component that is impossible to
+derive without the private key.
+
+The Fingerprint function returns the fingerprint of an extended key, which is
+the value its children report as their ParentFingerprint.  The KeyOrigin type
+combines a fingerprint with the path derived from that key so other tools are
+able to identify the signing key of a derived key.
+
+Mnemonic Seeds
+
+Seeds may be backed up as a list of words.  The EncodePGPMnemonic and
+DecodePGPMnemonic functions convert a seed to and from words of the PGP word
+list, which is the encoding used by wallets to display their seeds.  The
+EncodeBIP39Mnemonic and DecodeBIP39Mnemonic functions do the same for BIP0039
+mnemonics, and BIP39Seed derives the seed for a master node from a BIP0039
+mnemonic and passphrase.  All mnemonics include a checksum to detect mistyped
+words.
+
 Normal vs Hardened Child Extended Keys
 
 A private extended key can be used to derive both hardened and non-hardened
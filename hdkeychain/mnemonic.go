@@ -0,0 +1,247 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hdkeychain
+
+// References:
+//   [BIP39]: BIP0039 - Mnemonic code for generating deterministic keys
+//   https://github.com/bitcoin/bips/blob/master/bip-0039.mediawiki
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// MinBIP39EntropyBytes is the minimum number of bytes of entropy which
+	// may be encoded as a BIP0039 mnemonic.
+	MinBIP39EntropyBytes = 16 // 128 bits, 12 words
+
+	// MaxBIP39EntropyBytes is the maximum number of bytes of entropy which
+	// may be encoded as a BIP0039 mnemonic.
+	MaxBIP39EntropyBytes = 32 // 256 bits, 24 words
+
+	// bip39SeedIterations is the number of PBKDF2 iterations used to stretch
+	// a BIP0039 mnemonic into a seed.
+	bip39SeedIterations = 2048
+)
+
+var (
+	// ErrBadMnemonicChecksum describes an error in which the checksum encoded
+	// with a mnemonic does not match the calculated value.
+	ErrBadMnemonicChecksum = errors.New("bad mnemonic checksum")
+
+	// ErrInvalidMnemonicLen describes an error in which a mnemonic does not
+	// consist of a number of words that can encode a seed and its checksum.
+	ErrInvalidMnemonicLen = errors.New("invalid number of mnemonic words")
+
+	// ErrInvalidEntropyLen describes an error in which the entropy to encode
+	// as a BIP0039 mnemonic is not a multiple of 32 bits in the allowed
+	// range.
+	ErrInvalidEntropyLen = fmt.Errorf("entropy length must be a multiple "+
+		"of 32 bits between %d and %d bits", MinBIP39EntropyBytes*8,
+		MaxBIP39EntropyBytes*8)
+)
+
+// pgpWordIndexes and bip39WordIndexes map the lowercase form of every word of
+// the respective word lists back to their index.  The PGP words are keyed such
+// that the index of odd words is offset by 256 to keep both lists in one map.
+var (
+	pgpWordIndexes   map[string]uint16
+	bip39WordIndexes map[string]uint16
+)
+
+func init() {
+	pgpWordIndexes = make(map[string]uint16, len(pgpWordsEven)*2)
+	for i, word := range pgpWordsEven {
+		pgpWordIndexes[strings.ToLower(word)] = uint16(i)
+	}
+	for i, word := range pgpWordsOdd {
+		pgpWordIndexes[strings.ToLower(word)] = uint16(i + 256)
+	}
+	bip39WordIndexes = make(map[string]uint16, len(bip39Words))
+	for i, word := range bip39Words {
+		bip39WordIndexes[word] = uint16(i)
+	}
+}
+
+// pgpWord returns the PGP word encoding byte b at the given position of a
+// mnemonic.  Even positions use two syllable words and odd positions use three
+// syllable words so that transposed or omitted words are detected.
+func pgpWord(b byte, position int) string {
+	if position%2 == 0 {
+		return pgpWordsEven[b]
+	}
+	return pgpWordsOdd[b]
+}
+
+// pgpChecksum returns the checksum byte of a PGP word list mnemonic, which is
+// the first byte of the double SHA256 hash of the seed.
+func pgpChecksum(seed []byte) byte {
+	hash := sha256.Sum256(seed)
+	hash = sha256.Sum256(hash[:])
+	return hash[0]
+}
+
+// EncodePGPMnemonic encodes the provided seed as a space separated list of PGP
+// words.  An additional word is appended which encodes the first byte of the
+// double SHA256 hash of the seed as a checksum.  This is the same encoding
+// wallets use to display their seeds, so existing seed backups may be decoded
+// with DecodePGPMnemonic.
+func EncodePGPMnemonic(seed []byte) (string, error) {
+	if len(seed) < MinSeedBytes || len(seed) > MaxSeedBytes {
+		return "", ErrInvalidSeedLen
+	}
+
+	words := make([]string, 0, len(seed)+1)
+	for i, b := range seed {
+		words = append(words, pgpWord(b, i))
+	}
+	checksum := pgpChecksum(seed)
+	words = append(words, pgpWord(checksum, len(seed)))
+	return strings.Join(words, " "), nil
+}
+
+// DecodePGPMnemonic decodes a mnemonic created by EncodePGPMnemonic back to the
+// seed it encodes.  Words are matched case insensitively and may be separated
+// by any amount of whitespace.  ErrBadMnemonicChecksum is returned when the
+// final checksum word does not match the seed.
+func DecodePGPMnemonic(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < MinSeedBytes+1 || len(words) > MaxSeedBytes+1 {
+		return nil, ErrInvalidMnemonicLen
+	}
+
+	decoded := make([]byte, len(words))
+	for i, word := range words {
+		index, ok := pgpWordIndexes[strings.ToLower(word)]
+		if !ok {
+			return nil, fmt.Errorf("word %q is not in the PGP word "+
+				"list", word)
+		}
+		if int(index/256) != i%2 {
+			return nil, fmt.Errorf("word %q is not valid at position "+
+				"%d", word, i+1)
+		}
+		decoded[i] = byte(index)
+	}
+
+	seed := decoded[:len(decoded)-1]
+	if pgpChecksum(seed) != decoded[len(decoded)-1] {
+		zero(decoded)
+		return nil, ErrBadMnemonicChecksum
+	}
+	return seed, nil
+}
+
+// EncodeBIP39Mnemonic encodes the provided entropy as a space separated [BIP39]
+// mnemonic.  The entropy must be a multiple of 4 bytes between
+// MinBIP39EntropyBytes and MaxBIP39EntropyBytes.  The first len(entropy)/4 bits
+// of the SHA256 hash of the entropy are appended as a checksum, so every 3
+// words encode 4 bytes of entropy and 1 bit of checksum.
+func EncodeBIP39Mnemonic(entropy []byte) (string, error) {
+	if len(entropy) < MinBIP39EntropyBytes ||
+		len(entropy) > MaxBIP39EntropyBytes || len(entropy)%4 != 0 {
+
+		return "", ErrInvalidEntropyLen
+	}
+
+	// Append the checksum byte and only consume the bits of it which are
+	// part of the mnemonic.
+	hash := sha256.Sum256(entropy)
+	data := make([]byte, len(entropy)+1)
+	copy(data, entropy)
+	data[len(entropy)] = hash[0]
+	defer zero(data)
+
+	numWords := len(entropy) * 3 / 4
+	words := make([]string, numWords)
+	for i := 0; i < numWords; i++ {
+		words[i] = bip39Words[readBits11(data, i*11)]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// DecodeBIP39Mnemonic decodes a [BIP39] mnemonic back to the entropy it
+// encodes.  Words are matched case insensitively and may be separated by any
+// amount of whitespace.  ErrBadMnemonicChecksum is returned when the checksum
+// bits do not match the entropy.
+//
+// Note that the seed used to create a master node from a [BIP39] mnemonic is
+// derived with BIP39Seed rather than being the decoded entropy.
+func DecodeBIP39Mnemonic(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < MinBIP39EntropyBytes*3/4 ||
+		len(words) > MaxBIP39EntropyBytes*3/4 {
+
+		return nil, ErrInvalidMnemonicLen
+	}
+
+	data := make([]byte, len(words)*4/3+1)
+	for i, word := range words {
+		index, ok := bip39WordIndexes[strings.ToLower(word)]
+		if !ok {
+			return nil, fmt.Errorf("word %q is not in the BIP0039 "+
+				"word list", word)
+		}
+		writeBits11(data, i*11, index)
+	}
+
+	// The checksum consists of one bit for every 32 bits of entropy and
+	// is stored in the most significant bits of the final byte.
+	entropy := data[:len(words)*4/3]
+	checksumBits := uint(len(entropy) / 4)
+	mask := byte(0xff << (8 - checksumBits))
+	hash := sha256.Sum256(entropy)
+	if hash[0]&mask != data[len(entropy)]&mask {
+		zero(data)
+		return nil, ErrBadMnemonicChecksum
+	}
+	return entropy, nil
+}
+
+// BIP39Seed derives the seed for a master node from a [BIP39] mnemonic and an
+// optional passphrase.  The mnemonic is validated, including its checksum,
+// before the seed is derived with PBKDF2-HMAC-SHA512.  As required by [BIP39],
+// both the mnemonic and the passphrase are converted to Unicode NFKD form first
+// so passphrases with non-ASCII characters produce the same seed regardless of
+// how they were entered.
+func BIP39Seed(mnemonic, passphrase string) ([]byte, error) {
+	if _, err := DecodeBIP39Mnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	normalized := strings.ToLower(strings.Join(strings.Fields(mnemonic), " "))
+	normalized = norm.NFKD.String(normalized)
+	salt := norm.NFKD.String("mnemonic" + passphrase)
+	return pbkdf2.Key([]byte(normalized), []byte(salt), bip39SeedIterations,
+		64, sha512.New), nil
+}
+
+// readBits11 returns the 11 bits of data starting at the provided bit offset,
+// most significant bit first.
+func readBits11(data []byte, offset int) uint16 {
+	var v uint16
+	for i := offset; i < offset+11; i++ {
+		bit := (data[i/8] >> uint(7-i%8)) & 1
+		v = v<<1 | uint16(bit)
+	}
+	return v
+}
+
+// writeBits11 sets the 11 bits of data starting at the provided bit offset to
+// the low 11 bits of v, most significant bit first.
+func writeBits11(data []byte, offset int, v uint16) {
+	for i := 0; i < 11; i++ {
+		if v&(1<<uint(10-i)) != 0 {
+			pos := offset + i
+			data[pos/8] |= 1 << uint(7-pos%8)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hdkeychain_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/commanderu/cdrd/hdkeychain"
+)
+
+// TestPGPMnemonic ensures seeds round trip through PGP word list mnemonics and
+// that malformed mnemonics are rejected.
+func TestPGPMnemonic(t *testing.T) {
+	// The mnemonic of the all zero seed documented for the dev org address
+	// in the network parameters ends with the checksum word briefcase.
+	zeroSeed := make([]byte, 32)
+	mnemonic, err := hdkeychain.EncodePGPMnemonic(zeroSeed)
+	if err != nil {
+		t.Fatalf("EncodePGPMnemonic: unexpected error: %v", err)
+	}
+	want := strings.Repeat("aardvark adroitness ", 16) + "briefcase"
+	if mnemonic != want {
+		t.Fatalf("EncodePGPMnemonic: got %q, want %q", mnemonic, want)
+	}
+
+	seeds := []string{
+		"000102030405060708090a0b0c0d0e0f",
+		"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a2",
+		"b2a2f1d3c0f60b3d4ef4b7a5d3a1c3f2e1d0c9b8a7f6e5d4c3b2a1f0e9d8c7b6" +
+			"a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e9d8c7b6a5f4",
+	}
+	for _, seedHex := range seeds {
+		seed, _ := hex.DecodeString(seedHex)
+		mnemonic, err := hdkeychain.EncodePGPMnemonic(seed)
+		if err != nil {
+			t.Errorf("EncodePGPMnemonic(%s): unexpected error: %v",
+				seedHex, err)
+			continue
+		}
+		if n := len(strings.Fields(mnemonic)); n != len(seed)+1 {
+			t.Errorf("EncodePGPMnemonic(%s): got %d words, want %d",
+				seedHex, n, len(seed)+1)
+		}
+
+		// Decoding must be insensitive to case and extra whitespace.
+		mangled := "  " + strings.ToUpper(strings.Replace(mnemonic, " ",
+			"\n\t", -1))
+		got, err := hdkeychain.DecodePGPMnemonic(mangled)
+		if err != nil {
+			t.Errorf("DecodePGPMnemonic(%s): unexpected error: %v",
+				seedHex, err)
+			continue
+		}
+		if !bytes.Equal(got, seed) {
+			t.Errorf("DecodePGPMnemonic: got %x, want %s", got,
+				seedHex)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		mnemonic string
+	}{
+		{"too short", strings.Repeat("aardvark adroitness ", 4) + "aardvark"},
+		{"unknown word", strings.Replace(want, "adroitness", "wombat", 1)},
+		{"even word at odd position", strings.Replace(want, "adroitness",
+			"absurd", 1)},
+		{"odd word at even position", strings.Replace(want, "aardvark",
+			"adviser", 1)},
+		{"bad checksum", strings.Replace(want, "briefcase", "brickyard", 1)},
+		{"swapped words", "adroitness aardvark " +
+			strings.Repeat("aardvark adroitness ", 15) + "briefcase"},
+	}
+	for _, test := range tests {
+		if _, err := hdkeychain.DecodePGPMnemonic(test.mnemonic); err == nil {
+			t.Errorf("%s: DecodePGPMnemonic did not return an error",
+				test.name)
+		}
+	}
+	if _, err := hdkeychain.EncodePGPMnemonic(make([]byte, 8)); err != hdkeychain.ErrInvalidSeedLen {
+		t.Errorf("EncodePGPMnemonic: unexpected error for short seed: %v",
+			err)
+	}
+}
+
+// TestBIP0039Vectors tests a selection of the vectors provided by the
+// reference implementation of [BIP39] to ensure the encoding and seed
+// derivation work as intended.
+func TestBIP0039Vectors(t *testing.T) {
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+			seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			entropy:  "80808080808080808080808080808080",
+			mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+			seed:     "d71de856f81a8acc65e6fc851a38d4d7ec216fd0796d0a6827a3ad6ed5511a30fa280f12eb2e47ed2ac03b5c462a0358d18d69fe4f985ec81778c1b370b652a8",
+		},
+		{
+			entropy:  "9e885d952ad362caeb4efe34a8e91bd2",
+			mnemonic: "ozone drill grab fiber curtain grace pudding thank cruise elder eight picnic",
+			seed:     "274ddc525802f7c828d8ef7ddbcdc5304e87ac3535913611fbbfa986d0c9e5476c91689f9c8a54fd55bd38606aa6a8595ad213d4c9c9f9aca3fb217069a41028",
+		},
+		{
+			entropy:  "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+			seed:     "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+		},
+	}
+
+	for i, test := range tests {
+		entropy, _ := hex.DecodeString(test.entropy)
+		mnemonic, err := hdkeychain.EncodeBIP39Mnemonic(entropy)
+		if err != nil {
+			t.Errorf("#%d: EncodeBIP39Mnemonic: unexpected error: %v",
+				i, err)
+			continue
+		}
+		if mnemonic != test.mnemonic {
+			t.Errorf("#%d: EncodeBIP39Mnemonic: got %q, want %q", i,
+				mnemonic, test.mnemonic)
+			continue
+		}
+
+		decoded, err := hdkeychain.DecodeBIP39Mnemonic(mnemonic)
+		if err != nil {
+			t.Errorf("#%d: DecodeBIP39Mnemonic: unexpected error: %v",
+				i, err)
+			continue
+		}
+		if !bytes.Equal(decoded, entropy) {
+			t.Errorf("#%d: DecodeBIP39Mnemonic: got %x, want %x", i,
+				decoded, entropy)
+		}
+
+		seed, err := hdkeychain.BIP39Seed(mnemonic, "TREZOR")
+		if err != nil {
+			t.Errorf("#%d: BIP39Seed: unexpected error: %v", i, err)
+			continue
+		}
+		if hex.EncodeToString(seed) != test.seed {
+			t.Errorf("#%d: BIP39Seed: got %x, want %s", i, seed,
+				test.seed)
+		}
+	}
+}
+
+// TestBIP0039Passphrase ensures passphrases with non-ASCII characters are
+// normalized as required by [BIP39] so composed, decomposed, and compatibility
+// forms of the same passphrase all derive the same seed.
+func TestBIP0039Passphrase(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon " +
+		"abandon abandon abandon abandon abandon about"
+	tests := []struct {
+		name       string
+		passphrase string
+		seed       string
+	}{
+		{
+			name:       "composed",
+			passphrase: "\u0395\u03bb\u03bb\u03b7\u03bd\u03b9\u03ba\u03ac \u00f1and\u00fa",
+			seed:       "bfc4a30a48bb0879d4b78420d4bbfe88b631f48bd3291e3f09c6d74720a11ed2aaad38f5ff1056d38ccc1cc863a57d45dcc459d758c7d46137f0d2380c0aaa55",
+		},
+		{
+			name:       "decomposed",
+			passphrase: "\u0395\u03bb\u03bb\u03b7\u03bd\u03b9\u03ba\u03b1\u0301 n\u0303andu\u0301",
+			seed:       "bfc4a30a48bb0879d4b78420d4bbfe88b631f48bd3291e3f09c6d74720a11ed2aaad38f5ff1056d38ccc1cc863a57d45dcc459d758c7d46137f0d2380c0aaa55",
+		},
+		{
+			name:       "compatibility",
+			passphrase: "\u30d1\u30b9\u30d5\u30ec\u30fc\u30ba \ufb01",
+			seed:       "357f561e67a83fa3db3291b9a7a51923d601ffff00d184b369434224a1307d13b06477cb3de8fbb3cec90a83933224879f59cf15e02c9d4e24fb181c59c74f1b",
+		},
+	}
+
+	for _, test := range tests {
+		seed, err := hdkeychain.BIP39Seed(mnemonic, test.passphrase)
+		if err != nil {
+			t.Errorf("%s: BIP39Seed: unexpected error: %v", test.name,
+				err)
+			continue
+		}
+		if hex.EncodeToString(seed) != test.seed {
+			t.Errorf("%s: BIP39Seed: got %x, want %s", test.name, seed,
+				test.seed)
+		}
+	}
+}
+
+// TestBIP0039Errors ensures malformed entropy and mnemonics are rejected.
+func TestBIP0039Errors(t *testing.T) {
+	for _, n := range []int{0, 12, 17, 36} {
+		_, err := hdkeychain.EncodeBIP39Mnemonic(make([]byte, n))
+		if err != hdkeychain.ErrInvalidEntropyLen {
+			t.Errorf("EncodeBIP39Mnemonic(%d bytes): unexpected "+
+				"error: %v", n, err)
+		}
+	}
+
+	abandon := strings.Repeat("abandon ", 11)
+	tests := []struct {
+		name     string
+		mnemonic string
+		err      error
+	}{
+		{"too few words", "abandon abandon about",
+			hdkeychain.ErrInvalidMnemonicLen},
+		{"not a multiple of 3", abandon + "abandon about",
+			hdkeychain.ErrInvalidMnemonicLen},
+		{"bad checksum", abandon + "abandon",
+			hdkeychain.ErrBadMnemonicChecksum},
+		{"unknown word", abandon + "aardvark", nil},
+	}
+	for _, test := range tests {
+		_, err := hdkeychain.DecodeBIP39Mnemonic(test.mnemonic)
+		if err == nil || (test.err != nil && err != test.err) {
+			t.Errorf("%s: DecodeBIP39Mnemonic: unexpected error: %v",
+				test.name, err)
+		}
+		if _, err := hdkeychain.BIP39Seed(test.mnemonic, ""); err == nil {
+			t.Errorf("%s: BIP39Seed did not return an error",
+				test.name)
+		}
+	}
+}
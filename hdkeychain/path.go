@@ -0,0 +1,275 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hdkeychain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/commanderu/cdrd/cdrutil"
+)
+
+var (
+	// ErrInvalidPath describes an error in which a textual derivation path
+	// could not be parsed.
+	ErrInvalidPath = errors.New("invalid derivation path")
+
+	// ErrNotMasterKey describes an error in which the caller attempted to
+	// derive a path which is relative to the master node (starts with "m")
+	// from an extended key which is not a master node.
+	ErrNotMasterKey = errors.New("derivation path starting at the master " +
+		"node requires a master extended key")
+)
+
+// DerivationPath describes a sequence of child indexes to derive.  Hardened
+// children are represented by indexes at or above HardenedKeyStart.
+type DerivationPath []uint32
+
+// ParsePath parses a textual derivation path such as m/44'/42'/0'/0/5.  Path
+// components are separated by slashes and hardened components are marked with
+// a trailing ', h or H, in which case the component is offset by
+// HardenedKeyStart.  The path may optionally start with "m" to denote that it
+// is relative to the master node, and the path "m" on its own describes the
+// master node itself.
+//
+// The returned bool reports whether the path started with "m".
+func ParsePath(path string) (DerivationPath, bool, error) {
+	parts := strings.Split(path, "/")
+	absolute := parts[0] == "m" || parts[0] == "M"
+	if absolute {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 {
+		return DerivationPath{}, absolute, nil
+	}
+
+	p := make(DerivationPath, 0, len(parts))
+	for _, part := range parts {
+		var offset uint32
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") ||
+			strings.HasSuffix(part, "H") {
+
+			part = part[:len(part)-1]
+			offset = HardenedKeyStart
+		}
+
+		// Only plain decimal numbers are allowed and the number must be
+		// less than HardenedKeyStart since the hardened form is
+		// expressed with the suffix.
+		if part == "" || part[0] == '+' || part[0] == '-' {
+			return nil, false, ErrInvalidPath
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || index >= HardenedKeyStart {
+			return nil, false, ErrInvalidPath
+		}
+		p = append(p, uint32(index)+offset)
+	}
+	return p, absolute, nil
+}
+
+// String returns the textual form of the derivation path relative to the
+// master node using the ' suffix for hardened components.  The result can be
+// parsed with ParsePath.
+func (p DerivationPath) String() string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, index := range p {
+		sb.WriteString("/")
+		sb.WriteString(formatIndex(index))
+	}
+	return sb.String()
+}
+
+// formatIndex returns the textual form of a single path component.
+func formatIndex(index uint32) string {
+	if index >= HardenedKeyStart {
+		return strconv.FormatUint(uint64(index-HardenedKeyStart), 10) + "'"
+	}
+	return strconv.FormatUint(uint64(index), 10)
+}
+
+// Hardened returns the positions of all hardened components of the path.
+// These components can only be derived from a private extended key.
+func (p DerivationPath) Hardened() []int {
+	var hardened []int
+	for i, index := range p {
+		if index >= HardenedKeyStart {
+			hardened = append(hardened, i)
+		}
+	}
+	return hardened
+}
+
+// Derive returns the extended key at the provided path relative to this
+// extended key by repeatedly calling Child.  An empty path returns the key
+// itself.
+//
+// As with Child, ErrInvalidChild is returned in the extremely unlikely case any
+// component of the path is an invalid child and the caller should then choose
+// another path.
+func (k *ExtendedKey) Derive(path DerivationPath) (*ExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		var err error
+		key, err = key.Child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// DerivePath parses the textual derivation path with ParsePath and returns
+// the extended key at that path.  Paths starting with "m" may only be derived
+// from a master node, while paths without it are relative to this extended
+// key.
+//
+// For example, the external address key at index 5 of the first account of a
+// BIP0044 wallet is derived from the master node with the path m/44'/42'/0'/0/5.
+func (k *ExtendedKey) DerivePath(path string) (*ExtendedKey, error) {
+	p, absolute, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if absolute && k.depth != 0 {
+		return nil, ErrNotMasterKey
+	}
+	return k.Derive(p)
+}
+
+// PublicPathError describes a derivation path which can not be derived from an
+// extended public key because it contains hardened components.
+type PublicPathError struct {
+	// Path is the requested derivation path.
+	Path DerivationPath
+
+	// Hardened contains the positions of the components of Path which are
+	// impossible to derive without the private key.
+	Hardened []int
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *PublicPathError) Error() string {
+	components := make([]string, len(e.Hardened))
+	for i, pos := range e.Hardened {
+		components[i] = formatIndex(e.Path[pos])
+	}
+	return fmt.Sprintf("path %s requires the private key to derive "+
+		"hardened components %s", e.Path, strings.Join(components, ", "))
+}
+
+// DerivePublic returns the extended public key at the provided path relative
+// to this extended key.  The derivation only makes use of the public part of
+// this extended key, so it behaves identically whether or not this is a private
+// extended key.  This makes it suitable for watch-only derivation.
+//
+// When the path contains hardened components, a *PublicPathError which lists
+// every component that is impossible to derive without the private key is
+// returned.
+func (k *ExtendedKey) DerivePublic(path DerivationPath) (*ExtendedKey, error) {
+	if hardened := path.Hardened(); len(hardened) != 0 {
+		return nil, &PublicPathError{Path: path, Hardened: hardened}
+	}
+	pub, err := k.Neuter()
+	if err != nil {
+		return nil, err
+	}
+	return pub.Derive(path)
+}
+
+// DerivePublicPath is the same as DerivePublic except it parses the textual
+// derivation path with ParsePath.  As a public extended key carries no record
+// of being a master node, paths starting with "m" are only accepted for
+// extended keys at depth zero.
+func (k *ExtendedKey) DerivePublicPath(path string) (*ExtendedKey, error) {
+	p, absolute, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if absolute && k.depth != 0 {
+		return nil, ErrNotMasterKey
+	}
+	return k.DerivePublic(p)
+}
+
+// Fingerprint returns the fingerprint of this extended key, which is the first
+// 4 bytes of the RIPEMD160(BLAKE256(pubKey)).  It is the same value that
+// children of this extended key report as their ParentFingerprint.
+func (k *ExtendedKey) Fingerprint() uint32 {
+	return binary.BigEndian.Uint32(cdrutil.Hash160(k.pubKeyBytes())[:4])
+}
+
+// Depth returns the number of derivations from the master node to this
+// extended key.
+func (k *ExtendedKey) Depth() uint16 {
+	return k.depth
+}
+
+// ChildIndex returns the index at which this extended key was derived from its
+// parent.  It is zero for master nodes.
+func (k *ExtendedKey) ChildIndex() uint32 {
+	return k.childNum
+}
+
+// KeyOrigin describes where a derived extended key originates from by means of
+// the fingerprint of the extended key it was derived from and the path that
+// was derived.  This allows other tools to identify the key that must be used
+// to sign for a derived key, even when only extended public keys are shared.
+type KeyOrigin struct {
+	Fingerprint uint32
+	Path        DerivationPath
+}
+
+// String returns the key origin in the form used by output script descriptors,
+// which is the hex encoded fingerprint followed by the derivation path, for
+// example d34db33f/44'/42'/0'.
+func (o *KeyOrigin) String() string {
+	s := fmt.Sprintf("%08x", o.Fingerprint)
+	for _, index := range o.Path {
+		s += "/" + formatIndex(index)
+	}
+	return s
+}
+
+// ParseKeyOrigin parses a key origin in the form returned by KeyOrigin.String.
+func ParseKeyOrigin(origin string) (*KeyOrigin, error) {
+	parts := strings.SplitN(origin, "/", 2)
+	if len(parts[0]) != 8 {
+		return nil, fmt.Errorf("invalid key origin fingerprint %q",
+			parts[0])
+	}
+	fp, err := strconv.ParseUint(parts[0], 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key origin fingerprint %q",
+			parts[0])
+	}
+	o := &KeyOrigin{Fingerprint: uint32(fp), Path: DerivationPath{}}
+	if len(parts) == 2 {
+		path, absolute, err := ParsePath(parts[1])
+		if err != nil || absolute {
+			return nil, ErrInvalidPath
+		}
+		o.Path = path
+	}
+	return o, nil
+}
+
+// DeriveWithOrigin derives the provided path from this extended key and
+// returns the derived key along with its origin relative to this extended key.
+func (k *ExtendedKey) DeriveWithOrigin(path DerivationPath) (*ExtendedKey, *KeyOrigin, error) {
+	child, err := k.Derive(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	origin := &KeyOrigin{
+		Fingerprint: k.Fingerprint(),
+		Path:        append(DerivationPath(nil), path...),
+	}
+	return child, origin, nil
+}
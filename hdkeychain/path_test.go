@@ -0,0 +1,199 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package hdkeychain_test
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/hdkeychain"
+)
+
+// TestParsePath ensures textual derivation paths are parsed as expected and
+// invalid paths are rejected.
+func TestParsePath(t *testing.T) {
+	hkStart := uint32(hdkeychain.HardenedKeyStart)
+	tests := []struct {
+		path     string
+		want     hdkeychain.DerivationPath
+		absolute bool
+		str      string
+		err      error
+	}{
+		{"m", hdkeychain.DerivationPath{}, true, "m", nil},
+		{"m/44'/42'/0'/0/5", hdkeychain.DerivationPath{hkStart + 44,
+			hkStart + 42, hkStart, 0, 5}, true, "m/44'/42'/0'/0/5", nil},
+		{"M/44h/42H/0h", hdkeychain.DerivationPath{hkStart + 44,
+			hkStart + 42, hkStart}, true, "m/44'/42'/0'", nil},
+		{"0/2147483647", hdkeychain.DerivationPath{0, hkStart - 1}, false,
+			"m/0/2147483647", nil},
+		{"2147483647'", hdkeychain.DerivationPath{^uint32(0)}, false,
+			"m/2147483647'", nil},
+		{"", nil, false, "", hdkeychain.ErrInvalidPath},
+		{"m/", nil, false, "", hdkeychain.ErrInvalidPath},
+		{"m//1", nil, false, "", hdkeychain.ErrInvalidPath},
+		{"m/1/m", nil, false, "", hdkeychain.ErrInvalidPath},
+		{"m/-1", nil, false, "", hdkeychain.ErrInvalidPath},
+		{"m/+1", nil, false, "", hdkeychain.ErrInvalidPath},
+		{"m/1''", nil, false, "", hdkeychain.ErrInvalidPath},
+		{"m/2147483648", nil, false, "", hdkeychain.ErrInvalidPath},
+		{"m/0x10", nil, false, "", hdkeychain.ErrInvalidPath},
+	}
+
+	for _, test := range tests {
+		path, absolute, err := hdkeychain.ParsePath(test.path)
+		if err != test.err {
+			t.Errorf("ParsePath(%q): unexpected error: got %v, want %v",
+				test.path, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(path, test.want) || absolute != test.absolute {
+			t.Errorf("ParsePath(%q): got %v (absolute %v), want %v "+
+				"(absolute %v)", test.path, []uint32(path), absolute,
+				[]uint32(test.want), test.absolute)
+		}
+		if path.String() != test.str {
+			t.Errorf("ParsePath(%q): got string %q, want %q",
+				test.path, path.String(), test.str)
+		}
+	}
+}
+
+// TestDerivePath ensures deriving textual paths produces the same keys as
+// repeatedly deriving children, that public derivation reports the hardened
+// components it is unable to derive, and that key origins are reported
+// correctly.
+func TestDerivePath(t *testing.T) {
+	hkStart := uint32(hdkeychain.HardenedKeyStart)
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewMaster: unexpected error: %v", err)
+	}
+
+	// Derive m/0H/1/2H/2 from the [BIP32] test vector 1 by hand.
+	want := master
+	for _, index := range []uint32{hkStart, 1, hkStart + 2, 2} {
+		want, err = want.Child(index)
+		if err != nil {
+			t.Fatalf("Child: unexpected error: %v", err)
+		}
+	}
+	got, err := master.DerivePath("m/0'/1/2h/2")
+	if err != nil {
+		t.Fatalf("DerivePath: unexpected error: %v", err)
+	}
+	if got.String() != want.String() {
+		t.Fatalf("DerivePath: got %s, want %s", got, want)
+	}
+	if got.Depth() != 4 || got.ChildIndex() != 2 {
+		t.Errorf("DerivePath: got depth %d and index %d, want 4 and 2",
+			got.Depth(), got.ChildIndex())
+	}
+
+	// Relative paths are derived from any key, while absolute paths are
+	// only derived from master nodes.
+	account, err := master.DerivePath("m/0'/1")
+	if err != nil {
+		t.Fatalf("DerivePath: unexpected error: %v", err)
+	}
+	got, err = account.DerivePath("2'/2")
+	if err != nil {
+		t.Fatalf("DerivePath: unexpected error: %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("DerivePath: got %s, want %s", got, want)
+	}
+	if _, err := account.DerivePath("m/2'/2"); err != hdkeychain.ErrNotMasterKey {
+		t.Errorf("DerivePath: unexpected error for absolute path from "+
+			"non-master key: %v", err)
+	}
+
+	// Public derivation of non-hardened paths matches the neutered result
+	// of private derivation for both private and public parent keys.
+	accountPub, err := account.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+	wantPriv, err := account.DerivePath("0/5")
+	if err != nil {
+		t.Fatalf("DerivePath: unexpected error: %v", err)
+	}
+	wantPub, err := wantPriv.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: unexpected error: %v", err)
+	}
+	for _, parent := range []*hdkeychain.ExtendedKey{account, accountPub} {
+		got, err := parent.DerivePublicPath("0/5")
+		if err != nil {
+			t.Errorf("DerivePublicPath: unexpected error: %v", err)
+			continue
+		}
+		if got.IsPrivate() || got.String() != wantPub.String() {
+			t.Errorf("DerivePublicPath: got %s, want %s", got, wantPub)
+		}
+	}
+
+	// Public derivation of hardened components must report every one of
+	// them.
+	_, err = master.DerivePublicPath("m/44'/42'/0'/0/5")
+	pathErr, ok := err.(*hdkeychain.PublicPathError)
+	if !ok {
+		t.Fatalf("DerivePublicPath: unexpected error type %T: %v", err,
+			err)
+	}
+	if !reflect.DeepEqual(pathErr.Hardened, []int{0, 1, 2}) {
+		t.Errorf("DerivePublicPath: got hardened positions %v, want "+
+			"[0 1 2]", pathErr.Hardened)
+	}
+	wantErr := "path m/44'/42'/0'/0/5 requires the private key to " +
+		"derive hardened components 44', 42', 0'"
+	if pathErr.Error() != wantErr {
+		t.Errorf("DerivePublicPath: got error %q, want %q", pathErr,
+			wantErr)
+	}
+
+	// The fingerprint of a key is the parent fingerprint of its children
+	// and is reported as the origin of derived keys.
+	path := hdkeychain.DerivationPath{hkStart + 44, hkStart + 42, hkStart}
+	child, origin, err := master.DeriveWithOrigin(path)
+	if err != nil {
+		t.Fatalf("DeriveWithOrigin: unexpected error: %v", err)
+	}
+	first, err := master.Child(hkStart + 44)
+	if err != nil {
+		t.Fatalf("Child: unexpected error: %v", err)
+	}
+	if first.ParentFingerprint() != master.Fingerprint() ||
+		origin.Fingerprint != master.Fingerprint() {
+
+		t.Errorf("Fingerprint: got %08x, want parent fingerprint %08x",
+			master.Fingerprint(), first.ParentFingerprint())
+	}
+	if child.Depth() != 3 || !reflect.DeepEqual(origin.Path, path) {
+		t.Errorf("DeriveWithOrigin: unexpected origin %v", origin)
+	}
+	parsed, err := hdkeychain.ParseKeyOrigin(origin.String())
+	if err != nil {
+		t.Fatalf("ParseKeyOrigin(%q): unexpected error: %v",
+			origin.String(), err)
+	}
+	if !reflect.DeepEqual(parsed, origin) {
+		t.Errorf("ParseKeyOrigin(%q): got %v, want %v", origin.String(),
+			parsed, origin)
+	}
+	for _, s := range []string{"", "0011223", "zz112233", "00112233/",
+		"00112233/m/1"} {
+
+		if _, err := hdkeychain.ParseKeyOrigin(s); err == nil {
+			t.Errorf("ParseKeyOrigin(%q): did not return an error", s)
+		}
+	}
+}
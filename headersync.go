@@ -0,0 +1,350 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync/atomic"
+
+	"github.com/commanderu/cdrd/blockchain"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/wire"
+)
+
+const (
+	// maxCFiltersPerBatch is the maximum number of committed filters that
+	// are requested from the sync peer at once in headers-only mode.  The
+	// filters of a batch are verified against the filter headers of the
+	// batch and stored together.
+	maxCFiltersPerBatch = 500
+)
+
+// cfHeadersMsg packages a commanderu cfheaders message and the peer it came
+// from together so the block handler has access to that information.
+type cfHeadersMsg struct {
+	headers *wire.MsgCFHeaders
+	peer    *serverPeer
+}
+
+// cfilterMsg packages a commanderu cfilter message and the peer it came from
+// together so the block handler has access to that information.
+type cfilterMsg struct {
+	filter *wire.MsgCFilter
+	peer   *serverPeer
+}
+
+// cfilterBatch houses the state of a batch of committed filters which is being
+// downloaded from the sync peer in headers-only mode.
+type cfilterBatch struct {
+	peer     *serverPeer
+	tip      chainhash.Hash
+	hashes   []chainhash.Hash
+	index    map[chainhash.Hash]int
+	headers  []chainhash.Hash
+	filters  [][]byte
+	received int
+}
+
+// isHeadersOnlySyncCandidate returns whether or not the peer is a candidate to
+// consider syncing from in headers-only mode.  The peer must be a full node
+// which serves committed filters.
+func isHeadersOnlySyncCandidate(sp *serverPeer) bool {
+	services := wire.SFNodeNetwork | wire.SFNodeCF
+	return sp.Services()&services == services
+}
+
+// startHeadersOnlySync starts downloading the headers that follow the passed
+// locator from the passed peer in headers-only mode.
+func (b *blockManager) startHeadersOnlySync(sp *serverPeer, locator blockchain.BlockLocator) {
+	err := sp.PushGetHeadersMsg(locator, &zeroHash)
+	if err != nil {
+		bmgrLog.Errorf("Failed to push getheadermsg for the latest "+
+			"blocks: %v", err)
+		return
+	}
+	best := b.chain.BestSnapshot()
+	bmgrLog.Infof("Downloading headers for blocks %d to %d from peer %s",
+		best.Height+1, sp.LastBlock(), sp.Addr())
+	b.syncPeer = sp
+}
+
+// updateHeadersOnlyChainState updates the chain state associated with the
+// block manager in headers-only mode.  Only the best block and the next stake
+// difficulty are available since there is no ticket database.
+func (b *blockManager) updateHeadersOnlyChainState() {
+	best := b.chain.BestSnapshot()
+	nextStakeDiff, err := b.chain.CalcNextRequiredStakeDifficulty()
+	if err != nil {
+		bmgrLog.Warnf("Failed to get next stake difficulty "+
+			"calculation: %v", err)
+		return
+	}
+	b.updateChainState(&best.Hash, best.Height, [6]byte{}, 0,
+		nextStakeDiff, nil, nil, b.chain.BestPrevHash())
+}
+
+// handleHeadersOnlyHeadersMsg handles block headers messages from all peers in
+// headers-only mode.  The headers are validated and connected by the chain, and
+// more headers are requested when the maximum number of headers was received.
+// Otherwise the headers of the peer have been caught up with, so the committed
+// filters of the new main chain blocks are downloaded.
+func (b *blockManager) handleHeadersOnlyHeadersMsg(hmsg *headersMsg) {
+	msg := hmsg.headers
+	numHeaders := len(msg.Headers)
+	if numHeaders == 0 {
+		if hmsg.peer == b.syncPeer {
+			b.fetchCFilterHeaders(hmsg.peer)
+		}
+		return
+	}
+
+	// Process all of the received headers.  Headers that are already known
+	// are skipped, since they are expected when the peer announces a block
+	// that was learned from another peer or the locator is stale.
+	var finalHash chainhash.Hash
+	var numConnected int
+	for _, header := range msg.Headers {
+		finalHash = header.BlockHash()
+		_, err := b.chain.ProcessBlockHeader(header, blockchain.BFNone)
+		if err != nil {
+			rerr, ok := err.(blockchain.RuleError)
+			if ok && rerr.ErrorCode == blockchain.ErrDuplicateBlock {
+				continue
+			}
+			if ok && rerr.ErrorCode == blockchain.ErrMissingParent &&
+				hmsg.peer != b.syncPeer {
+
+				bmgrLog.Debugf("Ignoring headers from peer %s that do "+
+					"not connect: %v", hmsg.peer.Addr(), err)
+				return
+			}
+			bmgrLog.Warnf("Rejected block header %v from peer %s: %v "+
+				"-- disconnecting", finalHash, hmsg.peer.Addr(), err)
+			hmsg.peer.Disconnect()
+			return
+		}
+		numConnected++
+	}
+	if numConnected > 0 {
+		best := b.chain.BestSnapshot()
+		b.updateHeadersOnlyChainState()
+		bmgrLog.Infof("Processed %d block headers from peer %s (best "+
+			"height %d, hash %v)", numConnected, hmsg.peer.Addr(),
+			best.Height, best.Hash)
+	}
+	if height, err := b.chain.BlockHeightByHash(&finalHash); err == nil {
+		hmsg.peer.UpdateLastBlockHeight(height)
+	}
+
+	// Request the next batch of headers from the peer when it sent as many
+	// headers as possible, since it likely has more.
+	if numHeaders == wire.MaxBlockHeadersPerMsg {
+		locator := blockchain.BlockLocator([]*chainhash.Hash{&finalHash})
+		err := hmsg.peer.PushGetHeadersMsg(locator, &zeroHash)
+		if err != nil {
+			bmgrLog.Warnf("Failed to send getheaders message to "+
+				"peer %s: %v", hmsg.peer.Addr(), err)
+		}
+		return
+	}
+
+	if hmsg.peer == b.syncPeer || b.current() {
+		b.fetchCFilterHeaders(hmsg.peer)
+	}
+}
+
+// handleHeadersOnlyInvMsg handles the block announcements of inv messages in
+// headers-only mode by requesting the headers that lead to the final announced
+// block when it is not already known.  Transactions are never requested.
+func (b *blockManager) handleHeadersOnlyInvMsg(imsg *invMsg, lastBlock int) {
+	if lastBlock == -1 {
+		return
+	}
+	hash := &imsg.inv.InvList[lastBlock].Hash
+	haveHeader, err := b.chain.HaveBlock(hash)
+	if err != nil {
+		bmgrLog.Warnf("Unexpected failure when checking for existing "+
+			"header %v: %v", hash, err)
+		return
+	}
+	if haveHeader {
+		return
+	}
+
+	locator, err := b.chain.LatestBlockLocator()
+	if err != nil {
+		bmgrLog.Errorf("Failed to get block locator for the latest "+
+			"block: %v", err)
+		return
+	}
+	err = imsg.peer.PushGetHeadersMsg(locator, hash)
+	if err != nil {
+		bmgrLog.Warnf("Failed to send getheaders message to peer %s: %v",
+			imsg.peer.Addr(), err)
+	}
+}
+
+// fetchCFilterHeaders requests the committed filter headers for the next batch
+// of main chain blocks whose committed filters have not been verified yet from
+// the passed peer unless a batch is already being downloaded.
+func (b *blockManager) fetchCFilterHeaders(sp *serverPeer) {
+	if b.cfBatch != nil || !isHeadersOnlySyncCandidate(sp) {
+		return
+	}
+
+	tipHash, tipHeight := b.chain.CFilterTip()
+	hashes, err := b.chain.HeightRange(tipHeight+1,
+		tipHeight+1+maxCFiltersPerBatch)
+	if err != nil {
+		bmgrLog.Errorf("Failed to fetch the blocks that follow the "+
+			"committed filter tip: %v", err)
+		return
+	}
+	if len(hashes) == 0 {
+		return
+	}
+
+	msg := wire.NewMsgGetCFHeaders()
+	msg.AddBlockLocatorHash(&tipHash)
+	msg.HashStop = hashes[len(hashes)-1]
+	msg.FilterType = wire.GCSFilterRegular
+	sp.QueueMessage(msg, nil)
+
+	index := make(map[chainhash.Hash]int, len(hashes))
+	for i := range hashes {
+		index[hashes[i]] = i
+	}
+	b.cfBatch = &cfilterBatch{
+		peer:   sp,
+		tip:    tipHash,
+		hashes: hashes,
+		index:  index,
+	}
+	bmgrLog.Debugf("Downloading committed filter headers for blocks %d "+
+		"to %d from peer %s", tipHeight+1, tipHeight+int64(len(hashes)),
+		sp.Addr())
+}
+
+// handleCFHeadersMsg handles cfheaders messages from all peers in headers-only
+// mode.  The filter headers for the batch being downloaded are recorded and the
+// committed filters of the batch are requested.
+func (b *blockManager) handleCFHeadersMsg(cfhmsg *cfHeadersMsg) {
+	batch := b.cfBatch
+	if batch == nil || cfhmsg.peer != batch.peer || batch.headers != nil {
+		bmgrLog.Debugf("Ignoring unrequested committed filter headers "+
+			"from peer %s", cfhmsg.peer.Addr())
+		return
+	}
+
+	msg := cfhmsg.headers
+	if msg.FilterType != wire.GCSFilterRegular ||
+		msg.StopHash != batch.hashes[len(batch.hashes)-1] ||
+		len(msg.HeaderHashes) != len(batch.hashes) {
+
+		bmgrLog.Warnf("Received committed filter headers that do not "+
+			"match the request from peer %s -- disconnecting",
+			cfhmsg.peer.Addr())
+		b.cfBatch = nil
+		cfhmsg.peer.Disconnect()
+		return
+	}
+
+	batch.headers = make([]chainhash.Hash, len(msg.HeaderHashes))
+	for i, header := range msg.HeaderHashes {
+		batch.headers[i] = *header
+	}
+	batch.filters = make([][]byte, len(batch.hashes))
+	for i := range batch.hashes {
+		msg := wire.NewMsgGetCFilter(&batch.hashes[i],
+			wire.GCSFilterRegular)
+		cfhmsg.peer.QueueMessage(msg, nil)
+	}
+}
+
+// handleCFilterMsg handles cfilter messages from all peers in headers-only
+// mode.  Once all of the committed filters of the batch being downloaded have
+// been received, they are verified against the filter headers and stored by
+// the chain and the next batch is requested.
+func (b *blockManager) handleCFilterMsg(cfmsg *cfilterMsg) {
+	batch := b.cfBatch
+	if batch == nil || cfmsg.peer != batch.peer || batch.headers == nil {
+		bmgrLog.Debugf("Ignoring unrequested committed filter from "+
+			"peer %s", cfmsg.peer.Addr())
+		return
+	}
+	msg := cfmsg.filter
+	i, ok := batch.index[msg.BlockHash]
+	if !ok || msg.FilterType != wire.GCSFilterRegular ||
+		batch.filters[i] != nil {
+
+		bmgrLog.Debugf("Ignoring unrequested committed filter for "+
+			"block %v from peer %s", msg.BlockHash, cfmsg.peer.Addr())
+		return
+	}
+	batch.filters[i] = msg.Data
+	batch.received++
+	if batch.received < len(batch.hashes) {
+		return
+	}
+	b.cfBatch = nil
+
+	// Discard the batch when the blocks are no longer the ones that follow
+	// the committed filter tip in the main chain due to a reorganization.
+	tipHash, _ := b.chain.CFilterTip()
+	finalHash := &batch.hashes[len(batch.hashes)-1]
+	inMainChain, err := b.chain.MainChainHasBlock(finalHash)
+	if err != nil {
+		bmgrLog.Errorf("Failed to check for main chain block %v: %v",
+			finalHash, err)
+		return
+	}
+	if tipHash != batch.tip || !inMainChain {
+		bmgrLog.Debugf("Discarding committed filters for blocks that " +
+			"were reorganized out of the main chain")
+		b.fetchCFilterHeaders(cfmsg.peer)
+		return
+	}
+
+	err = b.chain.ProcessCFilters(batch.headers, batch.filters)
+	if err != nil {
+		if _, ok := err.(blockchain.RuleError); ok {
+			bmgrLog.Warnf("Rejected committed filters from peer %s: "+
+				"%v -- disconnecting", cfmsg.peer.Addr(), err)
+			cfmsg.peer.Disconnect()
+			return
+		}
+		bmgrLog.Errorf("Failed to process committed filters: %v", err)
+		return
+	}
+	_, tipHeight := b.chain.CFilterTip()
+	bmgrLog.Infof("Verified %d committed filters from peer %s (height "+
+		"%d, hash %v)", len(batch.hashes), cfmsg.peer.Addr(), tipHeight,
+		*finalHash)
+
+	b.fetchCFilterHeaders(cfmsg.peer)
+}
+
+// QueueCFHeaders adds the passed cfheaders message and peer to the block
+// handling queue.
+func (b *blockManager) QueueCFHeaders(headers *wire.MsgCFHeaders, sp *serverPeer) {
+	// No channel handling here because peers do not need to block on
+	// cfheaders messages.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		return
+	}
+
+	b.msgChan <- &cfHeadersMsg{headers: headers, peer: sp}
+}
+
+// QueueCFilter adds the passed cfilter message and peer to the block handling
+// queue.
+func (b *blockManager) QueueCFilter(filter *wire.MsgCFilter, sp *serverPeer) {
+	// No channel handling here because peers do not need to block on
+	// cfilter messages.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		return
+	}
+
+	b.msgChan <- &cfilterMsg{filter: filter, peer: sp}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/commanderu/cdrd/database"
 	"github.com/commanderu/cdrd/mempool"
 	"github.com/commanderu/cdrd/peer"
+	"github.com/commanderu/cdrd/pubsub"
 	"github.com/commanderu/cdrd/txscript"
 	"github.com/jrick/logrotate/rotator"
 )
@@ -64,6 +65,8 @@ var (
 	indxLog = backendLog.Logger("INDX")
 	minrLog = backendLog.Logger("MINR")
 	peerLog = backendLog.Logger("PEER")
+	pubsLog = backendLog.Logger("PUBS")
+	restLog = backendLog.Logger("REST")
 	rpcsLog = backendLog.Logger("RPCS")
 	scrpLog = backendLog.Logger("SCRP")
 	srvrLog = backendLog.Logger("SRVR")
@@ -79,6 +82,7 @@ func init() {
 	blockchain.UseLogger(chanLog)
 	indexers.UseLogger(indxLog)
 	peer.UseLogger(peerLog)
+	pubsub.UseLogger(pubsLog)
 	txscript.UseLogger(scrpLog)
 	stake.UseLogger(stkeLog)
 	mempool.UseLogger(txmpLog)
@@ -97,6 +101,8 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"INDX": indxLog,
 	"MINR": minrLog,
 	"PEER": peerLog,
+	"PUBS": pubsLog,
+	"REST": restLog,
 	"RPCS": rpcsLog,
 	"SCRP": scrpLog,
 	"SRVR": srvrLog,
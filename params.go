@@ -18,7 +18,8 @@ var activeNetParams = &mainNetParams
 // network and test networks.
 type params struct {
 	*chaincfg.Params
-	rpcPort string
+	rpcPort  string
+	restPort string
 }
 
 // mainNetParams contains parameters specific to the main network
@@ -28,22 +29,25 @@ type params struct {
 // it does not handle on to cdrd.  This approach allows the wallet process
 // to emulate the full reference implementation RPC API.
 var mainNetParams = params{
-	Params:  &chaincfg.MainNetParams,
-	rpcPort: "37460",
+	Params:   &chaincfg.MainNetParams,
+	rpcPort:  "37460",
+	restPort: "37461",
 }
 
 // testNet2Params contains parameters specific to the test network (version 2)
 // (wire.TestNet2).
 var testNet2Params = params{
-	Params:  &chaincfg.TestNet2Params,
-	rpcPort: "137460",
+	Params:   &chaincfg.TestNet2Params,
+	rpcPort:  "137460",
+	restPort: "19111",
 }
 
 // simNetParams contains parameters specific to the simulation test network
 // (wire.SimNet).
 var simNetParams = params{
-	Params:  &chaincfg.SimNetParams,
-	rpcPort: "19556",
+	Params:   &chaincfg.SimNetParams,
+	rpcPort:  "19556",
+	restPort: "19557",
 }
 
 // netName returns the name used when referring to a commanderu network.  At the
@@ -374,6 +374,12 @@ func TestPeerListeners(t *testing.T) {
 			OnGetCFTypes: func(p *peer.Peer, msg *wire.MsgGetCFTypes) {
 				ok <- msg
 			},
+			OnGetCFilters: func(p *peer.Peer, msg *wire.MsgGetCFilters) {
+				ok <- msg
+			},
+			OnGetCFCheckpt: func(p *peer.Peer, msg *wire.MsgGetCFCheckpt) {
+				ok <- msg
+			},
 			OnCFilter: func(p *peer.Peer, msg *wire.MsgCFilter) {
 				ok <- msg
 			},
@@ -383,9 +389,24 @@ func TestPeerListeners(t *testing.T) {
 			OnCFTypes: func(p *peer.Peer, msg *wire.MsgCFTypes) {
 				ok <- msg
 			},
+			OnCFCheckpt: func(p *peer.Peer, msg *wire.MsgCFCheckpt) {
+				ok <- msg
+			},
 			OnFeeFilter: func(p *peer.Peer, msg *wire.MsgFeeFilter) {
 				ok <- msg
 			},
+			OnFilterAdd: func(p *peer.Peer, msg *wire.MsgFilterAdd) {
+				ok <- msg
+			},
+			OnFilterClear: func(p *peer.Peer, msg *wire.MsgFilterClear) {
+				ok <- msg
+			},
+			OnFilterLoad: func(p *peer.Peer, msg *wire.MsgFilterLoad) {
+				ok <- msg
+			},
+			OnMerkleBlock: func(p *peer.Peer, msg *wire.MsgMerkleBlock) {
+				ok <- msg
+			},
 			OnVersion: func(p *peer.Peer, msg *wire.MsgVersion) {
 				ok <- msg
 			},
@@ -504,6 +525,16 @@ func TestPeerListeners(t *testing.T) {
 			"OnGetCFTypes",
 			wire.NewMsgGetCFTypes(),
 		},
+		{
+			"OnGetCFilters",
+			wire.NewMsgGetCFilters(wire.GCSFilterRegular, 0,
+				&chainhash.Hash{}),
+		},
+		{
+			"OnGetCFCheckpt",
+			wire.NewMsgGetCFCheckpt(wire.GCSFilterRegular,
+				&chainhash.Hash{}),
+		},
 		{
 			"OnCFilter",
 			wire.NewMsgCFilter(&chainhash.Hash{},
@@ -518,10 +549,34 @@ func TestPeerListeners(t *testing.T) {
 			wire.NewMsgCFTypes([]wire.FilterType{
 				wire.GCSFilterRegular, wire.GCSFilterExtended}),
 		},
+		{
+			"OnCFCheckpt",
+			wire.NewMsgCFCheckpt(wire.GCSFilterRegular,
+				&chainhash.Hash{}, 0),
+		},
 		{
 			"OnFeeFilter",
 			wire.NewMsgFeeFilter(15000),
 		},
+		{
+			"OnFilterAdd",
+			wire.NewMsgFilterAdd([]byte{0x01}),
+		},
+		{
+			"OnFilterClear",
+			wire.NewMsgFilterClear(),
+		},
+		{
+			"OnFilterLoad",
+			wire.NewMsgFilterLoad([]byte{0x01}, 10, 0, wire.BloomUpdateNone),
+		},
+		{
+			"OnMerkleBlock",
+			wire.NewMsgMerkleBlock(wire.NewBlockHeader(0,
+				&chainhash.Hash{}, &chainhash.Hash{},
+				&chainhash.Hash{}, 0, [6]byte{}, 0, 0, 0, 0, 0, 0,
+				0, 0, 0, [32]byte{}, 0)),
+		},
 		// only one version message is allowed
 		// only one verack message is allowed
 		{
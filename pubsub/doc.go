@@ -0,0 +1,30 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package pubsub implements a lightweight push publisher for chain and memory
+pool events.
+
+Publisher Overview
+
+A Publisher accepts connections from subscribers on one or more listeners and
+pushes every published message to each subscriber with a matching topic
+subscription.  It speaks the publisher side of the ZeroMQ Message Transport
+Protocol (ZMTP 3.0 with the NULL security mechanism) and is therefore
+compatible with standard ZeroMQ SUB and XSUB sockets without requiring any
+native libraries.
+
+Every published message consists of three frames:
+
+	<topic> <body> <sequence>
+
+The sequence is a 4-byte little-endian number which is incremented for each
+message published on the topic.  Since a publisher never blocks on slow
+subscribers and instead drops messages once a subscriber's queue is full,
+subscribers can use gaps in the sequence numbers to detect dropped messages.
+
+Subscriptions are prefix matches on the topic, as is standard for ZeroMQ, and
+an empty subscription matches all topics.
+*/
+package pubsub
@@ -0,0 +1,374 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultMaxQueuedMessages is the default number of messages that
+	// may be queued for a subscriber before further messages to it are
+	// dropped.
+	DefaultMaxQueuedMessages = 1000
+
+	// handshakeTimeout is the maximum amount of time a subscriber is given
+	// to complete the protocol handshake.
+	handshakeTimeout = time.Second * 10
+
+	// writeTimeout is the maximum amount of time a write to a subscriber
+	// may take before the subscriber is disconnected.
+	writeTimeout = time.Minute
+
+	// minAcceptRetryDelay and maxAcceptRetryDelay bound the amount of time
+	// the listener waits before accepting connections again after an error.
+	// The delay doubles for each consecutive error so persistent errors,
+	// such as running out of file descriptors, do not result in a busy
+	// loop.
+	minAcceptRetryDelay = time.Millisecond * 5
+	maxAcceptRetryDelay = time.Second
+)
+
+// Config holds the configuration options related to the publisher.
+type Config struct {
+	// Listeners defines a slice of listeners on which subscribers connect.
+	Listeners []net.Listener
+
+	// Topics is the set of topics published on the listeners.  Publishing
+	// any other topic has no effect.  All topics are published when it is
+	// empty.
+	Topics []string
+
+	// MaxQueuedMessages is the maximum number of messages queued for each
+	// subscriber.  Messages published while a subscriber's queue is full
+	// are dropped for that subscriber.  DefaultMaxQueuedMessages is used
+	// when it is zero.
+	MaxQueuedMessages int
+}
+
+// subscriber houses the state of a connected subscriber.
+type subscriber struct {
+	conn      net.Conn
+	sendQueue chan []byte
+	quit      chan struct{}
+
+	mtx           sync.Mutex
+	subscriptions map[string]struct{}
+}
+
+// subscribe adds the passed topic prefix to the subscriptions.
+func (s *subscriber) subscribe(prefix string) {
+	s.mtx.Lock()
+	s.subscriptions[prefix] = struct{}{}
+	s.mtx.Unlock()
+}
+
+// unsubscribe removes the passed topic prefix from the subscriptions.
+func (s *subscriber) unsubscribe(prefix string) {
+	s.mtx.Lock()
+	delete(s.subscriptions, prefix)
+	s.mtx.Unlock()
+}
+
+// matches returns whether the subscriber is subscribed to the passed topic.
+func (s *subscriber) matches(topic string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for prefix := range s.subscriptions {
+		if strings.HasPrefix(topic, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Publisher pushes published messages to all connected subscribers with a
+// matching subscription.  See the package documentation for details.
+type Publisher struct {
+	started  int32
+	shutdown int32
+
+	cfg    Config
+	topics map[string]struct{}
+
+	mtx         sync.Mutex
+	subscribers map[*subscriber]struct{}
+	sequences   map[string]uint32
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// HasTopic returns whether the passed topic is published by the publisher.
+// Callers may use this to avoid needlessly serializing messages.
+func (p *Publisher) HasTopic(topic string) bool {
+	if len(p.topics) == 0 {
+		return true
+	}
+	_, ok := p.topics[topic]
+	return ok
+}
+
+// Publish sends a message with the passed topic and body to every subscriber
+// with a matching subscription.  The per-topic sequence number is incremented
+// for every published message regardless of whether or not there are any
+// subscribers so subscribers are able to detect all dropped messages.
+//
+// This function is safe for concurrent access.
+func (p *Publisher) Publish(topic string, body []byte) {
+	if !p.HasTopic(topic) {
+		return
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	var seq [4]byte
+	binary.LittleEndian.PutUint32(seq[:], p.sequences[topic])
+	p.sequences[topic]++
+
+	var msg []byte
+	for sub := range p.subscribers {
+		if !sub.matches(topic) {
+			continue
+		}
+		if msg == nil {
+			msg = encodeMessage([]byte(topic), body, seq[:])
+		}
+		select {
+		case sub.sendQueue <- msg:
+		default:
+			log.Debugf("Dropping %s message %d for slow subscriber %s",
+				topic, binary.LittleEndian.Uint32(seq[:]),
+				sub.conn.RemoteAddr())
+		}
+	}
+}
+
+// NumSubscribers returns the number of connected subscribers.
+//
+// This function is safe for concurrent access.
+func (p *Publisher) NumSubscribers() int {
+	p.mtx.Lock()
+	n := len(p.subscribers)
+	p.mtx.Unlock()
+	return n
+}
+
+// listenHandler accepts incoming connections on the given listener.  It must
+// be run as a goroutine.
+func (p *Publisher) listenHandler(listener net.Listener) {
+	log.Infof("Publisher listening on %s", listener.Addr())
+	var retryDelay time.Duration
+out:
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			// Only log the error if not forcibly shutting down.
+			select {
+			case <-p.quit:
+				break out
+			default:
+			}
+			if retryDelay == 0 {
+				retryDelay = minAcceptRetryDelay
+			} else if retryDelay *= 2; retryDelay > maxAcceptRetryDelay {
+				retryDelay = maxAcceptRetryDelay
+			}
+			log.Errorf("Can't accept connection: %v; retrying in %v",
+				err, retryDelay)
+			select {
+			case <-time.After(retryDelay):
+			case <-p.quit:
+				break out
+			}
+			continue
+		}
+		retryDelay = 0
+		p.wg.Add(1)
+		go p.handleConn(conn)
+	}
+	p.wg.Done()
+	log.Tracef("Publisher listener handler done for %s", listener.Addr())
+}
+
+// handleConn performs the handshake with a new subscriber and services it
+// until it disconnects or the publisher is stopped.  It must be run as a
+// goroutine.
+func (p *Publisher) handleConn(conn net.Conn) {
+	defer p.wg.Done()
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := handshake(conn); err != nil {
+		log.Debugf("Handshake with subscriber %s failed: %v",
+			conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	sub := &subscriber{
+		conn:          conn,
+		sendQueue:     make(chan []byte, p.cfg.MaxQueuedMessages),
+		quit:          make(chan struct{}),
+		subscriptions: make(map[string]struct{}),
+	}
+	// Refuse the subscriber when the publisher was stopped while the
+	// handshake was in progress since it would otherwise never be
+	// disconnected.
+	p.mtx.Lock()
+	select {
+	case <-p.quit:
+		p.mtx.Unlock()
+		conn.Close()
+		return
+	default:
+	}
+	p.subscribers[sub] = struct{}{}
+	p.mtx.Unlock()
+	log.Debugf("New subscriber %s", conn.RemoteAddr())
+
+	p.wg.Add(1)
+	go p.writeHandler(sub)
+	p.readHandler(sub)
+
+	p.mtx.Lock()
+	delete(p.subscribers, sub)
+	p.mtx.Unlock()
+	close(sub.quit)
+	conn.Close()
+	log.Debugf("Subscriber %s disconnected", conn.RemoteAddr())
+}
+
+// readHandler processes subscription changes sent by a subscriber until the
+// connection is closed or the subscriber misbehaves.
+func (p *Publisher) readHandler(sub *subscriber) {
+	for {
+		f, err := readFrame(sub.conn)
+		if err != nil {
+			return
+		}
+
+		// ZMTP 3.1 subscribers send SUBSCRIBE and CANCEL commands while
+		// ZMTP 3.0 subscribers send single frame messages prefixed with
+		// a 1 or 0 byte respectively.  Both are accepted.  Any other
+		// commands are ignored.
+		if f.isCommand() {
+			name, data, err := parseCommand(f.body)
+			if err != nil {
+				return
+			}
+			switch name {
+			case "SUBSCRIBE":
+				sub.subscribe(string(data))
+			case "CANCEL":
+				sub.unsubscribe(string(data))
+			}
+			continue
+		}
+		if f.more() || len(f.body) == 0 {
+			log.Debugf("Ignoring unexpected message from subscriber %s",
+				sub.conn.RemoteAddr())
+			continue
+		}
+		switch f.body[0] {
+		case 1:
+			sub.subscribe(string(f.body[1:]))
+		case 0:
+			sub.unsubscribe(string(f.body[1:]))
+		}
+	}
+}
+
+// writeHandler writes queued messages to a subscriber.  It must be run as a
+// goroutine.
+func (p *Publisher) writeHandler(sub *subscriber) {
+	defer p.wg.Done()
+	for {
+		select {
+		case msg := <-sub.sendQueue:
+			sub.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if _, err := sub.conn.Write(msg); err != nil {
+				log.Debugf("Unable to write to subscriber %s: %v",
+					sub.conn.RemoteAddr(), err)
+				sub.conn.Close()
+				return
+			}
+		case <-sub.quit:
+			return
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// Start launches the publisher listeners.
+func (p *Publisher) Start() {
+	if atomic.AddInt32(&p.started, 1) != 1 {
+		return
+	}
+
+	log.Trace("Publisher starting")
+	for _, listener := range p.cfg.Listeners {
+		p.wg.Add(1)
+		go p.listenHandler(listener)
+	}
+}
+
+// Stop gracefully shuts down the publisher by closing all listeners and
+// disconnecting all subscribers.
+func (p *Publisher) Stop() {
+	if atomic.AddInt32(&p.shutdown, 1) != 1 {
+		log.Warnf("Publisher already stopped")
+		return
+	}
+
+	// Signal the handlers to quit before closing the listeners so that the
+	// resulting accept errors are recognized as part of the shutdown.
+	p.mtx.Lock()
+	close(p.quit)
+	p.mtx.Unlock()
+	for _, listener := range p.cfg.Listeners {
+		if err := listener.Close(); err != nil {
+			log.Warnf("Unable to close listener %s: %v",
+				listener.Addr(), err)
+		}
+	}
+	p.mtx.Lock()
+	for sub := range p.subscribers {
+		sub.conn.Close()
+	}
+	p.mtx.Unlock()
+	p.wg.Wait()
+	log.Trace("Publisher stopped")
+}
+
+// New returns a new publisher for the given configuration.  Use Start to begin
+// accepting subscribers.
+func New(cfg *Config) (*Publisher, error) {
+	if len(cfg.Listeners) == 0 {
+		return nil, errors.New("no listeners specified")
+	}
+	if cfg.MaxQueuedMessages == 0 {
+		cfg.MaxQueuedMessages = DefaultMaxQueuedMessages
+	}
+	topics := make(map[string]struct{}, len(cfg.Topics))
+	for _, topic := range cfg.Topics {
+		topics[topic] = struct{}{}
+	}
+	return &Publisher{
+		cfg:         *cfg,
+		topics:      topics,
+		subscribers: make(map[*subscriber]struct{}),
+		sequences:   make(map[string]uint32),
+		quit:        make(chan struct{}),
+	}, nil
+}
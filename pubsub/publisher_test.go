@@ -0,0 +1,209 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// dialSubscriber connects to the passed address and performs the subscriber
+// side of the ZMTP handshake.
+func dialSubscriber(t *testing.T, addr string) net.Conn {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial: unexpected error: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(time.Second * 5))
+
+	if _, err := conn.Write(greeting()); err != nil {
+		t.Fatalf("write greeting: %v", err)
+	}
+	var g [greetingSize]byte
+	if _, err := io.ReadFull(conn, g[:]); err != nil {
+		t.Fatalf("read greeting: %v", err)
+	}
+	if err := checkGreeting(g[:]); err != nil {
+		t.Fatalf("checkGreeting: %v", err)
+	}
+	ready := encodeCommand("READY", encodeMetadata(map[string]string{
+		"Socket-Type": socketTypeSub,
+	}))
+	if _, err := conn.Write(appendFrame(nil, flagCommand, ready)); err != nil {
+		t.Fatalf("write READY: %v", err)
+	}
+	f, err := readFrame(conn)
+	if err != nil {
+		t.Fatalf("read READY: %v", err)
+	}
+	name, data, err := parseCommand(f.body)
+	if err != nil || !f.isCommand() || name != "READY" {
+		t.Fatalf("unexpected READY frame %x (err %v)", f.body, err)
+	}
+	props, err := parseMetadata(data)
+	if err != nil || props["socket-type"] != socketTypePub {
+		t.Fatalf("unexpected READY metadata %v (err %v)", props, err)
+	}
+	return conn
+}
+
+// readMessage reads a full multipart message from r.
+func readMessage(t *testing.T, r io.Reader) [][]byte {
+	var parts [][]byte
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			t.Fatalf("readFrame: unexpected error: %v", err)
+		}
+		parts = append(parts, f.body)
+		if !f.more() {
+			return parts
+		}
+	}
+}
+
+// TestFrameEncoding ensures short and long frames round trip.
+func TestFrameEncoding(t *testing.T) {
+	for _, size := range []int{0, 1, 255, 256, 70000} {
+		body := bytes.Repeat([]byte{0xa5}, size)
+		buf := appendFrame(nil, flagMore, body)
+		if size > maxFrameSize {
+			if _, err := readFrame(bytes.NewReader(buf)); err != ErrFrameTooLarge {
+				t.Errorf("readFrame(%d): got err %v, want %v", size,
+					err, ErrFrameTooLarge)
+			}
+			continue
+		}
+		f, err := readFrame(bytes.NewReader(buf))
+		if err != nil {
+			t.Errorf("readFrame(%d): unexpected error: %v", size, err)
+			continue
+		}
+		if !f.more() || f.isCommand() || !bytes.Equal(f.body, body) {
+			t.Errorf("readFrame(%d): mismatched frame", size)
+		}
+	}
+}
+
+// TestPublisher ensures subscribers only receive the topics they subscribe to
+// along with the expected per-topic sequence numbers.
+func TestPublisher(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: unexpected error: %v", err)
+	}
+	p, err := New(&Config{
+		Listeners: []net.Listener{listener},
+		Topics:    []string{"hashblock", "hashtx"},
+	})
+	if err != nil {
+		t.Fatalf("New: unexpected error: %v", err)
+	}
+	p.Start()
+	defer p.Stop()
+
+	if p.HasTopic("rawtx") {
+		t.Fatal("HasTopic: unexpected rawtx topic")
+	}
+
+	conn := dialSubscriber(t, listener.Addr().String())
+	defer conn.Close()
+	sub := appendFrame(nil, 0, append([]byte{1}, "hashtx"...))
+	if _, err := conn.Write(sub); err != nil {
+		t.Fatalf("write subscription: %v", err)
+	}
+
+	// Wait for the subscription to be registered.
+	for i := 0; ; i++ {
+		var subscribed bool
+		p.mtx.Lock()
+		for s := range p.subscribers {
+			subscribed = s.matches("hashtx")
+		}
+		p.mtx.Unlock()
+		if subscribed {
+			break
+		}
+		if i == 100 {
+			t.Fatal("subscription was never registered")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	p.Publish("hashblock", []byte{0x01})
+	p.Publish("rawtx", []byte{0x02})
+	p.Publish("hashtx", []byte{0x03})
+	p.Publish("hashtx", []byte{0x04})
+
+	for i, want := range []byte{0x03, 0x04} {
+		parts := readMessage(t, conn)
+		if len(parts) != 3 {
+			t.Fatalf("message %d: got %d parts, want 3", i, len(parts))
+		}
+		if string(parts[0]) != "hashtx" {
+			t.Fatalf("message %d: got topic %q, want hashtx", i,
+				parts[0])
+		}
+		if !bytes.Equal(parts[1], []byte{want}) {
+			t.Fatalf("message %d: got body %x, want %x", i, parts[1],
+				want)
+		}
+		if seq := binary.LittleEndian.Uint32(parts[2]); seq != uint32(i) {
+			t.Fatalf("message %d: got sequence %d, want %d", i, seq, i)
+		}
+	}
+}
+
+// errListener is a net.Listener whose Accept always fails.  It counts the
+// number of calls to Accept.
+type errListener struct {
+	accepts int32
+	closed  chan struct{}
+}
+
+func (l *errListener) Accept() (net.Conn, error) {
+	atomic.AddInt32(&l.accepts, 1)
+	return nil, errors.New("too many open files")
+}
+
+func (l *errListener) Close() error {
+	close(l.closed)
+	return nil
+}
+
+func (l *errListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+}
+
+// TestPublisherAcceptBackoff ensures the publisher backs off when accepting
+// connections fails rather than retrying in a busy loop and that it still
+// stops promptly while waiting to retry.
+func TestPublisherAcceptBackoff(t *testing.T) {
+	listener := &errListener{closed: make(chan struct{})}
+	p, err := New(&Config{Listeners: []net.Listener{listener}})
+	if err != nil {
+		t.Fatalf("New: unexpected error: %v", err)
+	}
+	p.Start()
+	time.Sleep(time.Millisecond * 100)
+	start := time.Now()
+	p.Stop()
+	if elapsed := time.Since(start); elapsed >= maxAcceptRetryDelay {
+		t.Fatalf("Stop took %v while waiting to retry", elapsed)
+	}
+
+	// The retry delays of 5, 10, 20, and 40ms allow at most five calls in
+	// 100ms, so allow some leeway for slow test machines while still
+	// detecting a busy loop.
+	if accepts := atomic.LoadInt32(&listener.accepts); accepts > 10 {
+		t.Fatalf("Accept was called %d times", accepts)
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pubsub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// greetingSize is the size of the ZMTP greeting sent by each side of a
+	// connection.
+	greetingSize = 64
+
+	// zmtpMajorVersion and zmtpMinorVersion are the protocol version
+	// advertised in the greeting.
+	zmtpMajorVersion = 3
+	zmtpMinorVersion = 0
+
+	// maxFrameSize is the maximum size of a frame accepted from a peer.
+	// Subscribers only send handshake commands and subscriptions, so there
+	// is no reason to allow large frames.
+	maxFrameSize = 1024 * 64
+)
+
+// Frame flags as defined by ZMTP 3.0.
+const (
+	flagMore    = 0x01
+	flagLong    = 0x02
+	flagCommand = 0x04
+)
+
+// Socket types exchanged in the READY command.
+const (
+	socketTypePub  = "PUB"
+	socketTypeSub  = "SUB"
+	socketTypeXSub = "XSUB"
+)
+
+// mechanismNull is the name of the only security mechanism supported.
+var mechanismNull = [20]byte{'N', 'U', 'L', 'L'}
+
+var (
+	// ErrBadGreeting indicates a peer sent a greeting that is not a
+	// valid ZMTP 3.x greeting.
+	ErrBadGreeting = errors.New("invalid ZMTP greeting")
+
+	// ErrUnsupportedMechanism indicates a peer requested a security
+	// mechanism other than NULL.
+	ErrUnsupportedMechanism = errors.New("unsupported ZMTP security " +
+		"mechanism")
+
+	// ErrFrameTooLarge indicates a peer sent a frame larger than
+	// maxFrameSize.
+	ErrFrameTooLarge = errors.New("ZMTP frame too large")
+
+	// ErrBadSocketType indicates a peer is not a subscriber socket.
+	ErrBadSocketType = errors.New("incompatible ZMTP socket type")
+)
+
+// greeting returns the ZMTP greeting sent by the publisher.
+func greeting() []byte {
+	var g [greetingSize]byte
+	g[0] = 0xff
+	g[9] = 0x7f
+	g[10] = zmtpMajorVersion
+	g[11] = zmtpMinorVersion
+	copy(g[12:32], mechanismNull[:])
+	// The as-server field and the filler are all zero.
+	return g[:]
+}
+
+// checkGreeting validates the greeting received from a peer.  Peers which
+// advertise a later 3.x minor version are accepted since they are required to
+// downgrade to the version advertised by the publisher.
+func checkGreeting(g []byte) error {
+	if len(g) != greetingSize || g[0] != 0xff || g[9] != 0x7f {
+		return ErrBadGreeting
+	}
+	if g[10] < zmtpMajorVersion {
+		return ErrBadGreeting
+	}
+	if !bytes.Equal(g[12:32], mechanismNull[:]) {
+		return ErrUnsupportedMechanism
+	}
+	return nil
+}
+
+// frame is a single ZMTP frame.
+type frame struct {
+	flags byte
+	body  []byte
+}
+
+// isCommand returns whether the frame is a command frame.
+func (f *frame) isCommand() bool {
+	return f.flags&flagCommand == flagCommand
+}
+
+// more returns whether more frames of the same message follow.
+func (f *frame) more() bool {
+	return f.flags&flagMore == flagMore
+}
+
+// appendFrame appends the wire encoding of a frame with the given flags and
+// body to buf and returns the result.
+func appendFrame(buf []byte, flags byte, body []byte) []byte {
+	if len(body) > 255 {
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(body)))
+		buf = append(buf, flags|flagLong)
+		buf = append(buf, size[:]...)
+	} else {
+		buf = append(buf, flags, byte(len(body)))
+	}
+	return append(buf, body...)
+}
+
+// encodeMessage returns the wire encoding of a multipart message consisting of
+// the passed parts.
+func encodeMessage(parts ...[]byte) []byte {
+	size := 0
+	for _, part := range parts {
+		size += 9 + len(part)
+	}
+	buf := make([]byte, 0, size)
+	for i, part := range parts {
+		var flags byte
+		if i != len(parts)-1 {
+			flags = flagMore
+		}
+		buf = appendFrame(buf, flags, part)
+	}
+	return buf
+}
+
+// readFrame reads a single frame from r.
+func readFrame(r io.Reader) (*frame, error) {
+	var hdr [9]byte
+	if _, err := io.ReadFull(r, hdr[:2]); err != nil {
+		return nil, err
+	}
+	flags := hdr[0]
+	size := uint64(hdr[1])
+	if flags&flagLong == flagLong {
+		if _, err := io.ReadFull(r, hdr[2:9]); err != nil {
+			return nil, err
+		}
+		size = binary.BigEndian.Uint64(hdr[1:9])
+	}
+	if size > maxFrameSize {
+		return nil, ErrFrameTooLarge
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return &frame{flags: flags &^ flagLong, body: body}, nil
+}
+
+// encodeCommand returns the body of a command frame with the given name and
+// data.
+func encodeCommand(name string, data []byte) []byte {
+	body := make([]byte, 0, 1+len(name)+len(data))
+	body = append(body, byte(len(name)))
+	body = append(body, name...)
+	return append(body, data...)
+}
+
+// parseCommand splits the body of a command frame into the command name and
+// its data.
+func parseCommand(body []byte) (string, []byte, error) {
+	if len(body) == 0 || int(body[0]) > len(body)-1 {
+		return "", nil, errors.New("malformed ZMTP command")
+	}
+	nameLen := int(body[0])
+	return string(body[1 : 1+nameLen]), body[1+nameLen:], nil
+}
+
+// encodeMetadata encodes the passed properties as used by the READY command.
+func encodeMetadata(props map[string]string) []byte {
+	var buf []byte
+	for name, value := range props {
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(value)))
+		buf = append(buf, byte(len(name)))
+		buf = append(buf, name...)
+		buf = append(buf, size[:]...)
+		buf = append(buf, value...)
+	}
+	return buf
+}
+
+// parseMetadata decodes the properties of a READY command.  Property names are
+// case-insensitive and are returned in canonical lower case.
+func parseMetadata(data []byte) (map[string]string, error) {
+	props := make(map[string]string)
+	for len(data) > 0 {
+		nameLen := int(data[0])
+		if len(data) < 1+nameLen+4 {
+			return nil, errors.New("malformed ZMTP metadata")
+		}
+		name := string(bytes.ToLower(data[1 : 1+nameLen]))
+		data = data[1+nameLen:]
+		valueLen := binary.BigEndian.Uint32(data[:4])
+		data = data[4:]
+		if uint64(len(data)) < uint64(valueLen) {
+			return nil, errors.New("malformed ZMTP metadata")
+		}
+		props[name] = string(data[:valueLen])
+		data = data[valueLen:]
+	}
+	return props, nil
+}
+
+// handshake performs the publisher side of a ZMTP 3.x handshake over rw using
+// the NULL security mechanism.
+func handshake(rw io.ReadWriter) error {
+	if _, err := rw.Write(greeting()); err != nil {
+		return err
+	}
+	var peerGreeting [greetingSize]byte
+	if _, err := io.ReadFull(rw, peerGreeting[:]); err != nil {
+		return err
+	}
+	if err := checkGreeting(peerGreeting[:]); err != nil {
+		return err
+	}
+
+	ready := encodeCommand("READY", encodeMetadata(map[string]string{
+		"Socket-Type": socketTypePub,
+	}))
+	if _, err := rw.Write(appendFrame(nil, flagCommand, ready)); err != nil {
+		return err
+	}
+
+	f, err := readFrame(rw)
+	if err != nil {
+		return err
+	}
+	if !f.isCommand() {
+		return errors.New("expected ZMTP READY command")
+	}
+	name, data, err := parseCommand(f.body)
+	if err != nil {
+		return err
+	}
+	if name == "ERROR" {
+		return fmt.Errorf("peer rejected handshake: %q", data)
+	}
+	if name != "READY" {
+		return fmt.Errorf("expected ZMTP READY command, got %q", name)
+	}
+	props, err := parseMetadata(data)
+	if err != nil {
+		return err
+	}
+	switch props["socket-type"] {
+	case socketTypeSub, socketTypeXSub:
+	default:
+		return ErrBadSocketType
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/pubsub"
+)
+
+// Topics published by the pub/sub notifier.
+const (
+	pubTopicHashBlock       = "hashblock"
+	pubTopicRawBlock        = "rawblock"
+	pubTopicHashTx          = "hashtx"
+	pubTopicRawTx           = "rawtx"
+	pubTopicWinningTickets  = "winningtickets"
+	pubTopicStakeDifficulty = "stakedifficulty"
+)
+
+// pubSubNotifier publishes chain and mempool events to subscribers connected
+// to the publisher addresses specified in the configuration.  Topics configured
+// with the same address share a single publisher.
+type pubSubNotifier struct {
+	publishers []*pubsub.Publisher
+}
+
+// appendDisplayHash appends the passed hash to buf in the byte order it is
+// displayed in, which is the reverse of its internal order, and returns the
+// result.
+func appendDisplayHash(buf []byte, hash *chainhash.Hash) []byte {
+	for i := chainhash.HashSize - 1; i >= 0; i-- {
+		buf = append(buf, hash[i])
+	}
+	return buf
+}
+
+// publish sends the body returned by bodyFunc to all publishers of the passed
+// topic.  The body is only created when there is at least one such publisher.
+func (n *pubSubNotifier) publish(topic string, bodyFunc func() ([]byte, error)) {
+	var body []byte
+	for _, p := range n.publishers {
+		if !p.HasTopic(topic) {
+			continue
+		}
+		if body == nil {
+			var err error
+			body, err = bodyFunc()
+			if err != nil {
+				pubsLog.Errorf("Unable to create %s message: %v",
+					topic, err)
+				return
+			}
+		}
+		p.Publish(topic, body)
+	}
+}
+
+// NotifyBlockConnected publishes the hashblock and rawblock topics for the
+// passed block.
+func (n *pubSubNotifier) NotifyBlockConnected(block *cdrutil.Block) {
+	n.publish(pubTopicHashBlock, func() ([]byte, error) {
+		return appendDisplayHash(nil, block.Hash()), nil
+	})
+	n.publish(pubTopicRawBlock, block.Bytes)
+}
+
+// NotifyMempoolTx publishes the hashtx and rawtx topics for the passed
+// transaction which was accepted to the memory pool.
+func (n *pubSubNotifier) NotifyMempoolTx(tx *cdrutil.Tx) {
+	n.publish(pubTopicHashTx, func() ([]byte, error) {
+		return appendDisplayHash(nil, tx.Hash()), nil
+	})
+	n.publish(pubTopicRawTx, func() ([]byte, error) {
+		return tx.MsgTx().Bytes()
+	})
+}
+
+// NotifyWinningTickets publishes the winningtickets topic.  The body is the
+// block hash, the block height as a little-endian uint32, and the hashes of the
+// winning tickets.
+func (n *pubSubNotifier) NotifyWinningTickets(data *WinningTicketsNtfnData) {
+	n.publish(pubTopicWinningTickets, func() ([]byte, error) {
+		body := make([]byte, 0, chainhash.HashSize*(1+len(data.Tickets))+4)
+		body = appendDisplayHash(body, &data.BlockHash)
+		var height [4]byte
+		binary.LittleEndian.PutUint32(height[:], uint32(data.BlockHeight))
+		body = append(body, height[:]...)
+		for i := range data.Tickets {
+			body = appendDisplayHash(body, &data.Tickets[i])
+		}
+		return body, nil
+	})
+}
+
+// NotifyStakeDifficulty publishes the stakedifficulty topic.  The body is the
+// block hash, the block height as a little-endian uint32, and the next stake
+// difficulty in atoms as a little-endian int64.
+func (n *pubSubNotifier) NotifyStakeDifficulty(data *StakeDifficultyNtfnData) {
+	n.publish(pubTopicStakeDifficulty, func() ([]byte, error) {
+		body := make([]byte, 0, chainhash.HashSize+12)
+		body = appendDisplayHash(body, &data.BlockHash)
+		var buf [12]byte
+		binary.LittleEndian.PutUint32(buf[:4], uint32(data.BlockHeight))
+		binary.LittleEndian.PutUint64(buf[4:], uint64(data.StakeDifficulty))
+		return append(body, buf[:]...), nil
+	})
+}
+
+// Start begins accepting subscribers on all publishers.
+func (n *pubSubNotifier) Start() {
+	for _, p := range n.publishers {
+		p.Start()
+	}
+}
+
+// Stop disconnects all subscribers and shuts down all publishers.
+func (n *pubSubNotifier) Stop() {
+	for _, p := range n.publishers {
+		p.Stop()
+	}
+}
+
+// newPubSubNotifier returns a pub/sub notifier for the publisher addresses in
+// the configuration, or nil when none are configured.
+func newPubSubNotifier() (*pubSubNotifier, error) {
+	topicAddrs := []struct {
+		topic string
+		addr  string
+	}{
+		{pubTopicHashBlock, cfg.PubHashBlock},
+		{pubTopicRawBlock, cfg.PubRawBlock},
+		{pubTopicHashTx, cfg.PubHashTx},
+		{pubTopicRawTx, cfg.PubRawTx},
+		{pubTopicWinningTickets, cfg.PubWinningTickets},
+		{pubTopicStakeDifficulty, cfg.PubStakeDifficulty},
+	}
+
+	// Group the topics by address while retaining the configured order.
+	var addrs []string
+	topics := make(map[string][]string)
+	for _, ta := range topicAddrs {
+		if ta.addr == "" {
+			continue
+		}
+		if _, ok := topics[ta.addr]; !ok {
+			addrs = append(addrs, ta.addr)
+		}
+		topics[ta.addr] = append(topics[ta.addr], ta.topic)
+	}
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	n := &pubSubNotifier{
+		publishers: make([]*pubsub.Publisher, 0, len(addrs)),
+	}
+	for _, addr := range addrs {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			n.Stop()
+			return nil, err
+		}
+		p, err := pubsub.New(&pubsub.Config{
+			Listeners: []net.Listener{listener},
+			Topics:    topics[addr],
+		})
+		if err != nil {
+			listener.Close()
+			n.Stop()
+			return nil, err
+		}
+		n.publishers = append(n.publishers, p)
+	}
+	return n, nil
+}
@@ -0,0 +1,862 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/commanderu/cdrd/blockchain"
+	"github.com/commanderu/cdrd/blockchain/stake"
+	"github.com/commanderu/cdrd/cdrjson"
+	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/txscript"
+	"github.com/commanderu/cdrd/wire"
+)
+
+const (
+	// restURIPrefix is the path prefix all REST resources are served under.
+	restURIPrefix = "/rest/"
+
+	// restMaxHeaders is the maximum number of block headers that may be
+	// requested with a single call to the headers resource.
+	restMaxHeaders = 2000
+
+	// restMaxOutpoints is the maximum number of outpoints that may be
+	// queried with a single call to the getutxos resource.
+	restMaxOutpoints = 15
+
+	// restMempoolHeight is the height reported for unspent outputs which
+	// are only available in the memory pool.
+	restMempoolHeight = 0x7fffffff
+
+	// restReadTimeout is the maximum duration allowed for reading a
+	// request from a REST client.
+	restReadTimeout = time.Second * 10
+)
+
+// restFormat identifies the encoding of a REST response.
+type restFormat int
+
+// These constants define the supported REST response encodings.
+const (
+	restFormatJSON restFormat = iota
+	restFormatHex
+	restFormatBinary
+)
+
+// restFormatSuffixes maps the file extension style suffixes accepted on REST
+// resources to the response encoding they select.
+var restFormatSuffixes = map[string]restFormat{
+	"json": restFormatJSON,
+	"hex":  restFormatHex,
+	"bin":  restFormatBinary,
+}
+
+// restError describes a failed REST request along with the HTTP status code
+// that should be returned to the client.
+type restError struct {
+	status  int
+	message string
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *restError) Error() string {
+	return e.message
+}
+
+// newRESTError returns a new restError for the given status and message.
+func newRESTError(status int, format string, args ...interface{}) *restError {
+	return &restError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// restErrorFromRPC converts an error returned by one of the shared RPC command
+// handlers into a restError with an appropriate HTTP status code.
+func restErrorFromRPC(err error) *restError {
+	rpcErr, ok := err.(*cdrjson.RPCError)
+	if !ok {
+		return newRESTError(http.StatusInternalServerError, "%v", err)
+	}
+
+	switch rpcErr.Code {
+	case cdrjson.ErrRPCBlockNotFound:
+		// Note that ErrRPCNoTxInfo and ErrRPCNoCFIndex share this code.
+		return newRESTError(http.StatusNotFound, "%s", rpcErr.Message)
+	case cdrjson.ErrRPCDecodeHexString, cdrjson.ErrRPCInvalidParameter,
+		cdrjson.ErrRPCMisc:
+		return newRESTError(http.StatusBadRequest, "%s", rpcErr.Message)
+	}
+	return newRESTError(http.StatusInternalServerError, "%s", rpcErr.Message)
+}
+
+// parseRESTResource splits the final component of a REST path of the form
+// <resource>.<format> into the resource and the requested response encoding.
+func parseRESTResource(param string) (string, restFormat, error) {
+	dot := strings.LastIndex(param, ".")
+	if dot == -1 {
+		return "", 0, newRESTError(http.StatusNotFound, "output format "+
+			"not found (available: .json, .hex, .bin)")
+	}
+
+	format, ok := restFormatSuffixes[param[dot+1:]]
+	if !ok {
+		return "", 0, newRESTError(http.StatusNotFound, "output format "+
+			"%q not supported (available: .json, .hex, .bin)",
+			param[dot+1:])
+	}
+	return param[:dot], format, nil
+}
+
+// parseRESTHash decodes a block or transaction hash provided in a REST path.
+func parseRESTHash(hashStr string) (*chainhash.Hash, error) {
+	if len(hashStr) != chainhash.MaxHashStringSize {
+		return nil, newRESTError(http.StatusBadRequest, "invalid hash: %s",
+			hashStr)
+	}
+	hash, err := chainhash.NewHashFromStr(hashStr)
+	if err != nil {
+		return nil, newRESTError(http.StatusBadRequest, "invalid hash: %s",
+			hashStr)
+	}
+	return hash, nil
+}
+
+// parseRESTOutPoint decodes an outpoint provided in a REST path in the form
+// <txid>-<index>.
+func parseRESTOutPoint(str string) (*wire.OutPoint, error) {
+	dash := strings.LastIndex(str, "-")
+	if dash == -1 {
+		return nil, newRESTError(http.StatusBadRequest, "invalid "+
+			"outpoint: %s", str)
+	}
+	hash, err := parseRESTHash(str[:dash])
+	if err != nil {
+		return nil, err
+	}
+	index, err := strconv.ParseUint(str[dash+1:], 10, 32)
+	if err != nil {
+		return nil, newRESTError(http.StatusBadRequest, "invalid "+
+			"outpoint index: %s", str)
+	}
+	return wire.NewOutPoint(hash, uint32(index), wire.TxTreeUnknown), nil
+}
+
+// restChainInfoResult models the data returned by the chaininfo resource.
+type restChainInfoResult struct {
+	Chain                string  `json:"chain"`
+	Blocks               int64   `json:"blocks"`
+	BestBlockHash        string  `json:"bestblockhash"`
+	Difficulty           float64 `json:"difficulty"`
+	MedianTime           int64   `json:"mediantime"`
+	TotalTransactions    uint64  `json:"totaltransactions"`
+	TotalSubsidy         int64   `json:"totalsubsidy"`
+	InitialBlockDownload bool    `json:"initialblockdownload"`
+}
+
+// restUtxoResult models a single unspent output returned by the getutxos
+// resource.
+type restUtxoResult struct {
+	Height       int64                      `json:"height"`
+	TxVersion    uint16                     `json:"txversion"`
+	TxType       string                     `json:"txtype"`
+	Coinbase     bool                       `json:"coinbase"`
+	Value        float64                    `json:"value"`
+	ScriptPubKey cdrjson.ScriptPubKeyResult `json:"scriptPubKey"`
+
+	// These fields are only used for the binary and hex encodings.
+	atoms         int64
+	scriptVersion uint16
+	pkScript      []byte
+}
+
+// restGetUtxosResult models the data returned by the getutxos resource.
+type restGetUtxosResult struct {
+	ChainHeight  int64            `json:"chainHeight"`
+	ChainTipHash string           `json:"chainTipHash"`
+	Bitmap       string           `json:"bitmap"`
+	Utxos        []restUtxoResult `json:"utxos"`
+
+	// These fields are only used for the binary and hex encodings.
+	tipHash chainhash.Hash
+	bitmap  []byte
+}
+
+// serialize encodes the result into the binary format used by the hex and
+// binary encodings of the getutxos resource.
+//
+// The format is the chain height as a little-endian uint32, the chain tip
+// hash, the variable length bitmap of which queried outpoints were found
+// unspent, and a variable length list of the found outputs each encoded as
+// the little-endian uint32 height, int64 amount, uint16 script version and
+// variable length public key script.
+func (r *restGetUtxosResult) serialize() ([]byte, error) {
+	var buf bytes.Buffer
+	var scratch [8]byte
+	binary.LittleEndian.PutUint32(scratch[:4], uint32(r.ChainHeight))
+	buf.Write(scratch[:4])
+	buf.Write(r.tipHash[:])
+	if err := wire.WriteVarBytes(&buf, 0, r.bitmap); err != nil {
+		return nil, err
+	}
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(r.Utxos))); err != nil {
+		return nil, err
+	}
+	for i := range r.Utxos {
+		utxo := &r.Utxos[i]
+		binary.LittleEndian.PutUint32(scratch[:4], uint32(utxo.Height))
+		buf.Write(scratch[:4])
+		binary.LittleEndian.PutUint64(scratch[:], uint64(utxo.atoms))
+		buf.Write(scratch[:])
+		binary.LittleEndian.PutUint16(scratch[:2], utxo.scriptVersion)
+		buf.Write(scratch[:2])
+		err := wire.WriteVarBytes(&buf, 0, utxo.pkScript)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// restCFilterResult models the data returned by the JSON encoding of the
+// cfilter resource.
+type restCFilterResult struct {
+	BlockHash  string `json:"blockhash"`
+	FilterType string `json:"filtertype"`
+	Data       string `json:"data"`
+}
+
+// restHandler describes a callback function used to handle a REST resource.
+// The params are the path components that follow the resource name.
+type restHandler func(s *restServer, params []string) (interface{}, restFormat, error)
+
+// restHandlers maps REST resource names to the appropriate handler.
+var restHandlers = map[string]restHandler{
+	"block":     handleRESTBlock,
+	"cfilter":   handleRESTCFilter,
+	"chaininfo": handleRESTChainInfo,
+	"getutxos":  handleRESTGetUtxos,
+	"headers":   handleRESTHeaders,
+	"mempool":   handleRESTMempool,
+	"tx":        handleRESTTx,
+}
+
+// restServer provides an unauthenticated, read-only HTTP interface to public
+// chain and memory pool data.
+type restServer struct {
+	started    int32
+	shutdown   int32
+	numClients int32
+	server     *server
+	chain      *blockchain.BlockChain
+	listeners  []net.Listener
+	wg         sync.WaitGroup
+
+	// rpc is the context the shared RPC command handlers are invoked with
+	// so both servers return identical results.  It is never started and
+	// does not have any listeners of its own.
+	rpc *rpcServer
+}
+
+// Start is used by server.go to start the REST listeners.
+func (s *restServer) Start() {
+	if atomic.AddInt32(&s.started, 1) != 1 {
+		return
+	}
+
+	restLog.Trace("Starting REST server")
+	restServeMux := http.NewServeMux()
+	httpServer := &http.Server{
+		Handler:     restServeMux,
+		ReadTimeout: restReadTimeout,
+	}
+	restServeMux.HandleFunc(restURIPrefix, s.handleRequest)
+
+	for _, listener := range s.listeners {
+		s.wg.Add(1)
+		go func(listener net.Listener) {
+			restLog.Infof("REST server listening on %s",
+				listener.Addr())
+			httpServer.Serve(listener)
+			restLog.Tracef("REST listener done for %s",
+				listener.Addr())
+			s.wg.Done()
+		}(listener)
+	}
+}
+
+// Stop is used by server.go to stop the REST listeners.
+func (s *restServer) Stop() error {
+	if atomic.AddInt32(&s.shutdown, 1) != 1 {
+		restLog.Infof("REST server is already in the process of " +
+			"shutting down")
+		return nil
+	}
+	restLog.Warnf("REST server shutting down")
+	for _, listener := range s.listeners {
+		err := listener.Close()
+		if err != nil {
+			restLog.Errorf("Problem shutting down REST: %v", err)
+			return err
+		}
+	}
+	s.wg.Wait()
+	restLog.Infof("REST server shutdown complete")
+	return nil
+}
+
+// handleRequest dispatches a REST request to the handler registered for the
+// requested resource and writes the result in the requested encoding.
+func (s *restServer) handleRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Limit the number of connections to max allowed.
+	if int(atomic.LoadInt32(&s.numClients)+1) > cfg.RESTMaxClients {
+		restLog.Infof("Max REST clients exceeded [%d] - disconnecting "+
+			"client %s", cfg.RESTMaxClients, r.RemoteAddr)
+		http.Error(w, "503 Too busy.  Try again later.",
+			http.StatusServiceUnavailable)
+		return
+	}
+	atomic.AddInt32(&s.numClients, 1)
+	defer atomic.AddInt32(&s.numClients, -1)
+
+	path := strings.TrimPrefix(r.URL.Path, restURIPrefix)
+	parts := strings.Split(path, "/")
+
+	// Resources without any parameters such as chaininfo carry the format
+	// suffix on the resource name itself, so the handler is passed the
+	// entire component to parse in that case.
+	name, params := parts[0], parts[1:]
+	if len(parts) == 1 {
+		if dot := strings.LastIndex(name, "."); dot != -1 {
+			name, params = name[:dot], parts
+		}
+	}
+	handler, ok := restHandlers[name]
+	if !ok {
+		http.Error(w, "unknown resource", http.StatusNotFound)
+		return
+	}
+
+	result, format, err := handler(s, params)
+	if err != nil {
+		restErr, ok := err.(*restError)
+		if !ok {
+			restErr = restErrorFromRPC(err)
+		}
+		restLog.Debugf("REST request %s from %s failed: %v", r.URL.Path,
+			r.RemoteAddr, restErr)
+		http.Error(w, restErr.message, restErr.status)
+		return
+	}
+
+	switch format {
+	case restFormatBinary:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(result.([]byte))
+
+	case restFormatHex:
+		w.Header().Set("Content-Type", "text/plain")
+		switch result := result.(type) {
+		case string:
+			w.Write([]byte(result))
+		case []byte:
+			w.Write([]byte(hex.EncodeToString(result)))
+		}
+		w.Write([]byte("\n"))
+
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			restLog.Errorf("Failed to marshal REST reply: %v", err)
+		}
+	}
+}
+
+// handleRESTBlock implements the /rest/block/<hash>.<format> resource.
+func handleRESTBlock(s *restServer, params []string) (interface{}, restFormat, error) {
+	if len(params) != 1 {
+		return nil, 0, newRESTError(http.StatusBadRequest, "usage: "+
+			"/rest/block/<hash>.<json|hex|bin>")
+	}
+	hashStr, format, err := parseRESTResource(params[0])
+	if err != nil {
+		return nil, 0, err
+	}
+	hash, err := parseRESTHash(hashStr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if format == restFormatJSON {
+		result, err := handleGetBlock(s.rpc, &cdrjson.GetBlockCmd{
+			Hash:      hash.String(),
+			Verbose:   cdrjson.Bool(true),
+			VerboseTx: cdrjson.Bool(true),
+		}, nil)
+		return result, format, err
+	}
+
+	blk, err := s.chain.FetchBlockByHash(hash)
+	if err != nil {
+		return nil, 0, newRESTError(http.StatusNotFound, "block %v not "+
+			"found", hash)
+	}
+	blkBytes, err := blk.Bytes()
+	if err != nil {
+		return nil, 0, err
+	}
+	return blkBytes, format, nil
+}
+
+// handleRESTTx implements the /rest/tx/<hash>.<format> resource.
+func handleRESTTx(s *restServer, params []string) (interface{}, restFormat, error) {
+	if len(params) != 1 {
+		return nil, 0, newRESTError(http.StatusBadRequest, "usage: "+
+			"/rest/tx/<hash>.<json|hex|bin>")
+	}
+	hashStr, format, err := parseRESTResource(params[0])
+	if err != nil {
+		return nil, 0, err
+	}
+	hash, err := parseRESTHash(hashStr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	verbose := 0
+	if format == restFormatJSON {
+		verbose = 1
+	}
+	result, err := handleGetRawTransaction(s.rpc,
+		&cdrjson.GetRawTransactionCmd{
+			Txid:    hash.String(),
+			Verbose: cdrjson.Int(verbose),
+		}, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if format == restFormatBinary {
+		txBytes, err := hex.DecodeString(result.(string))
+		if err != nil {
+			return nil, 0, err
+		}
+		return txBytes, format, nil
+	}
+	return result, format, nil
+}
+
+// handleRESTHeaders implements the /rest/headers/<count>/<hash>.<format>
+// resource.  It returns up to count main chain headers starting with the
+// provided block.
+func handleRESTHeaders(s *restServer, params []string) (interface{}, restFormat, error) {
+	if len(params) != 2 {
+		return nil, 0, newRESTError(http.StatusBadRequest, "usage: "+
+			"/rest/headers/<count>/<hash>.<json|hex|bin>")
+	}
+	count, err := strconv.Atoi(params[0])
+	if err != nil || count < 1 || count > restMaxHeaders {
+		return nil, 0, newRESTError(http.StatusBadRequest, "header "+
+			"count out of range: %s (max %d)", params[0],
+			restMaxHeaders)
+	}
+	hashStr, format, err := parseRESTResource(params[1])
+	if err != nil {
+		return nil, 0, err
+	}
+	hash, err := parseRESTHash(hashStr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Collect the hashes of the requested headers.  Only the starting block
+	// is returned when it is not part of the main chain.
+	header, err := s.chain.FetchHeader(hash)
+	if err != nil {
+		return nil, 0, newRESTError(http.StatusNotFound, "block %v not "+
+			"found", hash)
+	}
+	hashes := []chainhash.Hash{*hash}
+	headers := []wire.BlockHeader{header}
+	if onMainChain, _ := s.chain.MainChainHasBlock(hash); onMainChain {
+		bestHeight := s.chain.BestSnapshot().Height
+		height := int64(header.Height) + 1
+		for ; len(hashes) < count && height <= bestHeight; height++ {
+			nextHeader, err := s.chain.HeaderByHeight(height)
+			if err != nil {
+				return nil, 0, err
+			}
+			hashes = append(hashes, nextHeader.BlockHash())
+			headers = append(headers, *nextHeader)
+		}
+	}
+
+	if format == restFormatJSON {
+		results := make([]interface{}, 0, len(hashes))
+		for i := range hashes {
+			result, err := handleGetBlockHeader(s.rpc,
+				&cdrjson.GetBlockHeaderCmd{
+					Hash:    hashes[i].String(),
+					Verbose: cdrjson.Bool(true),
+				}, nil)
+			if err != nil {
+				return nil, 0, err
+			}
+			results = append(results, result)
+		}
+		return results, format, nil
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(headers) * wire.MaxBlockHeaderPayload)
+	for i := range headers {
+		if err := headers[i].Serialize(&buf); err != nil {
+			return nil, 0, err
+		}
+	}
+	return buf.Bytes(), format, nil
+}
+
+// handleRESTChainInfo implements the /rest/chaininfo.json resource.
+func handleRESTChainInfo(s *restServer, params []string) (interface{}, restFormat, error) {
+	if len(params) != 1 {
+		return nil, 0, newRESTError(http.StatusBadRequest, "usage: "+
+			"/rest/chaininfo.json")
+	}
+	if _, format, err := parseRESTResource(params[0]); err != nil {
+		return nil, 0, err
+	} else if format != restFormatJSON {
+		return nil, 0, newRESTError(http.StatusNotFound, "output format "+
+			"not supported (available: .json)")
+	}
+
+	best := s.chain.BestSnapshot()
+	return &restChainInfoResult{
+		Chain:                s.server.chainParams.Name,
+		Blocks:               best.Height,
+		BestBlockHash:        best.Hash.String(),
+		Difficulty:           getDifficultyRatio(best.Bits),
+		MedianTime:           best.MedianTime.Unix(),
+		TotalTransactions:    best.TotalTxns,
+		TotalSubsidy:         best.TotalSubsidy,
+		InitialBlockDownload: !s.chain.IsCurrent(),
+	}, restFormatJSON, nil
+}
+
+// handleRESTMempool implements the /rest/mempool/info.json and
+// /rest/mempool/contents.json resources.
+func handleRESTMempool(s *restServer, params []string) (interface{}, restFormat, error) {
+	if len(params) != 1 {
+		return nil, 0, newRESTError(http.StatusBadRequest, "usage: "+
+			"/rest/mempool/<info|contents>.json")
+	}
+	resource, format, err := parseRESTResource(params[0])
+	if err != nil {
+		return nil, 0, err
+	}
+	if format != restFormatJSON {
+		return nil, 0, newRESTError(http.StatusNotFound, "output format "+
+			"not supported (available: .json)")
+	}
+
+	switch resource {
+	case "info":
+		result, err := handleGetMempoolInfo(s.rpc, nil, nil)
+		return result, format, err
+	case "contents":
+		return s.server.txMemPool.RawMempoolVerbose(nil), format, nil
+	}
+	return nil, 0, newRESTError(http.StatusNotFound, "unknown mempool "+
+		"resource: %s", resource)
+}
+
+// handleRESTGetUtxos implements the
+// /rest/getutxos[/checkmempool]/<txid>-<n>/<txid>-<n>/.../<txid>-<n>.<format>
+// resource.  It reports which of the requested outpoints are unspent along
+// with the details of each unspent output.  When checkmempool is specified,
+// outputs created by memory pool transactions are considered unspent and
+// outputs spent by memory pool transactions are considered spent.
+func handleRESTGetUtxos(s *restServer, params []string) (interface{}, restFormat, error) {
+	if len(params) == 0 {
+		return nil, 0, newRESTError(http.StatusBadRequest, "usage: "+
+			"/rest/getutxos[/checkmempool]/<txid>-<n>/.../"+
+			"<txid>-<n>.<json|hex|bin>")
+	}
+	last, format, err := parseRESTResource(params[len(params)-1])
+	if err != nil {
+		return nil, 0, err
+	}
+	params[len(params)-1] = last
+
+	checkMempool := params[0] == "checkmempool"
+	if checkMempool {
+		params = params[1:]
+	}
+	if len(params) == 0 {
+		return nil, 0, newRESTError(http.StatusBadRequest, "no outpoints "+
+			"specified")
+	}
+	if len(params) > restMaxOutpoints {
+		return nil, 0, newRESTError(http.StatusBadRequest, "too many "+
+			"outpoints requested (max %d)", restMaxOutpoints)
+	}
+
+	outPoints := make([]*wire.OutPoint, 0, len(params))
+	for _, param := range params {
+		op, err := parseRESTOutPoint(param)
+		if err != nil {
+			return nil, 0, err
+		}
+		outPoints = append(outPoints, op)
+	}
+
+	best := s.chain.BestSnapshot()
+	result := &restGetUtxosResult{
+		ChainHeight:  best.Height,
+		ChainTipHash: best.Hash.String(),
+		Utxos:        make([]restUtxoResult, 0, len(outPoints)),
+		tipHash:      best.Hash,
+		bitmap:       make([]byte, (len(outPoints)+7)/8),
+	}
+	chainParams := s.server.chainParams
+	for i, op := range outPoints {
+		var utxo restUtxoResult
+		var found bool
+		if checkMempool {
+			utxo, found = s.mempoolUtxo(op)
+		}
+		if !found {
+			entry, err := s.chain.FetchUtxoEntry(&op.Hash)
+			if err != nil {
+				return nil, 0, err
+			}
+			if entry == nil || entry.IsOutputSpent(op.Index) {
+				continue
+			}
+			utxo = restUtxoResult{
+				Height:        entry.BlockHeight(),
+				TxVersion:     entry.TxVersion(),
+				TxType:        txTypeString(entry.TransactionType()),
+				Coinbase:      entry.IsCoinBase(),
+				atoms:         entry.AmountByIndex(op.Index),
+				scriptVersion: entry.ScriptVersionByIndex(op.Index),
+				pkScript:      entry.PkScriptByIndex(op.Index),
+			}
+			op.Tree = wire.TxTreeRegular
+			if utxo.TxType != "regular" {
+				op.Tree = wire.TxTreeStake
+			}
+		}
+
+		// Outputs spent by a transaction in the memory pool are treated
+		// as spent when requested.
+		if checkMempool && s.server.txMemPool.CheckSpend(*op) != nil {
+			continue
+		}
+
+		utxo.Value = cdrutil.Amount(utxo.atoms).ToCoin()
+		disbuf, _ := txscript.DisasmString(utxo.pkScript)
+		class, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(
+			utxo.scriptVersion, utxo.pkScript, chainParams)
+		addresses := make([]string, len(addrs))
+		for j, addr := range addrs {
+			addresses[j] = addr.EncodeAddress()
+		}
+		utxo.ScriptPubKey = cdrjson.ScriptPubKeyResult{
+			Asm:       disbuf,
+			Hex:       hex.EncodeToString(utxo.pkScript),
+			ReqSigs:   int32(reqSigs),
+			Type:      class.String(),
+			Addresses: addresses,
+		}
+
+		result.bitmap[i/8] |= 1 << uint(i%8)
+		result.Utxos = append(result.Utxos, utxo)
+	}
+	result.Bitmap = hex.EncodeToString(result.bitmap)
+
+	if format == restFormatJSON {
+		return result, format, nil
+	}
+	serialized, err := result.serialize()
+	if err != nil {
+		return nil, 0, err
+	}
+	return serialized, format, nil
+}
+
+// mempoolUtxo returns the output referenced by the passed outpoint when it is
+// created by a transaction in the memory pool.  The tree of the outpoint is
+// updated to match the tree of the transaction that created it.
+func (s *restServer) mempoolUtxo(op *wire.OutPoint) (restUtxoResult, bool) {
+	tx, err := s.server.txMemPool.FetchTransaction(&op.Hash, false)
+	if err != nil {
+		return restUtxoResult{}, false
+	}
+	msgTx := tx.MsgTx()
+	if op.Index >= uint32(len(msgTx.TxOut)) {
+		return restUtxoResult{}, false
+	}
+
+	txType := stake.DetermineTxType(msgTx)
+	op.Tree = wire.TxTreeRegular
+	if txType != stake.TxTypeRegular {
+		op.Tree = wire.TxTreeStake
+	}
+	txOut := msgTx.TxOut[op.Index]
+	return restUtxoResult{
+		Height:        restMempoolHeight,
+		TxVersion:     msgTx.Version,
+		TxType:        txTypeString(txType),
+		atoms:         txOut.Value,
+		scriptVersion: txOut.Version,
+		pkScript:      txOut.PkScript,
+	}, true
+}
+
+// txTypeString returns a human-readable name for the passed stake transaction
+// type.
+func txTypeString(txType stake.TxType) string {
+	switch txType {
+	case stake.TxTypeSStx:
+		return "ticket"
+	case stake.TxTypeSSGen:
+		return "vote"
+	case stake.TxTypeSSRtx:
+		return "revocation"
+	}
+	return "regular"
+}
+
+// handleRESTCFilter implements the /rest/cfilter/[<type>/]<hash>.<format>
+// resource.  The filter type is either regular or extended and defaults to
+// regular when not specified.
+func handleRESTCFilter(s *restServer, params []string) (interface{}, restFormat, error) {
+	filterType := "regular"
+	switch len(params) {
+	case 1:
+	case 2:
+		filterType = params[0]
+		params = params[1:]
+	default:
+		return nil, 0, newRESTError(http.StatusBadRequest, "usage: "+
+			"/rest/cfilter/[<regular|extended>/]<hash>."+
+			"<json|hex|bin>")
+	}
+	hashStr, format, err := parseRESTResource(params[0])
+	if err != nil {
+		return nil, 0, err
+	}
+	hash, err := parseRESTHash(hashStr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result, err := handleGetCFilter(s.rpc, &cdrjson.GetCFilterCmd{
+		Hash:       hash.String(),
+		FilterType: filterType,
+	}, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	filterHex := result.(string)
+
+	switch format {
+	case restFormatJSON:
+		return &restCFilterResult{
+			BlockHash:  hash.String(),
+			FilterType: filterType,
+			Data:       filterHex,
+		}, format, nil
+	case restFormatBinary:
+		filterBytes, err := hex.DecodeString(filterHex)
+		if err != nil {
+			return nil, 0, err
+		}
+		return filterBytes, format, nil
+	}
+	return filterHex, format, nil
+}
+
+// newRESTServer returns a new instance of the restServer struct.
+func newRESTServer(listenAddrs []string, s *server) (*restServer, error) {
+	rest := restServer{
+		server: s,
+		chain:  s.blockManager.chain,
+		rpc: &rpcServer{
+			server: s,
+			chain:  s.blockManager.chain,
+		},
+	}
+
+	// The REST server shares the TLS configuration of the RPC server.
+	listenFunc := net.Listen
+	if !cfg.DisableTLS {
+		if !fileExists(cfg.RPCKey) && !fileExists(cfg.RPCCert) {
+			err := genCertPair(cfg.RPCCert, cfg.RPCKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+		keypair, err := tls.LoadX509KeyPair(cfg.RPCCert, cfg.RPCKey)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig := tls.Config{
+			Certificates: []tls.Certificate{keypair},
+			MinVersion:   tls.VersionTLS12,
+		}
+		listenFunc = func(net string, laddr string) (net.Listener, error) {
+			return tls.Listen(net, laddr, &tlsConfig)
+		}
+	}
+
+	ipv4ListenAddrs, ipv6ListenAddrs, _, err := parseListeners(listenAddrs)
+	if err != nil {
+		return nil, err
+	}
+	listeners := make([]net.Listener, 0,
+		len(ipv6ListenAddrs)+len(ipv4ListenAddrs))
+	for _, addr := range ipv4ListenAddrs {
+		listener, err := listenFunc("tcp4", addr)
+		if err != nil {
+			restLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	for _, addr := range ipv6ListenAddrs {
+		listener, err := listenFunc("tcp6", addr)
+		if err != nil {
+			restLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("REST: No valid listen address")
+	}
+	rest.listeners = listeners
+
+	return &rest, nil
+}
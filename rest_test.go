@@ -0,0 +1,123 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// TestParseRESTResource ensures the format suffix of REST resources is parsed
+// as expected.
+func TestParseRESTResource(t *testing.T) {
+	tests := []struct {
+		param    string
+		resource string
+		format   restFormat
+		status   int
+	}{
+		{param: "chaininfo.json", resource: "chaininfo", format: restFormatJSON},
+		{param: "abc.hex", resource: "abc", format: restFormatHex},
+		{param: "abc.def.bin", resource: "abc.def", format: restFormatBinary},
+		{param: "abc", status: http.StatusNotFound},
+		{param: "abc.xml", status: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		resource, format, err := parseRESTResource(test.param)
+		if test.status != 0 {
+			restErr, ok := err.(*restError)
+			if !ok || restErr.status != test.status {
+				t.Errorf("parseRESTResource(%q): unexpected error "+
+					"- got %v, want status %d", test.param, err,
+					test.status)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRESTResource(%q): unexpected error: %v",
+				test.param, err)
+			continue
+		}
+		if resource != test.resource || format != test.format {
+			t.Errorf("parseRESTResource(%q): got (%q, %d), want "+
+				"(%q, %d)", test.param, resource, format,
+				test.resource, test.format)
+		}
+	}
+}
+
+// TestParseRESTOutPoint ensures outpoints provided to the getutxos resource
+// are parsed as expected.
+func TestParseRESTOutPoint(t *testing.T) {
+	const txid = "2b6f1b4e0d9a5e6c0e1ff9cb1b0e8bf3c3c6c2e4e6d8c4ce0a6f9e3b1d2c3a4b"
+	op, err := parseRESTOutPoint(txid + "-3")
+	if err != nil {
+		t.Fatalf("parseRESTOutPoint: unexpected error: %v", err)
+	}
+	if op.Hash.String() != txid || op.Index != 3 {
+		t.Fatalf("parseRESTOutPoint: got %v, want %s:3", op, txid)
+	}
+
+	invalid := []string{txid, txid + "-", txid + "-x", txid[1:] + "-0",
+		"zz" + txid[2:] + "-0", txid + "-4294967296"}
+	for _, str := range invalid {
+		if _, err := parseRESTOutPoint(str); err == nil {
+			t.Errorf("parseRESTOutPoint(%q): did not receive "+
+				"expected error", str)
+		}
+	}
+}
+
+// TestRESTGetUtxosSerialize ensures the binary encoding of the getutxos
+// resource is as expected.
+func TestRESTGetUtxosSerialize(t *testing.T) {
+	result := restGetUtxosResult{
+		ChainHeight: 0x0102,
+		tipHash:     chainhash.Hash{0x01},
+		bitmap:      []byte{0x05},
+		Utxos: []restUtxoResult{{
+			Height:        0x0100,
+			atoms:         0x0200,
+			scriptVersion: 1,
+			pkScript:      []byte{0xac},
+		}, {
+			Height: restMempoolHeight,
+			atoms:  1,
+		}},
+	}
+	got, err := result.serialize()
+	if err != nil {
+		t.Fatalf("serialize: unexpected error: %v", err)
+	}
+
+	var want bytes.Buffer
+	want.Write([]byte{0x02, 0x01, 0x00, 0x00})
+	want.Write(result.tipHash[:])
+	want.Write([]byte{0x01, 0x05, 0x02})
+	want.Write([]byte{0x00, 0x01, 0x00, 0x00})
+	want.Write([]byte{0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	want.Write([]byte{0x01, 0x00, 0x01, 0xac})
+	want.Write([]byte{0xff, 0xff, 0xff, 0x7f})
+	want.Write([]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	want.Write([]byte{0x00, 0x00, 0x00})
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Fatalf("serialize: mismatched result - got %s, want %s",
+			hex.EncodeToString(got), hex.EncodeToString(want.Bytes()))
+	}
+
+	// Ensure the bitmap and count can be read back with the wire helpers.
+	r := bytes.NewReader(got[4+chainhash.HashSize:])
+	bitmap, err := wire.ReadVarBytes(r, 0, 1024, "bitmap")
+	if err != nil || !bytes.Equal(bitmap, result.bitmap) {
+		t.Fatalf("ReadVarBytes: got %x (err %v), want %x", bitmap, err,
+			result.bitmap)
+	}
+}
@@ -356,6 +356,49 @@ func (c *Client) GetBlockHeaderVerbose(hash *chainhash.Hash) (*cdrjson.GetBlockH
 	return c.GetBlockHeaderVerboseAsync(hash).Receive()
 }
 
+// FutureGetBlockStatsResult is a future promise to deliver the result of a
+// GetBlockStatsAsync RPC invocation (or an applicable error).
+type FutureGetBlockStatsResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// statistics of the block requested from the server given its hash.
+func (r FutureGetBlockStatsResult) Receive() (*cdrjson.GetBlockStatsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result
+	var stats cdrjson.GetBlockStatsResult
+	err = json.Unmarshal(res, &stats)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetBlockStatsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetBlockStats for the blocking version and more details.
+func (c *Client) GetBlockStatsAsync(hash *chainhash.Hash, stats []string) FutureGetBlockStatsResult {
+	var statsPtr *[]string
+	if len(stats) > 0 {
+		statsPtr = &stats
+	}
+	cmd := cdrjson.NewGetBlockStatsCmd(hash.String(), statsPtr)
+	return c.sendCmd(cmd)
+}
+
+// GetBlockStats returns statistics about the transactions, fees, and subsidy
+// of the block with the given hash.  Only the named stats are requested when
+// any are provided, in which case the remaining fields of the result are left
+// at their zero values.
+func (c *Client) GetBlockStats(hash *chainhash.Hash, stats []string) (*cdrjson.GetBlockStatsResult, error) {
+	return c.GetBlockStatsAsync(hash, stats).Receive()
+}
+
 // FutureGetBlockSubsidyResult is a future promise to deliver the result of a
 // GetBlockSubsidyAsync RPC invocation (or an applicable error).
 type FutureGetBlockSubsidyResult chan *response
@@ -394,6 +437,50 @@ func (c *Client) GetBlockSubsidy(height int64, voters uint16) (*cdrjson.GetBlock
 	return c.GetBlockSubsidyAsync(height, voters).Receive()
 }
 
+// FutureGetChainTxStatsResult is a future promise to deliver the result of a
+// GetChainTxStatsAsync RPC invocation (or an applicable error).
+type FutureGetChainTxStatsResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// transaction statistics of the requested window of blocks.
+func (r FutureGetChainTxStatsResult) Receive() (*cdrjson.GetChainTxStatsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result
+	var stats cdrjson.GetChainTxStatsResult
+	err = json.Unmarshal(res, &stats)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetChainTxStatsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetChainTxStats for the blocking version and more details.
+func (c *Client) GetChainTxStatsAsync(nBlocks *int32, hash *chainhash.Hash) FutureGetChainTxStatsResult {
+	var hashStr *string
+	if hash != nil {
+		hashStr = cdrjson.String(hash.String())
+	}
+	cmd := cdrjson.NewGetChainTxStatsCmd(nBlocks, hashStr)
+	return c.sendCmd(cmd)
+}
+
+// GetChainTxStats returns statistics about the total number and rate of
+// transactions in the main chain over the window of the given number of blocks
+// which ends with the block with the given hash.  Passing nil for either
+// parameter uses the server default of approximately one month worth of blocks
+// ending at the current best block respectively.
+func (c *Client) GetChainTxStats(nBlocks *int32, hash *chainhash.Hash) (*cdrjson.GetChainTxStatsResult, error) {
+	return c.GetChainTxStatsAsync(nBlocks, hash).Receive()
+}
+
 // FutureGetCoinSupplyResult is a future promise to deliver the result of a
 // GetCoinSupplyAsync RPC invocation (or an applicable error).
 type FutureGetCoinSupplyResult chan *response
@@ -614,6 +701,115 @@ func (c *Client) VerifyChainBlocks(checkLevel, numBlocks int64) (bool, error) {
 	return c.VerifyChainBlocksAsync(checkLevel, numBlocks).Receive()
 }
 
+// FutureVerifyChainVerboseResult is a future promise to deliver the result of a
+// VerifyChainVerboseAsync RPC invocation (or an applicable error).
+type FutureVerifyChainVerboseResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// details of the chain verification, including all of the inconsistencies that
+// were found.
+func (r FutureVerifyChainVerboseResult) Receive() (*cdrjson.VerifyChainResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a verifychain result object.
+	var result cdrjson.VerifyChainResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// VerifyChainVerboseAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See VerifyChainVerbose for the blocking version and more details.
+func (c *Client) VerifyChainVerboseAsync(checkLevel, numBlocks int64) FutureVerifyChainVerboseResult {
+	cmd := cdrjson.NewVerifyChainCmd(&checkLevel, &numBlocks)
+	cmd.Verbose = cdrjson.Bool(true)
+	return c.sendCmd(cmd)
+}
+
+// VerifyChainVerbose requests the server to verify the block chain database
+// using the passed check level and number of blocks to verify and returns the
+// details of the verification, including all of the inconsistencies that were
+// found rather than only whether or not the chain verified.
+//
+// See VerifyChainBlocks to only retrieve whether or not the chain verified.
+func (c *Client) VerifyChainVerbose(checkLevel, numBlocks int64) (*cdrjson.VerifyChainResult, error) {
+	return c.VerifyChainVerboseAsync(checkLevel, numBlocks).Receive()
+}
+
+// FutureInvalidateBlockResult is a future promise to deliver the result of a
+// InvalidateBlockAsync RPC invocation (or an applicable error).
+type FutureInvalidateBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns the error,
+// if any, of marking the block invalid.
+func (r FutureInvalidateBlockResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// InvalidateBlockAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See InvalidateBlock for the blocking version and more details.
+func (c *Client) InvalidateBlockAsync(blockHash *chainhash.Hash) FutureInvalidateBlockResult {
+	hash := ""
+	if blockHash != nil {
+		hash = blockHash.String()
+	}
+
+	cmd := cdrjson.NewInvalidateBlockCmd(hash)
+	return c.sendCmd(cmd)
+}
+
+// InvalidateBlock permanently marks the block with the given hash and all of
+// its descendants as invalid, which reorganizes the chain of the server when
+// the block is part of its best chain.
+func (c *Client) InvalidateBlock(blockHash *chainhash.Hash) error {
+	return c.InvalidateBlockAsync(blockHash).Receive()
+}
+
+// FutureReconsiderBlockResult is a future promise to deliver the result of a
+// ReconsiderBlockAsync RPC invocation (or an applicable error).
+type FutureReconsiderBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns the error,
+// if any, of reconsidering the block.
+func (r FutureReconsiderBlockResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// ReconsiderBlockAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See ReconsiderBlock for the blocking version and more details.
+func (c *Client) ReconsiderBlockAsync(blockHash *chainhash.Hash) FutureReconsiderBlockResult {
+	hash := ""
+	if blockHash != nil {
+		hash = blockHash.String()
+	}
+
+	cmd := cdrjson.NewReconsiderBlockCmd(hash)
+	return c.sendCmd(cmd)
+}
+
+// ReconsiderBlock removes the invalid status of the block with the given hash
+// along with that of its ancestors and descendants and causes the server to
+// reorganize to the valid chain with the most cumulative work.
+func (c *Client) ReconsiderBlock(blockHash *chainhash.Hash) error {
+	return c.ReconsiderBlockAsync(blockHash).Receive()
+}
+
 // FutureGetTxOutResult is a future promise to deliver the result of a
 // GetTxOutAsync RPC invocation (or an applicable error).
 type FutureGetTxOutResult chan *response
@@ -663,6 +859,245 @@ func (c *Client) GetTxOut(txHash *chainhash.Hash, index uint32, mempool bool) (*
 	return c.GetTxOutAsync(txHash, index, mempool).Receive()
 }
 
+// FutureGetTxOutProofResult is a future promise to deliver the result of a
+// GetTxOutProofAsync RPC invocation (or an applicable error).
+type FutureGetTxOutProofResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// merkleblock message which proves the inclusion of the requested transactions.
+func (r FutureGetTxOutProofResult) Receive() (*wire.MsgMerkleBlock, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a string.
+	var proofHex string
+	err = json.Unmarshal(res, &proofHex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode the serialized proof hex to raw bytes.
+	serializedProof, err := hex.DecodeString(proofHex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deserialize the merkleblock message and return it.
+	var msgMerkleBlock wire.MsgMerkleBlock
+	err = msgMerkleBlock.BtcDecode(bytes.NewReader(serializedProof),
+		wire.ProtocolVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &msgMerkleBlock, nil
+}
+
+// GetTxOutProofAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetTxOutProof for the blocking version and more details.
+func (c *Client) GetTxOutProofAsync(txHashes []*chainhash.Hash, blockHash *chainhash.Hash) FutureGetTxOutProofResult {
+	txIDs := make([]string, 0, len(txHashes))
+	for _, txHash := range txHashes {
+		txIDs = append(txIDs, txHash.String())
+	}
+	var hash *string
+	if blockHash != nil {
+		hash = cdrjson.String(blockHash.String())
+	}
+
+	cmd := cdrjson.NewGetTxOutProofCmd(txIDs, hash)
+	return c.sendCmd(cmd)
+}
+
+// GetTxOutProof returns a merkleblock message which proves the inclusion of the
+// transactions with the given hashes in a block.  The block is looked up by the
+// server when the block hash is nil.
+func (c *Client) GetTxOutProof(txHashes []*chainhash.Hash, blockHash *chainhash.Hash) (*wire.MsgMerkleBlock, error) {
+	return c.GetTxOutProofAsync(txHashes, blockHash).Receive()
+}
+
+// FutureVerifyTxOutProofResult is a future promise to deliver the result of a
+// VerifyTxOutProofAsync RPC invocation (or an applicable error).
+type FutureVerifyTxOutProofResult chan *response
+
+// Receive waits for the response promised by the future and returns the hashes
+// of the transactions the proof commits to.
+func (r FutureVerifyTxOutProofResult) Receive() ([]*chainhash.Hash, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of strings.
+	var txIDs []string
+	err = json.Unmarshal(res, &txIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create a slice of transaction hashes to return.
+	txHashes := make([]*chainhash.Hash, 0, len(txIDs))
+	for _, txID := range txIDs {
+		txHash, err := chainhash.NewHashFromStr(txID)
+		if err != nil {
+			return nil, err
+		}
+		txHashes = append(txHashes, txHash)
+	}
+	return txHashes, nil
+}
+
+// VerifyTxOutProofAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See VerifyTxOutProof for the blocking version and more details.
+func (c *Client) VerifyTxOutProofAsync(proof *wire.MsgMerkleBlock) FutureVerifyTxOutProofResult {
+	proofHex := ""
+	if proof != nil {
+		var buf bytes.Buffer
+		if err := proof.BtcEncode(&buf, wire.ProtocolVersion); err != nil {
+			return newFutureError(err)
+		}
+		proofHex = hex.EncodeToString(buf.Bytes())
+	}
+
+	cmd := cdrjson.NewVerifyTxOutProofCmd(proofHex)
+	return c.sendCmd(cmd)
+}
+
+// VerifyTxOutProof verifies the provided merkleblock message created by
+// GetTxOutProof and returns the hashes of the transactions it commits to.  An
+// error is returned when the proof is invalid or its block is not part of the
+// main chain of the server.
+func (c *Client) VerifyTxOutProof(proof *wire.MsgMerkleBlock) ([]*chainhash.Hash, error) {
+	return c.VerifyTxOutProofAsync(proof).Receive()
+}
+
+// FutureScanTxOutSetResult is a future promise to deliver the result of a
+// ScanTxOutSetAsync RPC invocation (or an applicable error).
+type FutureScanTxOutSetResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// unspent transaction outputs which matched the scan.
+func (r FutureScanTxOutSetResult) Receive() (*cdrjson.ScanTxOutSetResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result
+	var result cdrjson.ScanTxOutSetResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ScanTxOutSetAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ScanTxOutSet for the blocking version and more details.
+func (c *Client) ScanTxOutSetAsync(descriptors []cdrjson.Descriptor) FutureScanTxOutSetResult {
+	cmd := cdrjson.NewScanTxOutSetCmd(cdrjson.ScanTxOutSetStart,
+		&descriptors)
+	return c.sendCmd(cmd)
+}
+
+// ScanTxOutSet scans the unspent transaction output set for outputs paying to
+// the output scripts described by the provided descriptors.  The call does not
+// return until the scan completes, which may take a long time, so
+// ScanTxOutSetStatus and AbortScanTxOutSet may be used from another goroutine
+// to monitor or abort it.
+func (c *Client) ScanTxOutSet(descriptors []cdrjson.Descriptor) (*cdrjson.ScanTxOutSetResult, error) {
+	return c.ScanTxOutSetAsync(descriptors).Receive()
+}
+
+// FutureScanTxOutSetStatusResult is a future promise to deliver the result of
+// a ScanTxOutSetStatusAsync RPC invocation (or an applicable error).
+type FutureScanTxOutSetStatusResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// progress of the scan in progress as a percentage.  It returns false when no
+// scan is in progress.
+func (r FutureScanTxOutSetStatusResult) Receive() (float64, bool, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Unmarshal the result
+	var result *cdrjson.ScanTxOutSetStatusResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return 0, false, err
+	}
+	if result == nil {
+		return 0, false, nil
+	}
+	return result.Progress, true, nil
+}
+
+// ScanTxOutSetStatusAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See ScanTxOutSetStatus for the blocking version and more details.
+func (c *Client) ScanTxOutSetStatusAsync() FutureScanTxOutSetStatusResult {
+	cmd := cdrjson.NewScanTxOutSetCmd(cdrjson.ScanTxOutSetStatus, nil)
+	return c.sendCmd(cmd)
+}
+
+// ScanTxOutSetStatus returns the progress of the unspent transaction output set
+// scan in progress as a percentage along with whether or not a scan is in
+// progress.
+func (c *Client) ScanTxOutSetStatus() (float64, bool, error) {
+	return c.ScanTxOutSetStatusAsync().Receive()
+}
+
+// FutureAbortScanTxOutSetResult is a future promise to deliver the result of
+// an AbortScanTxOutSetAsync RPC invocation (or an applicable error).
+type FutureAbortScanTxOutSetResult chan *response
+
+// Receive waits for the response promised by the future and returns whether or
+// not a scan was in progress and has been aborted.
+func (r FutureAbortScanTxOutSetResult) Receive() (bool, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return false, err
+	}
+
+	// Unmarshal the result
+	var aborted bool
+	err = json.Unmarshal(res, &aborted)
+	if err != nil {
+		return false, err
+	}
+	return aborted, nil
+}
+
+// AbortScanTxOutSetAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See AbortScanTxOutSet for the blocking version and more details.
+func (c *Client) AbortScanTxOutSetAsync() FutureAbortScanTxOutSetResult {
+	cmd := cdrjson.NewScanTxOutSetCmd(cdrjson.ScanTxOutSetAbort, nil)
+	return c.sendCmd(cmd)
+}
+
+// AbortScanTxOutSet aborts the unspent transaction output set scan in progress
+// and returns whether or not a scan was in progress.
+func (c *Client) AbortScanTxOutSet() (bool, error) {
+	return c.AbortScanTxOutSetAsync().Receive()
+}
+
 // FutureRescanResult is a future promise to deliver the result of a
 // RescanAsynnc RPC invocation (or an applicable error).
 type FutureRescanResult chan *response
@@ -695,7 +1130,7 @@ func (c *Client) RescanAsync(blockHashes []chainhash.Hash) FutureRescanResult {
 		copy(concatenatedBlockHashes[i*chainhash.HashSize:], blockHashes[i][:])
 	}
 
-	cmd := cdrjson.NewRescanCmd(hex.EncodeToString(concatenatedBlockHashes))
+	cmd := cdrjson.NewRescanCmd(hex.EncodeToString(concatenatedBlockHashes), nil)
 	return c.sendCmd(cmd)
 }
 
@@ -706,6 +1141,34 @@ func (c *Client) Rescan(blockHashes []chainhash.Hash) (*cdrjson.RescanResult, er
 	return c.RescanAsync(blockHashes).Receive()
 }
 
+// RescanDescriptorsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See RescanDescriptors for the blocking version and more details.
+func (c *Client) RescanDescriptorsAsync(blockHashes []chainhash.Hash,
+	descriptors []cdrjson.Descriptor) FutureRescanResult {
+
+	concatenatedBlockHashes := make([]byte, chainhash.HashSize*len(blockHashes))
+	for i := range blockHashes {
+		copy(concatenatedBlockHashes[i*chainhash.HashSize:], blockHashes[i][:])
+	}
+
+	cmd := cdrjson.NewRescanCmd(hex.EncodeToString(concatenatedBlockHashes),
+		&descriptors)
+	return c.sendCmd(cmd)
+}
+
+// RescanDescriptors adds the addresses described by the output script
+// descriptors to the client's transaction filter, loading a new filter if none
+// is loaded, and then rescans the blocks identified by blockHashes in the same
+// manner as Rescan.
+func (c *Client) RescanDescriptors(blockHashes []chainhash.Hash,
+	descriptors []cdrjson.Descriptor) (*cdrjson.RescanResult, error) {
+
+	return c.RescanDescriptorsAsync(blockHashes, descriptors).Receive()
+}
+
 // FutureGetCFilterResult is a future promise to deliver the result of a
 // GetCFilterAsync RPC invocation (or an applicable error).
 type FutureGetCFilterResult chan *response
@@ -256,6 +256,14 @@ func (c *Client) trackRegisteredNtfns(cmd interface{}) {
 	case *cdrjson.NotifyBlocksCmd:
 		c.ntfnState.notifyBlocks = true
 
+	case *cdrjson.NotifyBlocksFromCmd:
+		// Events which are replayed may be received before the reply,
+		// so the cursor is only set when none have been received yet.
+		c.ntfnState.notifyChainEvents = true
+		if c.ntfnState.chainEventCursor == "" {
+			c.ntfnState.chainEventCursor = bcmd.Cursor
+		}
+
 	case *cdrjson.NotifyNewTransactionsCmd:
 		if bcmd.Verbose != nil && *bcmd.Verbose {
 			c.ntfnState.notifyNewTxVerbose = true
@@ -524,6 +532,16 @@ func (c *Client) reregisterNtfns() error {
 		}
 	}
 
+	// Reregister notifyblocksfrom if needed, resuming from the last chain
+	// event that was received.
+	if stateCopy.notifyChainEvents {
+		log.Debugf("Reregistering [notifyblocksfrom] from %s",
+			stateCopy.chainEventCursor)
+		if err := c.NotifyBlocksFrom(stateCopy.chainEventCursor); err != nil {
+			return err
+		}
+	}
+
 	// Reregister notifywinningtickets if needed.
 	if stateCopy.notifyWinningTickets {
 		log.Debugf("Reregistering [notifywinningtickets]")
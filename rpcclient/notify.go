@@ -32,6 +32,8 @@ var (
 // reconnect.
 type notificationState struct {
 	notifyBlocks                bool
+	notifyChainEvents           bool
+	chainEventCursor            string
 	notifyWinningTickets        bool
 	notifySpentAndMissedTickets bool
 	notifyNewTickets            bool
@@ -44,6 +46,8 @@ type notificationState struct {
 func (s *notificationState) Copy() *notificationState {
 	var stateCopy notificationState
 	stateCopy.notifyBlocks = s.notifyBlocks
+	stateCopy.notifyChainEvents = s.notifyChainEvents
+	stateCopy.chainEventCursor = s.chainEventCursor
 	stateCopy.notifyWinningTickets = s.notifyWinningTickets
 	stateCopy.notifySpentAndMissedTickets = s.notifySpentAndMissedTickets
 	stateCopy.notifyNewTickets = s.notifyNewTickets
@@ -96,6 +100,14 @@ type NotificationHandlers struct {
 	// function is non-nil.
 	OnBlockDisconnected func(blockHeader []byte)
 
+	// OnChainEvent is invoked for every event in the chain event log of the
+	// server which follows the cursor passed to NotifyBlocksFrom.  It will
+	// only be invoked if a preceding call to NotifyBlocksFrom has been made
+	// to register for the notification and the function is non-nil.  The
+	// sequence number of the last event is used to resume from when the
+	// client reconnects.
+	OnChainEvent func(event *cdrjson.ChainEventResult)
+
 	// OnRelevantTxAccepted is invoked when an unmined transaction passes
 	// the client's transaction filter.
 	OnRelevantTxAccepted func(transaction []byte)
@@ -248,6 +260,25 @@ func (c *Client) handleNotification(ntfn *rawNotification) {
 
 		c.ntfnHandlers.OnBlockDisconnected(blockHeader)
 
+	// OnChainEvent
+	case cdrjson.ChainEventNtfnMethod:
+		event, err := parseChainEventNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warnf("Received invalid chainevent notification: %v",
+				err)
+			return
+		}
+
+		if c.ntfnHandlers.OnChainEvent != nil {
+			c.ntfnHandlers.OnChainEvent(event)
+		}
+
+		// Resume from the event on reconnect now that it has been
+		// processed.
+		c.ntfnStateLock.Lock()
+		c.ntfnState.chainEventCursor = strconv.FormatUint(event.Sequence, 10)
+		c.ntfnStateLock.Unlock()
+
 	case cdrjson.RelevantTxAcceptedNtfnMethod:
 		// Ignore the notification if the client is not interested in
 		// it.
@@ -580,6 +611,23 @@ func parseRelevantTxAcceptedParams(params []json.RawMessage) (transaction []byte
 	return parseHexParam(params[0])
 }
 
+// parseChainEventNtfnParams parses out the chain event from the parameters of a
+// chainevent notification.
+func parseChainEventNtfnParams(params []json.RawMessage) (*cdrjson.ChainEventResult, error) {
+	if len(params) != 1 {
+		return nil, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a chain event result object.
+	var event cdrjson.ChainEventResult
+	err := json.Unmarshal(params[0], &event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
 func parseReorganizationNtfnParams(params []json.RawMessage) (*chainhash.Hash,
 	int32, *chainhash.Hash, int32, error) {
 	errorOut := func(err error) (*chainhash.Hash, int32, *chainhash.Hash,
@@ -1174,6 +1222,59 @@ func (c *Client) NotifyBlocks() error {
 	return c.NotifyBlocksAsync().Receive()
 }
 
+// FutureNotifyBlocksFromResult is a future promise to deliver the result of a
+// NotifyBlocksFromAsync RPC invocation (or an applicable error).
+type FutureNotifyBlocksFromResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the registration was not successful.
+func (r FutureNotifyBlocksFromResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// NotifyBlocksFromAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See NotifyBlocksFrom for the blocking version and more details.
+//
+// NOTE: This is a cdrd extension and requires a websocket connection.
+func (c *Client) NotifyBlocksFromAsync(cursor string) FutureNotifyBlocksFromResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := cdrjson.NewNotifyBlocksFromCmd(cursor)
+	return c.sendCmd(cmd)
+}
+
+// NotifyBlocksFrom registers the client to receive a notification for every
+// event in the chain event log of the server which follows the provided
+// cursor.  The cursor is either the sequence number of the last event that was
+// processed, "0" for all available events, or the hash of the last block that
+// was processed.  The events which follow the cursor are replayed before any
+// new ones, and the client automatically resumes from the last event it
+// received when it reconnects.  The notifications are delivered to the
+// notification handlers associated with the client.  Calling this function has
+// no effect if there are no notification handlers and will result in an error
+// if the client is configured to run in HTTP POST mode.
+//
+// The notifications delivered as a result of this call will be via
+// OnChainEvent.
+//
+// NOTE: This is a cdrd extension and requires a websocket connection.
+func (c *Client) NotifyBlocksFrom(cursor string) error {
+	return c.NotifyBlocksFromAsync(cursor).Receive()
+}
+
 // FutureNotifyWinningTicketsResult is a future promise to deliver the result of a
 // NotifyWinningTicketsAsync RPC invocation (or an applicable error).
 type FutureNotifyWinningTicketsResult chan *response
@@ -1455,7 +1556,7 @@ func (c *Client) LoadTxFilterAsync(reload bool, addresses []cdrutil.Address,
 		}
 	}
 
-	cmd := cdrjson.NewLoadTxFilterCmd(reload, addrStrs, outPointObjects)
+	cmd := cdrjson.NewLoadTxFilterCmd(reload, addrStrs, outPointObjects, nil)
 	return c.sendCmd(cmd)
 }
 
@@ -1467,3 +1568,26 @@ func (c *Client) LoadTxFilterAsync(reload bool, addresses []cdrutil.Address,
 func (c *Client) LoadTxFilter(reload bool, addresses []cdrutil.Address, outPoints []wire.OutPoint) error {
 	return c.LoadTxFilterAsync(reload, addresses, outPoints).Receive()
 }
+
+// LoadTxFilterDescriptorsAsync returns an instance of a type that can be used
+// to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See LoadTxFilterDescriptors for the blocking version and more details.
+//
+// NOTE: This is a cdrd extension and requires a websocket connection.
+func (c *Client) LoadTxFilterDescriptorsAsync(reload bool,
+	descriptors []cdrjson.Descriptor) FutureLoadTxFilterResult {
+
+	cmd := cdrjson.NewLoadTxFilterCmd(reload, []string{},
+		[]cdrjson.OutPoint{}, &descriptors)
+	return c.sendCmd(cmd)
+}
+
+// LoadTxFilterDescriptors loads, reloads, or adds the addresses described by
+// output script descriptors to a websocket client's transaction filter.
+//
+// NOTE: This is a cdrd extension and requires a websocket connection.
+func (c *Client) LoadTxFilterDescriptors(reload bool, descriptors []cdrjson.Descriptor) error {
+	return c.LoadTxFilterDescriptorsAsync(reload, descriptors).Receive()
+}
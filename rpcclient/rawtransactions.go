@@ -200,6 +200,57 @@ func (c *Client) DecodeRawTransaction(serializedTx []byte) (*cdrjson.TxRawResult
 	return c.DecodeRawTransactionAsync(serializedTx).Receive()
 }
 
+// FutureDebugScriptResult is a future promise to deliver the result of a
+// DebugScriptAsync RPC invocation (or an applicable error).
+type FutureDebugScriptResult chan *response
+
+// Receive waits for the response promised by the future and returns the trace
+// of executing the transaction input.
+func (r FutureDebugScriptResult) Receive() (*cdrjson.DebugScriptResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a debugscript result object.
+	var debugScriptResult cdrjson.DebugScriptResult
+	err = json.Unmarshal(res, &debugScriptResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &debugScriptResult, nil
+}
+
+// DebugScriptAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See DebugScript for the blocking version and more details.
+func (c *Client) DebugScriptAsync(tx *wire.MsgTx, index uint32, pkScript []byte, scriptVersion uint16) FutureDebugScriptResult {
+	txHex := ""
+	if tx != nil {
+		// Serialize the transaction and convert to hex string.
+		buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+		if err := tx.Serialize(buf); err != nil {
+			return newFutureError(err)
+		}
+		txHex = hex.EncodeToString(buf.Bytes())
+	}
+
+	cmd := cdrjson.NewDebugScriptCmd(txHex, index,
+		hex.EncodeToString(pkScript), &scriptVersion)
+	return c.sendCmd(cmd)
+}
+
+// DebugScript executes the signature script of the transaction input at the
+// provided index against the provided previous output script and returns the
+// state of the script engine after each executed opcode along with the step
+// execution failed at, if any.
+func (c *Client) DebugScript(tx *wire.MsgTx, index uint32, pkScript []byte, scriptVersion uint16) (*cdrjson.DebugScriptResult, error) {
+	return c.DebugScriptAsync(tx, index, pkScript, scriptVersion).Receive()
+}
+
 // FutureCreateRawTransactionResult is a future promise to deliver the result
 // of a CreateRawTransactionAsync RPC invocation (or an applicable error).
 type FutureCreateRawTransactionResult chan *response
@@ -855,3 +906,119 @@ func (c *Client) SearchRawTransactionsVerbose(address cdrutil.Address, skip,
 	return c.SearchRawTransactionsVerboseAsync(address, skip, count,
 		includePrevOut, reverse, &filterAddrs).Receive()
 }
+
+// FutureDecodePsbtResult is a future promise to deliver the result of a
+// DecodePsbtAsync RPC invocation (or an applicable error).
+type FutureDecodePsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns
+// information about a partially signed transaction.
+func (r FutureDecodePsbtResult) Receive() (*cdrjson.DecodePsbtResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a decodepsbt result object.
+	var decodePsbtResult cdrjson.DecodePsbtResult
+	err = json.Unmarshal(res, &decodePsbtResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decodePsbtResult, nil
+}
+
+// DecodePsbtAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See DecodePsbt for the blocking version and more details.
+func (c *Client) DecodePsbtAsync(psbt string) FutureDecodePsbtResult {
+	cmd := cdrjson.NewDecodePsbtCmd(psbt)
+	return c.sendCmd(cmd)
+}
+
+// DecodePsbt returns information about the passed base64-encoded partially
+// signed transaction.
+func (c *Client) DecodePsbt(psbt string) (*cdrjson.DecodePsbtResult, error) {
+	return c.DecodePsbtAsync(psbt).Receive()
+}
+
+// FutureCombinePsbtResult is a future promise to deliver the result of a
+// CombinePsbtAsync RPC invocation (or an applicable error).
+type FutureCombinePsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// base64-encoded combined partially signed transaction.
+func (r FutureCombinePsbtResult) Receive() (string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return "", err
+	}
+
+	// Unmarshal result as a string.
+	var psbt string
+	err = json.Unmarshal(res, &psbt)
+	if err != nil {
+		return "", err
+	}
+
+	return psbt, nil
+}
+
+// CombinePsbtAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See CombinePsbt for the blocking version and more details.
+func (c *Client) CombinePsbtAsync(psbts []string) FutureCombinePsbtResult {
+	cmd := cdrjson.NewCombinePsbtCmd(psbts)
+	return c.sendCmd(cmd)
+}
+
+// CombinePsbt merges the passed base64-encoded partially signed transactions
+// for the same unsigned transaction into one.
+func (c *Client) CombinePsbt(psbts []string) (string, error) {
+	return c.CombinePsbtAsync(psbts).Receive()
+}
+
+// FutureFinalizePsbtResult is a future promise to deliver the result of a
+// FinalizePsbtAsync RPC invocation (or an applicable error).
+type FutureFinalizePsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// finalized partially signed transaction or the fully signed transaction.
+func (r FutureFinalizePsbtResult) Receive() (*cdrjson.FinalizePsbtResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a finalizepsbt result object.
+	var finalizePsbtResult cdrjson.FinalizePsbtResult
+	err = json.Unmarshal(res, &finalizePsbtResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &finalizePsbtResult, nil
+}
+
+// FinalizePsbtAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See FinalizePsbt for the blocking version and more details.
+func (c *Client) FinalizePsbtAsync(psbt string, extract bool) FutureFinalizePsbtResult {
+	cmd := cdrjson.NewFinalizePsbtCmd(psbt, &extract)
+	return c.sendCmd(cmd)
+}
+
+// FinalizePsbt builds the final signature scripts of the inputs of the passed
+// base64-encoded partially signed transaction which have enough signatures.
+// When all inputs are finalized and extract is true, the hex-encoded fully
+// signed transaction is returned instead of the partially signed transaction.
+func (c *Client) FinalizePsbt(psbt string, extract bool) (*cdrjson.FinalizePsbtResult, error) {
+	return c.FinalizePsbtAsync(psbt, extract).Receive()
+}
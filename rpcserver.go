@@ -36,14 +36,18 @@ import (
 	"github.com/btcsuite/websocket"
 
 	"github.com/commanderu/cdrd/blockchain"
+	"github.com/commanderu/cdrd/blockchain/indexers"
 	"github.com/commanderu/cdrd/blockchain/stake"
 	"github.com/commanderu/cdrd/certgen"
 	"github.com/commanderu/cdrd/chaincfg"
 	"github.com/commanderu/cdrd/chaincfg/chainec"
 	"github.com/commanderu/cdrd/chaincfg/chainhash"
 	"github.com/commanderu/cdrd/database"
+	"github.com/commanderu/cdrd/descriptor"
 	"github.com/commanderu/cdrd/cdrjson"
 	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/cdrutil/psbt"
+	"github.com/commanderu/cdrd/hdkeychain"
 	"github.com/commanderu/cdrd/mempool"
 	"github.com/commanderu/cdrd/mining"
 	"github.com/commanderu/cdrd/txscript"
@@ -157,6 +161,14 @@ var (
 		Message: "This implementation does not implement wallet commands",
 	}
 
+	// ErrRPCHeadersOnly is an error returned to RPC clients when the
+	// provided command requires data that is not available in headers-only
+	// mode.
+	ErrRPCHeadersOnly = &cdrjson.RPCError{
+		Code:    cdrjson.ErrRPCMisc,
+		Message: "Command unavailable in headers-only mode",
+	}
+
 	// ErrInvalidLongPoll is an internal error code to indicate that
 	// longpollid is not formated properly.
 	ErrInvalidLongPoll = errors.New("invalid longpollid format")
@@ -170,12 +182,15 @@ type commandHandler func(*rpcServer, interface{}, <-chan struct{}) (interface{},
 var rpcHandlers map[string]commandHandler
 var rpcHandlersBeforeInit = map[string]commandHandler{
 	"addnode":               handleAddNode,
+	"combinepsbt":           handleCombinePsbt,
 	"createrawsstx":         handleCreateRawSStx,
 	"createrawssgentx":      handleCreateRawSSGenTx,
 	"createrawssrtx":        handleCreateRawSSRtx,
 	"createrawtransaction":  handleCreateRawTransaction,
 	"debuglevel":            handleDebugLevel,
+	"decodepsbt":            handleDecodePsbt,
 	"decoderawtransaction":  handleDecodeRawTransaction,
+	"debugscript":           handleDebugScript,
 	"decodescript":          handleDecodeScript,
 	"estimatefee":           handleEstimateFee,
 	"estimatestakediff":     handleEstimateStakeDiff,
@@ -186,6 +201,7 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"existsliveticket":      handleExistsLiveTicket,
 	"existslivetickets":     handleExistsLiveTickets,
 	"existsmempooltxs":      handleExistsMempoolTxs,
+	"finalizepsbt":          handleFinalizePsbt,
 	"generate":              handleGenerate,
 	"getaddednodeinfo":      handleGetAddedNodeInfo,
 	"getbestblock":          handleGetBestBlock,
@@ -194,8 +210,10 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getblockcount":         handleGetBlockCount,
 	"getblockhash":          handleGetBlockHash,
 	"getblockheader":        handleGetBlockHeader,
+	"getblockstats":         handleGetBlockStats,
 	"getblocksubsidy":       handleGetBlockSubsidy,
 	"getchaintips":          handleGetChainTips,
+	"getchaintxstats":       handleGetChainTxStats,
 	"getcoinsupply":         handleGetCoinSupply,
 	"getconnectioncount":    handleGetConnectionCount,
 	"getcurrentnet":         handleGetCurrentNet,
@@ -219,15 +237,19 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getticketpoolvalue":    handleGetTicketPoolValue,
 	"getvoteinfo":           handleGetVoteInfo,
 	"gettxout":              handleGetTxOut,
+	"gettxoutproof":         handleGetTxOutProof,
 	"getwork":               handleGetWork,
 	"help":                  handleHelp,
+	"invalidateblock":       handleInvalidateBlock,
 	"livetickets":           handleLiveTickets,
 	"missedtickets":         handleMissedTickets,
 	"node":                  handleNode,
 	"ping":                  handlePing,
+	"scantxoutset":          handleScanTxOutSet,
 	"searchrawtransactions": handleSearchRawTransactions,
 	"rebroadcastmissed":     handleRebroadcastMissed,
 	"rebroadcastwinners":    handleRebroadcastWinners,
+	"reconsiderblock":       handleReconsiderBlock,
 	"sendrawtransaction":    handleSendRawTransaction,
 	"setgenerate":           handleSetGenerate,
 	"stop":                  handleStop,
@@ -239,6 +261,7 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"validateaddress":       handleValidateAddress,
 	"verifychain":           handleVerifyChain,
 	"verifymessage":         handleVerifyMessage,
+	"verifytxoutproof":      handleVerifyTxOutProof,
 	"version":               handleVersion,
 }
 
@@ -303,6 +326,38 @@ var rpcUnimplemented = map[string]struct{}{
 	"getnetworkinfo":    {},
 }
 
+// Commands, including websocket commands, that are available in headers-only
+// mode since they can be answered from the block headers and verified committed
+// filters or do not involve the chain.
+var rpcHeadersOnly = map[string]struct{}{
+	"addnode":            {},
+	"debuglevel":         {},
+	"getaddednodeinfo":   {},
+	"getbestblock":       {},
+	"getbestblockhash":   {},
+	"getblockcount":      {},
+	"getblockhash":       {},
+	"getblockheader":     {},
+	"getcfilter":         {},
+	"getcfilterheader":   {},
+	"getchaintips":       {},
+	"getconnectioncount": {},
+	"getcurrentnet":      {},
+	"getdifficulty":      {},
+	"getheaders":         {},
+	"getnettotals":       {},
+	"getpeerinfo":        {},
+	"getstakedifficulty": {},
+	"help":               {},
+	"node":               {},
+	"ping":               {},
+	"session":            {},
+	"stop":               {},
+	"validateaddress":    {},
+	"verifymessage":      {},
+	"version":            {},
+}
+
 // Commands that are available to a limited user
 var rpcLimited = map[string]struct{}{
 	// Websockets commands
@@ -317,15 +372,21 @@ var rpcLimited = map[string]struct{}{
 	"help": {},
 
 	// HTTP/S-only commands
+	"combinepsbt":           {},
 	"createrawtransaction":  {},
+	"decodepsbt":            {},
 	"decoderawtransaction":  {},
+	"debugscript":           {},
 	"decodescript":          {},
+	"finalizepsbt":          {},
 	"getbestblock":          {},
 	"getbestblockhash":      {},
 	"getblock":              {},
 	"getblockcount":         {},
 	"getblockhash":          {},
+	"getblockstats":         {},
 	"getchaintips":          {},
+	"getchaintxstats":       {},
 	"getcurrentnet":         {},
 	"getdifficulty":         {},
 	"getinfo":               {},
@@ -334,11 +395,13 @@ var rpcLimited = map[string]struct{}{
 	"getrawmempool":         {},
 	"getrawtransaction":     {},
 	"gettxout":              {},
+	"gettxoutproof":         {},
 	"searchrawtransactions": {},
 	"sendrawtransaction":    {},
 	"submitblock":           {},
 	"validateaddress":       {},
 	"verifymessage":         {},
+	"verifytxoutproof":      {},
 	"version":               {},
 }
 
@@ -617,6 +680,50 @@ func messageToHex(msg wire.Message) (string, error) {
 	return hex.EncodeToString(buf.Bytes()), nil
 }
 
+// decodePsbtParam decodes the passed base64-encoded partially signed
+// transaction parameter.
+func decodePsbtParam(b64 string) (*psbt.Packet, error) {
+	packet, err := psbt.B64Decode(b64)
+	if err != nil {
+		return nil, rpcDeserializationError("Could not decode PSBT: %v",
+			err)
+	}
+	return packet, nil
+}
+
+// encodePsbtResult returns the base64 encoding of the passed partially signed
+// transaction.
+func encodePsbtResult(packet *psbt.Packet) (string, error) {
+	b64, err := packet.B64Encode()
+	if err != nil {
+		return "", rpcInternalError(err.Error(), "Failed to encode PSBT")
+	}
+	return b64, nil
+}
+
+// handleCombinePsbt handles combinepsbt commands.
+func handleCombinePsbt(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.CombinePsbtCmd)
+
+	if len(c.Psbts) == 0 {
+		return nil, rpcInvalidError("At least one PSBT is required")
+	}
+	packets := make([]*psbt.Packet, 0, len(c.Psbts))
+	for _, b64 := range c.Psbts {
+		packet, err := decodePsbtParam(b64)
+		if err != nil {
+			return nil, err
+		}
+		packets = append(packets, packet)
+	}
+
+	combined, err := psbt.Combine(packets...)
+	if err != nil {
+		return nil, rpcInvalidError("Could not combine PSBTs: %v", err)
+	}
+	return encodePsbtResult(combined)
+}
+
 // handleCreateRawTransaction handles createrawtransaction commands.
 func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*cdrjson.CreateRawTransactionCmd)
@@ -1406,6 +1513,270 @@ func createTxRawResult(chainParams *chaincfg.Params, mtx *wire.MsgTx, txHash str
 	return txReply, nil
 }
 
+// handleDebugScript handles debugscript commands.
+func handleDebugScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.DebugScriptCmd)
+
+	// Deserialize the transaction.
+	hexStr := c.HexTx
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serializedTx, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var mtx wire.MsgTx
+	err = mtx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, rpcDeserializationError("Could not decode Tx: %v",
+			err)
+	}
+	if int(c.Index) >= len(mtx.TxIn) {
+		return nil, rpcInvalidError("Transaction input index %d is out "+
+			"of range (%d inputs)", c.Index, len(mtx.TxIn))
+	}
+
+	// Convert the hex previous output script to bytes.
+	hexStr = c.PkScript
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	pkScript, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+
+	// Execute the script pair with the same flags used to accept
+	// transactions into the mempool while recording every step.
+	scriptFlags, err := standardScriptVerifyFlags(s.server.blockManager.chain)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Could not obtain script verification flags")
+	}
+	var trace txscript.ExecutionTrace
+	vm, err := txscript.NewEngine(pkScript, &mtx, int(c.Index),
+		scriptFlags, *c.ScriptVersion, nil)
+	if err == nil {
+		vm.SetTracer(&trace)
+		err = vm.Execute()
+	}
+
+	// Highlight the step that failed.  Execution may also fail after the
+	// final step, such as when the resulting stack is false, in which case
+	// the final step is highlighted instead.
+	failedStep := trace.FailedStep()
+	if err != nil && failedStep == -1 && len(trace.Steps) > 0 {
+		failedStep = len(trace.Steps) - 1
+	}
+
+	reply := cdrjson.DebugScriptResult{
+		Valid:      err == nil,
+		FailedStep: failedStep,
+		Steps:      make([]cdrjson.DebugScriptStep, 0, len(trace.Steps)),
+	}
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	for i := range trace.Steps {
+		step := &trace.Steps[i]
+		condStack := step.CondStack
+		if condStack == nil {
+			condStack = []int{}
+		}
+		var stepErr string
+		if step.Err != nil {
+			stepErr = step.Err.Error()
+		}
+		reply.Steps = append(reply.Steps, cdrjson.DebugScriptStep{
+			ScriptIdx: step.ScriptIdx,
+			OpcodeIdx: step.OpcodeIdx,
+			Opcode:    step.OpcodeName(),
+			Disasm:    step.Disasm,
+			DataStack: txscript.HexStack(step.DataStack),
+			AltStack:  txscript.HexStack(step.AltStack),
+			CondStack: condStack,
+			Error:     stepErr,
+		})
+	}
+	return reply, nil
+}
+
+// psbtUnknownsResult returns the hex-encoded keys and values of the passed
+// unknown PSBT key and value pairs or nil when there are none.
+func psbtUnknownsResult(unknowns []*psbt.Unknown) map[string]string {
+	if len(unknowns) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(unknowns))
+	for _, u := range unknowns {
+		result[hex.EncodeToString(u.Key)] = hex.EncodeToString(u.Value)
+	}
+	return result
+}
+
+// psbtDerivationsResult returns the passed PSBT derivations with the paths in
+// the conventional m/0'/1 form.
+func psbtDerivationsResult(derivations []*psbt.Derivation) []cdrjson.PsbtDerivation {
+	if len(derivations) == 0 {
+		return nil
+	}
+	result := make([]cdrjson.PsbtDerivation, 0, len(derivations))
+	for _, d := range derivations {
+		path := "m"
+		for _, index := range d.Path {
+			if index >= hdkeychain.HardenedKeyStart {
+				path += fmt.Sprintf("/%d'",
+					index-hdkeychain.HardenedKeyStart)
+				continue
+			}
+			path += fmt.Sprintf("/%d", index)
+		}
+		result = append(result, cdrjson.PsbtDerivation{
+			PubKey:            hex.EncodeToString(d.PubKey),
+			MasterFingerprint: fmt.Sprintf("%08x", d.MasterKeyFingerprint),
+			Path:              path,
+		})
+	}
+	return result
+}
+
+// psbtSigTypeString returns the name of the passed PSBT signature type.
+func psbtSigTypeString(sigType int) string {
+	switch sigType {
+	case chainec.ECTypeSecp256k1:
+		return "secp256k1"
+	case chainec.ECTypeEdwards:
+		return "edwards"
+	case chainec.ECTypeSecSchnorr:
+		return "schnorr"
+	}
+	return "unknown"
+}
+
+// handleDecodePsbt handles decodepsbt commands.
+func handleDecodePsbt(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.DecodePsbtCmd)
+
+	packet, err := decodePsbtParam(c.Psbt)
+	if err != nil {
+		return nil, err
+	}
+	mtx := packet.UnsignedTx
+	params := s.server.chainParams
+
+	var txTypeStr string
+	switch packet.TxType() {
+	case stake.TxTypeRegular:
+		txTypeStr = "regular"
+	case stake.TxTypeSStx:
+		txTypeStr = "ticket"
+	case stake.TxTypeSSGen:
+		txTypeStr = "vote"
+	case stake.TxTypeSSRtx:
+		txTypeStr = "revocation"
+	}
+
+	// The fee can only be calculated when all of the previous outputs are
+	// known.  The stakebase input of a vote has no previous output, so its
+	// input amount is used instead.
+	isVote := packet.TxType() == stake.TxTypeSSGen
+	var totalIn int64
+	feeKnown := true
+	inputs := make([]cdrjson.DecodePsbtInput, 0, len(packet.Inputs))
+	for i := range packet.Inputs {
+		pi := &packet.Inputs[i]
+		input := cdrjson.DecodePsbtInput{
+			Derivations: psbtDerivationsResult(pi.Derivations),
+			SigHashType: uint32(pi.SigHashType),
+			Unknown:     psbtUnknownsResult(pi.Unknowns),
+		}
+		switch {
+		case pi.PrevOut != nil:
+			totalIn += pi.PrevOut.Value
+			disbuf, _ := txscript.DisasmString(pi.PrevOut.PkScript)
+			sc, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(
+				pi.PrevOut.Version, pi.PrevOut.PkScript, params)
+			encodedAddrs := make([]string, len(addrs))
+			for j, addr := range addrs {
+				encodedAddrs[j] = addr.EncodeAddress()
+			}
+			input.PrevOut = &cdrjson.PsbtPrevOut{
+				Value:   cdrutil.Amount(pi.PrevOut.Value).ToCoin(),
+				Version: pi.PrevOut.Version,
+				ScriptPubKey: cdrjson.ScriptPubKeyResult{
+					Asm:       disbuf,
+					Hex:       hex.EncodeToString(pi.PrevOut.PkScript),
+					ReqSigs:   int32(reqSigs),
+					Type:      sc.String(),
+					Addresses: encodedAddrs,
+				},
+			}
+		case isVote && i == 0:
+			totalIn += mtx.TxIn[i].ValueIn
+		default:
+			feeKnown = false
+		}
+		if pi.RedeemScript != nil {
+			input.RedeemScript = hex.EncodeToString(pi.RedeemScript)
+		}
+		for _, ps := range pi.PartialSigs {
+			input.PartialSigs = append(input.PartialSigs,
+				cdrjson.PsbtPartialSig{
+					PubKey:    hex.EncodeToString(ps.PubKey),
+					Signature: hex.EncodeToString(ps.Signature),
+					SigType:   psbtSigTypeString(ps.SigType),
+				})
+		}
+		if pi.FinalScriptSig != nil {
+			disbuf, _ := txscript.DisasmString(pi.FinalScriptSig)
+			input.FinalScriptSig = &cdrjson.ScriptSig{
+				Asm: disbuf,
+				Hex: hex.EncodeToString(pi.FinalScriptSig),
+			}
+		}
+		inputs = append(inputs, input)
+	}
+
+	outputs := make([]cdrjson.DecodePsbtOutput, 0, len(packet.Outputs))
+	for i := range packet.Outputs {
+		po := &packet.Outputs[i]
+		output := cdrjson.DecodePsbtOutput{
+			Derivations: psbtDerivationsResult(po.Derivations),
+			Unknown:     psbtUnknownsResult(po.Unknowns),
+		}
+		if po.RedeemScript != nil {
+			output.RedeemScript = hex.EncodeToString(po.RedeemScript)
+		}
+		outputs = append(outputs, output)
+	}
+
+	reply := cdrjson.DecodePsbtResult{
+		Tx: cdrjson.TxRawDecodeResult{
+			Txid:     mtx.TxHash().String(),
+			Version:  int32(mtx.Version),
+			Locktime: mtx.LockTime,
+			Expiry:   mtx.Expiry,
+			Vin:      createVinList(mtx),
+			Vout:     createVoutList(mtx, params, nil),
+		},
+		TxType:   txTypeStr,
+		Inputs:   inputs,
+		Outputs:  outputs,
+		Unknown:  psbtUnknownsResult(packet.Unknowns),
+		Complete: packet.IsComplete(),
+	}
+	if feeKnown {
+		var totalOut int64
+		for _, txOut := range mtx.TxOut {
+			totalOut += txOut.Value
+		}
+		fee := cdrutil.Amount(totalIn - totalOut).ToCoin()
+		reply.Fee = &fee
+	}
+	return reply, nil
+}
+
 // handleDecodeRawTransaction handles decoderawtransaction commands.
 func handleDecodeRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*cdrjson.DecodeRawTransactionCmd)
@@ -1758,6 +2129,44 @@ func handleExistsMempoolTxs(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return hex.EncodeToString([]byte(set)), nil
 }
 
+// handleFinalizePsbt handles finalizepsbt commands.
+func handleFinalizePsbt(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.FinalizePsbtCmd)
+
+	packet, err := decodePsbtParam(c.Psbt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Finalize as many inputs as possible.  Inputs which do not have
+	// enough signatures yet are left as they are, so the error is only of
+	// interest when it is not due to missing signatures.
+	err = packet.Finalize()
+	if err != nil && err != psbt.ErrIncomplete {
+		return nil, rpcInvalidError("Could not finalize PSBT: %v", err)
+	}
+
+	reply := cdrjson.FinalizePsbtResult{Complete: packet.IsComplete()}
+	if reply.Complete && *c.Extract {
+		tx, err := packet.Extract()
+		if err != nil {
+			return nil, rpcInternalError(err.Error(),
+				"Failed to extract transaction")
+		}
+		reply.Hex, err = messageToHex(tx)
+		if err != nil {
+			return nil, err
+		}
+		return reply, nil
+	}
+
+	reply.Psbt, err = encodePsbtResult(packet)
+	if err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
 // handleGenerate handles generate commands.
 func handleGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// Respond with an error if there are no addresses to pay the
@@ -2141,6 +2550,130 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 
 }
 
+// handleGetBlockStats implements the getblockstats command.
+func handleGetBlockStats(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.GetBlockStatsCmd)
+
+	// The block may be identified by either its height in the main chain or
+	// its hash.
+	var hash *chainhash.Hash
+	hashOrHeight := string(c.HashOrHeight)
+	if len(hashOrHeight) < chainhash.MaxHashStringSize {
+		height, err := strconv.ParseInt(hashOrHeight, 10, 64)
+		if err != nil {
+			return nil, rpcInvalidError("Invalid block hash or "+
+				"height: %v", hashOrHeight)
+		}
+		best := s.chain.BestSnapshot()
+		if height < 0 || height > best.Height {
+			return nil, &cdrjson.RPCError{
+				Code: cdrjson.ErrRPCOutOfRange,
+				Message: fmt.Sprintf("Block height %d out of "+
+					"range [0, %d]", height, best.Height),
+			}
+		}
+		hash, err = s.chain.BlockHashByHeight(height)
+		if err != nil {
+			context := "Failed to fetch block hash"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+	} else {
+		var err error
+		hash, err = chainhash.NewHashFromStr(hashOrHeight)
+		if err != nil {
+			return nil, rpcDecodeHexError(hashOrHeight)
+		}
+	}
+
+	// Use the stats from the block stats index when it is enabled and has
+	// an entry for the block.  Otherwise, calculate them from the block.
+	var stats *indexers.BlockStats
+	if s.server.blockStatsIndex != nil {
+		var err error
+		stats, err = s.server.blockStatsIndex.BlockStats(hash)
+		if err != nil {
+			context := "Failed to fetch block stats"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+	}
+	if stats == nil {
+		block, err := s.chain.FetchBlockByHash(hash)
+		if err != nil {
+			return nil, &cdrjson.RPCError{
+				Code:    cdrjson.ErrRPCBlockNotFound,
+				Message: fmt.Sprintf("Block not found: %v", hash),
+			}
+		}
+
+		var prevHeader *wire.BlockHeader
+		if block.Height() > 0 {
+			prevHash := &block.MsgBlock().Header.PrevBlock
+			header, err := s.chain.FetchHeader(prevHash)
+			if err != nil {
+				context := "Failed to fetch parent block header"
+				return nil, rpcInternalError(err.Error(), context)
+			}
+			prevHeader = &header
+		}
+
+		stats = indexers.CalcBlockStats(block, prevHeader,
+			s.chain.FetchSubsidyCache(), s.server.chainParams)
+	}
+
+	result := &cdrjson.GetBlockStatsResult{
+		BlockHash:          hash.String(),
+		Height:             int64(stats.Height),
+		Time:               stats.Time,
+		Interval:           stats.Interval,
+		BlockSize:          stats.Size,
+		Txs:                stats.NumTxns,
+		Tickets:            stats.NumTickets,
+		Votes:              stats.NumVotes,
+		Revocations:        stats.NumRevocations,
+		Ins:                stats.NumInputs,
+		Outs:               stats.NumOutputs,
+		UtxoIncrease:       stats.UtxoIncrease,
+		TotalOut:           stats.TotalOut,
+		TotalStakeOut:      stats.TotalStakeOut,
+		TotalFee:           stats.TotalFee,
+		TotalStakeFee:      stats.TotalStakeFee,
+		MinFeeRate:         stats.MinFeeRate,
+		MaxFeeRate:         stats.MaxFeeRate,
+		AvgFeeRate:         stats.AvgFeeRate,
+		MedianFeeRate:      stats.FeeRates[2],
+		FeeRatePercentiles: stats.FeeRates[:],
+		PoWSubsidy:         stats.PoWSubsidy,
+		PoSSubsidy:         stats.PoSSubsidy,
+		DevSubsidy:         stats.DevSubsidy,
+	}
+	if c.Stats == nil || len(*c.Stats) == 0 {
+		return result, nil
+	}
+
+	// Only return the requested stats.  The result is converted to a map
+	// keyed by the JSON field names so they can be selected by name.
+	marshalled, err := json.Marshal(result)
+	if err != nil {
+		context := "Failed to marshal block stats"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+	var allStats map[string]json.RawMessage
+	if err := json.Unmarshal(marshalled, &allStats); err != nil {
+		context := "Failed to unmarshal block stats"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+	selected := make(map[string]json.RawMessage, len(*c.Stats))
+	for _, name := range *c.Stats {
+		stat, ok := allStats[name]
+		if !ok {
+			return nil, rpcInvalidError("Invalid selected stat: %v",
+				name)
+		}
+		selected[name] = stat
+	}
+	return selected, nil
+}
+
 // handleGetBlockSubsidy implements the getblocksubsidy command.
 func handleGetBlockSubsidy(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*cdrjson.GetBlockSubsidyCmd)
@@ -3041,6 +3574,70 @@ func handleGetChainTips(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 	return s.chain.ChainTips(), nil
 }
 
+// handleGetChainTxStats implements the getchaintxstats command.
+func handleGetChainTxStats(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.GetChainTxStatsCmd)
+
+	// The window ends at the current best block unless a block is provided.
+	var hash *chainhash.Hash
+	if c.BlockHash != nil {
+		var err error
+		hash, err = chainhash.NewHashFromStr(*c.BlockHash)
+		if err != nil {
+			return nil, rpcDecodeHexError(*c.BlockHash)
+		}
+	} else {
+		best := s.chain.BestSnapshot()
+		hash = &best.Hash
+	}
+	height, err := s.chain.BlockHeightByHash(hash)
+	if err != nil {
+		return nil, &cdrjson.RPCError{
+			Code:    cdrjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found in main chain: %v", hash),
+		}
+	}
+
+	// Default to a window of approximately one month worth of blocks, limited
+	// to the blocks that are available before the final block.
+	var numBlocks int64
+	if c.NBlocks != nil {
+		numBlocks = int64(*c.NBlocks)
+		if numBlocks < 0 || (numBlocks > 0 && numBlocks >= height) {
+			return nil, rpcInvalidError("Invalid block count: should be "+
+				"between 0 and the block's height - 1 (%d)", height-1)
+		}
+	} else {
+		params := s.server.chainParams
+		numBlocks = int64(time.Hour * 24 * 30 / params.TargetTimePerBlock)
+		if numBlocks >= height {
+			numBlocks = height - 1
+		}
+		if numBlocks < 0 {
+			numBlocks = 0
+		}
+	}
+
+	stats, err := s.chain.ChainTxStats(hash, numBlocks)
+	if err != nil {
+		context := "Failed to calculate chain transaction stats"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+	result := &cdrjson.GetChainTxStatsResult{
+		Time:                 stats.Time,
+		TxCount:              stats.TxCount,
+		WindowFinalBlockHash: stats.WindowFinalHash.String(),
+		WindowBlockCount:     stats.WindowBlockCount,
+		WindowTxCount:        stats.WindowTxCount,
+		WindowInterval:       stats.WindowInterval,
+	}
+	if stats.WindowInterval > 0 {
+		result.TxRate = float64(stats.WindowTxCount) /
+			float64(stats.WindowInterval)
+	}
+	return result, nil
+}
+
 // handleGetCoinSupply implements the getcoinsupply command.
 func handleGetCoinSupply(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	return s.chain.TotalSubsidy(), nil
@@ -3074,6 +3671,9 @@ func handleGetHashesPerSec(s *rpcServer, cmd interface{}, closeChan <-chan struc
 
 // handleGetCFilter implements the getcfilter command.
 func handleGetCFilter(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if cfg.HeadersOnly {
+		return handleGetCFilterHeadersOnly(s, cmd, false)
+	}
 	if s.server.cfIndex == nil {
 		return nil, &cdrjson.RPCError{
 			Code:    cdrjson.ErrRPCNoCFIndex,
@@ -3113,6 +3713,9 @@ func handleGetCFilter(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 
 // handleGetCFilterHeader implements the getcfilterheader command.
 func handleGetCFilterHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if cfg.HeadersOnly {
+		return handleGetCFilterHeadersOnly(s, cmd, true)
+	}
 	if s.server.cfIndex == nil {
 		return nil, &cdrjson.RPCError{
 			Code:    cdrjson.ErrRPCNoCFIndex,
@@ -3152,6 +3755,50 @@ func handleGetCFilterHeader(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return hash.String(), nil
 }
 
+// handleGetCFilterHeadersOnly implements the getcfilter and getcfilterheader
+// commands in headers-only mode, where only the verified regular committed
+// filters and their filter headers stored by the chain are available.
+func handleGetCFilterHeadersOnly(s *rpcServer, cmd interface{}, wantHeader bool) (interface{}, error) {
+	var hashStr, filterType string
+	switch c := cmd.(type) {
+	case *cdrjson.GetCFilterCmd:
+		hashStr, filterType = c.Hash, c.FilterType
+	case *cdrjson.GetCFilterHeaderCmd:
+		hashStr, filterType = c.Hash, c.FilterType
+	}
+	hash, err := chainhash.NewHashFromStr(hashStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hashStr)
+	}
+
+	switch filterType {
+	case "regular":
+	case "extended":
+		return nil, &cdrjson.RPCError{
+			Code: cdrjson.ErrRPCNoCFIndex,
+			Message: "Only regular committed filters are available " +
+				"in headers-only mode",
+		}
+	default:
+		return nil, rpcMiscError("unknown filter type " + filterType)
+	}
+
+	filter, header, err := s.chain.CFilterByHash(hash)
+	if err != nil {
+		rpcsLog.Debugf("Could not find verified committed filter for "+
+			"%v: %v", hash, err)
+		return nil, &cdrjson.RPCError{
+			Code:    cdrjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+
+	if wantHeader {
+		return header.String(), nil
+	}
+	return hex.EncodeToString(filter), nil
+}
+
 // handleGetHeaders implements the getheaders command.
 func handleGetHeaders(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*cdrjson.GetHeadersCmd)
@@ -4012,6 +4659,101 @@ func handleGetTxOut(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 	return txOutReply, nil
 }
 
+// handleGetTxOutProof implements the gettxoutproof command.
+func handleGetTxOutProof(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.GetTxOutProofCmd)
+
+	// Convert the provided transaction hashes and ensure there are no
+	// duplicates.
+	if len(c.TxIDs) == 0 {
+		return nil, rpcInvalidError("At least one transaction hash " +
+			"must be specified")
+	}
+	txHashes := make(map[chainhash.Hash]struct{}, len(c.TxIDs))
+	var firstTxHash *chainhash.Hash
+	for _, txid := range c.TxIDs {
+		txHash, err := chainhash.NewHashFromStr(txid)
+		if err != nil {
+			return nil, rpcDecodeHexError(txid)
+		}
+		if _, ok := txHashes[*txHash]; ok {
+			return nil, rpcInvalidError("Duplicate transaction hash %v",
+				txHash)
+		}
+		txHashes[*txHash] = struct{}{}
+		if firstTxHash == nil {
+			firstTxHash = txHash
+		}
+	}
+
+	// Use the provided block hash when specified.  Otherwise, look up the
+	// block that contains the first transaction in the utxo set, which
+	// only works while it has unspent outputs, and then in the transaction
+	// index when it is enabled.
+	var blockHash *chainhash.Hash
+	if c.BlockHash != nil {
+		var err error
+		blockHash, err = chainhash.NewHashFromStr(*c.BlockHash)
+		if err != nil {
+			return nil, rpcDecodeHexError(*c.BlockHash)
+		}
+	} else {
+		entry, err := s.chain.FetchUtxoEntry(firstTxHash)
+		if err != nil {
+			context := "Failed to fetch utxo"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+		if entry != nil {
+			blockHash, err = s.chain.BlockHashByHeight(entry.BlockHeight())
+			if err != nil {
+				context := "Failed to retrieve block hash"
+				return nil, rpcInternalError(err.Error(), context)
+			}
+		} else if txIndex := s.server.txIndex; txIndex != nil {
+			blockRegion, err := txIndex.TxBlockRegion(*firstTxHash)
+			if err != nil {
+				context := "Failed to retrieve transaction location"
+				return nil, rpcInternalError(err.Error(), context)
+			}
+			if blockRegion != nil {
+				blockHash = blockRegion.Hash
+			}
+		}
+		if blockHash == nil {
+			return nil, rpcNoTxInfoError(firstTxHash)
+		}
+	}
+
+	block, err := s.chain.FetchBlockByHash(blockHash)
+	if err != nil {
+		return nil, &cdrjson.RPCError{
+			Code:    cdrjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found: %v", blockHash),
+		}
+	}
+
+	// Create a merkleblock message that proves the inclusion of the
+	// requested transactions and ensure they were all found in the block.
+	var numMatched int
+	msgMerkleBlock := blockchain.NewMerkleBlock(block, func(tx *cdrutil.Tx) bool {
+		if _, ok := txHashes[*tx.Hash()]; !ok {
+			return false
+		}
+		numMatched++
+		return true
+	})
+	if numMatched != len(txHashes) {
+		return nil, rpcInvalidError("Not all transactions found in block "+
+			"%v", blockHash)
+	}
+
+	proofHex, err := messageToHex(msgMerkleBlock)
+	if err != nil {
+		return nil, err
+	}
+	return proofHex, nil
+}
+
 // pruneOldBlockTemplates prunes all old block templates from the templatePool
 // map. Must be called with the RPC workstate locked to avoid races to the map.
 func pruneOldBlockTemplates(s *rpcServer, bestHeight int64) {
@@ -4401,6 +5143,22 @@ func handleHelp(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return help, nil
 }
 
+// handleInvalidateBlock implements the invalidateblock command.
+func handleInvalidateBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.InvalidateBlockCmd)
+	hash, err := chainhash.NewHashFromStr(c.BlockHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.BlockHash)
+	}
+
+	err = s.server.blockManager.InvalidateBlock(hash)
+	if err != nil {
+		return nil, rpcMiscError(fmt.Sprintf("Failed to invalidate "+
+			"block %v: %v", hash, err))
+	}
+	return nil, nil
+}
+
 // handleLiveTickets implements the livetickets command.
 func handleLiveTickets(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	lt, err := s.server.blockManager.chain.LiveTickets()
@@ -4503,6 +5261,22 @@ func handleRebroadcastWinners(s *rpcServer, cmd interface{}, closeChan <-chan st
 	return nil, nil
 }
 
+// handleReconsiderBlock implements the reconsiderblock command.
+func handleReconsiderBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.ReconsiderBlockCmd)
+	hash, err := chainhash.NewHashFromStr(c.BlockHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.BlockHash)
+	}
+
+	err = s.server.blockManager.ReconsiderBlock(hash)
+	if err != nil {
+		return nil, rpcMiscError(fmt.Sprintf("Failed to reconsider "+
+			"block %v: %v", hash, err))
+	}
+	return nil, nil
+}
+
 // retrievedTx represents a transaction that was either loaded from the
 // transaction memory pool or from the database.  When a transaction is loaded
 // from the database, it is loaded with the raw serialized bytes while the
@@ -4749,6 +5523,159 @@ func fetchMempoolTxnsForAddress(s *rpcServer, addr cdrutil.Address, numToSkip, n
 	return mpTxns[numToSkip:rangeEnd], numToSkip
 }
 
+// utxoScanState houses the state of the scan of the utxo set started by the
+// scantxoutset command.  Only a single scan may run at a time.
+type utxoScanState struct {
+	sync.Mutex
+	running  bool
+	progress float64
+	abort    chan struct{}
+}
+
+// handleScanTxOutSet implements the scantxoutset command.
+func handleScanTxOutSet(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.ScanTxOutSetCmd)
+	scan := &s.utxoScan
+
+	switch c.Action {
+	case cdrjson.ScanTxOutSetStatus:
+		scan.Lock()
+		defer scan.Unlock()
+		if !scan.running {
+			return nil, nil
+		}
+		return &cdrjson.ScanTxOutSetStatusResult{
+			Progress: math.Floor(scan.progress*10000) / 100,
+		}, nil
+
+	case cdrjson.ScanTxOutSetAbort:
+		scan.Lock()
+		defer scan.Unlock()
+		if !scan.running {
+			return false, nil
+		}
+		select {
+		case <-scan.abort:
+		default:
+			close(scan.abort)
+		}
+		return true, nil
+
+	case cdrjson.ScanTxOutSetStart:
+	default:
+		return nil, rpcInvalidError("Invalid action: %v", c.Action)
+	}
+
+	// Expand the scan objects into the output scripts to search for along
+	// with the descriptors they were derived from.
+	if c.ScanObjects == nil || len(*c.ScanObjects) == 0 {
+		return nil, rpcInvalidError("Scan objects must be provided to " +
+			"start a scan")
+	}
+	scripts := make(map[string]string)
+	err := expandDescriptors(*c.ScanObjects, s.server.chainParams,
+		func(d *descriptor.Descriptor, script []byte) {
+			scripts[string(script)] = d.String()
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	// scriptDesc returns the descriptor which describes the provided output
+	// script.  Stake outputs are tagged with a stake opcode, so they also
+	// match the descriptor of the script they tag.
+	scriptDesc := func(pkScript []byte) (string, bool) {
+		if desc, ok := scripts[string(pkScript)]; ok {
+			return desc, true
+		}
+		if len(pkScript) > 0 {
+			desc, ok := scripts[string(pkScript[1:])]
+			if ok && txscript.IsStakeOutput(pkScript) {
+				return desc, true
+			}
+		}
+		return "", false
+	}
+
+	scan.Lock()
+	if scan.running {
+		scan.Unlock()
+		return nil, rpcMiscError("Scan already in progress, use " +
+			"action \"abort\" or \"status\"")
+	}
+	abort := make(chan struct{})
+	scan.running = true
+	scan.progress = 0
+	scan.abort = abort
+	scan.Unlock()
+	defer func() {
+		scan.Lock()
+		scan.running = false
+		scan.Unlock()
+	}()
+
+	// Interrupt the scan when it is aborted, the client disconnects, or
+	// the server is shutting down.
+	interrupt := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-abort:
+		case <-closeChan:
+		case <-s.quit:
+		case <-done:
+			return
+		}
+		close(interrupt)
+	}()
+
+	scanResult, err := s.chain.ScanUtxoSet(func(_ uint16, pkScript []byte) bool {
+		_, ok := scriptDesc(pkScript)
+		return ok
+	}, func(progress float64) {
+		scan.Lock()
+		scan.progress = progress
+		scan.Unlock()
+	}, interrupt)
+	if err != nil {
+		select {
+		case <-interrupt:
+			return nil, rpcMiscError("Scan aborted")
+		default:
+		}
+		context := "Failed to scan utxo set"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	var totalAmount int64
+	unspents := make([]cdrjson.ScanTxOutSetUnspent, 0, len(scanResult.Matches))
+	for i := range scanResult.Matches {
+		utxo := &scanResult.Matches[i]
+		desc, _ := scriptDesc(utxo.PkScript)
+		totalAmount += utxo.Amount
+		unspents = append(unspents, cdrjson.ScanTxOutSetUnspent{
+			TxID:         utxo.OutPoint.Hash.String(),
+			Vout:         utxo.OutPoint.Index,
+			Tree:         utxo.OutPoint.Tree,
+			ScriptPubKey: hex.EncodeToString(utxo.PkScript),
+			Desc:         desc,
+			Amount:       cdrutil.Amount(utxo.Amount).ToCoin(),
+			Height:       utxo.BlockHeight,
+			TxType:       txTypeString(utxo.TxType),
+			Coinbase:     utxo.IsCoinBase,
+		})
+	}
+
+	return &cdrjson.ScanTxOutSetResult{
+		SearchedItems: scanResult.NumScanned,
+		BestBlock:     scanResult.Hash.String(),
+		Height:        scanResult.Height,
+		Unspents:      unspents,
+		TotalAmount:   cdrutil.Amount(totalAmount).ToCoin(),
+	}, nil
+}
+
 // handleSearchRawTransactions implements the searchrawtransactions command.
 func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// Respond with an error if the address index is not enabled.
@@ -5641,42 +6568,6 @@ func handleValidateAddress(s *rpcServer, cmd interface{}, closeChan <-chan struc
 	return result, nil
 }
 
-func verifyChain(s *rpcServer, level, depth int64) error {
-	best := s.chain.BestSnapshot()
-	finishHeight := best.Height - depth
-	if finishHeight < 0 {
-		finishHeight = 0
-	}
-	rpcsLog.Infof("Verifying chain for %d blocks at level %d",
-		best.Height-finishHeight, level)
-
-	for height := best.Height; height > finishHeight; height-- {
-		// Level 0 just looks up the block.
-		block, err := s.chain.BlockByHeight(height)
-		if err != nil {
-			rpcsLog.Errorf("Verify is unable to fetch block at "+
-				"height %d: %v", height, err)
-			return err
-		}
-
-		// Level 1 does basic chain sanity checks.
-		if level > 0 {
-			err := blockchain.CheckBlockSanity(block,
-				s.server.timeSource,
-				activeNetParams.Params)
-			if err != nil {
-				rpcsLog.Errorf("Verify is unable to validate "+
-					"block at hash %v height %d: %v",
-					block.Hash(), height, err)
-				return err
-			}
-		}
-	}
-	rpcsLog.Infof("Chain verify completed successfully")
-
-	return nil
-}
-
 // handleVerifyChain implements the verifychain command.
 func handleVerifyChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*cdrjson.VerifyChainCmd)
@@ -5689,8 +6580,49 @@ func handleVerifyChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 		checkDepth = *c.CheckDepth
 	}
 
-	err := verifyChain(s, checkLevel, checkDepth)
-	return err == nil, nil
+	// Interrupt the verification when the client disconnects or the server
+	// is shutting down.
+	interrupt := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-closeChan:
+		case <-s.quit:
+		case <-done:
+			return
+		}
+		close(interrupt)
+	}()
+
+	result, err := s.chain.VerifyChain(checkLevel, checkDepth, interrupt)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Verify is unable to verify the chain")
+	}
+	verified := len(result.Inconsistencies) == 0
+	if c.Verbose == nil || !*c.Verbose {
+		return verified, nil
+	}
+
+	inconsistencies := make([]cdrjson.VerifyChainInconsistency, 0,
+		len(result.Inconsistencies))
+	for _, inconsistency := range result.Inconsistencies {
+		inconsistencies = append(inconsistencies,
+			cdrjson.VerifyChainInconsistency{
+				Hash:        inconsistency.Hash.String(),
+				Height:      inconsistency.Height,
+				Description: inconsistency.Description,
+			})
+	}
+	return &cdrjson.VerifyChainResult{
+		Verified:        verified,
+		CheckLevel:      result.Level,
+		NumBlocks:       result.NumBlocks,
+		UtxoSetValue:    cdrutil.Amount(result.UtxoSetValue).ToCoin(),
+		CoinSupply:      cdrutil.Amount(result.CoinSupply).ToCoin(),
+		Inconsistencies: inconsistencies,
+	}, nil
 }
 
 // handleVerifyMessage implements the verifymessage command.
@@ -5755,6 +6687,67 @@ func handleVerifyMessage(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 	return address.EncodeAddress() == c.Address, nil
 }
 
+// handleVerifyTxOutProof implements the verifytxoutproof command.
+func handleVerifyTxOutProof(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*cdrjson.VerifyTxOutProofCmd)
+
+	// Deserialize the proof.
+	hexStr := c.Proof
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serializedProof, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var msgMerkleBlock wire.MsgMerkleBlock
+	err = msgMerkleBlock.BtcDecode(bytes.NewReader(serializedProof),
+		maxProtocolVersion)
+	if err != nil {
+		return nil, rpcDeserializationError("Could not decode proof: %v",
+			err)
+	}
+
+	// Ensure the partial merkle trees commit to the merkle roots of the
+	// header.
+	regularIndices, stakeIndices, err := blockchain.CheckMerkleBlock(
+		&msgMerkleBlock)
+	if err != nil {
+		return nil, rpcInvalidError("Invalid proof: %v", err)
+	}
+
+	// The proof is only meaningful when the block is part of the main
+	// chain.  The matched transactions are looked up in the block since
+	// the leaves of the partial merkle trees are the full transaction
+	// hashes.
+	blockHash := msgMerkleBlock.Header.BlockHash()
+	block, err := s.chain.BlockByHash(&blockHash)
+	if err != nil {
+		return nil, &cdrjson.RPCError{
+			Code: cdrjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found in main chain: %v",
+				blockHash),
+		}
+	}
+	txns := block.Transactions()
+	stxns := block.STransactions()
+	if uint32(len(txns)) != msgMerkleBlock.Transactions ||
+		uint32(len(stxns)) != msgMerkleBlock.STransactions {
+
+		return nil, rpcInvalidError("Invalid proof: mismatched number " +
+			"of transactions")
+	}
+
+	txIDs := make([]string, 0, len(regularIndices)+len(stakeIndices))
+	for _, idx := range regularIndices {
+		txIDs = append(txIDs, txns[idx].Hash().String())
+	}
+	for _, idx := range stakeIndices {
+		txIDs = append(txIDs, stxns[idx].Hash().String())
+	}
+	return txIDs, nil
+}
+
 // handleVersion implements the version command.
 func handleVersion(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	runtimeVer := strings.Replace(runtime.Version(), ".", "-", -1)
@@ -5803,6 +6796,10 @@ type rpcServer struct {
 	helpCacher             *helpCacher
 	requestProcessShutdown chan struct{}
 	quit                   chan int
+
+	// utxoScan houses the state of the utxo set scan started by the
+	// scantxoutset command.
+	utxoScan utxoScanState
 }
 
 // httpStatusLine returns a response Status-Line (RFC 2616 Section 6.1) for the
@@ -5982,6 +6979,12 @@ type parsedRPCCmd struct {
 // Any commands which are not recognized or not implemented will return an
 // error suitable for use in replies.
 func (s *rpcServer) standardCmdResult(cmd *parsedRPCCmd, closeChan <-chan struct{}) (interface{}, error) {
+	if cfg.HeadersOnly {
+		if _, ok := rpcHeadersOnly[cmd.method]; !ok {
+			return nil, ErrRPCHeadersOnly
+		}
+	}
+
 	handler, ok := rpcHandlers[cmd.method]
 	if ok {
 		goto handled
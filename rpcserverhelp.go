@@ -81,6 +81,12 @@ var helpDescsEnUS = map[string]string{
 	"createrawssrtx-inputs":   "The inputs to the transaction of type sstxinput",
 	"createrawssrtx-fee":      "The fee to apply to the revocation in Coins",
 
+	// CombinePsbtCmd help.
+	"combinepsbt--synopsis": "Combines multiple base64-encoded partially signed transactions for the same unsigned transaction into one.\n" +
+		"The signatures and other information of every partially signed transaction are merged.",
+	"combinepsbt-psbts":    "The base64-encoded partially signed transactions to combine",
+	"combinepsbt--result0": "The base64-encoded combined partially signed transaction",
+
 	// CreateRawTransactionCmd help.
 	"createrawtransaction--synopsis": "Returns a new transaction spending the provided inputs and sending to the provided addresses.\n" +
 		"The transaction inputs are not signed in the created transaction.\n" +
@@ -149,6 +155,80 @@ var helpDescsEnUS = map[string]string{
 	"txrawdecoderesult-vout":     "The transaction outputs as JSON objects",
 	"txrawdecoderesult-expiry":   "The transaction expiry",
 
+	// DecodePsbtCmd help.
+	"decodepsbt--synopsis": "Returns a JSON object representing the provided base64-encoded partially signed transaction.",
+	"decodepsbt-psbt":      "The base64-encoded partially signed transaction",
+
+	// DecodePsbtResult help.
+	"decodepsbtresult-tx":             "The unsigned transaction as a JSON object",
+	"decodepsbtresult-txtype":         "The type of the unsigned transaction (regular, ticket, vote, or revocation)",
+	"decodepsbtresult-inputs":         "The information about each input used to sign it",
+	"decodepsbtresult-outputs":        "The information about each output",
+	"decodepsbtresult-unknown":        "The hex-encoded global keys and values unknown to the server",
+	"decodepsbtresult-unknown--key":   "key",
+	"decodepsbtresult-unknown--value": "value",
+	"decodepsbtresult-unknown--desc":  "The hex-encoded key and value",
+	"decodepsbtresult-fee":            "The transaction fee in coins (only present when all previous outputs are known)",
+	"decodepsbtresult-complete":       "Whether or not all inputs are finalized",
+
+	// DecodePsbtInput help.
+	"decodepsbtinput-prevout":        "The previous output spent by the input (only present when known)",
+	"decodepsbtinput-redeemscript":   "The hex-encoded redeem script of a pay-to-script-hash previous output",
+	"decodepsbtinput-derivations":    "The HD derivation paths of the keys relevant to the input",
+	"decodepsbtinput-partialsigs":    "The signatures collected for the input",
+	"decodepsbtinput-sighashtype":    "The signature hash type signatures must use",
+	"decodepsbtinput-finalscriptsig": "The signature script of the finalized input",
+	"decodepsbtinput-unknown":        "The hex-encoded input keys and values unknown to the server",
+	"decodepsbtinput-unknown--key":   "key",
+	"decodepsbtinput-unknown--value": "value",
+	"decodepsbtinput-unknown--desc":  "The hex-encoded key and value",
+
+	// DecodePsbtOutput help.
+	"decodepsbtoutput-redeemscript":   "The hex-encoded redeem script of a pay-to-script-hash output",
+	"decodepsbtoutput-derivations":    "The HD derivation paths of the keys relevant to the output",
+	"decodepsbtoutput-unknown":        "The hex-encoded output keys and values unknown to the server",
+	"decodepsbtoutput-unknown--key":   "key",
+	"decodepsbtoutput-unknown--value": "value",
+	"decodepsbtoutput-unknown--desc":  "The hex-encoded key and value",
+
+	// PsbtPrevOut help.
+	"psbtprevout-value":        "The amount of the previous output in coins",
+	"psbtprevout-version":      "The script version of the previous output",
+	"psbtprevout-scriptPubKey": "The public key script of the previous output as a JSON object",
+
+	// PsbtDerivation help.
+	"psbtderivation-pubkey":            "The hex-encoded public key",
+	"psbtderivation-masterfingerprint": "The hex-encoded fingerprint of the master key",
+	"psbtderivation-path":              "The derivation path from the master key",
+
+	// PsbtPartialSig help.
+	"psbtpartialsig-pubkey":    "The hex-encoded public key",
+	"psbtpartialsig-signature": "The hex-encoded signature including the signature hash type",
+	"psbtpartialsig-sigtype":   "The signature type (secp256k1, edwards, or schnorr)",
+
+	// DebugScriptCmd help.
+	"debugscript--synopsis":     "Executes the signature script of a transaction input against the provided previous output script and returns the state of the script engine after each executed opcode.",
+	"debugscript-hextx":         "Serialized, hex-encoded transaction",
+	"debugscript-index":         "The index of the transaction input to execute",
+	"debugscript-pkscript":      "Hex-encoded public key script of the output spent by the input",
+	"debugscript-scriptversion": "The version of the public key script",
+
+	// DebugScriptResult help.
+	"debugscriptresult-valid":      "Whether or not the script pair executed successfully",
+	"debugscriptresult-error":      "The reason execution failed (only present when execution failed)",
+	"debugscriptresult-failedstep": "The index of the step execution failed at or -1 when execution succeeded",
+	"debugscriptresult-steps":      "The state of the script engine after each executed opcode",
+
+	// DebugScriptStep help.
+	"debugscriptstep-scriptidx": "The script the opcode belongs to (0 = signature script, 1 = public key script, 2 = redeem script)",
+	"debugscriptstep-opcodeidx": "The index of the opcode within its script",
+	"debugscriptstep-opcode":    "The name of the executed opcode",
+	"debugscriptstep-disasm":    "The disassembly of the executed opcode including any pushed data",
+	"debugscriptstep-datastack": "The hex-encoded data stack items from bottom to top",
+	"debugscriptstep-altstack":  "The hex-encoded alternate stack items from bottom to top",
+	"debugscriptstep-condstack": "The conditional execution stack from bottom to top (0 = false, 1 = true, 2 = skip)",
+	"debugscriptstep-error":     "The error that resulted from executing the opcode (only present for the failed step)",
+
 	// DecodeRawTransactionCmd help.
 	"decoderawtransaction--synopsis": "Returns a JSON object representing the provided serialized, hex-encoded transaction.",
 	"decoderawtransaction-hextx":     "Serialized, hex-encoded transaction",
@@ -164,6 +244,17 @@ var helpDescsEnUS = map[string]string{
 	"decodescript--synopsis": "Returns a JSON object with information about the provided hex-encoded script.",
 	"decodescript-hexscript": "Hex-encoded script",
 
+	// FinalizePsbtCmd help.
+	"finalizepsbt--synopsis": "Builds the final signature scripts of the inputs of a base64-encoded partially signed transaction which have enough signatures.\n" +
+		"The fully signed transaction is returned once all inputs are finalized.",
+	"finalizepsbt-psbt":    "The base64-encoded partially signed transaction",
+	"finalizepsbt-extract": "Return the fully signed transaction instead of the partially signed transaction when all inputs are finalized",
+
+	// FinalizePsbtResult help.
+	"finalizepsbtresult-psbt":     "The base64-encoded partially signed transaction (only present when it is incomplete or extract is false)",
+	"finalizepsbtresult-hex":      "The hex-encoded fully signed transaction (only present when it is complete and extract is true)",
+	"finalizepsbtresult-complete": "Whether or not all inputs are finalized",
+
 	// ExistsAddressCmd help.
 	"existsaddress--synopsis": "Test for the existence of the provided address",
 	"existsaddress-address":   "The address to check",
@@ -341,7 +432,37 @@ var helpDescsEnUS = map[string]string{
 	"getblockheaderverboseresult-stakeroot":         "The merkle root of the stake transaction tree",
 	"getblockheaderverboseresult-stakeversion":      "The stake version of the block",
 
-	// GetBlockSubsidyCmd help.
+	// GetBlockStatsCmd help.
+	"getblockstats--synopsis":    "Returns per-block statistics about the transactions, fees, and subsidy of a block.  All amounts are in atoms and all fee rates are in atoms per kilobyte.",
+	"getblockstats-hashorheight": "The hash or main chain height of the block",
+	"getblockstats-stats":        "The names of the stats to return, or all stats when omitted",
+
+	// GetBlockStatsResult help.
+	"getblockstatsresult-blockhash":          "The hash of the block",
+	"getblockstatsresult-height":             "The height of the block",
+	"getblockstatsresult-time":               "The block time in seconds since 1 Jan 1970 GMT",
+	"getblockstatsresult-interval":           "The number of seconds since the parent block, which may be negative",
+	"getblockstatsresult-blocksize":          "The serialized size of the block",
+	"getblockstatsresult-txs":                "The number of regular transactions including the coinbase",
+	"getblockstatsresult-tickets":            "The number of ticket purchases",
+	"getblockstatsresult-votes":              "The number of votes",
+	"getblockstatsresult-revocations":        "The number of revocations",
+	"getblockstatsresult-ins":                "The number of inputs excluding the coinbase and stakebase inputs",
+	"getblockstatsresult-outs":               "The number of outputs",
+	"getblockstatsresult-utxoincrease":       "The change in the number of unspent transaction outputs",
+	"getblockstatsresult-totalout":           "The total output value of the regular transactions",
+	"getblockstatsresult-totalstakeout":      "The total output value of the stake transactions",
+	"getblockstatsresult-totalfee":           "The total fees of the regular transactions",
+	"getblockstatsresult-totalstakefee":      "The total fees of the stake transactions",
+	"getblockstatsresult-minfeerate":         "The minimum fee rate of the regular transactions excluding the coinbase",
+	"getblockstatsresult-maxfeerate":         "The maximum fee rate of the regular transactions excluding the coinbase",
+	"getblockstatsresult-avgfeerate":         "The average fee rate of the regular transactions excluding the coinbase",
+	"getblockstatsresult-medianfeerate":      "The size-weighted median fee rate of the regular transactions excluding the coinbase",
+	"getblockstatsresult-feeratepercentiles": "The size-weighted 10th, 25th, 50th, 75th, and 90th percentile fee rates of the regular transactions excluding the coinbase",
+	"getblockstatsresult-powsubsidy":         "The Proof-of-Work subsidy",
+	"getblockstatsresult-possubsidy":         "The total Proof-of-Stake subsidy of the included votes",
+	"getblockstatsresult-devsubsidy":         "The developer subsidy",
+
 	"getblocksubsidy--synopsis": "Returns information regarding subsidy amounts.",
 	"getblocksubsidy-height":    "The block height",
 	"getblocksubsidy-voters":    "The number of voters",
@@ -438,6 +559,20 @@ var helpDescsEnUS = map[string]string{
 	"getchaintipsresult-status":    "The status of the chain (active, invalid, headers-only, valid-fork, valid-headers)",
 	"getchaintipsresults--result0": "test",
 
+	// GetChainTxStatsCmd help.
+	"getchaintxstats--synopsis": "Returns statistics about the total number and rate of transactions in the main chain over a window of blocks.",
+	"getchaintxstats-nblocks":   "The number of blocks in the window (default: approximately one month worth of blocks)",
+	"getchaintxstats-blockhash": "The hash of the final block in the window (default: the current best block)",
+
+	// GetChainTxStatsResult help.
+	"getchaintxstatsresult-time":                 "The timestamp of the final block in the window in seconds since 1 Jan 1970 GMT",
+	"getchaintxstatsresult-txcount":              "The total number of transactions in the chain up to and including the final block in the window",
+	"getchaintxstatsresult-windowfinalblockhash": "The hash of the final block in the window",
+	"getchaintxstatsresult-windowblockcount":     "The number of blocks in the window",
+	"getchaintxstatsresult-windowtxcount":        "The number of transactions in the window",
+	"getchaintxstatsresult-windowinterval":       "The elapsed time of the window in seconds",
+	"getchaintxstatsresult-txrate":               "The average number of transactions per second in the window (0 when the window interval is not positive)",
+
 	// GetConnectionCountCmd help.
 	"getconnectioncount--synopsis": "Returns the number of active connections to other peers.",
 	"getconnectioncount--result0":  "The number of connections",
@@ -661,6 +796,14 @@ var helpDescsEnUS = map[string]string{
 	"gettxout-vout":           "The index of the output",
 	"gettxout-includemempool": "Include the mempool when true",
 
+	// GetTxOutProofCmd help.
+	"gettxoutproof--synopsis": "Returns a hex-encoded proof that the specified transactions are included in a block.\n" +
+		"The proof is a serialized merkleblock message with partial merkle trees for the regular and stake transaction trees.\n" +
+		"Without a block hash, the block is found via the unspent outputs of the first transaction or the transaction index when it is enabled (--txindex).",
+	"gettxoutproof-txids":     "The hashes of the transactions to prove, which must all be in the same block",
+	"gettxoutproof-blockhash": "The hash of the block that contains the transactions",
+	"gettxoutproof--result0":  "The hex-encoded proof",
+
 	// GetWorkResult help.
 	"getworkresult-data":     "Hex-encoded block data",
 	"getworkresult-hash1":    "(DEPRECATED) Hex-encoded formatted hash buffer",
@@ -682,6 +825,11 @@ var helpDescsEnUS = map[string]string{
 	"help--result0":    "List of commands",
 	"help--result1":    "Help for specified command",
 
+	// InvalidateBlockCmd help.
+	"invalidateblock--synopsis": "Permanently marks a block and all of its descendants as invalid.\n" +
+		"The chain is reorganized to the valid chain with the most cumulative work when the block is part of the best chain.",
+	"invalidateblock-blockhash": "The hash of the block to mark as invalid",
+
 	// PingCmd help.
 	"ping--synopsis": "Queues a ping to be sent to each connected peer.\n" +
 		"Ping times are provided by getpeerinfo via the pingtime and pingwait fields.",
@@ -692,6 +840,44 @@ var helpDescsEnUS = map[string]string{
 	// RebroadcastWinnerCmd help.
 	"rebroadcastwinners--synopsis": "Asks the daemon to rebroadcast the winners of the voting lottery.\n",
 
+	// ReconsiderBlockCmd help.
+	"reconsiderblock--synopsis": "Removes the invalid status of a block, its ancestors and its descendants which was set by invalidateblock or failed validation.\n" +
+		"The blocks are validated again and the chain is reorganized to the valid chain with the most cumulative work.",
+	"reconsiderblock-blockhash": "The hash of the block to reconsider",
+
+	// ScanTxOutSetCmd help.
+	"scantxoutset--synopsis": "Scans the unspent transaction output set for outputs paying to the output scripts described by the provided descriptors.\n" +
+		"The descriptors may describe addresses, raw output scripts, or ranges of child keys derived from extended public keys.\n" +
+		"Stake outputs match the descriptors of the output scripts they tag.\n" +
+		"Only a single scan may run at a time and its progress may be queried or the scan aborted while it runs.",
+	"scantxoutset-action":      "The action to take: 'start' a scan and wait for its result, 'abort' the scan in progress, or query the 'status' of the scan in progress",
+	"scantxoutset-scanobjects": "Array of output script descriptors to search for, required when starting a scan",
+	"scantxoutset--condition0": "action=start",
+	"scantxoutset--condition1": "action=status",
+	"scantxoutset--condition2": "action=abort",
+	"scantxoutset--result2":    "Whether or not a scan was in progress and has been aborted",
+
+	// ScanTxOutSetResult help.
+	"scantxoutsetresult-searcheditems": "The number of unspent transaction outputs scanned",
+	"scantxoutsetresult-bestblock":     "The hash of the block the scanned unspent transaction output set is as of",
+	"scantxoutsetresult-height":        "The height of the block the scanned unspent transaction output set is as of",
+	"scantxoutsetresult-unspents":      "The matching unspent transaction outputs",
+	"scantxoutsetresult-totalamount":   "The total amount of all matching unspent transaction outputs in coins",
+
+	// ScanTxOutSetUnspent help.
+	"scantxoutsetunspent-txid":         "The hash of the transaction",
+	"scantxoutsetunspent-vout":         "The index of the output",
+	"scantxoutsetunspent-tree":         "The tree of the transaction",
+	"scantxoutsetunspent-scriptpubkey": "The hex-encoded output script",
+	"scantxoutsetunspent-desc":         "The descriptor which matched the output script",
+	"scantxoutsetunspent-amount":       "The amount of the output in coins",
+	"scantxoutsetunspent-height":       "The height of the block containing the transaction",
+	"scantxoutsetunspent-txtype":       "The type of the transaction (regular, ticket, vote, or revocation)",
+	"scantxoutsetunspent-coinbase":     "Whether or not the transaction is a coinbase",
+
+	// ScanTxOutSetStatusResult help.
+	"scantxoutsetstatusresult-progress": "The approximate percentage of the unspent transaction output set that has been scanned",
+
 	// SearchRawTransactionsCmd help.
 	"searchrawtransactions--synopsis": "Returns raw data for transactions involving the passed address.\n" +
 		"Returned transactions are pulled from both the database, and transactions currently in the mempool.\n" +
@@ -748,10 +934,31 @@ var helpDescsEnUS = map[string]string{
 		"The actual checks performed by the checklevel parameter are implementation specific.\n" +
 		"For cdrd this is:\n" +
 		"checklevel=0 - Look up each block and ensure it can be loaded from the database.\n" +
-		"checklevel=1 - Perform basic context-free sanity checks on each block.",
-	"verifychain-checklevel": "How thorough the block verification is",
-	"verifychain-checkdepth": "The number of blocks to check",
-	"verifychain--result0":   "Whether or not the chain verified",
+		"checklevel=1 - Perform basic context-free sanity checks on each block.\n" +
+		"checklevel=2 - Load the spend journal entry of each block and use it to disconnect the block in a scratch view of the utxo set.\n" +
+		"checklevel=3 - Ensure the ticket database matches the ticket treaps recomputed from the blocks and the commitments in their headers.\n" +
+		"checklevel=4 - Reconnect the disconnected blocks with full validation, including scripts, and ensure the spent outputs match the spend journal and the resulting utxos match the utxo set.\n" +
+		"checklevel=5 - Ensure the total value of the utxo set does not exceed the coin supply.\n" +
+		"Each level also performs the checks of all lower levels, and all inconsistencies found are reported rather than only the first one.",
+	"verifychain-checklevel":  "How thorough the block verification is (checklevel 2 and above keep all of the blocks being verified in memory and are significantly more expensive)",
+	"verifychain-checkdepth":  "The number of blocks to check (0 for all, limited to 2880 for checklevel 2 and above)",
+	"verifychain-verbose":     "Return the details of the verification including all inconsistencies found instead of only whether or not the chain verified",
+	"verifychain--condition0": "verbose=false",
+	"verifychain--condition1": "verbose=true",
+	"verifychain--result0":    "Whether or not the chain verified",
+
+	// VerifyChainResult help.
+	"verifychainresult-verified":        "Whether or not the chain verified",
+	"verifychainresult-checklevel":      "The check level that was used after clamping the requested one to the supported levels",
+	"verifychainresult-numblocks":       "The number of blocks that were verified",
+	"verifychainresult-utxosetvalue":    "The total value of the utxo set (only for checklevel 5)",
+	"verifychainresult-coinsupply":      "The total coin supply (only for checklevel 5)",
+	"verifychainresult-inconsistencies": "The inconsistencies found while verifying the chain",
+
+	// VerifyChainInconsistency help.
+	"verifychaininconsistency-hash":        "The hash of the block the inconsistency relates to",
+	"verifychaininconsistency-height":      "The height of the block the inconsistency relates to",
+	"verifychaininconsistency-description": "A description of the inconsistency",
 
 	// VerifyMessageCmd help.
 	"verifymessage--synopsis": "Verify a signed message.",
@@ -760,6 +967,12 @@ var helpDescsEnUS = map[string]string{
 	"verifymessage-message":   "The signed message",
 	"verifymessage--result0":  "Whether or not the signature verified",
 
+	// VerifyTxOutProofCmd help.
+	"verifytxoutproof--synopsis": "Verifies a proof created by gettxoutproof and returns the hashes of the transactions it commits to.\n" +
+		"An error is returned when the proof is invalid or its block is not part of the main chain.",
+	"verifytxoutproof-proof":    "The hex-encoded proof",
+	"verifytxoutproof--result0": "The hashes of the transactions the proof commits to",
+
 	// -------- Websocket-specific help --------
 
 	// Session help.
@@ -781,8 +994,14 @@ var helpDescsEnUS = map[string]string{
 	// NotifyBlocksCmd help.
 	"notifyblocks--synopsis": "Request notifications for whenever a block is connected or disconnected from the main (best) chain.",
 
+	// NotifyBlocksFromCmd help.
+	"notifyblocksfrom--synopsis": "Request notifications for every event in the chain event log which follows the provided cursor, which includes blocks being connected and disconnected, reorganizations and the tickets of each block.\n" +
+		"The events which follow the cursor are replayed first, possibly before the reply, and each event is sent exactly once and in order.\n" +
+		"Only a limited number of the most recent events are retained, so an error is returned when the events which follow the cursor are no longer available.",
+	"notifyblocksfrom-cursor": "The sequence number of the last chain event that was processed (0 for all available events) or the hash of the last block that was processed",
+
 	// StopNotifyBlocksCmd help.
-	"stopnotifyblocks--synopsis": "Cancel registered notifications for whenever a block is connected or disconnected from the main (best) chain.",
+	"stopnotifyblocks--synopsis": "Cancel registered notifications for whenever a block is connected or disconnected from the main (best) chain, including chain event notifications.",
 
 	// NotifyNewTransactionsCmd help.
 	"notifynewtransactions--synopsis": "Send either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
@@ -796,15 +1015,22 @@ var helpDescsEnUS = map[string]string{
 	"outpoint-index": "The index of the outpoint",
 	"outpoint-tree":  "The tree of the outpoint",
 
+	// Descriptor help.
+	"descriptor-desc":       "The output script descriptor, optionally including its checksum",
+	"descriptor-rangestart": "The first child index to derive for ranged descriptors",
+	"descriptor-rangeend":   "The last child index to derive for ranged descriptors (default 999)",
+
 	// LoadTxFilterCmd help.
-	"loadtxfilter--synopsis": "Load, add to, or reload a websocket client's transaction filter for mempool transactions, new blocks and rescans.",
-	"loadtxfilter-reload":    "Load a new filter instead of adding data to an existing one",
-	"loadtxfilter-addresses": "Array of addresses to add to the transaction filter",
-	"loadtxfilter-outpoints": "Array of outpoints to add to the transaction filter",
+	"loadtxfilter--synopsis":   "Load, add to, or reload a websocket client's transaction filter for mempool transactions, new blocks and rescans.",
+	"loadtxfilter-reload":      "Load a new filter instead of adding data to an existing one",
+	"loadtxfilter-addresses":   "Array of addresses to add to the transaction filter",
+	"loadtxfilter-outpoints":   "Array of outpoints to add to the transaction filter",
+	"loadtxfilter-descriptors": "Array of output script descriptors whose addresses are added to the transaction filter",
 
 	// Rescan help.
 	"rescan--synopsis":   "Rescan blocks for transactions matching the loaded transaction filter.",
 	"rescan-blockhashes": "Concatenated block hashes to rescan.  Each next block must be a child of the previous.",
+	"rescan-descriptors": "Array of output script descriptors whose addresses are added to the transaction filter before rescanning, loading a new filter if none is loaded",
 
 	// -------- commanderu-specific help --------
 
@@ -916,11 +1142,14 @@ var helpDescsEnUS = map[string]string{
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[string][]interface{}{
 	"addnode":               nil,
+	"combinepsbt":           {(*string)(nil)},
 	"createrawsstx":         {(*string)(nil)},
 	"createrawssgentx":      {(*string)(nil)},
 	"createrawssrtx":        {(*string)(nil)},
 	"createrawtransaction":  {(*string)(nil)},
 	"debuglevel":            {(*string)(nil), (*string)(nil)},
+	"debugscript":           {(*cdrjson.DebugScriptResult)(nil)},
+	"decodepsbt":            {(*cdrjson.DecodePsbtResult)(nil)},
 	"decoderawtransaction":  {(*cdrjson.TxRawDecodeResult)(nil)},
 	"decodescript":          {(*cdrjson.DecodeScriptResult)(nil)},
 	"estimatefee":           {(*float64)(nil)},
@@ -932,6 +1161,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"existsliveticket":      {(*bool)(nil)},
 	"existslivetickets":     {(*string)(nil)},
 	"existsmempooltxs":      {(*string)(nil)},
+	"finalizepsbt":          {(*cdrjson.FinalizePsbtResult)(nil)},
 	"getaddednodeinfo":      {(*[]string)(nil), (*[]cdrjson.GetAddedNodeInfoResult)(nil)},
 	"getbestblock":          {(*cdrjson.GetBestBlockResult)(nil)},
 	"generate":              {(*[]string)(nil)},
@@ -940,11 +1170,13 @@ var rpcResultTypes = map[string][]interface{}{
 	"getblockcount":         {(*int64)(nil)},
 	"getblockhash":          {(*string)(nil)},
 	"getblockheader":        {(*string)(nil), (*cdrjson.GetBlockHeaderVerboseResult)(nil)},
+	"getblockstats":         {(*cdrjson.GetBlockStatsResult)(nil)},
 	"getblocksubsidy":       {(*cdrjson.GetBlockSubsidyResult)(nil)},
 	"getblocktemplate":      {(*cdrjson.GetBlockTemplateResult)(nil), (*string)(nil), nil},
 	"getcfilter":            {(*string)(nil)},
 	"getcfilterheader":      {(*string)(nil)},
 	"getchaintips":          {(*[]cdrjson.GetChainTipsResult)(nil)},
+	"getchaintxstats":       {(*cdrjson.GetChainTxStatsResult)(nil)},
 	"getconnectioncount":    {(*int32)(nil)},
 	"getcurrentnet":         {(*uint32)(nil)},
 	"getdifficulty":         {(*float64)(nil)},
@@ -964,16 +1196,20 @@ var rpcResultTypes = map[string][]interface{}{
 	"getrawtransaction":     {(*string)(nil), (*cdrjson.TxRawResult)(nil)},
 	"getticketpoolvalue":    {(*float64)(nil)},
 	"gettxout":              {(*cdrjson.GetTxOutResult)(nil)},
+	"gettxoutproof":         {(*string)(nil)},
 	"getvoteinfo":           {(*cdrjson.GetVoteInfoResult)(nil)},
 	"getwork":               {(*cdrjson.GetWorkResult)(nil), (*bool)(nil)},
 	"getcoinsupply":         {(*int64)(nil)},
 	"help":                  {(*string)(nil), (*string)(nil)},
+	"invalidateblock":       nil,
 	"livetickets":           {(*cdrjson.LiveTicketsResult)(nil)},
 	"missedtickets":         {(*cdrjson.MissedTicketsResult)(nil)},
 	"node":                  nil,
 	"ping":                  nil,
 	"rebroadcastmissed":     nil,
 	"rebroadcastwinners":    nil,
+	"reconsiderblock":       nil,
+	"scantxoutset":          {(*cdrjson.ScanTxOutSetResult)(nil), (*cdrjson.ScanTxOutSetStatusResult)(nil), (*bool)(nil)},
 	"searchrawtransactions": {(*string)(nil), (*[]cdrjson.SearchRawTransactionsResult)(nil)},
 	"sendrawtransaction":    {(*string)(nil)},
 	"setgenerate":           nil,
@@ -984,8 +1220,9 @@ var rpcResultTypes = map[string][]interface{}{
 	"ticketvwap":            {(*float64)(nil)},
 	"txfeeinfo":             {(*cdrjson.TxFeeInfoResult)(nil)},
 	"validateaddress":       {(*cdrjson.ValidateAddressChainResult)(nil)},
-	"verifychain":           {(*bool)(nil)},
+	"verifychain":           {(*bool)(nil), (*cdrjson.VerifyChainResult)(nil)},
 	"verifymessage":         {(*bool)(nil)},
+	"verifytxoutproof":      {(*[]string)(nil)},
 	"version":               {(*map[string]cdrjson.VersionResult)(nil)},
 
 	// Websocket commands.
@@ -996,6 +1233,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"notifynewtickets":            nil,
 	"notifystakedifficulty":       nil,
 	"notifyblocks":                nil,
+	"notifyblocksfrom":            nil,
 	"notifynewtransactions":       nil,
 	"notifyreceived":              nil,
 	"notifyspent":                 nil,
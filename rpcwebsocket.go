@@ -25,14 +25,24 @@ import (
 
 	"github.com/commanderu/cdrd/blockchain"
 	"github.com/commanderu/cdrd/blockchain/stake"
+	"github.com/commanderu/cdrd/chaincfg"
 	"github.com/commanderu/cdrd/chaincfg/chainhash"
 	"github.com/commanderu/cdrd/cdrjson"
 	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/descriptor"
 	"github.com/commanderu/cdrd/txscript"
 	"github.com/commanderu/cdrd/wire"
 )
 
 const (
+	// defaultDescriptorRangeEnd is the end of the range of child indexes
+	// derived for ranged descriptors when a range end is not provided.
+	defaultDescriptorRangeEnd = 999
+
+	// maxDescriptorScripts is the maximum number of output scripts a
+	// single request may expand its descriptors into.
+	maxDescriptorScripts = 100000
+
 	// websocketSendBufferSize is the number of elements the send channel
 	// can queue before blocking.  Note that this only applies to requests
 	// handled directly in the websocket client input handler or the async
@@ -65,6 +75,7 @@ var wsHandlers map[string]wsCommandHandler
 var wsHandlersBeforeInit = map[string]wsCommandHandler{
 	"loadtxfilter":                handleLoadTxFilter,
 	"notifyblocks":                handleNotifyBlocks,
+	"notifyblocksfrom":            handleNotifyBlocksFrom,
 	"notifywinningtickets":        handleWinningTickets,
 	"notifyspentandmissedtickets": handleSpentAndMissedTickets,
 	"notifynewtickets":            handleNewTickets,
@@ -236,6 +247,19 @@ func (m *wsNotificationManager) NotifyReorganization(rd *blockchain.Reorganizati
 	}
 }
 
+// NotifyChainEvent passes an event added to the chain event log to the
+// notification manager for chain event notification processing.
+func (m *wsNotificationManager) NotifyChainEvent(event *blockchain.ChainEvent) {
+	// As NotifyChainEvent will be called by the block manager
+	// and the RPC server may no longer be running, use a select
+	// statement to unblock enqueuing the notification once the RPC
+	// server has begun shutting down.
+	select {
+	case m.queueNotification <- (*notificationChainEvent)(event):
+	case <-m.quit:
+	}
+}
+
 // NotifyWinningTickets passes newly winning tickets for an incoming block
 // to the notification manager for further processing.
 func (m *wsNotificationManager) NotifyWinningTickets(
@@ -453,6 +477,7 @@ func (f *wsClientFilter) existsUnspentOutPoint(op *wire.OutPoint) bool {
 type notificationBlockConnected cdrutil.Block
 type notificationBlockDisconnected cdrutil.Block
 type notificationReorganization blockchain.ReorganizationNtfnsData
+type notificationChainEvent blockchain.ChainEvent
 type notificationWinningTickets WinningTicketsNtfnData
 type notificationSpentAndMissedTickets blockchain.TicketNotificationsData
 type notificationNewTickets blockchain.TicketNotificationsData
@@ -467,6 +492,8 @@ type notificationRegisterClient wsClient
 type notificationUnregisterClient wsClient
 type notificationRegisterBlocks wsClient
 type notificationUnregisterBlocks wsClient
+type notificationRegisterChainEvents chainEventClient
+type notificationChainEventsCaughtUp chainEventClient
 type notificationRegisterWinningTickets wsClient
 type notificationUnregisterWinningTickets wsClient
 type notificationRegisterSpentAndMissedTickets wsClient
@@ -492,6 +519,7 @@ func (m *wsNotificationManager) notificationHandler() {
 	// Where possible, the quit channel is used as the unique id for a client
 	// since it is quite a bit more efficient than using the entire struct.
 	blockNotifications := make(map[chan struct{}]*wsClient)
+	chainEventNotifications := make(map[chan struct{}]*chainEventClient)
 	winningTicketNotifications := make(map[chan struct{}]*wsClient)
 	ticketSMNotifications := make(map[chan struct{}]*wsClient)
 	ticketNewNotifications := make(map[chan struct{}]*wsClient)
@@ -526,6 +554,10 @@ out:
 				m.notifyReorganization(blockNotifications,
 					(*blockchain.ReorganizationNtfnsData)(n))
 
+			case *notificationChainEvent:
+				m.notifyChainEvent(chainEventNotifications,
+					(*blockchain.ChainEvent)(n))
+
 			case *notificationWinningTickets:
 				m.notifyWinningTickets(winningTicketNotifications,
 					(*WinningTicketsNtfnData)(n))
@@ -555,6 +587,19 @@ out:
 			case *notificationUnregisterBlocks:
 				wsc := (*wsClient)(n)
 				delete(blockNotifications, wsc.quit)
+				delete(chainEventNotifications, wsc.quit)
+
+			case *notificationRegisterChainEvents:
+				// The events for the client are held until it has
+				// caught up by replaying the log.
+				c := (*chainEventClient)(n)
+				c.catchingUp = true
+				chainEventNotifications[c.wsc.quit] = c
+				close(c.registered)
+
+			case *notificationChainEventsCaughtUp:
+				m.sendPendingChainEvents(chainEventNotifications,
+					(*chainEventClient)(n))
 
 			case *notificationRegisterWinningTickets:
 				wsc := (*wsClient)(n)
@@ -597,6 +642,7 @@ out:
 				// Remove any requests made by the client as well as
 				// the client itself.
 				delete(blockNotifications, wsc.quit)
+				delete(chainEventNotifications, wsc.quit)
 				delete(txNotifications, wsc.quit)
 				delete(clients, wsc.quit)
 
@@ -641,12 +687,33 @@ func (m *wsNotificationManager) RegisterBlockUpdates(wsc *wsClient) {
 	m.queueNotification <- (*notificationRegisterBlocks)(wsc)
 }
 
-// UnregisterBlockUpdates removes block update notifications for the passed
-// websocket client.
+// UnregisterBlockUpdates removes block update and chain event notifications
+// for the passed websocket client.
 func (m *wsNotificationManager) UnregisterBlockUpdates(wsc *wsClient) {
 	m.queueNotification <- (*notificationUnregisterBlocks)(wsc)
 }
 
+// RegisterChainEvents requests chain event notifications to the passed websocket
+// client and waits for the request to be processed.  The events are held until
+// ChainEventsCaughtUp is called once the client has replayed the events which
+// follow the last one it was sent from the chain event log.
+func (m *wsNotificationManager) RegisterChainEvents(c *chainEventClient) {
+	c.registered = make(chan struct{})
+	m.queueNotification <- (*notificationRegisterChainEvents)(c)
+	select {
+	case <-c.registered:
+	case <-c.wsc.quit:
+	case <-m.quit:
+	}
+}
+
+// ChainEventsCaughtUp sends the chain events held for the passed websocket
+// client which follow the last one it was sent and resumes sending it the
+// events as they happen.
+func (m *wsNotificationManager) ChainEventsCaughtUp(c *chainEventClient) {
+	m.queueNotification <- (*notificationChainEventsCaughtUp)(c)
+}
+
 // subscribedClients returns the set of all websocket client quit channels that
 // are registered to receive notifications regarding tx, either due to tx
 // spending a watched output or outputting to a watched address.  Matching
@@ -814,6 +881,167 @@ func (m *wsNotificationManager) notifyReorganization(clients map[chan struct{}]*
 	}
 }
 
+// maxChainEventsPerBatch is the maximum number of events loaded from the chain
+// event log at a time when replaying them to websocket clients.
+const maxChainEventsPerBatch = 1000
+
+// chainEventClient houses a websocket client registered for chain event
+// notifications along with the sequence number of the last event it was sent.
+//
+// The client replays the events which follow the cursor it provided from the
+// chain event log in its own goroutine so the notification manager, which is
+// shared by all clients, never has to read the log.  Meanwhile, the events
+// which are added to the log are held by the notification manager until the
+// client has caught up.
+type chainEventClient struct {
+	wsc     *wsClient
+	lastSeq uint64
+
+	// registered is closed once the notification manager has registered
+	// the client.
+	registered chan struct{}
+
+	// catchingUp and pending are only accessed by the notification manager
+	// once the client is registered.  The pending events are the ones
+	// which were added to the log while the client is catching up.
+	catchingUp bool
+	pending    []*blockchain.ChainEvent
+}
+
+// marshalChainEventNtfn returns the marshalled chainevent notification for the
+// provided chain event.
+func marshalChainEventNtfn(event *blockchain.ChainEvent) ([]byte, error) {
+	hashStrings := func(hashes []chainhash.Hash) []string {
+		if len(hashes) == 0 {
+			return nil
+		}
+		strs := make([]string, 0, len(hashes))
+		for i := range hashes {
+			strs = append(strs, hashes[i].String())
+		}
+		return strs
+	}
+
+	result := cdrjson.ChainEventResult{
+		Sequence: event.Sequence,
+		Type:     event.Type.String(),
+		Hash:     event.Hash.String(),
+		Height:   event.Height,
+	}
+	switch event.Type {
+	case blockchain.ChainEventReorganization:
+		result.OldHash = event.OldHash.String()
+		result.OldHeight = event.OldHeight
+
+	case blockchain.ChainEventTickets:
+		result.StakeDifficulty = event.StakeDifficulty
+		result.TicketsSpent = hashStrings(event.TicketsSpent)
+		result.TicketsMissed = hashStrings(event.TicketsMissed)
+		result.TicketsNew = hashStrings(event.TicketsNew)
+	}
+	return cdrjson.MarshalCmd("1.0", nil, cdrjson.NewChainEventNtfn(result))
+}
+
+// replayChainEvents sends all of the events from the chain event log which
+// follow the last event sent to the passed client.
+func replayChainEvents(chain *blockchain.BlockChain, c *chainEventClient) error {
+	for {
+		events, err := chain.ChainEventsAfter(c.lastSeq,
+			maxChainEventsPerBatch)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		for i := range events {
+			marshalledJSON, err := marshalChainEventNtfn(&events[i])
+			if err != nil {
+				return err
+			}
+			if err := c.wsc.QueueNotification(marshalledJSON); err != nil {
+				return err
+			}
+			c.lastSeq = events[i].Sequence
+		}
+	}
+}
+
+// sendChainEvent sends the passed marshalled notification for the provided
+// event to the passed client when it is the next event the client expects.
+// Events which were already sent are skipped.  It returns false when the
+// client missed any events, in which case it must be disconnected.
+func sendChainEvent(c *chainEventClient, event *blockchain.ChainEvent, marshalledJSON []byte) bool {
+	if event.Sequence <= c.lastSeq {
+		return true
+	}
+	if event.Sequence != c.lastSeq+1 {
+		rpcsLog.Errorf("Websocket client %s missed chain events %d "+
+			"through %d", c.wsc.addr, c.lastSeq+1, event.Sequence-1)
+		return false
+	}
+	c.wsc.QueueNotification(marshalledJSON)
+	c.lastSeq = event.Sequence
+	return true
+}
+
+// sendPendingChainEvents sends the events which were held for the passed
+// websocket client while it was catching up and which follow the last event it
+// was sent, and marks the client caught up so it is sent the events as they
+// happen from then on.
+func (m *wsNotificationManager) sendPendingChainEvents(clients map[chan struct{}]*chainEventClient, c *chainEventClient) {
+	// Nothing to do when the client was unregistered in the meantime.
+	if clients[c.wsc.quit] != c {
+		return
+	}
+
+	for _, event := range c.pending {
+		marshalledJSON, err := marshalChainEventNtfn(event)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal chain event "+
+				"notification: %v", err)
+			continue
+		}
+		if !sendChainEvent(c, event, marshalledJSON) {
+			delete(clients, c.wsc.quit)
+			c.wsc.Disconnect()
+			return
+		}
+	}
+	c.pending = nil
+	c.catchingUp = false
+}
+
+// notifyChainEvent notifies websocket clients that have registered for chain
+// events when an event is added to the chain event log.  The event is held for
+// the clients which are still catching up by replaying the log so that clients
+// receive every event exactly once and in order.
+func (m *wsNotificationManager) notifyChainEvent(clients map[chan struct{}]*chainEventClient, event *blockchain.ChainEvent) {
+	// Skip notification creation if no clients have requested chain event
+	// notifications.
+	if len(clients) == 0 {
+		return
+	}
+
+	marshalledJSON, err := marshalChainEventNtfn(event)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal chain event notification: %v",
+			err)
+		return
+	}
+	for quitChan, c := range clients {
+		if c.catchingUp {
+			c.pending = append(c.pending, event)
+			continue
+		}
+		if !sendChainEvent(c, event, marshalledJSON) {
+			delete(clients, quitChan)
+			c.wsc.Disconnect()
+		}
+	}
+}
+
 // RegisterWinningTickets requests winning tickets update notifications
 // to the passed websocket client.
 func (m *wsNotificationManager) RegisterWinningTickets(wsc *wsClient) {
@@ -1606,15 +1834,7 @@ out:
 							}
 						}
 
-						// Lookup the websocket extension for the command, if it doesn't
-						// exist fallback to handling the command as a standard command.
-						var resp interface{}
-						wsHandler, ok := wsHandlers[cmd.method]
-						if ok {
-							resp, err = wsHandler(c, cmd.cmd)
-						} else {
-							resp, err = c.server.standardCmdResult(cmd, nil)
-						}
+						resp, err := c.commandResult(cmd)
 
 						// Marshal request output.
 						reply, err := createMarshalledReply(cmd.jsonrpc, cmd.id, resp, err)
@@ -1669,6 +1889,24 @@ out:
 	rpcsLog.Tracef("Websocket client input handler done for %s", c.addr)
 }
 
+// commandResult looks up and runs the websocket extension for the parsed
+// command and if it doesn't exist falls back to handling the command as a
+// standard command.  Only the commands that are available in headers-only mode
+// are run when it is active.
+func (c *wsClient) commandResult(r *parsedRPCCmd) (interface{}, error) {
+	if cfg.HeadersOnly {
+		if _, ok := rpcHeadersOnly[r.method]; !ok {
+			return nil, ErrRPCHeadersOnly
+		}
+	}
+
+	wsHandler, ok := wsHandlers[r.method]
+	if ok {
+		return wsHandler(c, r.cmd)
+	}
+	return c.server.standardCmdResult(r, nil)
+}
+
 // serviceRequest services a parsed RPC request by looking up and executing the
 // appropriate RPC handler.  The response is marshalled and sent to the websocket
 // client.
@@ -1678,14 +1916,7 @@ func (c *wsClient) serviceRequest(r *parsedRPCCmd) {
 		err    error
 	)
 
-	// Lookup the websocket extension for the command and if it doesn't
-	// exist fallback to handling the command as a standard command.
-	wsHandler, ok := wsHandlers[r.method]
-	if ok {
-		result, err = wsHandler(c, r.cmd)
-	} else {
-		result, err = c.server.standardCmdResult(r, nil)
-	}
+	result, err = c.commandResult(r)
 	reply, err := createMarshalledReply(r.jsonrpc, r.id, result, err)
 	if err != nil {
 		rpcsLog.Errorf("Failed to marshal reply for <%s> "+
@@ -1970,11 +2201,86 @@ func handleWebsocketHelp(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	return help, nil
 }
 
+// expandDescriptors expands the passed descriptors into the output scripts they
+// describe and invokes the provided function with each script along with the
+// parsed descriptor it was derived from.
+func expandDescriptors(descs []cdrjson.Descriptor, params *chaincfg.Params, fn func(d *descriptor.Descriptor, script []byte)) error {
+	var numScripts uint64
+	for i := range descs {
+		d, err := descriptor.Parse(descs[i].Desc, params)
+		if err != nil {
+			return rpcInvalidError("Invalid descriptor %q: %v",
+				descs[i].Desc, err)
+		}
+
+		start, end := uint32(0), uint32(defaultDescriptorRangeEnd)
+		if descs[i].RangeStart != nil {
+			start = *descs[i].RangeStart
+		}
+		if descs[i].RangeEnd != nil {
+			end = *descs[i].RangeEnd
+		}
+		if d.IsRange() {
+			if start > end {
+				return rpcInvalidError("Invalid range for "+
+					"descriptor %q: start %d is after end %d",
+					descs[i].Desc, start, end)
+			}
+			numScripts += uint64(end-start) + 1
+		} else {
+			numScripts++
+		}
+		if numScripts > maxDescriptorScripts {
+			return rpcInvalidError("Descriptors expand to more "+
+				"than the max of %d scripts", maxDescriptorScripts)
+		}
+
+		scripts, err := d.Expand(start, end)
+		if err != nil {
+			return rpcInvalidError("Unable to expand descriptor "+
+				"%q: %v", descs[i].Desc, err)
+		}
+		for _, script := range scripts {
+			fn(d, script)
+		}
+	}
+	return nil
+}
+
+// descriptorAddresses expands the passed descriptors into the addresses paid
+// by the output scripts they describe.  Output scripts which do not pay to an
+// address are ignored since they can't be matched by a transaction filter.
+func descriptorAddresses(descs []cdrjson.Descriptor, params *chaincfg.Params) ([]cdrutil.Address, error) {
+	var addrs []cdrutil.Address
+	err := expandDescriptors(descs, params, func(_ *descriptor.Descriptor, script []byte) {
+		_, scriptAddrs, _, err := txscript.ExtractPkScriptAddrs(
+			txscript.DefaultScriptVersion, script, params)
+		if err != nil {
+			return
+		}
+		addrs = append(addrs, scriptAddrs...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
 // handleLoadTxFilter implements the loadtxfilter command extension for
 // websocket connections.
 func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	cmd := icmd.(*cdrjson.LoadTxFilterCmd)
 
+	var descAddrs []cdrutil.Address
+	if cmd.Descriptors != nil {
+		var err error
+		descAddrs, err = descriptorAddresses(*cmd.Descriptors,
+			wsc.server.server.chainParams)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	outPoints := make([]*wire.OutPoint, len(cmd.OutPoints))
 	for i := range cmd.OutPoints {
 		hash, err := chainhash.NewHashFromStr(cmd.OutPoints[i].Hash)
@@ -1993,7 +2299,11 @@ func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 
 	wsc.Lock()
 	if cmd.Reload || wsc.filterData == nil {
-		wsc.filterData = makeWSClientFilter(cmd.Addresses, outPoints)
+		filter := makeWSClientFilter(cmd.Addresses, outPoints)
+		for _, a := range descAddrs {
+			filter.addAddress(a)
+		}
+		wsc.filterData = filter
 		wsc.Unlock()
 	} else {
 		filter := wsc.filterData
@@ -2003,6 +2313,9 @@ func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 		for _, a := range cmd.Addresses {
 			filter.addAddressStr(a)
 		}
+		for _, a := range descAddrs {
+			filter.addAddress(a)
+		}
 		for _, op := range outPoints {
 			filter.addUnspentOutPoint(op)
 		}
@@ -2019,6 +2332,55 @@ func handleNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	return nil, nil
 }
 
+// handleNotifyBlocksFrom implements the notifyblocksfrom command extension for
+// websocket connections.
+func handleNotifyBlocksFrom(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*cdrjson.NotifyBlocksFromCmd)
+	if !ok {
+		return nil, cdrjson.ErrRPCInternal
+	}
+
+	// The cursor is either the hash of the last block the client processed
+	// or the sequence number of the last event it processed.
+	chain := wsc.server.chain
+	var seq uint64
+	if len(cmd.Cursor) == chainhash.MaxHashStringSize {
+		hash, err := chainhash.NewHashFromStr(cmd.Cursor)
+		if err != nil {
+			return nil, rpcDecodeHexError(cmd.Cursor)
+		}
+		seq, err = chain.ChainEventSequenceByHash(hash)
+		if err != nil {
+			return nil, rpcInvalidError("%v", err)
+		}
+	} else {
+		var err error
+		seq, err = strconv.ParseUint(cmd.Cursor, 10, 64)
+		if err != nil {
+			return nil, rpcInvalidError("Cursor %q is neither a chain "+
+				"event sequence number nor a block hash", cmd.Cursor)
+		}
+	}
+
+	// Ensure the events which follow the cursor are available.
+	if _, err := chain.ChainEventsAfter(seq, 0); err != nil {
+		return nil, rpcInvalidError("%v", err)
+	}
+
+	// Register the client before replaying the events which follow the
+	// cursor so the events which are added to the log in the meantime are
+	// held for it, and then hand it over to the notification manager to
+	// send the events as they happen.
+	c := &chainEventClient{wsc: wsc, lastSeq: seq}
+	wsc.server.ntfnMgr.RegisterChainEvents(c)
+	if err := replayChainEvents(chain, c); err != nil {
+		wsc.Disconnect()
+		return nil, err
+	}
+	wsc.server.ntfnMgr.ChainEventsCaughtUp(c)
+	return nil, nil
+}
+
 // handleSession implements the session command extension for websocket
 // connections.
 func handleSession(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -2171,8 +2533,23 @@ func handleRescan(wsc *wsClient, icmd interface{}) (interface{}, error) {
 		return nil, cdrjson.ErrRPCInternal
 	}
 
-	// Load client's transaction filter.  Must exist in order to continue.
+	// Expand any descriptors to add to the transaction filter.
+	var descAddrs []cdrutil.Address
+	if cmd.Descriptors != nil {
+		var err error
+		descAddrs, err = descriptorAddresses(*cmd.Descriptors,
+			wsc.server.server.chainParams)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Load client's transaction filter.  Must exist in order to continue
+	// unless descriptors were provided to load it with.
 	wsc.Lock()
+	if wsc.filterData == nil && cmd.Descriptors != nil {
+		wsc.filterData = makeWSClientFilter(nil, nil)
+	}
 	filter := wsc.filterData
 	wsc.Unlock()
 	if filter == nil {
@@ -2181,6 +2558,13 @@ func handleRescan(wsc *wsClient, icmd interface{}) (interface{}, error) {
 			Message: "Transaction filter must be loaded before rescanning",
 		}
 	}
+	if len(descAddrs) > 0 {
+		filter.mu.Lock()
+		for _, a := range descAddrs {
+			filter.addAddress(a)
+		}
+		filter.mu.Unlock()
+	}
 
 	blockHashes, err := cdrjson.DecodeConcatenatedHashes(cmd.BlockHashes)
 	if err != nil {
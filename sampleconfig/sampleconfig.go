@@ -169,6 +169,10 @@ const FileContents = `[Application Options]
 ; Disable listening for incoming connections.  This will override all listeners.
 ; nolisten=1
 
+; Enable serving filtered blocks and transactions to SPV peers which load a
+; bloom filter.  It is disabled by default.
+; peerbloomfilters=1
+
 
 ; ------------------------------------------------------------------------------
 ; RPC server options - The following options control the built-in RPC server
@@ -224,6 +228,29 @@ const FileContents = `[Application Options]
 ; server without having to remove credentials from the config file.
 ; norpc=1
 
+; Specify the interfaces for the unauthenticated read-only REST server to listen
+; on.  One listen address per line.  The REST server is disabled unless at least
+; one interface is specified.  It shares the TLS certificate of the RPC server
+; unless TLS is disabled with 'notls'.
+; All interfaces on default port:
+;   restlisten=
+; Only ipv4 localhost on port 37461:
+;   restlisten=127.0.0.1:37461
+
+; Specify the maximum number of concurrent REST clients.
+; restmaxclients=10
+
+; Publish chain and memory pool events to subscribers using a ZeroMQ compatible
+; PUB socket.  Each option specifies the address to publish the topic on and
+; topics configured with the same address share a socket.  Publishing is
+; disabled for topics without an address.
+; zmqpubhashblock=tcp://127.0.0.1:28332
+; zmqpubrawblock=tcp://127.0.0.1:28332
+; zmqpubhashtx=tcp://127.0.0.1:28332
+; zmqpubrawtx=tcp://127.0.0.1:28332
+; zmqpubwinningtickets=tcp://127.0.0.1:28333
+; zmqpubstakedifficulty=tcp://127.0.0.1:28333
+
 
 
 ; ------------------------------------------------------------------------------
@@ -274,6 +301,10 @@ const FileContents = `[Application Options]
 ; searchrawtransactions RPC available.
 ; addrindex=1
 
+; Build and maintain an index of per-block statistics which makes historic
+; queries via the getblockstats RPC faster.
+; blockstatsindex=1
+
 
 ; ------------------------------------------------------------------------------
 ; Signature Verification Cache
@@ -282,6 +313,12 @@ const FileContents = `[Application Options]
 ; Limit the signature cache to a max of 50000 entries.
 ; sigcachemaxsize=50000
 
+; Skip script validation for the ancestors of the specified block, which is
+; assumed to be valid, while still enforcing all other consensus rules.  The
+; default is a recent block for the active network.  Use 0 to validate the
+; scripts of all blocks.
+; assumevalid=0
+
 
 ; ------------------------------------------------------------------------------
 ; Coin Generation (Mining) Settings - The following options control the
@@ -12,7 +12,9 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,6 +29,7 @@ import (
 	"github.com/commanderu/cdrd/connmgr"
 	"github.com/commanderu/cdrd/database"
 	"github.com/commanderu/cdrd/cdrutil"
+	"github.com/commanderu/cdrd/cdrutil/bloom"
 	"github.com/commanderu/cdrd/gcs"
 	"github.com/commanderu/cdrd/gcs/blockcf"
 	"github.com/commanderu/cdrd/mempool"
@@ -55,7 +58,12 @@ const (
 	connectionRetryInterval = time.Second * 5
 
 	// maxProtocolVersion is the max protocol version the server supports.
-	maxProtocolVersion = wire.NodeCFVersion
+	maxProtocolVersion = wire.BatchedCFiltersVersion
+
+	// feelerTimeout is the maximum amount of time a feeler connection is
+	// allowed to take to complete the version handshake before it is
+	// disconnected.
+	feelerTimeout = time.Second * 30
 )
 
 var (
@@ -154,6 +162,8 @@ type server struct {
 	connManager          *connmgr.ConnManager
 	sigCache             *txscript.SigCache
 	rpcServer            *rpcServer
+	restServer           *restServer
+	pubSub               *pubSubNotifier
 	blockManager         *blockManager
 	txMemPool            *mempool.TxPool
 	cpuMiner             *CPUMiner
@@ -180,11 +190,27 @@ type server struct {
 	addrIndex       *indexers.AddrIndex
 	existsAddrIndex *indexers.ExistsAddrIndex
 	cfIndex         *indexers.CFIndex
+	blockStatsIndex *indexers.BlockStatsIndex
+
+	// cfilterCache and cfCheckptCache keep recently served committed
+	// filters and the committed filter header checkpoints in memory so
+	// light clients can sync filters for the whole chain efficiently.
+	cfilterCache   *cfilterCache
+	cfCheckptCache *cfCheckptCache
+
+	// netGroupKey is a random value used to order inbound peers by network
+	// group when choosing a peer to evict in a way that can't be predicted
+	// by remote peers.
+	netGroupKey uint64
 }
 
 // serverPeer extends the peer to maintain state shared by the server and
 // the blockmanager.
 type serverPeer struct {
+	// The following variables must only be used atomically.
+	lastBlockTime int64
+	lastTxTime    int64
+
 	*peer.Peer
 
 	connReq         *connmgr.ConnReq
@@ -194,6 +220,8 @@ type serverPeer struct {
 	relayMtx        sync.Mutex
 	disableRelayTx  bool
 	isWhitelisted   bool
+	isFeeler        bool
+	filter          *bloom.Filter
 	requestQueue    []*wire.InvVect
 	requestedTxns   map[chainhash.Hash]struct{}
 	requestedBlocks map[chainhash.Hash]struct{}
@@ -212,6 +240,7 @@ func newServerPeer(s *server, isPersistent bool) *serverPeer {
 	return &serverPeer{
 		server:          s,
 		persistent:      isPersistent,
+		filter:          bloom.LoadFilter(nil),
 		requestedTxns:   make(map[chainhash.Hash]struct{}),
 		requestedBlocks: make(map[chainhash.Hash]struct{}),
 		knownAddresses:  make(map[string]struct{}),
@@ -323,6 +352,15 @@ func (sp *serverPeer) addBanScore(persistent, transient uint32, reason string) {
 // to negotiate the protocol version details as well as kick start the
 // communications.
 func (sp *serverPeer) OnVersion(p *peer.Peer, msg *wire.MsgVersion) {
+	// Feeler connections only exist to test that the address is reachable,
+	// so mark the address as good and disconnect.
+	if sp.isFeeler {
+		peerLog.Debugf("Feeler connection to %s succeeded", p)
+		sp.server.addrManager.Good(p.NA())
+		sp.Disconnect()
+		return
+	}
+
 	// Add the remote peer time as a sample for creating an offset against
 	// the local clock to keep the network time in sync.
 	sp.server.timeSource.AddTimeSample(p.Addr(), msg.Timestamp)
@@ -388,10 +426,17 @@ func (sp *serverPeer) OnMemPool(p *peer.Peer, msg *wire.MsgMemPool) {
 	txDescs := txMemPool.TxDescs()
 	invMsg := wire.NewMsgInvSizeHint(uint(len(txDescs)))
 
-	for i, txDesc := range txDescs {
+	for _, txDesc := range txDescs {
+		// Either add all transactions when there is no bloom filter,
+		// or only the transactions that match the filter when there is
+		// one.
+		if sp.filter.IsLoaded() && !sp.filter.MatchTxAndUpdate(txDesc.Tx) {
+			continue
+		}
+
 		iv := wire.NewInvVect(wire.InvTypeTx, txDesc.Tx.Hash())
 		invMsg.AddInvVect(iv)
-		if i+1 >= wire.MaxInvPerMsg {
+		if len(invMsg.InvList) >= wire.MaxInvPerMsg {
 			break
 		}
 	}
@@ -527,6 +572,7 @@ func (sp *serverPeer) OnTx(p *peer.Peer, msg *wire.MsgTx) {
 	tx := cdrutil.NewTx(msg)
 	iv := wire.NewInvVect(wire.InvTypeTx, tx.Hash())
 	p.AddKnownInventory(iv)
+	atomic.StoreInt64(&sp.lastTxTime, time.Now().Unix())
 
 	// Queue the transaction up to be handled by the block manager and
 	// intentionally block further receives until the transaction is fully
@@ -547,6 +593,7 @@ func (sp *serverPeer) OnBlock(p *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 	// Add the block to the known inventory for the peer.
 	iv := wire.NewInvVect(wire.InvTypeBlock, block.Hash())
 	p.AddKnownInventory(iv)
+	atomic.StoreInt64(&sp.lastBlockTime, time.Now().Unix())
 
 	// Queue the block up to be handled by the block manager and
 	// intentionally block further receives until the network block is fully
@@ -599,6 +646,26 @@ func (sp *serverPeer) OnHeaders(p *peer.Peer, msg *wire.MsgHeaders) {
 	sp.server.blockManager.QueueHeaders(msg, sp)
 }
 
+// OnCFHeaders is invoked when a peer receives a cfheaders wire message.  The
+// committed filter headers are only requested in headers-only mode, so the
+// message is ignored otherwise.
+func (sp *serverPeer) OnCFHeaders(p *peer.Peer, msg *wire.MsgCFHeaders) {
+	if !cfg.HeadersOnly {
+		return
+	}
+	sp.server.blockManager.QueueCFHeaders(msg, sp)
+}
+
+// OnCFilter is invoked when a peer receives a cfilter wire message.  The
+// committed filters are only requested in headers-only mode, so the message is
+// ignored otherwise.
+func (sp *serverPeer) OnCFilter(p *peer.Peer, msg *wire.MsgCFilter) {
+	if !cfg.HeadersOnly {
+		return
+	}
+	sp.server.blockManager.QueueCFilter(msg, sp)
+}
+
 // handleGetData is invoked when a peer receives a getdata wire message and is
 // used to deliver block and transaction information.
 func (sp *serverPeer) OnGetData(p *peer.Peer, msg *wire.MsgGetData) {
@@ -642,6 +709,8 @@ func (sp *serverPeer) OnGetData(p *peer.Peer, msg *wire.MsgGetData) {
 			err = sp.server.pushTxMsg(sp, &iv.Hash, c, waitChan)
 		case wire.InvTypeBlock:
 			err = sp.server.pushBlockMsg(sp, &iv.Hash, c, waitChan)
+		case wire.InvTypeFilteredBlock:
+			err = sp.server.pushMerkleBlockMsg(sp, &iv.Hash, c, waitChan)
 		default:
 			peerLog.Warnf("Unknown type in inventory request %d",
 				iv.Type)
@@ -866,50 +935,13 @@ func (sp *serverPeer) OnGetCFilter(p *peer.Peer, msg *wire.MsgGetCFilter) {
 		return
 	}
 
-	filterBytes, err := sp.server.cfIndex.FilterByBlockHash(&msg.BlockHash,
+	filterBytes, err := sp.server.fetchCFilter(&msg.BlockHash,
 		msg.FilterType)
 	if err != nil {
-		peerLog.Errorf("OnGetCFilter: failed to fetch cfilter: %v", err)
+		peerLog.Errorf("OnGetCFilter: %v", err)
 		return
 	}
 
-	// If the filter is not saved in the index (perhaps it was removed as a
-	// block was disconnected, or this has always been a sidechain block) build
-	// the filter on the spot.
-	if len(filterBytes) == 0 {
-		block, err := sp.server.blockManager.chain.FetchBlockByHash(
-			&msg.BlockHash)
-		if err != nil {
-			peerLog.Errorf("OnGetCFilter: failed to fetch non-mainchain "+
-				"block %v: %v", &msg.BlockHash, err)
-			return
-		}
-
-		var f *gcs.Filter
-		switch msg.FilterType {
-		case wire.GCSFilterRegular:
-			f, err = blockcf.Regular(block.MsgBlock())
-			if err != nil {
-				peerLog.Errorf("OnGetCFilter: failed to build regular "+
-					"cfilter for block %v: %v", &msg.BlockHash, err)
-				return
-			}
-		case wire.GCSFilterExtended:
-			f, err = blockcf.Extended(block.MsgBlock())
-			if err != nil {
-				peerLog.Errorf("OnGetCFilter: failed to build extended "+
-					"cfilter for block %v: %v", &msg.BlockHash, err)
-				return
-			}
-		default:
-			peerLog.Errorf("OnGetCFilter: unhandled filter type %d",
-				msg.FilterType)
-			return
-		}
-
-		filterBytes = f.NBytes()
-	}
-
 	peerLog.Tracef("Obtained CF for %v", &msg.BlockHash)
 
 	filterMsg := wire.NewMsgCFilter(&msg.BlockHash, msg.FilterType,
@@ -1045,6 +1077,240 @@ func (sp *serverPeer) OnGetCFHeaders(p *peer.Peer, msg *wire.MsgGetCFHeaders) {
 	sp.QueueMessage(headersMsg, nil)
 }
 
+// fetchCFilter returns the serialized committed filter of the given type for
+// the block with the passed hash.  Recently requested filters are served from
+// an in-memory cache.  When the filter is not saved in the index (perhaps it
+// was removed as a block was disconnected, or this has always been a sidechain
+// block) it is built on the spot.
+//
+// This function is safe for concurrent access.
+func (s *server) fetchCFilter(hash *chainhash.Hash, filterType wire.FilterType) ([]byte, error) {
+	if filterBytes, ok := s.cfilterCache.Lookup(hash, filterType); ok {
+		return filterBytes, nil
+	}
+
+	filterBytes, err := s.cfIndex.FilterByBlockHash(hash, filterType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cfilter: %v", err)
+	}
+
+	if len(filterBytes) == 0 {
+		block, err := s.blockManager.chain.FetchBlockByHash(hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch non-mainchain "+
+				"block %v: %v", hash, err)
+		}
+
+		var f *gcs.Filter
+		switch filterType {
+		case wire.GCSFilterRegular:
+			f, err = blockcf.Regular(block.MsgBlock())
+			if err != nil {
+				return nil, fmt.Errorf("failed to build regular "+
+					"cfilter for block %v: %v", hash, err)
+			}
+		case wire.GCSFilterExtended:
+			f, err = blockcf.Extended(block.MsgBlock())
+			if err != nil {
+				return nil, fmt.Errorf("failed to build extended "+
+					"cfilter for block %v: %v", hash, err)
+			}
+		default:
+			return nil, fmt.Errorf("unhandled filter type %d",
+				filterType)
+		}
+
+		filterBytes = f.NBytes()
+	}
+
+	s.cfilterCache.Add(hash, filterType, filterBytes)
+	return filterBytes, nil
+}
+
+// OnGetCFilters is invoked when a peer receives a getcfilters wire message.
+// The requested filters are sent as individual cfilter messages in ascending
+// height order.
+func (sp *serverPeer) OnGetCFilters(p *peer.Peer, msg *wire.MsgGetCFilters) {
+	// Disconnect and/or ban depending on the node cf services flag and
+	// negotiated protocol version.
+	if !sp.enforceNodeCFFlag(msg.Command()) {
+		return
+	}
+	if !sp.enforceBatchedCFVersion(msg.Command()) {
+		return
+	}
+
+	// Ignore getcfilters requests if cfg.NoCFilters is set or we're not in
+	// sync.
+	if cfg.NoCFilters || !sp.server.blockManager.IsCurrent() {
+		return
+	}
+
+	// Check for understood filter type.
+	switch msg.FilterType {
+	case wire.GCSFilterRegular, wire.GCSFilterExtended:
+	default:
+		peerLog.Warnf("OnGetCFilters: unsupported filter type %v",
+			msg.FilterType)
+		return
+	}
+
+	// Look up the height of the provided stop hash.  Nothing is sent when
+	// the stop hash is not part of the main chain.
+	chain := sp.server.blockManager.chain
+	stopHeight, err := chain.BlockHeightByHash(&msg.StopHash)
+	if err != nil {
+		peerLog.Debugf("OnGetCFilters: stop hash %v is not in the main "+
+			"chain", &msg.StopHash)
+		return
+	}
+
+	// Ensure the requested range is sane and not larger than the maximum
+	// allowed.
+	startHeight := int64(msg.StartHeight)
+	if startHeight > stopHeight {
+		peerLog.Debugf("OnGetCFilters: invalid range from peer %v - "+
+			"start height %d is after stop height %d", sp,
+			startHeight, stopHeight)
+		return
+	}
+	if stopHeight-startHeight >= wire.MaxGetCFiltersReqRange {
+		peerLog.Debugf("OnGetCFilters: peer %v requested too many "+
+			"filters [count %d, max %d]", sp, stopHeight-startHeight+1,
+			wire.MaxGetCFiltersReqRange)
+		return
+	}
+
+	// Fetch the hashes of the blocks in the requested range and ensure the
+	// chain was not reorganized in the mean time.
+	hashList, err := chain.HeightRange(startHeight, stopHeight+1)
+	if err != nil {
+		peerLog.Warnf("OnGetCFilters: block lookup failed: %v", err)
+		return
+	}
+	if len(hashList) == 0 || hashList[len(hashList)-1] != msg.StopHash {
+		return
+	}
+
+	for i := range hashList {
+		filterBytes, err := sp.server.fetchCFilter(&hashList[i],
+			msg.FilterType)
+		if err != nil {
+			peerLog.Errorf("OnGetCFilters: %v", err)
+			return
+		}
+
+		filterMsg := wire.NewMsgCFilter(&hashList[i], msg.FilterType,
+			filterBytes)
+		sp.QueueMessage(filterMsg, nil)
+	}
+}
+
+// cfCheckptHeaders returns the committed filter headers of the given type for
+// the main chain blocks at every wire.CFCheckptInterval heights up to and
+// including the passed stop height.  Previously fetched checkpoints are cached
+// and only the checkpoints which are either new or were reorganized out of the
+// main chain are loaded from the database.
+//
+// This function is safe for concurrent access.
+func (s *server) cfCheckptHeaders(filterType wire.FilterType, stopHeight int64) ([]*chainhash.Hash, error) {
+	c := s.cfCheckptCache
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	chain := s.blockManager.chain
+	cached := c.checkpts[filterType]
+	numCheckpts := stopHeight / wire.CFCheckptInterval
+	headers := make([]*chainhash.Hash, 0, numCheckpts)
+	for i := int64(0); i < numCheckpts; i++ {
+		height := (i + 1) * wire.CFCheckptInterval
+		blockHash, err := chain.BlockHashByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+
+		// Use the cached checkpoint when it still refers to the block
+		// in the main chain.
+		if i < int64(len(cached)) && cached[i].blockHash == *blockHash {
+			header := cached[i].filterHeader
+			headers = append(headers, &header)
+			continue
+		}
+
+		// The cached checkpoints from here on are either missing or no
+		// longer in the main chain, so discard them and load the
+		// header from the database instead.
+		cached = cached[:i]
+		headerBytes, err := s.cfIndex.FilterHeaderByBlockHash(blockHash,
+			filterType)
+		if err != nil {
+			return nil, err
+		}
+		var header chainhash.Hash
+		err = header.SetBytes(headerBytes)
+		if err != nil {
+			return nil, err
+		}
+		cached = append(cached, cfCheckpt{
+			blockHash:    *blockHash,
+			filterHeader: header,
+		})
+		headers = append(headers, &header)
+	}
+	c.checkpts[filterType] = cached
+
+	return headers, nil
+}
+
+// OnGetCFCheckpt is invoked when a peer receives a getcfcheckpt wire message.
+func (sp *serverPeer) OnGetCFCheckpt(p *peer.Peer, msg *wire.MsgGetCFCheckpt) {
+	// Disconnect and/or ban depending on the node cf services flag and
+	// negotiated protocol version.
+	if !sp.enforceNodeCFFlag(msg.Command()) {
+		return
+	}
+	if !sp.enforceBatchedCFVersion(msg.Command()) {
+		return
+	}
+
+	// Ignore getcfcheckpt requests if cfg.NoCFilters is set or we're not
+	// in sync.
+	if cfg.NoCFilters || !sp.server.blockManager.IsCurrent() {
+		return
+	}
+
+	// Check for understood filter type.
+	switch msg.FilterType {
+	case wire.GCSFilterRegular, wire.GCSFilterExtended:
+	default:
+		peerLog.Warnf("OnGetCFCheckpt: unsupported filter type %v",
+			msg.FilterType)
+		return
+	}
+
+	// Look up the height of the provided stop hash.  Nothing is sent when
+	// the stop hash is not part of the main chain.
+	stopHeight, err := sp.server.blockManager.chain.BlockHeightByHash(
+		&msg.StopHash)
+	if err != nil {
+		peerLog.Debugf("OnGetCFCheckpt: stop hash %v is not in the main "+
+			"chain", &msg.StopHash)
+		return
+	}
+
+	headers, err := sp.server.cfCheckptHeaders(msg.FilterType, stopHeight)
+	if err != nil {
+		peerLog.Warnf("OnGetCFCheckpt: could not obtain CF header "+
+			"checkpoints: %v", err)
+		return
+	}
+
+	checkptMsg := wire.NewMsgCFCheckpt(msg.FilterType, &msg.StopHash,
+		len(headers))
+	checkptMsg.FilterHeaders = headers
+	sp.QueueMessage(checkptMsg, nil)
+}
+
 // OnGetCFTypes is invoked when a peer receives a getcftypes wire message.
 func (sp *serverPeer) OnGetCFTypes(p *peer.Peer, msg *wire.MsgGetCFTypes) {
 	// Disconnect and/or ban depending on the node cf services flag and
@@ -1099,6 +1365,114 @@ func (sp *serverPeer) enforceNodeCFFlag(cmd string) bool {
 	return true
 }
 
+// enforceBatchedCFVersion disconnects and bans the peer if it has not
+// negotiated to a protocol version that is high enough to support the batched
+// committed filter messages since it is intentionally violating the protocol.
+func (sp *serverPeer) enforceBatchedCFVersion(cmd string) bool {
+	if sp.ProtocolVersion() < wire.BatchedCFiltersVersion {
+		peerLog.Debugf("%s sent a %s request with protocol version %d "+
+			"-- disconnecting", sp, cmd, sp.ProtocolVersion())
+		sp.addBanScore(100, 0, cmd)
+		sp.Disconnect()
+		return false
+	}
+
+	return true
+}
+
+// enforceNodeBloomFlag disconnects the peer if the server is not configured to
+// allow bloom filters.  Additionally, if the peer has negotiated to a protocol
+// version that is high enough to observe the bloom filter service support bit,
+// it will be banned since it is intentionally violating the protocol.
+func (sp *serverPeer) enforceNodeBloomFlag(cmd string) bool {
+	if sp.server.services&wire.SFNodeBloom != wire.SFNodeBloom {
+		// Ban the peer if the protocol version is high enough that the
+		// peer is knowingly violating the protocol and banning is
+		// enabled.
+		//
+		// NOTE: Even though the addBanScore function already examines
+		// whether or not banning is enabled, it is checked here as well
+		// to ensure the violation is logged and the peer is
+		// disconnected regardless.
+		if sp.ProtocolVersion() >= wire.NodeBloomVersion &&
+			!cfg.DisableBanning {
+
+			// Disonnect the peer regardless of whether it was
+			// banned.
+			sp.addBanScore(100, 0, cmd)
+			sp.Disconnect()
+			return false
+		}
+
+		// Disconnect the peer regardless of protocol version or banning
+		// state.
+		peerLog.Debugf("%s sent an unsupported %s request -- "+
+			"disconnecting", sp, cmd)
+		sp.Disconnect()
+		return false
+	}
+
+	return true
+}
+
+// OnFilterAdd is invoked when a peer receives a filteradd wire message and is
+// used by remote peers to add data to an already loaded bloom filter.  The peer
+// will be disconnected if a filter is not loaded when this message is received
+// or the server is not configured to allow bloom filters.
+func (sp *serverPeer) OnFilterAdd(p *peer.Peer, msg *wire.MsgFilterAdd) {
+	// Disconnect and/or ban depending on the node bloom services flag and
+	// negotiated protocol version.
+	if !sp.enforceNodeBloomFlag(msg.Command()) {
+		return
+	}
+
+	if !sp.filter.IsLoaded() {
+		peerLog.Debugf("%s sent a filteradd request with no filter "+
+			"loaded -- disconnecting", sp)
+		sp.Disconnect()
+		return
+	}
+
+	sp.filter.Add(msg.Data)
+}
+
+// OnFilterClear is invoked when a peer receives a filterclear wire message and
+// is used by remote peers to clear an already loaded bloom filter.  The peer
+// will be disconnected if a filter is not loaded when this message is received
+// or the server is not configured to allow bloom filters.
+func (sp *serverPeer) OnFilterClear(p *peer.Peer, msg *wire.MsgFilterClear) {
+	// Disconnect and/or ban depending on the node bloom services flag and
+	// negotiated protocol version.
+	if !sp.enforceNodeBloomFlag(msg.Command()) {
+		return
+	}
+
+	if !sp.filter.IsLoaded() {
+		peerLog.Debugf("%s sent a filterclear request with no "+
+			"filter loaded -- disconnecting", sp)
+		sp.Disconnect()
+		return
+	}
+
+	sp.filter.Unload()
+}
+
+// OnFilterLoad is invoked when a peer receives a filterload wire message and it
+// is used to load a bloom filter that should be used for delivering merkle
+// blocks and associated transactions that match the filter.  The peer will be
+// disconnected if the server is not configured to allow bloom filters.
+func (sp *serverPeer) OnFilterLoad(p *peer.Peer, msg *wire.MsgFilterLoad) {
+	// Disconnect and/or ban depending on the node bloom services flag and
+	// negotiated protocol version.
+	if !sp.enforceNodeBloomFlag(msg.Command()) {
+		return
+	}
+
+	sp.setDisableRelayTx(false)
+
+	sp.filter.Reload(msg)
+}
+
 // OnGetAddr is invoked when a peer receives a getaddr wire message and is used
 // to provide the peer with known addresses from the address manager.
 func (sp *serverPeer) OnGetAddr(p *peer.Peer, msg *wire.MsgGetAddr) {
@@ -1242,6 +1616,22 @@ func (s *server) AnnounceNewTransactions(newTxs []*cdrutil.Tx) {
 			s.rpcServer.gbtWorkState.NotifyMempoolTx(
 				s.txMemPool.LastUpdated())
 		}
+
+		// Notify pub/sub subscribers about mempool transactions.
+		if s.pubSub != nil {
+			s.pubSub.NotifyMempoolTx(tx)
+		}
+	}
+}
+
+// notifyWinningTickets notifies registered websocket clients and pub/sub
+// subscribers of the tickets eligible to vote on a block.
+func (s *server) notifyWinningTickets(ntfnData *WinningTicketsNtfnData) {
+	if s.rpcServer != nil {
+		s.rpcServer.ntfnMgr.NotifyWinningTickets(ntfnData)
+	}
+	if s.pubSub != nil {
+		s.pubSub.NotifyWinningTickets(ntfnData)
 	}
 }
 
@@ -1320,6 +1710,73 @@ func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan cha
 	return nil
 }
 
+// pushMerkleBlockMsg sends a merkleblock message for the provided block hash to
+// the connected peer.  Since a merkle block requires the peer to have a filter
+// loaded, this call will simply be ignored if there is no filter loaded.  An
+// error is returned if the block hash is not known.
+func (s *server) pushMerkleBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<- struct{}, waitChan <-chan struct{}) error {
+	// Do not send a response if the peer doesn't have a filter loaded.
+	if !sp.filter.IsLoaded() {
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return nil
+	}
+
+	block, err := sp.server.blockManager.chain.FetchBlockByHash(hash)
+	if err != nil {
+		peerLog.Tracef("Unable to fetch requested block hash %v: %v",
+			hash, err)
+
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return err
+	}
+
+	// Generate a merkle block by filtering the requested block according
+	// to the filter for the peer.
+	merkle, matchedTxHashes := bloom.NewMerkleBlock(block, sp.filter)
+
+	// Once we have fetched data wait for any previous operation to finish.
+	if waitChan != nil {
+		<-waitChan
+	}
+
+	// Send the merkleblock.  Only send the done channel with this message
+	// if no transactions will be sent afterwards.
+	var dc chan<- struct{}
+	if len(matchedTxHashes) == 0 {
+		dc = doneChan
+	}
+	sp.QueueMessage(merkle, dc)
+
+	// Finally, send any matched transactions from both the regular and
+	// stake transaction trees in the order they appear in the block.
+	matched := make(map[chainhash.Hash]struct{}, len(matchedTxHashes))
+	for _, txHash := range matchedTxHashes {
+		matched[*txHash] = struct{}{}
+	}
+	numSent := 0
+	for _, txns := range [][]*cdrutil.Tx{block.Transactions(),
+		block.STransactions()} {
+
+		for _, tx := range txns {
+			if _, ok := matched[*tx.Hash()]; !ok {
+				continue
+			}
+			numSent++
+			var dc chan<- struct{}
+			if numSent == len(matchedTxHashes) {
+				dc = doneChan
+			}
+			sp.QueueMessage(tx.MsgTx(), dc)
+		}
+	}
+
+	return nil
+}
+
 // handleUpdatePeerHeight updates the heights of all peers who were known to
 // announce a block we recently accepted.
 func (s *server) handleUpdatePeerHeights(state *peerState, umsg updatePeerHeightsMsg) {
@@ -1383,8 +1840,12 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 
 	// TODO: Check for max peers from a single IP.
 
-	// Limit max number of total peers.
-	if state.Count() >= cfg.MaxPeers {
+	// Limit max number of total peers.  Inbound peers are allowed to
+	// replace an existing inbound peer which is chosen such that filling
+	// all of the inbound slots does not allow an attacker to eclipse the
+	// node.
+	if state.Count() >= cfg.MaxPeers && (!sp.Inbound() ||
+		!s.evictInboundPeer(state)) {
 		srvrLog.Infof("Max peers reached [%d] - disconnecting peer %s",
 			cfg.MaxPeers, sp)
 		sp.Disconnect()
@@ -1409,6 +1870,45 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 	return true
 }
 
+// evictInboundPeer attempts to disconnect an existing inbound peer in order to
+// make room for a new inbound peer.  Whitelisted peers are never evicted.  It
+// returns whether or not a peer was evicted.  It is invoked from the
+// peerHandler goroutine.
+func (s *server) evictInboundPeer(state *peerState) bool {
+	candidates := make([]connmgr.EvictionCandidate, 0,
+		len(state.inboundPeers))
+	for id, sp := range state.inboundPeers {
+		if sp.isWhitelisted {
+			continue
+		}
+		pingTime := time.Duration(sp.LastPingMicros()) * time.Microsecond
+		candidates = append(candidates, connmgr.EvictionCandidate{
+			ID:            uint64(id),
+			NetGroup:      addrmgr.GroupKey(sp.NA()),
+			PingTime:      pingTime,
+			LastBlockTime: time.Unix(atomic.LoadInt64(&sp.lastBlockTime), 0),
+			LastTxTime:    time.Unix(atomic.LoadInt64(&sp.lastTxTime), 0),
+			ConnectedTime: sp.TimeConnected(),
+		})
+	}
+
+	selected, ok := connmgr.SelectEvictionCandidate(candidates,
+		s.netGroupKey)
+	if !ok {
+		return false
+	}
+
+	// Remove the evicted peer from the inbound peers right away so it no
+	// longer counts toward the maximum number of peers.
+	id := int32(selected.ID)
+	sp := state.inboundPeers[id]
+	delete(state.inboundPeers, id)
+	srvrLog.Debugf("Evicting inbound peer %s to make room for a new "+
+		"inbound peer", sp)
+	sp.Disconnect()
+	return true
+}
+
 // handleDonePeerMsg deals with peers that have signalled they are done.  It is
 // invoked from the peerHandler goroutine.
 func (s *server) handleDonePeerMsg(state *peerState, sp *serverPeer) {
@@ -1494,6 +1994,21 @@ func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
 			if sp.relayTxDisabled() {
 				return
 			}
+
+			// Don't relay the transaction if there is a bloom
+			// filter loaded and the transaction doesn't match it.
+			if sp.filter.IsLoaded() {
+				tx, ok := msg.data.(*cdrutil.Tx)
+				if !ok {
+					peerLog.Warnf("Underlying data for tx" +
+						" inv relay is not a transaction")
+					return
+				}
+
+				if !sp.filter.MatchTxAndUpdate(tx) {
+					return
+				}
+			}
 		}
 
 		// Queue the inventory to be relayed with the next batch.
@@ -1702,12 +2217,19 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 			OnBlock:          sp.OnBlock,
 			OnInv:            sp.OnInv,
 			OnHeaders:        sp.OnHeaders,
+			OnCFHeaders:      sp.OnCFHeaders,
+			OnCFilter:        sp.OnCFilter,
 			OnGetData:        sp.OnGetData,
 			OnGetBlocks:      sp.OnGetBlocks,
 			OnGetHeaders:     sp.OnGetHeaders,
 			OnGetCFilter:     sp.OnGetCFilter,
 			OnGetCFHeaders:   sp.OnGetCFHeaders,
 			OnGetCFTypes:     sp.OnGetCFTypes,
+			OnGetCFilters:    sp.OnGetCFilters,
+			OnGetCFCheckpt:   sp.OnGetCFCheckpt,
+			OnFilterAdd:      sp.OnFilterAdd,
+			OnFilterClear:    sp.OnFilterClear,
+			OnFilterLoad:     sp.OnFilterLoad,
 			OnGetAddr:        sp.OnGetAddr,
 			OnAddr:           sp.OnAddr,
 			OnRead:           sp.OnRead,
@@ -1757,6 +2279,85 @@ func (s *server) outboundPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
 	s.addrManager.Attempt(sp.NA())
 }
 
+// feelerPeerConnected is invoked by the connection manager when a new feeler
+// connection is established.  It initializes a new outbound server peer
+// instance flagged as a feeler which is disconnected once the version
+// handshake has been performed or the handshake times out.  Feeler peers are
+// never added to the server.
+func (s *server) feelerPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
+	sp := newServerPeer(s, false)
+	sp.isFeeler = true
+	p, err := peer.NewOutboundPeer(newPeerConfig(sp), c.Addr.String())
+	if err != nil {
+		srvrLog.Debugf("Cannot create feeler peer %s: %v", c.Addr, err)
+		conn.Close()
+		return
+	}
+	sp.Peer = p
+	sp.AssociateConnection(conn)
+	s.addrManager.Attempt(sp.NA())
+	s.wg.Add(1)
+	go s.feelerDoneHandler(sp)
+}
+
+// feelerDoneHandler disconnects the passed feeler peer once the handshake
+// times out or the server is shutting down, whichever comes first, and waits
+// for the peer to disconnect.  Feeler peers that disconnect sooner, such as
+// after a successful handshake, cause it to return immediately.
+//
+// It must be run as a goroutine.
+func (s *server) feelerDoneHandler(sp *serverPeer) {
+	go func() {
+		sp.WaitForDisconnect()
+		close(sp.quit)
+	}()
+
+	timeout := time.NewTimer(feelerTimeout)
+	select {
+	case <-sp.quit:
+	case <-timeout.C:
+	case <-s.quit:
+	}
+	timeout.Stop()
+	sp.Disconnect()
+	<-sp.quit
+	s.wg.Done()
+}
+
+// saveAnchors persists the addresses of the longest running automatic outbound
+// peers so they can be reconnected to first after a restart.  It is invoked
+// from the peerHandler goroutine.
+func (s *server) saveAnchors(state *peerState) {
+	// Anchors are only used when automatically connecting to peers.
+	if cfg.SimNet || len(cfg.ConnectPeers) > 0 {
+		return
+	}
+
+	anchors := make([]*serverPeer, 0, len(state.outboundPeers))
+	for _, sp := range state.outboundPeers {
+		if sp.Connected() && sp.VerAckReceived() {
+			anchors = append(anchors, sp)
+		}
+	}
+	sort.Slice(anchors, func(i, j int) bool {
+		return anchors[i].TimeConnected().Before(anchors[j].TimeConnected())
+	})
+	addrs := make([]string, 0, connmgr.MaxAnchors)
+	for i := 0; i < len(anchors) && i < connmgr.MaxAnchors; i++ {
+		addrs = append(addrs, anchors[i].Addr())
+	}
+	if len(addrs) == 0 {
+		return
+	}
+
+	anchorsFile := filepath.Join(cfg.DataDir, connmgr.AnchorsFilename)
+	if err := connmgr.SaveAnchors(anchorsFile, addrs); err != nil {
+		srvrLog.Warnf("Unable to save anchor peers: %v", err)
+		return
+	}
+	srvrLog.Debugf("Saved %d anchor peers", len(addrs))
+}
+
 // peerDoneHandler handles peer disconnects by notifiying the server that it's
 // done.
 func (s *server) peerDoneHandler(sp *serverPeer) {
@@ -1837,7 +2438,9 @@ out:
 			s.handleQuery(state, qmsg)
 
 		case <-s.quit:
-			// Disconnect all peers on server shutdown.
+			// Persist the anchor peers and disconnect all peers on
+			// server shutdown.
+			s.saveAnchors(state)
 			state.forAllPeers(func(sp *serverPeer) {
 				srvrLog.Tracef("Shutdown peer %s", sp)
 				sp.Disconnect()
@@ -2111,6 +2714,14 @@ func (s *server) Start() {
 		s.rpcServer.Start()
 	}
 
+	if s.restServer != nil {
+		s.restServer.Start()
+	}
+
+	if s.pubSub != nil {
+		s.pubSub.Start()
+	}
+
 	// Start the CPU miner if generation is enabled.
 	if cfg.Generate {
 		s.cpuMiner.Start()
@@ -2138,6 +2749,16 @@ func (s *server) Stop() error {
 		s.rpcServer.Stop()
 	}
 
+	// Shutdown the REST server if it's enabled.
+	if s.restServer != nil {
+		s.restServer.Stop()
+	}
+
+	// Shutdown the pub/sub publishers if they're enabled.
+	if s.pubSub != nil {
+		s.pubSub.Stop()
+	}
+
 	// Signal the remaining goroutines to quit.
 	close(s.quit)
 	return nil
@@ -2319,9 +2940,15 @@ func standardScriptVerifyFlags(chain *blockchain.BlockChain) (txscript.ScriptFla
 // connections from peers.
 func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Params, interrupt <-chan struct{}) (*server, error) {
 	services := defaultServices
+	if cfg.PeerBloomFilters {
+		services |= wire.SFNodeBloom
+	}
 	if cfg.NoCFilters {
 		services &^= wire.SFNodeCF
 	}
+	if cfg.HeadersOnly {
+		services &^= wire.SFNodeNetwork
+	}
 
 	amgr := addrmgr.New(cfg.DataDir, cdrdLookup)
 
@@ -2471,6 +3098,13 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		sigCache:             txscript.NewSigCache(cfg.SigCacheMaxSize),
 	}
 
+	// Generate the random key used to order inbound peers by network group
+	// when choosing a peer to evict.
+	err := binary.Read(rand.Reader, binary.LittleEndian, &s.netGroupKey)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create the transaction and address indexes if needed.
 	//
 	// CAUTION: the txindex needs to be first in the indexes array because
@@ -2506,6 +3140,13 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		indxLog.Info("CF index is enabled")
 		s.cfIndex = indexers.NewCfIndex(db, chainParams)
 		indexes = append(indexes, s.cfIndex)
+		s.cfilterCache = newCFilterCache(cfilterCacheLimit)
+		s.cfCheckptCache = newCFCheckptCache()
+	}
+	if cfg.BlockStatsIndex {
+		indxLog.Info("Block stats index is enabled")
+		s.blockStatsIndex = indexers.NewBlockStatsIndex(db, chainParams)
+		indexes = append(indexes, s.blockStatsIndex)
 	}
 
 	// Create an index manager if any of the optional indexes are enabled.
@@ -2611,19 +3252,52 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		}
 	}
 
+	// Load the anchor peers persisted during the previous run and setup a
+	// function to return addresses to make feeler connections to when new
+	// addresses are automatically connected to.
+	var anchors []net.Addr
+	var feelerAddressFunc func() (net.Addr, error)
+	if newAddressFunc != nil {
+		anchorsFile := filepath.Join(cfg.DataDir, connmgr.AnchorsFilename)
+		anchorAddrs, err := connmgr.LoadAnchors(anchorsFile)
+		if err != nil {
+			srvrLog.Warnf("Unable to load anchor peers: %v", err)
+		}
+		for _, addr := range anchorAddrs {
+			netAddr, err := addrStringToNetAddr(addr)
+			if err != nil {
+				srvrLog.Debugf("Ignoring anchor peer %s: %v", addr, err)
+				continue
+			}
+			anchors = append(anchors, netAddr)
+		}
+
+		feelerAddressFunc = func() (net.Addr, error) {
+			addr := s.addrManager.GetFeelerAddress()
+			if addr == nil {
+				return nil, errors.New("no new addresses")
+			}
+			addrString := addrmgr.NetAddressKey(addr.NetAddress())
+			return addrStringToNetAddr(addrString)
+		}
+	}
+
 	// Create a connection manager.
 	targetOutbound := defaultTargetOutbound
 	if cfg.MaxPeers < targetOutbound {
 		targetOutbound = cfg.MaxPeers
 	}
 	cmgr, err := connmgr.New(&connmgr.Config{
-		Listeners:      listeners,
-		OnAccept:       s.inboundPeerConnected,
-		RetryDuration:  connectionRetryInterval,
-		TargetOutbound: uint32(targetOutbound),
-		Dial:           cdrdDial,
-		OnConnection:   s.outboundPeerConnected,
-		GetNewAddress:  newAddressFunc,
+		Listeners:          listeners,
+		OnAccept:           s.inboundPeerConnected,
+		RetryDuration:      connectionRetryInterval,
+		TargetOutbound:     uint32(targetOutbound),
+		Dial:               cdrdDial,
+		OnConnection:       s.outboundPeerConnected,
+		GetNewAddress:      newAddressFunc,
+		Anchors:            anchors,
+		GetFeelerAddress:   feelerAddressFunc,
+		OnFeelerConnection: s.feelerPeerConnected,
 	})
 	if err != nil {
 		return nil, err
@@ -2660,6 +3334,18 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		}()
 	}
 
+	if len(cfg.RESTListeners) > 0 {
+		s.restServer, err = newRESTServer(cfg.RESTListeners, &s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.pubSub, err = newPubSubNotifier()
+	if err != nil {
+		return nil, err
+	}
+
 	return &s, nil
 }
 
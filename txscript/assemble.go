@@ -0,0 +1,255 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AssembleError identifies a failure to assemble a script from its textual
+// form along with the position of the offending token.  The caller can use a
+// type assertion to detect this error type.
+type AssembleError struct {
+	// Offset is the byte offset of the offending token in the assembled
+	// text.  It is the length of the text when the text ended before an
+	// expected token.
+	Offset int
+
+	// Token is the offending token.  It is empty when the text ended before
+	// an expected token.
+	Token string
+
+	// Description is a human-readable description of the failure.
+	Description string
+}
+
+// Error implements the error interface.
+func (e *AssembleError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("%s at offset %d", e.Description, e.Offset)
+	}
+	return fmt.Sprintf("%s at offset %d (token %q)", e.Description, e.Offset,
+		e.Token)
+}
+
+// assembleToken houses a single whitespace-separated token of the text being
+// assembled along with its byte offset in the text.
+type assembleToken struct {
+	text   string
+	offset int
+}
+
+// splitAssembleTokens splits the passed text into whitespace-separated tokens
+// while tracking their offsets.
+func splitAssembleTokens(text string) []assembleToken {
+	var tokens []assembleToken
+	start := -1
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case ' ', '\t', '\n', '\r':
+			if start != -1 {
+				tokens = append(tokens, assembleToken{text[start:i], start})
+				start = -1
+			}
+		default:
+			if start == -1 {
+				start = i
+			}
+		}
+	}
+	if start != -1 {
+		tokens = append(tokens, assembleToken{text[start:], start})
+	}
+	return tokens
+}
+
+// assembleSmallInts maps the one-line disassembly of the opcodes that
+// represent small integers back to the opcodes.  It is the inverse of
+// opcodeOnelineRepls.
+var assembleSmallInts = func() map[string]byte {
+	smallInts := make(map[string]byte, len(opcodeOnelineRepls))
+	for i := range opcodeArray {
+		op := &opcodeArray[i]
+		if repl, ok := opcodeOnelineRepls[op.name]; ok {
+			smallInts[repl] = op.value
+		}
+	}
+	return smallInts
+}()
+
+// appendPushData appends the passed data to the script using the smallest
+// data push opcode able to represent it.  Unlike the script builder, data
+// which could be represented by a small integer opcode is still pushed as data
+// so the result disassembles to the same text.
+func appendPushData(script, data []byte) []byte {
+	dataLen := len(data)
+	switch {
+	case dataLen <= OP_DATA_75:
+		script = append(script, byte(OP_DATA_1-1+dataLen))
+	case dataLen <= 0xff:
+		script = append(script, OP_PUSHDATA1, byte(dataLen))
+	case dataLen <= 0xffff:
+		var buf [2]byte
+		binary.LittleEndian.PutUint16(buf[:], uint16(dataLen))
+		script = append(script, OP_PUSHDATA2)
+		script = append(script, buf[:]...)
+	default:
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], uint32(dataLen))
+		script = append(script, OP_PUSHDATA4)
+		script = append(script, buf[:]...)
+	}
+	return append(script, data...)
+}
+
+// Assemble converts the textual form of a script back to the raw script.  It
+// is the inverse of DisasmString and also accepts the full form produced by
+// DisasmPC and DisasmScript.
+//
+// The text consists of whitespace-separated tokens which are one of:
+//
+//   - An opcode name such as OP_DUP, OP_CHECKSIG, or OP_SSTX
+//   - A small integer from -1 through 16, which is assembled to the opcode
+//     that represents it
+//   - Hex-encoded data without a prefix, which is pushed with the smallest
+//     data push opcode able to represent it
+//   - OP_DATA_N followed by exactly N bytes of 0x-prefixed hex-encoded data
+//   - OP_PUSHDATA1, OP_PUSHDATA2, or OP_PUSHDATA4 followed by the 0x-prefixed
+//     hex-encoded data length and the 0x-prefixed hex-encoded data
+//
+// Small integers take precedence over hex-encoded data, so a single byte of
+// data from 0x10 through 0x16 in the one-line form is assembled to the opcode
+// for the integer with the same digits.  This matches the disassembly of both.
+//
+// Note that the one-line form can't represent an empty data push other than
+// OP_0, and that scripts which do not parse or exceed the limits imposed by
+// the script engine are not rejected so they can be constructed for testing
+// purposes.
+//
+// Any failure is returned as an *AssembleError which identifies the position
+// of the offending token.
+func Assemble(text string) ([]byte, error) {
+	tokens := splitAssembleTokens(text)
+	script := make([]byte, 0, len(text)/2)
+	for i := 0; i < len(tokens); i++ {
+		tok := &tokens[i]
+
+		// Small integers.
+		if opcodeVal, ok := assembleSmallInts[tok.text]; ok {
+			script = append(script, opcodeVal)
+			continue
+		}
+
+		// Opcodes by name.
+		if opcodeVal, ok := OpcodeByName[tok.text]; ok {
+			op := &opcodeArray[opcodeVal]
+			switch {
+			// No additional data.
+			case op.length == 1:
+				script = append(script, opcodeVal)
+
+			// Data pushes of specific lengths -- OP_DATA_[1-75].
+			case op.length > 1:
+				if i+1 >= len(tokens) {
+					return nil, &AssembleError{len(text), "",
+						fmt.Sprintf("missing data for %s", op.name)}
+				}
+				i++
+				data, err := assembleHexData(&tokens[i], op.length-1)
+				if err != nil {
+					return nil, err
+				}
+				script = append(script, opcodeVal)
+				script = append(script, data...)
+
+			// Data pushes with explicit lengths -- OP_PUSHDATA{1,2,4}.
+			default:
+				if i+2 >= len(tokens) {
+					return nil, &AssembleError{len(text), "",
+						fmt.Sprintf("missing data length or data "+
+							"for %s", op.name)}
+				}
+				i++
+				lenTok := &tokens[i]
+				dataLen, err := assembleHexUint(lenTok, -op.length)
+				if err != nil {
+					return nil, err
+				}
+				i++
+				data, err := assembleHexData(&tokens[i], int(dataLen))
+				if err != nil {
+					return nil, err
+				}
+
+				script = append(script, opcodeVal)
+				var buf [4]byte
+				binary.LittleEndian.PutUint32(buf[:], uint32(dataLen))
+				script = append(script, buf[:-op.length]...)
+				script = append(script, data...)
+			}
+			continue
+		}
+
+		// Data pushes.
+		if strings.HasPrefix(tok.text, "0x") {
+			return nil, &AssembleError{tok.offset, tok.text,
+				"data must follow an OP_DATA_N or OP_PUSHDATA opcode " +
+					"when prefixed with 0x"}
+		}
+		data, err := hex.DecodeString(tok.text)
+		if err != nil {
+			return nil, &AssembleError{tok.offset, tok.text,
+				"unknown opcode or invalid hex data"}
+		}
+		script = appendPushData(script, data)
+	}
+
+	return script, nil
+}
+
+// assembleHexData decodes the 0x-prefixed hex-encoded data of the passed token
+// and ensures it is the expected length.  As a special case, an expected length
+// of zero allows a single zero byte to match the full disassembly of an empty
+// data push.
+func assembleHexData(tok *assembleToken, expectedLen int) ([]byte, error) {
+	if !strings.HasPrefix(tok.text, "0x") {
+		return nil, &AssembleError{tok.offset, tok.text,
+			"data must be prefixed with 0x"}
+	}
+	data, err := hex.DecodeString(tok.text[2:])
+	if err != nil {
+		return nil, &AssembleError{tok.offset, tok.text,
+			"invalid hex data"}
+	}
+	if expectedLen == 0 && len(data) == 1 && data[0] == 0x00 {
+		return nil, nil
+	}
+	if len(data) != expectedLen {
+		return nil, &AssembleError{tok.offset, tok.text,
+			fmt.Sprintf("data is %d bytes instead of the expected %d",
+				len(data), expectedLen)}
+	}
+	return data, nil
+}
+
+// assembleHexUint decodes the 0x-prefixed hex-encoded unsigned integer of the
+// passed token and ensures it fits in the provided number of bytes.
+func assembleHexUint(tok *assembleToken, numBytes int) (uint32, error) {
+	if !strings.HasPrefix(tok.text, "0x") {
+		return 0, &AssembleError{tok.offset, tok.text,
+			"data length must be prefixed with 0x"}
+	}
+	val, err := strconv.ParseUint(tok.text[2:], 16, numBytes*8)
+	if err != nil {
+		return 0, &AssembleError{tok.offset, tok.text,
+			fmt.Sprintf("data length is not a %d-byte hex integer",
+				numBytes)}
+	}
+	return uint32(val), nil
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/commanderu/cdrd/wire"
+)
+
+// TestAssemble ensures assembling various scripts from their textual form
+// produces the expected raw scripts and errors.
+func TestAssemble(t *testing.T) {
+	t.Parallel()
+
+	data76 := strings.Repeat("01", 76)
+	tests := []struct {
+		name      string // test description
+		text      string // text to assemble
+		expected  string // expected raw script in short form
+		errOffset int    // expected error offset, -1 for no error
+	}{{
+		name:      "empty",
+		text:      "",
+		expected:  "",
+		errOffset: -1,
+	}, {
+		name:      "pay-to-pubkey-hash one-line",
+		text:      "OP_DUP OP_HASH160 " + strings.Repeat("02", 20) + " OP_EQUALVERIFY OP_CHECKSIG",
+		expected:  "DUP HASH160 DATA_20 0x" + strings.Repeat("02", 20) + " EQUALVERIFY CHECKSIG",
+		errOffset: -1,
+	}, {
+		name:      "small integers",
+		text:      "-1 0 1 16",
+		expected:  "1NEGATE 0 1 16",
+		errOffset: -1,
+	}, {
+		name:      "opcode aliases",
+		text:      "OP_FALSE OP_TRUE OP_NOP2 OP_NOP3",
+		expected:  "0 1 CHECKLOCKTIMEVERIFY CHECKSEQUENCEVERIFY",
+		errOffset: -1,
+	}, {
+		name:      "one byte data is not a small integer",
+		text:      "01 05 81 17",
+		expected:  "DATA_1 0x01 DATA_1 0x05 DATA_1 0x81 DATA_1 0x17",
+		errOffset: -1,
+	}, {
+		name:      "one-line data uses smallest push",
+		text:      data76,
+		expected:  "PUSHDATA1 0x4c 0x" + data76,
+		errOffset: -1,
+	}, {
+		name:      "stake opcodes",
+		text:      "OP_SSTX OP_SSGEN OP_SSRTX OP_SSTXCHANGE OP_DUP",
+		expected:  "SSTX SSGEN SSRTX SSTXCHANGE DUP",
+		errOffset: -1,
+	}, {
+		name:      "full form data pushes",
+		text:      "OP_DATA_1 0x01 OP_PUSHDATA1 0x01 0x02 OP_PUSHDATA2 0x0001 0x03 OP_PUSHDATA4 0x00000001 0x04",
+		expected:  "DATA_1 0x01 PUSHDATA1 0x01 0x02 PUSHDATA2 0x0100 0x03 PUSHDATA4 0x01000000 0x04",
+		errOffset: -1,
+	}, {
+		name:      "full form empty push",
+		text:      "OP_PUSHDATA1 0x00 0x00 OP_PUSHDATA2 0x0000 0x",
+		expected:  "PUSHDATA1 0x00 PUSHDATA2 0x0000",
+		errOffset: -1,
+	}, {
+		name:      "extra whitespace",
+		text:      " \tOP_DUP\n\r\nOP_DROP  ",
+		expected:  "DUP DROP",
+		errOffset: -1,
+	}, {
+		name:      "unknown opcode",
+		text:      "OP_DUP OP_FOO",
+		errOffset: 7,
+	}, {
+		name:      "short form opcode",
+		text:      "DUP",
+		errOffset: 0,
+	}, {
+		name:      "odd length hex",
+		text:      "OP_1 abc",
+		errOffset: 5,
+	}, {
+		name:      "bare prefixed hex",
+		text:      "OP_1 0x01",
+		errOffset: 5,
+	}, {
+		name:      "missing OP_DATA_N data",
+		text:      "OP_1 OP_DATA_2",
+		errOffset: 14,
+	}, {
+		name:      "OP_DATA_N data without prefix",
+		text:      "OP_DATA_1 01",
+		errOffset: 10,
+	}, {
+		name:      "OP_DATA_N short data",
+		text:      "OP_DATA_2 0x01",
+		errOffset: 10,
+	}, {
+		name:      "OP_PUSHDATA1 missing data",
+		text:      "OP_PUSHDATA1 0x01",
+		errOffset: 17,
+	}, {
+		name:      "OP_PUSHDATA1 length too big",
+		text:      "OP_PUSHDATA1 0x0100 0x01",
+		errOffset: 13,
+	}, {
+		name:      "OP_PUSHDATA2 length mismatch",
+		text:      "OP_PUSHDATA2 0x0002 0x01",
+		errOffset: 20,
+	}}
+
+	for _, test := range tests {
+		script, err := Assemble(test.text)
+		if test.errOffset != -1 {
+			aErr, ok := err.(*AssembleError)
+			if !ok {
+				t.Errorf("%q: unexpected error type -- got %T (%v), "+
+					"want *AssembleError", test.name, err, err)
+				continue
+			}
+			if aErr.Offset != test.errOffset {
+				t.Errorf("%q: unexpected error offset -- got %d, want "+
+					"%d (%v)", test.name, aErr.Offset, test.errOffset,
+					aErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.name, err)
+			continue
+		}
+
+		expected := mustParseShortForm(test.expected)
+		if !bytes.Equal(script, expected) {
+			t.Errorf("%q: unexpected script -- got %x, want %x",
+				test.name, script, expected)
+		}
+	}
+}
+
+// loadAssembleTestScripts returns all of the scripts from the reference test
+// data that parse and any error encountered.
+func loadAssembleTestScripts() ([][]byte, error) {
+	var scripts [][]byte
+
+	// Both the signature and public key scripts of the script tests.
+	for _, fileName := range []string{"script_valid.json", "script_invalid.json"} {
+		file, err := ioutil.ReadFile("data/" + fileName)
+		if err != nil {
+			return nil, err
+		}
+		var tests [][]string
+		if err := json.Unmarshal(file, &tests); err != nil {
+			return nil, err
+		}
+		for _, test := range tests {
+			if len(test) < 2 {
+				continue
+			}
+			for _, shortForm := range test[:2] {
+				script, err := parseShortForm(shortForm)
+				if err != nil {
+					continue
+				}
+				scripts = append(scripts, script)
+			}
+		}
+	}
+
+	// The previous output scripts and signature scripts of the transaction
+	// tests.
+	for _, fileName := range []string{"tx_valid.json", "tx_invalid.json"} {
+		file, err := ioutil.ReadFile("data/" + fileName)
+		if err != nil {
+			return nil, err
+		}
+		var tests [][]interface{}
+		if err := json.Unmarshal(file, &tests); err != nil {
+			return nil, err
+		}
+		for _, test := range tests {
+			inputs, ok := test[0].([]interface{})
+			if !ok || len(test) != 3 {
+				continue
+			}
+			for _, input := range inputs {
+				input, ok := input.([]interface{})
+				if !ok || len(input) != 3 {
+					continue
+				}
+				shortForm, ok := input[2].(string)
+				if !ok {
+					continue
+				}
+				script, err := parseShortForm(shortForm)
+				if err != nil {
+					continue
+				}
+				scripts = append(scripts, script)
+			}
+
+			serializedHex, ok := test[1].(string)
+			if !ok {
+				continue
+			}
+			serializedTx, err := hex.DecodeString(serializedHex)
+			if err != nil {
+				continue
+			}
+			var tx wire.MsgTx
+			if err := tx.FromBytes(serializedTx); err != nil {
+				continue
+			}
+			for _, txIn := range tx.TxIn {
+				scripts = append(scripts, txIn.SignatureScript)
+			}
+		}
+	}
+
+	// The scripts of the signature hash tests.
+	file, err := ioutil.ReadFile("data/sighash.json")
+	if err != nil {
+		return nil, err
+	}
+	var tests [][]interface{}
+	if err := json.Unmarshal(file, &tests); err != nil {
+		return nil, err
+	}
+	for _, test := range tests {
+		if len(test) < 2 {
+			continue
+		}
+		scriptHex, ok := test[1].(string)
+		if !ok {
+			continue
+		}
+		script, err := hex.DecodeString(scriptHex)
+		if err != nil {
+			continue
+		}
+		scripts = append(scripts, script)
+	}
+
+	return scripts, nil
+}
+
+// TestAssembleRoundTrip ensures that assembling the disassembly of all of the
+// scripts in the reference test data round trips.  The one-line form produced
+// by DisasmString must disassemble to the same text once assembled, since it
+// does not preserve the exact data push opcodes, while the full form must
+// assemble to the exact original script.
+func TestAssembleRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	scripts, err := loadAssembleTestScripts()
+	if err != nil {
+		t.Fatalf("unable to load test scripts: %v", err)
+	}
+
+	var numTested int
+	for _, script := range scripts {
+		// Skip scripts that do not parse since they can't be
+		// disassembled.
+		text, err := DisasmString(script)
+		if err != nil {
+			continue
+		}
+
+		// Build the full form disassembly and count the opcodes in order
+		// to detect empty data pushes which the one-line form can't
+		// represent.
+		var fullForm []string
+		tokenizer := MakeScriptTokenizer(0, script)
+		for tokenizer.Next() {
+			fullForm = append(fullForm, disasmOpcode(tokenizer.op,
+				tokenizer.Data(), false))
+		}
+
+		if len(strings.Fields(text)) == len(fullForm) {
+			assembled, err := Assemble(text)
+			if err != nil {
+				t.Errorf("Assemble(%q): unexpected error: %v", text, err)
+				continue
+			}
+			gotText, err := DisasmString(assembled)
+			if err != nil || gotText != text {
+				t.Errorf("Assemble(%q): one-line form does not round "+
+					"trip -- got %q (err %v)", text, gotText, err)
+				continue
+			}
+		}
+
+		fullText := strings.Join(fullForm, " ")
+		assembled, err := Assemble(fullText)
+		if err != nil {
+			t.Errorf("Assemble(%q): unexpected error: %v", fullText, err)
+			continue
+		}
+		if !bytes.Equal(assembled, script) {
+			t.Errorf("Assemble(%q): full form does not round trip -- "+
+				"got %x, want %x", fullText, assembled, script)
+			continue
+		}
+		numTested++
+	}
+	if numTested == 0 {
+		t.Fatal("no scripts were tested")
+	}
+}
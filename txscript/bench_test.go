@@ -10,6 +10,8 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/commanderu/cdrd/chaincfg"
+	"github.com/commanderu/cdrd/cdrutil"
 	"github.com/commanderu/cdrd/wire"
 )
 
@@ -36,6 +38,213 @@ func BenchmarkCalcSigHash(b *testing.B) {
 	}
 }
 
+// makeBenchmarkScript returns a large script consisting of many data pushes
+// of varying sizes followed by a signature operation which is useful for
+// benchmarking script parsing.
+func makeBenchmarkScript() []byte {
+	builder := NewScriptBuilder()
+	for i := 0; i < 100; i++ {
+		builder.AddData(bytes.Repeat([]byte{0x01}, i%80+1))
+		builder.AddOp(OP_DROP)
+	}
+	builder.AddOp(OP_CHECKSIG)
+	script, err := builder.Script()
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
+
+// BenchmarkParseScript benchmarks how long it takes to parse a large script
+// into a slice of parsed opcodes.  It is intended to be compared against
+// BenchmarkScriptTokenizer.
+func BenchmarkParseScript(b *testing.B) {
+	script := makeBenchmarkScript()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := parseScript(script)
+		if err != nil {
+			b.Fatalf("failed to parse script: %v", err)
+		}
+	}
+}
+
+// BenchmarkScriptTokenizer benchmarks how long it takes to tokenize a large
+// script.
+func BenchmarkScriptTokenizer(b *testing.B) {
+	script := makeBenchmarkScript()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tokenizer := MakeScriptTokenizer(0, script)
+		for tokenizer.Next() {
+			_ = tokenizer.Opcode()
+			_ = tokenizer.Data()
+		}
+		if err := tokenizer.Err(); err != nil {
+			b.Fatalf("failed to tokenize script: %v", err)
+		}
+	}
+}
+
+// BenchmarkDisasmString benchmarks how long it takes to disassemble a large
+// script.
+func BenchmarkDisasmString(b *testing.B) {
+	script := makeBenchmarkScript()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := DisasmString(script)
+		if err != nil {
+			b.Fatalf("failed to disassemble script: %v", err)
+		}
+	}
+}
+
+// BenchmarkIsPayToScriptHash benchmarks how long it takes to determine if a
+// large script and a standard pay-to-script-hash script are pay-to-script-hash
+// scripts.
+func BenchmarkIsPayToScriptHash(b *testing.B) {
+	script := makeBenchmarkScript()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = IsPayToScriptHash(script)
+		_ = IsPayToScriptHash(prevOutScript)
+	}
+}
+
+// BenchmarkIsPushOnlyScript benchmarks how long it takes to determine if a
+// large script is push only.
+func BenchmarkIsPushOnlyScript(b *testing.B) {
+	script := makeBenchmarkScript()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = IsPushOnlyScript(script)
+	}
+}
+
+// BenchmarkGetSigOpCount benchmarks how long it takes to count the signature
+// operations of a large script.
+func BenchmarkGetSigOpCount(b *testing.B) {
+	script := makeBenchmarkScript()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = GetSigOpCount(script)
+	}
+}
+
+// BenchmarkGetPreciseSigOpCount benchmarks how long it takes to count the
+// signature operations of a multisig script redeemed via pay-to-script-hash.
+func BenchmarkGetPreciseSigOpCount(b *testing.B) {
+	builder := NewScriptBuilder().AddOp(OP_1)
+	for i := 0; i < 15; i++ {
+		builder.AddData(bytes.Repeat([]byte{0x02}, 33))
+	}
+	redeemScript, err := builder.AddOp(OP_15).AddOp(OP_CHECKMULTISIG).Script()
+	if err != nil {
+		b.Fatalf("failed to create redeem script: %v", err)
+	}
+	sigScript, err := NewScriptBuilder().AddData(redeemScript).Script()
+	if err != nil {
+		b.Fatalf("failed to create signature script: %v", err)
+	}
+	pkScript, err := PayToScriptHashScript(cdrutil.Hash160(redeemScript))
+	if err != nil {
+		b.Fatalf("failed to create public key script: %v", err)
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = GetPreciseSigOpCount(sigScript, pkScript, true)
+	}
+}
+
+// BenchmarkGetScriptClass benchmarks how long it takes to determine the class
+// of a large nonstandard script along with several standard scripts.
+func BenchmarkGetScriptClass(b *testing.B) {
+	scripts := [][]byte{
+		makeBenchmarkScript(),
+		prevOutScript,
+		mustParseShortForm("DUP HASH160 DATA_20 0x433ec2ac1ffa1b7b7d0" +
+			"27f564529c57197f9ae88 EQUALVERIFY CHECKSIG"),
+		mustParseShortForm("SSGEN DUP HASH160 DATA_20 0x433ec2ac1ffa1b7" +
+			"b7d027f564529c57197f9ae88 EQUALVERIFY CHECKSIG"),
+		mustParseShortForm("1 DATA_33 0x0232abdc893e7f0631364d7fd01cb33" +
+			"d24da45329a00357b3a7886211ab414d55a 1 CHECKMULTISIG"),
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, script := range scripts {
+			_ = GetScriptClass(DefaultScriptVersion, script)
+		}
+	}
+}
+
+// BenchmarkExtractPkScriptAddrs benchmarks how long it takes to extract the
+// addresses from a pay-to-pubkey-hash script.
+func BenchmarkExtractPkScriptAddrs(b *testing.B) {
+	script := mustParseShortForm("DUP HASH160 DATA_20 0x433ec2ac1ffa1b7b7d0" +
+		"27f564529c57197f9ae88 EQUALVERIFY CHECKSIG")
+	params := &chaincfg.MainNetParams
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _, _, err := ExtractPkScriptAddrs(DefaultScriptVersion, script,
+			params)
+		if err != nil {
+			b.Fatalf("failed to extract addresses: %v", err)
+		}
+	}
+}
+
+// BenchmarkExecute benchmarks how long it takes to create an engine for and
+// execute a script pair that performs many non-signature operations.
+func BenchmarkExecute(b *testing.B) {
+	builder := NewScriptBuilder()
+	for i := 0; i < 100; i++ {
+		builder.AddOp(OP_DUP).AddOp(OP_DROP)
+	}
+	builder.AddOp(OP_TRUE)
+	pkScript, err := builder.Script()
+	if err != nil {
+		b.Fatalf("failed to create public key script: %v", err)
+	}
+	tx := &wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			SignatureScript: []byte{OP_TRUE},
+			Sequence:        wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{PkScript: []byte{OP_TRUE}}},
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		vm, err := NewEngine(pkScript, tx, 0, 0, DefaultScriptVersion, nil)
+		if err != nil {
+			b.Fatalf("failed to create engine: %v", err)
+		}
+		if err := vm.Execute(); err != nil {
+			b.Fatalf("failed to execute scripts: %v", err)
+		}
+	}
+}
+
 func init() {
 	// tx 620f57c92cf05a7f7e7f7d28255d5f7089437bc48e34dcfebf7751d08b7fb8f5
 	txHex, err := ioutil.ReadFile("data/many_inputs_tx.hex")
@@ -26,8 +26,7 @@ const (
 // required by the consensus rules for the coinbase output that is used to
 // ensure the coinbase has a unique hash and returns the data it pushes.
 func ExtractCoinbaseNullData(pkScript []byte) ([]byte, error) {
-	pops, err := parseScript(pkScript)
-	if err != nil {
+	if err := checkScriptParses(pkScript); err != nil {
 		return nil, fmt.Errorf("script parse failure")
 	}
 
@@ -44,14 +43,18 @@ func ExtractCoinbaseNullData(pkScript []byte) ([]byte, error) {
 	// height to be encoded as a 4-byte little-endian uint32 pushed via a normal
 	// data push, as opposed to using the normal number handling semantics of
 	// scripts, so this is specialized to accomodate that.
-	if len(pops) == 1 && pops[0].opcode.value == OP_RETURN {
+	if len(pkScript) == 0 || pkScript[0] != OP_RETURN {
+		return nil, fmt.Errorf("not a properly-formed nulldata script")
+	}
+	if len(pkScript) == 1 {
 		return nil, nil
 	}
-	if len(pops) == 2 && pops[0].opcode.value == OP_RETURN &&
-		pops[1].opcode.value <= OP_PUSHDATA4 && len(pops[1].data) <=
-		maxUniqueCoinbaseNullDataSize {
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, pkScript[1:])
+	if tokenizer.Next() && tokenizer.Done() &&
+		tokenizer.Opcode() <= OP_PUSHDATA4 &&
+		len(tokenizer.Data()) <= maxUniqueCoinbaseNullDataSize {
 
-		return pops[1].data, nil
+		return tokenizer.Data(), nil
 	}
 
 	return nil, fmt.Errorf("not a properly-formed nulldata script")
@@ -95,12 +95,23 @@ var halfOrder = new(big.Int).Rsh(chainec.Secp256k1.GetN(), 1)
 
 // Engine is the virtual machine that executes scripts.
 type Engine struct {
-	scripts         [][]parsedOpcode
+	scripts         [][]byte
 	savedFirstStack [][]byte // stack from first script for bip16 scripts
 	sigCache        *SigCache
 
+	// scriptIdx tracks the index into the scripts array for the current
+	// program counter and opcodeIdx tracks the number of opcodes that have
+	// been executed in the current script.
+	//
+	// tokenizer provides the token stream of the current script being
+	// executed and doubles as state tracking for the program counter within
+	// the script.
+	//
+	// lastCodeSep specifies the byte offset of the most recently executed
+	// OP_CODESEPARATOR within the current script.
 	scriptIdx   int
-	scriptOff   int
+	opcodeIdx   int
+	tokenizer   ScriptTokenizer
 	lastCodeSep int
 	dstack      stack // data stack
 	astack      stack // alt stack
@@ -111,6 +122,8 @@ type Engine struct {
 	flags       ScriptFlags
 	version     uint16
 	bip16       bool // treat execution as pay-to-script-hash
+	tracer      Tracer
+	sigBatch    *SigBatch
 }
 
 // hasFlag returns whether the script engine instance has the passed flag set.
@@ -132,54 +145,65 @@ func (vm *Engine) isBranchExecuting() bool {
 // executeOpcode peforms execution on the passed opcode.  It takes into account
 // whether or not it is hidden by conditionals, but some rules still must be
 // tested in this case.
-func (vm *Engine) executeOpcode(pop *parsedOpcode) error {
+func (vm *Engine) executeOpcode(op *opcode, data []byte) error {
 	// Disabled opcodes are fail on program counter.
-	if pop.isDisabled() {
+	if isOpcodeDisabled(op.value) {
 		return ErrStackOpDisabled
 	}
 
 	// Always-illegal opcodes are fail on program counter.
-	if pop.alwaysIllegal() {
+	if isOpcodeAlwaysIllegal(op.value) {
 		return ErrStackReservedOpcode
 	}
 
 	// Note that this includes OP_RESERVED which counts as a push operation.
-	if pop.opcode.value > OP_16 {
+	if op.value > OP_16 {
 		vm.numOps++
 		if vm.numOps > MaxOpsPerScript {
 			return ErrStackTooManyOperations
 		}
 
-	} else if len(pop.data) > MaxScriptElementSize {
+	} else if len(data) > MaxScriptElementSize {
 		return ErrStackElementTooBig
 	}
 
 	// Nothing left to do when this is not a conditional opcode and it is
 	// not in an executing branch.
-	if !vm.isBranchExecuting() && !pop.isConditional() {
+	if !vm.isBranchExecuting() && !isOpcodeConditional(op.value) {
 		return nil
 	}
 
 	// Ensure all executed data push opcodes use the minimal encoding when
 	// the minimal data verification flag is set.
 	if vm.dstack.verifyMinimalData && vm.isBranchExecuting() &&
-		pop.opcode.value >= 0 && pop.opcode.value <= OP_PUSHDATA4 {
+		op.value >= 0 && op.value <= OP_PUSHDATA4 {
 
-		if err := pop.checkMinimalDataPush(); err != nil {
+		if err := checkMinimalDataPush(op, data); err != nil {
 			return err
 		}
 	}
 
-	return pop.opcode.opfunc(pop, vm)
+	return op.opfunc(op, data, vm)
+}
+
+// checkScriptParses returns an error if the provided script fails to parse.
+func checkScriptParses(script []byte) error {
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	for tokenizer.Next() {
+		// Nothing to do.
+	}
+	return tokenizer.Err()
 }
 
 // disasm is a helper function to produce the output for DisasmPC and
 // DisasmScript.  It produces the opcode prefixed by the program counter at the
 // provided position in the script.  It does no error checking and leaves that
 // to the caller to provide a valid offset.
-func (vm *Engine) disasm(scriptIdx int, scriptOff int) string {
-	return fmt.Sprintf("%02x:%04x: %s", scriptIdx, scriptOff,
-		vm.scripts[scriptIdx][scriptOff].print(false))
+func (vm *Engine) disasm(scriptIdx int, opcodeIdx int, op *opcode,
+	data []byte) string {
+
+	return fmt.Sprintf("%02x:%04x: %s", scriptIdx, opcodeIdx,
+		disasmOpcode(op, data, false))
 }
 
 // validPC returns an error if the current script position is valid for
@@ -187,11 +211,11 @@ func (vm *Engine) disasm(scriptIdx int, scriptOff int) string {
 func (vm *Engine) validPC() error {
 	if vm.scriptIdx >= len(vm.scripts) {
 		return fmt.Errorf("past input scripts %v:%v %v:xxxx",
-			vm.scriptIdx, vm.scriptOff, len(vm.scripts))
+			vm.scriptIdx, vm.opcodeIdx, len(vm.scripts))
 	}
-	if vm.scriptOff >= len(vm.scripts[vm.scriptIdx]) {
+	if vm.tokenizer.Done() {
 		return fmt.Errorf("past input scripts %v:%v %v:%04d",
-			vm.scriptIdx, vm.scriptOff, vm.scriptIdx,
+			vm.scriptIdx, vm.opcodeIdx, vm.scriptIdx,
 			len(vm.scripts[vm.scriptIdx]))
 	}
 	return nil
@@ -204,17 +228,28 @@ func (vm *Engine) curPC() (script int, off int, err error) {
 	if err != nil {
 		return 0, 0, err
 	}
-	return vm.scriptIdx, vm.scriptOff, nil
+	return vm.scriptIdx, vm.opcodeIdx, nil
 }
 
 // DisasmPC returns the string for the disassembly of the opcode that will be
 // next to execute when Step() is called.
 func (vm *Engine) DisasmPC() (string, error) {
-	scriptIdx, scriptOff, err := vm.curPC()
+	scriptIdx, opcodeIdx, err := vm.curPC()
 	if err != nil {
 		return "", err
 	}
-	return vm.disasm(scriptIdx, scriptOff), nil
+
+	// Parse the next opcode from a copy of the current tokenizer so the
+	// program counter is not modified.
+	peekTokenizer := vm.tokenizer
+	if !peekTokenizer.Next() {
+		if err := peekTokenizer.Err(); err != nil {
+			return "", err
+		}
+		return "", ErrStackShortScript
+	}
+	return vm.disasm(scriptIdx, opcodeIdx, peekTokenizer.op,
+		peekTokenizer.Data()), nil
 }
 
 // DisasmScript returns the disassembly string for the script at the requested
@@ -226,10 +261,14 @@ func (vm *Engine) DisasmScript(idx int) (string, error) {
 	}
 
 	var disstr string
-	for i := range vm.scripts[idx] {
-		disstr = disstr + vm.disasm(idx, i) + "\n"
-	}
-	return disstr, nil
+	var opcodeIdx int
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, vm.scripts[idx])
+	for tokenizer.Next() {
+		disstr = disstr + vm.disasm(idx, opcodeIdx, tokenizer.op,
+			tokenizer.Data()) + "\n"
+		opcodeIdx++
+	}
+	return disstr, tokenizer.Err()
 }
 
 // CheckErrorCondition returns nil if the running script has ended and was
@@ -277,25 +316,39 @@ func (vm *Engine) Step() (done bool, err error) {
 	if err != nil {
 		return true, err
 	}
-	opcode := &vm.scripts[vm.scriptIdx][vm.scriptOff]
+
+	// Attempt to parse the next opcode from the current script.
+	if !vm.tokenizer.Next() {
+		// All scripts are checked for parse failures before they are
+		// executed, so this is only reachable if that assumption is
+		// broken.
+		if err := vm.tokenizer.Err(); err != nil {
+			return false, err
+		}
+		return true, ErrStackShortScript
+	}
 
 	// Execute the opcode while taking into account several things such as
 	// disabled opcodes, illegal opcodes, maximum allowed operations per
 	// script, maximum script element sizes, and conditionals.
-	err = vm.executeOpcode(opcode)
+	op, data := vm.tokenizer.op, vm.tokenizer.Data()
+	err = vm.executeOpcode(op, data)
 	if err != nil {
+		vm.traceStep(vm.scriptIdx, vm.opcodeIdx, op, data, err)
 		return true, err
 	}
 
 	// The number of elements in the combination of the data and alt stacks
 	// must not exceed the maximum number of stack elements allowed.
 	if vm.dstack.Depth()+vm.astack.Depth() > maxStackSize {
+		vm.traceStep(vm.scriptIdx, vm.opcodeIdx, op, data, ErrStackOverflow)
 		return false, ErrStackOverflow
 	}
+	vm.traceStep(vm.scriptIdx, vm.opcodeIdx, op, data, nil)
 
 	// Prepare for next instruction.
-	vm.scriptOff++
-	if vm.scriptOff >= len(vm.scripts[vm.scriptIdx]) {
+	vm.opcodeIdx++
+	if vm.tokenizer.Done() {
 		// Illegal to have an `if' that straddles two scripts.
 		if err == nil && len(vm.condStack) != 0 {
 			return false, ErrStackMissingEndif
@@ -305,7 +358,7 @@ func (vm *Engine) Step() (done bool, err error) {
 		_ = vm.astack.DropN(vm.astack.Depth())
 
 		vm.numOps = 0 // number of ops is per script.
-		vm.scriptOff = 0
+		vm.opcodeIdx = 0
 		if vm.scriptIdx == 0 && vm.bip16 {
 			vm.scriptIdx++
 			vm.savedFirstStack = vm.GetStack()
@@ -320,11 +373,10 @@ func (vm *Engine) Step() (done bool, err error) {
 			}
 
 			script := vm.savedFirstStack[len(vm.savedFirstStack)-1]
-			pops, err := parseScript(script)
-			if err != nil {
+			if err := checkScriptParses(script); err != nil {
 				return false, err
 			}
-			vm.scripts = append(vm.scripts, pops)
+			vm.scripts = append(vm.scripts, script)
 
 			// Set stack to be the stack from first script minus the
 			// script itself
@@ -334,13 +386,18 @@ func (vm *Engine) Step() (done bool, err error) {
 		}
 		// there are zero length scripts in the wild
 		if vm.scriptIdx < len(vm.scripts) &&
-			vm.scriptOff >= len(vm.scripts[vm.scriptIdx]) {
+			len(vm.scripts[vm.scriptIdx]) == 0 {
 			vm.scriptIdx++
 		}
 		vm.lastCodeSep = 0
 		if vm.scriptIdx >= len(vm.scripts) {
 			return true, nil
 		}
+
+		// Start tokenizing the new script associated with the program
+		// counter from the beginning.
+		vm.tokenizer = MakeScriptTokenizer(DefaultScriptVersion,
+			vm.scripts[vm.scriptIdx])
 	}
 	return false, nil
 }
@@ -388,7 +445,7 @@ func (vm *Engine) Execute() (err error) {
 }
 
 // subScript returns the script since the last OP_CODESEPARATOR.
-func (vm *Engine) subScript() []parsedOpcode {
+func (vm *Engine) subScript() []byte {
 	return vm.scripts[vm.scriptIdx][vm.lastCodeSep:]
 }
 
@@ -641,22 +698,22 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
 		}
 	}
 
-	// The engine stores the scripts in parsed form using a slice.  This
-	// allows multiple scripts to be executed in sequence.  For example,
-	// with a pay-to-script-hash transaction, there will be ultimately be
-	// a third script to execute.
+	// The engine stores the raw scripts using a slice and tokenizes them as
+	// they are executed.  This allows multiple scripts to be executed in
+	// sequence.  For example, with a pay-to-script-hash transaction, there
+	// will be ultimately be a third script to execute.  All of the scripts
+	// are checked for parse failures up front so that no opcodes are
+	// executed when any of them are malformed.
 	scripts := [][]byte{scriptSig, scriptPubKey}
-	vm.scripts = make([][]parsedOpcode, len(scripts))
-	for i, scr := range scripts {
+	for _, scr := range scripts {
 		if len(scr) > maxScriptSize {
 			return nil, ErrStackLongScript
 		}
-		var err error
-		vm.scripts[i], err = parseScript(scr)
-		if err != nil {
+		if err := checkScriptParses(scr); err != nil {
 			return nil, err
 		}
 	}
+	vm.scripts = scripts
 
 	// Advance the program counter to the public key script if the signature
 	// script is empty since there is nothing to execute for it in that
@@ -667,7 +724,7 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
 
 	if vm.hasFlag(ScriptBip16) && isAnyKindOfScriptHash(vm.scripts[1]) {
 		// Only accept input scripts that push data for P2SH.
-		if !isPushOnly(vm.scripts[0]) {
+		if !isPushOnlyScript(vm.scripts[0]) {
 			return nil, ErrStackP2SHNonPushOnly
 		}
 		vm.bip16 = true
@@ -680,5 +737,10 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
 	vm.tx = *tx
 	vm.txIdx = txIdx
 
+	// Setup the tokenizer used to parse through the script associated with
+	// the program counter one opcode at a time.
+	vm.tokenizer = MakeScriptTokenizer(DefaultScriptVersion,
+		vm.scripts[vm.scriptIdx])
+
 	return &vm, nil
 }
@@ -60,7 +60,14 @@ func TestBadPC(t *testing.T) {
 
 		// set to after all scripts
 		vm.scriptIdx = test.script
-		vm.scriptOff = test.off
+		if vm.scriptIdx < len(vm.scripts) {
+			vm.tokenizer = MakeScriptTokenizer(DefaultScriptVersion,
+				vm.scripts[vm.scriptIdx])
+			for i := 0; i < test.off; i++ {
+				vm.tokenizer.Next()
+			}
+		}
+		vm.opcodeIdx = test.off
 
 		_, err = vm.Step()
 		if err == nil {
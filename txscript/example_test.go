@@ -52,6 +52,27 @@ func ExamplePayToAddrScript() {
 	// Script Disassembly: OP_DUP OP_HASH160 128004ff2fcaf13b2b91eb654b1dc2b674f7ec61 OP_EQUALVERIFY OP_CHECKSIG
 }
 
+// This example demonstrates assembling a script from the human-readable
+// disassembly produced by DisasmString.
+func ExampleAssemble() {
+	// Assemble a standard pay-to-pubkey-hash script from its disassembly.
+	script, err := txscript.Assemble("OP_DUP OP_HASH160 " +
+		"128004ff2fcaf13b2b91eb654b1dc2b674f7ec61 OP_EQUALVERIFY OP_CHECKSIG")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Script Hex: %x\n", script)
+
+	// Errors identify the position of the offending token.
+	_, err = txscript.Assemble("OP_DUP OP_HASH161")
+	fmt.Println(err)
+
+	// Output:
+	// Script Hex: 76a914128004ff2fcaf13b2b91eb654b1dc2b674f7ec6188ac
+	// unknown opcode or invalid hex data at offset 7 (token "OP_HASH161")
+}
+
 // This example demonstrates extracting information from a standard public key
 // script.
 func ExampleExtractPkScriptAddrs() {
@@ -693,8 +693,7 @@ func TestCalcSignatureHashReference(t *testing.T) {
 				err)
 			continue
 		}
-		parsedScript, err := parseScript(subScript)
-		if err != nil {
+		if err := checkScriptParses(subScript); err != nil {
 			t.Errorf("Test #%d: unable to parse script: %v", i, err)
 			continue
 		}
@@ -739,7 +738,7 @@ func TestCalcSignatureHashReference(t *testing.T) {
 		}
 
 		// Calculate the signature hash and verify expected result.
-		hash, err := calcSignatureHash(parsedScript, hashType, &tx,
+		hash, err := calcSignatureHash(subScript, hashType, &tx,
 			int(inputIdxF64), nil)
 		if err != expectedErr {
 			t.Errorf("Test #%d: unexpected error: want %v, got %v", i,
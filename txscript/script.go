@@ -29,40 +29,32 @@ func isSmallInt(op *opcode) bool {
 // IsPayToScriptHash returns true if the script is in the standard
 // pay-to-script-hash (P2SH) format, false otherwise.
 func IsPayToScriptHash(script []byte) bool {
-	pops, err := parseScript(script)
-	if err != nil {
-		return false
-	}
-	return isScriptHash(pops)
+	return isScriptHash(script)
 }
 
-// isPushOnly returns true if the script only pushes data, false otherwise.
-func isPushOnly(pops []parsedOpcode) bool {
-	// NOTE: This function does NOT verify opcodes directly since it is
-	// internal and is only called with parsed opcodes for scripts that did
-	// not have any parse errors.  Thus, consensus is properly maintained.
-
-	for _, pop := range pops {
+// isPushOnlyScript returns whether or not the passed script only pushes data
+// according to the consensus definition of pushing data.
+//
+// False will be returned when the script does not parse.
+func isPushOnlyScript(script []byte) bool {
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	for tokenizer.Next() {
 		// All opcodes up to OP_16 are data push instructions.
 		// NOTE: This does consider OP_RESERVED to be a data push
 		// instruction, but execution of OP_RESERVED will fail anyways
 		// and matches the behavior required by consensus.
-		if pop.opcode.value > OP_16 {
+		if tokenizer.Opcode() > OP_16 {
 			return false
 		}
 	}
-	return true
+	return tokenizer.Err() == nil
 }
 
 // IsPushOnlyScript returns whether or not the passed script only pushes data.
 //
 // False will be returned when the script does not parse.
 func IsPushOnlyScript(script []byte) bool {
-	pops, err := parseScript(script)
-	if err != nil {
-		return false
-	}
-	return isPushOnly(pops)
+	return isPushOnlyScript(script)
 }
 
 // HasP2SHScriptSigStakeOpCodes returns an error is the p2sh script has either
@@ -205,39 +197,53 @@ func unparseScript(pops []parsedOpcode) ([]byte, error) {
 // if the caller wants more information about the failure.
 func DisasmString(buf []byte) (string, error) {
 	var disbuf bytes.Buffer
-	opcodes, err := parseScript(buf)
-	for _, pop := range opcodes {
-		disbuf.WriteString(pop.print(true))
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, buf)
+	for tokenizer.Next() {
+		disbuf.WriteString(disasmOpcode(tokenizer.op, tokenizer.Data(), true))
 		disbuf.WriteByte(' ')
 	}
 	if disbuf.Len() > 0 {
 		disbuf.Truncate(disbuf.Len() - 1)
 	}
-	if err != nil {
+	if err := tokenizer.Err(); err != nil {
 		disbuf.WriteString("[error]")
+		return disbuf.String(), err
 	}
-	return disbuf.String(), err
+	return disbuf.String(), nil
 }
 
-// removeOpcode will remove any opcode matching ``opcode'' from the opcode
-// stream in pkscript
-func removeOpcode(pkscript []parsedOpcode, opcode byte) []parsedOpcode {
-	retScript := make([]parsedOpcode, 0, len(pkscript))
-	for _, pop := range pkscript {
-		if pop.opcode.value != opcode {
-			retScript = append(retScript, pop)
+// removeOpcode will return the script minus any instances of the passed
+// opcode.  The passed script must have already been checked for parse
+// failures.
+//
+// NOTE: The passed script is returned unmodified when it does not contain the
+// opcode in order to avoid allocating.
+func removeOpcode(script []byte, opcode byte) []byte {
+	var result []byte
+	var prevOffset int32
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	for tokenizer.Next() {
+		if tokenizer.Opcode() == opcode {
+			if result == nil {
+				result = make([]byte, 0, len(script))
+				result = append(result, script[:prevOffset]...)
+			}
+		} else if result != nil {
+			result = append(result, script[prevOffset:tokenizer.ByteIndex()]...)
 		}
+		prevOffset = tokenizer.ByteIndex()
+	}
+	if result == nil {
+		return script
 	}
-	return retScript
+	return result
 }
 
-// canonicalPush returns true if the object is either not a push instruction
-// or the push instruction contained wherein is matches the canonical form
-// or using the smallest instruction to do the job. False otherwise.
-func canonicalPush(pop parsedOpcode) bool {
-	opcode := pop.opcode.value
-	data := pop.data
-	dataLen := len(pop.data)
+// isCanonicalPush returns true if the opcode is either not a push instruction
+// or the data associated with the push instruction uses the smallest
+// instruction to do the job.  False otherwise.
+func isCanonicalPush(opcode byte, data []byte) bool {
+	dataLen := len(data)
 	if opcode > OP_16 {
 		return true
 	}
@@ -258,16 +264,32 @@ func canonicalPush(pop parsedOpcode) bool {
 }
 
 // removeOpcodeByData will return the script minus any opcodes that would push
-// the passed data to the stack.
-func removeOpcodeByData(pkscript []parsedOpcode, data []byte) []parsedOpcode {
-	retScript := make([]parsedOpcode, 0, len(pkscript))
-	for _, pop := range pkscript {
-		if !canonicalPush(pop) || !bytes.Contains(pop.data, data) {
-			retScript = append(retScript, pop)
+// the passed data to the stack.  The passed script must have already been
+// checked for parse failures.
+//
+// NOTE: The passed script is returned unmodified when nothing is removed in
+// order to avoid allocating.
+func removeOpcodeByData(script []byte, data []byte) []byte {
+	var result []byte
+	var prevOffset int32
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	for tokenizer.Next() {
+		if isCanonicalPush(tokenizer.Opcode(), tokenizer.Data()) &&
+			bytes.Contains(tokenizer.Data(), data) {
+
+			if result == nil {
+				result = make([]byte, 0, len(script))
+				result = append(result, script[:prevOffset]...)
+			}
+		} else if result != nil {
+			result = append(result, script[prevOffset:tokenizer.ByteIndex()]...)
 		}
+		prevOffset = tokenizer.ByteIndex()
 	}
-	return retScript
-
+	if result == nil {
+		return script
+	}
+	return result
 }
 
 // asSmallInt returns the passed opcode, which must be true according to
@@ -280,42 +302,41 @@ func asSmallInt(op *opcode) int {
 	return int(op.value - (OP_1 - 1))
 }
 
-// getSigOpCount is the implementation function for counting the number of
-// signature operations in the script provided by pops. If precise mode is
-// requested then we attempt to count the number of operations for a multisig
-// op. Otherwise we use the maximum.
-func getSigOpCount(pops []parsedOpcode, precise bool) int {
-	nSigs := 0
-	for i, pop := range pops {
-		switch pop.opcode.value {
-		case OP_CHECKSIG:
-			fallthrough
-		case OP_CHECKSIGVERIFY:
-			fallthrough
-		case OP_CHECKSIGALT:
-			fallthrough
-		case OP_CHECKSIGALTVERIFY:
-			nSigs++
-		case OP_CHECKMULTISIG:
-			fallthrough
-		case OP_CHECKMULTISIGVERIFY:
+// countSigOps returns the number of signature operations in the provided
+// script up to the point of the first parse failure or the entire script when
+// there are no parse failures.  The precise flag attempts to accurately count
+// the number of operations for a multisig operation versus using the maximum
+// allowed.
+func countSigOps(script []byte, precise bool) int {
+	numSigOps := 0
+	var prevOp byte
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	for tokenizer.Next() {
+		switch tokenizer.Opcode() {
+		case OP_CHECKSIG, OP_CHECKSIGVERIFY, OP_CHECKSIGALT,
+			OP_CHECKSIGALTVERIFY:
+
+			numSigOps++
+
+		case OP_CHECKMULTISIG, OP_CHECKMULTISIGVERIFY:
 			// If we are being precise then look for familiar
 			// patterns for multisig, for now all we recognize is
 			// OP_1 - OP_16 to signify the number of pubkeys.
 			// Otherwise, we use the max of 20.
-			if precise && i > 0 &&
-				pops[i-1].opcode.value >= OP_1 &&
-				pops[i-1].opcode.value <= OP_16 {
-				nSigs += asSmallInt(pops[i-1].opcode)
+			if precise && prevOp >= OP_1 && prevOp <= OP_16 {
+				numSigOps += asSmallInt(&opcodeArray[prevOp])
 			} else {
-				nSigs += MaxPubKeysPerMultiSig
+				numSigOps += MaxPubKeysPerMultiSig
 			}
+
 		default:
 			// Not a sigop.
 		}
+
+		prevOp = tokenizer.Opcode()
 	}
 
-	return nSigs
+	return numSigOps
 }
 
 // GetSigOpCount provides a quick count of the number of signature operations
@@ -323,10 +344,26 @@ func getSigOpCount(pops []parsedOpcode, precise bool) int {
 // If the script fails to parse, then the count up to the point of failure is
 // returned.
 func GetSigOpCount(script []byte) int {
-	// Don't check error since parseScript returns the parsed-up-to-error
-	// list of pops.
-	pops, _ := parseScript(script)
-	return getSigOpCount(pops, false)
+	return countSigOps(script, false)
+}
+
+// finalOpcodeData returns the data associated with the final opcode in the
+// script.  It will return nil if the script fails to parse.
+func finalOpcodeData(script []byte) []byte {
+	// Avoid unnecessary work.
+	if len(script) == 0 {
+		return nil
+	}
+
+	var data []byte
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	for tokenizer.Next() {
+		data = tokenizer.Data()
+	}
+	if tokenizer.Err() != nil {
+		return nil
+	}
+	return data
 }
 
 // GetPreciseSigOpCount returns the number of signature operations in
@@ -335,43 +372,34 @@ func GetSigOpCount(script []byte) int {
 // operations in the transaction.  If the script fails to parse, then the count
 // up to the point of failure is returned.
 func GetPreciseSigOpCount(scriptSig, scriptPubKey []byte, bip16 bool) int {
-	// Don't check error since parseScript returns the parsed-up-to-error
-	// list of pops.
-	pops, _ := parseScript(scriptPubKey)
-
-	// Treat non P2SH transactions as normal.
-	if !(bip16 && isScriptHash(pops)) {
-		return getSigOpCount(pops, true)
-	}
-
-	// The public key script is a pay-to-script-hash, so parse the signature
-	// script to get the final item.  Scripts that fail to fully parse count
-	// as 0 signature operations.
-	sigPops, err := parseScript(scriptSig)
-	if err != nil {
-		return 0
+	// Treat non P2SH transactions as normal.  Note that signature operation
+	// counting includes all operations up to the first parse failure.
+	if !(bip16 && isScriptHash(scriptPubKey)) {
+		return countSigOps(scriptPubKey, true)
 	}
 
 	// The signature script must only push data to the stack for P2SH to be
 	// a valid pair, so the signature operation count is 0 when that is not
-	// the case.
-	if !isPushOnly(sigPops) || len(sigPops) == 0 {
+	// the case.  Scripts that fail to fully parse also count as 0
+	// signature operations.
+	if len(scriptSig) == 0 || !isPushOnlyScript(scriptSig) {
 		return 0
 	}
 
 	// The P2SH script is the last item the signature script pushes to the
 	// stack.  When the script is empty, there are no signature operations.
-	shScript := sigPops[len(sigPops)-1].data
+	//
+	// Notice that signature scripts that fail to fully parse fail the push
+	// only check above and therefore this function will never return nil
+	// for them.
+	shScript := finalOpcodeData(scriptSig)
 	if len(shScript) == 0 {
 		return 0
 	}
 
-	// Parse the P2SH script and don't check the error since parseScript
-	// returns the parsed-up-to-error list of pops and the consensus rules
-	// dictate signature operations are counted up to the first parse
-	// failure.
-	shPops, _ := parseScript(shScript)
-	return getSigOpCount(shPops, true)
+	// Count the signature operations in the P2SH script up to the first
+	// parse failure as dictated by the consensus rules.
+	return countSigOps(shScript, true)
 }
 
 // IsUnspendable returns whether the passed public key script is unspendable, or
@@ -382,10 +410,10 @@ func IsUnspendable(amount int64, pkScript []byte) bool {
 		return true
 	}
 
-	pops, err := parseScript(pkScript)
-	if err != nil {
+	// The script is unspendable when it starts with OP_RETURN or fails to
+	// parse.
+	if len(pkScript) > 0 && pkScript[0] == OP_RETURN {
 		return true
 	}
-
-	return len(pops) > 0 && pops[0].opcode.value == OP_RETURN
+	return checkScriptParses(pkScript) != nil
 }
@@ -3769,7 +3769,7 @@ func TestHasCanonicalPush(t *testing.T) {
 			continue
 		}
 		for _, pop := range pops {
-			if result := canonicalPush(pop); !result {
+			if result := isCanonicalPush(pop.opcode.value, pop.data); !result {
 				t.Errorf("canonicalPush: test #%d "+
 					"failed: %x\n", i, script)
 				break
@@ -3794,7 +3794,7 @@ func TestHasCanonicalPush(t *testing.T) {
 			continue
 		}
 		for _, pop := range pops {
-			if result := canonicalPush(pop); !result {
+			if result := isCanonicalPush(pop.opcode.value, pop.data); !result {
 				t.Errorf("StandardPushesTests canonicalPush test #%d failed: %x\n", i, script)
 				break
 			}
@@ -3910,16 +3910,13 @@ func TestRemoveOpcodes(t *testing.T) {
 		},
 	}
 
-	// tstRemoveOpcode is a convenience function to parse the provided
-	// raw script, remove the passed opcode, then unparse the result back
-	// into a raw script.
+	// tstRemoveOpcode is a convenience function to ensure the provided
+	// raw script parses and then remove the passed opcode.
 	tstRemoveOpcode := func(script []byte, opcode byte) ([]byte, error) {
-		pops, err := parseScript(script)
-		if err != nil {
+		if err := checkScriptParses(script); err != nil {
 			return nil, err
 		}
-		pops = removeOpcode(pops, opcode)
-		return unparseScript(pops)
+		return removeOpcode(script, opcode), nil
 	}
 
 	for _, test := range tests {
@@ -4085,16 +4082,13 @@ func TestRemoveOpcodeByData(t *testing.T) {
 		},
 	}
 
-	// tstRemoveOpcodeByData is a convenience function to parse the provided
-	// raw script, remove the passed data, then unparse the result back
-	// into a raw script.
+	// tstRemoveOpcodeByData is a convenience function to ensure the
+	// provided raw script parses and then remove the passed data.
 	tstRemoveOpcodeByData := func(script []byte, data []byte) ([]byte, error) {
-		pops, err := parseScript(script)
-		if err != nil {
+		if err := checkScriptParses(script); err != nil {
 			return nil, err
 		}
-		pops = removeOpcodeByData(pops, data)
-		return unparseScript(pops)
+		return removeOpcodeByData(script, data), nil
 	}
 
 	for _, test := range tests {
@@ -4166,7 +4160,7 @@ func TestHasCanonicalPushes(t *testing.T) {
 			continue
 		}
 		for _, pop := range pops {
-			if canonicalPush(pop) != test.expected {
+			if isCanonicalPush(pop.opcode.value, pop.data) != test.expected {
 				t.Errorf("canonicalPush: #%d (%s) wrong result"+
 					"\ngot: %v\nwant: %v", i, test.name,
 					true, test.expected)
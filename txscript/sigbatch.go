@@ -0,0 +1,124 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"github.com/commanderu/cdrd/chaincfg/chainec"
+)
+
+// deferredSig is a signature check which was deferred by the script engine.
+type deferredSig struct {
+	sigType int
+	dsa     chainec.DSA
+	pubKey  chainec.PublicKey
+	hash    []byte
+	sig     chainec.Signature
+}
+
+// SigBatch collects the secp256k1 Schnorr signature checks of OP_CHECKSIGALT so
+// they can be verified together, which is significantly faster than verifying
+// each of them as they are encountered.  A batch is registered with an engine
+// via SetSigBatch.  Ed25519 signature checks are never deferred.
+//
+// An engine with a registered batch treats every deferred signature as valid
+// while executing, so the result of the execution is only meaningful once the
+// batch is verified.  When the batch fails, VerifyRange identifies the deferred
+// checks which are invalid and the scripts which deferred them must be executed
+// again without a batch to determine their actual result, since a script may
+// legitimately rely on a signature being invalid.
+//
+// A SigBatch is not safe for concurrent access.
+type SigBatch struct {
+	sigs []deferredSig
+}
+
+// NewSigBatch returns a new empty signature batch.
+func NewSigBatch() *SigBatch {
+	return &SigBatch{}
+}
+
+// add defers the verification of the passed signature.
+func (b *SigBatch) add(sigType int, dsa chainec.DSA, pubKey chainec.PublicKey,
+	hash []byte, sig chainec.Signature) {
+
+	b.sigs = append(b.sigs, deferredSig{
+		sigType: sigType,
+		dsa:     dsa,
+		pubKey:  pubKey,
+		hash:    hash,
+		sig:     sig,
+	})
+}
+
+// Len returns the number of deferred signature checks in the batch.  It is
+// useful to determine the range of checks deferred by an execution.
+func (b *SigBatch) Len() int {
+	return len(b.sigs)
+}
+
+// Truncate discards all but the first n deferred signature checks, such as
+// those deferred by an execution which is going to be repeated without the
+// batch.
+func (b *SigBatch) Truncate(n int) {
+	if n < len(b.sigs) {
+		b.sigs = b.sigs[:n]
+	}
+}
+
+// Verify verifies all of the deferred signature checks at once and returns
+// whether or not they are all valid.
+func (b *SigBatch) Verify() bool {
+	if len(b.sigs) == 0 {
+		return true
+	}
+
+	// Group the checks by signature type since each one is verified by its
+	// own batch.
+	type group struct {
+		dsa     chainec.DSA
+		pubKeys []chainec.PublicKey
+		hashes  [][]byte
+		sigs    []chainec.Signature
+	}
+	var groups []*group
+	groupsByType := make(map[int]*group)
+	for i := range b.sigs {
+		s := &b.sigs[i]
+		g, ok := groupsByType[s.sigType]
+		if !ok {
+			g = &group{dsa: s.dsa}
+			groupsByType[s.sigType] = g
+			groups = append(groups, g)
+		}
+		g.pubKeys = append(g.pubKeys, s.pubKey)
+		g.hashes = append(g.hashes, s.hash)
+		g.sigs = append(g.sigs, s.sig)
+	}
+	for _, g := range groups {
+		if !g.dsa.BatchVerify(g.pubKeys, g.hashes, g.sigs) {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyRange verifies each of the deferred signature checks from start up to,
+// but not including, end individually and returns whether or not they are all
+// valid.
+func (b *SigBatch) VerifyRange(start, end int) bool {
+	for i := start; i < end && i < len(b.sigs); i++ {
+		s := &b.sigs[i]
+		if !s.dsa.Verify(s.pubKey, s.hash, s.sig.GetR(), s.sig.GetS()) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetSigBatch registers a batch which the secp256k1 Schnorr signature checks of
+// OP_CHECKSIGALT are deferred to instead of verifying them immediately.  Passing nil restores immediate verification.
+func (vm *Engine) SetSigBatch(batch *SigBatch) {
+	vm.sigBatch = batch
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg/chainec"
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/commanderu/cdrd/wire"
+)
+
+// TestSigBatch ensures the secp256k1 Schnorr signature checks of OP_CHECKSIGALT
+// are deferred to a registered batch and that invalid signatures are detected
+// by the batch rather than the execution, while Ed25519 signature checks are
+// always performed immediately.
+func TestSigBatch(t *testing.T) {
+	t.Parallel()
+
+	edPriv, edPub := chainec.Edwards.PrivKeyFromScalar(bytes.Repeat([]byte{0x01}, 32))
+	secPriv, secPub := chainec.Secp256k1.PrivKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	keys := []struct {
+		priv    chainec.PrivateKey
+		pubKey  []byte
+		sigType sigTypes
+	}{
+		{secPriv, secPub.SerializeCompressed(), secSchnorr},
+		{edPriv, edPub.Serialize(), edwards},
+	}
+
+	// Create a transaction which spends an output paying to each key.
+	tx := wire.NewMsgTx()
+	pkScripts := make([][]byte, len(keys))
+	for i, key := range keys {
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+			uint32(i), wire.TxTreeRegular), nil))
+		pkScripts[i] = mustParseShortForm(fmt.Sprintf("DATA_%d 0x%x %d "+
+			"CHECKSIGALT", len(key.pubKey), key.pubKey, key.sigType))
+	}
+	tx.AddTxOut(wire.NewTxOut(0, []byte{OP_TRUE}))
+	for i, key := range keys {
+		sig, err := RawTxInSignatureAlt(tx, i, pkScripts[i], SigHashAll,
+			key.priv, key.sigType)
+		if err != nil {
+			t.Fatalf("RawTxInSignatureAlt: %v", err)
+		}
+		tx.TxIn[i].SignatureScript, err = NewScriptBuilder().
+			AddData(sig).Script()
+		if err != nil {
+			t.Fatalf("failed to build signature script: %v", err)
+		}
+	}
+
+	// execute executes the script pair of each input of the passed
+	// transaction with the passed batch and returns the first error.
+	execute := func(tx *wire.MsgTx, batch *SigBatch) error {
+		for i := range tx.TxIn {
+			vm, err := NewEngine(pkScripts[i], tx, i, 0, 0, nil)
+			if err != nil {
+				return err
+			}
+			vm.SetSigBatch(batch)
+			if err := vm.Execute(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The valid secp256k1 Schnorr signature is deferred to the batch and
+	// verifies.
+	batch := NewSigBatch()
+	if err := execute(tx, batch); err != nil {
+		t.Fatalf("failed to execute valid scripts: %v", err)
+	}
+	if batch.Len() != 1 {
+		t.Fatalf("unexpected number of deferred checks -- got %d, want 1",
+			batch.Len())
+	}
+	if !batch.Verify() || !batch.VerifyRange(0, batch.Len()) {
+		t.Fatal("batch of valid signatures failed")
+	}
+
+	// Signatures which do not commit to the transaction still execute
+	// successfully with a batch when they are deferred, but the batch fails
+	// and identifies them.  The Ed25519 signature is verified immediately,
+	// so its script fails to execute.
+	badTx := tx.Copy()
+	badTx.TxOut[0].Value = 1
+	batch = NewSigBatch()
+	if err := execute(badTx, batch); err == nil {
+		t.Fatal("invalid Ed25519 signature executed successfully with " +
+			"a batch")
+	}
+	if batch.Len() != 1 {
+		t.Fatalf("unexpected number of deferred checks -- got %d, want 1",
+			batch.Len())
+	}
+	if batch.Verify() {
+		t.Fatal("batch of invalid signatures passed")
+	}
+	for i := 0; i < batch.Len(); i++ {
+		if batch.VerifyRange(i, i+1) {
+			t.Fatalf("invalid signature %d passed", i)
+		}
+	}
+	if err := execute(badTx, nil); err == nil {
+		t.Fatal("invalid signatures executed successfully without a batch")
+	}
+
+	// Discarding the invalid checks leaves a batch which verifies.
+	batch.Truncate(0)
+	if batch.Len() != 0 || !batch.Verify() {
+		t.Fatal("truncated batch failed")
+	}
+}
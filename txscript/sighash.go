@@ -227,7 +227,7 @@ func sigHashWitnessSerializeSize(hashType SigHashType, txIns []*wire.TxIn, signS
 // cached prefix parameter allows the caller to optimize the calculation by
 // providing the prefix hash to be reused in the case of SigHashAll without the
 // SigHashAnyOneCanPay flag set.
-func calcSignatureHash(prevOutScript []parsedOpcode, hashType SigHashType, tx *wire.MsgTx, idx int, cachedPrefix *chainhash.Hash) ([]byte, error) {
+func calcSignatureHash(prevOutScript []byte, hashType SigHashType, tx *wire.MsgTx, idx int, cachedPrefix *chainhash.Hash) ([]byte, error) {
 	// The SigHashSingle signature type signs only the corresponding input
 	// and output (the output with the same index number as the input).
 	//
@@ -239,11 +239,7 @@ func calcSignatureHash(prevOutScript []parsedOpcode, hashType SigHashType, tx *w
 	}
 
 	// Remove all instances of OP_CODESEPARATOR from the script.
-	//
-	// The call to unparseScript cannot fail here because removeOpcode
-	// only returns a valid script.
-	prevOutScript = removeOpcode(prevOutScript, OP_CODESEPARATOR)
-	signScript, _ := unparseScript(prevOutScript)
+	signScript := removeOpcode(prevOutScript, OP_CODESEPARATOR)
 
 	// Choose the inputs that will be committed to based on the signature
 	// hash type.
@@ -453,10 +449,9 @@ func calcSignatureHash(prevOutScript []parsedOpcode, hashType SigHashType, tx *w
 // providing the prefix hash to be reused in the case of SigHashAll without the
 // SigHashAnyOneCanPay flag set.
 func CalcSignatureHash(script []byte, hashType SigHashType, tx *wire.MsgTx, idx int, cachedPrefix *chainhash.Hash) ([]byte, error) {
-	pops, err := parseScript(script)
-	if err != nil {
+	if err := checkScriptParses(script); err != nil {
 		return nil, err
 	}
 
-	return calcSignatureHash(pops, hashType, tx, idx, cachedPrefix)
+	return calcSignatureHash(script, hashType, tx, idx, cachedPrefix)
 }
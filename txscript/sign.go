@@ -20,11 +20,10 @@ import (
 func RawTxInSignature(tx *wire.MsgTx, idx int, subScript []byte,
 	hashType SigHashType, key chainec.PrivateKey) ([]byte, error) {
 
-	parsedScript, err := parseScript(subScript)
-	if err != nil {
+	if err := checkScriptParses(subScript); err != nil {
 		return nil, fmt.Errorf("cannot parse output script: %v", err)
 	}
-	hash, err := calcSignatureHash(parsedScript, hashType, tx, idx, nil)
+	hash, err := calcSignatureHash(subScript, hashType, tx, idx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -44,11 +43,10 @@ func RawTxInSignatureAlt(tx *wire.MsgTx, idx int, subScript []byte,
 	hashType SigHashType, key chainec.PrivateKey, sigType sigTypes) ([]byte,
 	error) {
 
-	parsedScript, err := parseScript(subScript)
-	if err != nil {
+	if err := checkScriptParses(subScript); err != nil {
 		return nil, fmt.Errorf("cannot parse output script: %v", err)
 	}
-	hash, err := calcSignatureHash(parsedScript, hashType, tx, idx, nil)
+	hash, err := calcSignatureHash(subScript, hashType, tx, idx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -426,11 +424,6 @@ func mergeScripts(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
 func mergeMultiSig(tx *wire.MsgTx, idx int, addresses []cdrutil.Address,
 	nRequired int, pkScript, sigScript, prevScript []byte) []byte {
 
-	// This is an internal only function and we already parsed this script
-	// as ok for multisig (this is how we got here), so if this fails then
-	// all assumptions are broken and who knows which way is up?
-	pkPops, _ := parseScript(pkScript)
-
 	sigPops, err := parseScript(sigScript)
 	if err != nil || len(sigPops) == 0 {
 		return prevScript
@@ -483,7 +476,7 @@ sigLoop:
 		// however, assume no sigs etc are in the script since that
 		// would make the transaction nonstandard and thus not
 		// MultiSigTy, so we just need to hash the full thing.
-		hash, err := calcSignatureHash(pkPops, hashType, tx, idx, nil)
+		hash, err := calcSignatureHash(pkScript, hashType, tx, idx, nil)
 		if err != nil {
 			// commanderu -- is this the right handling for SIGHASH_SINGLE error ?
 			// TODO make sure this doesn't break anything.
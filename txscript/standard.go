@@ -69,296 +69,245 @@ func (t ScriptClass) String() string {
 
 // isPubkey returns true if the script passed is a pay-to-pubkey transaction,
 // false otherwise.
-func isPubkey(pops []parsedOpcode) bool {
+func isPubkey(script []byte) bool {
+	// A pay-to-pubkey script is of the form:
+	//  <pubkey> OP_CHECKSIG
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	if !tokenizer.Next() {
+		return false
+	}
+
 	// Valid pubkeys are either 33 or 65 bytes.
-	return len(pops) == 2 &&
-		(len(pops[0].data) == 33 || len(pops[0].data) == 65) &&
-		pops[1].opcode.value == OP_CHECKSIG
+	dataLen := len(tokenizer.Data())
+	if dataLen != 33 && dataLen != 65 {
+		return false
+	}
+
+	return tokenizer.Next() && tokenizer.Opcode() == OP_CHECKSIG &&
+		tokenizer.Done()
 }
 
-// isOneByteMaxDataPush returns true if the parsed opcode pushes exactly one
-// byte to the stack.
-func isOneByteMaxDataPush(po parsedOpcode) bool {
-	return po.opcode.value == OP_1 ||
-		po.opcode.value == OP_2 ||
-		po.opcode.value == OP_3 ||
-		po.opcode.value == OP_4 ||
-		po.opcode.value == OP_5 ||
-		po.opcode.value == OP_6 ||
-		po.opcode.value == OP_7 ||
-		po.opcode.value == OP_8 ||
-		po.opcode.value == OP_9 ||
-		po.opcode.value == OP_10 ||
-		po.opcode.value == OP_11 ||
-		po.opcode.value == OP_12 ||
-		po.opcode.value == OP_13 ||
-		po.opcode.value == OP_14 ||
-		po.opcode.value == OP_15 ||
-		po.opcode.value == OP_16 ||
-		po.opcode.value == OP_DATA_1
+// isOneByteMaxDataPush returns true if the opcode pushes exactly one byte to
+// the stack.
+func isOneByteMaxDataPush(opcode byte) bool {
+	return (opcode >= OP_1 && opcode <= OP_16) || opcode == OP_DATA_1
 }
 
-// isPubkey returns true if the script passed is an alternative pay-to-pubkey
-// transaction, false otherwise.
-func isPubkeyAlt(pops []parsedOpcode) bool {
+// isPubkeyAlt returns true if the script passed is an alternative
+// pay-to-pubkey transaction, false otherwise.
+func isPubkeyAlt(script []byte) bool {
+	// An alternative pay-to-pubkey script is of the form:
+	//  <pubkey> <type> OP_CHECKSIGALT
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+
 	// An alternative pubkey must be less than 512 bytes.
-	return len(pops) == 3 &&
-		len(pops[0].data) < 512 &&
-		isOneByteMaxDataPush(pops[1]) &&
-		pops[2].opcode.value == OP_CHECKSIGALT
+	if !tokenizer.Next() || len(tokenizer.Data()) >= 512 {
+		return false
+	}
+
+	return tokenizer.Next() && isOneByteMaxDataPush(tokenizer.Opcode()) &&
+		tokenizer.Next() && tokenizer.Opcode() == OP_CHECKSIGALT &&
+		tokenizer.Done()
 }
 
 // isPubkeyHash returns true if the script passed is a pay-to-pubkey-hash
 // transaction, false otherwise.
-func isPubkeyHash(pops []parsedOpcode) bool {
-	return len(pops) == 5 &&
-		pops[0].opcode.value == OP_DUP &&
-		pops[1].opcode.value == OP_HASH160 &&
-		pops[2].opcode.value == OP_DATA_20 &&
-		pops[3].opcode.value == OP_EQUALVERIFY &&
-		pops[4].opcode.value == OP_CHECKSIG
+func isPubkeyHash(script []byte) bool {
+	// A pay-to-pubkey-hash script is of the form:
+	//  OP_DUP OP_HASH160 <20-byte hash> OP_EQUALVERIFY OP_CHECKSIG
+	return len(script) == 25 &&
+		script[0] == OP_DUP &&
+		script[1] == OP_HASH160 &&
+		script[2] == OP_DATA_20 &&
+		script[23] == OP_EQUALVERIFY &&
+		script[24] == OP_CHECKSIG
 }
 
 // isPubkeyHashAlt returns true if the script passed is a pay-to-pubkey-hash
 // transaction, false otherwise.
-func isPubkeyHashAlt(pops []parsedOpcode) bool {
-	return len(pops) == 6 &&
-		pops[0].opcode.value == OP_DUP &&
-		pops[1].opcode.value == OP_HASH160 &&
-		pops[2].opcode.value == OP_DATA_20 &&
-		pops[3].opcode.value == OP_EQUALVERIFY &&
-		isOneByteMaxDataPush(pops[4]) &&
-		pops[5].opcode.value == OP_CHECKSIGALT
+func isPubkeyHashAlt(script []byte) bool {
+	// An alternative pay-to-pubkey-hash script is of the form:
+	//  OP_DUP OP_HASH160 <20-byte hash> OP_EQUALVERIFY <type> OP_CHECKSIGALT
+	//
+	// The signature type is either a small integer opcode or a single byte
+	// data push, so the script is either 26 or 27 bytes.
+	if len(script) < 26 || len(script) > 27 ||
+		script[0] != OP_DUP ||
+		script[1] != OP_HASH160 ||
+		script[2] != OP_DATA_20 ||
+		script[23] != OP_EQUALVERIFY ||
+		script[len(script)-1] != OP_CHECKSIGALT {
+
+		return false
+	}
+	if len(script) == 26 {
+		return script[24] >= OP_1 && script[24] <= OP_16
+	}
+	return script[24] == OP_DATA_1
 }
 
 // isScriptHash returns true if the script passed is a pay-to-script-hash
 // transaction, false otherwise.
-func isScriptHash(pops []parsedOpcode) bool {
-	return len(pops) == 3 &&
-		pops[0].opcode.value == OP_HASH160 &&
-		pops[1].opcode.value == OP_DATA_20 &&
-		pops[2].opcode.value == OP_EQUAL
+func isScriptHash(script []byte) bool {
+	// A pay-to-script-hash script is of the form:
+	//  OP_HASH160 <20-byte scripthash> OP_EQUAL
+	return len(script) == 23 &&
+		script[0] == OP_HASH160 &&
+		script[1] == OP_DATA_20 &&
+		script[22] == OP_EQUAL
+}
+
+// isStakeOpcode returns whether or not the opcode is one of the stake tagging
+// opcodes.
+func isStakeOpcode(opcode byte) bool {
+	return opcode >= OP_SSTX && opcode <= OP_SSTXCHANGE
 }
 
 // isAnyKindOfScriptHash returns true if the script passed is a pay-to-script-hash
 // or stake pay-to-script-hash transaction, false otherwise. Used to make the
 // engine have the correct behaviour.
-func isAnyKindOfScriptHash(pops []parsedOpcode) bool {
-	standardP2SH := len(pops) == 3 &&
-		pops[0].opcode.value == OP_HASH160 &&
-		pops[1].opcode.value == OP_DATA_20 &&
-		pops[2].opcode.value == OP_EQUAL
-	if standardP2SH {
+func isAnyKindOfScriptHash(script []byte) bool {
+	if isScriptHash(script) {
 		return true
 	}
 
-	return len(pops) == 4 &&
-		(pops[0].opcode.value >= 186 && pops[0].opcode.value <= 189) &&
-		pops[1].opcode.value == OP_HASH160 &&
-		pops[2].opcode.value == OP_DATA_20 &&
-		pops[3].opcode.value == OP_EQUAL
+	return len(script) > 0 && isStakeOpcode(script[0]) &&
+		isScriptHash(script[1:])
 }
 
 // isMultiSig returns true if the passed script is a multisig transaction, false
 // otherwise.
-func isMultiSig(pops []parsedOpcode) bool {
+func isMultiSig(script []byte) bool {
+	// A multi-signature script is of the form:
+	//  NUM_SIGS PUBKEY PUBKEY PUBKEY ... NUM_PUBKEYS OP_CHECKMULTISIG
+	//
 	// The absolute minimum is 1 pubkey:
-	// OP_0/OP_1-16 <pubkey> OP_1 OP_CHECKMULTISIG
-	l := len(pops)
-	if l < 4 {
-		return false
-	}
-	if !isSmallInt(pops[0].opcode) {
-		return false
-	}
-	if !isSmallInt(pops[l-2].opcode) {
+	//  OP_0/OP_1-16 <pubkey> OP_1 OP_CHECKMULTISIG
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	if !tokenizer.Next() || !isSmallInt(tokenizer.op) {
 		return false
 	}
-	if pops[l-1].opcode.value != OP_CHECKMULTISIG {
-		return false
+
+	// Count the pubkeys until the first opcode that does not push one.
+	// Valid pubkeys are either 33 or 65 bytes.
+	var numPubKeys int
+	for {
+		if !tokenizer.Next() {
+			return false
+		}
+		dataLen := len(tokenizer.Data())
+		if dataLen != 33 && dataLen != 65 {
+			break
+		}
+		numPubKeys++
 	}
 
 	// Verify the number of pubkeys specified matches the actual number
 	// of pubkeys provided.
-	if l-2-1 != asSmallInt(pops[l-2].opcode) {
+	if numPubKeys == 0 || !isSmallInt(tokenizer.op) ||
+		asSmallInt(tokenizer.op) != numPubKeys {
+
 		return false
 	}
 
-	for _, pop := range pops[1 : l-2] {
-		// Valid pubkeys are either 33 or 65 bytes.
-		if len(pop.data) != 33 && len(pop.data) != 65 {
-			return false
-		}
-	}
-	return true
+	return tokenizer.Next() && tokenizer.Opcode() == OP_CHECKMULTISIG &&
+		tokenizer.Done()
 }
 
 // IsMultisigScript takes a script, parses it, then returns whether or
 // not it is a multisignature script.
 func IsMultisigScript(script []byte) (bool, error) {
-	pops, err := parseScript(script)
-	if err != nil {
+	if err := checkScriptParses(script); err != nil {
 		return false, err
 	}
-	return isMultiSig(pops), nil
+	return isMultiSig(script), nil
 }
 
 // IsMultisigSigScript takes a script, parses it, then returns whether or
 // not it is a multisignature script.
 func IsMultisigSigScript(script []byte) bool {
-	if len(script) == 0 || script == nil {
-		return false
-	}
-	pops, err := parseScript(script)
-	if err != nil {
-		return false
-	}
-	subPops, err := parseScript(pops[len(pops)-1].data)
-	if err != nil {
-		return false
-	}
-
-	return isMultiSig(subPops)
+	// The redeem script is the final data push of the signature script.
+	// Note that the final data will be nil when the script does not parse.
+	return isMultiSig(finalOpcodeData(script))
 }
 
 // isNullData returns true if the passed script is a null data transaction,
 // false otherwise.
-func isNullData(pops []parsedOpcode) bool {
+func isNullData(script []byte) bool {
 	// A nulldata transaction is either a single OP_RETURN or an
 	// OP_RETURN SMALLDATA (where SMALLDATA is a data push up to
 	// MaxDataCarrierSize bytes).
-	l := len(pops)
-	if l == 1 && pops[0].opcode.value == OP_RETURN {
+	if len(script) == 0 || script[0] != OP_RETURN {
+		return false
+	}
+	if len(script) == 1 {
 		return true
 	}
 
-	return l == 2 &&
-		pops[0].opcode.value == OP_RETURN &&
-		(isSmallInt(pops[1].opcode) || pops[1].opcode.value <=
-			OP_PUSHDATA4) &&
-		len(pops[1].data) <= MaxDataCarrierSize
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script[1:])
+	return tokenizer.Next() && tokenizer.Done() &&
+		(isSmallInt(tokenizer.op) || tokenizer.Opcode() <= OP_PUSHDATA4) &&
+		len(tokenizer.Data()) <= MaxDataCarrierSize
 }
 
-// isStakeSubmission returns true if the script passed is a stake submission tx,
-// false otherwise.
-func isStakeSubmission(pops []parsedOpcode) bool {
-	if len(pops) == 6 &&
-		pops[0].opcode.value == OP_SSTX &&
-		pops[1].opcode.value == OP_DUP &&
-		pops[2].opcode.value == OP_HASH160 &&
-		pops[3].opcode.value == OP_DATA_20 &&
-		pops[4].opcode.value == OP_EQUALVERIFY &&
-		pops[5].opcode.value == OP_CHECKSIG {
-		return true
-	}
-
-	if len(pops) == 4 &&
-		pops[0].opcode.value == OP_SSTX &&
-		pops[1].opcode.value == OP_HASH160 &&
-		pops[2].opcode.value == OP_DATA_20 &&
-		pops[3].opcode.value == OP_EQUAL {
-		return true
+// isStakeTagged returns true if the script passed is a pay-to-pubkey-hash or
+// pay-to-script-hash script tagged with the provided stake opcode, false
+// otherwise.
+func isStakeTagged(script []byte, stakeOpcode byte) bool {
+	if len(script) == 0 || script[0] != stakeOpcode {
+		return false
 	}
+	return isPubkeyHash(script[1:]) || isScriptHash(script[1:])
+}
 
-	return false
+// isStakeSubmission returns true if the script passed is a stake submission tx,
+// false otherwise.
+func isStakeSubmission(script []byte) bool {
+	return isStakeTagged(script, OP_SSTX)
 }
 
 // isStakeGen returns true if the script passed is a stake generation tx,
 // false otherwise.
-func isStakeGen(pops []parsedOpcode) bool {
-	if len(pops) == 6 &&
-		pops[0].opcode.value == OP_SSGEN &&
-		pops[1].opcode.value == OP_DUP &&
-		pops[2].opcode.value == OP_HASH160 &&
-		pops[3].opcode.value == OP_DATA_20 &&
-		pops[4].opcode.value == OP_EQUALVERIFY &&
-		pops[5].opcode.value == OP_CHECKSIG {
-		return true
-	}
-
-	if len(pops) == 4 &&
-		pops[0].opcode.value == OP_SSGEN &&
-		pops[1].opcode.value == OP_HASH160 &&
-		pops[2].opcode.value == OP_DATA_20 &&
-		pops[3].opcode.value == OP_EQUAL {
-		return true
-	}
-
-	return false
+func isStakeGen(script []byte) bool {
+	return isStakeTagged(script, OP_SSGEN)
 }
 
 // isStakeRevocation returns true if the script passed is a stake submission
 // revocation tx, false otherwise.
-func isStakeRevocation(pops []parsedOpcode) bool {
-	if len(pops) == 6 &&
-		pops[0].opcode.value == OP_SSRTX &&
-		pops[1].opcode.value == OP_DUP &&
-		pops[2].opcode.value == OP_HASH160 &&
-		pops[3].opcode.value == OP_DATA_20 &&
-		pops[4].opcode.value == OP_EQUALVERIFY &&
-		pops[5].opcode.value == OP_CHECKSIG {
-		return true
-	}
-
-	if len(pops) == 4 &&
-		pops[0].opcode.value == OP_SSRTX &&
-		pops[1].opcode.value == OP_HASH160 &&
-		pops[2].opcode.value == OP_DATA_20 &&
-		pops[3].opcode.value == OP_EQUAL {
-		return true
-	}
-
-	return false
+func isStakeRevocation(script []byte) bool {
+	return isStakeTagged(script, OP_SSRTX)
 }
 
 // isSStxChange returns true if the script passed is a stake submission
 // change tx, false otherwise.
-func isSStxChange(pops []parsedOpcode) bool {
-	if len(pops) == 6 &&
-		pops[0].opcode.value == OP_SSTXCHANGE &&
-		pops[1].opcode.value == OP_DUP &&
-		pops[2].opcode.value == OP_HASH160 &&
-		pops[3].opcode.value == OP_DATA_20 &&
-		pops[4].opcode.value == OP_EQUALVERIFY &&
-		pops[5].opcode.value == OP_CHECKSIG {
-		return true
-	}
-
-	if len(pops) == 4 &&
-		pops[0].opcode.value == OP_SSTXCHANGE &&
-		pops[1].opcode.value == OP_HASH160 &&
-		pops[2].opcode.value == OP_DATA_20 &&
-		pops[3].opcode.value == OP_EQUAL {
-		return true
-	}
-
-	return false
+func isSStxChange(script []byte) bool {
+	return isStakeTagged(script, OP_SSTXCHANGE)
 }
 
 // scriptType returns the type of the script being inspected from the known
 // standard types.
-func typeOfScript(pops []parsedOpcode) ScriptClass {
-	if isPubkey(pops) {
+func typeOfScript(script []byte) ScriptClass {
+	if isPubkey(script) {
 		return PubKeyTy
-	} else if isPubkeyAlt(pops) {
+	} else if isPubkeyAlt(script) {
 		return PubkeyAltTy
-	} else if isPubkeyHash(pops) {
+	} else if isPubkeyHash(script) {
 		return PubKeyHashTy
-	} else if isPubkeyHashAlt(pops) {
+	} else if isPubkeyHashAlt(script) {
 		return PubkeyHashAltTy
-	} else if isScriptHash(pops) {
+	} else if isScriptHash(script) {
 		return ScriptHashTy
-	} else if isMultiSig(pops) {
+	} else if isMultiSig(script) {
 		return MultiSigTy
-	} else if isNullData(pops) {
+	} else if isNullData(script) {
 		return NullDataTy
-	} else if isStakeSubmission(pops) {
+	} else if isStakeSubmission(script) {
 		return StakeSubmissionTy
-	} else if isStakeGen(pops) {
+	} else if isStakeGen(script) {
 		return StakeGenTy
-	} else if isStakeRevocation(pops) {
+	} else if isStakeRevocation(script) {
 		return StakeRevocationTy
-	} else if isSStxChange(pops) {
+	} else if isSStxChange(script) {
 		return StakeSubChangeTy
 	}
 
@@ -375,20 +324,17 @@ func GetScriptClass(version uint16, script []byte) ScriptClass {
 		return NonStandardTy
 	}
 
-	pops, err := parseScript(script)
-	if err != nil {
-		return NonStandardTy
-	}
-
-	return typeOfScript(pops)
+	// Each of the script detection functions requires the entire script
+	// to parse, so scripts that fail to parse are nonstandard.
+	return typeOfScript(script)
 }
 
 // expectedInputs returns the number of arguments required by a script.
 // If the script is of unknown type such that the number can not be determined
 // then -1 is returned. We are an internal function and thus assume that class
-// is the real class of pops (and we can thus assume things that were determined
-// while finding out the type).
-func expectedInputs(pops []parsedOpcode, class ScriptClass,
+// is the real class of the script (and we can thus assume things that were
+// determined while finding out the type).
+func expectedInputs(script []byte, class ScriptClass,
 	subclass ScriptClass) int {
 	switch class {
 	case PubKeyTy:
@@ -433,7 +379,7 @@ func expectedInputs(pops []parsedOpcode, class ScriptClass,
 		// the original bitcoind bug where OP_CHECKMULTISIG pops an
 		// additional item from the stack, add an extra expected input
 		// for the extra push that is required to compensate.
-		return asSmallInt(pops[0].opcode)
+		return asSmallInt(&opcodeArray[script[0]])
 
 	case NullDataTy:
 		fallthrough
@@ -463,12 +409,7 @@ type ScriptInfo struct {
 
 // IsStakeOutput returns true is a script output is a stake type.
 func IsStakeOutput(pkScript []byte) bool {
-	pkPops, err := parseScript(pkScript)
-	if err != nil {
-		return false
-	}
-
-	class := typeOfScript(pkPops)
+	class := typeOfScript(pkScript)
 	return class == StakeSubmissionTy ||
 		class == StakeGenTy ||
 		class == StakeRevocationTy ||
@@ -478,33 +419,22 @@ func IsStakeOutput(pkScript []byte) bool {
 // GetStakeOutSubclass extracts the subclass (P2PKH or P2SH)
 // from a stake output.
 func GetStakeOutSubclass(pkScript []byte) (ScriptClass, error) {
-	pkPops, err := parseScript(pkScript)
-	if err != nil {
+	if err := checkScriptParses(pkScript); err != nil {
 		return 0, err
 	}
 
-	class := typeOfScript(pkPops)
+	class := typeOfScript(pkScript)
 	isStake := class == StakeSubmissionTy ||
 		class == StakeGenTy ||
 		class == StakeRevocationTy ||
 		class == StakeSubChangeTy
-
-	subClass := ScriptClass(0)
-	if isStake {
-		var stakeSubscript []parsedOpcode
-		for _, pop := range pkPops {
-			if pop.opcode.value >= 186 && pop.opcode.value <= 189 {
-				continue
-			}
-			stakeSubscript = append(stakeSubscript, pop)
-		}
-
-		subClass = typeOfScript(stakeSubscript)
-	} else {
+	if !isStake {
 		return 0, fmt.Errorf("not a stake output")
 	}
 
-	return subClass, nil
+	// The subscript of a stake output is everything after the stake
+	// tagging opcode.
+	return typeOfScript(getStakeOutSubscript(pkScript)), nil
 }
 
 // getStakeOutSubscript extracts the subscript (P2PKH or P2SH)
@@ -516,18 +446,18 @@ func getStakeOutSubscript(pkScript []byte) []byte {
 // ContainsStakeOpCodes returns whether or not a pkScript contains stake tagging
 // OP codes.
 func ContainsStakeOpCodes(pkScript []byte) (bool, error) {
-	shPops, err := parseScript(pkScript)
-	if err != nil {
-		return false, err
-	}
-
-	for _, pop := range shPops {
-		if pop.opcode.value >= 186 && pop.opcode.value <= 189 {
-			return true, nil
+	var hasStakeOpCodes bool
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, pkScript)
+	for tokenizer.Next() {
+		if isStakeOpcode(tokenizer.Opcode()) {
+			hasStakeOpCodes = true
 		}
 	}
+	if err := tokenizer.Err(); err != nil {
+		return false, err
+	}
 
-	return false, nil
+	return hasStakeOpCodes, nil
 }
 
 // CalcScriptInfo returns a structure providing data about the provided script
@@ -535,22 +465,19 @@ func ContainsStakeOpCodes(pkScript []byte) (bool, error) {
 // be analysed, i.e. if they do not parse or the pkScript is not a push-only
 // script
 func CalcScriptInfo(sigScript, pkScript []byte, bip16 bool) (*ScriptInfo, error) {
-	sigPops, err := parseScript(sigScript)
-	if err != nil {
+	if err := checkScriptParses(sigScript); err != nil {
 		return nil, err
 	}
-
-	pkPops, err := parseScript(pkScript)
-	if err != nil {
+	if err := checkScriptParses(pkScript); err != nil {
 		return nil, err
 	}
 
 	// Push only sigScript makes little sense.
 	si := new(ScriptInfo)
-	si.PkScriptClass = typeOfScript(pkPops)
+	si.PkScriptClass = typeOfScript(pkScript)
 
 	// Can't have a pkScript that doesn't just push data.
-	if !isPushOnly(sigPops) {
+	if !isPushOnlyScript(sigScript) {
 		return nil, ErrStackNonPushOnly
 	}
 
@@ -559,46 +486,70 @@ func CalcScriptInfo(sigScript, pkScript []byte, bip16 bool) (*ScriptInfo, error)
 		si.PkScriptClass == StakeGenTy ||
 		si.PkScriptClass == StakeRevocationTy ||
 		si.PkScriptClass == StakeSubChangeTy {
+		var err error
 		subClass, err = GetStakeOutSubclass(pkScript)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	si.ExpectedInputs = expectedInputs(pkPops, si.PkScriptClass, subClass)
+	si.ExpectedInputs = expectedInputs(pkScript, si.PkScriptClass, subClass)
 
 	// All entries pushed to stack (or are OP_RESERVED and exec will fail).
-	si.NumInputs = len(sigPops)
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, sigScript)
+	for tokenizer.Next() {
+		si.NumInputs++
+	}
 
 	// Count sigops taking into account pay-to-script-hash.
 	if (si.PkScriptClass == ScriptHashTy || subClass == ScriptHashTy) && bip16 {
 		// The pay-to-hash-script is the final data push of the
 		// signature script.
-		script := sigPops[len(sigPops)-1].data
-		shPops, err := parseScript(script)
-		if err != nil {
+		script := finalOpcodeData(sigScript)
+		if err := checkScriptParses(script); err != nil {
 			return nil, err
 		}
 
-		shInputs := expectedInputs(shPops, typeOfScript(shPops), 0)
+		shInputs := expectedInputs(script, typeOfScript(script), 0)
 		if shInputs == -1 {
 			si.ExpectedInputs = -1
 		} else {
 			si.ExpectedInputs += shInputs
 		}
-		si.SigOps = getSigOpCount(shPops, true)
+		si.SigOps = countSigOps(script, true)
 	} else {
-		si.SigOps = getSigOpCount(pkPops, true)
+		si.SigOps = countSigOps(pkScript, true)
 	}
 
 	return si, nil
 }
 
+// multiSigCountOpcodes returns the first and second to last opcodes of the
+// passed script along with the total number of opcodes it contains.  For a
+// multi-signature script, the first and second to last opcodes respectively
+// represent the number of required signatures and the number of public keys.
+func multiSigCountOpcodes(script []byte) (*opcode, *opcode, int, error) {
+	var first, prev, cur *opcode
+	var numOpcodes int
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	for tokenizer.Next() {
+		if first == nil {
+			first = tokenizer.op
+		}
+		prev, cur = cur, tokenizer.op
+		numOpcodes++
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, nil, 0, err
+	}
+	return first, prev, numOpcodes, nil
+}
+
 // CalcMultiSigStats returns the number of public keys and signatures from
 // a multi-signature transaction script.  The passed script MUST already be
 // known to be a multi-signature script.
 func CalcMultiSigStats(script []byte) (int, int, error) {
-	pops, err := parseScript(script)
+	numSigsOp, numPubKeysOp, numOpcodes, err := multiSigCountOpcodes(script)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -610,12 +561,12 @@ func CalcMultiSigStats(script []byte) (int, int, error) {
 	// minimum for a multi-signature script is 1 pubkey, so at least 4
 	// items must be on the stack per:
 	//  OP_1 PUBKEY OP_1 OP_CHECKMULTISIG
-	if len(pops) < 4 {
+	if numOpcodes < 4 {
 		return 0, 0, ErrStackUnderflow
 	}
 
-	numSigs := asSmallInt(pops[0].opcode)
-	numPubKeys := asSmallInt(pops[len(pops)-2].opcode)
+	numSigs := asSmallInt(numSigsOp)
+	numPubKeys := asSmallInt(numPubKeysOp)
 	return numPubKeys, numSigs, nil
 }
 
@@ -623,14 +574,13 @@ func CalcMultiSigStats(script []byte) (int, int, error) {
 // signature redeem script from a P2SH-redeeming input. It returns
 // nil if the signature script is not a multisignature script.
 func MultisigRedeemScriptFromScriptSig(script []byte) ([]byte, error) {
-	pops, err := parseScript(script)
-	if err != nil {
+	if err := checkScriptParses(script); err != nil {
 		return nil, err
 	}
 
 	// The redeemScript is always the last item on the stack of
 	// the script sig.
-	return pops[len(pops)-1].data, nil
+	return finalOpcodeData(script), nil
 }
 
 // payToPubKeyHashScript creates a new script to pay a transaction
@@ -673,20 +623,20 @@ func payToScriptHashScript(scriptHash []byte) ([]byte, error) {
 // GetScriptHashFromP2SHScript extracts the script hash from a valid
 // P2SH pkScript.
 func GetScriptHashFromP2SHScript(pkScript []byte) ([]byte, error) {
-	pops, err := parseScript(pkScript)
-	if err != nil {
+	if err := checkScriptParses(pkScript); err != nil {
 		return nil, err
 	}
 
 	var sh []byte
 	reachedHash160DataPush := false
-	for _, p := range pops {
-		if p.opcode.value == OP_HASH160 {
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, pkScript)
+	for tokenizer.Next() {
+		if tokenizer.Opcode() == OP_HASH160 {
 			reachedHash160DataPush = true
 			continue
 		}
 		if reachedHash160DataPush {
-			sh = p.data
+			sh = tokenizer.Data()
 			break
 		}
 	}
@@ -1075,19 +1025,18 @@ func MultiSigScript(pubkeys []*cdrutil.AddressSecpPubKey, nrequired int) ([]byte
 // PushedData returns an array of byte slices containing any pushed data found
 // in the passed script.  This includes OP_0, but not OP_1 - OP_16.
 func PushedData(script []byte) ([][]byte, error) {
-	pops, err := parseScript(script)
-	if err != nil {
-		return nil, err
-	}
-
 	var data [][]byte
-	for _, pop := range pops {
-		if pop.data != nil {
-			data = append(data, pop.data)
-		} else if pop.opcode.value == OP_0 {
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	for tokenizer.Next() {
+		if tokenizer.Data() != nil {
+			data = append(data, tokenizer.Data())
+		} else if tokenizer.Opcode() == OP_0 {
 			data = append(data, nil)
 		}
 	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, err
+	}
 	return data, nil
 }
 
@@ -1096,13 +1045,17 @@ func PushedData(script []byte) ([][]byte, error) {
 func GetMultisigMandN(script []byte) (uint8, uint8, error) {
 	// No valid addresses or required signatures if the script doesn't
 	// parse.
-	pops, err := parseScript(script)
+	requiredSigsOp, numPubKeysOp, numOpcodes, err :=
+		multiSigCountOpcodes(script)
 	if err != nil {
 		return 0, 0, err
 	}
+	if numOpcodes < 2 {
+		return 0, 0, ErrStackUnderflow
+	}
 
-	requiredSigs := uint8(asSmallInt(pops[0].opcode))
-	numPubKeys := uint8(asSmallInt(pops[len(pops)-2].opcode))
+	requiredSigs := uint8(asSmallInt(requiredSigsOp))
+	numPubKeys := uint8(asSmallInt(numPubKeysOp))
 
 	return requiredSigs, numPubKeys, nil
 }
@@ -1122,12 +1075,12 @@ func ExtractPkScriptAddrs(version uint16, pkScript []byte,
 
 	// No valid addresses or required signatures if the script doesn't
 	// parse.
-	pops, err := parseScript(pkScript)
-	if err != nil {
+	if err := checkScriptParses(pkScript); err != nil {
 		return NonStandardTy, nil, 0, err
 	}
 
-	scriptClass := typeOfScript(pops)
+	scriptClass := typeOfScript(pkScript)
+	var err error
 
 	switch scriptClass {
 	case PubKeyHashTy:
@@ -1136,7 +1089,7 @@ func ExtractPkScriptAddrs(version uint16, pkScript []byte,
 		// Therefore the pubkey hash is the 3rd item on the stack.
 		// Skip the pubkey hash if it's invalid for some reason.
 		requiredSigs = 1
-		addr, err := cdrutil.NewAddressPubKeyHash(pops[2].data,
+		addr, err := cdrutil.NewAddressPubKeyHash(pkScript[3:23],
 			chainParams, chainec.ECTypeSecp256k1)
 		if err == nil {
 			addrs = append(addrs, addr)
@@ -1149,7 +1102,7 @@ func ExtractPkScriptAddrs(version uint16, pkScript []byte,
 		// Skip the pubkey hash if it's invalid for some reason.
 		requiredSigs = 1
 		suite, _ := ExtractPkScriptAltSigType(pkScript)
-		addr, err := cdrutil.NewAddressPubKeyHash(pops[2].data,
+		addr, err := cdrutil.NewAddressPubKeyHash(pkScript[3:23],
 			chainParams, suite)
 		if err == nil {
 			addrs = append(addrs, addr)
@@ -1161,7 +1114,7 @@ func ExtractPkScriptAddrs(version uint16, pkScript []byte,
 		// Therefore the pubkey is the first item on the stack.
 		// Skip the pubkey if it's invalid for some reason.
 		requiredSigs = 1
-		pk, err := chainec.Secp256k1.ParsePubKey(pops[0].data)
+		pk, err := chainec.Secp256k1.ParsePubKey(firstOpcodeData(pkScript))
 		if err == nil {
 			addr, err := cdrutil.NewAddressSecpPubKeyCompressed(pk, chainParams)
 			if err == nil {
@@ -1176,14 +1129,15 @@ func ExtractPkScriptAddrs(version uint16, pkScript []byte,
 		// Skip the pubkey if it's invalid for some reason.
 		requiredSigs = 1
 		suite, _ := ExtractPkScriptAltSigType(pkScript)
+		pubKey := firstOpcodeData(pkScript)
 		var addr cdrutil.Address
 		err := fmt.Errorf("invalid signature suite for alt sig")
 		switch suite {
 		case chainec.ECTypeEdwards:
-			addr, err = cdrutil.NewAddressEdwardsPubKey(pops[0].data,
+			addr, err = cdrutil.NewAddressEdwardsPubKey(pubKey,
 				chainParams)
 		case chainec.ECTypeSecSchnorr:
-			addr, err = cdrutil.NewAddressSecSchnorrPubKey(pops[0].data,
+			addr, err = cdrutil.NewAddressSecSchnorrPubKey(pubKey,
 				chainParams)
 		}
 		if err == nil {
@@ -1239,7 +1193,7 @@ func ExtractPkScriptAddrs(version uint16, pkScript []byte,
 		// Therefore the script hash is the 2nd item on the stack.
 		// Skip the script hash if it's invalid for some reason.
 		requiredSigs = 1
-		addr, err := cdrutil.NewAddressScriptHashFromHash(pops[1].data,
+		addr, err := cdrutil.NewAddressScriptHashFromHash(pkScript[2:22],
 			chainParams)
 		if err == nil {
 			addrs = append(addrs, addr)
@@ -1251,13 +1205,18 @@ func ExtractPkScriptAddrs(version uint16, pkScript []byte,
 		// Therefore the number of required signatures is the 1st item
 		// on the stack and the number of public keys is the 2nd to last
 		// item on the stack.
-		requiredSigs = asSmallInt(pops[0].opcode)
-		numPubKeys := asSmallInt(pops[len(pops)-2].opcode)
+		tokenizer := MakeScriptTokenizer(DefaultScriptVersion, pkScript)
+		tokenizer.Next()
+		requiredSigs = asSmallInt(tokenizer.op)
+		numPubKeys := asSmallInt(&opcodeArray[pkScript[len(pkScript)-2]])
 
 		// Extract the public keys while skipping any that are invalid.
+		// The final two opcodes are the number of public keys and
+		// OP_CHECKMULTISIG, so stop once the first non-pubkey push is
+		// reached.
 		addrs = make([]cdrutil.Address, 0, numPubKeys)
-		for i := 0; i < numPubKeys; i++ {
-			pubkey, err := chainec.Secp256k1.ParsePubKey(pops[i+1].data)
+		for tokenizer.Next() && !isSmallInt(tokenizer.op) {
+			pubkey, err := chainec.Secp256k1.ParsePubKey(tokenizer.Data())
 			if err == nil {
 				addr, err := cdrutil.NewAddressSecpPubKeyCompressed(pubkey,
 					chainParams)
@@ -1279,54 +1238,53 @@ func ExtractPkScriptAddrs(version uint16, pkScript []byte,
 	return scriptClass, addrs, requiredSigs, nil
 }
 
+// firstOpcodeData returns the data associated with the first opcode in the
+// script.
+func firstOpcodeData(script []byte) []byte {
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, script)
+	tokenizer.Next()
+	return tokenizer.Data()
+}
+
 // extractOneBytePush returns the value of a one byte push.
-func extractOneBytePush(po parsedOpcode) int {
-	if !isOneByteMaxDataPush(po) {
+func extractOneBytePush(opcode byte, data []byte) int {
+	if !isOneByteMaxDataPush(opcode) {
 		return -1
 	}
 
-	if po.opcode.value == OP_1 ||
-		po.opcode.value == OP_2 ||
-		po.opcode.value == OP_3 ||
-		po.opcode.value == OP_4 ||
-		po.opcode.value == OP_5 ||
-		po.opcode.value == OP_6 ||
-		po.opcode.value == OP_7 ||
-		po.opcode.value == OP_8 ||
-		po.opcode.value == OP_9 ||
-		po.opcode.value == OP_10 ||
-		po.opcode.value == OP_11 ||
-		po.opcode.value == OP_12 ||
-		po.opcode.value == OP_13 ||
-		po.opcode.value == OP_14 ||
-		po.opcode.value == OP_15 ||
-		po.opcode.value == OP_16 {
-		return int(po.opcode.value - 80)
-	}
-
-	return int(po.data[0])
+	if opcode >= OP_1 && opcode <= OP_16 {
+		return int(opcode - 80)
+	}
+
+	return int(data[0])
 }
 
 // ExtractPkScriptAltSigType returns the signature scheme to use for an
 // alternative check signature script.
 func ExtractPkScriptAltSigType(pkScript []byte) (int, error) {
-	pops, err := parseScript(pkScript)
-	if err != nil {
+	if err := checkScriptParses(pkScript); err != nil {
 		return 0, err
 	}
 
-	isPKA := isPubkeyAlt(pops)
-	isPKHA := isPubkeyHashAlt(pops)
-	if !(isPKA || isPKHA) {
+	// The signature type is the second opcode of an alternative
+	// pay-to-pubkey script and the fifth opcode of an alternative
+	// pay-to-pubkey-hash script.
+	var sigTypeIdx int
+	switch {
+	case isPubkeyAlt(pkScript):
+		sigTypeIdx = 1
+	case isPubkeyHashAlt(pkScript):
+		sigTypeIdx = 4
+	default:
 		return -1, fmt.Errorf("wrong script type")
 	}
 
-	sigTypeLoc := 1
-	if isPKHA {
-		sigTypeLoc = 4
+	tokenizer := MakeScriptTokenizer(DefaultScriptVersion, pkScript)
+	for i := 0; i <= sigTypeIdx; i++ {
+		tokenizer.Next()
 	}
 
-	valInt := extractOneBytePush(pops[sigTypeLoc])
+	valInt := extractOneBytePush(tokenizer.Opcode(), tokenizer.Data())
 	if valInt < 0 {
 		return 0, fmt.Errorf("bad type push")
 	}
@@ -1374,7 +1332,7 @@ func ExtractAtomicSwapDataPushes(version uint16, pkScript []byte) (*AtomicSwapDa
 	}
 	isAtomicSwap := pops[0].opcode.value == OP_IF &&
 		pops[1].opcode.value == OP_SIZE &&
-		canonicalPush(pops[2]) &&
+		isCanonicalPush(pops[2].opcode.value, pops[2].data) &&
 		pops[3].opcode.value == OP_EQUALVERIFY &&
 		pops[4].opcode.value == OP_SHA256 &&
 		pops[5].opcode.value == OP_DATA_32 &&
@@ -1383,7 +1341,7 @@ func ExtractAtomicSwapDataPushes(version uint16, pkScript []byte) (*AtomicSwapDa
 		pops[8].opcode.value == OP_HASH160 &&
 		pops[9].opcode.value == OP_DATA_20 &&
 		pops[10].opcode.value == OP_ELSE &&
-		canonicalPush(pops[11]) &&
+		isCanonicalPush(pops[11].opcode.value, pops[11].data) &&
 		pops[12].opcode.value == OP_CHECKLOCKTIMEVERIFY &&
 		pops[13].opcode.value == OP_DROP &&
 		pops[14].opcode.value == OP_DUP &&
@@ -0,0 +1,140 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"encoding/hex"
+)
+
+// TraceStep houses the state of the script engine immediately after it
+// executed a single opcode.
+//
+// The byte slices in the data and alternate stacks are shared with the engine
+// and must not be modified.
+type TraceStep struct {
+	// ScriptIdx and OpcodeIdx identify the program counter of the executed
+	// opcode.  Script index 0 is the signature script, 1 is the public key
+	// script, and 2 is the redeem script of a pay-to-script-hash pair.
+	ScriptIdx int
+	OpcodeIdx int
+
+	// Opcode is the value of the executed opcode and Disasm is its full
+	// disassembly including any data it pushes.
+	Opcode byte
+	Disasm string
+
+	// DataStack, AltStack and CondStack are the contents of the data stack,
+	// the alternate stack and the conditional execution stack after the
+	// opcode was executed.  The last item is the top of each stack.
+	DataStack [][]byte
+	AltStack  [][]byte
+	CondStack []int
+
+	// Err is the error that resulted from executing the opcode, if any.
+	// Execution stops at the first step with an error.
+	Err error
+}
+
+// OpcodeName returns the human-readable name of the executed opcode.
+func (s *TraceStep) OpcodeName() string {
+	return opcodeArray[s.Opcode].name
+}
+
+// HexStack returns the passed stack with each item encoded as hex.  It is
+// primarily useful for displaying the stacks recorded in a TraceStep.
+func HexStack(stack [][]byte) []string {
+	hexItems := make([]string, len(stack))
+	for i, item := range stack {
+		hexItems[i] = hex.EncodeToString(item)
+	}
+	return hexItems
+}
+
+// copyStack returns a deep copy of the passed stack.
+func copyStack(stack [][]byte) [][]byte {
+	if stack == nil {
+		return nil
+	}
+	stackCopy := make([][]byte, len(stack))
+	for i, item := range stack {
+		stackCopy[i] = append([]byte(nil), item...)
+	}
+	return stackCopy
+}
+
+// Tracer defines the interface for receiving the state of the script engine
+// as it executes each opcode.  A tracer is registered with an engine via
+// SetTracer.
+type Tracer interface {
+	// TraceStep is invoked after each opcode is executed, including an
+	// opcode that fails.  The step is only valid for the duration of the
+	// call, so implementations must copy any fields they retain.
+	TraceStep(step *TraceStep)
+}
+
+// ExecutionTrace is a Tracer which records every step of the execution of a
+// script pair.  The zero value is ready to use.
+type ExecutionTrace struct {
+	Steps []TraceStep
+}
+
+// Ensure ExecutionTrace implements the Tracer interface.
+var _ Tracer = (*ExecutionTrace)(nil)
+
+// TraceStep records a copy of the passed step, including copies of the items in
+// its data and alternate stacks.
+//
+// This is part of the Tracer interface.
+func (t *ExecutionTrace) TraceStep(step *TraceStep) {
+	stepCopy := *step
+	stepCopy.DataStack = copyStack(step.DataStack)
+	stepCopy.AltStack = copyStack(step.AltStack)
+	t.Steps = append(t.Steps, stepCopy)
+}
+
+// FailedStep returns the index of the recorded step which failed to execute
+// or -1 when none of the recorded steps failed.  Note that execution can
+// still fail without a failed step, such as when the final stack does not
+// evaluate to true, in which case the error is returned by Execute.
+func (t *ExecutionTrace) FailedStep() int {
+	for i := range t.Steps {
+		if t.Steps[i].Err != nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetTracer registers a tracer which is invoked after each opcode the engine
+// executes.  Passing nil removes any registered tracer.
+func (vm *Engine) SetTracer(tracer Tracer) {
+	vm.tracer = tracer
+}
+
+// traceStep invokes the registered tracer, if any, with the current state of
+// the engine after executing the passed opcode.
+func (vm *Engine) traceStep(scriptIdx, opcodeIdx int, op *opcode, data []byte,
+	err error) {
+
+	if vm.tracer == nil {
+		return
+	}
+
+	var condStack []int
+	if len(vm.condStack) > 0 {
+		condStack = make([]int, len(vm.condStack))
+		copy(condStack, vm.condStack)
+	}
+	vm.tracer.TraceStep(&TraceStep{
+		ScriptIdx: scriptIdx,
+		OpcodeIdx: opcodeIdx,
+		Opcode:    op.value,
+		Disasm:    disasmOpcode(op, data, false),
+		DataStack: vm.GetStack(),
+		AltStack:  vm.GetAltStack(),
+		CondStack: condStack,
+		Err:       err,
+	})
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/commanderu/cdrd/wire"
+)
+
+// TestExecutionTrace ensures the execution trace records the expected state
+// of the engine after each executed opcode for both successful and failing
+// executions.
+func TestExecutionTrace(t *testing.T) {
+	t.Parallel()
+
+	type expectedStep struct {
+		scriptIdx int
+		opcodeIdx int
+		opcode    byte
+		dstack    [][]byte
+		astack    [][]byte
+		condStack []int
+		err       error
+	}
+
+	tests := []struct {
+		name       string
+		sigScript  string
+		pkScript   string
+		steps      []expectedStep
+		failedStep int
+		execErr    error
+	}{{
+		name:      "success with alt stack and conditional",
+		sigScript: "1 2",
+		pkScript:  "TOALTSTACK IF FROMALTSTACK ENDIF",
+		steps: []expectedStep{
+			{0, 0, OP_1, [][]byte{{1}}, nil, nil, nil},
+			{0, 1, OP_2, [][]byte{{1}, {2}}, nil, nil, nil},
+			{1, 0, OP_TOALTSTACK, [][]byte{{1}}, [][]byte{{2}}, nil, nil},
+			{1, 1, OP_IF, nil, [][]byte{{2}}, []int{OpCondTrue}, nil},
+			{1, 2, OP_FROMALTSTACK, [][]byte{{2}}, nil, []int{OpCondTrue}, nil},
+			{1, 3, OP_ENDIF, [][]byte{{2}}, nil, nil, nil},
+		},
+		failedStep: -1,
+		execErr:    nil,
+	}, {
+		name:      "failing verify",
+		sigScript: "1",
+		pkScript:  "2 EQUALVERIFY 1",
+		steps: []expectedStep{
+			{0, 0, OP_1, [][]byte{{1}}, nil, nil, nil},
+			{1, 0, OP_2, [][]byte{{1}, {2}}, nil, nil, nil},
+			{1, 1, OP_EQUALVERIFY, nil, nil, nil, ErrStackVerifyFailed},
+		},
+		failedStep: 2,
+		execErr:    ErrStackVerifyFailed,
+	}, {
+		name:      "false stack without failed step",
+		sigScript: "0",
+		pkScript:  "NOP",
+		steps: []expectedStep{
+			{0, 0, OP_0, [][]byte{nil}, nil, nil, nil},
+			{1, 0, OP_NOP, [][]byte{nil}, nil, nil, nil},
+		},
+		failedStep: -1,
+		execErr:    ErrStackScriptFailed,
+	}}
+
+	for _, test := range tests {
+		tx := &wire.MsgTx{
+			SerType: wire.TxSerializeFull,
+			Version: 1,
+			TxIn: []*wire.TxIn{{
+				SignatureScript: mustParseShortForm(test.sigScript),
+				Sequence:        wire.MaxTxInSequenceNum,
+			}},
+			TxOut: []*wire.TxOut{{Value: 1}},
+		}
+		pkScript := mustParseShortForm(test.pkScript)
+		vm, err := NewEngine(pkScript, tx, 0, 0, 0, nil)
+		if err != nil {
+			t.Fatalf("%q: failed to create engine: %v", test.name, err)
+		}
+		var trace ExecutionTrace
+		vm.SetTracer(&trace)
+		err = vm.Execute()
+		if err != test.execErr {
+			t.Fatalf("%q: unexpected execute error -- got %v, want %v",
+				test.name, err, test.execErr)
+		}
+
+		if len(trace.Steps) != len(test.steps) {
+			t.Fatalf("%q: unexpected number of steps -- got %d, want %d",
+				test.name, len(trace.Steps), len(test.steps))
+		}
+		for i, want := range test.steps {
+			got := &trace.Steps[i]
+			if got.ScriptIdx != want.scriptIdx ||
+				got.OpcodeIdx != want.opcodeIdx {
+				t.Fatalf("%q: step %d: unexpected pc -- got %d:%d, "+
+					"want %d:%d", test.name, i, got.ScriptIdx,
+					got.OpcodeIdx, want.scriptIdx, want.opcodeIdx)
+			}
+			if got.Opcode != want.opcode {
+				t.Fatalf("%q: step %d: unexpected opcode -- got %v, "+
+					"want %v", test.name, i, got.Opcode, want.opcode)
+			}
+			if !equalStacks(got.DataStack, want.dstack) {
+				t.Fatalf("%q: step %d: unexpected data stack -- got "+
+					"%x, want %x", test.name, i, got.DataStack,
+					want.dstack)
+			}
+			if !equalStacks(got.AltStack, want.astack) {
+				t.Fatalf("%q: step %d: unexpected alt stack -- got "+
+					"%x, want %x", test.name, i, got.AltStack,
+					want.astack)
+			}
+			if !reflect.DeepEqual(got.CondStack, want.condStack) {
+				t.Fatalf("%q: step %d: unexpected cond stack -- got "+
+					"%v, want %v", test.name, i, got.CondStack,
+					want.condStack)
+			}
+			if got.Err != want.err {
+				t.Fatalf("%q: step %d: unexpected error -- got %v, "+
+					"want %v", test.name, i, got.Err, want.err)
+			}
+		}
+		if failedStep := trace.FailedStep(); failedStep != test.failedStep {
+			t.Fatalf("%q: unexpected failed step -- got %d, want %d",
+				test.name, failedStep, test.failedStep)
+		}
+	}
+}
+
+// TestExecutionTraceCopiesStacks ensures the execution trace records copies of
+// the stack items so later changes to the items in the engine do not modify the
+// recorded steps.
+func TestExecutionTraceCopiesStacks(t *testing.T) {
+	t.Parallel()
+
+	dstack := [][]byte{{0x01, 0x02}}
+	astack := [][]byte{{0x03}}
+	var trace ExecutionTrace
+	trace.TraceStep(&TraceStep{DataStack: dstack, AltStack: astack})
+	dstack[0][0] = 0xff
+	astack[0][0] = 0xff
+	if !equalStacks(trace.Steps[0].DataStack, [][]byte{{0x01, 0x02}}) {
+		t.Fatalf("recorded data stack was modified: %x",
+			trace.Steps[0].DataStack)
+	}
+	if !equalStacks(trace.Steps[0].AltStack, [][]byte{{0x03}}) {
+		t.Fatalf("recorded alt stack was modified: %x",
+			trace.Steps[0].AltStack)
+	}
+}
+
+// equalStacks returns whether the passed stacks have the same items while
+// treating nil and empty items and stacks as equal.
+func equalStacks(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -52,9 +52,16 @@ const (
 	CmdGetCFilter     = "getcfilter"
 	CmdGetCFHeaders   = "getcfheaders"
 	CmdGetCFTypes     = "getcftypes"
+	CmdGetCFilters    = "getcfilters"
+	CmdGetCFCheckpt   = "getcfcheckpt"
 	CmdCFilter        = "cfilter"
 	CmdCFHeaders      = "cfheaders"
 	CmdCFTypes        = "cftypes"
+	CmdCFCheckpt      = "cfcheckpt"
+	CmdFilterAdd      = "filteradd"
+	CmdFilterClear    = "filterclear"
+	CmdFilterLoad     = "filterload"
+	CmdMerkleBlock    = "merkleblock"
 )
 
 // Message is an interface that describes a commanderu message.  A type that
@@ -151,6 +158,27 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdCFTypes:
 		msg = &MsgCFTypes{}
 
+	case CmdGetCFilters:
+		msg = &MsgGetCFilters{}
+
+	case CmdGetCFCheckpt:
+		msg = &MsgGetCFCheckpt{}
+
+	case CmdCFCheckpt:
+		msg = &MsgCFCheckpt{}
+
+	case CmdFilterAdd:
+		msg = &MsgFilterAdd{}
+
+	case CmdFilterClear:
+		msg = &MsgFilterClear{}
+
+	case CmdFilterLoad:
+		msg = &MsgFilterLoad{}
+
+	case CmdMerkleBlock:
+		msg = &MsgMerkleBlock{}
+
 	default:
 		return nil, fmt.Errorf("unhandled command [%s]", command)
 	}
@@ -76,6 +76,18 @@ func TestMessage(t *testing.T) {
 		[]byte("payload"))
 	msgCFHeaders := NewMsgCFHeaders()
 	msgCFTypes := NewMsgCFTypes([]FilterType{GCSFilterExtended})
+	msgGetCFilters := NewMsgGetCFilters(GCSFilterRegular, 0,
+		&chainhash.Hash{})
+	msgGetCFCheckpt := NewMsgGetCFCheckpt(GCSFilterRegular,
+		&chainhash.Hash{})
+	msgCFCheckpt := NewMsgCFCheckpt(GCSFilterRegular, &chainhash.Hash{}, 0)
+	msgFilterAdd := NewMsgFilterAdd([]byte{0x01})
+	msgFilterClear := NewMsgFilterClear()
+	msgFilterLoad := NewMsgFilterLoad([]byte{0x01}, 10, 0, BloomUpdateNone)
+	bh := NewBlockHeader(1, &chainhash.Hash{}, &chainhash.Hash{},
+		&chainhash.Hash{}, 0, [6]byte{}, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		[32]byte{}, 0)
+	msgMerkleBlock := NewMsgMerkleBlock(bh)
 	msgReject := NewMsgReject("block", RejectDuplicate, "duplicate block")
 
 	tests := []struct {
@@ -100,6 +112,10 @@ func TestMessage(t *testing.T) {
 		{msgGetHeaders, msgGetHeaders, pver, MainNet, 61},     // [12]
 		{msgHeaders, msgHeaders, pver, MainNet, 25},           // [13]
 		{msgMemPool, msgMemPool, pver, MainNet, 24},           // [15]
+		{msgFilterAdd, msgFilterAdd, pver, MainNet, 26},       // [16]
+		{msgFilterClear, msgFilterClear, pver, MainNet, 24},   // [17]
+		{msgFilterLoad, msgFilterLoad, pver, MainNet, 35},     // [18]
+		{msgMerkleBlock, msgMerkleBlock, pver, MainNet, 216},  // [19]
 		{msgReject, msgReject, pver, MainNet, 79},             // [20]
 		{msgGetCFilter, msgGetCFilter, pver, MainNet, 57},     // [21]
 		{msgGetCFHeaders, msgGetCFHeaders, pver, MainNet, 58}, // [22]
@@ -107,6 +123,9 @@ func TestMessage(t *testing.T) {
 		{msgCFilter, msgCFilter, pver, MainNet, 65},           // [24]
 		{msgCFHeaders, msgCFHeaders, pver, MainNet, 58},       // [25]
 		{msgCFTypes, msgCFTypes, pver, MainNet, 26},           // [26]
+		{msgGetCFilters, msgGetCFilters, pver, MainNet, 61},   // [27]
+		{msgGetCFCheckpt, msgGetCFCheckpt, pver, MainNet, 57}, // [28]
+		{msgCFCheckpt, msgCFCheckpt, pver, MainNet, 58},       // [29]
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -0,0 +1,160 @@
+// Copyright (c) 2018 The btcsuite developers
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+)
+
+const (
+	// CFCheckptInterval is the gap (in number of blocks) between each
+	// committed filter header checkpoint.
+	CFCheckptInterval = 1000
+
+	// maxCFCheckptsPerMsg is the maximum number of committed filter header
+	// checkpoints that can possibly fit in a single cfcheckpt message.
+	maxCFCheckptsPerMsg = (MaxMessagePayload - chainhash.HashSize - 1 -
+		MaxVarIntPayload) / chainhash.HashSize
+)
+
+// MsgCFCheckpt implements the Message interface and represents a cfcheckpt
+// message.  It is used to deliver committed filter header checkpoints in
+// response to a getcfcheckpt message (MsgGetCFCheckpt).  The filter headers
+// are those of the blocks at every CFCheckptInterval heights, in ascending
+// order, up to the block identified by the stop hash.
+type MsgCFCheckpt struct {
+	FilterType    FilterType
+	StopHash      chainhash.Hash
+	FilterHeaders []*chainhash.Hash
+}
+
+// AddCFHeader adds a new committed filter header to the message.
+func (msg *MsgCFCheckpt) AddCFHeader(header *chainhash.Hash) error {
+	if len(msg.FilterHeaders)+1 > maxCFCheckptsPerMsg {
+		str := fmt.Sprintf("too many filter headers in message [max %v]",
+			maxCFCheckptsPerMsg)
+		return messageError("MsgCFCheckpt.AddCFHeader", str)
+	}
+
+	msg.FilterHeaders = append(msg.FilterHeaders, header)
+	return nil
+}
+
+// BtcDecode decodes r using the wire protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCFCheckpt) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < BatchedCFiltersVersion {
+		str := fmt.Sprintf("cfcheckpt message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCFCheckpt.BtcDecode", str)
+	}
+
+	err := readElement(r, (*uint8)(&msg.FilterType))
+	if err != nil {
+		return err
+	}
+	err = readElement(r, &msg.StopHash)
+	if err != nil {
+		return err
+	}
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+
+	// Refuse to decode an insane number of filter headers.
+	if count > maxCFCheckptsPerMsg {
+		str := fmt.Sprintf("too many filter headers for message "+
+			"[count %v, max %v]", count, maxCFCheckptsPerMsg)
+		return messageError("MsgCFCheckpt.BtcDecode", str)
+	}
+
+	// Create a contiguous slice of hashes to deserialize into in order to
+	// reduce the number of allocations.
+	headers := make([]chainhash.Hash, count)
+	msg.FilterHeaders = make([]*chainhash.Hash, 0, count)
+	for i := uint64(0); i < count; i++ {
+		header := &headers[i]
+		err := readElement(r, header)
+		if err != nil {
+			return err
+		}
+		msg.FilterHeaders = append(msg.FilterHeaders, header)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the wire protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCFCheckpt) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < BatchedCFiltersVersion {
+		str := fmt.Sprintf("cfcheckpt message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCFCheckpt.BtcEncode", str)
+	}
+
+	err := binarySerializer.PutUint8(w, uint8(msg.FilterType))
+	if err != nil {
+		return err
+	}
+	err = writeElement(w, &msg.StopHash)
+	if err != nil {
+		return err
+	}
+
+	count := len(msg.FilterHeaders)
+	if count > maxCFCheckptsPerMsg {
+		str := fmt.Sprintf("too many filter headers for message "+
+			"[count %v, max %v]", count, maxCFCheckptsPerMsg)
+		return messageError("MsgCFCheckpt.BtcEncode", str)
+	}
+
+	err = WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for _, header := range msg.FilterHeaders {
+		err := writeElement(w, header)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCFCheckpt) Command() string {
+	return CmdCFCheckpt
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCFCheckpt) MaxPayloadLength(pver uint32) uint32 {
+	// Message size depends on the blockchain height, so return the general
+	// limit for all messages.
+	return MaxMessagePayload
+}
+
+// NewMsgCFCheckpt returns a new cfcheckpt message that conforms to the Message
+// interface using the passed parameters and defaults for the remaining fields.
+// The size hint is used to preallocate space for the expected number of filter
+// headers.
+func NewMsgCFCheckpt(filterType FilterType, stopHash *chainhash.Hash,
+	headersCount int) *MsgCFCheckpt {
+	return &MsgCFCheckpt{
+		FilterType:    filterType,
+		StopHash:      *stopHash,
+		FilterHeaders: make([]*chainhash.Hash, 0, headersCount),
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestCFCheckptWire tests the MsgCFCheckpt wire encode and decode for various
+// numbers of filter headers and protocol versions.
+func TestCFCheckptWire(t *testing.T) {
+	stopHash := chainhash.Hash{0x01}
+	header1 := chainhash.Hash{0x02}
+	header2 := chainhash.Hash{0x03}
+
+	noHeaders := NewMsgCFCheckpt(GCSFilterExtended, &stopHash, 0)
+	noHeadersEncoded := make([]byte, 0, 34)
+	noHeadersEncoded = append(noHeadersEncoded, 0x01) // Filter type
+	noHeadersEncoded = append(noHeadersEncoded, stopHash[:]...)
+	noHeadersEncoded = append(noHeadersEncoded, 0x00) // Varint for count
+
+	twoHeaders := NewMsgCFCheckpt(GCSFilterRegular, &stopHash, 2)
+	twoHeaders.AddCFHeader(&header1)
+	twoHeaders.AddCFHeader(&header2)
+	twoHeadersEncoded := make([]byte, 0, 98)
+	twoHeadersEncoded = append(twoHeadersEncoded, 0x00) // Filter type
+	twoHeadersEncoded = append(twoHeadersEncoded, stopHash[:]...)
+	twoHeadersEncoded = append(twoHeadersEncoded, 0x02) // Varint for count
+	twoHeadersEncoded = append(twoHeadersEncoded, header1[:]...)
+	twoHeadersEncoded = append(twoHeadersEncoded, header2[:]...)
+
+	tests := []struct {
+		in   *MsgCFCheckpt // Message to encode
+		out  *MsgCFCheckpt // Expected decoded message
+		buf  []byte        // Wire encoding
+		pver uint32        // Protocol version for wire encoding
+	}{
+		// Latest protocol version with no filter headers.
+		{noHeaders, noHeaders, noHeadersEncoded, ProtocolVersion},
+
+		// Latest protocol version with multiple filter headers.
+		{twoHeaders, twoHeaders, twoHeadersEncoded, ProtocolVersion},
+
+		// Protocol version BatchedCFiltersVersion with multiple filter
+		// headers.
+		{twoHeaders, twoHeaders, twoHeadersEncoded, BatchedCFiltersVersion},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		// Encode the message to wire format.
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, test.pver)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %s want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		// Decode the message from wire format.
+		var msg MsgCFCheckpt
+		rbuf := bytes.NewReader(test.buf)
+		err = msg.BtcDecode(rbuf, test.pver)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(&msg, test.out) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(&msg), spew.Sdump(test.out))
+			continue
+		}
+	}
+}
+
+// TestCFCheckptWireErrors performs negative tests against wire encode and
+// decode of MsgCFCheckpt to confirm error paths work correctly.
+func TestCFCheckptWireErrors(t *testing.T) {
+	stopHash := chainhash.Hash{0x01}
+	msg := NewMsgCFCheckpt(GCSFilterRegular, &stopHash, 0)
+
+	// Ensure the message is rejected prior to the batched committed filter
+	// protocol version.
+	pver := BatchedCFiltersVersion - 1
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, pver)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode: wrong error for protocol version %d - "+
+			"got %v, want *MessageError", pver, err)
+	}
+	err = msg.BtcDecode(bytes.NewReader(make([]byte, 34)), pver)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode: wrong error for protocol version %d - "+
+			"got %v, want *MessageError", pver, err)
+	}
+
+	// Ensure a count that exceeds the maximum number of filter headers is
+	// rejected.
+	encoded := make([]byte, 0, 42)
+	encoded = append(encoded, 0x00)
+	encoded = append(encoded, stopHash[:]...)
+	encoded = append(encoded, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0x7f)
+	var readMsg MsgCFCheckpt
+	err = readMsg.BtcDecode(bytes.NewReader(encoded), ProtocolVersion)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode: wrong error for oversized count - got %v, "+
+			"want *MessageError", err)
+	}
+
+	// Ensure the getcfilters and getcfcheckpt requests are also rejected
+	// prior to the batched committed filter protocol version.
+	getFilters := NewMsgGetCFilters(GCSFilterRegular, 0, &stopHash)
+	err = getFilters.BtcEncode(&buf, pver)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("MsgGetCFilters.BtcEncode: wrong error for protocol "+
+			"version %d - got %v, want *MessageError", pver, err)
+	}
+	getCheckpt := NewMsgGetCFCheckpt(GCSFilterRegular, &stopHash)
+	err = getCheckpt.BtcEncode(&buf, pver)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("MsgGetCFCheckpt.BtcEncode: wrong error for protocol "+
+			"version %d - got %v, want *MessageError", pver, err)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestFilterLoadWire tests the MsgFilterLoad wire encode and decode.
+func TestFilterLoadWire(t *testing.T) {
+	pver := ProtocolVersion
+
+	msg := NewMsgFilterLoad([]byte{0x01, 0x02}, 10, 0x01020304,
+		BloomUpdateP2PubkeyOnly)
+	msgBytes := []byte{
+		0x02, 0x01, 0x02, // Filter
+		0x0a, 0x00, 0x00, 0x00, // HashFuncs
+		0x04, 0x03, 0x02, 0x01, // Tweak
+		0x02, // Flags
+	}
+
+	// Ensure the command is expected value.
+	if cmd := msg.Command(); cmd != "filterload" {
+		t.Errorf("NewMsgFilterLoad: wrong command - got %v want %v",
+			cmd, "filterload")
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	wantPayload := uint32(36012)
+	if maxPayload := msg.MaxPayloadLength(pver); maxPayload != wantPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length - got %v, "+
+			"want %v", maxPayload, wantPayload)
+	}
+
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("BtcEncode: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), msgBytes) {
+		t.Fatalf("BtcEncode: got %s want %s", spew.Sdump(buf.Bytes()),
+			spew.Sdump(msgBytes))
+	}
+
+	var readMsg MsgFilterLoad
+	if err := readMsg.BtcDecode(bytes.NewReader(msgBytes), pver); err != nil {
+		t.Fatalf("BtcDecode: %v", err)
+	}
+	if !reflect.DeepEqual(&readMsg, msg) {
+		t.Fatalf("BtcDecode: got %s want %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+
+	// Ensure the message is rejected before the bloom protocol version.
+	if err := msg.BtcEncode(&buf, NodeBloomVersion-1); err == nil {
+		t.Error("BtcEncode: did not receive error for old protocol " +
+			"version")
+	}
+}
+
+// TestFilterLoadWireErrors performs negative tests against wire encode and
+// decode of MsgFilterLoad to confirm error paths work correctly.
+func TestFilterLoadWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Filter too large.
+	tooLarge := NewMsgFilterLoad(make([]byte, MaxFilterLoadFilterSize+1),
+		10, 0, BloomUpdateNone)
+	var buf bytes.Buffer
+	if err := tooLarge.BtcEncode(&buf, pver); err == nil {
+		t.Error("BtcEncode: did not receive error for oversized filter")
+	}
+
+	// Too many hash functions.
+	tooMany := NewMsgFilterLoad([]byte{0x01}, MaxFilterLoadHashFuncs+1, 0,
+		BloomUpdateNone)
+	if err := tooMany.BtcEncode(&buf, pver); err == nil {
+		t.Error("BtcEncode: did not receive error for too many hash " +
+			"functions")
+	}
+	encoded := []byte{0x01, 0x01, 0x33, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00}
+	var readMsg MsgFilterLoad
+	err := readMsg.BtcDecode(bytes.NewReader(encoded), pver)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode: did not receive expected error for too "+
+			"many hash functions - got %v", err)
+	}
+
+	// Truncated encoding.
+	err = readMsg.BtcDecode(bytes.NewReader(encoded[:4]), pver)
+	if err == nil {
+		t.Error("BtcDecode: did not receive error for truncated message")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2018 The btcsuite developers
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+)
+
+// MsgGetCFCheckpt implements the Message interface and represents a
+// getcfcheckpt message.  It is used to request the committed filter headers at
+// every CFCheckptInterval blocks in the main chain up to and including the
+// block identified by the stop hash.  See MsgCFCheckpt for details on the
+// response.
+type MsgGetCFCheckpt struct {
+	FilterType FilterType
+	StopHash   chainhash.Hash
+}
+
+// BtcDecode decodes r using the wire protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFCheckpt) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < BatchedCFiltersVersion {
+		str := fmt.Sprintf("getcfcheckpt message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFCheckpt.BtcDecode", str)
+	}
+
+	err := readElement(r, (*uint8)(&msg.FilterType))
+	if err != nil {
+		return err
+	}
+	return readElement(r, &msg.StopHash)
+}
+
+// BtcEncode encodes the receiver to w using the wire protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFCheckpt) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < BatchedCFiltersVersion {
+		str := fmt.Sprintf("getcfcheckpt message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFCheckpt.BtcEncode", str)
+	}
+
+	err := binarySerializer.PutUint8(w, uint8(msg.FilterType))
+	if err != nil {
+		return err
+	}
+	return writeElement(w, &msg.StopHash)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetCFCheckpt) Command() string {
+	return CmdGetCFCheckpt
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetCFCheckpt) MaxPayloadLength(pver uint32) uint32 {
+	// Filter type + block hash.
+	return 1 + chainhash.HashSize
+}
+
+// NewMsgGetCFCheckpt returns a new getcfcheckpt message that conforms to the
+// Message interface using the passed parameters and defaults for the remaining
+// fields.
+func NewMsgGetCFCheckpt(filterType FilterType, stopHash *chainhash.Hash) *MsgGetCFCheckpt {
+	return &MsgGetCFCheckpt{
+		FilterType: filterType,
+		StopHash:   *stopHash,
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2017 The btcsuite developers
+// Copyright (c) 2017 The Lightning Network Developers
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+)
+
+// MaxGetCFiltersReqRange is the maximum number of filters that may be
+// requested in a getcfilters message.
+const MaxGetCFiltersReqRange = 1000
+
+// MsgGetCFilters implements the Message interface and represents a getcfilters
+// message.  It is used to request committed filters for a sequence of blocks
+// in the main chain starting at the provided height and ending with the block
+// identified by the stop hash.  Each requested filter is delivered in its own
+// cfilter message (MsgCFilter), in ascending height order.  No more than
+// MaxGetCFiltersReqRange filters may be requested at once.
+type MsgGetCFilters struct {
+	FilterType  FilterType
+	StartHeight uint32
+	StopHash    chainhash.Hash
+}
+
+// BtcDecode decodes r using the wire protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFilters) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < BatchedCFiltersVersion {
+		str := fmt.Sprintf("getcfilters message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFilters.BtcDecode", str)
+	}
+
+	err := readElement(r, (*uint8)(&msg.FilterType))
+	if err != nil {
+		return err
+	}
+	err = readElement(r, &msg.StartHeight)
+	if err != nil {
+		return err
+	}
+	return readElement(r, &msg.StopHash)
+}
+
+// BtcEncode encodes the receiver to w using the wire protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFilters) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < BatchedCFiltersVersion {
+		str := fmt.Sprintf("getcfilters message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFilters.BtcEncode", str)
+	}
+
+	err := binarySerializer.PutUint8(w, uint8(msg.FilterType))
+	if err != nil {
+		return err
+	}
+	err = binarySerializer.PutUint32(w, littleEndian, msg.StartHeight)
+	if err != nil {
+		return err
+	}
+	return writeElement(w, &msg.StopHash)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetCFilters) Command() string {
+	return CmdGetCFilters
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetCFilters) MaxPayloadLength(pver uint32) uint32 {
+	// Filter type + uint32 + block hash.
+	return 1 + 4 + chainhash.HashSize
+}
+
+// NewMsgGetCFilters returns a new getcfilters message that conforms to the
+// Message interface using the passed parameters and defaults for the remaining
+// fields.
+func NewMsgGetCFilters(filterType FilterType, startHeight uint32,
+	stopHash *chainhash.Hash) *MsgGetCFilters {
+	return &MsgGetCFilters{
+		FilterType:  filterType,
+		StartHeight: startHeight,
+		StopHash:    *stopHash,
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+)
+
+// maxFlagsPerMerkleBlock is the maximum number of flag bytes that could
+// possibly fit into a merkle block for a single transaction tree.  Since each
+// transaction is represented by a single bit, this is the max number of
+// transactions per tree divided by 8 bits per byte.  Then an extra one to
+// cover partials.
+func maxFlagsPerMerkleBlock(pver uint32) uint64 {
+	return MaxTxPerTxTree(pver)/8 + 1
+}
+
+// MsgMerkleBlock implements the Message interface and represents a merkleblock
+// message.  It is used to deliver a filtered block in response to a getdata
+// message (MsgGetData) requesting InvTypeFilteredBlock and houses a partial
+// merkle tree for each of the regular and stake transaction trees of the block.
+//
+// This message was not added until protocol version NodeBloomVersion.
+type MsgMerkleBlock struct {
+	Header        BlockHeader
+	Transactions  uint32
+	Hashes        []*chainhash.Hash
+	Flags         []byte
+	STransactions uint32
+	SHashes       []*chainhash.Hash
+	SFlags        []byte
+}
+
+// AddTxHash adds a new transaction hash to the regular tree of the message.
+func (msg *MsgMerkleBlock) AddTxHash(hash *chainhash.Hash) error {
+	if uint64(len(msg.Hashes)+1) > MaxTxPerTxTree(ProtocolVersion) {
+		str := fmt.Sprintf("too many tx hashes for message [max %v]",
+			MaxTxPerTxTree(ProtocolVersion))
+		return messageError("MsgMerkleBlock.AddTxHash", str)
+	}
+
+	msg.Hashes = append(msg.Hashes, hash)
+	return nil
+}
+
+// AddSTxHash adds a new transaction hash to the stake tree of the message.
+func (msg *MsgMerkleBlock) AddSTxHash(hash *chainhash.Hash) error {
+	if uint64(len(msg.SHashes)+1) > MaxTxPerTxTree(ProtocolVersion) {
+		str := fmt.Sprintf("too many stake tx hashes for message "+
+			"[max %v]", MaxTxPerTxTree(ProtocolVersion))
+		return messageError("MsgMerkleBlock.AddSTxHash", str)
+	}
+
+	msg.SHashes = append(msg.SHashes, hash)
+	return nil
+}
+
+// readMerkleHashes reads a count prefixed list of hashes from r.
+func readMerkleHashes(r io.Reader, pver uint32) ([]*chainhash.Hash, error) {
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+	if count > MaxTxPerTxTree(pver) {
+		str := fmt.Sprintf("too many transaction hashes for message "+
+			"[count %v, max %v]", count, MaxTxPerTxTree(pver))
+		return nil, messageError("MsgMerkleBlock.BtcDecode", str)
+	}
+
+	// Create a contiguous slice of hashes to deserialize into in order to
+	// reduce the number of allocations.
+	hashes := make([]chainhash.Hash, count)
+	result := make([]*chainhash.Hash, 0, count)
+	for i := uint64(0); i < count; i++ {
+		hash := &hashes[i]
+		err := readElement(r, hash)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, hash)
+	}
+	return result, nil
+}
+
+// writeMerkleHashes writes a count prefixed list of hashes to w.
+func writeMerkleHashes(w io.Writer, pver uint32, hashes []*chainhash.Hash) error {
+	err := WriteVarInt(w, pver, uint64(len(hashes)))
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		err = writeElement(w, hash)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BtcDecode decodes r using the protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgMerkleBlock) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < NodeBloomVersion {
+		str := fmt.Sprintf("merkleblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgMerkleBlock.BtcDecode", str)
+	}
+
+	err := readBlockHeader(r, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+
+	err = readElement(r, &msg.Transactions)
+	if err != nil {
+		return err
+	}
+	msg.Hashes, err = readMerkleHashes(r, pver)
+	if err != nil {
+		return err
+	}
+	msg.Flags, err = ReadVarBytes(r, pver,
+		uint32(maxFlagsPerMerkleBlock(pver)), "merkle block flags size")
+	if err != nil {
+		return err
+	}
+
+	err = readElement(r, &msg.STransactions)
+	if err != nil {
+		return err
+	}
+	msg.SHashes, err = readMerkleHashes(r, pver)
+	if err != nil {
+		return err
+	}
+	msg.SFlags, err = ReadVarBytes(r, pver,
+		uint32(maxFlagsPerMerkleBlock(pver)),
+		"merkle block stake flags size")
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgMerkleBlock) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < NodeBloomVersion {
+		str := fmt.Sprintf("merkleblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgMerkleBlock.BtcEncode", str)
+	}
+
+	// Limit the number of hashes and flag bytes for each tree.
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	numHashes := len(msg.Hashes)
+	if uint64(numHashes) > maxTxPerTree {
+		str := fmt.Sprintf("too many transaction hashes for message "+
+			"[count %v, max %v]", numHashes, maxTxPerTree)
+		return messageError("MsgMerkleBlock.BtcEncode", str)
+	}
+	numSHashes := len(msg.SHashes)
+	if uint64(numSHashes) > maxTxPerTree {
+		str := fmt.Sprintf("too many stake transaction hashes for "+
+			"message [count %v, max %v]", numSHashes, maxTxPerTree)
+		return messageError("MsgMerkleBlock.BtcEncode", str)
+	}
+	maxFlags := maxFlagsPerMerkleBlock(pver)
+	numFlagBytes := len(msg.Flags)
+	if uint64(numFlagBytes) > maxFlags {
+		str := fmt.Sprintf("too many flag bytes for message [count %v, "+
+			"max %v]", numFlagBytes, maxFlags)
+		return messageError("MsgMerkleBlock.BtcEncode", str)
+	}
+	numSFlagBytes := len(msg.SFlags)
+	if uint64(numSFlagBytes) > maxFlags {
+		str := fmt.Sprintf("too many stake flag bytes for message "+
+			"[count %v, max %v]", numSFlagBytes, maxFlags)
+		return messageError("MsgMerkleBlock.BtcEncode", str)
+	}
+
+	err := writeBlockHeader(w, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+
+	err = writeElement(w, msg.Transactions)
+	if err != nil {
+		return err
+	}
+	err = writeMerkleHashes(w, pver, msg.Hashes)
+	if err != nil {
+		return err
+	}
+	err = WriteVarBytes(w, pver, msg.Flags)
+	if err != nil {
+		return err
+	}
+
+	err = writeElement(w, msg.STransactions)
+	if err != nil {
+		return err
+	}
+	err = writeMerkleHashes(w, pver, msg.SHashes)
+	if err != nil {
+		return err
+	}
+	return WriteVarBytes(w, pver, msg.SFlags)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgMerkleBlock) Command() string {
+	return CmdMerkleBlock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgMerkleBlock) MaxPayloadLength(pver uint32) uint32 {
+	// A merkle block can't be larger than the block it represents.
+	if pver <= 3 {
+		return MaxBlockPayloadV3
+	}
+	return MaxBlockPayload
+}
+
+// NewMsgMerkleBlock returns a new merkleblock message that conforms to the
+// Message interface.  See MsgMerkleBlock for details.
+func NewMsgMerkleBlock(bh *BlockHeader) *MsgMerkleBlock {
+	return &MsgMerkleBlock{
+		Header:        *bh,
+		Transactions:  0,
+		Hashes:        make([]*chainhash.Hash, 0),
+		Flags:         make([]byte, 0),
+		STransactions: 0,
+		SHashes:       make([]*chainhash.Hash, 0),
+		SFlags:        make([]byte, 0),
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2014-2016 The btcsuite developers
+// Copyright (c) 2018 The commanderu developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/commanderu/cdrd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestMerkleBlockWire tests the MsgMerkleBlock wire encode and decode round
+// trip along with the limits imposed on hashes and flags.
+func TestMerkleBlockWire(t *testing.T) {
+	pver := ProtocolVersion
+
+	bh := &testBlock.Header
+	msg := NewMsgMerkleBlock(bh)
+
+	// Ensure the command is expected value.
+	if cmd := msg.Command(); cmd != "merkleblock" {
+		t.Errorf("NewMsgMerkleBlock: wrong command - got %v want %v",
+			cmd, "merkleblock")
+	}
+
+	// Ensure max payload is expected value for latest protocol version.
+	if maxPayload := msg.MaxPayloadLength(pver); maxPayload != MaxBlockPayload {
+		t.Errorf("MaxPayloadLength: wrong max payload length - got %v, "+
+			"want %v", maxPayload, MaxBlockPayload)
+	}
+
+	msg.Transactions = 3
+	msg.STransactions = 1
+	for i := byte(0); i < 3; i++ {
+		if err := msg.AddTxHash(&chainhash.Hash{i}); err != nil {
+			t.Fatalf("AddTxHash: %v", err)
+		}
+	}
+	if err := msg.AddSTxHash(&chainhash.Hash{0xff}); err != nil {
+		t.Fatalf("AddSTxHash: %v", err)
+	}
+	msg.Flags = []byte{0x1d}
+	msg.SFlags = []byte{0x01}
+
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("BtcEncode: %v", err)
+	}
+	wantLen := MaxBlockHeaderPayload + 4 + 1 + 3*chainhash.HashSize + 2 +
+		4 + 1 + chainhash.HashSize + 2
+	if buf.Len() != wantLen {
+		t.Fatalf("BtcEncode: got %d bytes, want %d", buf.Len(), wantLen)
+	}
+
+	var readMsg MsgMerkleBlock
+	if err := readMsg.BtcDecode(&buf, pver); err != nil {
+		t.Fatalf("BtcDecode: %v", err)
+	}
+	if !reflect.DeepEqual(&readMsg, msg) {
+		t.Fatalf("BtcDecode: got %s want %s", spew.Sdump(&readMsg),
+			spew.Sdump(msg))
+	}
+
+	// Ensure too many flag bytes are rejected.
+	msg.SFlags = make([]byte, maxFlagsPerMerkleBlock(pver)+1)
+	if err := msg.BtcEncode(&buf, pver); err == nil {
+		t.Error("BtcEncode: did not receive error for too many flag " +
+			"bytes")
+	}
+
+	// Ensure the message is rejected before the bloom protocol version.
+	if err := msg.BtcDecode(&buf, NodeBloomVersion-1); err == nil {
+		t.Error("BtcDecode: did not receive error for old protocol " +
+			"version")
+	}
+}
@@ -17,7 +17,7 @@ const (
 	InitialProcotolVersion uint32 = 1
 
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 6
+	ProtocolVersion uint32 = 7
 
 	// NodeBloomVersion is the protocol version which added the SFNodeBloom
 	// service flag (unused).
@@ -39,6 +39,10 @@ const (
 	// flag and the cfheaders, cfilter, cftypes, getcfheaders, getcfilter and
 	// getcftypes messages.
 	NodeCFVersion uint32 = 6
+
+	// BatchedCFiltersVersion is the protocol version which adds the
+	// getcfilters, getcfcheckpt and cfcheckpt messages.
+	BatchedCFiltersVersion uint32 = 7
 )
 
 // ServiceFlag identifies services supported by a commanderu peer.